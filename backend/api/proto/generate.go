@@ -0,0 +1,6 @@
+// Package proto holds the protobuf definitions of the internal gRPC APIs
+// between services. Regenerate the Go code with `go generate ./api/proto`
+// (needs protoc, protoc-gen-go and protoc-gen-go-grpc on PATH)
+package proto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative engine/v1/engine.proto notification/v1/notification.proto
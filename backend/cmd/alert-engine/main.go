@@ -10,11 +10,21 @@ import (
 	"syscall"
 	"time"
 
+	enginev1 "github.com/weqory/backend/api/proto/engine/v1"
 	"github.com/weqory/backend/internal/alert"
 	"github.com/weqory/backend/internal/binance"
 	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/coingecko"
+	"github.com/weqory/backend/internal/gas"
+	"github.com/weqory/backend/internal/kafkasink"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/internal/rpc"
+	"github.com/weqory/backend/internal/scheduler"
+	"github.com/weqory/backend/internal/status"
+	"github.com/weqory/backend/internal/whale"
 	"github.com/weqory/backend/pkg/config"
 	"github.com/weqory/backend/pkg/database"
+	"github.com/weqory/backend/pkg/eventbus"
 	"github.com/weqory/backend/pkg/logger"
 	"github.com/weqory/backend/pkg/redis"
 )
@@ -66,34 +76,157 @@ func main() {
 	defer redisClient.Close()
 	log.Info("connected to Redis")
 
+	// Connect to the event bus
+	bus, err := eventbus.New(eventbus.Config{
+		Transport:    cfg.EventBus.Transport,
+		NATSURL:      cfg.EventBus.NATSURL,
+		StreamMaxLen: cfg.EventBus.StreamMaxLen,
+		Consumer:     cfg.EventBus.Consumer,
+	}, redisClient)
+	if err != nil {
+		log.Error("failed to connect to event bus", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer bus.Close()
+	log.Info("connected to event bus", slog.String("transport", cfg.EventBus.Transport))
+
 	// Initialize components
 	binanceClient := binance.NewClient(log.Logger)
+	if cfg.Binance.WSURL != "" {
+		binanceClient.SetBaseURL(cfg.Binance.WSURL)
+		log.Warn("using non-default Binance endpoint", slog.String("url", cfg.Binance.WSURL))
+	}
 	priceCache := cache.NewPriceCache(redisClient, log.Logger)
-	publisher := alert.NewPublisher(redisClient, log.Logger)
-	pricePublisher := alert.NewPricePublisher(redisClient, log.Logger)
+	if migrated, err := priceCache.MigrateHistoryLists(ctx); err != nil {
+		log.Error("failed to migrate price history", slog.String("error", err.Error()))
+	} else if migrated > 0 {
+		log.Info("migrated price history to sorted sets", slog.Int("symbols", migrated))
+	}
+	publisher := alert.NewPublisher(redisClient, bus, log.Logger)
+	pricePublisher := alert.NewPricePublisher(bus, log.Logger)
 
 	// Initialize alert engine
 	engine := alert.NewEngine(pool, binanceClient, priceCache, pricePublisher, log.Logger)
-	engine.SetTriggerHandler(publisher.CreateTriggerHandler())
+
+	// Alerts firing on the same tick are published in paced chunks
+	burstPacer := alert.NewBurstPacer(publisher.Publish, bus, log.Logger)
+	burstPacer.SetPace(cfg.AlertEngine.BurstChunkSize, cfg.AlertEngine.BurstInterval)
+	engine.SetTriggerHandler(burstPacer.Enqueue)
+	go burstPacer.Run(ctx)
+
+	anomalyFilter := alert.NewAnomalyFilter(
+		cfg.AlertEngine.AnomalyMaxJumpPct,
+		cfg.AlertEngine.AnomalyConfirmWindow,
+		log.Logger,
+	)
+	engine.SetAnomalyFilter(anomalyFilter)
+	engine.SetTradeStreamNearPct(cfg.AlertEngine.TradeStreamNearPct)
+	engine.SetBookTickerNearPct(cfg.AlertEngine.BookTickerNearPct)
+	engine.SetWatchlistSymbolLimit(cfg.AlertEngine.WatchlistSymbolLimit)
+
+	// Persist minute history to Postgres beyond the 24h kept in Redis
+	engine.SetHistoryStore(pricehistory.NewStore(pool))
+
+	// Stream the symbols WebSocket clients view, announced by the gateways
+	engine.SetDemandBus(bus)
+
+	// Tell the owner's open Mini App sessions about triggered alerts
+	engine.SetUpdatePublisher(alert.NewUpdatePublisher(bus, log.Logger))
+
+	// Mirror ticks and trigger events to Kafka for analytics (optional)
+	var kafkaSink *kafkasink.Sink
+	if len(cfg.Kafka.Brokers) > 0 {
+		kafkaSink, err = kafkasink.New(kafkasink.Config{
+			Brokers:       cfg.Kafka.Brokers,
+			TicksTopic:    cfg.Kafka.TicksTopic,
+			TriggersTopic: cfg.Kafka.TriggersTopic,
+			BatchSize:     cfg.Kafka.BatchSize,
+			BatchTimeout:  cfg.Kafka.BatchTimeout,
+			Compression:   cfg.Kafka.Compression,
+			BufferSize:    cfg.Kafka.BufferSize,
+		}, log.Logger)
+		if err != nil {
+			log.Error("failed to create kafka sink", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		engine.SetSink(kafkaSink)
+		go kafkaSink.Run(ctx)
+		log.Info("mirroring ticks and triggers to kafka", slog.Any("brokers", cfg.Kafka.Brokers))
+	}
+
+	// Coins without a Binance pair are polled from CoinGecko at a lower rate
+	cgClient := coingecko.NewClient(cfg.CoinGecko.APIKey, log.Logger)
+	engine.SetFallbackPoller(alert.NewFallbackPoller(cgClient, log.Logger))
+
+	// Whale transfer alerts need the Whale Alert API
+	if cfg.AlertEngine.WhaleAlertAPIKey != "" {
+		whaleClient := whale.NewClient(cfg.AlertEngine.WhaleAlertAPIKey, log.Logger)
+		engine.SetWhalePoller(alert.NewWhalePoller(whaleClient, cfg.AlertEngine.WhalePollInterval, log.Logger))
+	}
+
+	// Gas alerts and the overview's gas price need an Ethereum node
+	if cfg.AlertEngine.EthRPCURL != "" {
+		gasClient := gas.NewClient(cfg.AlertEngine.EthRPCURL, log.Logger)
+		engine.SetGasPoller(alert.NewGasPoller(gasClient, gas.NewCache(redisClient), cfg.AlertEngine.GasPollInterval, log.Logger))
+	}
+
+	publisher.SetRetryPolicy(alert.RetryPolicy{
+		MaxAttempts: cfg.AlertEngine.RetryMaxAttempts,
+		BaseDelay:   cfg.AlertEngine.RetryBaseDelay,
+		MaxDelay:    cfg.AlertEngine.RetryMaxDelay,
+	})
 
 	// Start retry queue processor in background
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if err := publisher.ProcessRetryQueue(ctx); err != nil {
-					if ctx.Err() == nil {
-						log.Error("retry queue processing error", slog.String("error", err.Error()))
-					}
-				}
-			}
+	jobs := scheduler.New(nil, cfg.Scheduler.Overrides, log.Logger)
+	if err := jobs.Register(scheduler.Job{
+		Name:     "retry-queue",
+		Schedule: "@every 5s",
+		Run:      publisher.ProcessRetryQueue,
+	}); err != nil {
+		log.Error("failed to register job", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Binance stream uptime for the public status page
+	statusTracker := status.NewTracker(redisClient)
+	if err := jobs.Register(scheduler.Job{
+		Name:     "status-heartbeat",
+		Schedule: "@every 30s",
+		Run: func(ctx context.Context) error {
+			return statusTracker.RecordUp(ctx, status.ComponentBinanceStream, binanceClient.IsConnected())
+		},
+	}); err != nil {
+		log.Error("failed to register job", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	jobs.Start(ctx)
+
+	// Apply tunables on SIGHUP
+	reloader := config.NewReloader(cfg, log.Logger)
+	reloader.OnReload(func(c *config.Config) {
+		anomalyFilter.SetThresholds(c.AlertEngine.AnomalyMaxJumpPct, c.AlertEngine.AnomalyConfirmWindow)
+		engine.SetTradeStreamNearPct(c.AlertEngine.TradeStreamNearPct)
+		engine.SetBookTickerNearPct(c.AlertEngine.BookTickerNearPct)
+		engine.SetWatchlistSymbolLimit(c.AlertEngine.WatchlistSymbolLimit)
+		burstPacer.SetPace(c.AlertEngine.BurstChunkSize, c.AlertEngine.BurstInterval)
+		publisher.SetRetryPolicy(alert.RetryPolicy{
+			MaxAttempts: c.AlertEngine.RetryMaxAttempts,
+			BaseDelay:   c.AlertEngine.RetryBaseDelay,
+			MaxDelay:    c.AlertEngine.RetryMaxDelay,
+		})
+		if err := jobs.UpdateOverrides(c.Scheduler.Overrides); err != nil {
+			log.Error("failed to apply job schedules", slog.String("error", err.Error()))
 		}
-	}()
+	})
+	reloader.Watch(ctx)
+
+	// Only logs rotations here; DATABASE_URL changes need a restart
+	secrets, err := config.NewSecretStore(cfg, log.Logger)
+	if err != nil {
+		log.Error("failed to init secret store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	secrets.Watch(ctx)
 
 	// Start alert engine in background
 	go func() {
@@ -104,6 +237,17 @@ func main() {
 		}
 	}()
 
+	// Internal gRPC API (stats, forced alert refresh) for gateway admin endpoints
+	if cfg.Server.GRPCPort != "" {
+		grpcServer := rpc.NewServer(cfg.Services.GRPCToken, log.Logger)
+		enginev1.RegisterEngineServiceServer(grpcServer, alert.NewGRPCServer(engine, publisher))
+		go func() {
+			if err := rpc.Serve(ctx, grpcServer, cfg.Server.GRPCPort, log.Logger); err != nil {
+				log.Error("grpc server error", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
 	// Health check and metrics server
 	mux := http.NewServeMux()
 
@@ -114,12 +258,24 @@ func main() {
 
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		retryQueueLen, _ := publisher.GetRetryQueueLength(context.Background())
+		deadLetterLen, _ := publisher.GetDeadLetterQueueLength(context.Background())
 
 		metrics := map[string]interface{}{
-			"active_alerts":      engine.GetAlertCount(),
-			"monitored_symbols":  engine.GetSymbolCount(),
-			"binance_connected":  binanceClient.IsConnected(),
-			"retry_queue_length": retryQueueLen,
+			"active_alerts":       engine.GetAlertCount(),
+			"monitored_symbols":   engine.GetSymbolCount(),
+			"binance_connected":   binanceClient.IsConnected(),
+			"retry_queue_length":  retryQueueLen,
+			"dead_letter_length":  deadLetterLen,
+			"rejected_ticks":      engine.GetRejectedTickCount(),
+			"trade_symbols":       engine.GetTradeSymbolCount(),
+			"book_ticker_symbols": engine.GetBookTickerSymbolCount(),
+			"watchlist_symbols":   engine.GetWatchlistSymbolCount(),
+			"demanded_symbols":    engine.GetDemandedSymbolCount(),
+			"burst_pacer":         burstPacer.Stats(),
+			"jobs":                jobs.Stats(),
+		}
+		if kafkaSink != nil {
+			metrics["kafka_sink"] = kafkaSink.Stats()
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -172,6 +328,17 @@ func main() {
 
 	// Stop alert engine (waits for background tasks with timeout)
 	engine.Stop()
+	jobs.Stop()
+
+	// Publish notifications still paced
+	burstPacer.Flush()
+
+	// Flush messages still queued for Kafka
+	if kafkaSink != nil {
+		if err := kafkaSink.Close(); err != nil {
+			log.Error("kafka sink close error", slog.String("error", err.Error()))
+		}
+	}
 
 	log.Info("alert-engine stopped gracefully")
 }
@@ -11,15 +11,30 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	enginev1 "github.com/weqory/backend/api/proto/engine/v1"
+	notificationv1 "github.com/weqory/backend/api/proto/notification/v1"
+	"github.com/weqory/backend/internal/alert"
 	"github.com/weqory/backend/internal/api/handlers"
 	"github.com/weqory/backend/internal/api/middleware"
 	"github.com/weqory/backend/internal/api/routes"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/cache"
 	"github.com/weqory/backend/internal/coingecko"
+	"github.com/weqory/backend/internal/experiment"
+	"github.com/weqory/backend/internal/gas"
+	"github.com/weqory/backend/internal/kafkasink"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/internal/rpc"
+	"github.com/weqory/backend/internal/scheduler"
 	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/status"
 	"github.com/weqory/backend/internal/telegram"
 	"github.com/weqory/backend/internal/websocket"
 	"github.com/weqory/backend/pkg/config"
+	"github.com/weqory/backend/pkg/crypto"
 	"github.com/weqory/backend/pkg/database"
+	"github.com/weqory/backend/pkg/eventbus"
+	"github.com/weqory/backend/pkg/leader"
 	"github.com/weqory/backend/pkg/logger"
 	"github.com/weqory/backend/pkg/redis"
 	"github.com/weqory/backend/pkg/validator"
@@ -72,45 +87,269 @@ func main() {
 	defer redisClient.Close()
 	log.Info("connected to Redis")
 
+	// Connect to the event bus
+	bus, err := eventbus.New(eventbus.Config{
+		Transport:    cfg.EventBus.Transport,
+		NATSURL:      cfg.EventBus.NATSURL,
+		StreamMaxLen: cfg.EventBus.StreamMaxLen,
+		Consumer:     cfg.EventBus.Consumer,
+	}, redisClient)
+	if err != nil {
+		log.Error("failed to connect to event bus", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer bus.Close()
+	log.Info("connected to event bus", slog.String("transport", cfg.EventBus.Transport))
+
+	// Only the elected replica runs singleton background jobs
+	jobsLeader := leader.New(redisClient, "api-gateway:jobs", leader.DefaultTTL, log.Logger)
+	jobsLeader.Start(ctx)
+	defer jobsLeader.Stop()
+
+	// Background jobs run on cron schedules (overridable via SCHEDULE_* env)
+	jobs := scheduler.New(jobsLeader, cfg.Scheduler.Overrides, log.Logger)
+
+	// Rate limits and job schedules are reloaded on SIGHUP
+	reloader := config.NewReloader(cfg, log.Logger)
+	reloader.OnReload(func(c *config.Config) {
+		if err := jobs.UpdateOverrides(c.Scheduler.Overrides); err != nil {
+			log.Error("failed to apply job schedules", slog.String("error", err.Error()))
+		}
+	})
+	reloader.Watch(ctx)
+
 	// Initialize validator
 	v := validator.New()
 
-	// Initialize services (services use pool directly, not repositories)
+	// Cached Binance exchangeInfo snapshot (pair validation and symbol reconciliation)
+	exchangeInfo := binance.NewExchangeInfo(log.Logger)
+	if cfg.Binance.RESTURL != "" {
+		exchangeInfo.SetBaseURL(cfg.Binance.RESTURL)
+		log.Warn("using non-default Binance endpoint", slog.String("url", cfg.Binance.RESTURL))
+	}
+
+	// Initialize services (services take the pool; alerts and watchlists go
+	// through repositories built on it)
 	userService := service.NewUserService(pool)
 	watchlistService := service.NewWatchlistService(pool, userService)
-	alertService := service.NewAlertService(pool, userService, watchlistService)
+	alertService := service.NewAlertService(pool, userService, watchlistService, exchangeInfo)
 	historyService := service.NewHistoryService(pool, userService)
+	categoryService := service.NewCategoryService(pool)
+	featureFlagService := service.NewFeatureFlagService(pool, redisClient, log.Logger)
+	experimentService := experiment.NewService(pool, redisClient, featureFlagService, log.Logger)
+	targetService := service.NewTargetService(pool, cache.NewPriceCache(redisClient, log.Logger), log.Logger)
+	watchlistSummaryService := service.NewWatchlistSummaryService(pool, cache.NewPriceCache(redisClient, log.Logger), log.Logger)
 
 	// AuthService needs JWT config and bot token
 	authService := service.NewAuthService(userService, cfg.JWT.Secret, cfg.Telegram.BotToken, cfg.JWT.Expiry)
 
+	// Admins acting as a user, only accepted when enabled
+	impersonationService := service.NewImpersonationService(pool, authService, log.Logger)
+	var impersonator middleware.Impersonator
+	if cfg.Admin.ImpersonationEnabled {
+		impersonator = impersonationService
+	}
+
+	// Throttle writes of users creating and deleting in a loop
+	auditService := service.NewAuditService(pool)
+	abuseService := service.NewAbuseService(redisClient, auditService,
+		cfg.Abuse.ChurnLimit, cfg.Abuse.ChurnWindow, cfg.Abuse.ThrottleDuration, log.Logger)
+
 	// Initialize Telegram bot client for payments
 	telegramBot := telegram.NewClient(cfg.Telegram.BotToken, log.Logger)
 
+	// Same templates as the notification service, for message previews
+	templates, err := telegram.NewTemplates(cfg.Notification.TemplatesDir)
+	if err != nil {
+		log.Error("failed to load message templates", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	telegramBot.SetTemplates(templates)
+	reloader.OnReload(func(*config.Config) {
+		if err := templates.Reload(); err != nil {
+			log.Error("failed to reload message templates", slog.String("error", err.Error()))
+		}
+	})
+
+	// Secrets from Vault / AWS Secrets Manager are re-fetched periodically;
+	// a rotated bot token or JWT secret is applied without a restart
+	secrets, err := config.NewSecretStore(cfg, log.Logger)
+	if err != nil {
+		log.Error("failed to init secret store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	secrets.OnRotate("TELEGRAM_BOT_TOKEN", func(token string) {
+		telegramBot.SetToken(token)
+		authService.SetBotToken(token)
+	})
+	secrets.OnRotate("JWT_SECRET", authService.SetJWTSecret)
+	secrets.Watch(ctx)
+
+	// Register the webhook with its secret token, so Telegram signs every
+	// update it delivers; without TELEGRAM_WEBHOOK_URL it is managed by hand
+	if cfg.Telegram.WebhookURL != "" {
+		webhookCtx, webhookCancel := context.WithTimeout(ctx, 10*time.Second)
+		err := telegramBot.SetWebhook(webhookCtx, telegram.SetWebhookRequest{
+			URL:            cfg.Telegram.WebhookURL,
+			SecretToken:    cfg.Telegram.WebhookSecret,
+			AllowedUpdates: []string{"message", "callback_query", "pre_checkout_query"},
+		})
+		webhookCancel()
+		if err != nil {
+			log.Error("failed to set telegram webhook", slog.String("error", err.Error()))
+		}
+	}
+
 	// Initialize payment service
 	paymentService := service.NewPaymentService(pool, telegramBot, log.Logger)
 
+	// GET /users/me is served from a short-lived per-user cache that
+	// changes to the profile, watchlist, alerts and plan invalidate
+	profileCache := service.NewUserProfileCache(redisClient, log.Logger)
+	userService.SetProfileCache(profileCache)
+	watchlistService.SetProfileCache(profileCache)
+	alertService.SetProfileCache(profileCache)
+	paymentService.SetProfileCache(profileCache)
+
 	// Initialize cleanup service for background tasks
 	cleanupService := service.NewCleanupService(pool, userService, log.Logger)
-	cleanupService.Start(ctx)
-	defer cleanupService.Stop()
-	log.Info("cleanup service started")
+	cleanupService.SetTelegram(telegramBot)
 
-	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService, v)
-	userHandler := handlers.NewUserHandler(userService, watchlistService, alertService, historyService, v)
-	watchlistHandler := handlers.NewWatchlistHandler(watchlistService, userService, v)
-	alertsHandler := handlers.NewAlertsHandler(alertService, userService, v)
-	historyHandler := handlers.NewHistoryHandler(historyService)
-	marketHandler := handlers.NewMarketHandler(watchlistService)
-	paymentHandler := handlers.NewPaymentHandler(paymentService, v, log.Logger)
+	// Reconcile coin symbols against Binance exchangeInfo
+	symbolMappingService := service.NewSymbolMappingService(pool, exchangeInfo, log.Logger)
+	coinExclusionService := service.NewCoinExclusionService(pool)
+
+	// Detect coins that disappeared from both Binance and CoinGecko
+	cgClient := coingecko.NewClient(cfg.CoinGecko.APIKey, log.Logger)
+	delistingService := service.NewDelistingService(pool, exchangeInfo, cgClient, telegramBot, cfg.Telegram.MiniAppURL, log.Logger)
+
+	// Track onboarding steps; completing the last one sends a congratulation
+	onboardingService := service.NewOnboardingService(pool, telegramBot, cfg.Telegram.MiniAppURL, log.Logger)
+	userService.SetOnboarding(onboardingService)
+	watchlistService.SetOnboarding(onboardingService)
+	alertService.SetOnboarding(onboardingService)
+
+	// Upsell events and nudges when users near their plan limits
+	usageWarningService := service.NewUsageWarningService(pool, telegramBot, cfg.Telegram.MiniAppURL, cfg.Telegram.UsageNudges, log.Logger)
+	watchlistService.SetUsageWarnings(usageWarningService)
+	alertService.SetUsageWarnings(usageWarningService)
+
+	// Pausing and resuming alerts reach the owner's open Mini App sessions
+	alertService.SetUpdatePublisher(alert.NewUpdatePublisher(bus, log.Logger))
+
+	// Exchange API keys are stored encrypted; without a key-encryption key
+	// connecting exchanges returns 503
+	var keyCipher *crypto.Cipher
+	if cfg.Exchange.KeyEncryptionKey != "" {
+		keyCipher, err = crypto.NewCipherFromBase64(cfg.Exchange.KeyEncryptionKey)
+		if err != nil {
+			log.Error("failed to init exchange key cipher", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+	exchangeService := service.NewExchangeService(pool, keyCipher, watchlistService, log.Logger)
+
+	// Durable price history written by the alert engine
+	priceHistoryStore := pricehistory.NewStore(pool)
+	priceHistoryService := service.NewPriceHistoryService(pool, priceHistoryStore, userService)
+	alertReplayService := service.NewAlertReplayService(pool, priceHistoryStore, log.Logger)
+	backtestService := service.NewBacktestService(priceHistoryService, userService, log.Logger)
+	coinStatsService := service.NewCoinStatsService(priceHistoryService, redisClient, log.Logger)
+	alertSuggestionService := service.NewAlertSuggestionService(priceHistoryService, coinStatsService, redisClient, log.Logger)
+
+	// Bulk import of coins and alerts from CSV/JSON files
+	importService := service.NewImportService(watchlistService, alertService, log.Logger)
+
+	// Opens and clicks of alert messages, mirrored to Kafka for analytics
+	engagementService := service.NewEngagementService(pool, log.Logger)
+	if len(cfg.Kafka.Brokers) > 0 {
+		kafkaSink, err := kafkasink.New(kafkasink.Config{
+			Brokers:           cfg.Kafka.Brokers,
+			InteractionsTopic: cfg.Kafka.InteractionsTopic,
+			BatchSize:         cfg.Kafka.BatchSize,
+			BatchTimeout:      cfg.Kafka.BatchTimeout,
+			Compression:       cfg.Kafka.Compression,
+			BufferSize:        cfg.Kafka.BufferSize,
+		}, log.Logger)
+		if err != nil {
+			log.Error("failed to create kafka sink", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		go kafkaSink.Run(ctx)
+		defer func() {
+			if err := kafkaSink.Close(); err != nil {
+				log.Error("kafka sink close error", slog.String("error", err.Error()))
+			}
+		}()
+		engagementService.SetSink(kafkaSink)
+	}
 
 	// Initialize WebSocket hub
 	wsHub := websocket.NewHub(log.Logger)
 	go wsHub.Run(ctx)
 
+	// Connect to the internal gRPC APIs of alert-engine and notification;
+	// the admin endpoints backed by them return 503 when an address is unset
+	var engineClient enginev1.EngineServiceClient
+	if cfg.Services.AlertEngineGRPCAddr != "" {
+		conn, err := rpc.Dial(cfg.Services.AlertEngineGRPCAddr, cfg.Services.GRPCToken)
+		if err != nil {
+			log.Error("failed to create alert engine gRPC client", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer conn.Close()
+		engineClient = enginev1.NewEngineServiceClient(conn)
+		alertService.SetRefresher(rpc.EngineRefresher{Client: engineClient})
+	}
+
+	var notificationClient notificationv1.NotificationServiceClient
+	if cfg.Services.NotificationGRPCAddr != "" {
+		conn, err := rpc.Dial(cfg.Services.NotificationGRPCAddr, cfg.Services.GRPCToken)
+		if err != nil {
+			log.Error("failed to create notification gRPC client", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer conn.Close()
+		notificationClient = notificationv1.NewNotificationServiceClient(conn)
+	}
+
+	// Initialize handlers
+	authHandler := handlers.NewAuthHandler(authService, v)
+	rateLimitRegistry := middleware.NewRateLimitRegistry()
+	userHandler := handlers.NewUserHandler(userService, watchlistService, alertService, historyService, v, rateLimitRegistry)
+	watchlistHandler := handlers.NewWatchlistHandler(watchlistService, userService, v, log.Logger)
+	alertsHandler := handlers.NewAlertsHandler(alertService, userService, backtestService, v)
+	historyHandler := handlers.NewHistoryHandler(historyService, userService, v)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, v, log.Logger)
+	telegramWebhookHandler := handlers.NewTelegramWebhookHandler(
+		paymentService,
+		handlers.NewBotCallbackHandler(userService, alertService, engagementService, telegramBot, log.Logger),
+		handlers.NewBotCommandHandler(userService, telegramBot, cfg.Telegram.MiniAppURL, log.Logger),
+		log.Logger,
+	)
+	adminHandler := handlers.NewAdminHandler(symbolMappingService, coinExclusionService, delistingService, paymentService, alertReplayService, jobs, wsHub, v)
+	featureFlagHandler := handlers.NewFeatureFlagHandler(featureFlagService, v)
+	experimentHandler := handlers.NewExperimentHandler(experimentService, v)
+	targetsHandler := handlers.NewTargetsHandler(targetService, v)
+	servicesHandler := handlers.NewServicesHandler(engineClient, notificationClient, v)
+	onboardingHandler := handlers.NewOnboardingHandler(onboardingService)
+	exchangesHandler := handlers.NewExchangesHandler(exchangeService, v)
+	importHandler := handlers.NewImportHandler(importService, v)
+	priceHistoryHandler := handlers.NewPriceHistoryHandler(priceHistoryService, v)
+	coinStatsHandler := handlers.NewCoinStatsHandler(coinStatsService, alertSuggestionService)
+	coinsHandler := handlers.NewCoinsHandler(service.NewCoinService(pool))
+	trendingService := service.NewTrendingService(pool, log.Logger)
+	trendingHandler := handlers.NewTrendingHandler(trendingService, v)
+	alertDensityService := service.NewAlertDensityService(pool)
+	alertDensityHandler := handlers.NewAlertDensityHandler(alertDensityService, v)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService, cfg.Admin.ImpersonationEnabled, v)
+	abuseHandler := handlers.NewAbuseHandler(abuseService, auditService, v)
+	rolesHandler := handlers.NewRolesHandler(userService, auditService, v)
+	notificationPreviewHandler := handlers.NewNotificationPreviewHandler(alertService, userService, telegramBot, v)
+	engagementHandler := handlers.NewEngagementHandler(engagementService, v)
+
 	// Initialize price subscriber to forward prices from Alert Engine to WebSocket clients
-	priceSubscriber := websocket.NewPriceSubscriber(redisClient, wsHub, log.Logger)
+	priceSubscriber := websocket.NewPriceSubscriber(bus, wsHub, log.Logger)
 	go func() {
 		if err := priceSubscriber.Subscribe(ctx); err != nil {
 			if ctx.Err() == nil {
@@ -119,14 +358,180 @@ func main() {
 		}
 	}()
 
+	// Ask the alert engine to stream the symbols clients view that have no
+	// alerts or watchlists behind them
+	demandAnnouncer := websocket.NewDemandAnnouncer(wsHub, bus, log.Logger)
+	go demandAnnouncer.Run(ctx)
+
+	// Push alert changes, from the engine or another replica, to the
+	// owner's sessions on this one
+	alertUpdates := websocket.NewAlertUpdateRelay(bus, wsHub, log.Logger)
+	go alertUpdates.Run(ctx)
+
 	// Initialize WebSocket handler
 	wsHandler := websocket.NewHandler(wsHub, log.Logger)
 
-	// Initialize CoinGecko sync service
-	cgClient := coingecko.NewClient(cfg.CoinGecko.APIKey, log.Logger)
+	// Stream watchlist summaries to the users subscribed to their topic
+	watchlistSummaries := websocket.NewWatchlistSummaryPublisher(wsHub, watchlistSummaryService, log.Logger)
+	wsHandler.SetWatchlistSummaries(watchlistSummaries)
+	go watchlistSummaries.Run(ctx)
+
+	// Stream the order book depth of the coins users are viewing
+	if cfg.Binance.DepthEnabled {
+		depthClient := binance.NewClient(log.Logger)
+		if cfg.Binance.WSURL != "" {
+			depthClient.SetBaseURL(cfg.Binance.WSURL)
+		}
+		depthRelay := websocket.NewDepthRelay(wsHub, depthClient, cfg.Binance.DepthIdleTimeout, log.Logger)
+		depthClient.SetDepthHandler(depthRelay.Handle)
+		wsHandler.SetDepthRelay(depthRelay)
+		go func() {
+			if err := depthClient.Run(ctx); err != nil && ctx.Err() == nil {
+				log.Error("binance depth stream error", slog.String("error", err.Error()))
+			}
+		}()
+		defer depthClient.Close()
+		go depthRelay.Run(ctx)
+	}
+
+	// Initialize CoinGecko sync services
 	cgSync := coingecko.NewSyncService(cgClient, pool, log.Logger)
-	// Sync top 500 coins every hour (covers DeFi, Gaming, AI categories)
-	cgSync.StartPeriodicSync(ctx, 500, 1*time.Hour)
+	cgGlobalSync := coingecko.NewGlobalSyncService(cgClient, redisClient, log.Logger)
+
+	// Singleton jobs run only on the elected replica
+	for _, job := range []scheduler.Job{
+		{
+			Name:       "cleanup-daily",
+			Schedule:   "0 3 * * *",
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        cleanupService.RunDailyCleanup,
+		},
+		{
+			// Deletes alerts past expires_at or max_triggers
+			Name:       "alert-expiry",
+			Schedule:   "*/5 * * * *",
+			Timeout:    5 * time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        cleanupService.RunAlertExpiry,
+		},
+		{
+			// Only resets when a new month has started
+			Name:       "monthly-reset",
+			Schedule:   "@hourly",
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        cleanupService.RunMonthlyReset,
+		},
+		{
+			// Limit checks read counters kept by triggers; fixes any drift
+			Name:       "usage-reconcile",
+			Schedule:   "0 4 * * *",
+			Timeout:    10 * time.Minute,
+			LeaderOnly: true,
+			Run:        cleanupService.RunUsageReconcile,
+		},
+		{
+			// Top 500 coins (covers DeFi, Gaming, AI categories)
+			Name:       "coingecko-sync",
+			Schedule:   "@hourly",
+			Jitter:     5 * time.Minute,
+			Timeout:    30 * time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run: func(ctx context.Context) error {
+				return cgSync.Sync(ctx, 500)
+			},
+		},
+		{
+			// Descriptions, links and supply for coin pages, refreshed weekly
+			// and spread over runs as it takes a request per coin
+			Name:       "coin-metadata",
+			Schedule:   "20 */6 * * *",
+			Jitter:     5 * time.Minute,
+			Timeout:    10 * time.Minute,
+			LeaderOnly: true,
+			Run: func(ctx context.Context) error {
+				return cgSync.SyncMetadata(ctx, 100)
+			},
+		},
+		{
+			// Keeps /market/overview off the request path for external APIs
+			Name:       "market-overview",
+			Schedule:   "*/5 * * * *",
+			Timeout:    time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        cgGlobalSync.SyncGlobal,
+		},
+		{
+			Name:       "symbol-reconcile",
+			Schedule:   "0 */6 * * *",
+			Jitter:     10 * time.Minute,
+			Timeout:    10 * time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        symbolMappingService.RunReconcile,
+		},
+		{
+			Name:       "delisting-detect",
+			Schedule:   "30 */6 * * *",
+			Jitter:     10 * time.Minute,
+			Timeout:    30 * time.Minute,
+			LeaderOnly: true,
+			Run:        delistingService.RunDetect,
+		},
+		{
+			// Watcher and alert counts behind /coins/trending
+			Name:       "coin-trending",
+			Schedule:   "@hourly",
+			Jitter:     5 * time.Minute,
+			Timeout:    5 * time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        trendingService.RunCompute,
+		},
+		{
+			// Completes payments whose webhook was missed, expires stale ones
+			Name:       "payment-reconcile",
+			Schedule:   "*/15 * * * *",
+			Timeout:    5 * time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        paymentService.RunReconcile,
+		},
+		{
+			// Paid plans whose activation failed after the payment was recorded
+			Name:       "payment-activate",
+			Schedule:   "* * * * *",
+			Timeout:    time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        paymentService.RunRetryActivations,
+		},
+		{
+			// Revenue reports and per-user matching read the stored copy
+			Name:       "star-transactions-sync",
+			Schedule:   "10 * * * *",
+			Timeout:    10 * time.Minute,
+			LeaderOnly: true,
+			RunOnStart: true,
+			Run:        paymentService.RunSyncStarTransactions,
+		},
+	} {
+		if err := jobs.Register(job); err != nil {
+			log.Error("failed to register job", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+	jobs.Start(ctx)
+	defer jobs.Stop()
+
+	marketHandler := handlers.NewMarketHandler(watchlistService, categoryService, cgGlobalSync, gas.NewCache(redisClient), log.Logger)
+	healthHandler := handlers.NewHealthHandler(pool, redisClient, cfg.Services.AlertEngineURL, cfg.Services.NotificationURL, log.Logger)
+	statusService := service.NewStatusService(pool, status.NewTracker(redisClient), log.Logger)
+	statusHandler := handlers.NewStatusHandler(healthHandler, statusService, v, log.Logger)
 
 	// Setup rate limiter
 	rateLimiter := redis.NewRateLimiter(redisClient)
@@ -144,9 +549,19 @@ func main() {
 	app.Use(recover.New())
 	app.Use(middleware.RequestID())
 	app.Use(middleware.Logging(middleware.LoggingConfig{
-		Logger:     log,
-		SkipPaths:  []string{"/health"},
+		Logger:        log,
+		SkipPaths:     []string{"/health"},
 		SlowThreshold: 500 * time.Millisecond,
+		LogBodies:     cfg.Logging.RequestBodies,
+		MaxBodySize:   cfg.Logging.BodyMaxSize,
+		// High-volume polling endpoints
+		SampleRates: map[string]float64{
+			"/api/v1/market/overview": 0.1,
+			"/api/v1/coins":           0.1,
+			"/health/deep":            0.1,
+			"/api/v1/status":          0.1,
+			"/status":                 0.1,
+		},
 	}))
 
 	// CORS configuration
@@ -166,24 +581,70 @@ func main() {
 			return true
 		},
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Request-ID,X-Telegram-Init-Data",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Request-ID,X-Telegram-Init-Data,If-Match",
+		ExposeHeaders:    "ETag",
 		AllowCredentials: true,
 	}))
 
+	// Init data is claimed by its first client for as long as it is valid
+	var initDataReplay middleware.ReplayGuard
+	if cfg.Telegram.InitDataReplayProtection {
+		initDataReplay = redis.NewReplayGuard(redisClient, cfg.Telegram.InitDataMaxAge)
+	}
+
 	// Setup routes
 	routes.Setup(app, &routes.Config{
-		BotToken:    cfg.Telegram.BotToken,
-		RateLimiter: rateLimiter,
-		Log:         log,
-		UserService: userService,
+		BotToken:      cfg.Telegram.BotToken,
+		BotTokenFunc:  authService.BotToken,
+		AdminAPIKey:   cfg.Admin.APIKey,
+		WebhookSecret: cfg.Telegram.WebhookSecret,
+		RateLimiter:   rateLimiter,
+		ResponseCache: redis.NewResponseCache(redisClient),
+		RateLimits: func() (int64, int64) {
+			rl := reloader.Current().RateLimit
+			return int64(rl.MaxRequests), int64(rl.Window / time.Second)
+		},
+		RateLimitRegistry: rateLimitRegistry,
+		InitDataMaxAge:    cfg.Telegram.InitDataMaxAge,
+		InitDataBotID:     cfg.Telegram.InitDataBotID,
+		InitDataReplay:    initDataReplay,
+		Impersonator:      impersonator,
+		AbuseGuard:        abuseService,
+		RequestTimeout:    cfg.Server.RequestTimeout,
+		Development:       cfg.IsDevelopment(),
+		Log:               log,
+		UserService:       userService,
+		FeatureFlags:      featureFlagService,
 		Handlers: &routes.Handlers{
-			Auth:      authHandler,
-			User:      userHandler,
-			Watchlist: watchlistHandler,
-			Alerts:    alertsHandler,
-			History:   historyHandler,
-			Market:    marketHandler,
-			Payment:   paymentHandler,
+			Auth:        authHandler,
+			User:        userHandler,
+			Watchlist:   watchlistHandler,
+			Alerts:      alertsHandler,
+			History:     historyHandler,
+			Market:      marketHandler,
+			Payment:     paymentHandler,
+			Telegram:    telegramWebhookHandler,
+			Admin:       adminHandler,
+			Health:      healthHandler,
+			Features:    featureFlagHandler,
+			Experiments: experimentHandler,
+			Targets:     targetsHandler,
+			Services:    servicesHandler,
+			Onboarding:  onboardingHandler,
+			Exchanges:   exchangesHandler,
+			Import:      importHandler,
+			Prices:      priceHistoryHandler,
+			CoinStats:   coinStatsHandler,
+			Coins:       coinsHandler,
+			Trending:    trendingHandler,
+
+			Impersonation: impersonationHandler,
+			Abuse:         abuseHandler,
+			Roles:         rolesHandler,
+			Preview:       notificationPreviewHandler,
+			Status:        statusHandler,
+			Engagement:    engagementHandler,
+			AlertDensity:  alertDensityHandler,
 		},
 		WSHandler: wsHandler,
 	})
@@ -0,0 +1,312 @@
+// Command loadgen drives a local stack with synthetic market data and
+// WebSocket clients to validate throughput.
+//
+// It serves a fake Binance endpoint streaming N ticks/sec; start the
+// alert-engine with BINANCE_WS_URL (and the api-gateway with
+// BINANCE_REST_URL) pointing at it. M clients connect to the gateway's
+// /ws/prices, subscribe to the symbols and count the price updates they
+// receive. Stats are printed periodically and totals at the end:
+//
+//	go run ./cmd/loadgen -ticks 5000 -clients 2000 -duration 2m
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/weqory/backend/internal/binance/binancetest"
+)
+
+// defaultSymbols are streamed unless -symbols is given
+const defaultSymbols = "BTCUSDT,ETHUSDT,BNBUSDT,SOLUSDT,XRPUSDT,DOGEUSDT,ADAUSDT,TRXUSDT,AVAXUSDT,LINKUSDT"
+
+type options struct {
+	listen     string
+	symbols    []string
+	ticks      int
+	allStreams bool
+	seed       uint64
+
+	wsURL   string
+	clients int
+	ramp    time.Duration
+
+	duration time.Duration
+	report   time.Duration
+}
+
+// clientStats are the counters shared by all WebSocket clients
+type clientStats struct {
+	connected    atomic.Int64
+	dialFailures atomic.Int64
+	disconnects  atomic.Int64
+	priceUpdates atomic.Int64
+	errors       atomic.Int64
+}
+
+func main() {
+	opts := parseFlags()
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancelRun := context.WithTimeout(ctx, opts.duration)
+	defer cancelRun()
+
+	var fake *binancetest.Server
+	if opts.listen != "" {
+		var err error
+		fake, err = startFakeBinance(ctx, opts, log)
+		if err != nil {
+			log.Error("failed to start fake binance", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	stats := &clientStats{}
+	var wg sync.WaitGroup
+	if opts.clients > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runClients(ctx, opts, stats, log)
+		}()
+	}
+
+	start := time.Now()
+	report(ctx, opts.report, fake, stats)
+	wg.Wait()
+
+	printSummary(time.Since(start), fake, stats)
+}
+
+func parseFlags() options {
+	var opts options
+	var symbols string
+
+	flag.StringVar(&opts.listen, "listen", "localhost:9443", "address of the fake Binance server (empty disables it)")
+	flag.StringVar(&symbols, "symbols", defaultSymbols, "comma-separated symbols to stream and subscribe to")
+	flag.IntVar(&opts.ticks, "ticks", 1000, "ticker updates per second streamed by the fake Binance server")
+	flag.BoolVar(&opts.allStreams, "all-streams", true, "stream every symbol regardless of the alert-engine's subscriptions")
+	flag.Uint64Var(&opts.seed, "seed", 1, "seed of the synthetic prices")
+	flag.StringVar(&opts.wsURL, "ws-url", "ws://localhost:8080/ws/prices", "api-gateway WebSocket endpoint")
+	flag.IntVar(&opts.clients, "clients", 100, "concurrent WebSocket clients (0 disables them)")
+	flag.DurationVar(&opts.ramp, "ramp", 5*time.Second, "time over which the clients connect")
+	flag.DurationVar(&opts.duration, "duration", time.Minute, "length of the run")
+	flag.DurationVar(&opts.report, "report", 5*time.Second, "interval between stats lines")
+	flag.Parse()
+
+	for _, s := range strings.Split(symbols, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			opts.symbols = append(opts.symbols, s)
+		}
+	}
+	if len(opts.symbols) == 0 || opts.ticks < 1 || opts.clients < 0 || opts.duration <= 0 || opts.report <= 0 {
+		fmt.Fprintln(os.Stderr, "loadgen: -symbols must not be empty, -ticks must be positive, -clients must not be negative and -duration and -report must be positive")
+		os.Exit(2)
+	}
+
+	return opts
+}
+
+// startFakeBinance serves the fake Binance endpoint and streams ticks to it
+// until ctx is done
+func startFakeBinance(ctx context.Context, opts options, log *slog.Logger) (*binancetest.Server, error) {
+	fake := binancetest.NewServer(opts.symbols)
+	fake.SetBroadcast(opts.allStreams)
+
+	ln, err := net.Listen("tcp", opts.listen)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: fake, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("fake binance server failed", slog.String("error", err.Error()))
+		}
+	}()
+	go binancetest.Stream(ctx, fake, binancetest.NewGenerator(opts.symbols, opts.seed), opts.ticks)
+	go func() {
+		<-ctx.Done()
+		fake.CloseConnections()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	base := "http://" + ln.Addr().String()
+	log.Info("fake binance listening",
+		slog.String("ws_url", binancetest.WSURL(base)),
+		slog.String("rest_url", base),
+		slog.Int("symbols", len(opts.symbols)),
+		slog.Int("ticks_per_sec", opts.ticks),
+	)
+	return fake, nil
+}
+
+// runClients connects the clients evenly over the ramp and waits for them
+// to finish
+func runClients(ctx context.Context, opts options, stats *clientStats, log *slog.Logger) {
+	subscribe, err := json.Marshal(map[string]any{
+		"type":    "subscribe",
+		"payload": map[string]any{"symbols": opts.symbols},
+	})
+	if err != nil {
+		log.Error("failed to build subscribe message", slog.String("error", err.Error()))
+		return
+	}
+
+	interval := opts.ramp / time.Duration(opts.clients)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.clients; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runClient(ctx, opts.wsURL, subscribe, stats)
+		}()
+	}
+	wg.Wait()
+
+	if n := stats.dialFailures.Load(); n > 0 {
+		log.Warn("some clients failed to connect", slog.Int64("failures", n))
+	}
+}
+
+// runClient keeps a client connected and counting updates until ctx is
+// done, redialling after a disconnect
+func runClient(ctx context.Context, wsURL string, subscribe []byte, stats *clientStats) {
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+
+	for ctx.Err() == nil {
+		conn, _, err := dialer.DialContext(ctx, wsURL, nil)
+		if err != nil {
+			stats.dialFailures.Add(1)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		stats.connected.Add(1)
+		readUpdates(ctx, conn, subscribe, stats)
+		stats.connected.Add(-1)
+		if ctx.Err() == nil {
+			stats.disconnects.Add(1)
+		}
+	}
+}
+
+// readUpdates subscribes and counts messages until the connection fails or
+// ctx is done
+func readUpdates(ctx context.Context, conn *websocket.Conn, subscribe []byte, stats *clientStats) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, subscribe); err != nil {
+		return
+	}
+
+	var msg struct {
+		Type string `json:"type"`
+	}
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			stats.errors.Add(1)
+			continue
+		}
+
+		switch msg.Type {
+		case "price_update":
+			stats.priceUpdates.Add(1)
+		case "ping":
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"pong"}`))
+		case "error":
+			stats.errors.Add(1)
+		}
+	}
+}
+
+// report prints the rates of the last interval until ctx is done
+func report(ctx context.Context, interval time.Duration, fake *binancetest.Server, stats *clientStats) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastTicks, lastUpdates int64
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			elapsed := now.Sub(last).Seconds()
+			last = now
+
+			line := ""
+			if fake != nil {
+				fs := fake.Stats()
+				line += fmt.Sprintf("binance: %d conns %.0f ticks/s %d dropped | ",
+					fs.Connections, float64(fs.Published-lastTicks)/elapsed, fs.Dropped)
+				lastTicks = fs.Published
+			}
+
+			updates := stats.priceUpdates.Load()
+			connected := stats.connected.Load()
+			rate := float64(updates-lastUpdates) / elapsed
+			lastUpdates = updates
+			perClient := 0.0
+			if connected > 0 {
+				perClient = rate / float64(connected)
+			}
+			line += fmt.Sprintf("clients: %d connected %.0f updates/s (%.1f per client) %d disconnects %d errors",
+				connected, rate, perClient, stats.disconnects.Load(), stats.errors.Load())
+
+			fmt.Println(line)
+		}
+	}
+}
+
+// printSummary prints the totals of the run
+func printSummary(elapsed time.Duration, fake *binancetest.Server, stats *clientStats) {
+	seconds := elapsed.Seconds()
+
+	fmt.Printf("\n--- %s run ---\n", elapsed.Round(time.Second))
+	if fake != nil {
+		fs := fake.Stats()
+		fmt.Printf("binance:  %d ticks (%.0f/s), %d messages sent, %d dropped\n",
+			fs.Published, float64(fs.Published)/seconds, fs.Sent, fs.Dropped)
+	}
+	updates := stats.priceUpdates.Load()
+	fmt.Printf("clients:  %d price updates (%.0f/s), %d dial failures, %d disconnects, %d errors\n",
+		updates, float64(updates)/seconds, stats.dialFailures.Load(), stats.disconnects.Load(), stats.errors.Load())
+}
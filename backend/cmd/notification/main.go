@@ -10,10 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	notificationv1 "github.com/weqory/backend/api/proto/notification/v1"
+	"github.com/weqory/backend/internal/experiment"
 	"github.com/weqory/backend/internal/notification"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/internal/rpc"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/slo"
+	"github.com/weqory/backend/internal/status"
 	"github.com/weqory/backend/internal/telegram"
 	"github.com/weqory/backend/pkg/config"
 	"github.com/weqory/backend/pkg/database"
+	"github.com/weqory/backend/pkg/eventbus"
 	"github.com/weqory/backend/pkg/logger"
 	"github.com/weqory/backend/pkg/redis"
 )
@@ -65,9 +73,40 @@ func main() {
 	defer redisClient.Close()
 	log.Info("connected to Redis")
 
+	// Connect to the event bus
+	bus, err := eventbus.New(eventbus.Config{
+		Transport:    cfg.EventBus.Transport,
+		NATSURL:      cfg.EventBus.NATSURL,
+		StreamMaxLen: cfg.EventBus.StreamMaxLen,
+		Consumer:     cfg.EventBus.Consumer,
+	}, redisClient)
+	if err != nil {
+		log.Error("failed to connect to event bus", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer bus.Close()
+	log.Info("connected to event bus", slog.String("transport", cfg.EventBus.Transport))
+
 	// Initialize Telegram client
 	telegramClient := telegram.NewClient(cfg.Telegram.BotToken, log.Logger)
 
+	// Alert messages are rendered from templates, re-read on SIGHUP
+	templates, err := telegram.NewTemplates(cfg.Notification.TemplatesDir)
+	if err != nil {
+		log.Error("failed to load message templates", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	telegramClient.SetTemplates(templates)
+
+	// Rebuild the bot client in place when the token rotates in the secret store
+	secrets, err := config.NewSecretStore(cfg, log.Logger)
+	if err != nil {
+		log.Error("failed to init secret store", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	secrets.OnRotate("TELEGRAM_BOT_TOKEN", telegramClient.SetToken)
+	secrets.Watch(ctx)
+
 	// Verify bot token
 	botUser, err := telegramClient.GetMe(ctx)
 	if err != nil {
@@ -88,13 +127,54 @@ func main() {
 		log.Logger,
 	)
 
+	// Delivery success rate for the public status page
+	notificationService.SetStatusTracker(status.NewTracker(redisClient))
+
+	// Latency from price tick to Telegram send, checked against its SLO
+	latency := slo.NewRecorder(slo.DefaultWindow)
+	notificationService.SetLatencyRecorder(latency)
+	var sloAlert slo.Alerter
+	if chatID := cfg.Notification.SLOAlertChatID; chatID != 0 {
+		sloAlert = func(ctx context.Context, text string) error {
+			_, err := telegramClient.SendMessage(ctx, telegram.SendMessageRequest{
+				ChatID: chatID,
+				Text:   text,
+			})
+			return err
+		}
+	}
+	sloMonitor := slo.NewMonitor(latency, cfg.Notification.LatencySLO, sloAlert, log.Logger)
+	go sloMonitor.Run(ctx)
+
 	// Initialize subscriber
 	subscriber := notification.NewSubscriber(
 		pool,
 		redisClient,
+		bus,
 		notificationService,
 		log.Logger,
 	)
+	subscriber.SetBatching(cfg.Notification.BatchWindow, cfg.Notification.BatchMaxSize)
+	subscriber.SetCoinThrottle(cfg.Notification.CoinThrottle)
+	subscriber.SetWorkers(cfg.Notification.MinWorkers, cfg.Notification.MaxWorkers)
+	subscriber.SetQueueSize(cfg.Notification.QueueSize)
+
+	// Notification copy experiment; flags gate which users are enrolled
+	featureFlagService := service.NewFeatureFlagService(pool, redisClient, log.Logger)
+	subscriber.SetExperiments(experiment.NewService(pool, redisClient, featureFlagService, log.Logger))
+	// Charts for users who turned them on in their settings
+	subscriber.SetPriceHistory(pricehistory.NewStore(pool))
+
+	// SIGHUP re-reads the message templates and reports settings that need
+	// a restart instead of terminating the process
+	reloader := config.NewReloader(cfg, log.Logger)
+	reloader.OnReload(func(c *config.Config) {
+		if err := templates.Reload(); err != nil {
+			log.Error("failed to reload message templates", slog.String("error", err.Error()))
+		}
+		sloMonitor.SetObjective(c.Notification.LatencySLO)
+	})
+	reloader.Watch(ctx)
 
 	// Start subscriber in background
 	go func() {
@@ -105,6 +185,17 @@ func main() {
 		}
 	}()
 
+	// Internal gRPC API (stats, test notifications) for gateway endpoints
+	if cfg.Server.GRPCPort != "" {
+		grpcServer := rpc.NewServer(cfg.Services.GRPCToken, log.Logger)
+		notificationv1.RegisterNotificationServiceServer(grpcServer, notification.NewGRPCServer(notificationService, subscriber))
+		go func() {
+			if err := rpc.Serve(ctx, grpcServer, cfg.Server.GRPCPort, log.Logger); err != nil {
+				log.Error("grpc server error", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
 	// Health check and metrics server
 	mux := http.NewServeMux()
 
@@ -115,12 +206,20 @@ func main() {
 
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		sent, failed, rateLimited := notificationService.GetStats()
+		overflowLen, _ := subscriber.GetOverflowLength(r.Context())
 
 		metrics := map[string]interface{}{
 			"notifications_sent":         sent,
 			"notifications_failed":       failed,
 			"notifications_rate_limited": rateLimited,
 			"queue_length":               subscriber.GetQueueLength(),
+			"queue_length_by_priority":   subscriber.GetQueueLengthByPriority(),
+			"pending_batched":            subscriber.GetPendingBatchCount(),
+			"workers":                    subscriber.GetWorkerStats(),
+			"overflow_length":            overflowLen,
+			"pipeline_latency":           latency.Snapshot(),
+			"pipeline_slo_ms":            sloMonitor.Objective().Milliseconds(),
+			"pipeline_slo_breached":      sloMonitor.Breached(),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coin_categories;
+DROP TABLE IF EXISTS categories;
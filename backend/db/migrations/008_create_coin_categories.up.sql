@@ -0,0 +1,52 @@
+-- Market categories (sectors) synced from CoinGecko
+CREATE TABLE categories (
+    id                    VARCHAR(50) PRIMARY KEY,
+    coingecko_id          VARCHAR(100) UNIQUE NOT NULL,
+    name                  VARCHAR(100) NOT NULL,
+
+    -- Cached data (updated periodically)
+    market_cap            DECIMAL(30, 2),
+    market_cap_change_24h DECIMAL(10, 4),
+    volume_24h            DECIMAL(30, 2),
+
+    last_updated          TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Coin to category membership
+CREATE TABLE coin_categories (
+    coin_id               INTEGER NOT NULL REFERENCES coins(id) ON DELETE CASCADE,
+    category_id           VARCHAR(50) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (coin_id, category_id)
+);
+
+-- Indexes
+CREATE INDEX idx_coin_categories_category_id ON coin_categories(category_id);
+
+-- Insert tracked categories
+INSERT INTO categories (id, coingecko_id, name) VALUES
+    ('defi', 'decentralized-finance-defi', 'DeFi'),
+    ('layer1', 'layer-1', 'Layer 1'),
+    ('meme', 'meme-token', 'Meme'),
+    ('gaming', 'gaming', 'Gaming'),
+    ('ai', 'artificial-intelligence', 'AI');
+
+-- Seed membership for coins that already exist (refined by the CoinGecko sync)
+INSERT INTO coin_categories (coin_id, category_id)
+SELECT c.id, s.category_id
+FROM (VALUES
+    ('defi', 'UNI'), ('defi', 'AAVE'), ('defi', 'CAKE'), ('defi', 'SUSHI'), ('defi', 'CRV'),
+    ('defi', 'COMP'), ('defi', 'MKR'), ('defi', 'SNX'), ('defi', 'LDO'), ('defi', '1INCH'),
+    ('layer1', 'BTC'), ('layer1', 'ETH'), ('layer1', 'SOL'), ('layer1', 'ADA'), ('layer1', 'AVAX'),
+    ('layer1', 'DOT'), ('layer1', 'NEAR'), ('layer1', 'ATOM'), ('layer1', 'APT'), ('layer1', 'SUI'),
+    ('layer1', 'TON'), ('layer1', 'TRX'),
+    ('meme', 'DOGE'), ('meme', 'SHIB'), ('meme', 'PEPE'), ('meme', 'FLOKI'), ('meme', 'BONK'),
+    ('meme', 'WIF'),
+    ('gaming', 'AXS'), ('gaming', 'SAND'), ('gaming', 'MANA'), ('gaming', 'GALA'), ('gaming', 'IMX'),
+    ('ai', 'FET'), ('ai', 'RNDR'), ('ai', 'TAO'), ('ai', 'WLD'), ('ai', 'GRT')
+) AS s(category_id, symbol)
+JOIN coins c ON c.symbol = s.symbol
+ON CONFLICT DO NOTHING;
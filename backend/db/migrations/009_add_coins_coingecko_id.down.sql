@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_coins_coingecko_id;
+
+UPDATE coins SET binance_symbol = symbol || 'USDT' WHERE binance_symbol IS NULL;
+ALTER TABLE coins ALTER COLUMN binance_symbol SET NOT NULL;
+
+ALTER TABLE coins DROP COLUMN IF EXISTS coingecko_id;
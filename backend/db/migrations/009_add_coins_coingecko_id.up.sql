@@ -0,0 +1,7 @@
+-- CoinGecko ID is used to poll prices for coins that have no Binance pair
+ALTER TABLE coins ADD COLUMN coingecko_id VARCHAR(100);
+
+-- Coins without a Binance pair are evaluated from CoinGecko instead
+ALTER TABLE coins ALTER COLUMN binance_symbol DROP NOT NULL;
+
+CREATE INDEX idx_coins_coingecko_id ON coins(coingecko_id);
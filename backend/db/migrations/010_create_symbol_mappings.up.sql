@@ -0,0 +1,25 @@
+-- Coin symbol to Binance trading pair mapping
+-- Manual rows are admin overrides; auto rows are maintained by the reconciler
+CREATE TABLE symbol_mappings (
+    symbol                VARCHAR(20) PRIMARY KEY,
+    binance_symbol        VARCHAR(20),  -- NULL = not traded on Binance
+    source                VARCHAR(10) NOT NULL DEFAULT 'auto' CHECK (source IN ('auto', 'manual')),
+
+    -- Reconciler result against Binance exchangeInfo
+    is_valid              BOOLEAN,
+    checked_at            TIMESTAMP WITH TIME ZONE,
+
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_symbol_mappings_updated_at
+    BEFORE UPDATE ON symbol_mappings
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Known renames where the coin symbol no longer matches the Binance pair
+INSERT INTO symbol_mappings (symbol, binance_symbol, source) VALUES
+    ('MATIC', 'POLUSDT', 'manual'),
+    ('FTM', 'SUSDT', 'manual');
@@ -0,0 +1,6 @@
+ALTER TABLE alerts DROP COLUMN IF EXISTS paused_reason;
+
+DROP INDEX IF EXISTS idx_coins_delisted;
+ALTER TABLE coins DROP COLUMN IF EXISTS delist_reason;
+ALTER TABLE coins DROP COLUMN IF EXISTS delisted_at;
+ALTER TABLE coins DROP COLUMN IF EXISTS is_active;
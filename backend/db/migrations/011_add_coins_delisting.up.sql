@@ -0,0 +1,10 @@
+-- Track coins that disappeared from Binance and CoinGecko
+ALTER TABLE coins ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE coins ADD COLUMN delisted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE coins ADD COLUMN delist_reason VARCHAR(255);
+
+CREATE INDEX idx_coins_delisted ON coins(delisted_at) WHERE is_active = false;
+
+-- Why an alert was paused by the system (NULL when paused by the user)
+ALTER TABLE alerts ADD COLUMN paused_reason VARCHAR(30)
+    CHECK (paused_reason IN ('coin_delisted'));
@@ -0,0 +1,3 @@
+ALTER TABLE alerts DROP COLUMN IF EXISTS last_evaluated_at;
+ALTER TABLE alerts DROP COLUMN IF EXISTS last_evaluated_price;
+ALTER TABLE alerts DROP COLUMN IF EXISTS trigger_state;
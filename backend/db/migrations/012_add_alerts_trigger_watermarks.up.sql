@@ -0,0 +1,6 @@
+-- Persisted trigger state so a restarted alert engine cannot fire an alert
+-- again before its in-memory state catches up with the database
+ALTER TABLE alerts ADD COLUMN trigger_state VARCHAR(10) NOT NULL DEFAULT 'armed'
+    CHECK (trigger_state IN ('armed', 'fired'));
+ALTER TABLE alerts ADD COLUMN last_evaluated_price DECIMAL(30, 10);
+ALTER TABLE alerts ADD COLUMN last_evaluated_at TIMESTAMP WITH TIME ZONE;
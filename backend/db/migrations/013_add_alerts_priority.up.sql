@@ -0,0 +1,6 @@
+-- Delivery priority: high-priority triggers are sent ahead of low-priority
+-- ones (periodic updates) when the notification queue backs up
+ALTER TABLE alerts ADD COLUMN priority VARCHAR(10) NOT NULL DEFAULT 'normal'
+    CHECK (priority IN ('low', 'normal', 'high'));
+
+UPDATE alerts SET priority = 'low' WHERE alert_type = 'PERIODIC';
@@ -0,0 +1,30 @@
+-- Feature flags with percentage rollouts
+-- A user gets a flag when it is enabled and their stable bucket (0-99) is
+-- below rollout_percentage; per-user overrides win over both
+CREATE TABLE feature_flags (
+    key                   VARCHAR(64) PRIMARY KEY,
+    description           TEXT NOT NULL DEFAULT '',
+    enabled               BOOLEAN NOT NULL DEFAULT false,  -- kill switch
+    rollout_percentage    SMALLINT NOT NULL DEFAULT 0 CHECK (rollout_percentage BETWEEN 0 AND 100),
+
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_feature_flags_updated_at
+    BEFORE UPDATE ON feature_flags
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Per-user overrides (beta testers, support cases)
+CREATE TABLE feature_flag_overrides (
+    flag_key              VARCHAR(64) NOT NULL REFERENCES feature_flags(key) ON DELETE CASCADE,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    enabled               BOOLEAN NOT NULL,
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (flag_key, user_id)
+);
+
+CREATE INDEX idx_feature_flag_overrides_user ON feature_flag_overrides(user_id);
@@ -0,0 +1,36 @@
+-- A/B experiments built on feature flags
+-- Users are assigned to a variant by a stable hash of their ID; when flag_key
+-- is set only users with that flag enabled are enrolled
+CREATE TABLE experiments (
+    key                   VARCHAR(64) PRIMARY KEY,
+    description           TEXT NOT NULL DEFAULT '',
+    flag_key              VARCHAR(64) REFERENCES feature_flags(key) ON DELETE SET NULL,
+    variants              JSONB NOT NULL,  -- [{"name": "control", "weight": 50}, ...]
+    is_active             BOOLEAN NOT NULL DEFAULT true,
+
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_experiments_updated_at
+    BEFORE UPDATE ON experiments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- One row per enrolled user; every exposure is also sent to the analytics stream
+CREATE TABLE experiment_exposures (
+    experiment_key        VARCHAR(64) NOT NULL REFERENCES experiments(key) ON DELETE CASCADE,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    variant               VARCHAR(64) NOT NULL,
+    exposures             INTEGER NOT NULL DEFAULT 1,
+    first_exposed_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_exposed_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (experiment_key, user_id)
+);
+
+-- Alert notification copy test (inactive until enabled by an admin)
+INSERT INTO experiments (key, description, variants, is_active) VALUES
+    ('notification_copy', 'Compact single-line alert notification text',
+     '[{"name": "control", "weight": 50}, {"name": "compact", "weight": 50}]', false);
@@ -0,0 +1,22 @@
+-- Personal price targets (tracked for progress, never notified; see alerts)
+CREATE TABLE price_targets (
+    id                    BIGSERIAL PRIMARY KEY,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coin_id               INTEGER NOT NULL REFERENCES coins(id) ON DELETE CASCADE,
+
+    target_price          DECIMAL(30, 10) NOT NULL CHECK (target_price > 0),
+    price_when_created    DECIMAL(30, 10),
+    note                  TEXT NOT NULL DEFAULT '',
+
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Indexes
+CREATE INDEX idx_price_targets_user_id ON price_targets(user_id);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_price_targets_updated_at
+    BEFORE UPDATE ON price_targets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,28 @@
+-- Onboarding progress; a step is done once its timestamp is set
+CREATE TABLE user_onboarding (
+    user_id                   BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+
+    added_first_coin_at       TIMESTAMP WITH TIME ZONE,
+    created_first_alert_at    TIMESTAMP WITH TIME ZONE,
+    enabled_notifications_at  TIMESTAMP WITH TIME ZONE,
+    completed_at              TIMESTAMP WITH TIME ZONE,
+
+    created_at                TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at                TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_user_onboarding_updated_at
+    BEFORE UPDATE ON user_onboarding
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing users keep the steps they have already done
+INSERT INTO user_onboarding (user_id, added_first_coin_at, created_first_alert_at)
+SELECT
+    u.id,
+    (SELECT MIN(w.created_at) FROM watchlist w WHERE w.user_id = u.id),
+    (SELECT MIN(a.created_at) FROM alerts a WHERE a.user_id = u.id)
+FROM users u
+WHERE EXISTS (SELECT 1 FROM watchlist w WHERE w.user_id = u.id)
+   OR EXISTS (SELECT 1 FROM alerts a WHERE a.user_id = u.id);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+ALTER TABLE alerts DROP COLUMN IF EXISTS schedule;
@@ -0,0 +1,7 @@
+-- Weekly windows during which an alert is evaluated, e.g.
+-- {"windows": [{"days": ["mon", "fri"], "start": "09:00", "end": "18:00"}]}
+-- NULL means always active
+ALTER TABLE alerts ADD COLUMN schedule JSONB;
+
+-- IANA timezone the user's schedules are evaluated in
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
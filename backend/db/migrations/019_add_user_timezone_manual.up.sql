@@ -0,0 +1,3 @@
+-- Set once the user picks a timezone in settings; until then the timezone
+-- detected by the Mini App on login is kept up to date
+ALTER TABLE users ADD COLUMN timezone_manual BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,23 @@
+-- Read-only exchange API keys used to import balances into the watchlist.
+-- Keys are encrypted by the API gateway (AES-256-GCM), never stored plain
+CREATE TABLE exchange_connections (
+    id                    BIGSERIAL PRIMARY KEY,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    exchange              VARCHAR(20) NOT NULL CHECK (exchange IN ('binance', 'bybit')),
+
+    api_key_encrypted     TEXT NOT NULL,
+    api_secret_encrypted  TEXT NOT NULL,
+    key_hint              VARCHAR(10) NOT NULL,
+
+    last_synced_at        TIMESTAMP WITH TIME ZONE,
+    created_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    UNIQUE (user_id, exchange)
+);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_exchange_connections_updated_at
+    BEFORE UPDATE ON exchange_connections
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+ALTER TABLE subscription_plans DROP COLUMN IF EXISTS price_history_days;
+DROP TABLE IF EXISTS price_history;
@@ -0,0 +1,29 @@
+-- Durable minute price history written by the alert engine. Redis keeps
+-- only the last 24h; this table backs charts and alert backtesting.
+-- symbol is the engine's price key: the Binance pair (BTCUSDT) or
+-- CG:<coingecko id> for CoinGecko-polled coins
+CREATE TABLE price_history (
+    symbol                VARCHAR(100) NOT NULL,
+    recorded_at           TIMESTAMP WITH TIME ZONE NOT NULL,
+    price                 DOUBLE PRECISION NOT NULL,
+
+    PRIMARY KEY (symbol, recorded_at)
+);
+
+-- Use a TimescaleDB hypertable when the extension is available
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb') THEN
+        CREATE EXTENSION IF NOT EXISTS timescaledb;
+        PERFORM create_hypertable('price_history', 'recorded_at',
+            chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE);
+    END IF;
+END
+$$;
+
+-- How far back each plan can read price history
+ALTER TABLE subscription_plans ADD COLUMN price_history_days INTEGER NOT NULL DEFAULT 7;
+
+UPDATE subscription_plans SET price_history_days = 7 WHERE name = 'standard';
+UPDATE subscription_plans SET price_history_days = 90 WHERE name = 'pro';
+UPDATE subscription_plans SET price_history_days = 365 WHERE name = 'ultimate';
@@ -0,0 +1,3 @@
+-- "Mute all alerts" switch: no alert notifications are sent until this time.
+-- Separate from per-alert pause, which is kept as is
+ALTER TABLE users ADD COLUMN alerts_muted_until TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_payments_pending_created_at;
+
+UPDATE payments SET status = 'failed' WHERE status = 'expired';
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'completed', 'refunded', 'failed'));
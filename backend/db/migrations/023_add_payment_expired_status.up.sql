@@ -0,0 +1,6 @@
+-- Pending payments left unpaid are expired by the reconciliation job
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'completed', 'refunded', 'failed', 'expired'));
+
+CREATE INDEX idx_payments_pending_created_at ON payments(created_at) WHERE status = 'pending';
@@ -0,0 +1,13 @@
+-- Free trials of paid plans, one per Telegram account. user_id is kept
+-- NULL when the account is deleted so that the trial cannot be redeemed
+-- again by signing up anew
+CREATE TABLE trials (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    telegram_id   BIGINT NOT NULL UNIQUE,
+    plan          VARCHAR(20) NOT NULL CHECK (plan IN ('pro', 'ultimate')),
+    started_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_trials_user_id ON trials(user_id);
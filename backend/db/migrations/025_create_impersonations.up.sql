@@ -0,0 +1,25 @@
+-- Admin sessions acting as a user, and every request made in them. Kept
+-- when the user is deleted so the audit trail survives
+CREATE TABLE impersonations (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    operator      VARCHAR(100) NOT NULL,
+    reason        TEXT NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_impersonations_user_id ON impersonations(user_id);
+CREATE INDEX idx_impersonations_created_at ON impersonations(created_at DESC);
+
+CREATE TABLE impersonation_requests (
+    id                 BIGSERIAL PRIMARY KEY,
+    impersonation_id   BIGINT NOT NULL REFERENCES impersonations(id) ON DELETE CASCADE,
+    method             VARCHAR(10) NOT NULL,
+    path               TEXT NOT NULL,
+    status             INTEGER NOT NULL,
+    created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_impersonation_requests_impersonation_id ON impersonation_requests(impersonation_id);
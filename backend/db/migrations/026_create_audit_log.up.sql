@@ -0,0 +1,13 @@
+-- Security-relevant events, such as users throttled for abuse and admins
+-- lifting the throttle. Kept when the user is deleted
+CREATE TABLE audit_log (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    actor         VARCHAR(100) NOT NULL,
+    action        VARCHAR(50) NOT NULL,
+    details       JSONB NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_user_id ON audit_log(user_id, created_at DESC);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at DESC);
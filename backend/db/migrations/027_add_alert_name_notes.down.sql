@@ -0,0 +1,2 @@
+ALTER TABLE alerts DROP COLUMN IF EXISTS notes;
+ALTER TABLE alerts DROP COLUMN IF EXISTS name;
@@ -0,0 +1,4 @@
+-- User labels for alerts, e.g. "Buy zone" or "Stop loss", and free-form
+-- notes. The name is shown in Telegram notifications
+ALTER TABLE alerts ADD COLUMN name VARCHAR(64);
+ALTER TABLE alerts ADD COLUMN notes TEXT;
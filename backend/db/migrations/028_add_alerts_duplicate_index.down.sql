@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_alerts_unique_condition;
+ALTER TABLE alerts DROP COLUMN IF EXISTS allow_duplicate;
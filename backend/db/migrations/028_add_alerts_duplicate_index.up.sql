@@ -0,0 +1,23 @@
+-- Set on alerts created on purpose as a copy of another one (force=true);
+-- they are exempt from the duplicate check
+ALTER TABLE alerts ADD COLUMN allow_duplicate BOOLEAN NOT NULL DEFAULT false;
+
+-- Keep the oldest of any identical alerts created before the index
+UPDATE alerts a
+SET allow_duplicate = true
+WHERE EXISTS (
+    SELECT 1 FROM alerts o
+    WHERE o.user_id = a.user_id
+      AND o.coin_id = a.coin_id
+      AND o.alert_type = a.alert_type
+      AND o.condition_value = a.condition_value
+      AND COALESCE(o.condition_timeframe, '') = COALESCE(a.condition_timeframe, '')
+      AND COALESCE(o.periodic_interval, '') = COALESCE(a.periodic_interval, '')
+      AND o.id < a.id
+);
+
+-- One alert per user, coin, type, value and timeframe
+CREATE UNIQUE INDEX idx_alerts_unique_condition ON alerts (
+    user_id, coin_id, alert_type, condition_value,
+    COALESCE(condition_timeframe, ''), COALESCE(periodic_interval, '')
+) WHERE allow_duplicate = false;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_alerts_expires_at;
+ALTER TABLE alerts DROP COLUMN IF EXISTS max_triggers;
+ALTER TABLE alerts DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,7 @@
+-- Optional end of an alert: a date after which it is no longer evaluated,
+-- and a number of triggers after which it stops. Expired alerts are
+-- deleted by the cleanup job; their history is kept
+ALTER TABLE alerts ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE alerts ADD COLUMN max_triggers INTEGER CHECK (max_triggers > 0);
+
+CREATE INDEX idx_alerts_expires_at ON alerts(expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Access level of a user. Staff (support, admin) can use the admin API with
+-- their Telegram login instead of the shared admin key
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'support', 'admin'));
+
+CREATE INDEX idx_users_staff ON users(role) WHERE role <> 'user';
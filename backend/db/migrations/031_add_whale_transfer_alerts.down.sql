@@ -0,0 +1,8 @@
+DELETE FROM alerts WHERE alert_type = 'WHALE_TRANSFER';
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_alert_type_check;
+ALTER TABLE alerts ADD CONSTRAINT alerts_alert_type_check
+    CHECK (alert_type IN (
+        'PRICE_ABOVE', 'PRICE_BELOW', 'PRICE_CHANGE_PCT',
+        'VOLUME_CHANGE_PCT', 'VOLUME_SPIKE',
+        'MARKET_CAP_ABOVE', 'MARKET_CAP_BELOW', 'PERIODIC'
+    ));
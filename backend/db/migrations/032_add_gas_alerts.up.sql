@@ -0,0 +1,9 @@
+-- Gas alerts fire when the Ethereum gas price drops below a threshold in gwei
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_alert_type_check;
+ALTER TABLE alerts ADD CONSTRAINT alerts_alert_type_check
+    CHECK (alert_type IN (
+        'PRICE_ABOVE', 'PRICE_BELOW', 'PRICE_CHANGE_PCT',
+        'VOLUME_CHANGE_PCT', 'VOLUME_SPIKE',
+        'MARKET_CAP_ABOVE', 'MARKET_CAP_BELOW', 'PERIODIC',
+        'WHALE_TRANSFER', 'GAS_BELOW'
+    ));
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS silent_at_night,
+    DROP COLUMN IF EXISTS include_chart,
+    DROP COLUMN IF EXISTS message_format;
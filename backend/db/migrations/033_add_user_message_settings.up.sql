@@ -0,0 +1,7 @@
+-- How alert messages are delivered: detailed or one-line text, a 24h price
+-- chart attached, and no sound at night in the user's timezone
+ALTER TABLE users
+    ADD COLUMN message_format VARCHAR(16) NOT NULL DEFAULT 'detailed'
+        CHECK (message_format IN ('detailed', 'compact')),
+    ADD COLUMN include_chart BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN silent_at_night BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE subscription_plans DROP COLUMN IF EXISTS alert_charts;
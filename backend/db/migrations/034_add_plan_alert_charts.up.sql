@@ -0,0 +1,4 @@
+-- Plans whose users can get a price chart with their alerts
+ALTER TABLE subscription_plans ADD COLUMN alert_charts BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE subscription_plans SET alert_charts = TRUE WHERE name IN ('pro', 'ultimate');
@@ -0,0 +1,12 @@
+-- Incidents posted by operators on the public status page
+CREATE TABLE status_incidents (
+    id            BIGSERIAL PRIMARY KEY,
+    title         VARCHAR(200) NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    severity      VARCHAR(16) NOT NULL CHECK (severity IN ('minor', 'major', 'critical')),
+    operator      VARCHAR(100) NOT NULL,
+    started_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_status_incidents_started_at ON status_incidents(started_at DESC);
@@ -0,0 +1,15 @@
+-- Alert messages sent, with the token of their Mini App link, to measure
+-- which alert types users open or act on
+CREATE TABLE notification_deliveries (
+    id                    BIGSERIAL PRIMARY KEY,
+    token                 VARCHAR(32) NOT NULL UNIQUE,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    telegram_message_id   BIGINT NOT NULL,
+    alert_types           TEXT[] NOT NULL,
+    sent_at               TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    opened_at             TIMESTAMP WITH TIME ZONE,
+    clicked_at            TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_notification_deliveries_message ON notification_deliveries(user_id, telegram_message_id);
+CREATE INDEX idx_notification_deliveries_sent_at ON notification_deliveries(sent_at);
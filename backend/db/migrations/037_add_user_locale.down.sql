@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS number_format,
+    DROP COLUMN IF EXISTS language_manual;
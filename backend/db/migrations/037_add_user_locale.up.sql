@@ -0,0 +1,6 @@
+-- Language and number format of user-facing text. language_code follows
+-- Telegram's on every login until the user sets one in the app
+ALTER TABLE users
+    ADD COLUMN language_manual BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN number_format VARCHAR(16) NOT NULL DEFAULT 'auto'
+        CHECK (number_format IN ('auto', 'point', 'comma', 'space'));
@@ -0,0 +1,16 @@
+-- Hourly snapshots of how many users watch and alert each coin, with the
+-- trending score of the discovery screen. Growth is measured against the
+-- snapshot of a day before
+CREATE TABLE coin_popularity (
+    coin_id          INTEGER NOT NULL REFERENCES coins(id) ON DELETE CASCADE,
+    recorded_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    watchers         INTEGER NOT NULL,
+    alert_users      INTEGER NOT NULL,
+    watcher_growth   INTEGER NOT NULL,
+    triggers_24h     INTEGER NOT NULL,
+    score            DOUBLE PRECISION NOT NULL,
+
+    PRIMARY KEY (coin_id, recorded_at)
+);
+
+CREATE INDEX idx_coin_popularity_recorded_at ON coin_popularity(recorded_at, score DESC);
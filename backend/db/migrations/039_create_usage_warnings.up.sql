@@ -0,0 +1,18 @@
+-- Upsell events raised when a user reaches 80% or 100% of the coins or
+-- alerts of their plan. Each threshold is raised once per plan limit, so a
+-- user is not nagged again until their limit changes
+CREATE TABLE usage_warnings (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    resource      VARCHAR(16) NOT NULL CHECK (resource IN ('coins', 'alerts')),
+    threshold     SMALLINT NOT NULL,
+    used          INTEGER NOT NULL,
+    plan_limit    INTEGER NOT NULL,
+    plan          VARCHAR(20) NOT NULL,
+    nudged_at     TIMESTAMP WITH TIME ZONE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE (user_id, resource, threshold, plan_limit)
+);
+
+CREATE INDEX idx_usage_warnings_created_at ON usage_warnings(created_at);
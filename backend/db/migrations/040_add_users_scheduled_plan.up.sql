@@ -0,0 +1,5 @@
+-- Plan a subscriber moves to at plan_expires_at instead of renewing:
+-- standard after cancelling, a cheaper plan after scheduling a downgrade.
+-- NULL renews the current plan
+ALTER TABLE users ADD COLUMN scheduled_plan VARCHAR(20)
+    CHECK (scheduled_plan IN ('standard', 'pro', 'ultimate'));
@@ -0,0 +1,24 @@
+-- The bot's Telegram Stars transactions, synced from getStarTransactions so
+-- revenue can be reconciled without relying on payment webhooks. A refund
+-- carries the ID of the payment it refunds, hence the direction in the key
+CREATE TABLE star_transactions (
+    id                  VARCHAR(255) NOT NULL,
+    direction           VARCHAR(8) NOT NULL CHECK (direction IN ('in', 'out')),
+    amount              INTEGER NOT NULL,
+    partner_type        VARCHAR(32) NOT NULL DEFAULT '',
+    telegram_user_id    BIGINT,
+    invoice_payload     TEXT,
+    transaction_date    TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    -- Matched on sync; NULL when nothing matches
+    user_id             BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    payment_id          BIGINT REFERENCES payments(id) ON DELETE SET NULL,
+
+    synced_at           TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (id, direction)
+);
+
+CREATE INDEX idx_star_transactions_date ON star_transactions(transaction_date);
+CREATE INDEX idx_star_transactions_user_id ON star_transactions(user_id);
+CREATE INDEX idx_star_transactions_payment_id ON star_transactions(payment_id);
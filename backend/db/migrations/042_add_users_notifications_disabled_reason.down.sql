@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS notifications_disabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS notifications_disabled_reason;
@@ -0,0 +1,6 @@
+-- Why notifications were turned off for a user the bot can no longer
+-- message: blocked, chat_not_found or deactivated. NULL when the user
+-- turned them off or they are on. Cleared when the user sends /start
+ALTER TABLE users ADD COLUMN notifications_disabled_reason VARCHAR(20)
+    CHECK (notifications_disabled_reason IN ('blocked', 'chat_not_found', 'deactivated'));
+ALTER TABLE users ADD COLUMN notifications_disabled_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS count_alerts_usage ON alerts;
+DROP TRIGGER IF EXISTS count_watchlist_usage ON watchlist;
+DROP FUNCTION IF EXISTS count_alerts_usage();
+DROP FUNCTION IF EXISTS count_watchlist_usage();
+ALTER TABLE users DROP COLUMN IF EXISTS alerts_used;
+ALTER TABLE users DROP COLUMN IF EXISTS coins_used;
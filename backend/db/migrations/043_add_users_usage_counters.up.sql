@@ -0,0 +1,43 @@
+-- Watchlist coins and alerts per user, read by plan limit checks instead
+-- of counting on every request. Kept by the triggers below, which also
+-- see cascaded deletes; a scheduled job corrects any drift
+ALTER TABLE users ADD COLUMN coins_used INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN alerts_used INTEGER NOT NULL DEFAULT 0;
+
+UPDATE users u SET
+    coins_used = (SELECT COUNT(*) FROM watchlist w WHERE w.user_id = u.id),
+    alerts_used = (SELECT COUNT(*) FROM alerts a WHERE a.user_id = u.id);
+
+CREATE OR REPLACE FUNCTION count_watchlist_usage()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE users SET coins_used = coins_used + 1 WHERE id = NEW.user_id;
+    ELSE
+        UPDATE users SET coins_used = GREATEST(coins_used - 1, 0) WHERE id = OLD.user_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION count_alerts_usage()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE users SET alerts_used = alerts_used + 1 WHERE id = NEW.user_id;
+    ELSE
+        UPDATE users SET alerts_used = GREATEST(alerts_used - 1, 0) WHERE id = OLD.user_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER count_watchlist_usage
+    AFTER INSERT OR DELETE ON watchlist
+    FOR EACH ROW
+    EXECUTE FUNCTION count_watchlist_usage();
+
+CREATE TRIGGER count_alerts_usage
+    AFTER INSERT OR DELETE ON alerts
+    FOR EACH ROW
+    EXECUTE FUNCTION count_alerts_usage();
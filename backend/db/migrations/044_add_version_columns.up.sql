@@ -0,0 +1,5 @@
+-- Counts edits of an alert, or of a user's settings, made through the API.
+-- Updates sent with If-Match apply only while the version is unchanged,
+-- so concurrent edits fail with 409 instead of overwriting each other
+ALTER TABLE alerts ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
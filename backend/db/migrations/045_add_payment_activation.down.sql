@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payments_pending_activation;
+ALTER TABLE payments DROP COLUMN IF EXISTS activation_error;
+ALTER TABLE payments DROP COLUMN IF EXISTS activation_retry_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS activation_attempts;
+ALTER TABLE payments DROP COLUMN IF EXISTS activated_at;
@@ -0,0 +1,14 @@
+-- Completing a payment and activating its plan are separate steps, so a
+-- failed activation does not undo the recorded payment. Completed payments
+-- without activated_at are retried by the payment-activate job, backing
+-- off until activation_retry_at
+ALTER TABLE payments ADD COLUMN activated_at TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN activation_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN activation_retry_at TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN activation_error TEXT;
+
+UPDATE payments SET activated_at = completed_at
+WHERE status IN ('completed', 'refunded');
+
+CREATE INDEX idx_payments_pending_activation ON payments(completed_at)
+    WHERE status = 'completed' AND activated_at IS NULL;
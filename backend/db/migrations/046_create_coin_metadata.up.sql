@@ -0,0 +1,18 @@
+-- Description, links and supply of coins from CoinGecko, shown on the coin
+-- detail page. Refreshed weekly by the coin-metadata job; a coin without a
+-- row has not been fetched yet
+CREATE TABLE coin_metadata (
+    coin_id             INTEGER PRIMARY KEY REFERENCES coins(id) ON DELETE CASCADE,
+    description         TEXT,
+    homepage_url        TEXT,
+    explorer_urls       TEXT[] NOT NULL DEFAULT '{}',
+
+    -- NULL when CoinGecko does not know; max_supply is NULL for uncapped coins
+    circulating_supply  DECIMAL(40, 8),
+    total_supply        DECIMAL(40, 8),
+    max_supply          DECIMAL(40, 8),
+
+    updated_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_coin_metadata_updated_at ON coin_metadata(updated_at);
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS allow_stablecoins;
+ALTER TABLE coins DROP COLUMN IF EXISTS is_excluded;
+DROP TABLE IF EXISTS coin_exclusions;
@@ -0,0 +1,35 @@
+-- Coins kept out of the watchlist and coin search, managed by admins.
+-- 'stablecoin' coins can still be watched by users who allow stablecoins
+-- (e.g. for depeg monitoring); 'excluded' coins are hidden from everyone.
+-- The coin sync copies the list onto coins.is_stablecoin and is_excluded
+CREATE TABLE coin_exclusions (
+    symbol      VARCHAR(20) PRIMARY KEY,
+    kind        VARCHAR(20) NOT NULL CHECK (kind IN ('stablecoin', 'excluded')),
+    note        TEXT,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO coin_exclusions (symbol, kind) VALUES
+    ('USDT', 'stablecoin'),
+    ('USDC', 'stablecoin'),
+    ('DAI', 'stablecoin'),
+    ('BUSD', 'stablecoin'),
+    ('TUSD', 'stablecoin'),
+    ('USDP', 'stablecoin'),
+    ('FRAX', 'stablecoin'),
+    ('USDD', 'stablecoin'),
+    ('GUSD', 'stablecoin'),
+    ('PAXG', 'stablecoin'),
+    ('XAUT', 'stablecoin'),
+    ('FDUSD', 'stablecoin'),
+    ('PYUSD', 'stablecoin'),
+    ('USDE', 'stablecoin');
+
+ALTER TABLE coins ADD COLUMN is_excluded BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE coins c SET
+    is_stablecoin = EXISTS (SELECT 1 FROM coin_exclusions e WHERE e.symbol = c.symbol AND e.kind = 'stablecoin'),
+    is_excluded = EXISTS (SELECT 1 FROM coin_exclusions e WHERE e.symbol = c.symbol AND e.kind = 'excluded');
+
+-- Lets a user add stablecoins to their watchlist
+ALTER TABLE users ADD COLUMN allow_stablecoins BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,25 @@
+-- Alerts differing only in their quote become duplicates; keep the oldest
+UPDATE alerts a
+SET allow_duplicate = true
+WHERE a.allow_duplicate = false
+  AND EXISTS (
+    SELECT 1 FROM alerts o
+    WHERE o.user_id = a.user_id
+      AND o.coin_id = a.coin_id
+      AND o.alert_type = a.alert_type
+      AND o.condition_value = a.condition_value
+      AND COALESCE(o.condition_timeframe, '') = COALESCE(a.condition_timeframe, '')
+      AND COALESCE(o.periodic_interval, '') = COALESCE(a.periodic_interval, '')
+      AND o.allow_duplicate = false
+      AND o.id < a.id
+  );
+
+DROP INDEX IF EXISTS idx_alerts_unique_condition;
+CREATE UNIQUE INDEX idx_alerts_unique_condition ON alerts (
+    user_id, coin_id, alert_type, condition_value,
+    COALESCE(condition_timeframe, ''), COALESCE(periodic_interval, '')
+) WHERE allow_duplicate = false;
+
+ALTER TABLE alert_history DROP COLUMN IF EXISTS quote_asset;
+ALTER TABLE alerts DROP COLUMN IF EXISTS pair_symbol;
+ALTER TABLE alerts DROP COLUMN IF EXISTS quote_asset;
@@ -0,0 +1,16 @@
+-- Alerts on another quote asset than the coin's USD pair, e.g. ETH in BTC.
+-- pair_symbol is the Binance pair the alert is evaluated on, resolved from
+-- exchangeInfo when the alert is created; both are NULL for USD alerts
+ALTER TABLE alerts ADD COLUMN quote_asset VARCHAR(10);
+ALTER TABLE alerts ADD COLUMN pair_symbol VARCHAR(30);
+
+-- History outlives its alerts, so it keeps the quote of its prices too
+ALTER TABLE alert_history ADD COLUMN quote_asset VARCHAR(10);
+
+-- The same threshold in another quote is a different alert
+DROP INDEX IF EXISTS idx_alerts_unique_condition;
+CREATE UNIQUE INDEX idx_alerts_unique_condition ON alerts (
+    user_id, coin_id, alert_type, condition_value,
+    COALESCE(condition_timeframe, ''), COALESCE(periodic_interval, ''),
+    COALESCE(quote_asset, '')
+) WHERE allow_duplicate = false;
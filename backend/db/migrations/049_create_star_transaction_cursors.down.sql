@@ -0,0 +1 @@
+DROP TABLE IF EXISTS star_transaction_cursors;
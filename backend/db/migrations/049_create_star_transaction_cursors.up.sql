@@ -0,0 +1,8 @@
+-- Where each reader of getStarTransactions resumes. Telegram lists the
+-- transactions oldest first, so an offset keeps pointing at the same
+-- transaction as new ones are added
+CREATE TABLE star_transaction_cursors (
+    name          VARCHAR(32) PRIMARY KEY,
+    next_offset   INTEGER NOT NULL DEFAULT 0 CHECK (next_offset >= 0),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
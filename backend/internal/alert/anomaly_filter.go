@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AnomalyFilter drops suspicious price ticks (flash wicks, bad data) before
+// they reach the evaluator. A tick that jumps more than maxJumpPct from the
+// last accepted price is held back until a second tick near the new level
+// confirms it within confirmWindow
+type AnomalyFilter struct {
+	maxJumpPct    float64
+	confirmWindow time.Duration
+	logger        *slog.Logger
+
+	state map[string]*tickState
+	mu    sync.Mutex
+
+	rejected atomic.Int64
+}
+
+// tickState tracks the last accepted and pending price for a symbol
+type tickState struct {
+	lastPrice    float64
+	pendingPrice float64
+	pendingAt    time.Time
+}
+
+// NewAnomalyFilter creates a new anomaly filter
+// A maxJumpPct of 0 disables filtering
+func NewAnomalyFilter(maxJumpPct float64, confirmWindow time.Duration, logger *slog.Logger) *AnomalyFilter {
+	return &AnomalyFilter{
+		maxJumpPct:    maxJumpPct,
+		confirmWindow: confirmWindow,
+		logger:        logger,
+		state:         make(map[string]*tickState),
+	}
+}
+
+// SetThresholds changes the filter thresholds at runtime
+func (f *AnomalyFilter) SetThresholds(maxJumpPct float64, confirmWindow time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maxJumpPct = maxJumpPct
+	f.confirmWindow = confirmWindow
+}
+
+// Allow reports whether a tick should be processed
+func (f *AnomalyFilter) Allow(symbol string, price float64, now time.Time) bool {
+	if f == nil {
+		return true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxJumpPct <= 0 {
+		return true
+	}
+
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		f.rejected.Add(1)
+		f.logger.Warn("rejected invalid price tick",
+			slog.String("symbol", symbol),
+			slog.Float64("price", price),
+		)
+		return false
+	}
+
+	st, ok := f.state[symbol]
+	if !ok {
+		f.state[symbol] = &tickState{lastPrice: price}
+		return true
+	}
+
+	if jumpPercent(st.lastPrice, price) <= f.maxJumpPct {
+		st.lastPrice = price
+		st.pendingAt = time.Time{}
+		return true
+	}
+
+	// A second tick close to the pending one confirms the move
+	if !st.pendingAt.IsZero() &&
+		now.Sub(st.pendingAt) <= f.confirmWindow &&
+		jumpPercent(st.pendingPrice, price) <= f.maxJumpPct {
+		f.logger.Info("price jump confirmed",
+			slog.String("symbol", symbol),
+			slog.Float64("from", st.lastPrice),
+			slog.Float64("to", price),
+		)
+		st.lastPrice = price
+		st.pendingAt = time.Time{}
+		return true
+	}
+
+	st.pendingPrice = price
+	st.pendingAt = now
+	f.rejected.Add(1)
+
+	f.logger.Warn("rejected anomalous price tick",
+		slog.String("symbol", symbol),
+		slog.Float64("last_price", st.lastPrice),
+		slog.Float64("price", price),
+		slog.Float64("jump_pct", jumpPercent(st.lastPrice, price)),
+	)
+
+	return false
+}
+
+// GetRejectedCount returns the number of rejected ticks
+func (f *AnomalyFilter) GetRejectedCount() int64 {
+	if f == nil {
+		return 0
+	}
+	return f.rejected.Load()
+}
+
+// jumpPercent returns the absolute percentage change from a to b
+func jumpPercent(a, b float64) float64 {
+	if a == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(b-a) / a * 100
+}
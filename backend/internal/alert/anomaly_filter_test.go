@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAnomalyFilter() *AnomalyFilter {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewAnomalyFilter(20, 2*time.Minute, logger)
+}
+
+func TestAnomalyFilter_AcceptsNormalMoves(t *testing.T) {
+	f := newTestAnomalyFilter()
+	now := time.Now()
+
+	assert.True(t, f.Allow("BTCUSDT", 50000, now))
+	assert.True(t, f.Allow("BTCUSDT", 51000, now.Add(time.Second)))
+	assert.True(t, f.Allow("BTCUSDT", 45000, now.Add(2*time.Second)))
+	assert.Equal(t, int64(0), f.GetRejectedCount())
+}
+
+func TestAnomalyFilter_RejectsSingleWick(t *testing.T) {
+	f := newTestAnomalyFilter()
+	now := time.Now()
+
+	assert.True(t, f.Allow("BTCUSDT", 50000, now))
+	assert.False(t, f.Allow("BTCUSDT", 5000, now.Add(time.Second)), "flash wick should be rejected")
+	assert.True(t, f.Allow("BTCUSDT", 50100, now.Add(2*time.Second)), "price back to normal")
+	assert.Equal(t, int64(1), f.GetRejectedCount())
+}
+
+func TestAnomalyFilter_ConfirmedJumpIsAccepted(t *testing.T) {
+	f := newTestAnomalyFilter()
+	now := time.Now()
+
+	assert.True(t, f.Allow("PEPEUSDT", 1.0, now))
+	assert.False(t, f.Allow("PEPEUSDT", 1.5, now.Add(time.Second)))
+	assert.True(t, f.Allow("PEPEUSDT", 1.52, now.Add(2*time.Second)), "second tick confirms the move")
+	assert.True(t, f.Allow("PEPEUSDT", 1.55, now.Add(3*time.Second)), "new level is the baseline")
+}
+
+func TestAnomalyFilter_ConfirmationExpires(t *testing.T) {
+	f := newTestAnomalyFilter()
+	now := time.Now()
+
+	assert.True(t, f.Allow("ETHUSDT", 3000, now))
+	assert.False(t, f.Allow("ETHUSDT", 4500, now.Add(time.Second)))
+	assert.False(t, f.Allow("ETHUSDT", 4500, now.Add(5*time.Minute)), "stale pending tick cannot confirm")
+	assert.True(t, f.Allow("ETHUSDT", 4510, now.Add(5*time.Minute+time.Second)))
+}
+
+func TestAnomalyFilter_RejectsInvalidPrices(t *testing.T) {
+	f := newTestAnomalyFilter()
+	now := time.Now()
+
+	assert.False(t, f.Allow("BTCUSDT", 0, now))
+	assert.False(t, f.Allow("BTCUSDT", -1, now))
+}
+
+func TestAnomalyFilter_Disabled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	f := NewAnomalyFilter(0, time.Minute, logger)
+	now := time.Now()
+
+	assert.True(t, f.Allow("BTCUSDT", 50000, now))
+	assert.True(t, f.Allow("BTCUSDT", 5000, now))
+
+	var nilFilter *AnomalyFilter
+	assert.True(t, nilFilter.Allow("BTCUSDT", 1, now))
+}
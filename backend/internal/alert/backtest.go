@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/pricehistory"
+)
+
+// MaxBacktestTriggers caps the triggers a backtest reports
+const MaxBacktestTriggers = 500
+
+// Points replayed between checks for a cancelled context
+const backtestCtxCheckInterval = 1024
+
+// ErrBacktestUnsupported is returned for alert types that depend on data
+// price history does not have (volume, market cap)
+var ErrBacktestUnsupported = errors.New("alert type cannot be backtested")
+
+// BacktestTrigger is a moment a replayed alert would have fired
+type BacktestTrigger struct {
+	Time  time.Time
+	Price float64
+}
+
+// BacktestResult is the outcome of replaying an alert over price history
+type BacktestResult struct {
+	Triggers  []BacktestTrigger
+	Evaluated int  // points the alert was evaluated at
+	Truncated bool // more than MaxBacktestTriggers triggers
+}
+
+// Backtest replays an alert over price history, oldest point first, with
+// the engine's rules: schedule windows, re-arming once the condition
+// clears, periodic cooldowns and pausing one-shot alerts after they fire
+func Backtest(ctx context.Context, alert Alert, points []pricehistory.Point, logger *slog.Logger) (*BacktestResult, error) {
+	switch alert.AlertType {
+	case AlertTypePriceAbove, AlertTypePriceBelow, AlertTypePriceChangePct, AlertTypePeriodic:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrBacktestUnsupported, alert.AlertType)
+	}
+
+	replay := &replayHistory{points: points}
+	evaluator := &Evaluator{
+		history: replay,
+		clock:   replay,
+		logger:  logger,
+	}
+
+	alert.IsPaused = false
+	alert.TriggerState = TriggerStateArmed
+	alert.LastTriggeredAt = nil
+	alert.TimesTriggered = 0
+
+	result := &BacktestResult{Triggers: []BacktestTrigger{}}
+	for i, point := range points {
+		if i%backtestCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		replay.index = i
+		// Zero until the history covers a day
+		changePercent, _ := replay.change(24 * time.Hour)
+		data := &binance.PriceData{
+			Symbol:        alert.BinanceSymbol,
+			Price:         point.Price,
+			ChangePercent: changePercent,
+		}
+
+		rearm, err := evaluator.ShouldRearm(ctx, &alert, data)
+		if err != nil {
+			return nil, err
+		}
+		if rearm {
+			alert.TriggerState = TriggerStateArmed
+		}
+
+		if !alert.Schedule.Active(point.Time, alert.Location) {
+			continue
+		}
+		result.Evaluated++
+
+		event, err := evaluator.Evaluate(ctx, &alert, data)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			continue
+		}
+
+		if len(result.Triggers) == MaxBacktestTriggers {
+			result.Truncated = true
+			break
+		}
+		result.Triggers = append(result.Triggers, BacktestTrigger{Time: point.Time, Price: point.Price})
+
+		at := point.Time
+		alert.TimesTriggered++
+		alert.LastTriggeredAt = &at
+		alert.TriggerState = alert.stateAfterTrigger()
+		if !alert.IsRecurring && alert.PeriodicInterval == "" {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// replayHistory serves MarketHistory from stored points as of the point
+// being replayed
+type replayHistory struct {
+	points []pricehistory.Point
+	index  int
+}
+
+// Now is the time of the point being replayed
+func (r *replayHistory) Now() time.Time {
+	return r.points[r.index].Time
+}
+
+// change returns the percent change from the price duration ago,
+// interpolated between the points either side like the live price cache.
+// Fails with cache.ErrInsufficientHistory when history is shorter
+func (r *replayHistory) change(duration time.Duration) (float64, error) {
+	target := r.Now().Add(-duration)
+	// First point after target; the one before it is at or before target
+	i := sort.Search(r.index+1, func(i int) bool { return r.points[i].Time.After(target) })
+	if i == 0 {
+		return 0, cache.ErrInsufficientHistory
+	}
+
+	before, after := r.points[i-1], r.points[i]
+	old := before.Price
+	if gap := after.Time.Sub(before.Time); gap > 0 {
+		old += (after.Price - before.Price) * float64(target.Sub(before.Time)) / float64(gap)
+	}
+	if old <= 0 {
+		return 0, cache.ErrInsufficientHistory
+	}
+	return (r.points[r.index].Price - old) / old * 100, nil
+}
+
+// GetPriceChange implements MarketHistory
+func (r *replayHistory) GetPriceChange(_ context.Context, _ string, duration time.Duration) (float64, error) {
+	return r.change(duration)
+}
+
+// GetAverageVolume implements MarketHistory; price history has no volume
+func (r *replayHistory) GetAverageVolume(context.Context, string, time.Duration) (float64, error) {
+	return 0, ErrBacktestUnsupported
+}
+
+// GetVolumeChange implements MarketHistory; price history has no volume
+func (r *replayHistory) GetVolumeChange(context.Context, string, time.Duration) (float64, error) {
+	return 0, ErrBacktestUnsupported
+}
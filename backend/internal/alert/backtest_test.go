@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/pricehistory"
+)
+
+// minutePoints returns one point per minute starting at start
+func minutePoints(start time.Time, prices ...float64) []pricehistory.Point {
+	points := make([]pricehistory.Point, len(prices))
+	for i, p := range prices {
+		points[i] = pricehistory.Point{Time: start.Add(time.Duration(i) * time.Minute), Price: p}
+	}
+	return points
+}
+
+func TestBacktest(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	points := minutePoints(start, 90, 101, 105, 95, 99, 102, 98)
+
+	// Recurring: fires on each crossing once the price dropped back below
+	result, err := Backtest(ctx, Alert{AlertType: AlertTypePriceAbove, ConditionValue: 100, IsRecurring: true}, points, logger)
+	require.NoError(t, err)
+	require.Len(t, result.Triggers, 2)
+	assert.Equal(t, BacktestTrigger{Time: start.Add(time.Minute), Price: 101}, result.Triggers[0])
+	assert.Equal(t, start.Add(5*time.Minute), result.Triggers[1].Time)
+	assert.Equal(t, 7, result.Evaluated)
+
+	// One-shot: stops after the first trigger
+	result, err = Backtest(ctx, Alert{AlertType: AlertTypePriceBelow, ConditionValue: 99}, points, logger)
+	require.NoError(t, err)
+	require.Len(t, result.Triggers, 1)
+	assert.Equal(t, 90.0, result.Triggers[0].Price)
+	assert.Equal(t, 1, result.Evaluated)
+
+	// Percent change over the timeframe, once the history covers it
+	tf := Alert{AlertType: AlertTypePriceChangePct, ConditionValue: 10, ConditionTimeframe: "5m"}
+	result, err = Backtest(ctx, tf, points, logger)
+	require.NoError(t, err)
+	require.Len(t, result.Triggers, 1)
+	assert.Equal(t, start.Add(5*time.Minute), result.Triggers[0].Time)
+
+	// Periodic: once per interval
+	prices := make([]float64, 180)
+	for i := range prices {
+		prices[i] = 100
+	}
+	result, err = Backtest(ctx, Alert{AlertType: AlertTypePeriodic, PeriodicInterval: "1h"}, minutePoints(start, prices...), logger)
+	require.NoError(t, err)
+	assert.Len(t, result.Triggers, 3)
+
+	_, err = Backtest(ctx, Alert{AlertType: AlertTypeVolumeSpike}, points, logger)
+	assert.ErrorIs(t, err, ErrBacktestUnsupported)
+}
@@ -0,0 +1,305 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+const (
+	// Event bus topic on which bursts of notifications are announced
+	alertBurstChannel = "alert:bursts"
+
+	// Trigger events published at once, then every DefaultBurstInterval
+	// while more are queued: 200 per second
+	DefaultBurstChunkSize = 100
+	DefaultBurstInterval  = 500 * time.Millisecond
+
+	// Bounds publishing the events left at shutdown
+	burstFlushTimeout = 10 * time.Second
+)
+
+// BurstAnnouncement tells consumers that a burst of notifications is on
+// its way and how fast it will arrive, so they can scale before it does
+type BurstAnnouncement struct {
+	Events     int            `json:"events"`
+	Symbols    map[string]int `json:"symbols"` // queued events per coin
+	RatePerSec float64        `json:"rate_per_sec"`
+	DurationMs int64          `json:"duration_ms"` // expected time to publish them all
+	StartedAt  time.Time      `json:"started_at"`
+}
+
+// BurstStats are the pacer's queue metrics since the engine started
+type BurstStats struct {
+	QueueDepth    int        `json:"queue_depth"`
+	MaxQueueDepth int        `json:"max_queue_depth"`
+	Published     int64      `json:"published"`
+	Failed        int64      `json:"failed"`
+	Bursts        int64      `json:"bursts"`
+	InBurst       bool       `json:"in_burst"`
+	LastBurstAt   *time.Time `json:"last_burst_at,omitempty"`
+	LastBurstSize int        `json:"last_burst_size"`
+	ChunkSize     int        `json:"chunk_size"`
+	IntervalMs    int64      `json:"interval_ms"`
+}
+
+// BurstPacer smooths the publishing of trigger events. A single tick can
+// fire thousands of alerts sharing a threshold; instead of publishing
+// them at once, the pacer publishes them in chunks of chunkSize every
+// interval and announces the burst on the event bus before it starts.
+// Fewer than chunkSize events are published without delay
+type BurstPacer struct {
+	publish func(ctx context.Context, event *TriggerEvent) error
+	bus     eventbus.Publisher
+	logger  *slog.Logger
+
+	chunkSize int
+	interval  time.Duration
+	queue     []*TriggerEvent
+	maxDepth  int
+	inBurst   bool
+	lastBurst *BurstAnnouncement
+	mu        sync.Mutex
+
+	wake      chan struct{}
+	published atomic.Int64
+	failed    atomic.Int64
+	bursts    atomic.Int64
+}
+
+// NewBurstPacer creates a pacer handing events to publish, typically
+// Publisher.Publish, and announcing bursts on bus
+func NewBurstPacer(publish func(ctx context.Context, event *TriggerEvent) error, bus eventbus.Publisher, logger *slog.Logger) *BurstPacer {
+	return &BurstPacer{
+		publish:   publish,
+		bus:       bus,
+		logger:    logger,
+		chunkSize: DefaultBurstChunkSize,
+		interval:  DefaultBurstInterval,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// SetPace sets how many events are published per interval; non-positive
+// values keep the current setting
+func (p *BurstPacer) SetPace(chunkSize int, interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if chunkSize > 0 {
+		p.chunkSize = chunkSize
+	}
+	if interval > 0 {
+		p.interval = interval
+	}
+}
+
+// Enqueue queues a trigger event for publishing. It never blocks, so it
+// can be the engine's trigger handler
+func (p *BurstPacer) Enqueue(event *TriggerEvent) {
+	p.mu.Lock()
+	p.queue = append(p.queue, event)
+	p.maxDepth = max(p.maxDepth, len(p.queue))
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes queued events until ctx is done
+func (p *BurstPacer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+			p.drain(ctx)
+		}
+	}
+}
+
+// Flush publishes the events still queued without pacing. Call it after
+// Run has returned
+func (p *BurstPacer) Flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), burstFlushTimeout)
+	defer cancel()
+
+	for {
+		chunk := p.take(math.MaxInt)
+		if len(chunk) == 0 {
+			return
+		}
+		p.logger.Info("publishing queued notifications before shutdown", slog.Int("events", len(chunk)))
+		p.publishChunk(ctx, chunk)
+	}
+}
+
+// drain publishes queued events a chunk at a time until the queue is
+// empty, announcing a burst when more than a chunk is waiting
+func (p *BurstPacer) drain(ctx context.Context) {
+	var started time.Time
+	var size int
+	for {
+		if announcement := p.startBurst(); announcement != nil {
+			started, size = announcement.StartedAt, announcement.Events
+			p.announce(ctx, announcement)
+		}
+
+		chunk := p.take(0)
+		if len(chunk) == 0 {
+			break
+		}
+		p.publishChunk(ctx, chunk)
+
+		if p.Depth() == 0 {
+			break
+		}
+
+		_, interval := p.pace()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+
+	if p.endBurst() {
+		p.logger.Info("notification burst published",
+			slog.Int("events", size),
+			slog.Duration("duration", time.Since(started)),
+		)
+	}
+}
+
+// startBurst returns the announcement of a burst when more than a chunk
+// of events is queued and none is in progress
+func (p *BurstPacer) startBurst() *BurstAnnouncement {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.inBurst || len(p.queue) <= p.chunkSize {
+		return nil
+	}
+
+	symbols := make(map[string]int)
+	for _, event := range p.queue {
+		symbols[event.CoinSymbol]++
+	}
+	chunks := (len(p.queue) + p.chunkSize - 1) / p.chunkSize
+	announcement := &BurstAnnouncement{
+		Events:     len(p.queue),
+		Symbols:    symbols,
+		RatePerSec: float64(p.chunkSize) / p.interval.Seconds(),
+		DurationMs: (time.Duration(chunks-1) * p.interval).Milliseconds(),
+		StartedAt:  time.Now(),
+	}
+
+	p.inBurst = true
+	p.lastBurst = announcement
+	p.bursts.Add(1)
+	return announcement
+}
+
+// endBurst reports whether a burst was in progress and ends it
+func (p *BurstPacer) endBurst() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inBurst := p.inBurst
+	p.inBurst = false
+	return inBurst
+}
+
+// announce publishes a burst announcement; failures only lose the heads-up
+func (p *BurstPacer) announce(ctx context.Context, announcement *BurstAnnouncement) {
+	p.logger.Warn("notification burst, pacing publishes",
+		slog.Int("events", announcement.Events),
+		slog.Float64("rate_per_sec", announcement.RatePerSec),
+		slog.Int64("duration_ms", announcement.DurationMs),
+	)
+
+	data, err := json.Marshal(announcement)
+	if err != nil {
+		return
+	}
+	if err := p.bus.Publish(ctx, alertBurstChannel, data); err != nil {
+		p.logger.Error("failed to announce notification burst", slog.String("error", err.Error()))
+	}
+}
+
+// take removes up to n queued events, a chunk when n is 0
+func (p *BurstPacer) take(n int) []*TriggerEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n == 0 {
+		n = p.chunkSize
+	}
+	n = min(n, len(p.queue))
+	chunk := p.queue[:n:n]
+	p.queue = p.queue[n:]
+	if len(p.queue) == 0 {
+		// Let go of the backing array once a burst is published
+		p.queue = nil
+	}
+	return chunk
+}
+
+// publishChunk publishes events; failed publishes are already queued for
+// retry by the publisher
+func (p *BurstPacer) publishChunk(ctx context.Context, chunk []*TriggerEvent) {
+	for _, event := range chunk {
+		if err := p.publish(ctx, event); err != nil {
+			p.failed.Add(1)
+			p.logger.Error("failed to publish trigger event",
+				slog.Int64("alert_id", event.AlertID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		p.published.Add(1)
+	}
+}
+
+// pace returns the current chunk size and interval
+func (p *BurstPacer) pace() (int, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.chunkSize, p.interval
+}
+
+// Depth returns the number of events waiting to be published
+func (p *BurstPacer) Depth() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+// Stats returns the queue metrics
+func (p *BurstPacer) Stats() BurstStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := BurstStats{
+		QueueDepth:    len(p.queue),
+		MaxQueueDepth: p.maxDepth,
+		Published:     p.published.Load(),
+		Failed:        p.failed.Load(),
+		Bursts:        p.bursts.Load(),
+		InBurst:       p.inBurst,
+		ChunkSize:     p.chunkSize,
+		IntervalMs:    p.interval.Milliseconds(),
+	}
+	if p.lastBurst != nil {
+		stats.LastBurstAt = &p.lastBurst.StartedAt
+		stats.LastBurstSize = p.lastBurst.Events
+	}
+	return stats
+}
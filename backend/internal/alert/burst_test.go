@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBus records what is published on it
+type recordingBus struct {
+	mu       sync.Mutex
+	messages map[string][][]byte
+}
+
+func (b *recordingBus) Publish(_ context.Context, topic string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.messages == nil {
+		b.messages = make(map[string][][]byte)
+	}
+	b.messages[topic] = append(b.messages[topic], data)
+	return nil
+}
+
+func (b *recordingBus) topic(topic string) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.messages[topic]
+}
+
+func newTestPacer(t *testing.T) (*BurstPacer, *recordingBus, func() []time.Time) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var publishedAt []time.Time
+	publish := func(_ context.Context, _ *TriggerEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		publishedAt = append(publishedAt, time.Now())
+		return nil
+	}
+	published := func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), publishedAt...)
+	}
+
+	bus := &recordingBus{}
+	return NewBurstPacer(publish, bus, slog.New(slog.NewTextHandler(io.Discard, nil))), bus, published
+}
+
+func TestBurstPacer_SmallBatchPublishedAtOnce(t *testing.T) {
+	pacer, bus, published := newTestPacer(t)
+	pacer.SetPace(10, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pacer.Run(ctx)
+
+	for i := 0; i < 10; i++ {
+		pacer.Enqueue(&TriggerEvent{AlertID: int64(i), CoinSymbol: "BTC"})
+	}
+
+	require.Eventually(t, func() bool { return len(published()) == 10 }, time.Second, 5*time.Millisecond)
+	assert.Empty(t, bus.topic(alertBurstChannel), "a chunk is not a burst")
+	assert.Equal(t, int64(0), pacer.Stats().Bursts)
+}
+
+func TestBurstPacer_BurstIsAnnouncedAndPaced(t *testing.T) {
+	pacer, bus, published := newTestPacer(t)
+	pacer.SetPace(10, 50*time.Millisecond)
+
+	// Queued before Run so the whole burst is seen at once
+	for i := 0; i < 25; i++ {
+		pacer.Enqueue(&TriggerEvent{AlertID: int64(i), CoinSymbol: "BTC"})
+	}
+	pacer.Enqueue(&TriggerEvent{AlertID: 25, CoinSymbol: "ETH"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pacer.Run(ctx)
+
+	require.Eventually(t, func() bool { return len(published()) == 26 }, 2*time.Second, 5*time.Millisecond)
+
+	times := published()
+	assert.GreaterOrEqual(t, times[10].Sub(times[9]), 50*time.Millisecond, "second chunk waits an interval")
+	assert.GreaterOrEqual(t, times[20].Sub(times[19]), 50*time.Millisecond, "third chunk waits an interval")
+
+	announcements := bus.topic(alertBurstChannel)
+	require.Len(t, announcements, 1)
+	var announcement BurstAnnouncement
+	require.NoError(t, json.Unmarshal(announcements[0], &announcement))
+	assert.Equal(t, 26, announcement.Events)
+	assert.Equal(t, map[string]int{"BTC": 25, "ETH": 1}, announcement.Symbols)
+	assert.InDelta(t, 200, announcement.RatePerSec, 0.001)
+	assert.Equal(t, int64(100), announcement.DurationMs)
+
+	require.Eventually(t, func() bool { return !pacer.Stats().InBurst }, time.Second, 5*time.Millisecond)
+	stats := pacer.Stats()
+	assert.Equal(t, 0, stats.QueueDepth)
+	assert.Equal(t, 26, stats.MaxQueueDepth)
+	assert.Equal(t, int64(26), stats.Published)
+	assert.Equal(t, int64(1), stats.Bursts)
+	assert.Equal(t, 26, stats.LastBurstSize)
+}
+
+func TestBurstPacer_FlushPublishesEverything(t *testing.T) {
+	pacer, _, published := newTestPacer(t)
+	pacer.SetPace(10, time.Hour)
+
+	for i := 0; i < 35; i++ {
+		pacer.Enqueue(&TriggerEvent{AlertID: int64(i)})
+	}
+	pacer.Flush()
+
+	assert.Len(t, published(), 35)
+	assert.Equal(t, 0, pacer.Depth())
+}
@@ -9,6 +9,13 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/weqory/backend/internal/binance"
 	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/gas"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/internal/whale"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/clock"
+	"github.com/weqory/backend/pkg/eventbus"
+	"github.com/weqory/backend/pkg/schedule"
 )
 
 const (
@@ -25,24 +32,62 @@ const (
 // TriggerHandler handles triggered alert events
 type TriggerHandler func(event *TriggerEvent)
 
+// Sink mirrors accepted price ticks and trigger events to an external
+// system. Both methods are called on the hot path and must not block
+type Sink interface {
+	Tick(data binance.PriceData)
+	Trigger(event *TriggerEvent)
+}
+
 // Engine is the main alert processing engine
 type Engine struct {
 	pool           *pgxpool.Pool
 	binanceClient  *binance.Client
 	priceCache     *cache.PriceCache
 	pricePublisher *PricePublisher
+	fallbackPoller *FallbackPoller
+	whalePoller    *WhalePoller
+	gasPoller      *GasPoller
+	anomalyFilter  *AnomalyFilter
 	evaluator      *Evaluator
 	triggerHandler TriggerHandler
+	historyStore   *pricehistory.Store
+	sink           Sink
+	updates        *UpdatePublisher
+	clock          clock.Clock
 	logger         *slog.Logger
 
 	alerts       map[int64]*Alert
 	symbolAlerts map[string][]*Alert // symbol -> alerts
+	whaleAlerts  map[string][]*Alert // coin symbol -> whale transfer alerts
+	gasAlerts    []*Alert
 	mu           sync.RWMutex
 
+	// Symbols subscribed on Binance: those with alerts and the most
+	// watched watchlist coins, up to watchlistLimit
+	streamed         map[string]bool
+	watchlistSymbols map[string]bool
+	watchlistLimit   int
+
+	// Symbols WebSocket clients view, announced per api-gateway instance
+	demandBus eventbus.Subscriber
+	demand    map[string]demandLease
+	demandMu  sync.Mutex
+
+	// Serializes periodic and forced refreshes
+	refreshMu sync.Mutex
+
 	priceBuffer     map[string]*binance.PriceData
 	priceBufferMu   sync.RWMutex
 	lastHistorySave time.Time
 
+	// Last evaluated price per alert, flushed to the database on refresh
+	watermarks   map[int64]float64
+	watermarksMu sync.Mutex
+
+	// Stream tier of each symbol, hotter as its alerts near their threshold
+	tiers *streamTiers
+
 	done chan struct{}
 	wg   sync.WaitGroup
 	ctx  context.Context
@@ -62,10 +107,17 @@ func NewEngine(
 		priceCache:     priceCache,
 		pricePublisher: pricePublisher,
 		evaluator:      NewEvaluator(priceCache, logger),
+		clock:          clock.Real{},
 		logger:         logger,
 		alerts:         make(map[int64]*Alert),
 		symbolAlerts:   make(map[string][]*Alert),
+		whaleAlerts:    make(map[string][]*Alert),
+		streamed:       make(map[string]bool),
+		demand:         make(map[string]demandLease),
+		watchlistLimit: DefaultWatchlistSymbolLimit,
 		priceBuffer:    make(map[string]*binance.PriceData),
+		watermarks:     make(map[int64]float64),
+		tiers:          newStreamTiers(),
 		done:           make(chan struct{}),
 	}
 }
@@ -75,6 +127,48 @@ func (e *Engine) SetTriggerHandler(handler TriggerHandler) {
 	e.triggerHandler = handler
 }
 
+// SetFallbackPoller sets the CoinGecko poller used for coins without a Binance pair
+func (e *Engine) SetFallbackPoller(poller *FallbackPoller) {
+	e.fallbackPoller = poller
+}
+
+// SetWhalePoller sets the poller feeding on-chain transfers to whale alerts
+func (e *Engine) SetWhalePoller(poller *WhalePoller) {
+	e.whalePoller = poller
+}
+
+// SetGasPoller sets the poller feeding the Ethereum gas price to gas alerts
+func (e *Engine) SetGasPoller(poller *GasPoller) {
+	e.gasPoller = poller
+}
+
+// SetAnomalyFilter sets the filter that drops suspicious price ticks
+func (e *Engine) SetAnomalyFilter(filter *AnomalyFilter) {
+	e.anomalyFilter = filter
+}
+
+// SetHistoryStore sets the durable store the history loop also writes to
+func (e *Engine) SetHistoryStore(store *pricehistory.Store) {
+	e.historyStore = store
+}
+
+// SetSink sets the sink that ticks and trigger events are mirrored to
+func (e *Engine) SetSink(sink Sink) {
+	e.sink = sink
+}
+
+// SetUpdatePublisher sets the publisher announcing triggered alerts to
+// the owner's open Mini App sessions
+func (e *Engine) SetUpdatePublisher(p *UpdatePublisher) {
+	e.updates = p
+}
+
+// SetClock sets the clock the engine and its evaluator tell time by
+func (e *Engine) SetClock(c clock.Clock) {
+	e.clock = c
+	e.evaluator.clock = c
+}
+
 // Run starts the alert engine
 func (e *Engine) Run(ctx context.Context) error {
 	e.logger.Info("starting alert engine")
@@ -89,11 +183,46 @@ func (e *Engine) Run(ctx context.Context) error {
 
 	// Subscribe to price updates
 	e.binanceClient.SetPriceHandler(e.handlePriceUpdate)
+	e.binanceClient.SetTradeHandler(e.handleTrade)
+	e.binanceClient.SetBookTickerHandler(e.handleBookTicker)
 
 	// Start background tasks
-	e.wg.Add(2)
+	e.wg.Add(3)
 	go e.alertRefreshLoop(ctx)
 	go e.priceHistoryLoop(ctx)
+	go e.priceCacheLoop(ctx)
+
+	if e.demandBus != nil {
+		e.wg.Add(1)
+		go e.demandLoop(ctx)
+	}
+
+	if e.fallbackPoller != nil {
+		e.fallbackPoller.SetPriceHandler(e.handlePriceUpdate)
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.fallbackPoller.Run(ctx)
+		}()
+	}
+
+	if e.whalePoller != nil {
+		e.whalePoller.SetTransferHandler(e.handleWhaleTransfer)
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.whalePoller.Run(ctx)
+		}()
+	}
+
+	if e.gasPoller != nil {
+		e.gasPoller.SetPriceHandler(e.handleGasPrice)
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.gasPoller.Run(ctx)
+		}()
+	}
 
 	// Start Binance client
 	if err := e.binanceClient.Run(ctx); err != nil {
@@ -118,36 +247,82 @@ func (e *Engine) handlePriceUpdate(data binance.PriceData) {
 	default:
 	}
 
-	// Update price cache
-	if err := e.priceCache.Set(ctx, data); err != nil {
-		e.logger.Error("failed to cache price",
-			slog.String("symbol", data.Symbol),
-			slog.String("error", err.Error()),
-		)
+	// Drop bogus ticks before they reach the cache or trigger alerts
+	if !e.anomalyFilter.Allow(data.Symbol, data.Price, e.clock.Now()) {
+		return
 	}
 
-	// Publish price update to WebSocket clients via Redis pub/sub
+	e.processPrice(ctx, data)
+}
+
+// processPrice caches, publishes and buffers a ticker for history and
+// evaluates the alerts of its symbol
+func (e *Engine) processPrice(ctx context.Context, data binance.PriceData) {
+	// Update price cache, written to Redis by priceCacheLoop
+	e.priceCache.Buffer(data)
+
+	// Publish price update to WebSocket clients via the event bus
 	if e.pricePublisher != nil {
 		e.pricePublisher.Publish(ctx, data)
 	}
 
+	if e.sink != nil {
+		e.sink.Tick(data)
+	}
+
 	// Buffer price for history saving
 	e.priceBufferMu.Lock()
 	e.priceBuffer[data.Symbol] = &data
 	e.priceBufferMu.Unlock()
 
-	// Get alerts for this symbol - make a copy to avoid holding lock
+	alerts := e.alertsForSymbol(data.Symbol)
+
+	// Stream symbols whose alerts are close to firing at a higher frequency
+	e.updateStreamTier(data, alerts)
+
+	e.evaluatePrice(ctx, data, alerts)
+}
+
+// alertsForSymbol returns copies of the alerts of a symbol, so they can be
+// evaluated without holding the lock
+func (e *Engine) alertsForSymbol(symbol string) []*Alert {
 	e.mu.RLock()
-	alertsForSymbol := e.symbolAlerts[data.Symbol]
-	// Deep copy the alert slice to avoid race conditions
+	defer e.mu.RUnlock()
+
+	alertsForSymbol := e.symbolAlerts[symbol]
 	alerts := make([]*Alert, len(alertsForSymbol))
 	for i, alert := range alertsForSymbol {
 		// Create a copy of the alert to avoid concurrent modification
 		alertCopy := *alert
 		alerts[i] = &alertCopy
 	}
-	e.mu.RUnlock()
+	return alerts
+}
 
+// evaluatePrice re-arms and evaluates alerts against a price and processes
+// the trigger events
+func (e *Engine) evaluatePrice(ctx context.Context, data binance.PriceData, alerts []*Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	e.watermarksMu.Lock()
+	for _, alert := range alerts {
+		e.watermarks[alert.ID] = data.Price
+	}
+	e.watermarksMu.Unlock()
+
+	// Re-arm fired alerts whose condition has cleared
+	for _, alert := range alerts {
+		rearm, err := e.evaluator.ShouldRearm(ctx, alert, &data)
+		if err != nil || !rearm {
+			continue
+		}
+		e.rearmAlert(ctx, alert.ID, data.Price)
+	}
+
+	// Alerts outside their schedule windows are not evaluated
+	alerts = scheduledAlerts(alerts, e.clock.Now())
 	if len(alerts) == 0 {
 		return
 	}
@@ -166,23 +341,117 @@ func (e *Engine) handlePriceUpdate(data binance.PriceData) {
 	}
 }
 
+// handleWhaleTransfer evaluates the whale alerts of a transfer's coin
+func (e *Engine) handleWhaleTransfer(transfer whale.Transfer) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	e.mu.RLock()
+	alertsForCoin := e.whaleAlerts[transfer.CoinSymbol()]
+	alerts := make([]*Alert, len(alertsForCoin))
+	for i, alert := range alertsForCoin {
+		alertCopy := *alert
+		alerts[i] = &alertCopy
+	}
+	e.mu.RUnlock()
+
+	for _, alert := range scheduledAlerts(alerts, e.clock.Now()) {
+		if event := e.evaluator.EvaluateTransfer(alert, transfer); event != nil {
+			e.processTriggerEvent(ctx, event)
+		}
+	}
+}
+
+// handleGasPrice re-arms and evaluates the gas alerts for a gas price
+func (e *Engine) handleGasPrice(price gas.Price) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	e.mu.RLock()
+	alerts := make([]*Alert, len(e.gasAlerts))
+	for i, alert := range e.gasAlerts {
+		alertCopy := *alert
+		alerts[i] = &alertCopy
+	}
+	e.mu.RUnlock()
+
+	for _, alert := range alerts {
+		if e.evaluator.ShouldRearmGas(alert, price) {
+			e.rearmAlert(ctx, alert.ID, price.StandardGwei)
+		}
+	}
+
+	for _, alert := range scheduledAlerts(alerts, e.clock.Now()) {
+		if event := e.evaluator.EvaluateGas(alert, price); event != nil {
+			e.processTriggerEvent(ctx, event)
+		}
+	}
+}
+
+// scheduledAlerts returns the alerts whose schedule is active at now,
+// reusing the slice
+func scheduledAlerts(alerts []*Alert, now time.Time) []*Alert {
+	active := alerts[:0]
+	for _, alert := range alerts {
+		if alert.Schedule.Active(now, alert.Location) {
+			active = append(active, alert)
+		}
+	}
+	return active
+}
+
 // processTriggerEvent handles a triggered alert
 func (e *Engine) processTriggerEvent(ctx context.Context, event *TriggerEvent) {
-	e.logger.Info("alert triggered",
-		slog.Int64("alert_id", event.AlertID),
-		slog.Int64("user_id", event.UserID),
-		slog.String("symbol", event.CoinSymbol),
-		slog.Float64("price", event.TriggeredPrice),
-	)
+	e.mu.RLock()
+	alert, ok := e.alerts[event.AlertID]
+	var expected Alert
+	if ok {
+		expected = *alert
+	}
+	e.mu.RUnlock()
+
+	if !ok {
+		return
+	}
 
-	// Update alert in database
-	if err := e.markAlertTriggered(ctx, event.AlertID); err != nil {
+	// Persist the trigger before notifying; a conflicting watermark means
+	// another engine instance (or a previous run) already fired this alert.
+	// Nothing is sent when the write fails: another instance may still fire
+	// it, and the alert stays armed here, so the next tick tries again
+	fired, err := e.markAlertTriggered(ctx, &expected, event)
+	if err != nil {
 		e.logger.Error("failed to mark alert triggered",
 			slog.Int64("alert_id", event.AlertID),
 			slog.String("error", err.Error()),
 		)
+		return
+	}
+	if !fired {
+		e.logger.Info("skipping duplicate trigger",
+			slog.Int64("alert_id", event.AlertID),
+			slog.String("symbol", event.CoinSymbol),
+		)
+		return
 	}
 
+	e.logger.Info("alert triggered",
+		slog.Int64("alert_id", event.AlertID),
+		slog.Int64("user_id", event.UserID),
+		slog.String("symbol", event.CoinSymbol),
+		slog.Float64("price", event.TriggeredPrice),
+		slog.String("request_id", event.RequestID),
+	)
+
 	// Create history record
 	if err := e.createHistoryRecord(ctx, event); err != nil {
 		e.logger.Error("failed to create history record",
@@ -192,23 +461,70 @@ func (e *Engine) processTriggerEvent(ctx context.Context, event *TriggerEvent) {
 	}
 
 	// Update local alert state
+	update := AlertUpdate{
+		AlertID:         event.AlertID,
+		UserID:          event.UserID,
+		Reason:          UpdateTriggered,
+		IsPaused:        expected.pauseAfterTrigger(),
+		TimesTriggered:  expected.TimesTriggered + 1,
+		LastTriggeredAt: &event.TriggeredAt,
+		TriggeredPrice:  &event.TriggeredPrice,
+		UpdatedAt:       e.clock.Now(),
+		RequestID:       event.RequestID,
+	}
 	e.mu.Lock()
 	if alert, ok := e.alerts[event.AlertID]; ok {
-		alert.TimesTriggered++
-		now := time.Now()
-		alert.LastTriggeredAt = &now
-
-		// If non-recurring, pause the alert
-		if !alert.IsRecurring && alert.PeriodicInterval == "" {
+		// Checked before counting the trigger
+		if alert.pauseAfterTrigger() {
 			alert.IsPaused = true
 		}
+
+		alert.TimesTriggered++
+		alert.LastTriggeredAt = &event.TriggeredAt
+		alert.LastEvaluatedPrice = &event.TriggeredPrice
+		alert.TriggerState = alert.stateAfterTrigger()
+		update.IsPaused, update.TimesTriggered = alert.IsPaused, alert.TimesTriggered
 	}
 	e.mu.Unlock()
 
+	if e.updates != nil {
+		e.updates.Publish(ctx, update)
+	}
+
 	// Call trigger handler
 	if e.triggerHandler != nil {
 		e.triggerHandler(event)
 	}
+
+	if e.sink != nil {
+		e.sink.Trigger(event)
+	}
+}
+
+// rearmAlert persists and applies the armed state for a fired alert
+func (e *Engine) rearmAlert(ctx context.Context, alertID int64, price float64) {
+	_, err := e.pool.Exec(ctx, `
+		UPDATE alerts
+		SET trigger_state = 'armed',
+		    last_evaluated_price = $2,
+		    last_evaluated_at = NOW()
+		WHERE id = $1 AND trigger_state = 'fired'
+	`, alertID, price)
+	if err != nil {
+		e.logger.Error("failed to re-arm alert",
+			slog.Int64("alert_id", alertID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	e.mu.Lock()
+	if alert, ok := e.alerts[alertID]; ok {
+		alert.TriggerState = TriggerStateArmed
+	}
+	e.mu.Unlock()
+
+	e.logger.Debug("alert re-armed", slog.Int64("alert_id", alertID))
 }
 
 // alertRefreshLoop periodically refreshes alerts from database
@@ -251,6 +567,28 @@ func (e *Engine) priceHistoryLoop(ctx context.Context) {
 	}
 }
 
+// priceCacheLoop writes the buffered prices to the price cache in batches,
+// one pipeline per interval instead of a write per tick
+func (e *Engine) priceCacheLoop(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(cache.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.done:
+			return
+		case <-ticker.C:
+			if err := e.priceCache.Flush(ctx); err != nil {
+				e.logger.Error("failed to flush price cache", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
 // saveAllPriceHistory saves buffered prices to history
 func (e *Engine) saveAllPriceHistory(ctx context.Context) {
 	e.priceBufferMu.Lock()
@@ -258,7 +596,7 @@ func (e *Engine) saveAllPriceHistory(ctx context.Context) {
 	e.priceBuffer = make(map[string]*binance.PriceData)
 	e.priceBufferMu.Unlock()
 
-	now := time.Now()
+	now := e.clock.Now()
 	for symbol, data := range prices {
 		if err := e.priceCache.AddToHistory(ctx, symbol, data.Price, now); err != nil {
 			e.logger.Error("failed to save price history",
@@ -268,19 +606,44 @@ func (e *Engine) saveAllPriceHistory(ctx context.Context) {
 		}
 	}
 
+	if e.historyStore != nil {
+		points := make(map[string]float64, len(prices))
+		for symbol, data := range prices {
+			points[symbol] = data.Price
+		}
+		if err := e.historyStore.Save(ctx, now, points); err != nil {
+			e.logger.Error("failed to persist price history",
+				slog.Int("symbols", len(points)),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
 	e.lastHistorySave = now
 }
 
 // refreshAlerts loads/refreshes alerts from database
 func (e *Engine) refreshAlerts(ctx context.Context) error {
+	e.refreshMu.Lock()
+	defer e.refreshMu.Unlock()
+
+	// Flush watermarks first so the reload does not roll them back
+	e.flushWatermarks(ctx)
+
 	query := `
-		SELECT a.id, a.user_id, c.symbol, c.binance_symbol, a.alert_type,
-		       a.condition_operator, a.condition_value, a.condition_timeframe,
-		       a.is_recurring, a.is_paused, a.periodic_interval, a.times_triggered,
-		       a.last_triggered_at, a.price_when_created, a.created_at
+		SELECT a.id, a.user_id, c.symbol, c.binance_symbol, c.coingecko_id, a.alert_type,
+		       a.condition_operator, a.condition_value, COALESCE(a.condition_timeframe, ''),
+		       a.is_recurring, a.is_paused, COALESCE(a.periodic_interval, ''), a.times_triggered,
+		       a.last_triggered_at, COALESCE(a.price_when_created, 0), a.created_at,
+		       a.trigger_state, a.last_evaluated_price, a.priority,
+		       a.schedule, COALESCE(a.name, ''), a.expires_at, a.max_triggers, u.timezone,
+		       a.pair_symbol, COALESCE(a.quote_asset, '')
 		FROM alerts a
 		JOIN coins c ON a.coin_id = c.id
-		WHERE a.is_deleted = false AND a.is_paused = false
+		JOIN users u ON a.user_id = u.id
+		WHERE a.is_paused = false
+		  AND (a.expires_at IS NULL OR a.expires_at > NOW())
+		  AND (a.max_triggers IS NULL OR a.times_triggered < a.max_triggers)
 	`
 
 	rows, err := e.pool.Query(ctx, query)
@@ -291,49 +654,138 @@ func (e *Engine) refreshAlerts(ctx context.Context) error {
 
 	newAlerts := make(map[int64]*Alert)
 	newSymbolAlerts := make(map[string][]*Alert)
-	symbols := make(map[string]bool)
+	newWhaleAlerts := make(map[string][]*Alert)
+	var newGasAlerts []*Alert
+	var minWhaleValue float64
+	fallbackIDs := make(map[string]bool)
+	locations := make(map[string]*time.Location)
 
 	for rows.Next() {
 		var alert Alert
-		var binanceSymbol *string
+		var binanceSymbol, coingeckoID, pairSymbol *string
+		var timezone string
 
 		err := rows.Scan(
-			&alert.ID, &alert.UserID, &alert.CoinSymbol, &binanceSymbol,
+			&alert.ID, &alert.UserID, &alert.CoinSymbol, &binanceSymbol, &coingeckoID,
 			&alert.AlertType, &alert.ConditionOperator, &alert.ConditionValue,
 			&alert.ConditionTimeframe, &alert.IsRecurring, &alert.IsPaused,
 			&alert.PeriodicInterval, &alert.TimesTriggered, &alert.LastTriggeredAt,
 			&alert.PriceWhenCreated, &alert.CreatedAt,
+			&alert.TriggerState, &alert.LastEvaluatedPrice, &alert.Priority,
+			&alert.Schedule, &alert.Name, &alert.ExpiresAt, &alert.MaxTriggers, &timezone,
+			&pairSymbol, &alert.QuoteAsset,
 		)
 		if err != nil {
 			e.logger.Error("failed to scan alert", slog.String("error", err.Error()))
 			continue
 		}
 
-		// Use binance_symbol if available, otherwise construct from coin symbol
-		if binanceSymbol != nil && *binanceSymbol != "" {
+		// Alerts in another quote use their own pair. Otherwise use
+		// binance_symbol if available, then CoinGecko polling for coins
+		// not on Binance, otherwise construct from coin symbol
+		if pairSymbol != nil && *pairSymbol != "" {
+			alert.BinanceSymbol = *pairSymbol
+		} else if binanceSymbol != nil && *binanceSymbol != "" {
 			alert.BinanceSymbol = *binanceSymbol
+		} else if coingeckoID != nil && *coingeckoID != "" {
+			alert.BinanceSymbol = FallbackSymbol(*coingeckoID)
+			fallbackIDs[*coingeckoID] = true
 		} else {
 			alert.BinanceSymbol = alert.CoinSymbol + "USDT"
 		}
 
+		if alert.Schedule != nil {
+			loc, ok := locations[timezone]
+			if !ok {
+				loc = schedule.LoadLocation(timezone)
+				locations[timezone] = loc
+			}
+			alert.Location = loc
+		}
+
 		newAlerts[alert.ID] = &alert
+
+		// Alerts on other feeds than the coin's price need no subscription
+		switch alert.AlertType.source() {
+		case alerttype.SourceTransfer:
+			newWhaleAlerts[alert.CoinSymbol] = append(newWhaleAlerts[alert.CoinSymbol], &alert)
+			if minWhaleValue == 0 || alert.ConditionValue < minWhaleValue {
+				minWhaleValue = alert.ConditionValue
+			}
+			continue
+		case alerttype.SourceGas:
+			newGasAlerts = append(newGasAlerts, &alert)
+			continue
+		}
+
 		newSymbolAlerts[alert.BinanceSymbol] = append(newSymbolAlerts[alert.BinanceSymbol], &alert)
-		symbols[alert.BinanceSymbol] = true
 	}
+	rows.Close()
 
-	// Update subscriptions
-	e.mu.Lock()
-	oldSymbols := make(map[string]bool)
-	for symbol := range e.symbolAlerts {
-		oldSymbols[symbol] = true
+	// Coins only on watchlists are streamed too, so the app shows their
+	// live prices
+	watched, err := e.loadWatchlistSymbols(ctx)
+	if err != nil {
+		e.logger.Error("failed to load watchlist symbols", slog.String("error", err.Error()))
+		e.mu.RLock()
+		watched = e.watchlistSymbols
+		e.mu.RUnlock()
 	}
+
+	e.mu.Lock()
+	e.watchlistSymbols = watched
 	e.alerts = newAlerts
 	e.symbolAlerts = newSymbolAlerts
+	e.whaleAlerts = newWhaleAlerts
+	e.gasAlerts = newGasAlerts
+	e.mu.Unlock()
+
+	if e.whalePoller != nil {
+		e.whalePoller.SetMinValue(minWhaleValue)
+	}
+
+	// CoinGecko-polled coins are not streamed from Binance
+	if e.fallbackPoller != nil {
+		ids := make([]string, 0, len(fallbackIDs))
+		for id := range fallbackIDs {
+			ids = append(ids, id)
+		}
+		e.fallbackPoller.SetIDs(ids)
+	}
+
+	e.syncSubscriptions()
+
+	e.logger.Debug("refreshed alerts",
+		slog.Int("count", len(newAlerts)),
+		slog.Int("symbols", e.GetSymbolCount()),
+	)
+
+	return nil
+}
+
+// syncSubscriptions subscribes on Binance to the symbols of alerts,
+// watchlists and WebSocket clients, and unsubscribes from the rest. Must be
+// called with refreshMu held
+func (e *Engine) syncSubscriptions() {
+	symbols := e.demandedSymbols()
+
+	e.mu.Lock()
+	for symbol := range e.symbolAlerts {
+		symbols[symbol] = true
+	}
+	for symbol := range e.watchlistSymbols {
+		symbols[symbol] = true
+	}
+	oldSymbols := e.streamed
+	e.streamed = symbols
 	e.mu.Unlock()
 
 	// Subscribe to new symbols
 	var toSubscribe []string
 	for symbol := range symbols {
+		if isFallbackSymbol(symbol) {
+			continue
+		}
 		if !oldSymbols[symbol] {
 			toSubscribe = append(toSubscribe, symbol)
 		}
@@ -348,6 +800,9 @@ func (e *Engine) refreshAlerts(ctx context.Context) error {
 	// Unsubscribe from removed symbols
 	var toUnsubscribe []string
 	for symbol := range oldSymbols {
+		if isFallbackSymbol(symbol) {
+			continue
+		}
 		if !symbols[symbol] {
 			toUnsubscribe = append(toUnsubscribe, symbol)
 		}
@@ -357,27 +812,73 @@ func (e *Engine) refreshAlerts(ctx context.Context) error {
 		if err := e.binanceClient.Unsubscribe(toUnsubscribe); err != nil {
 			e.logger.Error("failed to unsubscribe from symbols", slog.String("error", err.Error()))
 		}
+		e.resetStreamTiers(toUnsubscribe)
 	}
-
-	e.logger.Debug("refreshed alerts",
-		slog.Int("count", len(newAlerts)),
-		slog.Int("symbols", len(symbols)),
-	)
-
-	return nil
 }
 
-// markAlertTriggered updates the alert in database
-func (e *Engine) markAlertTriggered(ctx context.Context, alertID int64) error {
+// markAlertTriggered persists a trigger if the alert is still in the state
+// the engine evaluated it in. Returns false when the watermark moved on,
+// meaning the trigger was already recorded
+func (e *Engine) markAlertTriggered(ctx context.Context, alert *Alert, event *TriggerEvent) (bool, error) {
+	pause := alert.pauseAfterTrigger()
+
 	query := `
 		UPDATE alerts
 		SET times_triggered = times_triggered + 1,
-		    last_triggered_at = NOW(),
+		    last_triggered_at = $3,
+		    last_evaluated_price = $4,
+		    last_evaluated_at = NOW(),
+		    trigger_state = $5,
+		    is_paused = is_paused OR $6,
 		    updated_at = NOW()
 		WHERE id = $1
+		  AND times_triggered = $2
+		  AND trigger_state = 'armed'
+		  AND is_paused = false
 	`
-	_, err := e.pool.Exec(ctx, query, alertID)
-	return err
+	result, err := e.pool.Exec(ctx, query,
+		alert.ID, alert.TimesTriggered, event.TriggeredAt, event.TriggeredPrice,
+		alert.stateAfterTrigger(), pause,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// flushWatermarks persists the last evaluated price of every alert seen since
+// the previous flush
+func (e *Engine) flushWatermarks(ctx context.Context) {
+	e.watermarksMu.Lock()
+	watermarks := e.watermarks
+	e.watermarks = make(map[int64]float64)
+	e.watermarksMu.Unlock()
+
+	if len(watermarks) == 0 {
+		return
+	}
+
+	ids := make([]int64, 0, len(watermarks))
+	prices := make([]float64, 0, len(watermarks))
+	for id, price := range watermarks {
+		ids = append(ids, id)
+		prices = append(prices, price)
+	}
+
+	_, err := e.pool.Exec(ctx, `
+		UPDATE alerts a
+		SET last_evaluated_price = w.price,
+		    last_evaluated_at = NOW()
+		FROM unnest($1::bigint[], $2::float8[]) AS w(id, price)
+		WHERE a.id = w.id
+	`, ids, prices)
+	if err != nil {
+		e.logger.Error("failed to flush alert watermarks",
+			slog.Int("count", len(ids)),
+			slog.String("error", err.Error()),
+		)
+	}
 }
 
 // createHistoryRecord creates an alert history record
@@ -385,9 +886,9 @@ func (e *Engine) createHistoryRecord(ctx context.Context, event *TriggerEvent) e
 	query := `
 		INSERT INTO alert_history (
 			alert_id, user_id, coin_id, alert_type, condition_operator,
-			condition_value, triggered_price, notified
+			condition_value, triggered_price, quote_asset, notification_sent
 		)
-		SELECT $1, $2, a.coin_id, $3, a.condition_operator, a.condition_value, $4, false
+		SELECT $1, $2, a.coin_id, $3, a.condition_operator, a.condition_value, $4, a.quote_asset, false
 		FROM alerts a
 		WHERE a.id = $1
 	`
@@ -395,6 +896,17 @@ func (e *Engine) createHistoryRecord(ctx context.Context, event *TriggerEvent) e
 	return err
 }
 
+// RefreshAlerts reloads alerts from the database immediately instead of
+// waiting for the next periodic refresh
+func (e *Engine) RefreshAlerts(ctx context.Context) error {
+	return e.refreshAlerts(ctx)
+}
+
+// IsBinanceConnected reports whether the Binance price stream is connected
+func (e *Engine) IsBinanceConnected() bool {
+	return e.binanceClient.IsConnected()
+}
+
 // GetAlertCount returns the number of active alerts
 func (e *Engine) GetAlertCount() int {
 	e.mu.RLock()
@@ -406,7 +918,12 @@ func (e *Engine) GetAlertCount() int {
 func (e *Engine) GetSymbolCount() int {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return len(e.symbolAlerts)
+	return len(e.streamed)
+}
+
+// GetRejectedTickCount returns the number of ticks dropped by the anomaly filter
+func (e *Engine) GetRejectedTickCount() int64 {
+	return e.anomalyFilter.GetRejectedCount()
 }
 
 // Stop stops the alert engine
@@ -432,4 +949,12 @@ func (e *Engine) Stop() {
 	case <-time.After(10 * time.Second):
 		e.logger.Warn("timeout waiting for background tasks to stop")
 	}
+
+	// Persist the latest watermarks so the next run starts from them
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.flushWatermarks(ctx)
+	if err := e.priceCache.Flush(ctx); err != nil {
+		e.logger.Error("failed to flush price cache", slog.String("error", err.Error()))
+	}
 }
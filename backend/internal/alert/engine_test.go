@@ -0,0 +1,30 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weqory/backend/pkg/schedule"
+)
+
+func TestScheduledAlerts(t *testing.T) {
+	// Monday 2026-03-02 10:00 UTC
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	always := &Alert{ID: 1}
+	officeHours := &Alert{
+		ID:       2,
+		Schedule: &schedule.Schedule{Windows: []schedule.Window{{Days: []string{"mon"}, Start: "09:00", End: "18:00"}}},
+		Location: time.UTC,
+	}
+	weekend := &Alert{
+		ID:       3,
+		Schedule: &schedule.Schedule{Windows: []schedule.Window{{Days: []string{"sat", "sun"}, Start: "00:00", End: "24:00"}}},
+		Location: time.UTC,
+	}
+
+	active := scheduledAlerts([]*Alert{always, officeHours, weekend}, now)
+
+	assert.Equal(t, []*Alert{always, officeHours}, active)
+}
@@ -2,27 +2,44 @@ package alert
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/weqory/backend/internal/binance"
 	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/gas"
+	"github.com/weqory/backend/internal/whale"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/clock"
+	"github.com/weqory/backend/pkg/schedule"
 )
 
 // AlertType represents the type of alert
 type AlertType string
 
 const (
-	AlertTypePriceAbove      AlertType = "PRICE_ABOVE"
-	AlertTypePriceBelow      AlertType = "PRICE_BELOW"
-	AlertTypePriceChangePct  AlertType = "PRICE_CHANGE_PCT"
-	AlertTypePeriodic        AlertType = "PERIODIC"
-	AlertTypeVolumeSpike     AlertType = "VOLUME_SPIKE"
-	AlertTypeVolumeChangePct AlertType = "VOLUME_CHANGE_PCT"
-	AlertTypeMarketCapAbove  AlertType = "MARKET_CAP_ABOVE"
-	AlertTypeMarketCapBelow  AlertType = "MARKET_CAP_BELOW"
+	AlertTypePriceAbove      AlertType = alerttype.PriceAbove
+	AlertTypePriceBelow      AlertType = alerttype.PriceBelow
+	AlertTypePriceChangePct  AlertType = alerttype.PriceChangePct
+	AlertTypePeriodic        AlertType = alerttype.Periodic
+	AlertTypeVolumeSpike     AlertType = alerttype.VolumeSpike
+	AlertTypeVolumeChangePct AlertType = alerttype.VolumeChangePct
+	AlertTypeMarketCapAbove  AlertType = alerttype.MarketCapAbove
+	AlertTypeMarketCapBelow  AlertType = alerttype.MarketCapBelow
+	AlertTypeWhaleTransfer   AlertType = alerttype.WhaleTransfer
+	AlertTypeGasBelow        AlertType = alerttype.GasBelow
 )
 
+// source returns the data the alert type is evaluated against
+func (t AlertType) source() alerttype.Source {
+	if def, ok := alerttype.Lookup(string(t)); ok {
+		return def.Source
+	}
+	return alerttype.SourcePrice
+}
+
 // ConditionOperator represents comparison operators
 type ConditionOperator string
 
@@ -33,12 +50,20 @@ const (
 	OperatorNotEquals   ConditionOperator = "!="
 )
 
+// Trigger states persisted per alert so a restarted engine knows which
+// alerts already fired for the current condition
+const (
+	TriggerStateArmed = "armed"
+	TriggerStateFired = "fired"
+)
+
 // Alert represents an alert to be evaluated
 type Alert struct {
 	ID                 int64
 	UserID             int64
 	CoinSymbol         string
-	BinanceSymbol      string
+	BinanceSymbol      string // price key; FallbackSymbol(id) for CoinGecko-polled coins
+	QuoteAsset         string // quote of the condition when not USD, e.g. BTC; BinanceSymbol is then its pair
 	AlertType          AlertType
 	ConditionOperator  ConditionOperator
 	ConditionValue     float64
@@ -50,6 +75,14 @@ type Alert struct {
 	LastTriggeredAt    *time.Time
 	PriceWhenCreated   float64
 	CreatedAt          time.Time
+	TriggerState       string             // armed or fired
+	LastEvaluatedPrice *float64           // last price the alert was evaluated against
+	Priority           string             // notification delivery priority: low, normal, high
+	Schedule           *schedule.Schedule // windows the alert is evaluated in; nil means always
+	Location           *time.Location     // owner's timezone, for Schedule
+	Name               string             // user label, empty when unnamed
+	ExpiresAt          *time.Time         // evaluated until then; nil means no expiry
+	MaxTriggers        *int               // fires at most this often; nil means no limit
 	// Extended data from coins table (for market cap alerts)
 	CoinMarketCap *float64
 }
@@ -62,37 +95,52 @@ type TriggerEvent struct {
 	AlertType      AlertType
 	ConditionValue float64
 	TriggeredPrice float64
+	QuoteAsset     string // quote of ConditionValue and TriggeredPrice; empty is USD
 	TriggeredAt    time.Time
+	Priority       string
+	AlertName      string
+	// Detail describes what fired an event-driven alert, e.g. the transfer
+	// of a whale alert; empty for price conditions. TriggeredPrice of gas
+	// alerts is the standard gas price in gwei
+	Detail string
+	// RequestID correlates the trigger's log lines across services. No API
+	// request causes a trigger, so the evaluator assigns a new one
+	RequestID string
+	// TickReceivedAt is when the tick that fired the alert reached the
+	// engine, for pipeline latency; zero when not fired by a Binance tick
+	TickReceivedAt time.Time
+}
+
+// MarketHistory provides the history that change and spike conditions
+// compare the current tick against
+type MarketHistory interface {
+	GetPriceChange(ctx context.Context, symbol string, duration time.Duration) (float64, error)
+	GetAverageVolume(ctx context.Context, symbol string, duration time.Duration) (float64, error)
+	GetVolumeChange(ctx context.Context, symbol string, duration time.Duration) (float64, error)
 }
 
 // Evaluator evaluates alert conditions
 type Evaluator struct {
-	priceCache *cache.PriceCache
-	logger     *slog.Logger
+	history MarketHistory
+	clock   clock.Clock
+	logger  *slog.Logger
 }
 
 // NewEvaluator creates a new alert evaluator
 func NewEvaluator(priceCache *cache.PriceCache, logger *slog.Logger) *Evaluator {
 	return &Evaluator{
-		priceCache: priceCache,
-		logger:     logger,
+		history: priceCache,
+		clock:   clock.Real{},
+		logger:  logger,
 	}
 }
 
 // Evaluate checks if an alert should trigger based on current price
 func (e *Evaluator) Evaluate(ctx context.Context, alert *Alert, priceData *binance.PriceData) (*TriggerEvent, error) {
-	if alert.IsPaused {
+	if e.held(alert) != "" {
 		return nil, nil
 	}
 
-	// Check periodic interval cooldown
-	if alert.LastTriggeredAt != nil && alert.PeriodicInterval != "" {
-		interval := parseInterval(alert.PeriodicInterval)
-		if time.Since(*alert.LastTriggeredAt) < interval {
-			return nil, nil
-		}
-	}
-
 	triggered, err := e.checkCondition(ctx, alert, priceData)
 	if err != nil {
 		return nil, err
@@ -109,11 +157,161 @@ func (e *Evaluator) Evaluate(ctx context.Context, alert *Alert, priceData *binan
 		AlertType:      alert.AlertType,
 		ConditionValue: alert.ConditionValue,
 		TriggeredPrice: priceData.Price,
-		TriggeredAt:    time.Now(),
+		QuoteAsset:     alert.QuoteAsset,
+		TriggeredAt:    e.clock.Now(),
+		Priority:       alert.Priority,
+		AlertName:      alert.Name,
+		RequestID:      uuid.NewString(),
+		TickReceivedAt: priceData.ReceivedAt,
 	}, nil
 }
 
+// held returns why an alert's condition is not checked right now, or an
+// empty decision when it is
+func (e *Evaluator) held(alert *Alert) Decision {
+	if alert.IsPaused {
+		return DecisionPaused
+	}
+	if alert.Expired(e.clock.Now()) {
+		return DecisionExpired
+	}
+
+	// Already fired for the current condition; waits to be re-armed
+	if alert.TriggerState == TriggerStateFired {
+		return DecisionAwaitingRearm
+	}
+
+	// Check periodic interval cooldown
+	if alert.LastTriggeredAt != nil && alert.PeriodicInterval != "" {
+		interval := parseInterval(alert.PeriodicInterval)
+		if e.clock.Now().Sub(*alert.LastTriggeredAt) < interval {
+			return DecisionCooldown
+		}
+	}
+
+	return ""
+}
+
+// EvaluateTransfer checks if a whale transfer alert fires for an on-chain
+// transfer of its coin
+func (e *Evaluator) EvaluateTransfer(alert *Alert, transfer whale.Transfer) *TriggerEvent {
+	if alert.AlertType != AlertTypeWhaleTransfer || alert.IsPaused || alert.Expired(e.clock.Now()) {
+		return nil
+	}
+	if alert.TriggerState == TriggerStateFired {
+		return nil
+	}
+	if transfer.CoinSymbol() != alert.CoinSymbol || transfer.AmountUSD < alert.ConditionValue {
+		return nil
+	}
+
+	return &TriggerEvent{
+		AlertID:        alert.ID,
+		UserID:         alert.UserID,
+		CoinSymbol:     alert.CoinSymbol,
+		AlertType:      alert.AlertType,
+		ConditionValue: alert.ConditionValue,
+		TriggeredPrice: transfer.Price(),
+		TriggeredAt:    e.clock.Now(),
+		Priority:       alert.Priority,
+		AlertName:      alert.Name,
+		Detail:         transfer.Summary(),
+		RequestID:      uuid.NewString(),
+	}
+}
+
+// EvaluateGas checks if a gas alert fires for the current Ethereum gas
+// price. The standard estimate is compared against the threshold in gwei
+func (e *Evaluator) EvaluateGas(alert *Alert, price gas.Price) *TriggerEvent {
+	if alert.AlertType != AlertTypeGasBelow || alert.IsPaused || alert.Expired(e.clock.Now()) {
+		return nil
+	}
+	if alert.TriggerState == TriggerStateFired {
+		return nil
+	}
+	if price.StandardGwei <= 0 || price.StandardGwei >= alert.ConditionValue {
+		return nil
+	}
+
+	return &TriggerEvent{
+		AlertID:        alert.ID,
+		UserID:         alert.UserID,
+		CoinSymbol:     alert.CoinSymbol,
+		AlertType:      alert.AlertType,
+		ConditionValue: alert.ConditionValue,
+		TriggeredPrice: price.StandardGwei,
+		TriggeredAt:    e.clock.Now(),
+		Priority:       alert.Priority,
+		AlertName:      alert.Name,
+		Detail:         price.Summary(),
+		RequestID:      uuid.NewString(),
+	}
+}
+
+// ShouldRearmGas reports whether a fired gas alert can trigger again
+// because the gas price rose back to its threshold
+func (e *Evaluator) ShouldRearmGas(alert *Alert, price gas.Price) bool {
+	if alert.TriggerState != TriggerStateFired || alert.IsPaused {
+		return false
+	}
+	return price.StandardGwei >= alert.ConditionValue
+}
+
+// ShouldRearm reports whether a fired alert's condition has cleared so it can
+// trigger again on the next crossing
+func (e *Evaluator) ShouldRearm(ctx context.Context, alert *Alert, priceData *binance.PriceData) (bool, error) {
+	if alert.TriggerState != TriggerStateFired || alert.IsPaused {
+		return false, nil
+	}
+
+	triggered, err := e.checkCondition(ctx, alert, priceData)
+	if err != nil {
+		return false, err
+	}
+
+	return !triggered, nil
+}
+
+// Expired reports whether an alert is past its expiry date or has fired
+// as often as allowed at now. Expired alerts are removed by the cleanup
+// service
+func (a *Alert) Expired(now time.Time) bool {
+	if a.ExpiresAt != nil && !now.Before(*a.ExpiresAt) {
+		return true
+	}
+	return a.MaxTriggers != nil && a.TimesTriggered >= *a.MaxTriggers
+}
+
+// pauseAfterTrigger reports whether an alert stops once it fires: one-shot
+// alerts, and alerts firing for the last allowed time
+func (a *Alert) pauseAfterTrigger() bool {
+	if !a.IsRecurring && a.PeriodicInterval == "" {
+		return true
+	}
+	return a.MaxTriggers != nil && a.TimesTriggered+1 >= *a.MaxTriggers
+}
+
+// stateAfterTrigger returns the trigger state an alert moves to once it fires
+// Recurring alerts fire once per crossing: they stay fired while the
+// condition holds and are re-armed by ShouldRearm once it clears.
+// Periodic alerts are rate limited by their interval and stay armed, as do
+// types firing once per event such as whale transfers
+func (a *Alert) stateAfterTrigger() string {
+	if a.PeriodicInterval != "" {
+		return TriggerStateArmed
+	}
+	if def, ok := alerttype.Lookup(string(a.AlertType)); ok && def.StaysArmed {
+		return TriggerStateArmed
+	}
+	return TriggerStateFired
+}
+
 func (e *Evaluator) checkCondition(ctx context.Context, alert *Alert, priceData *binance.PriceData) (bool, error) {
+	// Fired by their own feeds, see EvaluateTransfer and EvaluateGas
+	if alert.AlertType.source() != alerttype.SourcePrice {
+		return false, nil
+	}
+
 	switch alert.AlertType {
 	case AlertTypePriceAbove:
 		return priceData.Price > alert.ConditionValue, nil
@@ -158,7 +356,15 @@ func (e *Evaluator) checkPriceChangePct(ctx context.Context, alert *Alert, price
 		changePercent = priceData.ChangePercent
 	} else {
 		// Get historical price change for specified timeframe
-		changePercent, err = e.priceCache.GetPriceChange(ctx, alert.BinanceSymbol, duration)
+		changePercent, err = e.history.GetPriceChange(ctx, alert.BinanceSymbol, duration)
+		if errors.Is(err, cache.ErrInsufficientHistory) {
+			// The change over the timeframe is not known yet
+			e.logger.Debug("no price history for change check",
+				slog.String("symbol", alert.BinanceSymbol),
+				slog.String("timeframe", alert.ConditionTimeframe),
+			)
+			return false, nil
+		}
 		if err != nil {
 			return false, err
 		}
@@ -188,7 +394,7 @@ func (e *Evaluator) checkPeriodic(alert *Alert) (bool, error) {
 	}
 
 	// Check if enough time has passed
-	return time.Since(*alert.LastTriggeredAt) >= interval, nil
+	return e.clock.Now().Sub(*alert.LastTriggeredAt) >= interval, nil
 }
 
 // checkVolumeSpike checks if current volume is significantly higher than average
@@ -199,7 +405,7 @@ func (e *Evaluator) checkVolumeSpike(ctx context.Context, alert *Alert, priceDat
 	}
 
 	// Get average volume from cache (7-day average)
-	avgVolume, err := e.priceCache.GetAverageVolume(ctx, alert.BinanceSymbol, 7*24*time.Hour)
+	avgVolume, err := e.history.GetAverageVolume(ctx, alert.BinanceSymbol, 7*24*time.Hour)
 	if err != nil {
 		// If no historical data, can't determine spike
 		e.logger.Debug("no volume history for spike check",
@@ -229,7 +435,7 @@ func (e *Evaluator) checkVolumeChangePct(ctx context.Context, alert *Alert, pric
 	}
 
 	// Get volume change percentage
-	volumeChange, err := e.priceCache.GetVolumeChange(ctx, alert.BinanceSymbol, duration)
+	volumeChange, err := e.history.GetVolumeChange(ctx, alert.BinanceSymbol, duration)
 	if err != nil {
 		e.logger.Debug("no volume history for change check",
 			slog.String("symbol", alert.BinanceSymbol),
@@ -10,6 +10,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/gas"
+	"github.com/weqory/backend/internal/whale"
+	"github.com/weqory/backend/pkg/clock"
 )
 
 func TestEvaluator_PriceAbove(t *testing.T) {
@@ -317,6 +320,136 @@ func TestEvaluator_Periodic(t *testing.T) {
 	}
 }
 
+func TestEvaluator_PeriodicFollowsClock(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	evaluator := NewEvaluator(nil, logger)
+	fake := clock.NewFake(time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC))
+	evaluator.clock = fake
+
+	lastTriggered := fake.Now()
+	alert := &Alert{
+		ID:               1,
+		AlertType:        AlertTypePeriodic,
+		PeriodicInterval: "1h",
+		LastTriggeredAt:  &lastTriggered,
+	}
+	priceData := &binance.PriceData{Price: 50000}
+
+	fake.Advance(59 * time.Minute)
+	event, err := evaluator.Evaluate(context.Background(), alert, priceData)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	fake.Advance(time.Minute)
+	event, err = evaluator.Evaluate(context.Background(), alert, priceData)
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, fake.Now(), event.TriggeredAt)
+}
+
+func TestEvaluator_Expired(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	evaluator := NewEvaluator(nil, logger)
+	fake := clock.NewFake(time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC))
+	evaluator.clock = fake
+
+	expiresAt := fake.Now().Add(time.Hour)
+	alert := &Alert{
+		ID:             1,
+		AlertType:      AlertTypePriceAbove,
+		ConditionValue: 50000,
+		IsRecurring:    true,
+		ExpiresAt:      &expiresAt,
+	}
+	priceData := &binance.PriceData{Price: 51000}
+
+	event, err := evaluator.Evaluate(context.Background(), alert, priceData)
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+
+	fake.Advance(time.Hour)
+	event, err = evaluator.Evaluate(context.Background(), alert, priceData)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	// Out of triggers: the last allowed trigger pauses the alert
+	maxTriggers := 2
+	alert = &Alert{ID: 2, IsRecurring: true, MaxTriggers: &maxTriggers, TimesTriggered: 1}
+	assert.False(t, alert.Expired(fake.Now()))
+	assert.True(t, alert.pauseAfterTrigger())
+	alert.TimesTriggered = 2
+	assert.True(t, alert.Expired(fake.Now()))
+}
+
+func TestEvaluator_EvaluateTransfer(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	evaluator := NewEvaluator(nil, logger)
+
+	alert := &Alert{
+		ID:             1,
+		CoinSymbol:     "BTC",
+		AlertType:      AlertTypeWhaleTransfer,
+		ConditionValue: 10_000_000,
+		IsRecurring:    true,
+		TriggerState:   TriggerStateArmed,
+	}
+	transfer := whale.Transfer{
+		Symbol:    "btc",
+		Amount:    200,
+		AmountUSD: 14_000_000,
+		To:        whale.Owner{Owner: "binance"},
+	}
+
+	event := evaluator.EvaluateTransfer(alert, transfer)
+	require.NotNil(t, event)
+	assert.Equal(t, 70000.0, event.TriggeredPrice)
+	assert.Equal(t, "200 BTC ($14.0M) from unknown wallet to binance", event.Detail)
+	assert.Equal(t, TriggerStateArmed, alert.stateAfterTrigger(), "fires again on the next transfer")
+
+	small := transfer
+	small.AmountUSD = 9_000_000
+	assert.Nil(t, evaluator.EvaluateTransfer(alert, small))
+
+	other := transfer
+	other.Symbol = "eth"
+	assert.Nil(t, evaluator.EvaluateTransfer(alert, other))
+
+	priceAlert := *alert
+	priceAlert.AlertType = AlertTypePriceAbove
+	assert.Nil(t, evaluator.EvaluateTransfer(&priceAlert, transfer))
+}
+
+func TestEvaluator_EvaluateGas(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	evaluator := NewEvaluator(nil, logger)
+
+	alert := &Alert{
+		ID:             1,
+		CoinSymbol:     "ETH",
+		AlertType:      AlertTypeGasBelow,
+		ConditionValue: 10,
+		IsRecurring:    true,
+		TriggerState:   TriggerStateArmed,
+	}
+	price := gas.Price{BaseFeeGwei: 7.5, SlowGwei: 7.6, StandardGwei: 8, FastGwei: 9.25}
+
+	event := evaluator.EvaluateGas(alert, price)
+	require.NotNil(t, event)
+	assert.Equal(t, 8.0, event.TriggeredPrice)
+	assert.Equal(t, "base fee 7.50 gwei · fast 9.25 gwei", event.Detail)
+	assert.Equal(t, TriggerStateFired, alert.stateAfterTrigger())
+
+	high := price
+	high.StandardGwei = 12
+	assert.Nil(t, evaluator.EvaluateGas(alert, high))
+
+	fired := *alert
+	fired.TriggerState = TriggerStateFired
+	assert.Nil(t, evaluator.EvaluateGas(&fired, price))
+	assert.False(t, evaluator.ShouldRearmGas(&fired, price), "gas still below the threshold")
+	assert.True(t, evaluator.ShouldRearmGas(&fired, high))
+}
+
 func TestEvaluator_EvaluateBatch(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	evaluator := NewEvaluator(nil, logger)
@@ -460,3 +593,74 @@ func TestCompareValue(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluator_FiredAlertWaitsForRearm(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	evaluator := NewEvaluator(nil, logger)
+	ctx := context.Background()
+
+	alert := &Alert{
+		ID:             1,
+		AlertType:      AlertTypePriceAbove,
+		ConditionValue: 50000,
+		IsRecurring:    true,
+		TriggerState:   TriggerStateFired,
+	}
+
+	// Still above target: no new trigger and no re-arm
+	event, err := evaluator.Evaluate(ctx, alert, &binance.PriceData{Price: 51000})
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	rearm, err := evaluator.ShouldRearm(ctx, alert, &binance.PriceData{Price: 51000})
+	require.NoError(t, err)
+	assert.False(t, rearm)
+
+	// Back below target: re-arm, then trigger on the next crossing
+	rearm, err = evaluator.ShouldRearm(ctx, alert, &binance.PriceData{Price: 49000})
+	require.NoError(t, err)
+	assert.True(t, rearm)
+
+	alert.TriggerState = TriggerStateArmed
+	event, err = evaluator.Evaluate(ctx, alert, &binance.PriceData{Price: 51000})
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+}
+
+func TestEvaluator_RecurringFiresOncePerCrossing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	evaluator := NewEvaluator(nil, logger)
+	ctx := context.Background()
+
+	alert := &Alert{
+		ID:             1,
+		AlertType:      AlertTypePriceAbove,
+		ConditionValue: 50000,
+		IsRecurring:    true,
+		TriggerState:   TriggerStateArmed,
+	}
+
+	// Ticks as the engine handles them: re-arm, then evaluate
+	prices := []float64{49000, 51000, 52000, 50500, 49000, 49500, 51000, 53000}
+	var fired []bool
+	for _, price := range prices {
+		data := &binance.PriceData{Price: price}
+
+		rearm, err := evaluator.ShouldRearm(ctx, alert, data)
+		require.NoError(t, err)
+		if rearm {
+			alert.TriggerState = TriggerStateArmed
+		}
+
+		event, err := evaluator.Evaluate(ctx, alert, data)
+		require.NoError(t, err)
+		if event != nil {
+			assert.False(t, alert.pauseAfterTrigger(), "recurring alerts stay active")
+			alert.TriggerState = alert.stateAfterTrigger()
+		}
+		fired = append(fired, event != nil)
+	}
+
+	// Once per crossing above the target, not on every tick above it
+	assert.Equal(t, []bool{false, true, false, false, false, false, true, false}, fired)
+}
@@ -0,0 +1,120 @@
+package alert
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/coingecko"
+)
+
+// fallbackSymbolPrefix marks price keys for coins polled from CoinGecko so
+// they never collide with Binance pairs in the price cache
+const fallbackSymbolPrefix = "CG:"
+
+// FallbackSymbol returns the price key used for a CoinGecko-polled coin
+func FallbackSymbol(coingeckoID string) string {
+	return fallbackSymbolPrefix + coingeckoID
+}
+
+// isFallbackSymbol reports whether a price key belongs to a CoinGecko-polled coin
+func isFallbackSymbol(symbol string) bool {
+	return strings.HasPrefix(symbol, fallbackSymbolPrefix)
+}
+
+// FallbackPoller polls CoinGecko prices for coins that have no Binance pair
+// and feeds them into the engine like regular ticker updates
+type FallbackPoller struct {
+	client  *coingecko.Client
+	handler func(binance.PriceData)
+	logger  *slog.Logger
+
+	ids map[string]bool
+	mu  sync.RWMutex
+}
+
+// NewFallbackPoller creates a new CoinGecko fallback poller
+func NewFallbackPoller(client *coingecko.Client, logger *slog.Logger) *FallbackPoller {
+	return &FallbackPoller{
+		client: client,
+		logger: logger,
+		ids:    make(map[string]bool),
+	}
+}
+
+// SetPriceHandler sets the handler for polled prices
+func (p *FallbackPoller) SetPriceHandler(handler func(binance.PriceData)) {
+	p.handler = handler
+}
+
+// SetIDs replaces the set of CoinGecko IDs to poll
+func (p *FallbackPoller) SetIDs(ids []string) {
+	newIDs := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		newIDs[id] = true
+	}
+
+	p.mu.Lock()
+	p.ids = newIDs
+	p.mu.Unlock()
+}
+
+// Run polls prices until the context is cancelled
+func (p *FallbackPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(coingecko.PricePollInterval)
+	defer ticker.Stop()
+
+	p.poll(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// poll fetches prices for all tracked IDs and dispatches them
+func (p *FallbackPoller) poll(ctx context.Context) {
+	p.mu.RLock()
+	ids := make([]string, 0, len(p.ids))
+	for id := range p.ids {
+		ids = append(ids, id)
+	}
+	p.mu.RUnlock()
+
+	if len(ids) == 0 || p.handler == nil {
+		return
+	}
+
+	prices, err := p.client.GetSimplePrices(ctx, ids)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Error("failed to poll coingecko prices", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	for id, price := range prices {
+		updatedAt := time.Now()
+		if price.LastUpdated > 0 {
+			updatedAt = time.Unix(price.LastUpdated, 0)
+		}
+
+		p.handler(binance.PriceData{
+			Symbol:        FallbackSymbol(id),
+			Price:         price.USD,
+			ChangePercent: price.USD24hChange,
+			Volume24h:     price.USD24hVol,
+			QuoteVolume:   price.USD24hVol,
+			UpdatedAt:     updatedAt,
+		})
+	}
+
+	p.logger.Debug("polled coingecko prices", slog.Int("coins", len(prices)))
+}
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/coingecko"
+)
+
+func TestFallbackPoller_Poll(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var requestedIDs string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedIDs = r.URL.Query().Get("ids")
+		_, _ = io.WriteString(w, `{"obscure-coin":{"usd":2.5,"usd_24h_vol":1200,"usd_24h_change":-3.2,"last_updated_at":1767607200}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := coingecko.NewClient("", logger)
+	client.SetBaseURL(srv.URL)
+	poller := NewFallbackPoller(client, logger)
+
+	var got []binance.PriceData
+	poller.SetPriceHandler(func(data binance.PriceData) {
+		got = append(got, data)
+	})
+
+	// Nothing to poll yet
+	poller.poll(context.Background())
+	assert.Empty(t, requestedIDs)
+
+	poller.SetIDs([]string{"obscure-coin"})
+	poller.poll(context.Background())
+	assert.Equal(t, "obscure-coin", requestedIDs)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "CG:obscure-coin", got[0].Symbol)
+	assert.True(t, isFallbackSymbol(got[0].Symbol))
+	assert.Equal(t, 2.5, got[0].Price)
+	assert.Equal(t, -3.2, got[0].ChangePercent)
+	assert.Equal(t, int64(1767607200), got[0].UpdatedAt.Unix())
+}
@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/internal/gas"
+)
+
+// GasPoller polls the Ethereum gas price, caches it for the market overview
+// and feeds it into the engine
+type GasPoller struct {
+	client   *gas.Client
+	cache    *gas.Cache
+	interval time.Duration
+	handler  func(gas.Price)
+	logger   *slog.Logger
+}
+
+// NewGasPoller creates a new gas price poller
+func NewGasPoller(client *gas.Client, cache *gas.Cache, interval time.Duration, logger *slog.Logger) *GasPoller {
+	return &GasPoller{
+		client:   client,
+		cache:    cache,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// SetPriceHandler sets the handler for polled gas prices
+func (p *GasPoller) SetPriceHandler(handler func(gas.Price)) {
+	p.handler = handler
+}
+
+// Run polls the gas price until the context is cancelled
+func (p *GasPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// poll fetches, caches and dispatches the current gas price
+func (p *GasPoller) poll(ctx context.Context) {
+	price, err := p.client.Estimate(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Error("failed to poll gas price", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	if err := p.cache.Set(ctx, price); err != nil {
+		p.logger.Error("failed to cache gas price", slog.String("error", err.Error()))
+	}
+
+	if p.handler != nil {
+		p.handler(*price)
+	}
+
+	p.logger.Debug("polled gas price", slog.Float64("standard_gwei", price.StandardGwei))
+}
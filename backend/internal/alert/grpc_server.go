@@ -0,0 +1,57 @@
+package alert
+
+import (
+	"context"
+
+	enginev1 "github.com/weqory/backend/api/proto/engine/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer implements the engine's internal gRPC API
+type GRPCServer struct {
+	enginev1.UnimplementedEngineServiceServer
+	engine    *Engine
+	publisher *Publisher
+}
+
+// NewGRPCServer creates a new GRPCServer
+func NewGRPCServer(engine *Engine, publisher *Publisher) *GRPCServer {
+	return &GRPCServer{
+		engine:    engine,
+		publisher: publisher,
+	}
+}
+
+// GetStats returns the engine's current counters
+func (s *GRPCServer) GetStats(ctx context.Context, _ *enginev1.GetStatsRequest) (*enginev1.GetStatsResponse, error) {
+	retryQueueLen, err := s.publisher.GetRetryQueueLength(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to read retry queue: %v", err)
+	}
+	deadLetterLen, err := s.publisher.GetDeadLetterQueueLength(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to read dead letter queue: %v", err)
+	}
+
+	return &enginev1.GetStatsResponse{
+		ActiveAlerts:     int64(s.engine.GetAlertCount()),
+		MonitoredSymbols: int64(s.engine.GetSymbolCount()),
+		BinanceConnected: s.engine.IsBinanceConnected(),
+		RetryQueueLength: retryQueueLen,
+		DeadLetterLength: deadLetterLen,
+		RejectedTicks:    s.engine.GetRejectedTickCount(),
+	}, nil
+}
+
+// RefreshAlerts reloads alerts from the database
+func (s *GRPCServer) RefreshAlerts(ctx context.Context, _ *enginev1.RefreshAlertsRequest) (*enginev1.RefreshAlertsResponse, error) {
+	if err := s.engine.RefreshAlerts(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to refresh alerts: %v", err)
+	}
+
+	return &enginev1.RefreshAlertsResponse{
+		ActiveAlerts:     int64(s.engine.GetAlertCount()),
+		MonitoredSymbols: int64(s.engine.GetSymbolCount()),
+	}, nil
+}
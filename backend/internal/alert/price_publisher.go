@@ -7,12 +7,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/eventbus"
 )
 
 const (
-	// Redis channel for price stream updates
+	// Event bus topic for price stream updates
 	priceStreamChannel = "prices:stream"
 
 	// Throttle interval per symbol (avoid flooding)
@@ -28,9 +28,10 @@ type PriceStreamPayload struct {
 	UpdatedAt    string  `json:"updatedAt"`
 }
 
-// PricePublisher publishes price updates to Redis for WebSocket clients
+// PricePublisher publishes price updates on the event bus for WebSocket
+// clients
 type PricePublisher struct {
-	client *redis.Client
+	bus    eventbus.Publisher
 	logger *slog.Logger
 
 	// Throttling: track last publish time per symbol
@@ -39,15 +40,15 @@ type PricePublisher struct {
 }
 
 // NewPricePublisher creates a new price publisher
-func NewPricePublisher(client *redis.Client, logger *slog.Logger) *PricePublisher {
+func NewPricePublisher(bus eventbus.Publisher, logger *slog.Logger) *PricePublisher {
 	return &PricePublisher{
-		client:      client,
+		bus:         bus,
 		logger:      logger,
 		lastPublish: make(map[string]time.Time),
 	}
 }
 
-// Publish publishes a price update to Redis pub/sub
+// Publish publishes a price update on the event bus
 func (p *PricePublisher) Publish(ctx context.Context, data binance.PriceData) {
 	// Check throttle
 	if !p.shouldPublish(data.Symbol) {
@@ -71,8 +72,7 @@ func (p *PricePublisher) Publish(ctx context.Context, data binance.PriceData) {
 		return
 	}
 
-	// Publish to Redis pub/sub channel
-	if err := p.client.Publish(ctx, priceStreamChannel, jsonData).Err(); err != nil {
+	if err := p.bus.Publish(ctx, priceStreamChannel, jsonData); err != nil {
 		p.logger.Error("failed to publish price update",
 			slog.String("symbol", data.Symbol),
 			slog.String("error", err.Error()),
@@ -102,7 +102,7 @@ func (p *PricePublisher) shouldPublish(symbol string) bool {
 	return false
 }
 
-// GetChannel returns the Redis channel name for price streams
+// GetPriceStreamChannel returns the event bus topic for price streams
 func GetPriceStreamChannel() string {
 	return priceStreamChannel
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/eventbus"
 )
 
 func newTestPricePublisher(t *testing.T) (*PricePublisher, *redis.Client) {
@@ -27,7 +28,7 @@ func newTestPricePublisher(t *testing.T) (*PricePublisher, *redis.Client) {
 	}
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	publisher := NewPricePublisher(redisClient, logger)
+	publisher := NewPricePublisher(eventbus.NewRedisPubSub(redisClient), logger)
 
 	return publisher, redisClient
 }
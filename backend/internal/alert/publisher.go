@@ -5,17 +5,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/eventbus"
 )
 
 const (
-	// Redis channel for alert notifications
+	// Event bus topic for alert notifications
 	alertNotificationChannel = "alert:notifications"
 
-	// Redis queue for failed notifications (retry queue)
-	alertRetryQueue = "alert:retry_queue"
+	// Legacy list of failed notifications, drained into the delayed queue
+	alertRetryQueueLegacy = "alert:retry_queue"
+
+	// Sorted set of failed notifications scored by next attempt time (unix ms)
+	alertRetryQueue = "alert:retry_queue:delayed"
+
+	// List of notifications that exhausted their retry attempts
+	alertDeadLetterQueue = "alert:retry_queue:dead"
+
+	// Cap on the dead-letter list so it cannot grow without bound
+	deadLetterMaxLen = 10000
+
+	// Max entries processed per retry run
+	retryBatchSize = 100
 
 	// TTL for notification messages
 	notificationTTL = 24 * time.Hour
@@ -30,25 +45,107 @@ type NotificationPayload struct {
 	AlertType      string    `json:"alert_type"`
 	ConditionValue float64   `json:"condition_value"`
 	TriggeredPrice float64   `json:"triggered_price"`
+	QuoteAsset     string    `json:"quote_asset,omitempty"`
 	TriggeredAt    time.Time `json:"triggered_at"`
 	CreatedAt      time.Time `json:"created_at"`
+	Priority       string    `json:"priority,omitempty"`
+	AlertName      string    `json:"alert_name,omitempty"`
+	Detail         string    `json:"detail,omitempty"`
+	RequestID      string    `json:"request_id,omitempty"`
+	// Start of the pipeline latency, nil when not fired by a Binance tick
+	TickReceivedAt *time.Time `json:"tick_received_at,omitempty"`
+}
+
+// RetryPolicy controls how failed notifications are retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of retries before an entry is dead-lettered
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy retries for roughly an hour before giving up
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	BaseDelay:   5 * time.Second,
+	MaxDelay:    10 * time.Minute,
+}
+
+// Backoff returns the delay before the given retry attempt (1-based)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
 }
 
-// Publisher publishes alert events to Redis for notification service
+// RetryEntry wraps a failed notification with its retry metadata
+type RetryEntry struct {
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	FirstFailedAt time.Time       `json:"first_failed_at"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+}
+
+// Publisher publishes alert events on the event bus for notification
+// service. Failed publishes are retried from a queue kept in Redis
 type Publisher struct {
-	client *redis.Client
-	logger *slog.Logger
+	client  *redis.Client
+	bus     eventbus.Publisher
+	logger  *slog.Logger
+	retry   RetryPolicy
+	retryMu sync.RWMutex
 }
 
 // NewPublisher creates a new notification publisher
-func NewPublisher(client *redis.Client, logger *slog.Logger) *Publisher {
+func NewPublisher(client *redis.Client, bus eventbus.Publisher, logger *slog.Logger) *Publisher {
 	return &Publisher{
 		client: client,
+		bus:    bus,
 		logger: logger,
+		retry:  DefaultRetryPolicy,
 	}
 }
 
-// Publish publishes a trigger event to Redis
+// SetRetryPolicy replaces the default retry policy
+func (p *Publisher) SetRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+	p.retryMu.Lock()
+	p.retry = policy
+	p.retryMu.Unlock()
+}
+
+// retryPolicy returns the current retry policy
+func (p *Publisher) retryPolicy() RetryPolicy {
+	p.retryMu.RLock()
+	defer p.retryMu.RUnlock()
+	return p.retry
+}
+
+// Publish publishes a trigger event on the event bus
 func (p *Publisher) Publish(ctx context.Context, event *TriggerEvent) error {
 	payload := NotificationPayload{
 		EventID:        generateEventID(event),
@@ -58,8 +155,16 @@ func (p *Publisher) Publish(ctx context.Context, event *TriggerEvent) error {
 		AlertType:      string(event.AlertType),
 		ConditionValue: event.ConditionValue,
 		TriggeredPrice: event.TriggeredPrice,
+		QuoteAsset:     event.QuoteAsset,
 		TriggeredAt:    event.TriggeredAt,
 		CreatedAt:      time.Now(),
+		Priority:       event.Priority,
+		AlertName:      event.AlertName,
+		Detail:         event.Detail,
+		RequestID:      event.RequestID,
+	}
+	if !event.TickReceivedAt.IsZero() {
+		payload.TickReceivedAt = &event.TickReceivedAt
 	}
 
 	data, err := json.Marshal(payload)
@@ -67,16 +172,21 @@ func (p *Publisher) Publish(ctx context.Context, event *TriggerEvent) error {
 		return fmt.Errorf("failed to marshal notification payload: %w", err)
 	}
 
-	// Publish to Redis pub/sub channel
-	if err := p.client.Publish(ctx, alertNotificationChannel, data).Err(); err != nil {
+	if err := p.bus.Publish(ctx, alertNotificationChannel, data); err != nil {
 		// If publish fails, add to retry queue
 		p.logger.Error("failed to publish notification, adding to retry queue",
 			slog.Int64("alert_id", event.AlertID),
+			slog.String("request_id", event.RequestID),
 			slog.String("error", err.Error()),
 		)
-		if retryErr := p.addToRetryQueue(ctx, data); retryErr != nil {
+		entry := &RetryEntry{
+			Payload:       data,
+			FirstFailedAt: time.Now(),
+		}
+		if retryErr := p.scheduleRetry(ctx, entry, err); retryErr != nil {
 			p.logger.Error("CRITICAL: failed to add to retry queue - notification lost",
 				slog.Int64("alert_id", event.AlertID),
+				slog.String("request_id", event.RequestID),
 				slog.String("publish_error", err.Error()),
 				slog.String("retry_error", retryErr.Error()),
 			)
@@ -94,13 +204,62 @@ func (p *Publisher) Publish(ctx context.Context, event *TriggerEvent) error {
 	return nil
 }
 
-// addToRetryQueue adds a failed notification to the retry queue
-func (p *Publisher) addToRetryQueue(ctx context.Context, data []byte) error {
-	return p.client.RPush(ctx, alertRetryQueue, data).Err()
+// scheduleRetry records a failed attempt and schedules the next one with
+// exponential backoff, or moves the entry to the dead-letter queue once the
+// attempts are exhausted
+func (p *Publisher) scheduleRetry(ctx context.Context, entry *RetryEntry, cause error) error {
+	entry.Attempts++
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+
+	policy := p.retryPolicy()
+	if entry.Attempts > policy.MaxAttempts {
+		return p.deadLetter(ctx, entry)
+	}
+
+	entry.NextAttemptAt = time.Now().Add(policy.Backoff(entry.Attempts))
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retry entry: %w", err)
+	}
+
+	return p.client.ZAdd(ctx, alertRetryQueue, redis.Z{
+		Score:  float64(entry.NextAttemptAt.UnixMilli()),
+		Member: data,
+	}).Err()
 }
 
-// ProcessRetryQueue processes failed notifications in the retry queue
+// deadLetter moves an entry that exhausted its attempts to the dead-letter list
+func (p *Publisher) deadLetter(ctx context.Context, entry *RetryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retry entry: %w", err)
+	}
+
+	pipe := p.client.TxPipeline()
+	pipe.LPush(ctx, alertDeadLetterQueue, data)
+	pipe.LTrim(ctx, alertDeadLetterQueue, 0, deadLetterMaxLen-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to dead-letter notification: %w", err)
+	}
+
+	p.logger.Error("notification dead-lettered after max retry attempts",
+		slog.Int("attempts", entry.Attempts-1),
+		slog.Time("first_failed_at", entry.FirstFailedAt),
+		slog.String("last_error", entry.LastError),
+	)
+
+	return nil
+}
+
+// ProcessRetryQueue republishes failed notifications whose backoff has elapsed
 func (p *Publisher) ProcessRetryQueue(ctx context.Context) error {
+	if err := p.drainLegacyQueue(ctx); err != nil {
+		return err
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -108,41 +267,104 @@ func (p *Publisher) ProcessRetryQueue(ctx context.Context) error {
 		default:
 		}
 
-		// Pop from retry queue
-		data, err := p.client.LPop(ctx, alertRetryQueue).Bytes()
+		due, err := p.client.ZRangeByScore(ctx, alertRetryQueue, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Count: retryBatchSize,
+		}).Result()
 		if err != nil {
-			if err == redis.Nil {
-				// Queue is empty
-				return nil
+			return fmt.Errorf("failed to read retry queue: %w", err)
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		for _, member := range due {
+			if err := p.retryEntry(ctx, member); err != nil {
+				return err
 			}
-			return fmt.Errorf("failed to pop from retry queue: %w", err)
 		}
 
-		// Try to publish again
-		if err := p.client.Publish(ctx, alertNotificationChannel, data).Err(); err != nil {
-			// Put back at the end of queue with error handling
-			if rpushErr := p.client.RPush(ctx, alertRetryQueue, data).Err(); rpushErr != nil {
-				p.logger.Error("CRITICAL: failed to re-queue notification after retry failure",
-					slog.String("publish_error", err.Error()),
-					slog.String("rpush_error", rpushErr.Error()),
-				)
-				// Don't continue the loop - return error to prevent infinite loop
-				return fmt.Errorf("failed to re-queue notification: %w", rpushErr)
+		if len(due) < retryBatchSize {
+			return nil
+		}
+	}
+}
+
+// retryEntry claims a due entry and attempts to republish it
+func (p *Publisher) retryEntry(ctx context.Context, member string) error {
+	// ZREM doubles as a claim so concurrent processors never retry twice
+	removed, err := p.client.ZRem(ctx, alertRetryQueue, member).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim retry entry: %w", err)
+	}
+	if removed == 0 {
+		return nil
+	}
+
+	var entry RetryEntry
+	if err := json.Unmarshal([]byte(member), &entry); err != nil {
+		p.logger.Error("dropping malformed retry entry", slog.String("error", err.Error()))
+		return nil
+	}
+
+	pubErr := p.bus.Publish(ctx, alertNotificationChannel, entry.Payload)
+	if pubErr == nil {
+		p.logger.Debug("retried notification published successfully",
+			slog.Int("attempt", entry.Attempts+1),
+		)
+		return nil
+	}
+
+	if err := p.scheduleRetry(ctx, &entry, pubErr); err != nil {
+		p.logger.Error("CRITICAL: failed to re-queue notification after retry failure",
+			slog.String("publish_error", pubErr.Error()),
+			slog.String("queue_error", err.Error()),
+		)
+		return fmt.Errorf("failed to re-queue notification: %w", err)
+	}
+
+	p.logger.Warn("retry publish failed, rescheduled",
+		slog.Int("attempt", entry.Attempts),
+		slog.Time("next_attempt_at", entry.NextAttemptAt),
+		slog.String("error", pubErr.Error()),
+	)
+
+	return nil
+}
+
+// drainLegacyQueue moves entries left in the old list-based retry queue into
+// the delayed queue
+func (p *Publisher) drainLegacyQueue(ctx context.Context) error {
+	for {
+		data, err := p.client.LPop(ctx, alertRetryQueueLegacy).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return nil
 			}
-			p.logger.Error("retry publish failed, re-queued",
-				slog.String("error", err.Error()),
-			)
-			time.Sleep(time.Second)
-			continue
+			return fmt.Errorf("failed to pop from legacy retry queue: %w", err)
 		}
 
-		p.logger.Debug("retried notification published successfully")
+		entry := &RetryEntry{
+			Payload:       data,
+			FirstFailedAt: time.Now(),
+		}
+		if err := p.scheduleRetry(ctx, entry, nil); err != nil {
+			// Put it back so it is not lost
+			p.client.LPush(ctx, alertRetryQueueLegacy, data)
+			return fmt.Errorf("failed to migrate legacy retry entry: %w", err)
+		}
 	}
 }
 
-// GetRetryQueueLength returns the number of items in the retry queue
+// GetRetryQueueLength returns the number of items waiting to be retried
 func (p *Publisher) GetRetryQueueLength(ctx context.Context) (int64, error) {
-	return p.client.LLen(ctx, alertRetryQueue).Result()
+	return p.client.ZCard(ctx, alertRetryQueue).Result()
+}
+
+// GetDeadLetterQueueLength returns the number of dead-lettered notifications
+func (p *Publisher) GetDeadLetterQueueLength(ctx context.Context) (int64, error) {
+	return p.client.LLen(ctx, alertDeadLetterQueue).Result()
 }
 
 // CreateTriggerHandler creates a handler function that publishes events
@@ -165,15 +387,15 @@ func generateEventID(event *TriggerEvent) string {
 
 // Subscriber subscribes to alert notifications
 type Subscriber struct {
-	client  *redis.Client
+	bus     eventbus.Subscriber
 	logger  *slog.Logger
 	handler func(payload NotificationPayload)
 }
 
 // NewSubscriber creates a new notification subscriber
-func NewSubscriber(client *redis.Client, logger *slog.Logger) *Subscriber {
+func NewSubscriber(bus eventbus.Subscriber, logger *slog.Logger) *Subscriber {
 	return &Subscriber{
-		client: client,
+		bus:    bus,
 		logger: logger,
 	}
 }
@@ -185,37 +407,19 @@ func (s *Subscriber) SetHandler(handler func(payload NotificationPayload)) {
 
 // Subscribe starts listening for notifications
 func (s *Subscriber) Subscribe(ctx context.Context) error {
-	pubsub := s.client.Subscribe(ctx, alertNotificationChannel)
-	defer pubsub.Close()
-
 	s.logger.Info("subscribed to alert notifications")
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		msg, err := pubsub.ReceiveMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-			s.logger.Error("failed to receive message", slog.String("error", err.Error()))
-			continue
-		}
-
+	return s.bus.Subscribe(ctx, alertNotificationChannel, func(_ context.Context, msg eventbus.Message) {
 		var payload NotificationPayload
-		if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+		if err := json.Unmarshal(msg.Data, &payload); err != nil {
 			s.logger.Error("failed to unmarshal notification",
 				slog.String("error", err.Error()),
 			)
-			continue
+			return
 		}
 
 		if s.handler != nil {
 			s.handler(payload)
 		}
-	}
+	})
 }
@@ -0,0 +1,120 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+func newTestPublisher(t *testing.T) (*Publisher, *redis.Client) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err, "failed to start miniredis")
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+
+	return NewPublisher(client, eventbus.NewRedisPubSub(client), slog.New(slog.NewTextHandler(io.Discard, nil))), client
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 8*time.Second, policy.Backoff(4))
+	assert.Equal(t, 10*time.Second, policy.Backoff(5))
+	assert.Equal(t, 10*time.Second, policy.Backoff(50))
+}
+
+func TestPublisher_ScheduleRetryThenDeadLetter(t *testing.T) {
+	publisher, client := newTestPublisher(t)
+	publisher.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Minute})
+	ctx := context.Background()
+
+	entry := &RetryEntry{Payload: json.RawMessage(`{"event_id":"1"}`), FirstFailedAt: time.Now()}
+	cause := errors.New("redis down")
+
+	require.NoError(t, publisher.scheduleRetry(ctx, entry, cause))
+	require.NoError(t, publisher.scheduleRetry(ctx, entry, cause))
+
+	queued, err := publisher.GetRetryQueueLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queued, "each attempt is a distinct delayed entry")
+	assert.Equal(t, 2, entry.Attempts)
+
+	// Third failure exceeds MaxAttempts
+	require.NoError(t, publisher.scheduleRetry(ctx, entry, cause))
+
+	dead, err := publisher.GetDeadLetterQueueLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dead)
+
+	raw, err := client.LIndex(ctx, alertDeadLetterQueue, 0).Bytes()
+	require.NoError(t, err)
+	var dl RetryEntry
+	require.NoError(t, json.Unmarshal(raw, &dl))
+	assert.Equal(t, "redis down", dl.LastError)
+	assert.JSONEq(t, `{"event_id":"1"}`, string(dl.Payload))
+}
+
+func TestPublisher_ProcessRetryQueueOnlyRetriesDueEntries(t *testing.T) {
+	publisher, client := newTestPublisher(t)
+	ctx := context.Background()
+
+	due, _ := json.Marshal(RetryEntry{Payload: json.RawMessage(`{"event_id":"due"}`), Attempts: 1})
+	later, _ := json.Marshal(RetryEntry{Payload: json.RawMessage(`{"event_id":"later"}`), Attempts: 1})
+	require.NoError(t, client.ZAdd(ctx, alertRetryQueue,
+		redis.Z{Score: float64(time.Now().Add(-time.Second).UnixMilli()), Member: due},
+		redis.Z{Score: float64(time.Now().Add(time.Hour).UnixMilli()), Member: later},
+	).Err())
+
+	pubsub := client.Subscribe(ctx, alertNotificationChannel)
+	defer pubsub.Close()
+	_, err := pubsub.Receive(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, publisher.ProcessRetryQueue(ctx))
+
+	select {
+	case msg := <-pubsub.Channel():
+		assert.JSONEq(t, `{"event_id":"due"}`, msg.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("due entry was not republished")
+	}
+
+	remaining, err := client.ZRange(ctx, alertRetryQueue, 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, string(later), remaining[0])
+}
+
+func TestPublisher_DrainsLegacyQueue(t *testing.T) {
+	publisher, client := newTestPublisher(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.RPush(ctx, alertRetryQueueLegacy, `{"event_id":"old"}`).Err())
+	require.NoError(t, publisher.ProcessRetryQueue(ctx))
+
+	legacy, err := client.LLen(ctx, alertRetryQueueLegacy).Result()
+	require.NoError(t, err)
+	assert.Zero(t, legacy)
+
+	queued, err := publisher.GetRetryQueueLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queued)
+}
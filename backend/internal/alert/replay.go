@@ -0,0 +1,169 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/pricehistory"
+)
+
+// Decision is what the engine did with an alert at a replayed price
+type Decision string
+
+const (
+	DecisionNotCreated      Decision = "not_created"      // before the alert was created
+	DecisionPaused          Decision = "paused"           // paused after firing
+	DecisionExpired         Decision = "expired"          // past its expiry or trigger limit
+	DecisionOutsideSchedule Decision = "outside_schedule" // outside its schedule windows
+	DecisionAwaitingRearm   Decision = "awaiting_rearm"   // fired; condition has not cleared yet
+	DecisionCooldown        Decision = "cooldown"         // periodic interval not elapsed
+	DecisionNoHistory       Decision = "no_history"       // change over the timeframe not known yet
+	DecisionNotMet          Decision = "not_met"          // condition checked and false
+	DecisionFired           Decision = "fired"
+)
+
+// ReplayStep is the decision for one point of price history
+type ReplayStep struct {
+	Time     time.Time
+	Price    float64
+	Change   *float64 // percent change over the condition timeframe, for change alerts
+	Rearmed  bool     // the condition cleared and the alert was re-armed first
+	Decision Decision
+}
+
+// ReplayResult is the trace of replaying an alert over price history
+type ReplayResult struct {
+	Steps    []ReplayStep
+	Counts   map[Decision]int
+	Triggers int
+}
+
+// Changes returns the steps whose decision differs from the step before,
+// and steps that re-armed the alert
+func (r *ReplayResult) Changes() []ReplayStep {
+	changes := []ReplayStep{}
+	for i, step := range r.Steps {
+		if i == 0 || step.Rearmed || step.Decision != r.Steps[i-1].Decision {
+			changes = append(changes, step)
+		}
+	}
+	return changes
+}
+
+// Replay runs an alert through the evaluator at each point of price
+// history, oldest first, and records why it did or did not fire. Unlike
+// Backtest it keeps the alert's creation time, expiry and trigger limit,
+// and it does not stop after a one-shot alert fires. The alert starts
+// armed and untriggered since its state at the first point is not stored
+func Replay(ctx context.Context, alert Alert, points []pricehistory.Point, logger *slog.Logger) (*ReplayResult, error) {
+	switch alert.AlertType {
+	case AlertTypePriceAbove, AlertTypePriceBelow, AlertTypePriceChangePct, AlertTypePeriodic:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrBacktestUnsupported, alert.AlertType)
+	}
+
+	replay := &replayHistory{points: points}
+	evaluator := &Evaluator{
+		history: replay,
+		clock:   replay,
+		logger:  logger,
+	}
+
+	alert.IsPaused = false
+	alert.TriggerState = TriggerStateArmed
+	alert.LastTriggeredAt = nil
+	alert.TimesTriggered = 0
+
+	timeframe := parseTimeframe(alert.ConditionTimeframe)
+	result := &ReplayResult{
+		Steps:  make([]ReplayStep, 0, len(points)),
+		Counts: make(map[Decision]int),
+	}
+	for i, point := range points {
+		if i%backtestCtxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		replay.index = i
+		step := ReplayStep{Time: point.Time, Price: point.Price}
+
+		if point.Time.Before(alert.CreatedAt) {
+			step.Decision = DecisionNotCreated
+			result.record(step)
+			continue
+		}
+
+		// Zero until the history covers a day
+		changePercent, _ := replay.change(24 * time.Hour)
+		data := &binance.PriceData{
+			Symbol:        alert.BinanceSymbol,
+			Price:         point.Price,
+			ChangePercent: changePercent,
+		}
+
+		var changeErr error
+		if alert.AlertType == AlertTypePriceChangePct {
+			change := changePercent
+			if timeframe != 0 {
+				change, changeErr = replay.change(timeframe)
+			}
+			if changeErr == nil {
+				step.Change = &change
+			}
+		}
+
+		rearm, err := evaluator.ShouldRearm(ctx, &alert, data)
+		if err != nil {
+			return nil, err
+		}
+		if rearm {
+			alert.TriggerState = TriggerStateArmed
+			step.Rearmed = true
+		}
+
+		if step.Decision = evaluator.held(&alert); step.Decision != "" {
+			result.record(step)
+			continue
+		}
+		if !alert.Schedule.Active(point.Time, alert.Location) {
+			step.Decision = DecisionOutsideSchedule
+			result.record(step)
+			continue
+		}
+
+		triggered, err := evaluator.checkCondition(ctx, &alert, data)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case triggered:
+			step.Decision = DecisionFired
+			at := point.Time
+			alert.IsPaused = alert.pauseAfterTrigger()
+			alert.TimesTriggered++
+			alert.LastTriggeredAt = &at
+			alert.TriggerState = alert.stateAfterTrigger()
+		case changeErr != nil:
+			step.Decision = DecisionNoHistory
+		default:
+			step.Decision = DecisionNotMet
+		}
+		result.record(step)
+	}
+
+	return result, nil
+}
+
+// record appends a step and counts its decision
+func (r *ReplayResult) record(step ReplayStep) {
+	r.Steps = append(r.Steps, step)
+	r.Counts[step.Decision]++
+	if step.Decision == DecisionFired {
+		r.Triggers++
+	}
+}
@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decisions returns the decision of each step
+func decisions(result *ReplayResult) []Decision {
+	out := make([]Decision, len(result.Steps))
+	for i, step := range result.Steps {
+		out[i] = step.Decision
+	}
+	return out
+}
+
+func TestReplay(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	points := minutePoints(start, 90, 101, 105, 95, 99, 102, 98)
+
+	// Recurring, created after the first point
+	a := Alert{AlertType: AlertTypePriceAbove, ConditionValue: 100, IsRecurring: true, CreatedAt: start.Add(30 * time.Second)}
+	result, err := Replay(ctx, a, points, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []Decision{
+		DecisionNotCreated, DecisionFired, DecisionAwaitingRearm, DecisionNotMet,
+		DecisionNotMet, DecisionFired, DecisionNotMet,
+	}, decisions(result))
+	assert.Equal(t, 2, result.Triggers)
+	assert.True(t, result.Steps[3].Rearmed)
+	assert.Equal(t, 3, result.Counts[DecisionNotMet])
+
+	changes := result.Changes()
+	require.Len(t, changes, 6)
+	assert.Equal(t, start.Add(3*time.Minute), changes[3].Time)
+	assert.Equal(t, start.Add(5*time.Minute), changes[4].Time)
+
+	// One-shot: paused after firing, and expired once past its expiry
+	expires := start.Add(4 * time.Minute)
+	a = Alert{AlertType: AlertTypePriceAbove, ConditionValue: 100, ExpiresAt: &expires}
+	result, err = Replay(ctx, a, points, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []Decision{
+		DecisionNotMet, DecisionFired, DecisionPaused, DecisionPaused,
+		DecisionPaused, DecisionPaused, DecisionPaused,
+	}, decisions(result))
+
+	a.IsRecurring = true
+	result, err = Replay(ctx, a, points, logger)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionExpired, result.Steps[5].Decision)
+
+	// Percent change: unknown until the history covers the timeframe
+	a = Alert{AlertType: AlertTypePriceChangePct, ConditionValue: 10, ConditionTimeframe: "5m"}
+	result, err = Replay(ctx, a, points, logger)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionNoHistory, result.Steps[4].Decision)
+	assert.Nil(t, result.Steps[4].Change)
+	assert.Equal(t, DecisionFired, result.Steps[5].Decision)
+	require.NotNil(t, result.Steps[5].Change)
+	assert.InDelta(t, 13.33, *result.Steps[5].Change, 0.01)
+
+	// Volume has no stored history
+	_, err = Replay(ctx, Alert{AlertType: AlertTypeVolumeSpike}, points, logger)
+	assert.ErrorIs(t, err, ErrBacktestUnsupported)
+}
@@ -0,0 +1,483 @@
+package alert
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+)
+
+const (
+	// DefaultTradeStreamNearPct is how close, in percent of the threshold,
+	// the price must come to an alert for its symbol to be streamed trade by
+	// trade
+	DefaultTradeStreamNearPct = 0.5
+
+	// DefaultBookTickerNearPct is how close, in percent of the threshold, the
+	// price must come to an alert for its symbol to also stream the best bid
+	// and ask
+	DefaultBookTickerNearPct = 0.1
+
+	// maxHotSymbols caps the symbols streamed above the ticker tier; busy
+	// pairs send hundreds of trades and book updates a second
+	maxHotSymbols = 100
+
+	// tickerInterval is how often Binance sends a ticker, and how often a
+	// symbol streamed trade by trade is handled as one
+	tickerInterval = time.Second
+)
+
+// streamTier is how closely the price of a symbol is streamed. Symbols start
+// on the ticker and move up as the price nears one of their alert thresholds
+type streamTier int
+
+const (
+	// tierTicker streams the 24h ticker, once a second
+	tierTicker streamTier = iota
+	// tierTrade streams every trade instead of the ticker
+	tierTrade
+	// tierBook also streams the best bid and ask, which cross a target
+	// before a trade prints there
+	tierBook
+)
+
+func (t streamTier) String() string {
+	switch t {
+	case tierTrade:
+		return "trade"
+	case tierBook:
+		return "book"
+	default:
+		return "ticker"
+	}
+}
+
+// streamSet is the Binance streams open for a symbol
+type streamSet uint8
+
+const (
+	streamTicker streamSet = 1 << iota
+	streamTrade
+	streamBook
+)
+
+func (s streamSet) String() string {
+	var names []string
+	if s&streamTicker != 0 {
+		names = append(names, "ticker")
+	}
+	if s&streamTrade != 0 {
+		names = append(names, "trade")
+	}
+	if s&streamBook != 0 {
+		names = append(names, "book")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "+")
+}
+
+// streamTiers tracks the symbols streamed above the ticker tier. Binance
+// sends tickers once a second; trades and book updates let alerts close to
+// their threshold fire within milliseconds of the crossing, while symbols
+// far from any threshold cost one message a second
+type streamTiers struct {
+	mu       sync.Mutex
+	tradePct float64
+	bookPct  float64
+	hot      map[string]*hotSymbol
+}
+
+// hotSymbol is a symbol above the ticker tier
+type hotSymbol struct {
+	tier    streamTier
+	streams streamSet
+	// Last ticker, whose 24h statistics trades and book updates are
+	// evaluated with. Moved to each trade while trades replace the ticker
+	ticker binance.PriceData
+	// When the symbol was last handled as a ticker
+	tickedAt time.Time
+}
+
+func newStreamTiers() *streamTiers {
+	return &streamTiers{
+		tradePct: DefaultTradeStreamNearPct,
+		bookPct:  DefaultBookTickerNearPct,
+		hot:      make(map[string]*hotSymbol),
+	}
+}
+
+// nearPct returns how close the price must come to a threshold for a
+// symbol to be streamed on tier
+func (t *streamTiers) nearPct(tier streamTier) float64 {
+	switch tier {
+	case tierTrade:
+		return t.tradePct
+	case tierBook:
+		return t.bookPct
+	default:
+		return 0
+	}
+}
+
+// streamsFor returns the streams open for a symbol on tier. Trades replace
+// the ticker when they are enabled; with trade streams disabled the book
+// tier streams the ticker and the best bid and ask
+func (t *streamTiers) streamsFor(tier streamTier) streamSet {
+	streams := streamTicker
+	if tier >= tierTrade && t.tradePct > 0 {
+		streams = streamTrade
+	}
+	if tier == tierBook {
+		streams |= streamBook
+	}
+	return streams
+}
+
+// tierFor returns the tier of a symbol currently on from whose nearest
+// alert threshold is distance percent away. A symbol only drops below a
+// tier once the distance is twice that tier's, so a price hovering at the
+// edge does not flap between streams
+func (t *streamTiers) tierFor(from streamTier, distance float64) streamTier {
+	for tier := tierBook; tier > tierTicker; tier-- {
+		pct := t.nearPct(tier)
+		if pct <= 0 {
+			continue
+		}
+		if distance <= pct || tier <= from && distance <= 2*pct {
+			return tier
+		}
+	}
+	return tierTicker
+}
+
+// SetTradeStreamNearPct sets how close, in percent of the threshold, the
+// price must come to an alert for its symbol to be streamed trade by trade
+// (0 disables trade streams). Tiers are updated on the next ticker of each
+// symbol
+func (e *Engine) SetTradeStreamNearPct(pct float64) {
+	e.tiers.mu.Lock()
+	defer e.tiers.mu.Unlock()
+	e.tiers.tradePct = pct
+}
+
+// SetBookTickerNearPct sets how close, in percent of the threshold, the
+// price must come to an alert for its symbol to also stream the best bid
+// and ask (0 disables book ticker streams)
+func (e *Engine) SetBookTickerNearPct(pct float64) {
+	e.tiers.mu.Lock()
+	defer e.tiers.mu.Unlock()
+	e.tiers.bookPct = pct
+}
+
+// GetTradeSymbolCount returns the number of symbols streamed trade by trade
+func (e *Engine) GetTradeSymbolCount() int {
+	return e.countStreams(streamTrade)
+}
+
+// GetBookTickerSymbolCount returns the number of symbols streamed with their
+// best bid and ask
+func (e *Engine) GetBookTickerSymbolCount() int {
+	return e.countStreams(streamBook)
+}
+
+// countStreams returns the number of hot symbols with stream open
+func (e *Engine) countStreams(stream streamSet) int {
+	e.tiers.mu.Lock()
+	defer e.tiers.mu.Unlock()
+
+	count := 0
+	for _, hot := range e.tiers.hot {
+		if hot.streams&stream != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// updateStreamTier moves the symbol of a ticker to the tier matching the
+// distance to the nearest threshold of its alerts
+func (e *Engine) updateStreamTier(data binance.PriceData, alerts []*Alert) {
+	if isFallbackSymbol(data.Symbol) {
+		return
+	}
+	distance := thresholdDistance(alerts, data)
+
+	t := e.tiers
+	t.mu.Lock()
+	from, fromStreams := tierTicker, streamTicker
+	if hot, ok := t.hot[data.Symbol]; ok {
+		from, fromStreams = hot.tier, hot.streams
+	}
+	to := t.tierFor(from, distance)
+	if from == tierTicker && len(t.hot) >= maxHotSymbols {
+		to = tierTicker
+	}
+	toStreams := t.streamsFor(to)
+	if to == tierTicker {
+		delete(t.hot, data.Symbol)
+	} else {
+		t.hot[data.Symbol] = &hotSymbol{tier: to, streams: toStreams, ticker: data, tickedAt: e.clock.Now()}
+	}
+	t.mu.Unlock()
+
+	if from != to {
+		e.logger.Debug("switched stream tier",
+			slog.String("symbol", data.Symbol),
+			slog.String("from", from.String()),
+			slog.String("to", to.String()),
+		)
+	}
+	e.switchStreams([]string{data.Symbol}, fromStreams, toStreams)
+}
+
+// resetStreamTiers closes the streams of hot symbols that are no longer
+// streamed at all. Their tickers are unsubscribed by the caller
+func (e *Engine) resetStreamTiers(symbols []string) {
+	t := e.tiers
+	t.mu.Lock()
+	byStreams := make(map[streamSet][]string)
+	for _, symbol := range symbols {
+		if hot, ok := t.hot[symbol]; ok {
+			byStreams[hot.streams] = append(byStreams[hot.streams], symbol)
+			delete(t.hot, symbol)
+		}
+	}
+	t.mu.Unlock()
+
+	for streams, symbols := range byStreams {
+		e.switchStreams(symbols, streams&^streamTicker, 0)
+	}
+}
+
+// switchStreams opens the streams of symbols in to but not in from, and
+// closes those in from but not in to
+func (e *Engine) switchStreams(symbols []string, from, to streamSet) {
+	if from == to {
+		return
+	}
+
+	streams := []struct {
+		stream      streamSet
+		subscribe   func([]string) error
+		unsubscribe func([]string) error
+	}{
+		// Opened before the ticker is closed, so prices keep flowing
+		{streamTrade, e.binanceClient.SubscribeTrades, e.binanceClient.UnsubscribeTrades},
+		{streamTicker, e.binanceClient.Subscribe, e.binanceClient.Unsubscribe},
+		{streamBook, e.binanceClient.SubscribeBookTicker, e.binanceClient.UnsubscribeBookTicker},
+	}
+	for _, s := range streams {
+		var err error
+		switch {
+		case from&s.stream == 0 && to&s.stream != 0:
+			err = s.subscribe(symbols)
+		case from&s.stream != 0 && to&s.stream == 0:
+			err = s.unsubscribe(symbols)
+		default:
+			continue
+		}
+		if err != nil {
+			e.logger.Error("failed to switch price stream",
+				slog.Any("symbols", symbols),
+				slog.String("stream", s.stream.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// hotTicker returns the last ticker and tier of a symbol above the ticker
+// tier
+func (e *Engine) hotTicker(symbol string) (binance.PriceData, streamTier, bool) {
+	e.tiers.mu.Lock()
+	defer e.tiers.mu.Unlock()
+
+	hot, ok := e.tiers.hot[symbol]
+	if !ok {
+		return binance.PriceData{}, tierTicker, false
+	}
+	return hot.ticker, hot.tier, true
+}
+
+// tradeTicker moves the last ticker of a hot symbol to the price of a
+// trade. When trades replace the symbol's ticker, it also reports whether
+// the trade is due to be handled as the ticker, once per tickerInterval
+func (e *Engine) tradeTicker(trade binance.TradeData) (binance.PriceData, bool) {
+	now := e.clock.Now()
+
+	e.tiers.mu.Lock()
+	defer e.tiers.mu.Unlock()
+
+	hot, ok := e.tiers.hot[trade.Symbol]
+	if !ok {
+		return binance.PriceData{}, false
+	}
+	data := atPrice(hot.ticker, trade.Price)
+	data.UpdatedAt = trade.TradeTime
+	data.ReceivedAt = trade.ReceivedAt
+
+	if hot.streams&streamTicker != 0 {
+		return data, false
+	}
+	hot.ticker = data
+	if now.Sub(hot.tickedAt) < tickerInterval {
+		return data, false
+	}
+	hot.tickedAt = now
+	return data, true
+}
+
+// hotAlerts returns the armed alerts of a symbol that keep selects.
+// Re-arming is left to the ticker, so a price bouncing around a threshold
+// between trades does not fire a recurring alert repeatedly
+func (e *Engine) hotAlerts(symbol string, keep func(*Alert) bool) []*Alert {
+	var alerts []*Alert
+	for _, alert := range e.alertsForSymbol(symbol) {
+		if keep(alert) && alert.TriggerState != TriggerStateFired {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// handleTrade evaluates the alerts of a trade's symbol at the trade price.
+// Symbols streamed trade by trade get no ticker; once a second a trade
+// stands in for it, carrying the 24h statistics of the last real ticker,
+// and updates the cache, history and WebSocket clients like one
+func (e *Engine) handleTrade(trade binance.TradeData) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	if _, _, ok := e.hotTicker(trade.Symbol); !ok {
+		// Trades still arriving after the stream was stopped
+		return
+	}
+
+	if !e.anomalyFilter.Allow(trade.Symbol, trade.Price, e.clock.Now()) {
+		return
+	}
+
+	data, tick := e.tradeTicker(trade)
+	if tick {
+		e.processPrice(ctx, data)
+		return
+	}
+	e.evaluatePrice(ctx, data, e.hotAlerts(trade.Symbol, tracksPrice))
+}
+
+// handleBookTicker evaluates the price targets of a symbol on the book tier
+// at its best bid and ask. A target counts as crossed once the whole spread
+// has: the price is above a target when even the best bid is, and below
+// when even the best ask is
+func (e *Engine) handleBookTicker(book binance.BookTickerData) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	data, tier, ok := e.hotTicker(book.Symbol)
+	if !ok || tier < tierBook || book.BidPrice <= 0 || book.AskPrice <= 0 {
+		return
+	}
+	data.UpdatedAt = book.ReceivedAt
+	data.ReceivedAt = book.ReceivedAt
+
+	sides := []struct {
+		alertType AlertType
+		price     float64
+	}{
+		{AlertTypePriceAbove, book.BidPrice},
+		{AlertTypePriceBelow, book.AskPrice},
+	}
+	for _, side := range sides {
+		alerts := e.hotAlerts(book.Symbol, func(alert *Alert) bool {
+			return alert.AlertType == side.alertType
+		})
+		if len(alerts) == 0 || !e.anomalyFilter.Allow(book.Symbol, side.price, e.clock.Now()) {
+			continue
+		}
+		e.evaluatePrice(ctx, atPrice(data, side.price), alerts)
+	}
+}
+
+// isPriceTarget reports whether an alert fires on the price crossing a
+// target
+func isPriceTarget(alert *Alert) bool {
+	return alert.AlertType == AlertTypePriceAbove || alert.AlertType == AlertTypePriceBelow
+}
+
+// isDailyChange reports whether an alert fires on the 24h price change,
+// which a trade moves as much as the price
+func isDailyChange(alert *Alert) bool {
+	return alert.AlertType == AlertTypePriceChangePct && parseTimeframe(alert.ConditionTimeframe) == 0
+}
+
+// tracksPrice reports whether an alert can be evaluated at a trade price
+// with the statistics of the last ticker
+func tracksPrice(alert *Alert) bool {
+	return isPriceTarget(alert) || isDailyChange(alert)
+}
+
+// thresholds returns the prices at which an alert fires, given the 24h
+// statistics of data
+func thresholds(alert *Alert, data binance.PriceData) []float64 {
+	switch {
+	case isPriceTarget(alert):
+		return []float64{alert.ConditionValue}
+	case isDailyChange(alert):
+		open := data.Price - data.PriceChange
+		return []float64{
+			open * (1 + alert.ConditionValue/100),
+			open * (1 - alert.ConditionValue/100),
+		}
+	default:
+		return nil
+	}
+}
+
+// thresholdDistance returns how far, in percent of the threshold, the price
+// of data is from the nearest threshold of an armed alert; +Inf without one
+func thresholdDistance(alerts []*Alert, data binance.PriceData) float64 {
+	distance := math.Inf(1)
+	for _, alert := range alerts {
+		if alert.IsPaused || alert.TriggerState == TriggerStateFired {
+			continue
+		}
+		for _, level := range thresholds(alert, data) {
+			if level <= 0 {
+				continue
+			}
+			distance = min(distance, math.Abs(data.Price-level)/level*100)
+		}
+	}
+	return distance
+}
+
+// atPrice returns a ticker moved to price, its 24h statistics following
+func atPrice(data binance.PriceData, price float64) binance.PriceData {
+	open := data.Price - data.PriceChange
+	data.Price = price
+	data.PriceChange = price - open
+	if open > 0 {
+		data.ChangePercent = data.PriceChange / open * 100
+	}
+	data.High24h = max(data.High24h, price)
+	if data.Low24h > 0 {
+		data.Low24h = min(data.Low24h, price)
+	}
+	return data
+}
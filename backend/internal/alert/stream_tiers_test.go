@@ -0,0 +1,139 @@
+package alert
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+func TestEngine_UpdateStreamTier(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := binance.NewClient(logger)
+	engine := NewEngine(nil, client, nil, nil, logger)
+	engine.SetTradeStreamNearPct(1)
+	engine.SetBookTickerNearPct(0.2)
+
+	alerts := []*Alert{
+		{ID: 1, AlertType: AlertTypeVolumeSpike, ConditionValue: 100},
+		{ID: 2, AlertType: AlertTypePriceAbove, ConditionValue: 100, TriggerState: TriggerStateArmed},
+	}
+	tick := func(price float64) {
+		engine.updateStreamTier(binance.PriceData{Symbol: "BTCUSDT", Price: price}, alerts)
+	}
+	require.NoError(t, client.Subscribe([]string{"BTCUSDT"}))
+
+	tick(95)
+	assert.Empty(t, client.GetTradeSymbols(), "target 5% away")
+
+	tick(99.5)
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetTradeSymbols())
+	assert.Empty(t, client.GetSubscribedSymbols(), "trades replace the ticker")
+	assert.Empty(t, client.GetBookTickerSymbols())
+
+	tick(99.9)
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetBookTickerSymbols())
+
+	tick(99.7)
+	assert.Equal(t, 1, engine.GetBookTickerSymbolCount(), "kept within twice the distance")
+
+	tick(99)
+	assert.Empty(t, client.GetBookTickerSymbols())
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetTradeSymbols(), "back to trades only")
+
+	tick(97)
+	assert.Empty(t, client.GetTradeSymbols())
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetSubscribedSymbols())
+
+	tick(99.9)
+	require.NoError(t, client.Unsubscribe([]string{"BTCUSDT"}))
+	engine.resetStreamTiers([]string{"BTCUSDT"})
+	assert.Empty(t, client.GetTradeSymbols())
+	assert.Empty(t, client.GetBookTickerSymbols())
+	assert.Empty(t, client.GetSubscribedSymbols(), "removed symbols get no ticker back")
+
+	alerts[1].TriggerState = TriggerStateFired
+	tick(99.9)
+	assert.Empty(t, client.GetTradeSymbols(), "fired alerts wait for the ticker to re-arm")
+}
+
+func TestEngine_UpdateStreamTier_TradesDisabled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := binance.NewClient(logger)
+	engine := NewEngine(nil, client, nil, nil, logger)
+	engine.SetTradeStreamNearPct(0)
+	engine.SetBookTickerNearPct(0.2)
+	require.NoError(t, client.Subscribe([]string{"BTCUSDT"}))
+
+	alerts := []*Alert{{ID: 1, AlertType: AlertTypePriceAbove, ConditionValue: 100}}
+	tick := func(price float64) {
+		engine.updateStreamTier(binance.PriceData{Symbol: "BTCUSDT", Price: price}, alerts)
+	}
+
+	tick(99.5)
+	assert.Equal(t, 0, engine.GetTradeSymbolCount())
+
+	tick(99.9)
+	assert.Empty(t, client.GetTradeSymbols())
+	assert.Equal(t, 0, engine.GetTradeSymbolCount())
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetBookTickerSymbols())
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetSubscribedSymbols(), "book tier keeps the ticker")
+
+	tick(99)
+	assert.Empty(t, client.GetBookTickerSymbols())
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetSubscribedSymbols())
+}
+
+func TestEngine_TradeTicker(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	engine := NewEngine(nil, binance.NewClient(logger), nil, nil, logger)
+	engine.SetTradeStreamNearPct(1)
+	engine.SetBookTickerNearPct(0)
+	now := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	engine.SetClock(fake)
+
+	alerts := []*Alert{{ID: 1, AlertType: AlertTypePriceAbove, ConditionValue: 100}}
+	engine.updateStreamTier(binance.PriceData{Symbol: "BTCUSDT", Price: 99.5, PriceChange: 4.5, High24h: 99.6}, alerts)
+
+	trade := func(price float64) (binance.PriceData, bool) {
+		return engine.tradeTicker(binance.TradeData{Symbol: "BTCUSDT", Price: price, TradeTime: fake.Now()})
+	}
+
+	data, tick := trade(99.8)
+	assert.False(t, tick, "ticker handled within the last second")
+	assert.Equal(t, 99.8, data.Price)
+	assert.Equal(t, 99.8, data.High24h)
+
+	fake.Advance(tickerInterval)
+	data, tick = trade(99.7)
+	assert.True(t, tick, "a trade stands in for the ticker once a second")
+	assert.Equal(t, 99.8, data.High24h, "24h statistics follow the trades")
+	assert.InDelta(t, 4.7, data.PriceChange, 1e-9)
+
+	_, tick = trade(99.9)
+	assert.False(t, tick)
+
+	_, tick = engine.tradeTicker(binance.TradeData{Symbol: "ETHUSDT", Price: 3000})
+	assert.False(t, tick, "not streamed trade by trade")
+}
+
+func TestThresholdDistance_DailyChange(t *testing.T) {
+	// Opened at 100, now at 104.5: a 5% move fires at 105 or 95
+	data := binance.PriceData{Symbol: "BTCUSDT", Price: 104.5, PriceChange: 4.5}
+	alerts := []*Alert{{ID: 1, AlertType: AlertTypePriceChangePct, ConditionValue: 5}}
+
+	assert.InDelta(t, 0.476, thresholdDistance(alerts, data), 0.001)
+
+	alerts[0].ConditionTimeframe = "1h"
+	assert.True(t, thresholdDistance(alerts, data) > 100, "history-based changes have no price threshold")
+
+	moved := atPrice(data, 105.5)
+	assert.InDelta(t, 5.5, moved.ChangePercent, 1e-9)
+	assert.InDelta(t, 5.5, moved.PriceChange, 1e-9)
+}
@@ -0,0 +1,166 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+const (
+	// Event bus topic the api-gateways announce the symbols their
+	// WebSocket clients view on (must match websocket package)
+	symbolDemandChannel = "symbols:demand"
+
+	// demandTTL is how long the announced symbols of a gateway are streamed
+	// without it announcing again, three of its announce intervals
+	demandTTL = 90 * time.Second
+
+	// maxDemandSymbols caps the symbols streamed for WebSocket clients
+	// alone, on top of alerts and watchlists
+	maxDemandSymbols = 200
+
+	demandRetryDelay = 5 * time.Second
+)
+
+// symbolDemand is the payload of a symbols:demand message: every price
+// symbol the clients of a gateway instance are subscribed to. Each message
+// replaces the instance's previous one
+type symbolDemand struct {
+	Instance string   `json:"instance"`
+	Symbols  []string `json:"symbols"`
+}
+
+// demandLease is the announced symbols of a gateway instance
+type demandLease struct {
+	symbols   map[string]bool
+	expiresAt time.Time
+}
+
+// SetDemandBus makes the engine stream the symbols WebSocket clients view,
+// as announced by the api-gateways on the bus
+func (e *Engine) SetDemandBus(bus eventbus.Subscriber) {
+	e.demandBus = bus
+}
+
+// GetDemandedSymbolCount returns the number of symbols WebSocket clients
+// asked for
+func (e *Engine) GetDemandedSymbolCount() int {
+	return len(e.demandedSymbols())
+}
+
+// demandLoop listens for symbol announcements until ctx is done
+func (e *Engine) demandLoop(ctx context.Context) {
+	defer e.wg.Done()
+
+	for {
+		err := e.demandBus.Subscribe(ctx, symbolDemandChannel, func(_ context.Context, msg eventbus.Message) {
+			e.handleDemand(msg.Data)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			e.logger.Error("symbol demand subscription failed", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.done:
+			return
+		case <-time.After(demandRetryDelay):
+		}
+	}
+}
+
+// handleDemand records the symbols announced by a gateway and subscribes to
+// them right away when they changed; repeated announcements only extend
+// the lease
+func (e *Engine) handleDemand(data []byte) {
+	var demand symbolDemand
+	if err := json.Unmarshal(data, &demand); err != nil || demand.Instance == "" {
+		e.logger.Warn("invalid symbol demand", slog.String("data", string(data)))
+		return
+	}
+
+	symbols := make(map[string]bool, len(demand.Symbols))
+	for _, symbol := range demand.Symbols {
+		if isDemandSymbol(symbol) {
+			symbols[symbol] = true
+		}
+	}
+
+	e.demandMu.Lock()
+	previous := e.demand[demand.Instance].symbols
+	if len(symbols) == 0 {
+		delete(e.demand, demand.Instance)
+	} else {
+		e.demand[demand.Instance] = demandLease{
+			symbols:   symbols,
+			expiresAt: e.clock.Now().Add(demandTTL),
+		}
+	}
+	e.demandMu.Unlock()
+
+	if maps.Equal(previous, symbols) {
+		return
+	}
+
+	e.refreshMu.Lock()
+	defer e.refreshMu.Unlock()
+	e.syncSubscriptions()
+}
+
+// demandedSymbols returns the symbols announced by gateways whose lease has
+// not expired, at most maxDemandSymbols
+func (e *Engine) demandedSymbols() map[string]bool {
+	now := e.clock.Now()
+
+	e.demandMu.Lock()
+	var all []string
+	seen := make(map[string]bool)
+	for instance, lease := range e.demand {
+		if now.After(lease.expiresAt) {
+			delete(e.demand, instance)
+			continue
+		}
+		for symbol := range lease.symbols {
+			if !seen[symbol] {
+				seen[symbol] = true
+				all = append(all, symbol)
+			}
+		}
+	}
+	e.demandMu.Unlock()
+
+	// Sorted so the same symbols are kept while over the cap
+	sort.Strings(all)
+	if len(all) > maxDemandSymbols {
+		all = all[:maxDemandSymbols]
+	}
+
+	symbols := make(map[string]bool, len(all))
+	for _, symbol := range all {
+		symbols[symbol] = true
+	}
+	return symbols
+}
+
+// isDemandSymbol reports whether s looks like a Binance symbol, e.g.
+// BTCUSDT
+func isDemandSymbol(s string) bool {
+	if len(s) < 2 || len(s) > 20 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package alert
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+func TestEngine_HandleDemand(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := binance.NewClient(logger)
+	engine := NewEngine(nil, client, nil, nil, logger)
+	clk := clock.NewFake(time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC))
+	engine.SetClock(clk)
+
+	engine.handleDemand([]byte(`{"instance":"gw-1","symbols":["BTCUSDT","bad symbol"]}`))
+	engine.handleDemand([]byte(`{"instance":"gw-2","symbols":["BTCUSDT","ETHUSDT"]}`))
+	assert.ElementsMatch(t, []string{"BTCUSDT", "ETHUSDT"}, client.GetSubscribedSymbols())
+
+	// The last gateway viewing a symbol lets go of it
+	engine.handleDemand([]byte(`{"instance":"gw-2","symbols":[]}`))
+	assert.Equal(t, []string{"BTCUSDT"}, client.GetSubscribedSymbols())
+
+	// Gateways that stop announcing lose their symbols on the next sync
+	clk.Advance(demandTTL + time.Second)
+	engine.syncSubscriptions()
+	assert.Empty(t, client.GetSubscribedSymbols())
+	assert.Equal(t, 0, engine.GetDemandedSymbolCount())
+}
@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+// Event bus topic on which changes to alerts are announced, so the API
+// gateway can push them to the owner's open Mini App sessions
+const alertUpdatesChannel = "alert:updates"
+
+// Why an alert update was published
+const (
+	UpdateTriggered = "triggered"
+	UpdatePaused    = "paused"
+	UpdateResumed   = "resumed"
+)
+
+// AlertUpdate is the new state of an alert after it triggered or was
+// paused or resumed
+type AlertUpdate struct {
+	AlertID         int64      `json:"alertId"`
+	UserID          int64      `json:"userId"`
+	Reason          string     `json:"reason"` // one of the Update* constants
+	IsPaused        bool       `json:"isPaused"`
+	TimesTriggered  int        `json:"timesTriggered"`
+	LastTriggeredAt *time.Time `json:"lastTriggeredAt,omitempty"`
+	TriggeredPrice  *float64   `json:"triggeredPrice,omitempty"` // set when triggered
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	// RequestID is the API request or trigger that caused the update
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// UpdatePublisher publishes alert updates on the event bus
+type UpdatePublisher struct {
+	bus    eventbus.Publisher
+	logger *slog.Logger
+}
+
+// NewUpdatePublisher creates a new update publisher
+func NewUpdatePublisher(bus eventbus.Publisher, logger *slog.Logger) *UpdatePublisher {
+	return &UpdatePublisher{
+		bus:    bus,
+		logger: logger,
+	}
+}
+
+// Publish announces an alert update. Failures are logged and only cost the
+// open sessions a live update; the app catches up on its next fetch
+func (p *UpdatePublisher) Publish(ctx context.Context, update AlertUpdate) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return
+	}
+
+	if err := p.bus.Publish(ctx, alertUpdatesChannel, data); err != nil {
+		p.logger.Error("failed to publish alert update",
+			slog.Int64("alert_id", update.AlertID),
+			slog.String("reason", update.Reason),
+			slog.String("request_id", update.RequestID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package alert
+
+import (
+	"context"
+)
+
+// DefaultWatchlistSymbolLimit is how many watchlist coins without alerts
+// are streamed at most, the most watched first. A Binance connection takes
+// 1024 streams
+const DefaultWatchlistSymbolLimit = 300
+
+// SetWatchlistSymbolLimit sets how many watchlist coins without alerts are
+// streamed at most (0 streams none). Takes effect on the next refresh
+func (e *Engine) SetWatchlistSymbolLimit(limit int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.watchlistLimit = limit
+}
+
+// GetWatchlistSymbolCount returns the number of watchlist symbols streamed,
+// including those that also have alerts
+func (e *Engine) GetWatchlistSymbolCount() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.watchlistSymbols)
+}
+
+// loadWatchlistSymbols returns the Binance symbols of the most watched
+// active coins, named the way refreshAlerts names alert symbols. Coins only
+// priced through CoinGecko are left out
+func (e *Engine) loadWatchlistSymbols(ctx context.Context) (map[string]bool, error) {
+	e.mu.RLock()
+	limit := e.watchlistLimit
+	e.mu.RUnlock()
+
+	symbols := make(map[string]bool)
+	if limit <= 0 {
+		return symbols, nil
+	}
+
+	query := `
+		SELECT COALESCE(NULLIF(c.binance_symbol, ''), c.symbol || 'USDT')
+		FROM watchlist w
+		JOIN coins c ON c.id = w.coin_id
+		WHERE c.is_active
+		  AND (COALESCE(c.binance_symbol, '') <> '' OR COALESCE(c.coingecko_id, '') = '')
+		GROUP BY 1
+		ORDER BY COUNT(*) DESC, 1
+		LIMIT $1
+	`
+
+	rows, err := e.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols[symbol] = true
+	}
+	return symbols, rows.Err()
+}
@@ -0,0 +1,114 @@
+package alert
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/internal/whale"
+)
+
+const (
+	// Transfers per Whale Alert page; a full page means more may follow
+	whalePageSize = 100
+
+	// Pages fetched per poll at most, so a burst cannot stall the poller
+	whaleMaxPages = 10
+)
+
+// WhalePoller polls large on-chain transfers and feeds them into the engine
+// while any whale transfer alert is active
+type WhalePoller struct {
+	client   *whale.Client
+	interval time.Duration
+	handler  func(whale.Transfer)
+	logger   *slog.Logger
+
+	// Smallest threshold of the active alerts; 0 while there are none
+	minValue float64
+	mu       sync.RWMutex
+
+	// Position in the transfer feed; only touched by Run
+	cursor string
+}
+
+// NewWhalePoller creates a new whale transfer poller
+func NewWhalePoller(client *whale.Client, interval time.Duration, logger *slog.Logger) *WhalePoller {
+	return &WhalePoller{
+		client:   client,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// SetTransferHandler sets the handler for polled transfers
+func (p *WhalePoller) SetTransferHandler(handler func(whale.Transfer)) {
+	p.handler = handler
+}
+
+// SetMinValue sets the smallest USD value of the transfers to fetch; 0
+// pauses polling
+func (p *WhalePoller) SetMinValue(minValue float64) {
+	p.mu.Lock()
+	p.minValue = minValue
+	p.mu.Unlock()
+}
+
+// Run polls transfers until the context is cancelled
+func (p *WhalePoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// poll fetches the transfers since the previous poll and dispatches them
+func (p *WhalePoller) poll(ctx context.Context) {
+	p.mu.RLock()
+	minValue := p.minValue
+	p.mu.RUnlock()
+
+	if minValue == 0 || p.handler == nil {
+		// Nothing to alert on; start afresh instead of replaying the gap
+		p.cursor = ""
+		return
+	}
+
+	// The cursor continues where the last poll stopped; start only bounds
+	// the first poll and must stay within the API's lookback
+	lookback := min(2*p.interval, whale.MaxLookback-time.Minute)
+	start := time.Now().Add(-lookback)
+
+	dispatched := 0
+	for page := 0; page < whaleMaxPages; page++ {
+		result, err := p.client.Transactions(ctx, start, p.cursor, minValue)
+		if err != nil {
+			if ctx.Err() == nil {
+				p.logger.Error("failed to poll whale transfers", slog.String("error", err.Error()))
+			}
+			return
+		}
+		if result.Cursor != "" {
+			p.cursor = result.Cursor
+		}
+
+		for _, transfer := range result.Transactions {
+			p.handler(transfer)
+		}
+		dispatched += len(result.Transactions)
+
+		if len(result.Transactions) < whalePageSize {
+			break
+		}
+	}
+
+	p.logger.Debug("polled whale transfers", slog.Int("transfers", dispatched))
+}
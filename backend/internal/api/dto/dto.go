@@ -17,12 +17,34 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
-// PaginatedResponse represents a paginated response
+// PaginatedResponse represents one page of a list. next_cursor is passed
+// back as the cursor query parameter to get the next page and is omitted
+// on the last one
 type PaginatedResponse[T any] struct {
-	Items  []T   `json:"items"`
-	Total  int64 `json:"total"`
-	Limit  int   `json:"limit"`
-	Offset int   `json:"offset"`
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PageQuery represents cursor pagination query parameters
+type PageQuery struct {
+	Limit  int    `query:"limit" validate:"min=0"`
+	Cursor string `query:"cursor" validate:"max=512"`
+}
+
+// ComponentHealth represents the health of a single dependency
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// DeepHealthResponse represents the aggregated health of the system
+type DeepHealthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
 }
 
 // ============================================
@@ -32,6 +54,8 @@ type PaginatedResponse[T any] struct {
 // AuthRequest represents authentication request
 type AuthRequest struct {
 	InitData string `json:"init_data" validate:"required"`
+	// IANA timezone detected by the Mini App, used until the user sets one
+	Timezone string `json:"timezone,omitempty"`
 }
 
 // AuthResponse represents authentication response
@@ -55,13 +79,26 @@ type UserResponse struct {
 	Plan                 string        `json:"plan"`
 	PlanExpiresAt        *time.Time    `json:"plan_expires_at"`
 	PlanPeriod           *string       `json:"plan_period"`
+	ScheduledPlanChange  *PlanChange   `json:"scheduled_plan_change"`
 	NotificationsUsed    int           `json:"notifications_used"`
 	NotificationsResetAt *time.Time    `json:"notifications_reset_at"`
 	NotificationsEnabled bool          `json:"notifications_enabled"`
+	UnreachableReason    *string       `json:"notifications_disabled_reason,omitempty"` // blocked, chat_not_found or deactivated
 	VibrationEnabled     bool          `json:"vibration_enabled"`
+	Timezone             string        `json:"timezone"`
+	AlertsMutedUntil     *time.Time    `json:"alerts_muted_until"`
+	Role                 string        `json:"role"`
+	MessageFormat        string        `json:"message_format"`
+	IncludeChart         bool          `json:"include_chart"`
+	SilentAtNight        bool          `json:"silent_at_night"`
+	LanguageManual       bool          `json:"language_manual"`
+	NumberFormat         string        `json:"number_format"`
+	AllowStablecoins     bool          `json:"allow_stablecoins"`
 	Limits               *UserLimits   `json:"limits,omitempty"`
+	RateLimits           []RateLimit   `json:"rate_limits,omitempty"`
 	CreatedAt            time.Time     `json:"created_at"`
 	LastActiveAt         time.Time     `json:"last_active_at"`
+	Version              int           `json:"version"` // of the settings, sent back in If-Match
 }
 
 // UserLimits represents user's plan limits
@@ -70,14 +107,71 @@ type UserLimits struct {
 	MaxAlerts            int   `json:"max_alerts"`
 	MaxNotifications     *int  `json:"max_notifications"`
 	HistoryRetentionDays int   `json:"history_retention_days"`
+	PriceHistoryDays     int   `json:"price_history_days"`
+	AlertCharts          bool  `json:"alert_charts"`
 	CoinsUsed            int64 `json:"coins_used"`
 	AlertsUsed           int64 `json:"alerts_used"`
+	// Usage as a percentage of the limit, and the highest of
+	// UsageThresholds it reaches (0 below all), for upgrade prompts
+	CoinsUsagePct   float64 `json:"coins_usage_pct"`
+	AlertsUsagePct  float64 `json:"alerts_usage_pct"`
+	CoinsThreshold  int     `json:"coins_threshold"`
+	AlertsThreshold int     `json:"alerts_threshold"`
+	UsageThresholds []int   `json:"usage_thresholds"`
+}
+
+// RateLimit represents an API rate limit applied to the user. Remaining and
+// ResetAt are only known for limits checked on the current request
+type RateLimit struct {
+	Name          string `json:"name"`
+	Limit         int64  `json:"limit"`
+	WindowSeconds int64  `json:"window_seconds"`
+	Remaining     *int64 `json:"remaining,omitempty"`
+	ResetAt       *int64 `json:"reset_at,omitempty"`
 }
 
 // UpdateSettingsRequest represents settings update request
 type UpdateSettingsRequest struct {
-	NotificationsEnabled *bool `json:"notifications_enabled"`
-	VibrationEnabled     *bool `json:"vibration_enabled"`
+	NotificationsEnabled *bool   `json:"notifications_enabled"`
+	VibrationEnabled     *bool   `json:"vibration_enabled"`
+	Timezone             *string `json:"timezone" validate:"omitempty,timezone"`
+	MessageFormat        *string `json:"message_format" validate:"omitempty,oneof=detailed compact"`
+	IncludeChart         *bool   `json:"include_chart"`
+	SilentAtNight        *bool   `json:"silent_at_night"`
+	AllowStablecoins     *bool   `json:"allow_stablecoins"` // stablecoins can be watched, e.g. for depegs
+}
+
+// UpdateLocaleRequest sets the language and number format of the user's
+// messages. An empty language goes back to Telegram's
+type UpdateLocaleRequest struct {
+	Language     *string `json:"language" validate:"omitempty,max=10,bcp47_language_tag"`
+	NumberFormat *string `json:"number_format" validate:"omitempty,oneof=auto point comma space"`
+}
+
+// MuteAlertsRequest mutes all alert notifications for a number of hours
+type MuteAlertsRequest struct {
+	Hours int `json:"hours" validate:"required,min=1,max=168"`
+}
+
+// MuteAlertsResponse represents the mute-all state after a change
+type MuteAlertsResponse struct {
+	AlertsMutedUntil *time.Time `json:"alerts_muted_until"`
+}
+
+// OnboardingResponse represents the user's onboarding progress
+type OnboardingResponse struct {
+	Steps          []OnboardingStepResponse `json:"steps"`
+	CompletedSteps int                      `json:"completed_steps"`
+	TotalSteps     int                      `json:"total_steps"`
+	Completed      bool                     `json:"completed"`
+	CompletedAt    *time.Time               `json:"completed_at,omitempty"`
+}
+
+// OnboardingStepResponse represents one onboarding step
+type OnboardingStepResponse struct {
+	Key         string     `json:"key"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
 // ============================================
@@ -89,7 +183,7 @@ type CoinResponse struct {
 	ID               int      `json:"id"`
 	Symbol           string   `json:"symbol"`
 	Name             string   `json:"name"`
-	BinanceSymbol    string   `json:"binance_symbol"`
+	BinanceSymbol    *string  `json:"binance_symbol"`
 	Rank             *int     `json:"rank,omitempty"`
 	CurrentPrice     *float64 `json:"current_price,omitempty"`
 	MarketCap        *float64 `json:"market_cap,omitempty"`
@@ -97,6 +191,97 @@ type CoinResponse struct {
 	PriceChange24hPct *float64 `json:"price_change_24h_pct,omitempty"`
 }
 
+// ============================================
+// Price History DTOs
+// ============================================
+
+// PriceHistoryQuery represents price history query parameters
+type PriceHistoryQuery struct {
+	From string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To   string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// PricePoint represents a price at a moment
+type PricePoint struct {
+	Time  time.Time `json:"t"`
+	Price float64   `json:"p"`
+}
+
+// PriceHistoryResponse represents the price history of a coin
+type PriceHistoryResponse struct {
+	Symbol      string       `json:"symbol"`
+	From        time.Time    `json:"from"`
+	To          time.Time    `json:"to"`
+	HistoryDays int          `json:"history_days"`
+	Points      []PricePoint `json:"points"`
+}
+
+// CoinStatsResponse represents volatility, ATR and correlation statistics
+// of a coin; values are null without enough price history
+type CoinStatsResponse struct {
+	Symbol         string    `json:"symbol"`
+	Price          *float64  `json:"price"`
+	Volatility7d   *float64  `json:"volatility_7d"`
+	Volatility30d  *float64  `json:"volatility_30d"`
+	ATR1h          *float64  `json:"atr_1h"`
+	ATR1d          *float64  `json:"atr_1d"`
+	ATR1dPct       *float64  `json:"atr_1d_pct"`
+	CorrelationBTC *float64  `json:"correlation_btc"`
+	Candles        int       `json:"candles"`
+	ComputedAt     time.Time `json:"computed_at"`
+}
+
+// AlertSuggestionResponse represents a suggested price alert level
+type AlertSuggestionResponse struct {
+	AlertType      string  `json:"alert_type"`
+	ConditionValue float64 `json:"condition_value"`
+	Kind           string  `json:"kind"` // support, resistance, round_number or atr
+	DistancePct    float64 `json:"distance_pct"`
+}
+
+// AlertSuggestionsResponse represents suggested alert levels for a coin
+type AlertSuggestionsResponse struct {
+	Symbol      string                    `json:"symbol"`
+	Price       float64                   `json:"price"`
+	Suggestions []AlertSuggestionResponse `json:"suggestions"`
+	ComputedAt  time.Time                 `json:"computed_at"`
+}
+
+// CoinDetailResponse represents a coin on its detail page
+type CoinDetailResponse struct {
+	CoinResponse
+	PriceSource string                `json:"price_source"`
+	Metadata    *CoinMetadataResponse `json:"metadata"`
+}
+
+// CoinMetadataResponse represents the description, links and supply of a
+// coin, refreshed weekly
+type CoinMetadataResponse struct {
+	Description       *string   `json:"description"`
+	HomepageURL       *string   `json:"homepage_url"`
+	ExplorerURLs      []string  `json:"explorer_urls"`
+	CirculatingSupply *float64  `json:"circulating_supply"`
+	TotalSupply       *float64  `json:"total_supply"`
+	MaxSupply         *float64  `json:"max_supply"` // null for uncapped coins
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TrendingCoinsQuery represents trending coins query parameters
+type TrendingCoinsQuery struct {
+	Limit int `query:"limit" validate:"min=1"`
+}
+
+// TrendingCoinResponse represents a coin of the trending list with how
+// many users watch and alert it
+type TrendingCoinResponse struct {
+	CoinResponse
+	Watchers      int     `json:"watchers"`
+	AlertUsers    int     `json:"alert_users"`
+	WatcherGrowth int     `json:"watcher_growth_24h"`
+	Triggers24h   int     `json:"triggers_24h"`
+	Score         float64 `json:"score"`
+}
+
 // ============================================
 // Watchlist DTOs
 // ============================================
@@ -111,9 +296,19 @@ type WatchlistItemResponse struct {
 
 // WatchlistResponse represents the full watchlist
 type WatchlistResponse struct {
-	Items []WatchlistItemResponse `json:"items"`
-	Total int                     `json:"total"`
-	Limit int                     `json:"limit"`
+	Items      []WatchlistItemResponse `json:"items"`
+	Total      int                     `json:"total"`
+	Limit      int                     `json:"limit"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
+
+// AvailableCoinsQuery represents available coins query parameters
+type AvailableCoinsQuery struct {
+	Search   string `query:"search" validate:"max=50"`
+	Category string `query:"category" validate:"max=50"`
+	Limit    int    `query:"limit" validate:"min=1"`
+	// Adds stablecoins, for users who allow them in their watchlist
+	IncludeStablecoins bool `query:"include_stablecoins"`
 }
 
 // AddToWatchlistRequest represents add to watchlist request
@@ -121,6 +316,11 @@ type AddToWatchlistRequest struct {
 	CoinSymbol string `json:"coin_symbol" validate:"required,coin_symbol"`
 }
 
+// WatchlistExportQuery selects the format of a watchlist export
+type WatchlistExportQuery struct {
+	Format string `query:"format" validate:"omitempty,oneof=csv xlsx"`
+}
+
 // AddToWatchlistResponse represents add to watchlist response
 type AddToWatchlistResponse struct {
 	ID      int64         `json:"id"`
@@ -133,6 +333,84 @@ type RemoveFromWatchlistResponse struct {
 	DeletedAlertsCount int64 `json:"deleted_alerts_count"`
 }
 
+// ============================================
+// Exchange DTOs
+// ============================================
+
+// ConnectExchangeRequest stores a read-only exchange API key
+type ConnectExchangeRequest struct {
+	Exchange  string `json:"exchange" validate:"required,oneof=binance bybit"`
+	APIKey    string `json:"api_key" validate:"required,max=256"`
+	APISecret string `json:"api_secret" validate:"required,max=256"`
+}
+
+// ExchangeConnectionResponse represents a connected exchange
+type ExchangeConnectionResponse struct {
+	Exchange     string     `json:"exchange"`
+	KeyHint      string     `json:"key_hint"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ExchangeConnectionsResponse represents the user's connected exchanges
+type ExchangeConnectionsResponse struct {
+	Items []ExchangeConnectionResponse `json:"items"`
+}
+
+// ExchangeImportItemResponse represents the outcome for one exchange asset
+type ExchangeImportItemResponse struct {
+	Asset  string `json:"asset"`
+	Symbol string `json:"symbol,omitempty"`
+	Status string `json:"status"`
+}
+
+// ExchangeImportResponse represents the report of an exchange import
+type ExchangeImportResponse struct {
+	Items    []ExchangeImportItemResponse `json:"items"`
+	Added    int                          `json:"added"`
+	Existing int                          `json:"existing"`
+	Skipped  int                          `json:"skipped"`
+}
+
+// ============================================
+// Import DTOs
+// ============================================
+
+// ImportRowRequest represents one coin, and optionally an alert on it, of
+// an import file
+type ImportRowRequest struct {
+	CoinSymbol         string  `json:"coin_symbol" validate:"required,coin_symbol"`
+	AlertType          string  `json:"alert_type,omitempty" validate:"omitempty,alert_type"`
+	ConditionValue     float64 `json:"condition_value,omitempty" validate:"required_with=AlertType,omitempty,gt=0"`
+	ConditionTimeframe *string `json:"condition_timeframe,omitempty" validate:"omitempty,timeframe"`
+	IsRecurring        bool    `json:"is_recurring,omitempty"`
+	PeriodicInterval   *string `json:"periodic_interval,omitempty" validate:"omitempty,timeframe"`
+	Priority           string  `json:"priority,omitempty" validate:"omitempty,oneof=low normal high"`
+}
+
+// ImportRequest represents a JSON import file
+type ImportRequest struct {
+	Rows []ImportRowRequest `json:"rows"`
+}
+
+// ImportRowResponse represents the outcome of one import row
+type ImportRowResponse struct {
+	Line       int    `json:"line"`
+	CoinSymbol string `json:"coin_symbol"`
+	AlertType  string `json:"alert_type,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	AlertID    *int64 `json:"alert_id,omitempty"`
+}
+
+// ImportResponse represents the per-row report of an import
+type ImportResponse struct {
+	Rows          []ImportRowResponse `json:"rows"`
+	CoinsAdded    int                 `json:"coins_added"`
+	AlertsCreated int                 `json:"alerts_created"`
+	Failed        int                 `json:"failed"`
+}
+
 // ============================================
 // Alert DTOs
 // ============================================
@@ -147,34 +425,158 @@ type AlertResponse struct {
 	ConditionTimeframe *string      `json:"condition_timeframe,omitempty"`
 	IsRecurring       bool          `json:"is_recurring"`
 	IsPaused          bool          `json:"is_paused"`
+	PausedReason      *string       `json:"paused_reason,omitempty"`
+	Priority          string        `json:"priority"`
 	PeriodicInterval  *string       `json:"periodic_interval,omitempty"`
 	TimesTriggered    int           `json:"times_triggered"`
 	LastTriggeredAt   *time.Time    `json:"last_triggered_at,omitempty"`
 	PriceWhenCreated  *float64      `json:"price_when_created,omitempty"`
+	QuoteAsset        *string       `json:"quote_asset,omitempty"` // quote of condition_value; absent for USD
+	PairSymbol        *string       `json:"pair_symbol,omitempty"` // Binance pair of an alert in quote_asset
+	Schedule          *AlertSchedule `json:"schedule,omitempty"`
+	Name              *string       `json:"name,omitempty"`
+	Notes             *string       `json:"notes,omitempty"`
+	ExpiresAt         *time.Time    `json:"expires_at,omitempty"`
+	MaxTriggers       *int          `json:"max_triggers,omitempty"`
 	CreatedAt         time.Time     `json:"created_at"`
+	Version           int           `json:"version"` // sent back in If-Match
+	// Where prices are evaluated from and how often they refresh
+	// (binance is real-time, coingecko is polled)
+	PriceSource            string `json:"price_source"`
+	RefreshIntervalSeconds int    `json:"refresh_interval_seconds"`
 }
 
 // AlertsResponse represents alerts list
 type AlertsResponse struct {
-	Items   []AlertResponse            `json:"items"`
-	Total   int64                      `json:"total"`
-	Limit   int                        `json:"limit"`
-	Grouped map[string][]AlertResponse `json:"grouped,omitempty"`
+	Items      []AlertResponse            `json:"items"`
+	Total      int64                      `json:"total"`
+	Limit      int                        `json:"limit"`
+	Grouped    map[string][]AlertResponse `json:"grouped,omitempty"`
+	NextCursor string                     `json:"next_cursor,omitempty"`
 }
 
 // CreateAlertRequest represents create alert request
 type CreateAlertRequest struct {
-	CoinSymbol         string  `json:"coin_symbol" validate:"required,coin_symbol"`
-	AlertType          string  `json:"alert_type" validate:"required,alert_type"`
-	ConditionValue     float64 `json:"condition_value" validate:"required,gt=0"`
-	ConditionTimeframe *string `json:"condition_timeframe,omitempty" validate:"omitempty,timeframe"`
-	IsRecurring        bool    `json:"is_recurring"`
-	PeriodicInterval   *string `json:"periodic_interval,omitempty" validate:"omitempty,timeframe"`
+	CoinSymbol         string         `json:"coin_symbol" validate:"required,coin_symbol"`
+	AlertType          string         `json:"alert_type" validate:"required,alert_type"`
+	ConditionValue     float64        `json:"condition_value" validate:"required,gt=0"`
+	ConditionTimeframe *string        `json:"condition_timeframe,omitempty" validate:"omitempty,timeframe"`
+	// Recurring alerts fire once each time the condition starts to hold and
+	// re-arm when it clears; periodic_interval repeats while it holds instead
+	IsRecurring        bool           `json:"is_recurring"`
+	PeriodicInterval   *string        `json:"periodic_interval,omitempty" validate:"omitempty,timeframe"`
+	Priority           string         `json:"priority,omitempty" validate:"omitempty,oneof=low normal high"`
+	Schedule           *AlertSchedule `json:"schedule,omitempty"`
+	Name               *string        `json:"name,omitempty" validate:"omitempty,max=64"`
+	Notes              *string        `json:"notes,omitempty" validate:"omitempty,max=1000"`
+	// Quote of condition_value, e.g. BTC for ETH priced in BTC; empty is USD
+	QuoteAsset         string         `json:"quote_asset,omitempty" validate:"omitempty,alphanum,max=10"`
+	// The alert is deleted after this date or this many triggers
+	ExpiresAt          *time.Time     `json:"expires_at,omitempty"`
+	MaxTriggers        *int           `json:"max_triggers,omitempty" validate:"omitempty,min=1"`
+}
+
+// AlertSchedule represents the weekly windows during which an alert is
+// active, in the user's timezone
+type AlertSchedule struct {
+	Windows []AlertScheduleWindow `json:"windows" validate:"required,min=1,max=14,dive"`
+}
+
+// AlertScheduleWindow represents one window, e.g. mon-fri 09:00-18:00.
+// End before start means the window runs past midnight
+type AlertScheduleWindow struct {
+	Days  []string `json:"days" validate:"required,min=1,max=7,dive,oneof=mon tue wed thu fri sat sun"`
+	Start string   `json:"start" validate:"required,len=5"`
+	End   string   `json:"end" validate:"required,len=5"`
+}
+
+// UpdateAlertScheduleRequest represents update alert schedule request;
+// a null schedule makes the alert always active
+type UpdateAlertScheduleRequest struct {
+	Schedule *AlertSchedule `json:"schedule"`
+}
+
+// BacktestAlertRequest represents an alert definition to simulate against
+// price history; from and to default to the last 24 hours
+type BacktestAlertRequest struct {
+	CoinSymbol         string         `json:"coin_symbol" validate:"required,coin_symbol"`
+	AlertType          string         `json:"alert_type" validate:"required,oneof=PRICE_ABOVE PRICE_BELOW PRICE_CHANGE_PCT PERIODIC"`
+	ConditionValue     float64        `json:"condition_value" validate:"required_unless=AlertType PERIODIC,omitempty,gt=0"`
+	ConditionTimeframe *string        `json:"condition_timeframe,omitempty" validate:"omitempty,timeframe"`
+	IsRecurring        bool           `json:"is_recurring"`
+	PeriodicInterval   *string        `json:"periodic_interval,omitempty" validate:"omitempty,timeframe"`
+	Schedule           *AlertSchedule `json:"schedule,omitempty"`
+	From               *time.Time     `json:"from,omitempty"`
+	To                 *time.Time     `json:"to,omitempty"`
+}
+
+// BacktestTriggerResponse represents a moment the alert would have fired
+type BacktestTriggerResponse struct {
+	TriggeredAt time.Time `json:"triggered_at"`
+	Price       float64   `json:"price"`
+}
+
+// BacktestResponse represents the outcome of an alert backtest
+type BacktestResponse struct {
+	CoinSymbol      string                    `json:"coin_symbol"`
+	From            time.Time                 `json:"from"`
+	To              time.Time                 `json:"to"`
+	PointsEvaluated int                       `json:"points_evaluated"`
+	Triggers        []BacktestTriggerResponse `json:"triggers"`
+	TriggerCount    int                       `json:"trigger_count"`
+	Truncated       bool                      `json:"truncated"`
 }
 
 // UpdateAlertRequest represents update alert request
 type UpdateAlertRequest struct {
-	IsPaused *bool `json:"is_paused"`
+	IsPaused *bool `json:"is_paused" validate:"required"`
+}
+
+// EditAlertRequest represents edit alert request; omitted fields are left
+// unchanged and an empty name or notes clears them
+type EditAlertRequest struct {
+	ConditionValue     *float64 `json:"condition_value,omitempty" validate:"omitempty,gt=0"`
+	ConditionTimeframe *string  `json:"condition_timeframe,omitempty" validate:"omitempty,timeframe"`
+	IsRecurring        *bool    `json:"is_recurring,omitempty"`
+	PeriodicInterval   *string  `json:"periodic_interval,omitempty" validate:"omitempty,timeframe"`
+	Name               *string  `json:"name,omitempty" validate:"omitempty,max=64"`
+	Notes              *string  `json:"notes,omitempty" validate:"omitempty,max=1000"`
+}
+
+// ============================================
+// Price Target DTOs
+// ============================================
+
+// PriceTargetResponse represents a price target with its progress
+type PriceTargetResponse struct {
+	ID               int64         `json:"id"`
+	Coin             *CoinResponse `json:"coin"`
+	TargetPrice      float64       `json:"target_price"`
+	PriceWhenCreated *float64      `json:"price_when_created,omitempty"`
+	Note             string        `json:"note"`
+	CurrentPrice     *float64      `json:"current_price,omitempty"`
+	ProgressPct      *float64      `json:"progress_pct,omitempty"`
+	DistancePct      *float64      `json:"distance_pct,omitempty"`
+	Reached          bool          `json:"reached"`
+	ProjectedAt      *time.Time    `json:"projected_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+// PriceTargetsResponse represents a page of the user's price targets
+type PriceTargetsResponse = PaginatedResponse[PriceTargetResponse]
+
+// CreatePriceTargetRequest represents create price target request
+type CreatePriceTargetRequest struct {
+	CoinSymbol  string  `json:"coin_symbol" validate:"required,coin_symbol"`
+	TargetPrice float64 `json:"target_price" validate:"required,gt=0"`
+	Note        string  `json:"note" validate:"max=500"`
+}
+
+// UpdatePriceTargetRequest represents update price target request
+type UpdatePriceTargetRequest struct {
+	TargetPrice *float64 `json:"target_price,omitempty" validate:"omitempty,gt=0"`
+	Note        *string  `json:"note,omitempty" validate:"omitempty,max=500"`
 }
 
 // ============================================
@@ -190,6 +592,7 @@ type AlertHistoryResponse struct {
 	ConditionValue     float64       `json:"condition_value"`
 	ConditionTimeframe *string       `json:"condition_timeframe,omitempty"`
 	TriggeredPrice     float64       `json:"triggered_price"`
+	QuoteAsset         *string       `json:"quote_asset,omitempty"` // quote of the prices; absent for USD
 	TriggeredAt        time.Time     `json:"triggered_at"`
 }
 
@@ -198,6 +601,10 @@ type HistoryResponse struct {
 	Items         []AlertHistoryResponse `json:"items"`
 	Total         int64                  `json:"total"`
 	RetentionDays int                    `json:"retention_days"`
+	// Items grouped by day (YYYY-MM-DD) in the user's timezone
+	Timezone   string                            `json:"timezone"`
+	Grouped    map[string][]AlertHistoryResponse `json:"grouped"`
+	NextCursor string                            `json:"next_cursor,omitempty"`
 }
 
 // ============================================
@@ -213,6 +620,34 @@ type MarketOverviewResponse struct {
 	MarketCapChange24hPct float64             `json:"market_cap_change_24h_pct"`
 	FearGreedIndex       *FearGreedResponse   `json:"fear_greed_index"`
 	TopCoins             []CoinResponse       `json:"top_coins"`
+	UpdatedAt            *string              `json:"updated_at,omitempty"`
+	// Omitted while no recent estimate is available
+	GasPrice *GasPriceResponse `json:"gas_price,omitempty"`
+}
+
+// GasPriceResponse represents the Ethereum gas price in gwei
+type GasPriceResponse struct {
+	BaseFee   float64 `json:"base_fee"`
+	Slow      float64 `json:"slow"`
+	Standard  float64 `json:"standard"`
+	Fast      float64 `json:"fast"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// SectorResponse represents a market sector (coin category) with performance
+type SectorResponse struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	MarketCap            *float64 `json:"market_cap,omitempty"`
+	MarketCapChange24h   *float64 `json:"market_cap_change_24h,omitempty"`
+	Volume24h            *float64 `json:"volume_24h,omitempty"`
+	CoinsCount           int64    `json:"coins_count"`
+	AvgPriceChange24hPct *float64 `json:"avg_price_change_24h_pct,omitempty"`
+}
+
+// SectorsResponse represents sector performance for the market page
+type SectorsResponse struct {
+	Sectors []SectorResponse `json:"sectors"`
 }
 
 // FearGreedResponse represents fear and greed index
@@ -232,6 +667,8 @@ type SubscriptionPlanResponse struct {
 	MaxAlerts            int    `json:"max_alerts"`
 	MaxNotifications     *int   `json:"max_notifications"`
 	HistoryRetentionDays int    `json:"history_retention_days"`
+	PriceHistoryDays     int    `json:"price_history_days"`
+	AlertCharts          bool   `json:"alert_charts"`
 	PriceMonthly         *int   `json:"price_monthly"`
 	PriceYearly          *int   `json:"price_yearly"`
 }
@@ -253,6 +690,26 @@ type CreateInvoiceResponse struct {
 	PaymentID   int64  `json:"payment_id"`
 }
 
+// StartTrialResponse represents a started trial
+type StartTrialResponse struct {
+	Plan      string    `json:"plan"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CancelSubscriptionRequest represents a subscription cancellation; Plan
+// is the cheaper plan to move to at the end of the period, standard if empty
+type CancelSubscriptionRequest struct {
+	Plan string `json:"plan" validate:"omitempty,plan"`
+}
+
+// PlanChange represents the plan a user moves to when the current period
+// ends instead of renewing
+type PlanChange struct {
+	Plan        string    `json:"plan"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
 // PaymentResponse represents a payment record
 type PaymentResponse struct {
 	ID                int64      `json:"id"`
@@ -264,8 +721,611 @@ type PaymentResponse struct {
 	CompletedAt       *time.Time `json:"completed_at,omitempty"`
 }
 
-// PaymentHistoryResponse represents payment history
-type PaymentHistoryResponse struct {
-	Items []PaymentResponse `json:"items"`
-	Total int               `json:"total"`
+// PaymentHistoryResponse represents a page of payment history
+type PaymentHistoryResponse = PaginatedResponse[PaymentResponse]
+
+// ============================================
+// Admin DTOs
+// ============================================
+
+// SymbolMappingResponse represents a coin symbol to Binance pair mapping
+type SymbolMappingResponse struct {
+	Symbol        string     `json:"symbol"`
+	BinanceSymbol *string    `json:"binance_symbol"`
+	Source        string     `json:"source"`
+	IsValid       *bool      `json:"is_valid"`
+	CheckedAt     *time.Time `json:"checked_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// SymbolMappingsResponse represents all symbol mappings
+type SymbolMappingsResponse struct {
+	Items []SymbolMappingResponse `json:"items"`
+	Total int                     `json:"total"`
+}
+
+// UpdateSymbolMappingRequest represents a manual mapping override
+// A null binance_symbol marks the coin as not traded on Binance
+type UpdateSymbolMappingRequest struct {
+	BinanceSymbol *string `json:"binance_symbol" validate:"omitempty,alphanum,max=20"`
+}
+
+// CoinExclusionResponse represents a coin on the stablecoin or exclusion list
+type CoinExclusionResponse struct {
+	Symbol    string    `json:"symbol"`
+	Kind      string    `json:"kind"`
+	Note      *string   `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CoinExclusionsResponse represents the stablecoin and exclusion lists
+type CoinExclusionsResponse struct {
+	Items []CoinExclusionResponse `json:"items"`
+	Total int                     `json:"total"`
+}
+
+// UpdateCoinExclusionRequest lists a coin as a stablecoin, which users can
+// still opt into, or excludes it for everyone
+type UpdateCoinExclusionRequest struct {
+	Kind string  `json:"kind" validate:"required,oneof=stablecoin excluded"`
+	Note *string `json:"note" validate:"omitempty,max=200"`
+}
+
+// AlertReplayQuery selects the period an alert is replayed over, the 24
+// hours before to by default; changes_only keeps the steps where the
+// decision changed
+type AlertReplayQuery struct {
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ChangesOnly bool   `query:"changes_only"`
+}
+
+// AlertReplayStepResponse represents the engine's decision at a stored price
+type AlertReplayStepResponse struct {
+	Time      time.Time `json:"time"`
+	Price     float64   `json:"price"`
+	ChangePct *float64  `json:"change_pct,omitempty"`
+	Rearmed   bool      `json:"rearmed,omitempty"`
+	Decision  string    `json:"decision"`
+}
+
+// AlertReplayResponse represents an alert replayed over price history with
+// its current stored state
+type AlertReplayResponse struct {
+	AlertID            int64                     `json:"alert_id"`
+	UserID             int64                     `json:"user_id"`
+	CoinSymbol         string                    `json:"coin_symbol"`
+	PriceSymbol        string                    `json:"price_symbol"`
+	AlertType          string                    `json:"alert_type"`
+	ConditionValue     float64                   `json:"condition_value"`
+	ConditionTimeframe string                    `json:"condition_timeframe,omitempty"`
+	QuoteAsset         string                    `json:"quote_asset,omitempty"`
+	IsRecurring        bool                      `json:"is_recurring"`
+	PeriodicInterval   string                    `json:"periodic_interval,omitempty"`
+	IsPaused           bool                      `json:"is_paused"`
+	TriggerState       string                    `json:"trigger_state"`
+	TimesTriggered     int                       `json:"times_triggered"`
+	LastTriggeredAt    *time.Time                `json:"last_triggered_at"`
+	CreatedAt          time.Time                 `json:"created_at"`
+	From               time.Time                 `json:"from"`
+	To                 time.Time                 `json:"to"`
+	Points             int                       `json:"points"`
+	Triggers           int                       `json:"triggers"`
+	Decisions          map[string]int            `json:"decisions"`
+	Steps              []AlertReplayStepResponse `json:"steps"`
+}
+
+// ReconcileResponse represents the result of a symbol reconciliation
+type ReconcileResponse struct {
+	Checked int `json:"checked"`
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+	Changed int `json:"changed"`
+}
+
+// DelistedCoinResponse represents an inactive coin in the delisting report
+type DelistedCoinResponse struct {
+	Symbol        string     `json:"symbol"`
+	Name          string     `json:"name"`
+	BinanceSymbol *string    `json:"binance_symbol"`
+	CoingeckoID   *string    `json:"coingecko_id"`
+	Reason        *string    `json:"reason"`
+	DelistedAt    *time.Time `json:"delisted_at"`
+	PausedAlerts  int64      `json:"paused_alerts"`
+	AffectedUsers int64      `json:"affected_users"`
+}
+
+// DelistedCoinsResponse represents the delisting report
+type DelistedCoinsResponse struct {
+	Items []DelistedCoinResponse `json:"items"`
+	Total int                    `json:"total"`
+}
+
+// DelistingRunResponse represents the result of a delisting detection run
+type DelistingRunResponse struct {
+	Checked       int      `json:"checked"`
+	Delisted      []string `json:"delisted"`
+	Relisted      []string `json:"relisted"`
+	PausedAlerts  int      `json:"paused_alerts"`
+	NotifiedUsers int      `json:"notified_users"`
+}
+
+// PaymentReconcileResponse represents the result of a payment
+// reconciliation run
+type PaymentReconcileResponse struct {
+	Transactions  int                          `json:"transactions"`
+	Matched       int                          `json:"matched"`
+	Completed     int                          `json:"completed"`
+	Expired       int64                        `json:"expired"`
+	Discrepancies []PaymentDiscrepancyResponse `json:"discrepancies"`
+}
+
+// PaymentDiscrepancyResponse represents a payment that does not match its
+// Telegram transaction
+type PaymentDiscrepancyResponse struct {
+	PaymentID int64  `json:"payment_id,omitempty"`
+	ChargeID  string `json:"charge_id"`
+	Reason    string `json:"reason"`
+}
+
+// StarSyncResponse represents the result of a Star transaction sync
+type StarSyncResponse struct {
+	Read     int  `json:"read"`
+	Matched  int  `json:"matched"`
+	Complete bool `json:"complete"`
+}
+
+// RevenueQuery selects the days of the revenue report, from inclusive and
+// to exclusive
+type RevenueQuery struct {
+	From string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// RevenueResponse represents the Stars taken in a period, from synced
+// Telegram transactions
+type RevenueResponse struct {
+	From      time.Time             `json:"from"`
+	To        time.Time             `json:"to"`
+	Gross     int64                 `json:"gross"`
+	Refunded  int64                 `json:"refunded"`
+	Net       int64                 `json:"net"`
+	Payments  int64                 `json:"payments"`
+	Refunds   int64                 `json:"refunds"`
+	Unmatched int64                 `json:"unmatched"`
+	ByPlan    []PlanRevenueResponse `json:"by_plan"`
+	SyncedAt  *time.Time            `json:"synced_at"`
+}
+
+// PlanRevenueResponse represents the Stars paid for a plan; plan is empty
+// for payments without a payment record
+type PlanRevenueResponse struct {
+	Plan     string `json:"plan"`
+	Stars    int64  `json:"stars"`
+	Payments int64  `json:"payments"`
+}
+
+// StarTransactionsQuery limits the Star transactions listed
+type StarTransactionsQuery struct {
+	Limit int `query:"limit" validate:"min=0,max=500"`
+}
+
+// StarTransactionResponse represents a Telegram Stars transaction and the
+// payment it was matched to
+type StarTransactionResponse struct {
+	ID            string    `json:"id"`
+	Direction     string    `json:"direction"`
+	Amount        int       `json:"amount"`
+	Date          time.Time `json:"date"`
+	PaymentID     *int64    `json:"payment_id"`
+	PaymentStatus *string   `json:"payment_status"`
+	Plan          *string   `json:"plan"`
+}
+
+// StarTransactionsResponse represents a user's Star transactions
+type StarTransactionsResponse struct {
+	Items []StarTransactionResponse `json:"items"`
+	Total int                       `json:"total"`
+}
+
+// AlertHeatmapQuery selects the coins of the alert heatmap and the width of
+// its price bands, in percent of the current price
+type AlertHeatmapQuery struct {
+	Symbol  string  `query:"symbol" validate:"omitempty,coin_symbol"`
+	BandPct float64 `query:"band_pct" validate:"min=0,max=50"`
+	Limit   int     `query:"limit" validate:"min=0,max=200"`
+}
+
+// AlertHeatmapResponse represents how active alerts spread over coins and
+// price bands
+type AlertHeatmapResponse struct {
+	BandPct     float64                    `json:"band_pct"`
+	TotalAlerts int64                      `json:"total_alerts"`
+	Coins       []CoinAlertDensityResponse `json:"coins"`
+}
+
+// CoinAlertDensityResponse represents the active alerts of a coin. Bands
+// bucket price alerts by distance from the current price and top_levels
+// lists exact prices shared by several alerts, which trigger together
+type CoinAlertDensityResponse struct {
+	Symbol    string               `json:"symbol"`
+	Price     *float64             `json:"price"`
+	Alerts    int64                `json:"alerts"`
+	ByType    map[string]int64     `json:"by_type"`
+	Bands     []AlertBandResponse  `json:"bands"`
+	TopLevels []AlertLevelResponse `json:"top_levels"`
+}
+
+// AlertBandResponse represents the price alerts set from_pct to to_pct
+// away from the current price
+type AlertBandResponse struct {
+	FromPct   float64 `json:"from_pct"`
+	ToPct     float64 `json:"to_pct"`
+	FromPrice float64 `json:"from_price"`
+	ToPrice   float64 `json:"to_price"`
+	Alerts    int64   `json:"alerts"`
+}
+
+// AlertLevelResponse represents the price alerts sharing a threshold
+type AlertLevelResponse struct {
+	Price  float64 `json:"price"`
+	Alerts int64   `json:"alerts"`
+}
+
+// JobResponse represents a background job and its run metrics
+type JobResponse struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	LeaderOnly     bool       `json:"leader_only"`
+	Running        bool       `json:"running"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	Skipped        int64      `json:"skipped"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+}
+
+// JobsResponse represents all background jobs
+type JobsResponse struct {
+	Items []JobResponse `json:"items"`
+	Total int           `json:"total"`
+}
+
+// WebSocketStatsResponse represents WebSocket hub metrics of one replica
+type WebSocketStatsResponse struct {
+	Clients         int   `json:"clients"`
+	Symbols         int   `json:"symbols"`
+	MessagesSent    int64 `json:"messages_sent"`
+	MessagesDropped int64 `json:"messages_dropped"`
+	ClientsEvicted  int64 `json:"clients_evicted"`
+	Fanouts         int64 `json:"fanouts"`
+	FanoutAvgUs     int64 `json:"fanout_avg_us"`
+	FanoutMaxUs     int64 `json:"fanout_max_us"`
+	// Price update fanouts by latency, the last bucket has no upper bound
+	FanoutHistogram []FanoutBucketResponse `json:"fanout_histogram"`
+}
+
+// FanoutBucketResponse represents one fanout latency bucket
+type FanoutBucketResponse struct {
+	LeUs  *int64 `json:"le_us"`
+	Count int64  `json:"count"`
+}
+
+// EngineStatsResponse represents alert engine counters
+type EngineStatsResponse struct {
+	ActiveAlerts     int64 `json:"active_alerts"`
+	MonitoredSymbols int64 `json:"monitored_symbols"`
+	BinanceConnected bool  `json:"binance_connected"`
+	RetryQueueLength int64 `json:"retry_queue_length"`
+	DeadLetterLength int64 `json:"dead_letter_length"`
+	RejectedTicks    int64 `json:"rejected_ticks"`
+}
+
+// RefreshAlertsResponse represents the result of a forced alert refresh
+type RefreshAlertsResponse struct {
+	ActiveAlerts     int64 `json:"active_alerts"`
+	MonitoredSymbols int64 `json:"monitored_symbols"`
+}
+
+// NotificationStatsResponse represents notification service counters
+type NotificationStatsResponse struct {
+	NotificationsSent        int64 `json:"notifications_sent"`
+	NotificationsFailed      int64 `json:"notifications_failed"`
+	NotificationsRateLimited int64 `json:"notifications_rate_limited"`
+	QueueLength              int64 `json:"queue_length"`
+	PendingBatched           int64 `json:"pending_batched"`
+}
+
+// SendTestNotificationRequest sends a sample alert to a user
+type SendTestNotificationRequest struct {
+	UserID int64 `json:"user_id" validate:"required,gt=0"`
+}
+
+// NotificationPreviewQuery selects the alert to preview the message of
+type NotificationPreviewQuery struct {
+	AlertID int64 `query:"alert_id" validate:"required,gt=0"`
+}
+
+// NotificationPreviewResponse is the message an alert would send if it
+// triggered now
+type NotificationPreviewResponse struct {
+	AlertID   int64  `json:"alert_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// NotificationInteractionRequest reports the user opening or acting on an
+// alert message, identified by the nid parameter of its Mini App link
+type NotificationInteractionRequest struct {
+	Token  string `json:"token" validate:"required,len=32,hexadecimal"`
+	Action string `json:"action" validate:"required,oneof=open click"`
+}
+
+// EngagementQuery selects the period of the engagement stats
+type EngagementQuery struct {
+	Days int `query:"days" validate:"min=0,max=90"`
+}
+
+// AlertTypeEngagementResponse represents how often messages of an alert
+// type were opened or clicked
+type AlertTypeEngagementResponse struct {
+	AlertType    string  `json:"alert_type"`
+	Sent         int64   `json:"sent"`
+	Opened       int64   `json:"opened"`
+	Clicked      int64   `json:"clicked"`
+	OpenRatePct  float64 `json:"open_rate_pct"`
+	ClickRatePct float64 `json:"click_rate_pct"`
+}
+
+// EngagementResponse represents the engagement per alert type
+type EngagementResponse struct {
+	Since time.Time                     `json:"since"`
+	Items []AlertTypeEngagementResponse `json:"items"`
+}
+
+// ============================================
+// Feature Flag DTOs
+// ============================================
+
+// FeaturesResponse represents the feature flags of the current user
+type FeaturesResponse struct {
+	Features map[string]bool `json:"features"`
+}
+
+// FeatureFlagResponse represents a feature flag
+type FeatureFlagResponse struct {
+	Key               string    `json:"key"`
+	Description       string    `json:"description"`
+	Enabled           bool      `json:"enabled"`
+	RolloutPercentage int       `json:"rollout_percentage"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// FeatureFlagsResponse represents all feature flags
+type FeatureFlagsResponse struct {
+	Items []FeatureFlagResponse `json:"items"`
+	Total int                   `json:"total"`
+}
+
+// UpsertFeatureFlagRequest creates or updates a feature flag
+type UpsertFeatureFlagRequest struct {
+	Description       string `json:"description" validate:"max=500"`
+	Enabled           *bool  `json:"enabled" validate:"required"`
+	RolloutPercentage *int   `json:"rollout_percentage" validate:"required,min=0,max=100"`
+}
+
+// FeatureFlagOverrideResponse represents a per-user override
+type FeatureFlagOverrideResponse struct {
+	UserID  int64 `json:"user_id"`
+	Enabled bool  `json:"enabled"`
+}
+
+// FeatureFlagOverridesResponse represents all overrides of a flag
+type FeatureFlagOverridesResponse struct {
+	Items []FeatureFlagOverrideResponse `json:"items"`
+	Total int                           `json:"total"`
+}
+
+// SetFeatureFlagOverrideRequest forces a flag on or off for one user
+type SetFeatureFlagOverrideRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// ============================================
+// Experiment DTOs
+// ============================================
+
+// ExperimentVariant represents one arm of an experiment
+type ExperimentVariant struct {
+	Name   string `json:"name" validate:"required,max=50"`
+	Weight int    `json:"weight" validate:"min=0,max=10000"`
+}
+
+// ExperimentResponse represents an experiment
+type ExperimentResponse struct {
+	Key         string              `json:"key"`
+	Description string              `json:"description"`
+	FlagKey     *string             `json:"flag_key,omitempty"`
+	Variants    []ExperimentVariant `json:"variants"`
+	IsActive    bool                `json:"is_active"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// ExperimentsResponse represents all experiments
+type ExperimentsResponse struct {
+	Items []ExperimentResponse `json:"items"`
+	Total int                  `json:"total"`
+}
+
+// UpsertExperimentRequest creates or updates an experiment
+type UpsertExperimentRequest struct {
+	Description string              `json:"description" validate:"max=500"`
+	FlagKey     *string             `json:"flag_key,omitempty"`
+	Variants    []ExperimentVariant `json:"variants" validate:"required,min=2,max=10,dive"`
+	IsActive    *bool               `json:"is_active" validate:"required"`
+}
+
+// VariantSplitResponse represents exposures of one variant
+type VariantSplitResponse struct {
+	Variant   string `json:"variant"`
+	Weight    int    `json:"weight"`
+	Users     int64  `json:"users"`
+	Exposures int64  `json:"exposures"`
+}
+
+// ExperimentSplitsResponse represents how users are split between variants
+type ExperimentSplitsResponse struct {
+	Experiment ExperimentResponse     `json:"experiment"`
+	Splits     []VariantSplitResponse `json:"splits"`
+	TotalUsers int64                  `json:"total_users"`
+}
+
+// ============================================
+// Impersonation DTOs
+// ============================================
+
+// StartImpersonationRequest issues a token acting as a user
+type StartImpersonationRequest struct {
+	Reason     string `json:"reason" validate:"required,max=500"`
+	TTLMinutes int    `json:"ttl_minutes" validate:"omitempty,min=1,max=60"`
+}
+
+// ImpersonationResponse represents an audited impersonation
+type ImpersonationResponse struct {
+	ID        int64      `json:"id"`
+	UserID    *int64     `json:"user_id"`
+	Operator  string     `json:"operator"`
+	Reason    string     `json:"reason"`
+	Active    bool       `json:"active"`
+	Requests  int64      `json:"requests"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// StartImpersonationResponse carries the token of a new impersonation, to
+// be sent in the header named by Header instead of Telegram InitData
+type StartImpersonationResponse struct {
+	ImpersonationResponse
+	Token  string `json:"token"`
+	Header string `json:"header"`
+}
+
+// ImpersonationsQuery filters the impersonation audit log
+type ImpersonationsQuery struct {
+	UserID int64 `query:"user_id" validate:"min=0"`
+	Limit  int   `query:"limit" validate:"min=0,max=200"`
+}
+
+// ImpersonationsResponse represents the latest impersonations
+type ImpersonationsResponse struct {
+	Items []ImpersonationResponse `json:"items"`
+	Total int                     `json:"total"`
+}
+
+// ============================================
+// Abuse DTOs
+// ============================================
+
+// AbuseStatusResponse represents a user's standing with abuse detection
+type AbuseStatusResponse struct {
+	UserID         int64          `json:"user_id"`
+	Throttled      bool           `json:"throttled"`
+	ThrottledUntil *time.Time     `json:"throttled_until,omitempty"`
+	Reason         string         `json:"reason,omitempty"`
+	Churn          map[string]int `json:"churn"`
+}
+
+// UnlockUserResponse represents the outcome of an unlock
+type UnlockUserResponse struct {
+	UserID       int64 `json:"user_id"`
+	WasThrottled bool  `json:"was_throttled"`
+}
+
+// AuditLogQuery filters the audit log
+type AuditLogQuery struct {
+	UserID int64 `query:"user_id" validate:"min=0"`
+	Limit  int   `query:"limit" validate:"min=0,max=500"`
+}
+
+// AuditEntryResponse represents an audit log event
+type AuditEntryResponse struct {
+	ID        int64          `json:"id"`
+	UserID    *int64         `json:"user_id"`
+	Actor     string         `json:"actor"`
+	Action    string         `json:"action"`
+	Details   map[string]any `json:"details"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// AuditLogResponse represents the latest audit log events
+type AuditLogResponse struct {
+	Items []AuditEntryResponse `json:"items"`
+	Total int                  `json:"total"`
+}
+
+// SetRoleRequest grants a user a role
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user support admin"`
+}
+
+// SetRoleResponse represents the outcome of a role change
+type SetRoleResponse struct {
+	UserID       int64  `json:"user_id"`
+	Role         string `json:"role"`
+	PreviousRole string `json:"previous_role"`
+}
+
+// StaffMemberResponse represents a user holding a staff role
+type StaffMemberResponse struct {
+	UserID     int64   `json:"user_id"`
+	TelegramID int64   `json:"telegram_id"`
+	Username   *string `json:"username"`
+	FirstName  string  `json:"first_name"`
+	Role       string  `json:"role"`
+}
+
+// StaffListResponse represents the users holding a staff role
+type StaffListResponse struct {
+	Items []StaffMemberResponse `json:"items"`
+	Total int                   `json:"total"`
+}
+
+// ============================================
+// Status page DTOs
+// ============================================
+
+// StatusResponse represents the public status page
+type StatusResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+	// Share of the last 24h the Binance price stream was connected, nil
+	// when it was not sampled
+	BinanceUptimePct *float64 `json:"binance_uptime_pct"`
+	// Share of notifications delivered in the last hour, nil when none
+	// were sent
+	DeliverySuccessPct  *float64           `json:"delivery_success_pct"`
+	NotificationsSent   int64              `json:"notifications_sent"`
+	NotificationsFailed int64              `json:"notifications_failed"`
+	Incidents           []IncidentResponse `json:"incidents"`
+	CheckedAt           time.Time          `json:"checked_at"`
+}
+
+// IncidentResponse represents an incident on the status page
+type IncidentResponse struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Severity    string     `json:"severity"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+// CreateIncidentRequest posts an incident on the status page
+type CreateIncidentRequest struct {
+	Title       string `json:"title" validate:"required,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	Severity    string `json:"severity" validate:"required,oneof=minor major critical"`
 }
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// abuseUserParams is the path of a user's abuse endpoints
+type abuseUserParams struct {
+	UserID int64 `params:"user_id" validate:"gt=0"`
+}
+
+// AbuseHandler handles the admin endpoints of abuse detection
+type AbuseHandler struct {
+	abuseService *service.AbuseService
+	auditService *service.AuditService
+	validator    *validator.Validator
+}
+
+// NewAbuseHandler creates a new AbuseHandler
+func NewAbuseHandler(abuseService *service.AbuseService, auditService *service.AuditService, validator *validator.Validator) *AbuseHandler {
+	return &AbuseHandler{
+		abuseService: abuseService,
+		auditService: auditService,
+		validator:    validator,
+	}
+}
+
+// GetAbuseStatus handles GET /api/v1/admin/users/:user_id/abuse
+// Returns whether the user's writes are throttled and their recent changes
+func (h *AbuseHandler) GetAbuseStatus(c *fiber.Ctx) error {
+	var path abuseUserParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+
+	status, err := h.abuseService.Status(c.UserContext(), path.UserID)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.AbuseStatusResponse{
+		UserID:         path.UserID,
+		Throttled:      status.ThrottledUntil != nil,
+		ThrottledUntil: status.ThrottledUntil,
+		Reason:         status.Reason,
+		Churn:          status.Churn,
+	})
+}
+
+// UnlockUser handles POST /api/v1/admin/users/:user_id/unlock
+// Lifts the user's write throttle; recorded in the audit log
+func (h *AbuseHandler) UnlockUser(c *fiber.Ctx) error {
+	var path abuseUserParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+
+	wasThrottled, err := h.abuseService.Unlock(c.UserContext(), path.UserID, middleware.GetOperator(c))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.UnlockUserResponse{
+		UserID:       path.UserID,
+		WasThrottled: wasThrottled,
+	})
+}
+
+// GetAuditLog handles GET /api/v1/admin/audit-log
+// Returns the latest audit log events, optionally of one user (?user_id=)
+func (h *AbuseHandler) GetAuditLog(c *fiber.Ctx) error {
+	var query dto.AuditLogQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	entries, err := h.auditService.List(c.UserContext(), query.UserID, query.Limit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		items[i] = dto.AuditEntryResponse{
+			ID:        e.ID,
+			UserID:    e.UserID,
+			Actor:     e.Actor,
+			Action:    e.Action,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+
+	return c.JSON(dto.AuditLogResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
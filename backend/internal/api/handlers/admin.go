@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/scheduler"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/websocket"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// defaultRevenueDays is the period of the revenue report unless asked
+const defaultRevenueDays = 30
+
+// adminUserParams are the path parameters of per-user admin routes
+type adminUserParams struct {
+	UserID int64 `params:"user_id" validate:"gt=0"`
+}
+
+// AdminHandler handles admin endpoints
+type AdminHandler struct {
+	symbolMappingService *service.SymbolMappingService
+	coinExclusionService *service.CoinExclusionService
+	delistingService     *service.DelistingService
+	paymentService       *service.PaymentService
+	alertReplayService   *service.AlertReplayService
+	scheduler            *scheduler.Scheduler
+	hub                  *websocket.Hub
+	validator            *validator.Validator
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(
+	symbolMappingService *service.SymbolMappingService,
+	coinExclusionService *service.CoinExclusionService,
+	delistingService *service.DelistingService,
+	paymentService *service.PaymentService,
+	alertReplayService *service.AlertReplayService,
+	scheduler *scheduler.Scheduler,
+	hub *websocket.Hub,
+	validator *validator.Validator,
+) *AdminHandler {
+	return &AdminHandler{
+		symbolMappingService: symbolMappingService,
+		coinExclusionService: coinExclusionService,
+		delistingService:     delistingService,
+		paymentService:       paymentService,
+		alertReplayService:   alertReplayService,
+		scheduler:            scheduler,
+		hub:                  hub,
+		validator:            validator,
+	}
+}
+
+// GetSymbolMappings handles GET /api/v1/admin/symbol-mappings
+func (h *AdminHandler) GetSymbolMappings(c *fiber.Ctx) error {
+	mappings, err := h.symbolMappingService.GetAll(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.SymbolMappingResponse, len(mappings))
+	for i, m := range mappings {
+		items[i] = toSymbolMappingResponse(&m)
+	}
+
+	return c.JSON(dto.SymbolMappingsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// UpdateSymbolMapping handles PUT /api/v1/admin/symbol-mappings/:symbol
+func (h *AdminHandler) UpdateSymbolMapping(c *fiber.Ctx) error {
+	symbol := c.Params("symbol")
+	if symbol == "" {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Missing coin symbol"))
+	}
+
+	var req dto.UpdateSymbolMappingRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	mapping, err := h.symbolMappingService.SetManual(c.UserContext(), symbol, req.BinanceSymbol)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(toSymbolMappingResponse(mapping))
+}
+
+// DeleteSymbolMapping handles DELETE /api/v1/admin/symbol-mappings/:symbol
+// Removes a manual override so the reconciler manages the symbol again
+func (h *AdminHandler) DeleteSymbolMapping(c *fiber.Ctx) error {
+	symbol := c.Params("symbol")
+	if symbol == "" {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Missing coin symbol"))
+	}
+
+	if err := h.symbolMappingService.DeleteManual(c.UserContext(), symbol); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Manual mapping removed",
+	})
+}
+
+// ReconcileSymbolMappings handles POST /api/v1/admin/symbol-mappings/reconcile
+func (h *AdminHandler) ReconcileSymbolMappings(c *fiber.Ctx) error {
+	result, err := h.symbolMappingService.Reconcile(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.ReconcileResponse{
+		Checked: result.Checked,
+		Valid:   result.Valid,
+		Invalid: result.Invalid,
+		Changed: result.Changed,
+	})
+}
+
+// GetCoinExclusions handles GET /api/v1/admin/coin-exclusions
+func (h *AdminHandler) GetCoinExclusions(c *fiber.Ctx) error {
+	exclusions, err := h.coinExclusionService.GetAll(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.CoinExclusionResponse, len(exclusions))
+	for i, e := range exclusions {
+		items[i] = toCoinExclusionResponse(&e)
+	}
+
+	return c.JSON(dto.CoinExclusionsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// UpdateCoinExclusion handles PUT /api/v1/admin/coin-exclusions/:symbol
+func (h *AdminHandler) UpdateCoinExclusion(c *fiber.Ctx) error {
+	symbol := c.Params("symbol")
+	if symbol == "" {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Missing coin symbol"))
+	}
+
+	var req dto.UpdateCoinExclusionRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	exclusion, err := h.coinExclusionService.Set(c.UserContext(), symbol, req.Kind, req.Note)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(toCoinExclusionResponse(exclusion))
+}
+
+// DeleteCoinExclusion handles DELETE /api/v1/admin/coin-exclusions/:symbol
+// Takes the coin off the lists so everyone can watch it again
+func (h *AdminHandler) DeleteCoinExclusion(c *fiber.Ctx) error {
+	symbol := c.Params("symbol")
+	if symbol == "" {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Missing coin symbol"))
+	}
+
+	if err := h.coinExclusionService.Delete(c.UserContext(), symbol); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Coin removed from the list",
+	})
+}
+
+// ReplayAlert handles GET /api/v1/admin/alerts/:id/replay
+// Replays stored prices from ?from= to ?to= (RFC 3339) through the
+// evaluator and reports its decision at each one, to answer why an alert
+// did or did not fire
+func (h *AdminHandler) ReplayAlert(c *fiber.Ctx) error {
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	var query dto.AlertReplayQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+
+	// Both are valid times or empty, which means the default
+	from, _ := time.Parse(time.RFC3339, query.From)
+	to, _ := time.Parse(time.RFC3339, query.To)
+
+	replay, err := h.alertReplayService.Replay(c.UserContext(), path.ID, from, to)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	steps := replay.Steps
+	if query.ChangesOnly {
+		steps = replay.Changes()
+	}
+	items := make([]dto.AlertReplayStepResponse, len(steps))
+	for i, s := range steps {
+		items[i] = dto.AlertReplayStepResponse{
+			Time:      s.Time,
+			Price:     s.Price,
+			ChangePct: s.Change,
+			Rearmed:   s.Rearmed,
+			Decision:  string(s.Decision),
+		}
+	}
+
+	decisions := make(map[string]int, len(replay.Counts))
+	for d, n := range replay.Counts {
+		decisions[string(d)] = n
+	}
+
+	a := replay.Alert
+	return c.JSON(dto.AlertReplayResponse{
+		AlertID:            a.ID,
+		UserID:             a.UserID,
+		CoinSymbol:         a.CoinSymbol,
+		PriceSymbol:        replay.PriceSymbol,
+		AlertType:          string(a.AlertType),
+		ConditionValue:     a.ConditionValue,
+		ConditionTimeframe: a.ConditionTimeframe,
+		QuoteAsset:         a.QuoteAsset,
+		IsRecurring:        a.IsRecurring,
+		PeriodicInterval:   a.PeriodicInterval,
+		IsPaused:           a.IsPaused,
+		TriggerState:       a.TriggerState,
+		TimesTriggered:     a.TimesTriggered,
+		LastTriggeredAt:    a.LastTriggeredAt,
+		CreatedAt:          a.CreatedAt,
+		From:               replay.From,
+		To:                 replay.To,
+		Points:             len(replay.Steps),
+		Triggers:           replay.Triggers,
+		Decisions:          decisions,
+		Steps:              items,
+	})
+}
+
+// GetDelistedCoins handles GET /api/v1/admin/delisted-coins
+func (h *AdminHandler) GetDelistedCoins(c *fiber.Ctx) error {
+	coins, err := h.delistingService.GetDelisted(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.DelistedCoinResponse, len(coins))
+	for i, coin := range coins {
+		items[i] = dto.DelistedCoinResponse{
+			Symbol:        coin.Symbol,
+			Name:          coin.Name,
+			BinanceSymbol: coin.BinanceSymbol,
+			CoingeckoID:   coin.CoingeckoID,
+			Reason:        coin.Reason,
+			DelistedAt:    coin.DelistedAt,
+			PausedAlerts:  coin.PausedAlerts,
+			AffectedUsers: coin.AffectedUsers,
+		}
+	}
+
+	return c.JSON(dto.DelistedCoinsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// DetectDelistedCoins handles POST /api/v1/admin/delisted-coins/detect
+func (h *AdminHandler) DetectDelistedCoins(c *fiber.Ctx) error {
+	result, err := h.delistingService.Detect(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	resp := dto.DelistingRunResponse{
+		Checked:       result.Checked,
+		Delisted:      result.Delisted,
+		Relisted:      result.Relisted,
+		PausedAlerts:  result.PausedAlerts,
+		NotifiedUsers: result.NotifiedUsers,
+	}
+	if resp.Delisted == nil {
+		resp.Delisted = []string{}
+	}
+	if resp.Relisted == nil {
+		resp.Relisted = []string{}
+	}
+
+	return c.JSON(resp)
+}
+
+// ReconcilePayments handles POST /api/v1/admin/payments/reconcile
+// Runs the payment reconciliation job now and reports discrepancies
+func (h *AdminHandler) ReconcilePayments(c *fiber.Ctx) error {
+	result, err := h.paymentService.Reconcile(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	resp := dto.PaymentReconcileResponse{
+		Transactions:  result.Transactions,
+		Matched:       result.Matched,
+		Completed:     result.Completed,
+		Expired:       result.Expired,
+		Discrepancies: make([]dto.PaymentDiscrepancyResponse, len(result.Discrepancies)),
+	}
+	for i, d := range result.Discrepancies {
+		resp.Discrepancies[i] = dto.PaymentDiscrepancyResponse{
+			PaymentID: d.PaymentID,
+			ChargeID:  d.ChargeID,
+			Reason:    d.Reason,
+		}
+	}
+
+	return c.JSON(resp)
+}
+
+// SyncStarTransactions handles POST /api/v1/admin/payments/sync-transactions
+// Runs the Star transaction sync job now
+func (h *AdminHandler) SyncStarTransactions(c *fiber.Ctx) error {
+	result, err := h.paymentService.SyncStarTransactions(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.StarSyncResponse{
+		Read:     result.Read,
+		Matched:  result.Matched,
+		Complete: result.Complete,
+	})
+}
+
+// GetRevenue handles GET /api/v1/admin/revenue
+// Sums the synced Star transactions from ?from= to ?to= (YYYY-MM-DD, UTC),
+// the last 30 days by default
+func (h *AdminHandler) GetRevenue(c *fiber.Ctx) error {
+	var query dto.RevenueQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+
+	// Both are valid dates or empty, which means the default
+	to, _ := time.Parse(time.DateOnly, query.To)
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	from, _ := time.Parse(time.DateOnly, query.From)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultRevenueDays)
+	}
+	if !from.Before(to) {
+		return sendError(c, errors.ErrValidationFailed.WithMessage("from must be before to"))
+	}
+
+	revenue, err := h.paymentService.Revenue(c.UserContext(), from, to)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	byPlan := make([]dto.PlanRevenueResponse, len(revenue.ByPlan))
+	for i, p := range revenue.ByPlan {
+		byPlan[i] = dto.PlanRevenueResponse{Plan: p.Plan, Stars: p.Stars, Payments: p.Payments}
+	}
+
+	return c.JSON(dto.RevenueResponse{
+		From:      revenue.From,
+		To:        revenue.To,
+		Gross:     revenue.Gross,
+		Refunded:  revenue.Refunded,
+		Net:       revenue.Net(),
+		Payments:  revenue.Payments,
+		Refunds:   revenue.Refunds,
+		Unmatched: revenue.Unmatched,
+		ByPlan:    byPlan,
+		SyncedAt:  revenue.SyncedAt,
+	})
+}
+
+// GetUserStarTransactions handles GET /api/v1/admin/users/:user_id/star-transactions
+// Lists the user's synced Star transactions with their matched payments
+func (h *AdminHandler) GetUserStarTransactions(c *fiber.Ctx) error {
+	var path adminUserParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	query := dto.StarTransactionsQuery{}
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	records, err := h.paymentService.UserStarTransactions(c.UserContext(), path.UserID, query.Limit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.StarTransactionResponse, len(records))
+	for i, r := range records {
+		items[i] = dto.StarTransactionResponse{
+			ID:            r.ID,
+			Direction:     r.Direction,
+			Amount:        r.Amount,
+			Date:          r.Date,
+			PaymentID:     r.PaymentID,
+			PaymentStatus: r.PaymentStatus,
+			Plan:          r.Plan,
+		}
+	}
+
+	return c.JSON(dto.StarTransactionsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// GetJobs handles GET /api/v1/admin/jobs
+// Shows schedules and run metrics of background jobs on this replica
+func (h *AdminHandler) GetJobs(c *fiber.Ctx) error {
+	stats := h.scheduler.Stats()
+
+	items := make([]dto.JobResponse, len(stats))
+	for i, st := range stats {
+		items[i] = dto.JobResponse{
+			Name:           st.Name,
+			Schedule:       st.Schedule,
+			LeaderOnly:     st.LeaderOnly,
+			Running:        st.Running,
+			Runs:           st.Runs,
+			Failures:       st.Failures,
+			Skipped:        st.Skipped,
+			LastRunAt:      st.LastRunAt,
+			LastDurationMs: st.LastDuration.Milliseconds(),
+			LastError:      st.LastError,
+			NextRunAt:      st.NextRunAt,
+		}
+	}
+
+	return c.JSON(dto.JobsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// toCoinExclusionResponse converts service.CoinExclusion to dto.CoinExclusionResponse
+func toCoinExclusionResponse(e *service.CoinExclusion) dto.CoinExclusionResponse {
+	return dto.CoinExclusionResponse{
+		Symbol:    e.Symbol,
+		Kind:      e.Kind,
+		Note:      e.Note,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+// toSymbolMappingResponse converts service.SymbolMapping to dto.SymbolMappingResponse
+func toSymbolMappingResponse(m *service.SymbolMapping) dto.SymbolMappingResponse {
+	return dto.SymbolMappingResponse{
+		Symbol:        m.Symbol,
+		BinanceSymbol: m.BinanceSymbol,
+		Source:        m.Source,
+		IsValid:       m.IsValid,
+		CheckedAt:     m.CheckedAt,
+		UpdatedAt:     m.UpdatedAt,
+	}
+}
+
+// GetWebSocketStats handles GET /api/v1/admin/websocket
+// Shows connected clients, dropped messages and fanout latency on this replica
+func (h *AdminHandler) GetWebSocketStats(c *fiber.Ctx) error {
+	stats := h.hub.Stats()
+
+	bounds := websocket.FanoutBucketBounds()
+	histogram := make([]dto.FanoutBucketResponse, len(stats.FanoutHistogram))
+	for i, count := range stats.FanoutHistogram {
+		histogram[i].Count = count
+		if i < len(bounds) {
+			le := bounds[i].Microseconds()
+			histogram[i].LeUs = &le
+		}
+	}
+
+	return c.JSON(dto.WebSocketStatsResponse{
+		Clients:         stats.Clients,
+		Symbols:         stats.Symbols,
+		MessagesSent:    stats.Sent,
+		MessagesDropped: stats.Dropped,
+		ClientsEvicted:  stats.Evicted,
+		Fanouts:         stats.Fanouts,
+		FanoutAvgUs:     stats.FanoutAvg.Microseconds(),
+		FanoutMaxUs:     stats.FanoutMax.Microseconds(),
+		FanoutHistogram: histogram,
+	})
+}
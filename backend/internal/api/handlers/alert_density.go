@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// defaultHeatmapCoins is the number of coins in the alert heatmap unless
+// asked
+const defaultHeatmapCoins = 50
+
+// AlertDensityHandler reports how alerts spread over coins and price levels
+type AlertDensityHandler struct {
+	densityService *service.AlertDensityService
+	validator      *validator.Validator
+}
+
+// NewAlertDensityHandler creates a new AlertDensityHandler
+func NewAlertDensityHandler(densityService *service.AlertDensityService, validator *validator.Validator) *AlertDensityHandler {
+	return &AlertDensityHandler{
+		densityService: densityService,
+		validator:      validator,
+	}
+}
+
+// GetAlertHeatmap handles GET /api/v1/admin/alerts/heatmap
+// Returns the coins with the most active alerts, their price alerts
+// bucketed in bands of ?band_pct= percent (1 by default) around the current
+// price, and the exact levels many alerts share. ?symbol= selects one coin
+func (h *AlertDensityHandler) GetAlertHeatmap(c *fiber.Ctx) error {
+	var query dto.AlertHeatmapQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	if query.BandPct == 0 {
+		query.BandPct = service.DefaultDensityBandPct
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultHeatmapCoins
+	}
+
+	density, err := h.densityService.Density(c.UserContext(), query.Symbol, query.BandPct, query.Limit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	coins := make([]dto.CoinAlertDensityResponse, len(density.Coins))
+	for i, coin := range density.Coins {
+		bands := make([]dto.AlertBandResponse, len(coin.Bands))
+		for j, b := range coin.Bands {
+			bands[j] = dto.AlertBandResponse{
+				FromPct:   b.FromPct,
+				ToPct:     b.ToPct,
+				FromPrice: b.FromPrice,
+				ToPrice:   b.ToPrice,
+				Alerts:    b.Alerts,
+			}
+		}
+		levels := make([]dto.AlertLevelResponse, len(coin.TopLevels))
+		for j, l := range coin.TopLevels {
+			levels[j] = dto.AlertLevelResponse{Price: l.Price, Alerts: l.Alerts}
+		}
+
+		coins[i] = dto.CoinAlertDensityResponse{
+			Symbol:    coin.Symbol,
+			Price:     coin.Price,
+			Alerts:    coin.Alerts,
+			ByType:    coin.ByType,
+			Bands:     bands,
+			TopLevels: levels,
+		}
+	}
+
+	return c.JSON(dto.AlertHeatmapResponse{
+		BandPct:     density.BandPct,
+		TotalAlerts: density.TotalAlerts,
+		Coins:       coins,
+	})
+}
@@ -1,34 +1,39 @@
 package handlers
 
 import (
-	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/dto"
 	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/coingecko"
 	"github.com/weqory/backend/internal/service"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/schedule"
 	"github.com/weqory/backend/pkg/validator"
 )
 
 // AlertsHandler handles alert endpoints
 type AlertsHandler struct {
-	alertService *service.AlertService
-	userService  *service.UserService
-	validator    *validator.Validator
+	alertService    *service.AlertService
+	userService     *service.UserService
+	backtestService *service.BacktestService
+	validator       *validator.Validator
 }
 
 // NewAlertsHandler creates a new AlertsHandler
 func NewAlertsHandler(
 	alertService *service.AlertService,
 	userService *service.UserService,
+	backtestService *service.BacktestService,
 	validator *validator.Validator,
 ) *AlertsHandler {
 	return &AlertsHandler{
-		alertService: alertService,
-		userService:  userService,
-		validator:    validator,
+		alertService:    alertService,
+		userService:     userService,
+		backtestService: backtestService,
+		validator:       validator,
 	}
 }
 
@@ -39,22 +44,28 @@ func (h *AlertsHandler) GetAlerts(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	alerts, err := h.alertService.GetByUserID(c.Context(), userID)
+	// Every plan's alerts fit in one page by default
+	page, err := parsePage(c, h.validator, pagination.MaxLimit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	alerts, err := h.alertService.GetByUserID(c.UserContext(), userID, page)
 	if err != nil {
 		return sendError(c, err)
 	}
 
 	// Get user limits
-	user, err := h.userService.GetWithLimits(c.Context(), userID)
+	user, err := h.userService.GetWithLimits(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
 
 	// Convert to response
-	responseItems := make([]dto.AlertResponse, len(alerts))
+	responseItems := make([]dto.AlertResponse, len(alerts.Items))
 	grouped := make(map[string][]dto.AlertResponse)
 
-	for i, alert := range alerts {
+	for i, alert := range alerts.Items {
 		resp := toAlertResponse(&alert)
 		responseItems[i] = resp
 
@@ -64,14 +75,17 @@ func (h *AlertsHandler) GetAlerts(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(dto.AlertsResponse{
-		Items:   responseItems,
-		Total:   int64(len(alerts)),
-		Limit:   user.MaxAlerts,
-		Grouped: grouped,
+		Items:      responseItems,
+		Total:      alerts.Total,
+		Limit:      user.MaxAlerts,
+		Grouped:    grouped,
+		NextCursor: alerts.NextCursor,
 	})
 }
 
 // CreateAlert handles POST /api/v1/alerts
+// An alert identical to an existing one is rejected with 409 and the
+// existing alert's ID, unless ?force=true is passed
 func (h *AlertsHandler) CreateAlert(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
@@ -79,21 +93,25 @@ func (h *AlertsHandler) CreateAlert(c *fiber.Ctx) error {
 	}
 
 	var req dto.CreateAlertRequest
-	if err := c.BodyParser(&req); err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid request body"))
-	}
-
-	if errs := h.validator.Validate(req); errs != nil {
-		return sendValidationError(c, errs)
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
 	}
 
-	alert, err := h.alertService.Create(c.Context(), userID, service.CreateAlertParams{
+	alert, err := h.alertService.Create(c.UserContext(), userID, service.CreateAlertParams{
 		CoinSymbol:         req.CoinSymbol,
 		AlertType:          req.AlertType,
 		ConditionValue:     req.ConditionValue,
 		ConditionTimeframe: req.ConditionTimeframe,
 		IsRecurring:        req.IsRecurring,
 		PeriodicInterval:   req.PeriodicInterval,
+		Priority:           req.Priority,
+		Schedule:           toSchedule(req.Schedule),
+		Name:               req.Name,
+		Notes:              req.Notes,
+		QuoteAsset:         req.QuoteAsset,
+		ExpiresAt:          req.ExpiresAt,
+		MaxTriggers:        req.MaxTriggers,
+		Force:              c.QueryBool("force"),
 	})
 	if err != nil {
 		return sendError(c, err)
@@ -109,28 +127,162 @@ func (h *AlertsHandler) UpdateAlert(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	alertID, err := strconv.ParseInt(c.Params("id"), 10, 64)
-	if err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid alert ID"))
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
 	}
+	alertID := path.ID
 
 	var req dto.UpdateAlertRequest
-	if err := c.BodyParser(&req); err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid request body"))
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	alert, err := h.alertService.UpdatePaused(c.UserContext(), userID, alertID, *req.IsPaused, version)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	setETag(c, alert.Version)
+	return c.JSON(toAlertResponse(alert))
+}
+
+// EditAlert handles PATCH /api/v1/alerts/:id
+// Changes the target or recurrence of an alert in place, so its trigger
+// history is kept. Like the other alert updates, it honours If-Match with
+// the alert's version and answers 409 when the alert changed meanwhile
+func (h *AlertsHandler) EditAlert(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	alertID := path.ID
+
+	var req dto.EditAlertRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	alert, err := h.alertService.Update(c.UserContext(), userID, alertID, service.UpdateAlertParams{
+		ConditionValue:     req.ConditionValue,
+		ConditionTimeframe: req.ConditionTimeframe,
+		IsRecurring:        req.IsRecurring,
+		PeriodicInterval:   req.PeriodicInterval,
+		Name:               req.Name,
+		Notes:              req.Notes,
+		Version:            version,
+	})
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	setETag(c, alert.Version)
+	return c.JSON(toAlertResponse(alert))
+}
+
+// UpdateAlertSchedule handles PUT /api/v1/alerts/:id/schedule
+func (h *AlertsHandler) UpdateAlertSchedule(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	if req.IsPaused == nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("is_paused is required"))
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	alertID := path.ID
+
+	var req dto.UpdateAlertScheduleRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
 	}
 
-	alert, err := h.alertService.UpdatePaused(c.Context(), userID, alertID, *req.IsPaused)
+	version, err := parseIfMatch(c)
 	if err != nil {
 		return sendError(c, err)
 	}
 
+	alert, err := h.alertService.UpdateSchedule(c.UserContext(), userID, alertID, toSchedule(req.Schedule), version)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	setETag(c, alert.Version)
 	return c.JSON(toAlertResponse(alert))
 }
 
+// BacktestAlert handles POST /api/v1/alerts/backtest
+// Simulates an alert definition against stored price history without
+// creating it
+func (h *AlertsHandler) BacktestAlert(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var req dto.BacktestAlertRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	params := service.BacktestParams{
+		CreateAlertParams: service.CreateAlertParams{
+			CoinSymbol:         req.CoinSymbol,
+			AlertType:          req.AlertType,
+			ConditionValue:     req.ConditionValue,
+			ConditionTimeframe: req.ConditionTimeframe,
+			IsRecurring:        req.IsRecurring,
+			PeriodicInterval:   req.PeriodicInterval,
+			Schedule:           toSchedule(req.Schedule),
+		},
+	}
+	if req.From != nil {
+		params.From = *req.From
+	}
+	if req.To != nil {
+		params.To = *req.To
+	}
+
+	backtest, err := h.backtestService.Run(c.UserContext(), userID, params)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	triggers := make([]dto.BacktestTriggerResponse, len(backtest.Triggers))
+	for i, t := range backtest.Triggers {
+		triggers[i] = dto.BacktestTriggerResponse{
+			TriggeredAt: t.Time,
+			Price:       t.Price,
+		}
+	}
+
+	return c.JSON(dto.BacktestResponse{
+		CoinSymbol:      backtest.CoinSymbol,
+		From:            backtest.From,
+		To:              backtest.To,
+		PointsEvaluated: backtest.Evaluated,
+		Triggers:        triggers,
+		TriggerCount:    len(triggers),
+		Truncated:       backtest.Truncated,
+	})
+}
+
 // DeleteAlert handles DELETE /api/v1/alerts/:id
 func (h *AlertsHandler) DeleteAlert(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -138,12 +290,13 @@ func (h *AlertsHandler) DeleteAlert(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	alertID, err := strconv.ParseInt(c.Params("id"), 10, 64)
-	if err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid alert ID"))
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
 	}
+	alertID := path.ID
 
-	if err := h.alertService.Delete(c.Context(), userID, alertID); err != nil {
+	if err := h.alertService.Delete(c.UserContext(), userID, alertID); err != nil {
 		return sendError(c, err)
 	}
 
@@ -164,10 +317,31 @@ func toAlertResponse(a *service.Alert) dto.AlertResponse {
 		ConditionTimeframe: a.ConditionTimeframe,
 		IsRecurring:        a.IsRecurring,
 		IsPaused:           a.IsPaused,
+		PausedReason:       a.PausedReason,
+		Priority:           a.Priority,
 		PeriodicInterval:   a.PeriodicInterval,
 		TimesTriggered:     a.TimesTriggered,
 		PriceWhenCreated:   a.PriceWhenCreated,
+		QuoteAsset:         a.QuoteAsset,
+		PairSymbol:         a.PairSymbol,
+		Schedule:           toScheduleResponse(a.Schedule),
+		Name:               a.Name,
+		Notes:              a.Notes,
+		MaxTriggers:        a.MaxTriggers,
 		CreatedAt:          createdAt,
+		Version:            a.Version,
+		PriceSource:        a.Coin.PriceSource(),
+	}
+	if a.PairSymbol != nil {
+		// Alerts in another quote are evaluated on their Binance pair
+		resp.PriceSource = service.PriceSourceBinance
+	}
+
+	// Binance ticker streams push updates every second; other coins are polled
+	if resp.PriceSource == service.PriceSourceCoinGecko {
+		resp.RefreshIntervalSeconds = int(coingecko.PricePollInterval.Seconds())
+	} else {
+		resp.RefreshIntervalSeconds = 1
 	}
 
 	if a.LastTriggeredAt != nil {
@@ -175,5 +349,36 @@ func toAlertResponse(a *service.Alert) dto.AlertResponse {
 		resp.LastTriggeredAt = &t
 	}
 
+	if a.ExpiresAt != nil {
+		t, _ := time.Parse(time.RFC3339, *a.ExpiresAt)
+		resp.ExpiresAt = &t
+	}
+
+	return resp
+}
+
+// toSchedule converts a schedule from a request
+func toSchedule(s *dto.AlertSchedule) *schedule.Schedule {
+	if s == nil {
+		return nil
+	}
+
+	sched := &schedule.Schedule{Windows: make([]schedule.Window, len(s.Windows))}
+	for i, w := range s.Windows {
+		sched.Windows[i] = schedule.Window{Days: w.Days, Start: w.Start, End: w.End}
+	}
+	return sched
+}
+
+// toScheduleResponse converts a schedule for a response
+func toScheduleResponse(s *schedule.Schedule) *dto.AlertSchedule {
+	if s == nil {
+		return nil
+	}
+
+	resp := &dto.AlertSchedule{Windows: make([]dto.AlertScheduleWindow, len(s.Windows))}
+	for i, w := range s.Windows {
+		resp.Windows[i] = dto.AlertScheduleWindow{Days: w.Days, Start: w.Start, End: w.End}
+	}
 	return resp
 }
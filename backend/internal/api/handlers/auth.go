@@ -2,11 +2,12 @@ package handlers
 
 import (
 	"log/slog"
+	"math"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/dto"
 	"github.com/weqory/backend/internal/service"
-	"github.com/weqory/backend/pkg/errors"
 	"github.com/weqory/backend/pkg/validator"
 )
 
@@ -27,15 +28,11 @@ func NewAuthHandler(authService *service.AuthService, validator *validator.Valid
 // Authenticate handles POST /api/v1/auth/telegram
 func (h *AuthHandler) Authenticate(c *fiber.Ctx) error {
 	var req dto.AuthRequest
-	if err := c.BodyParser(&req); err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid request body"))
-	}
-
-	if errs := h.validator.Validate(req); errs != nil {
-		return sendValidationError(c, errs)
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
 	}
 
-	result, err := h.authService.Authenticate(c.Context(), req.InitData)
+	result, err := h.authService.Authenticate(c.UserContext(), req.InitData, req.Timezone)
 	if err != nil {
 		slog.Error("auth failed", slog.String("error", err.Error()), slog.String("init_data_length", string(rune(len(req.InitData)))))
 		return sendError(c, err)
@@ -62,21 +59,56 @@ func toUserResponse(u *service.UserWithLimits) *dto.UserResponse {
 		Plan:                 u.Plan,
 		PlanExpiresAt:        u.PlanExpiresAt,
 		PlanPeriod:           u.PlanPeriod,
+		ScheduledPlanChange:  toPlanChange(u.ScheduledChange()),
 		NotificationsUsed:    u.NotificationsUsed,
 		NotificationsResetAt: u.NotificationsResetAt,
 		NotificationsEnabled: u.NotificationsEnabled,
+		UnreachableReason:    u.UnreachableReason,
 		VibrationEnabled:     u.VibrationEnabled,
+		Timezone:             u.Timezone,
+		AlertsMutedUntil:     activeMute(&u.User),
+		Role:                 string(u.Role),
+		MessageFormat:        u.MessageFormat,
+		IncludeChart:         u.IncludeChart,
+		SilentAtNight:        u.SilentAtNight,
+		LanguageManual:       u.LanguageManual,
+		NumberFormat:         u.NumberFormat,
+		AllowStablecoins:     u.AllowStablecoins,
 		CreatedAt:            u.CreatedAt,
 		LastActiveAt:         u.LastActiveAt,
+		Version:              u.Version,
 		Limits: &dto.UserLimits{
 			MaxCoins:             u.MaxCoins,
 			MaxAlerts:            u.MaxAlerts,
 			MaxNotifications:     u.MaxNotifications,
 			HistoryRetentionDays: u.HistoryRetentionDays,
+			PriceHistoryDays:     u.PriceHistoryDays,
+			AlertCharts:          u.AlertCharts,
 			CoinsUsed:            u.CoinsUsed,
 			AlertsUsed:           u.AlertsUsed,
+			CoinsUsagePct:        math.Round(service.UsagePercent(u.CoinsUsed, u.MaxCoins)*10) / 10,
+			AlertsUsagePct:       math.Round(service.UsagePercent(u.AlertsUsed, u.MaxAlerts)*10) / 10,
+			CoinsThreshold:       service.UsageThreshold(u.CoinsUsed, u.MaxCoins),
+			AlertsThreshold:      service.UsageThreshold(u.AlertsUsed, u.MaxAlerts),
+			UsageThresholds:      service.UsageThresholds,
 		},
 	}
 
 	return resp
 }
+
+// toPlanChange converts a scheduled plan change, nil when there is none
+func toPlanChange(c *service.ScheduledPlanChange) *dto.PlanChange {
+	if c == nil {
+		return nil
+	}
+	return &dto.PlanChange{Plan: c.Plan, EffectiveAt: c.EffectiveAt}
+}
+
+// activeMute returns when the user's mute-all ends, or nil once it has
+func activeMute(u *service.User) *time.Time {
+	if !u.AlertsMuted(time.Now()) {
+		return nil
+	}
+	return u.AlertsMutedUntil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// idParams is the path of routes addressing a resource by numeric ID
+type idParams struct {
+	ID int64 `params:"id" validate:"gt=0"`
+}
+
+// parseBody decodes the JSON body into out and validates it. Failures are
+// validator.Errors, or ErrBadRequest for bodies that are not JSON at all;
+// sendError renders both
+func parseBody(c *fiber.Ctx, v *validator.Validator, out any) error {
+	if err := c.BodyParser(out); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return validator.Errors{typeError(typeErr.Field, validator.InBody, typeErr.Type)}
+		}
+		return errors.ErrBadRequest.WithMessage("Invalid request body")
+	}
+	return validate(v, out, validator.InBody)
+}
+
+// parseQuery decodes the query string into out, using its query tags, and
+// validates it
+func parseQuery(c *fiber.Ctx, v *validator.Validator, out any) error {
+	if err := c.QueryParser(out); err != nil {
+		return decodeError(err, validator.InQuery)
+	}
+	return validate(v, out, validator.InQuery)
+}
+
+// parseParams decodes the route's path parameters into out, using its
+// params tags, and validates it
+func parseParams(c *fiber.Ctx, v *validator.Validator, out any) error {
+	if err := c.ParamsParser(out); err != nil {
+		return decodeError(err, validator.InPath)
+	}
+	return validate(v, out, validator.InPath)
+}
+
+// parsePage parses the limit and cursor query parameters. Without a limit
+// the page holds defaultLimit items; larger limits are capped
+func parsePage(c *fiber.Ctx, v *validator.Validator, defaultLimit int) (pagination.Page, error) {
+	var query dto.PageQuery
+	if err := parseQuery(c, v, &query); err != nil {
+		return pagination.Page{}, err
+	}
+
+	after, err := pagination.Decode(query.Cursor)
+	if err != nil {
+		return pagination.Page{}, validator.Errors{{
+			Field:   "cursor",
+			In:      validator.InQuery,
+			Rule:    "cursor",
+			Message: "Invalid cursor",
+		}}
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	return pagination.Page{Limit: min(limit, pagination.MaxLimit), After: after}, nil
+}
+
+// parseIfMatch parses the If-Match header of an update into the version
+// the resource must still be at, as sent back from its ETag. Without the
+// header, or with *, the update applies whatever the version
+func parseIfMatch(c *fiber.Ctx) (*int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return nil, errors.ErrBadRequest.WithMessage("Invalid If-Match header")
+	}
+	return &version, nil
+}
+
+// setETag tags a response with the version of the resource it shows, for
+// clients to send back in If-Match
+func setETag(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, `"`+strconv.Itoa(version)+`"`)
+}
+
+// validate runs the struct's validate rules
+func validate(v *validator.Validator, out any, in string) error {
+	if errs := v.ValidateIn(out, in); errs != nil {
+		return validator.Errors(errs)
+	}
+	return nil
+}
+
+// decodeError converts a query or path decoding failure into field errors
+func decodeError(err error, in string) error {
+	var multi fiber.MultiError
+	if !errors.As(err, &multi) {
+		return errors.ErrBadRequest.WithMessage("Invalid " + in + " parameters")
+	}
+
+	errs := make(validator.Errors, 0, len(multi))
+	for key, fieldErr := range multi {
+		var typ reflect.Type
+		var conv fiber.ConversionError
+		if errors.As(fieldErr, &conv) {
+			typ = conv.Type
+		}
+		errs = append(errs, typeError(key, in, typ))
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+
+	return errs
+}
+
+// typeError reports a value that does not fit the field's type
+func typeError(field, in string, typ reflect.Type) validator.ValidationError {
+	message := "Invalid value"
+	if typ != nil {
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			message = "Value must be an integer"
+		case reflect.Float32, reflect.Float64:
+			message = "Value must be a number"
+		case reflect.Bool:
+			message = "Value must be true or false"
+		case reflect.String:
+			message = "Value must be a string"
+		case reflect.Slice, reflect.Array:
+			message = "Value must be an array"
+		case reflect.Map, reflect.Struct:
+			message = "Value must be an object"
+		}
+	}
+
+	return validator.ValidationError{
+		Field:   field,
+		In:      in,
+		Rule:    validator.RuleType,
+		Message: message,
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// validationResponse performs a request and decodes the validation errors
+func validationResponse(t *testing.T, app *fiber.App, method, target, body string) (int, []validator.ValidationError) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Details []validator.ValidationError `json:"details"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded.Details
+}
+
+func newBindingApp() *fiber.App {
+	v := validator.New()
+	app := fiber.New()
+
+	app.Get("/items/:id", func(c *fiber.Ctx) error {
+		var path idParams
+		if err := parseParams(c, v, &path); err != nil {
+			return sendError(c, err)
+		}
+		if _, err := parsePage(c, v, 20); err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(fiber.Map{"details": nil})
+	})
+	app.Post("/watchlist", func(c *fiber.Ctx) error {
+		var req dto.AddToWatchlistRequest
+		if err := parseBody(c, v, &req); err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(fiber.Map{"details": nil})
+	})
+
+	return app
+}
+
+func TestParseParams(t *testing.T) {
+	app := newBindingApp()
+
+	status, errs := validationResponse(t, app, "GET", "/items/abc", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, validator.ValidationError{
+		Field: "id", In: validator.InPath, Rule: validator.RuleType, Message: "Value must be an integer",
+	}, errs[0])
+
+	status, errs = validationResponse(t, app, "GET", "/items/0", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "gt", errs[0].Rule)
+	assert.Equal(t, validator.InPath, errs[0].In)
+
+	status, _ = validationResponse(t, app, "GET", "/items/7", "")
+	assert.Equal(t, fiber.StatusOK, status)
+}
+
+func TestParseQuery(t *testing.T) {
+	app := newBindingApp()
+
+	status, errs := validationResponse(t, app, "GET", "/items/7?limit=ten", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "limit", errs[0].Field)
+	assert.Equal(t, validator.InQuery, errs[0].In)
+	assert.Equal(t, validator.RuleType, errs[0].Rule)
+
+	status, errs = validationResponse(t, app, "GET", "/items/7?limit=-1", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "limit", errs[0].Field)
+	assert.Equal(t, "min", errs[0].Rule)
+
+	status, errs = validationResponse(t, app, "GET", "/items/7?cursor=garbage", "")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "cursor", errs[0].Field)
+}
+
+func TestParseBody(t *testing.T) {
+	app := newBindingApp()
+
+	status, errs := validationResponse(t, app, "POST", "/watchlist", `{"coin_symbol":42}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, validator.ValidationError{
+		Field: "coin_symbol", In: validator.InBody, Rule: validator.RuleType, Message: "Value must be a string",
+	}, errs[0])
+
+	status, errs = validationResponse(t, app, "POST", "/watchlist", `{}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "coin_symbol", errs[0].Field)
+	assert.Equal(t, "required", errs[0].Rule)
+	assert.Equal(t, validator.InBody, errs[0].In)
+
+	status, errs = validationResponse(t, app, "POST", "/watchlist", `{not json`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Empty(t, errs)
+}
+
+func TestParseIfMatch(t *testing.T) {
+	app := fiber.New()
+	app.Patch("/items/:id", func(c *fiber.Ctx) error {
+		version, err := parseIfMatch(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		if version == nil {
+			return c.SendString("any")
+		}
+		setETag(c, *version+1)
+		return c.SendString(strconv.Itoa(*version))
+	})
+
+	for header, want := range map[string]string{
+		"":         "any",
+		"*":        "any",
+		`"3"`:      "3",
+		`W/"4"`:    "4",
+		"5":        "5",
+		`"0"`:      "",
+		`"v1"`:     "",
+		`"1", "2"`: "",
+	} {
+		req := httptest.NewRequest("PATCH", "/items/1", nil)
+		if header != "" {
+			req.Header.Set(fiber.HeaderIfMatch, header)
+		}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if want == "" {
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, header)
+			continue
+		}
+		assert.Equal(t, want, string(body), header)
+		if want != "any" {
+			n, _ := strconv.Atoi(want)
+			assert.Equal(t, `"`+strconv.Itoa(n+1)+`"`, resp.Header.Get(fiber.HeaderETag))
+		}
+	}
+}
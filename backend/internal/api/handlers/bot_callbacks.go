@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// BotCallbackHandler handles presses of inline buttons on bot messages,
+// such as the mute-all switch and pause button under alert messages
+type BotCallbackHandler struct {
+	userService       *service.UserService
+	alertService      *service.AlertService
+	engagementService *service.EngagementService
+	telegramBot       *telegram.Client
+	logger            *slog.Logger
+}
+
+// NewBotCallbackHandler creates a new BotCallbackHandler
+func NewBotCallbackHandler(
+	userService *service.UserService,
+	alertService *service.AlertService,
+	engagementService *service.EngagementService,
+	telegramBot *telegram.Client,
+	logger *slog.Logger,
+) *BotCallbackHandler {
+	return &BotCallbackHandler{
+		userService:       userService,
+		alertService:      alertService,
+		engagementService: engagementService,
+		telegramBot:       telegramBot,
+		logger:            logger,
+	}
+}
+
+// HandleCallbackQuery acts on a button press and answers it, so the
+// button stops spinning in the user's Telegram client
+func (h *BotCallbackHandler) HandleCallbackQuery(ctx context.Context, query *telegram.CallbackQuery) {
+	answer := telegram.AnswerCallbackQueryRequest{CallbackQueryID: query.ID}
+
+	if duration, ok := telegram.ParseMuteAllCallback(query.Data); ok && query.From != nil {
+		answer.Text = muteAlerts(ctx, h.userService, h.logger, query.From.ID, duration)
+		h.recordClick(ctx, query)
+	} else if alertID, ok := telegram.ParsePauseAlertCallback(query.Data); ok && query.From != nil {
+		answer.Text = h.pauseAlert(ctx, query.From.ID, alertID)
+		h.recordClick(ctx, query)
+	} else {
+		h.logger.Warn("received unknown callback query",
+			slog.String("data", query.Data),
+		)
+	}
+
+	if err := h.telegramBot.AnswerCallbackQuery(ctx, answer); err != nil {
+		h.logger.Error("failed to answer callback query",
+			slog.String("query_id", query.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// pauseAlert pauses the alert under whose message the pause button was
+// pressed and returns the notice to show. Pausing reaches the user's open
+// Mini App sessions through the alert service
+func (h *BotCallbackHandler) pauseAlert(ctx context.Context, telegramID, alertID int64) string {
+	user, err := h.userService.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			return "Open Weqory to set up your alerts first"
+		}
+		h.logger.Error("failed to look up user pausing alert",
+			slog.Int64("telegram_id", telegramID),
+			slog.String("error", err.Error()),
+		)
+		return "Could not pause the alert, please try again"
+	}
+
+	if _, err := h.alertService.UpdatePaused(ctx, user.ID, alertID, true, nil); err != nil {
+		if errors.Is(err, errors.ErrAlertNotFound) || errors.Is(err, errors.ErrNotOwner) {
+			return "This alert no longer exists"
+		}
+		h.logger.Error("failed to pause alert",
+			slog.Int64("alert_id", alertID),
+			slog.String("error", err.Error()),
+		)
+		return "Could not pause the alert, please try again"
+	}
+
+	return "⏸ Alert paused. Resume it anytime in the app"
+}
+
+// recordClick counts a button press under an alert message for the
+// engagement stats
+func (h *BotCallbackHandler) recordClick(ctx context.Context, query *telegram.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+	if err := h.engagementService.RecordMessageClick(ctx, query.From.ID, query.Message.MessageID); err != nil {
+		h.logger.Warn("failed to record alert message click",
+			slog.Int64("message_id", query.Message.MessageID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// muteAlerts mutes the alerts of the user who pressed a mute-all button or
+// sent /mute and returns the notice to show them
+func muteAlerts(ctx context.Context, userService *service.UserService, logger *slog.Logger, telegramID int64, duration time.Duration) string {
+	user, err := userService.MuteAlertsByTelegramID(ctx, telegramID, duration)
+	if err != nil {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			return "Open Weqory to set up your alerts first"
+		}
+		logger.Error("failed to mute alerts",
+			slog.Int64("telegram_id", telegramID),
+			slog.String("error", err.Error()),
+		)
+		return "Could not mute alerts, please try again"
+	}
+
+	loc := time.UTC
+	if l, err := time.LoadLocation(user.Timezone); err == nil {
+		loc = l
+	}
+	return fmt.Sprintf("🔕 All alerts muted until %s. Unmute anytime in the app",
+		user.AlertsMutedUntil.In(loc).Format("Jan 2, 15:04 MST"))
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// defaultMuteHours is how long /mute without an argument mutes alerts for
+const defaultMuteHours = 1
+
+const botHelpText = `<b>Weqory bot commands</b>
+
+/start - open Weqory
+/mute [hours] - mute all alerts, 1 hour by default and up to 168
+/unmute - resume alerts
+/help - show this message`
+
+// BotCommandHandler answers commands users send to the bot in a private
+// chat, such as /start and /mute
+type BotCommandHandler struct {
+	userService *service.UserService
+	telegramBot *telegram.Client
+	miniAppURL  string
+	logger      *slog.Logger
+}
+
+// NewBotCommandHandler creates a new BotCommandHandler
+func NewBotCommandHandler(userService *service.UserService, telegramBot *telegram.Client, miniAppURL string, logger *slog.Logger) *BotCommandHandler {
+	return &BotCommandHandler{
+		userService: userService,
+		telegramBot: telegramBot,
+		miniAppURL:  miniAppURL,
+		logger:      logger,
+	}
+}
+
+// HandleMessage replies to a command. Other messages and messages outside
+// private chats are ignored
+func (h *BotCommandHandler) HandleMessage(ctx context.Context, msg *telegram.Message) {
+	if msg.From == nil || msg.Chat == nil || msg.Chat.Type != "private" {
+		return
+	}
+
+	command, args, ok := telegram.ParseCommand(msg.Text)
+	if !ok {
+		return
+	}
+
+	var reply telegram.SendMessageRequest
+	switch command {
+	case "start":
+		reply.Text = "👋 Welcome to Weqory! Track coins and get price alerts right here in Telegram."
+		// Users come back here after unblocking the bot
+		reachable, err := h.userService.ReachableAgain(ctx, msg.From.ID)
+		if err != nil {
+			h.logger.Error("failed to re-enable notifications",
+				slog.Int64("telegram_id", msg.From.ID),
+				slog.String("error", err.Error()),
+			)
+		} else if reachable {
+			reply.Text += "\n\n🔔 Welcome back! Your notifications are turned on again."
+		}
+		if h.miniAppURL != "" {
+			reply.ReplyMarkup = &telegram.InlineKeyboardMarkup{
+				InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+					{Text: "📱 Open Weqory", WebApp: &telegram.WebAppInfo{URL: h.miniAppURL}},
+				}},
+			}
+		}
+	case "help":
+		reply.Text = botHelpText
+	case "mute":
+		reply.Text = h.mute(ctx, msg.From.ID, args)
+	case "unmute":
+		reply.Text = h.unmute(ctx, msg.From.ID)
+	default:
+		reply.Text = "Unknown command. Send /help to see what I can do"
+	}
+
+	reply.ChatID = msg.Chat.ID
+	if _, err := h.telegramBot.SendMessage(ctx, reply); err != nil {
+		h.logger.Error("failed to reply to bot command",
+			slog.String("command", command),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// mute handles /mute [hours]
+func (h *BotCommandHandler) mute(ctx context.Context, telegramID int64, args string) string {
+	hours := defaultMuteHours
+	if args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n < 1 || time.Duration(n)*time.Hour > service.MaxAlertMute {
+			return "Usage: /mute [hours], with 1 to 168 hours"
+		}
+		hours = n
+	}
+
+	return muteAlerts(ctx, h.userService, h.logger, telegramID, time.Duration(hours)*time.Hour)
+}
+
+// unmute handles /unmute
+func (h *BotCommandHandler) unmute(ctx context.Context, telegramID int64) string {
+	user, err := h.userService.GetByTelegramID(ctx, telegramID)
+	if err == nil {
+		err = h.userService.UnmuteAlerts(ctx, user.ID)
+	}
+	if err != nil {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			return "Open Weqory to set up your alerts first"
+		}
+		h.logger.Error("failed to unmute alerts",
+			slog.Int64("telegram_id", telegramID),
+			slog.String("error", err.Error()),
+		)
+		return "Could not unmute alerts, please try again"
+	}
+
+	return "🔔 Alerts resumed"
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/service"
+)
+
+// CoinStatsHandler handles coin statistics endpoints
+type CoinStatsHandler struct {
+	coinStatsService       *service.CoinStatsService
+	alertSuggestionService *service.AlertSuggestionService
+}
+
+// NewCoinStatsHandler creates a new CoinStatsHandler
+func NewCoinStatsHandler(coinStatsService *service.CoinStatsService, alertSuggestionService *service.AlertSuggestionService) *CoinStatsHandler {
+	return &CoinStatsHandler{
+		coinStatsService:       coinStatsService,
+		alertSuggestionService: alertSuggestionService,
+	}
+}
+
+// GetCoinStats handles GET /api/v1/coins/:symbol/stats
+func (h *CoinStatsHandler) GetCoinStats(c *fiber.Ctx) error {
+	stats, err := h.coinStatsService.Get(c.UserContext(), c.Params("symbol"))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.CoinStatsResponse{
+		Symbol:         stats.Symbol,
+		Price:          stats.Price,
+		Volatility7d:   stats.Volatility7d,
+		Volatility30d:  stats.Volatility30d,
+		ATR1h:          stats.ATR1h,
+		ATR1d:          stats.ATR1d,
+		ATR1dPct:       stats.ATR1dPct,
+		CorrelationBTC: stats.CorrelationBTC,
+		Candles:        stats.Candles,
+		ComputedAt:     stats.ComputedAt,
+	})
+}
+
+// GetSuggestedAlerts handles GET /api/v1/coins/:symbol/suggested-alerts
+func (h *CoinStatsHandler) GetSuggestedAlerts(c *fiber.Ctx) error {
+	result, err := h.alertSuggestionService.Get(c.UserContext(), c.Params("symbol"))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	suggestions := make([]dto.AlertSuggestionResponse, len(result.Suggestions))
+	for i, s := range result.Suggestions {
+		suggestions[i] = dto.AlertSuggestionResponse{
+			AlertType:      s.AlertType,
+			ConditionValue: s.ConditionValue,
+			Kind:           s.Kind,
+			DistancePct:    s.DistancePct,
+		}
+	}
+
+	return c.JSON(dto.AlertSuggestionsResponse{
+		Symbol:      result.Symbol,
+		Price:       result.Price,
+		Suggestions: suggestions,
+		ComputedAt:  result.ComputedAt,
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/service"
+)
+
+// CoinsHandler handles the coin detail endpoint
+type CoinsHandler struct {
+	coinService *service.CoinService
+}
+
+// NewCoinsHandler creates a new CoinsHandler
+func NewCoinsHandler(coinService *service.CoinService) *CoinsHandler {
+	return &CoinsHandler{coinService: coinService}
+}
+
+// GetCoin handles GET /api/v1/coins/:symbol
+// Market data with the description, links and supply synced from CoinGecko;
+// metadata is null for coins not synced yet
+func (h *CoinsHandler) GetCoin(c *fiber.Ctx) error {
+	detail, err := h.coinService.GetDetail(c.UserContext(), c.Params("symbol"))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	resp := dto.CoinDetailResponse{
+		CoinResponse: *toCoinResponse(&detail.Coin),
+		PriceSource:  detail.Coin.PriceSource(),
+	}
+	if m := detail.Metadata; m != nil {
+		resp.Metadata = &dto.CoinMetadataResponse{
+			Description:       m.Description,
+			HomepageURL:       m.HomepageURL,
+			ExplorerURLs:      m.ExplorerURLs,
+			CirculatingSupply: m.CirculatingSupply,
+			TotalSupply:       m.TotalSupply,
+			MaxSupply:         m.MaxSupply,
+			UpdatedAt:         m.UpdatedAt,
+		}
+	}
+
+	return c.JSON(resp)
+}
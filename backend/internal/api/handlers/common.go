@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/dto"
 	"github.com/weqory/backend/internal/service"
@@ -10,10 +12,24 @@ import (
 
 // sendError sends an error response
 func sendError(c *fiber.Ctx, err error) error {
+	var validationErrs validator.Errors
+	if errors.As(err, &validationErrs) {
+		return sendValidationError(c, validationErrs)
+	}
+
+	// Work cut short by the route's time budget
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.ErrTimeout
+	}
+
+	resp := dto.ErrorResponse{Error: err.Error()}
+	var appErr *errors.AppError
+	if errors.As(err, &appErr) {
+		resp.Details = appErr.Details
+	}
+
 	statusCode := errors.GetStatusCode(err)
-	return c.Status(statusCode).JSON(dto.ErrorResponse{
-		Error: err.Error(),
-	})
+	return c.Status(statusCode).JSON(resp)
 }
 
 // sendValidationError sends a validation error response
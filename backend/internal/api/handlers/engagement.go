@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// defaultEngagementDays is the period of the engagement stats unless asked
+const defaultEngagementDays = 30
+
+// EngagementHandler records and reports interactions with alert messages
+type EngagementHandler struct {
+	engagementService *service.EngagementService
+	validator         *validator.Validator
+}
+
+// NewEngagementHandler creates a new EngagementHandler
+func NewEngagementHandler(engagementService *service.EngagementService, validator *validator.Validator) *EngagementHandler {
+	return &EngagementHandler{
+		engagementService: engagementService,
+		validator:         validator,
+	}
+}
+
+// TrackInteraction handles POST /api/v1/notifications/interactions
+// Called by the Mini App when opened from an alert message, with the token
+// of its link, and when the user acts on that alert
+func (h *EngagementHandler) TrackInteraction(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var req dto.NotificationInteractionRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	if err := h.engagementService.RecordInteraction(c.UserContext(), userID, req.Token, req.Action); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetEngagement handles GET /api/v1/admin/notifications/engagement
+// Returns how often messages of each alert type were opened or clicked
+// over the last ?days= (30 by default)
+func (h *EngagementHandler) GetEngagement(c *fiber.Ctx) error {
+	var query dto.EngagementQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	if query.Days == 0 {
+		query.Days = defaultEngagementDays
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -query.Days)
+	stats, err := h.engagementService.Stats(c.UserContext(), since)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.AlertTypeEngagementResponse, len(stats))
+	for i, s := range stats {
+		items[i] = dto.AlertTypeEngagementResponse{
+			AlertType: s.AlertType,
+			Sent:      s.Sent,
+			Opened:    s.Opened,
+			Clicked:   s.Clicked,
+		}
+		if s.Sent > 0 {
+			items[i].OpenRatePct = float64(s.Opened) / float64(s.Sent) * 100
+			items[i].ClickRatePct = float64(s.Clicked) / float64(s.Sent) * 100
+		}
+	}
+
+	return c.JSON(dto.EngagementResponse{
+		Since: since,
+		Items: items,
+	})
+}
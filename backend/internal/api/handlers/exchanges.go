@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/exchange"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// ExchangesHandler handles exchange connection endpoints
+type ExchangesHandler struct {
+	exchangeService *service.ExchangeService
+	validator       *validator.Validator
+}
+
+// NewExchangesHandler creates a new ExchangesHandler
+func NewExchangesHandler(exchangeService *service.ExchangeService, validator *validator.Validator) *ExchangesHandler {
+	return &ExchangesHandler{
+		exchangeService: exchangeService,
+		validator:       validator,
+	}
+}
+
+// GetExchanges handles GET /api/v1/exchanges
+func (h *ExchangesHandler) GetExchanges(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	connections, err := h.exchangeService.List(c.UserContext(), userID)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.ExchangeConnectionResponse, len(connections))
+	for i := range connections {
+		items[i] = toExchangeConnectionResponse(&connections[i])
+	}
+
+	return c.JSON(dto.ExchangeConnectionsResponse{
+		Items: items,
+	})
+}
+
+// ConnectExchange handles POST /api/v1/exchanges
+func (h *ExchangesHandler) ConnectExchange(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var req dto.ConnectExchangeRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	connection, err := h.exchangeService.Connect(c.UserContext(), userID, req.Exchange, exchange.Credentials{
+		APIKey:    req.APIKey,
+		APISecret: req.APISecret,
+	})
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toExchangeConnectionResponse(connection))
+}
+
+// DisconnectExchange handles DELETE /api/v1/exchanges/:exchange
+func (h *ExchangesHandler) DisconnectExchange(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	name := c.Params("exchange")
+	if !exchange.IsSupported(name) {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Unsupported exchange"))
+	}
+
+	if err := h.exchangeService.Disconnect(c.UserContext(), userID, name); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Exchange disconnected",
+	})
+}
+
+// ImportExchange handles POST /api/v1/exchanges/:exchange/import
+func (h *ExchangesHandler) ImportExchange(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	name := c.Params("exchange")
+	if !exchange.IsSupported(name) {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Unsupported exchange"))
+	}
+
+	result, err := h.exchangeService.Import(c.UserContext(), userID, name)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.ExchangeImportItemResponse, len(result.Items))
+	for i, item := range result.Items {
+		items[i] = dto.ExchangeImportItemResponse{
+			Asset:  item.Asset,
+			Symbol: item.Symbol,
+			Status: item.Status,
+		}
+	}
+
+	return c.JSON(dto.ExchangeImportResponse{
+		Items:    items,
+		Added:    result.Added,
+		Existing: result.Existing,
+		Skipped:  result.Skipped,
+	})
+}
+
+// toExchangeConnectionResponse converts service.ExchangeConnection to dto.ExchangeConnectionResponse
+func toExchangeConnectionResponse(c *service.ExchangeConnection) dto.ExchangeConnectionResponse {
+	return dto.ExchangeConnectionResponse{
+		Exchange:     c.Exchange,
+		KeyHint:      c.KeyHint,
+		LastSyncedAt: c.LastSyncedAt,
+		CreatedAt:    c.CreatedAt,
+	}
+}
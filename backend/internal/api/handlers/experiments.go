@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/experiment"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// ExperimentHandler handles experiment admin endpoints
+type ExperimentHandler struct {
+	experimentService *experiment.Service
+	validator         *validator.Validator
+}
+
+// NewExperimentHandler creates a new ExperimentHandler
+func NewExperimentHandler(experimentService *experiment.Service, validator *validator.Validator) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+		validator:         validator,
+	}
+}
+
+// GetExperiments handles GET /api/v1/admin/experiments
+func (h *ExperimentHandler) GetExperiments(c *fiber.Ctx) error {
+	experiments, err := h.experimentService.GetAll(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.ExperimentResponse, len(experiments))
+	for i := range experiments {
+		items[i] = toExperimentResponse(&experiments[i])
+	}
+
+	return c.JSON(dto.ExperimentsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// UpsertExperiment handles PUT /api/v1/admin/experiments/:key
+func (h *ExperimentHandler) UpsertExperiment(c *fiber.Ctx) error {
+	key := c.Params("key")
+	if !featureFlagKeyPattern.MatchString(key) {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid experiment key"))
+	}
+
+	var req dto.UpsertExperimentRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	variants := make([]experiment.Variant, len(req.Variants))
+	seen := make(map[string]bool, len(req.Variants))
+	total := 0
+	for i, v := range req.Variants {
+		if seen[v.Name] {
+			return sendError(c, errors.ErrBadRequest.WithMessage("Duplicate variant name: "+v.Name))
+		}
+		seen[v.Name] = true
+		total += v.Weight
+		variants[i] = experiment.Variant{Name: v.Name, Weight: v.Weight}
+	}
+	if total == 0 {
+		return sendError(c, errors.ErrBadRequest.WithMessage("At least one variant needs a positive weight"))
+	}
+
+	exp, err := h.experimentService.Upsert(c.UserContext(), experiment.Experiment{
+		Key:         key,
+		Description: req.Description,
+		FlagKey:     req.FlagKey,
+		Variants:    variants,
+		IsActive:    *req.IsActive,
+	})
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(toExperimentResponse(exp))
+}
+
+// GetExperimentSplits handles GET /api/v1/admin/experiments/:key/splits
+func (h *ExperimentHandler) GetExperimentSplits(c *fiber.Ctx) error {
+	exp, splits, err := h.experimentService.GetSplits(c.UserContext(), c.Params("key"))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	var totalUsers int64
+	items := make([]dto.VariantSplitResponse, len(splits))
+	for i, s := range splits {
+		items[i] = dto.VariantSplitResponse{
+			Variant:   s.Variant,
+			Weight:    s.Weight,
+			Users:     s.Users,
+			Exposures: s.Exposures,
+		}
+		totalUsers += s.Users
+	}
+
+	return c.JSON(dto.ExperimentSplitsResponse{
+		Experiment: toExperimentResponse(exp),
+		Splits:     items,
+		TotalUsers: totalUsers,
+	})
+}
+
+// toExperimentResponse converts experiment.Experiment to dto.ExperimentResponse
+func toExperimentResponse(e *experiment.Experiment) dto.ExperimentResponse {
+	variants := make([]dto.ExperimentVariant, len(e.Variants))
+	for i, v := range e.Variants {
+		variants[i] = dto.ExperimentVariant{Name: v.Name, Weight: v.Weight}
+	}
+
+	return dto.ExperimentResponse{
+		Key:         e.Key,
+		Description: e.Description,
+		FlagKey:     e.FlagKey,
+		Variants:    variants,
+		IsActive:    e.IsActive,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// featureFlagKeyPattern restricts flag keys to lowercase identifiers
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// overrideParams is the path of a per-user flag override
+type overrideParams struct {
+	Key    string `params:"key" validate:"required"`
+	UserID int64  `params:"user_id" validate:"gt=0"`
+}
+
+// FeatureFlagHandler handles feature flag endpoints
+type FeatureFlagHandler struct {
+	featureFlagService *service.FeatureFlagService
+	validator          *validator.Validator
+}
+
+// NewFeatureFlagHandler creates a new FeatureFlagHandler
+func NewFeatureFlagHandler(featureFlagService *service.FeatureFlagService, validator *validator.Validator) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		featureFlagService: featureFlagService,
+		validator:          validator,
+	}
+}
+
+// GetFeatures handles GET /api/v1/features
+// Returns the flags evaluated for the current user (set by the FeatureFlags middleware)
+func (h *FeatureFlagHandler) GetFeatures(c *fiber.Ctx) error {
+	return c.JSON(dto.FeaturesResponse{
+		Features: middleware.GetFeatureFlags(c),
+	})
+}
+
+// GetFeatureFlags handles GET /api/v1/admin/feature-flags
+func (h *FeatureFlagHandler) GetFeatureFlags(c *fiber.Ctx) error {
+	flags, err := h.featureFlagService.GetAll(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.FeatureFlagResponse, len(flags))
+	for i := range flags {
+		items[i] = toFeatureFlagResponse(&flags[i])
+	}
+
+	return c.JSON(dto.FeatureFlagsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// UpsertFeatureFlag handles PUT /api/v1/admin/feature-flags/:key
+func (h *FeatureFlagHandler) UpsertFeatureFlag(c *fiber.Ctx) error {
+	key := c.Params("key")
+	if !featureFlagKeyPattern.MatchString(key) {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid feature flag key"))
+	}
+
+	var req dto.UpsertFeatureFlagRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	flag, err := h.featureFlagService.Upsert(c.UserContext(), service.FeatureFlag{
+		Key:               key,
+		Description:       req.Description,
+		Enabled:           *req.Enabled,
+		RolloutPercentage: *req.RolloutPercentage,
+	})
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(toFeatureFlagResponse(flag))
+}
+
+// DeleteFeatureFlag handles DELETE /api/v1/admin/feature-flags/:key
+func (h *FeatureFlagHandler) DeleteFeatureFlag(c *fiber.Ctx) error {
+	if err := h.featureFlagService.Delete(c.UserContext(), c.Params("key")); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Feature flag deleted",
+	})
+}
+
+// GetFeatureFlagOverrides handles GET /api/v1/admin/feature-flags/:key/overrides
+func (h *FeatureFlagHandler) GetFeatureFlagOverrides(c *fiber.Ctx) error {
+	overrides, err := h.featureFlagService.GetOverrides(c.UserContext(), c.Params("key"))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.FeatureFlagOverrideResponse, len(overrides))
+	for i, o := range overrides {
+		items[i] = dto.FeatureFlagOverrideResponse{
+			UserID:  o.UserID,
+			Enabled: o.Enabled,
+		}
+	}
+
+	return c.JSON(dto.FeatureFlagOverridesResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// SetFeatureFlagOverride handles PUT /api/v1/admin/feature-flags/:key/overrides/:user_id
+func (h *FeatureFlagHandler) SetFeatureFlagOverride(c *fiber.Ctx) error {
+	var path overrideParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	userID := path.UserID
+
+	var req dto.SetFeatureFlagOverrideRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	if err := h.featureFlagService.SetOverride(c.UserContext(), path.Key, userID, *req.Enabled); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.FeatureFlagOverrideResponse{
+		UserID:  userID,
+		Enabled: *req.Enabled,
+	})
+}
+
+// DeleteFeatureFlagOverride handles DELETE /api/v1/admin/feature-flags/:key/overrides/:user_id
+func (h *FeatureFlagHandler) DeleteFeatureFlagOverride(c *fiber.Ctx) error {
+	var path overrideParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	userID := path.UserID
+
+	if err := h.featureFlagService.DeleteOverride(c.UserContext(), path.Key, userID); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Override removed",
+	})
+}
+
+// toFeatureFlagResponse converts service.FeatureFlag to dto.FeatureFlagResponse
+func toFeatureFlagResponse(f *service.FeatureFlag) dto.FeatureFlagResponse {
+	return dto.FeatureFlagResponse{
+		Key:               f.Key,
+		Description:       f.Description,
+		Enabled:           f.Enabled,
+		RolloutPercentage: f.RolloutPercentage,
+		UpdatedAt:         f.UpdatedAt,
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/pkg/database"
+	pkgredis "github.com/weqory/backend/pkg/redis"
+)
+
+// Component health statuses
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// healthCheckTimeout bounds each component check
+const healthCheckTimeout = 3 * time.Second
+
+// healthCheck is a single component probe
+type healthCheck struct {
+	name string
+	// critical components make the gateway unhealthy; others degrade it
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+// HealthHandler handles health endpoints
+type HealthHandler struct {
+	checks []healthCheck
+	logger *slog.Logger
+}
+
+// NewHealthHandler creates a new HealthHandler
+// Service URLs are optional; their /ready endpoints are probed when set
+func NewHealthHandler(
+	pool *pgxpool.Pool,
+	redisClient *redis.Client,
+	alertEngineURL string,
+	notificationURL string,
+	logger *slog.Logger,
+) *HealthHandler {
+	h := &HealthHandler{
+		logger: logger,
+		checks: []healthCheck{
+			{
+				name:     "postgres",
+				critical: true,
+				check:    func(ctx context.Context) error { return database.HealthCheck(ctx, pool) },
+			},
+			{
+				name:     "redis",
+				critical: true,
+				check:    func(ctx context.Context) error { return pkgredis.HealthCheck(ctx, redisClient) },
+			},
+		},
+	}
+
+	httpClient := &http.Client{Timeout: healthCheckTimeout}
+	if alertEngineURL != "" {
+		h.checks = append(h.checks, healthCheck{
+			name:  "alert_engine",
+			check: readyCheck(httpClient, alertEngineURL),
+		})
+	}
+	if notificationURL != "" {
+		h.checks = append(h.checks, healthCheck{
+			name:  "notification",
+			check: readyCheck(httpClient, notificationURL),
+		})
+	}
+
+	return h
+}
+
+// Deep handles GET /health/deep
+// Checks all components concurrently and returns 503 if a critical one is down.
+// The endpoint is public, so only the status of each component is returned;
+// why a check failed is logged
+func (h *HealthHandler) Deep(c *fiber.Ctx) error {
+	status, components := h.checkComponents(c.UserContext())
+
+	code := fiber.StatusOK
+	if status == HealthStatusUnhealthy {
+		code = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(code).JSON(dto.DeepHealthResponse{
+		Status:     status,
+		Components: components,
+		CheckedAt:  time.Now().UTC(),
+	})
+}
+
+// checkComponents runs all checks concurrently and returns the overall
+// status with the result of each component
+func (h *HealthHandler) checkComponents(ctx context.Context) (string, map[string]dto.ComponentHealth) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	components := make(map[string]dto.ComponentHealth, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, hc := range h.checks {
+		wg.Add(1)
+		go func(hc healthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := hc.check(ctx)
+
+			result := dto.ComponentHealth{
+				Status:    HealthStatusHealthy,
+				Critical:  hc.critical,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = HealthStatusUnhealthy
+				h.logger.Warn("health check failed",
+					slog.String("component", hc.name),
+					slog.String("error", err.Error()),
+				)
+			}
+
+			mu.Lock()
+			components[hc.name] = result
+			mu.Unlock()
+		}(hc)
+	}
+	wg.Wait()
+
+	status := HealthStatusHealthy
+	for _, comp := range components {
+		if comp.Status == HealthStatusHealthy {
+			continue
+		}
+		if comp.Critical {
+			status = HealthStatusUnhealthy
+			break
+		}
+		status = HealthStatusDegraded
+	}
+
+	return status, components
+}
+
+// readyCheck probes a service's /ready endpoint
+func readyCheck(client *http.Client, baseURL string) func(ctx context.Context) error {
+	url := strings.TrimRight(baseURL, "/") + "/ready"
+
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/api/dto"
+)
+
+func TestHealthHandler_Deep(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error {
+		return errors.New("dial tcp 10.0.3.7:5432: connection refused")
+	}
+
+	tests := []struct {
+		name   string
+		checks []healthCheck
+		code   int
+		status string
+	}{
+		{
+			name: "healthy",
+			checks: []healthCheck{
+				{name: "postgres", critical: true, check: up},
+				{name: "alert_engine", check: up},
+			},
+			code:   fiber.StatusOK,
+			status: HealthStatusHealthy,
+		},
+		{
+			name: "degraded",
+			checks: []healthCheck{
+				{name: "postgres", critical: true, check: up},
+				{name: "alert_engine", check: down},
+			},
+			code:   fiber.StatusOK,
+			status: HealthStatusDegraded,
+		},
+		{
+			name: "unhealthy",
+			checks: []healthCheck{
+				{name: "postgres", critical: true, check: down},
+				{name: "alert_engine", check: up},
+			},
+			code:   fiber.StatusServiceUnavailable,
+			status: HealthStatusUnhealthy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			h := &HealthHandler{checks: tt.checks, logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+			app := fiber.New()
+			app.Get("/health/deep", h.Deep)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/health/deep", nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			var health dto.DeepHealthResponse
+			require.NoError(t, json.Unmarshal(body, &health))
+			assert.Equal(t, tt.status, health.Status)
+			assert.Len(t, health.Components, len(tt.checks))
+
+			// Why a check failed is logged, not served
+			assert.NotContains(t, string(body), "10.0.3.7")
+			if tt.status == HealthStatusHealthy {
+				assert.Empty(t, logs.String())
+			} else {
+				assert.Contains(t, logs.String(), "10.0.3.7")
+			}
+		})
+	}
+}
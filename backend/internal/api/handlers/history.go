@@ -8,17 +8,24 @@ import (
 	"github.com/weqory/backend/internal/api/middleware"
 	"github.com/weqory/backend/internal/service"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/schedule"
+	"github.com/weqory/backend/pkg/validator"
 )
 
 // HistoryHandler handles history endpoints
 type HistoryHandler struct {
 	historyService *service.HistoryService
+	userService    *service.UserService
+	validator      *validator.Validator
 }
 
 // NewHistoryHandler creates a new HistoryHandler
-func NewHistoryHandler(historyService *service.HistoryService) *HistoryHandler {
+func NewHistoryHandler(historyService *service.HistoryService, userService *service.UserService, validator *validator.Validator) *HistoryHandler {
 	return &HistoryHandler{
 		historyService: historyService,
+		userService:    userService,
+		validator:      validator,
 	}
 }
 
@@ -29,27 +36,33 @@ func (h *HistoryHandler) GetHistory(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	limit := c.QueryInt("limit", 50)
-	offset := c.QueryInt("offset", 0)
+	page, err := parsePage(c, h.validator, pagination.DefaultLimit)
+	if err != nil {
+		return sendError(c, err)
+	}
 
-	if limit > 100 {
-		limit = 100
+	history, err := h.historyService.GetByUserID(c.UserContext(), userID, page)
+	if err != nil {
+		return sendError(c, err)
 	}
 
-	history, total, err := h.historyService.GetByUserID(c.Context(), userID, limit, offset)
+	retentionDays, err := h.historyService.GetRetentionDays(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
 
-	retentionDays, err := h.historyService.GetRetentionDays(c.Context(), userID)
+	user, err := h.userService.GetByID(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
+	loc := schedule.LoadLocation(user.Timezone)
 
-	// Convert to response
-	responseItems := make([]dto.AlertHistoryResponse, len(history))
-	for i, item := range history {
+	// Convert to response; times and day groups are in the user's timezone
+	responseItems := make([]dto.AlertHistoryResponse, len(history.Items))
+	grouped := make(map[string][]dto.AlertHistoryResponse)
+	for i, item := range history.Items {
 		triggeredAt, _ := time.Parse(time.RFC3339, item.TriggeredAt)
+		triggeredAt = triggeredAt.In(loc)
 		responseItems[i] = dto.AlertHistoryResponse{
 			ID:                 item.ID,
 			Coin:               toCoinResponse(&item.Coin),
@@ -58,13 +71,20 @@ func (h *HistoryHandler) GetHistory(c *fiber.Ctx) error {
 			ConditionValue:     item.ConditionValue,
 			ConditionTimeframe: item.ConditionTimeframe,
 			TriggeredPrice:     item.TriggeredPrice,
+			QuoteAsset:         item.QuoteAsset,
 			TriggeredAt:        triggeredAt,
 		}
+
+		day := triggeredAt.Format("2006-01-02")
+		grouped[day] = append(grouped[day], responseItems[i])
 	}
 
 	return c.JSON(dto.HistoryResponse{
 		Items:         responseItems,
-		Total:         total,
+		Total:         history.Total,
 		RetentionDays: retentionDays,
+		Timezone:      loc.String(),
+		Grouped:       grouped,
+		NextCursor:    history.NextCursor,
 	})
 }
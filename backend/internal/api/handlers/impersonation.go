@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// impersonationUserParams is the path of the user to impersonate
+type impersonationUserParams struct {
+	UserID int64 `params:"user_id" validate:"gt=0"`
+}
+
+// impersonationParams is the path of an impersonation
+type impersonationParams struct {
+	ID int64 `params:"id" validate:"gt=0"`
+}
+
+// ImpersonationHandler handles the admin endpoints acting as a user
+type ImpersonationHandler struct {
+	impersonationService *service.ImpersonationService
+	enabled              bool
+	validator            *validator.Validator
+}
+
+// NewImpersonationHandler creates a new ImpersonationHandler. While not
+// enabled, no impersonation tokens are issued
+func NewImpersonationHandler(impersonationService *service.ImpersonationService, enabled bool, validator *validator.Validator) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+		enabled:              enabled,
+		validator:            validator,
+	}
+}
+
+// StartImpersonation handles POST /api/v1/admin/users/:user_id/impersonate
+// Issues a short-lived read-only token acting as the user. The calling
+// operator and the reason are recorded along with every request made with
+// the token
+func (h *ImpersonationHandler) StartImpersonation(c *fiber.Ctx) error {
+	if !h.enabled {
+		return sendError(c, errors.ErrForbidden.WithMessage("impersonation is disabled"))
+	}
+
+	var path impersonationUserParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+
+	var req dto.StartImpersonationRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	imp, token, err := h.impersonationService.Start(c.UserContext(), path.UserID, middleware.GetOperator(c), req.Reason,
+		time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.StartImpersonationResponse{
+		ImpersonationResponse: toImpersonationResponse(imp),
+		Token:                 token,
+		Header:                middleware.ImpersonationHeader,
+	})
+}
+
+// GetImpersonations handles GET /api/v1/admin/impersonations
+// Returns the latest impersonations, optionally of one user (?user_id=)
+func (h *ImpersonationHandler) GetImpersonations(c *fiber.Ctx) error {
+	var query dto.ImpersonationsQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	if query.Limit == 0 {
+		query.Limit = 50
+	}
+
+	impersonations, err := h.impersonationService.List(c.UserContext(), query.UserID, query.Limit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.ImpersonationResponse, len(impersonations))
+	for i := range impersonations {
+		items[i] = toImpersonationResponse(&impersonations[i])
+	}
+
+	return c.JSON(dto.ImpersonationsResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// RevokeImpersonation handles DELETE /api/v1/admin/impersonations/:id
+func (h *ImpersonationHandler) RevokeImpersonation(c *fiber.Ctx) error {
+	var path impersonationParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+
+	if err := h.impersonationService.Revoke(c.UserContext(), path.ID); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Impersonation revoked",
+	})
+}
+
+// toImpersonationResponse converts service.Impersonation to dto.ImpersonationResponse
+func toImpersonationResponse(imp *service.Impersonation) dto.ImpersonationResponse {
+	return dto.ImpersonationResponse{
+		ID:        imp.ID,
+		UserID:    imp.UserID,
+		Operator:  imp.Operator,
+		Reason:    imp.Reason,
+		Active:    imp.Active(time.Now()),
+		Requests:  imp.Requests,
+		CreatedAt: imp.CreatedAt,
+		ExpiresAt: imp.ExpiresAt,
+		RevokedAt: imp.RevokedAt,
+	}
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+const (
+	maxImportFileSize = 1 << 20 // 1 MB
+	maxImportRows     = 500
+)
+
+// importColumns maps accepted CSV header names to import fields, covering
+// the column names used by TradingView-like exports
+var importColumns = map[string]string{
+	"coin_symbol":         "coin_symbol",
+	"coin":                "coin_symbol",
+	"symbol":              "coin_symbol",
+	"ticker":              "coin_symbol",
+	"alert_type":          "alert_type",
+	"type":                "alert_type",
+	"condition":           "alert_type",
+	"condition_value":     "condition_value",
+	"value":               "condition_value",
+	"price":               "condition_value",
+	"condition_timeframe": "condition_timeframe",
+	"timeframe":           "condition_timeframe",
+	"is_recurring":        "is_recurring",
+	"recurring":           "is_recurring",
+	"periodic_interval":   "periodic_interval",
+	"interval":            "periodic_interval",
+	"priority":            "priority",
+}
+
+// importAlertTypes maps condition names of other tools to alert types
+var importAlertTypes = map[string]string{
+	"ABOVE":          alerttype.PriceAbove,
+	"CROSSING_UP":    alerttype.PriceAbove,
+	"GREATER_THAN":   alerttype.PriceAbove,
+	"BELOW":          alerttype.PriceBelow,
+	"CROSSING_DOWN":  alerttype.PriceBelow,
+	"LESS_THAN":      alerttype.PriceBelow,
+	"CHANGE":         alerttype.PriceChangePct,
+	"PRICE_CHANGE":   alerttype.PriceChangePct,
+	"PERCENT_CHANGE": alerttype.PriceChangePct,
+}
+
+// importQuotes are quote assets stripped from pair symbols like BTCUSDT
+var importQuotes = []string{"FDUSD", "USDT", "USDC", "BUSD", "USD"}
+
+// importRecord is a parsed row with its line in the file and the reason
+// it could not be parsed, if any
+type importRecord struct {
+	line int
+	row  dto.ImportRowRequest
+	err  string
+}
+
+// ImportHandler handles bulk import of coins and alerts
+type ImportHandler struct {
+	importService *service.ImportService
+	validator     *validator.Validator
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(importService *service.ImportService, validator *validator.Validator) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+		validator:     validator,
+	}
+}
+
+// Import handles POST /api/v1/import
+// The file is sent as multipart field "file" or as the raw body; its format
+// comes from ?format=csv|json, the file extension or the Content-Type
+func (h *ImportHandler) Import(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	data, format, err := readImportFile(c)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	var records []importRecord
+	switch format {
+	case "csv":
+		records, err = parseImportCSV(data)
+	case "json":
+		records, err = parseImportJSON(data)
+	default:
+		return sendError(c, errors.ErrBadRequest.WithMessage("Unsupported file format, use csv or json"))
+	}
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	if len(records) == 0 {
+		return sendError(c, errors.ErrBadRequest.WithMessage("The file has no rows"))
+	}
+	if len(records) > maxImportRows {
+		return sendError(c, errors.ErrBadRequest.WithMessage(
+			fmt.Sprintf("The file has more than %d rows", maxImportRows),
+		))
+	}
+
+	rows := make([]service.ImportRow, len(records))
+	for i, rec := range records {
+		rows[i] = h.toImportRow(rec)
+	}
+
+	report, err := h.importService.Import(c.UserContext(), userID, rows)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	results := make([]dto.ImportRowResponse, len(report.Rows))
+	for i, r := range report.Rows {
+		results[i] = dto.ImportRowResponse{
+			Line:       r.Line,
+			CoinSymbol: r.CoinSymbol,
+			AlertType:  r.AlertType,
+			Status:     r.Status,
+			Error:      r.Error,
+			AlertID:    r.AlertID,
+		}
+	}
+
+	return c.JSON(dto.ImportResponse{
+		Rows:          results,
+		CoinsAdded:    report.CoinsAdded,
+		AlertsCreated: report.AlertsCreated,
+		Failed:        report.Failed,
+	})
+}
+
+// toImportRow validates a parsed record and converts it to a service row
+func (h *ImportHandler) toImportRow(rec importRecord) service.ImportRow {
+	row := service.ImportRow{Line: rec.line, CoinSymbol: rec.row.CoinSymbol, Invalid: rec.err}
+	if rec.row.AlertType != "" {
+		row.Alert = &service.CreateAlertParams{
+			AlertType:          rec.row.AlertType,
+			ConditionValue:     rec.row.ConditionValue,
+			ConditionTimeframe: rec.row.ConditionTimeframe,
+			IsRecurring:        rec.row.IsRecurring,
+			PeriodicInterval:   rec.row.PeriodicInterval,
+			Priority:           rec.row.Priority,
+		}
+	}
+
+	if row.Invalid == "" {
+		if errs := h.validator.Validate(rec.row); errs != nil {
+			msgs := make([]string, len(errs))
+			for i, e := range errs {
+				msgs[i] = e.Field + ": " + e.Message
+			}
+			row.Invalid = strings.Join(msgs, "; ")
+		}
+	}
+
+	return row
+}
+
+// readImportFile returns the uploaded file and its format
+func readImportFile(c *fiber.Ctx) ([]byte, string, error) {
+	format := strings.ToLower(c.Query("format"))
+
+	if fh, err := c.FormFile("file"); err == nil {
+		if fh.Size > maxImportFileSize {
+			return nil, "", errors.ErrBadRequest.WithMessage("The file is larger than 1 MB")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, "", errors.ErrBadRequest.WithMessage("Failed to read the file")
+		}
+		defer f.Close()
+
+		data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize))
+		if err != nil {
+			return nil, "", errors.ErrBadRequest.WithMessage("Failed to read the file")
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fh.Filename)), ".")
+		}
+		return data, format, nil
+	}
+
+	data := c.Body()
+	if len(data) > maxImportFileSize {
+		return nil, "", errors.ErrBadRequest.WithMessage("The file is larger than 1 MB")
+	}
+	if format == "" {
+		contentType := strings.ToLower(string(c.Request().Header.ContentType()))
+		switch {
+		case strings.Contains(contentType, "json"):
+			format = "json"
+		case strings.Contains(contentType, "csv"), strings.HasPrefix(contentType, "text/plain"):
+			format = "csv"
+		}
+	}
+	return data, format, nil
+}
+
+// parseImportJSON parses {"rows": [...]} or a bare array of rows
+func parseImportJSON(data []byte) ([]importRecord, error) {
+	var rows []dto.ImportRowRequest
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return nil, errors.ErrBadRequest.WithMessage("Invalid JSON file")
+		}
+	} else {
+		var req dto.ImportRequest
+		if err := json.Unmarshal(trimmed, &req); err != nil {
+			return nil, errors.ErrBadRequest.WithMessage("Invalid JSON file")
+		}
+		rows = req.Rows
+	}
+
+	records := make([]importRecord, len(rows))
+	for i, row := range rows {
+		row.CoinSymbol = normalizeImportSymbol(row.CoinSymbol)
+		row.AlertType = normalizeImportAlertType(row.AlertType)
+		records[i] = importRecord{line: i + 1, row: row}
+	}
+	return records, nil
+}
+
+// parseImportCSV parses a CSV file with a header row. A file without a
+// symbol column, such as an exported watchlist, is read as a list of coins
+func parseImportCSV(data []byte) ([]importRecord, error) {
+	type csvLine struct {
+		line   int
+		fields []string
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var lines []csvLine
+	for {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.ErrBadRequest.WithMessage("Invalid CSV file: " + err.Error())
+		}
+		line, _ := r.FieldPos(0)
+		lines = append(lines, csvLine{line: line, fields: fields})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range lines[0].fields {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := importColumns[name]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+
+	var records []importRecord
+	if _, ok := columns["coin_symbol"]; !ok {
+		// Symbol list: every cell is a coin, first line included
+		for _, l := range lines {
+			for _, field := range l.fields {
+				if strings.TrimSpace(field) != "" {
+					records = append(records, importRecord{
+						line: l.line,
+						row:  dto.ImportRowRequest{CoinSymbol: normalizeImportSymbol(field)},
+					})
+				}
+			}
+		}
+		return records, nil
+	}
+
+	for _, l := range lines[1:] {
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(l.fields) {
+				return ""
+			}
+			return strings.TrimSpace(l.fields[i])
+		}
+
+		rec := importRecord{line: l.line}
+		rec.row.CoinSymbol = normalizeImportSymbol(get("coin_symbol"))
+		rec.row.AlertType = normalizeImportAlertType(get("alert_type"))
+		rec.row.Priority = strings.ToLower(get("priority"))
+		if v := get("condition_value"); v != "" {
+			value, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+			if err != nil {
+				rec.err = "condition_value: must be a number"
+			}
+			rec.row.ConditionValue = value
+		}
+		if v := get("condition_timeframe"); v != "" {
+			rec.row.ConditionTimeframe = &v
+		}
+		if v := get("periodic_interval"); v != "" {
+			rec.row.PeriodicInterval = &v
+		}
+		if v := get("is_recurring"); v != "" {
+			recurring, ok := parseImportBool(v)
+			if !ok && rec.err == "" {
+				rec.err = "is_recurring: must be true or false"
+			}
+			rec.row.IsRecurring = recurring
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// normalizeImportSymbol turns exchange pairs like "BINANCE:BTCUSDT" or
+// "BTCUSDT.P" into coin symbols
+func normalizeImportSymbol(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if i := strings.LastIndex(symbol, ":"); i >= 0 {
+		symbol = symbol[i+1:]
+	}
+	symbol = strings.TrimSuffix(symbol, ".P")
+	symbol = strings.ReplaceAll(symbol, "/", "")
+
+	for _, quote := range importQuotes {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && len(base) >= 2 {
+			return base
+		}
+	}
+	return symbol
+}
+
+// normalizeImportAlertType accepts alert types in any case and the
+// condition names of other tools
+func normalizeImportAlertType(alertType string) string {
+	alertType = strings.ToUpper(strings.TrimSpace(alertType))
+	alertType = strings.NewReplacer(" ", "_", "-", "_").Replace(alertType)
+	if mapped, ok := importAlertTypes[alertType]; ok {
+		return mapped
+	}
+	return alertType
+}
+
+// parseImportBool parses true/false, 1/0 and yes/no
+func parseImportBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "yes", "y":
+		return true, true
+	case "no", "n":
+		return false, true
+	}
+	b, err := strconv.ParseBool(s)
+	return b, err == nil
+}
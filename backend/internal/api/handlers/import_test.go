@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	data := []byte("Symbol,Condition,Price,Recurring\n" +
+		"BINANCE:BTCUSDT,Crossing Up,\"100,000\",yes\n" +
+		"eth,,,\n" +
+		"SOL,below,abc,\n")
+
+	records, err := parseImportCSV(data)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, 2, records[0].line)
+	assert.Equal(t, "BTC", records[0].row.CoinSymbol)
+	assert.Equal(t, "PRICE_ABOVE", records[0].row.AlertType)
+	assert.Equal(t, 100000.0, records[0].row.ConditionValue)
+	assert.True(t, records[0].row.IsRecurring)
+	assert.Empty(t, records[0].err)
+
+	assert.Equal(t, "ETH", records[1].row.CoinSymbol)
+	assert.Empty(t, records[1].row.AlertType)
+
+	assert.Equal(t, 4, records[2].line)
+	assert.Equal(t, "PRICE_BELOW", records[2].row.AlertType)
+	assert.NotEmpty(t, records[2].err)
+}
+
+func TestParseImportCSV_SymbolList(t *testing.T) {
+	records, err := parseImportCSV([]byte("BINANCE:ETHUSDT,BYBIT:SOLUSDT.P\nBTC/USD\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "ETH", records[0].row.CoinSymbol)
+	assert.Equal(t, "SOL", records[1].row.CoinSymbol)
+	assert.Equal(t, "BTC", records[2].row.CoinSymbol)
+	assert.Equal(t, 2, records[2].line)
+}
+
+func TestParseImportJSON(t *testing.T) {
+	records, err := parseImportJSON([]byte(`{"rows":[{"coin_symbol":"btcusdt","alert_type":"price_below","condition_value":50000}]}`))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "BTC", records[0].row.CoinSymbol)
+	assert.Equal(t, "PRICE_BELOW", records[0].row.AlertType)
+
+	records, err = parseImportJSON([]byte(`[{"coin_symbol":"ETH"},{"coin_symbol":"SOL"}]`))
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	_, err = parseImportJSON([]byte(`{"rows":`))
+	assert.Error(t, err)
+}
@@ -2,13 +2,13 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/coingecko"
+	"github.com/weqory/backend/internal/gas"
 	"github.com/weqory/backend/internal/service"
 	"github.com/weqory/backend/pkg/errors"
 )
@@ -16,46 +16,74 @@ import (
 // MarketHandler handles market endpoints
 type MarketHandler struct {
 	watchlistService *service.WatchlistService
-	httpClient       *http.Client
+	categoryService  *service.CategoryService
+	globalSync       *coingecko.GlobalSyncService
+	gasCache         *gas.Cache
+	logger           *slog.Logger
 }
 
 // NewMarketHandler creates a new MarketHandler
-func NewMarketHandler(watchlistService *service.WatchlistService) *MarketHandler {
+func NewMarketHandler(
+	watchlistService *service.WatchlistService,
+	categoryService *service.CategoryService,
+	globalSync *coingecko.GlobalSyncService,
+	gasCache *gas.Cache,
+	logger *slog.Logger,
+) *MarketHandler {
 	return &MarketHandler{
 		watchlistService: watchlistService,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		categoryService:  categoryService,
+		globalSync:       globalSync,
+		gasCache:         gasCache,
+		logger:           logger,
 	}
 }
 
 // GetMarketOverview handles GET /api/v1/market/overview
+// Global stats come from the snapshot refreshed in the background, so this
+// endpoint never waits on CoinGecko or alternative.me
 func (h *MarketHandler) GetMarketOverview(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 
 	// Get top coins from database (100 to have enough for top 20 gainers/losers)
-	topCoins, err := h.watchlistService.GetAvailableCoins(ctx, "", 100)
+	topCoins, err := h.watchlistService.GetAvailableCoins(ctx, "", "", 100, false)
 	if err != nil {
 		return sendError(c, err)
 	}
 
-	// Fetch Fear & Greed Index
-	fearGreed, err := h.fetchFearGreedIndex(ctx)
-	if err != nil {
-		// Don't fail the entire request if Fear & Greed fails
-		fearGreed = &dto.FearGreedResponse{
-			Value:          50,
-			Classification: "Neutral",
-		}
-	}
-
 	// Build response
 	coinResponses := make([]dto.CoinResponse, len(topCoins))
 	for i, coin := range topCoins {
 		coinResponses[i] = *toCoinResponse(&coin)
 	}
 
-	// Calculate market stats from top coins (simplified)
+	overview, err := h.globalSync.GetOverview(ctx)
+	if err != nil {
+		// Cache errors are not fatal - fall back to values computed from the DB
+		h.logger.Warn("failed to read market overview cache", slog.String("error", err.Error()))
+	}
+
+	gasPrice := h.getGasPrice(ctx)
+
+	if overview != nil {
+		updatedAt := overview.UpdatedAt.Format(time.RFC3339)
+		return c.JSON(dto.MarketOverviewResponse{
+			TotalMarketCap:        overview.TotalMarketCap,
+			TotalVolume24h:        overview.TotalVolume24h,
+			BTCDominance:          overview.BTCDominance,
+			ETHDominance:          overview.ETHDominance,
+			MarketCapChange24hPct: overview.MarketCapChange24hPct,
+			FearGreedIndex: &dto.FearGreedResponse{
+				Value:          overview.FearGreedValue,
+				Classification: overview.FearGreedClassification,
+			},
+			TopCoins:  coinResponses,
+			UpdatedAt: &updatedAt,
+			GasPrice:  gasPrice,
+		})
+	}
+
+	// No snapshot yet (e.g. right after a cold start) - approximate from top coins
 	var totalMarketCap, totalVolume float64
 	for _, coin := range topCoins {
 		if coin.MarketCap != nil {
@@ -82,31 +110,50 @@ func (h *MarketHandler) GetMarketOverview(c *fiber.Ctx) error {
 		BTCDominance:          btcDominance,
 		ETHDominance:          ethDominance,
 		MarketCapChange24hPct: 0, // Would need historical data
-		FearGreedIndex:        fearGreed,
-		TopCoins:              coinResponses,
+		FearGreedIndex: &dto.FearGreedResponse{
+			Value:          50,
+			Classification: "Neutral",
+		},
+		TopCoins: coinResponses,
+		GasPrice: gasPrice,
 	})
 }
 
-// Category symbols for filtering
-var categorySymbols = map[string][]string{
-	"defi":   {"UNI", "AAVE", "CAKE", "SUSHI", "CRV", "COMP", "MKR", "SNX", "YFI", "LDO", "DYDX", "GMX", "1INCH", "BAL", "RUNE", "INJ", "PENDLE", "JUP", "RAY", "ORCA"},
-	"layer1": {"BTC", "ETH", "SOL", "ADA", "AVAX", "DOT", "NEAR", "ATOM", "FTM", "ALGO", "HBAR", "ICP", "APT", "SUI", "SEI", "TIA", "INJ", "KAS", "TON", "TRX"},
-	"meme":   {"DOGE", "SHIB", "PEPE", "FLOKI", "BONK", "WIF", "MEME", "ELON", "BABYDOGE", "SNEK", "MYRO", "BOME", "BRETT", "MOG", "POPCAT", "NEIRO", "TURBO", "LADYS", "WOJAK", "MONG"},
-	"gaming": {"AXS", "SAND", "MANA", "GALA", "ENJ", "IMX", "ILV", "GODS", "ALICE", "YGG", "MAGIC", "PRIME", "PIXEL", "PORTAL", "BEAM", "RONIN", "SUPER", "PYR", "WEMIX", "GMT"},
-	"ai":     {"FET", "AGIX", "OCEAN", "RNDR", "TAO", "ARKM", "WLD", "AI", "NMR", "CTXC", "GRT", "ORAI", "AIOZ", "PHB", "RSS3", "ALI", "VIDT", "NFP", "IO", "ATH"},
+// getGasPrice returns the cached Ethereum gas price, or nil when there is
+// none; the overview is served without it
+func (h *MarketHandler) getGasPrice(ctx context.Context) *dto.GasPriceResponse {
+	price, err := h.gasCache.Get(ctx)
+	if err != nil {
+		h.logger.Warn("failed to read gas price cache", slog.String("error", err.Error()))
+		return nil
+	}
+	if price == nil {
+		return nil
+	}
+
+	return &dto.GasPriceResponse{
+		BaseFee:   price.BaseFeeGwei,
+		Slow:      price.SlowGwei,
+		Standard:  price.StandardGwei,
+		Fast:      price.FastGwei,
+		UpdatedAt: price.UpdatedAt.Format(time.RFC3339),
+	}
 }
 
 // GetCategoryCoins handles GET /api/v1/market/category/:id
 func (h *MarketHandler) GetCategoryCoins(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 	categoryID := c.Params("id")
 
-	symbols, ok := categorySymbols[categoryID]
-	if !ok {
-		return sendError(c, errors.ErrInvalidInput.WithMessage("Invalid category ID"))
+	category, err := h.categoryService.GetByID(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, errors.ErrCategoryNotFound) {
+			return sendError(c, errors.ErrInvalidInput.WithMessage("Invalid category ID"))
+		}
+		return sendError(c, err)
 	}
 
-	coins, err := h.watchlistService.GetCoinsBySymbols(ctx, symbols, 20)
+	coins, err := h.watchlistService.GetAvailableCoins(ctx, "", category.ID, 20, false)
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -118,48 +165,33 @@ func (h *MarketHandler) GetCategoryCoins(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"category": categoryID,
+		"category": category.ID,
+		"name":     category.Name,
 		"coins":    coinResponses,
 	})
 }
 
-// fetchFearGreedIndex fetches the Fear & Greed Index from alternative.me API
-func (h *MarketHandler) fetchFearGreedIndex(ctx context.Context) (*dto.FearGreedResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.alternative.me/fng/?limit=1", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := h.httpClient.Do(req)
+// GetSectors handles GET /api/v1/market/sectors
+func (h *MarketHandler) GetSectors(c *fiber.Ctx) error {
+	categories, err := h.categoryService.GetAll(c.UserContext())
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fear greed API returned status %d", resp.StatusCode)
-	}
-
-	var result struct {
-		Data []struct {
-			Value               string `json:"value"`
-			ValueClassification string `json:"value_classification"`
-		} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+		return sendError(c, err)
 	}
 
-	if len(result.Data) == 0 {
-		return nil, errors.ErrExternalService
+	sectors := make([]dto.SectorResponse, len(categories))
+	for i, category := range categories {
+		sectors[i] = dto.SectorResponse{
+			ID:                   category.ID,
+			Name:                 category.Name,
+			MarketCap:            category.MarketCap,
+			MarketCapChange24h:   category.MarketCapChange24h,
+			Volume24h:            category.Volume24h,
+			CoinsCount:           category.CoinsCount,
+			AvgPriceChange24hPct: category.AvgPriceChange24hPct,
+		}
 	}
 
-	var value int
-	fmt.Sscanf(result.Data[0].Value, "%d", &value)
-
-	return &dto.FearGreedResponse{
-		Value:          value,
-		Classification: result.Data[0].ValueClassification,
-	}, nil
+	return c.JSON(dto.SectorsResponse{
+		Sectors: sectors,
+	})
 }
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// NotificationPreviewHandler renders alert messages without sending them
+type NotificationPreviewHandler struct {
+	alertService *service.AlertService
+	userService  *service.UserService
+	telegram     *telegram.Client
+	validator    *validator.Validator
+}
+
+// NewNotificationPreviewHandler creates a new NotificationPreviewHandler
+func NewNotificationPreviewHandler(
+	alertService *service.AlertService,
+	userService *service.UserService,
+	telegramClient *telegram.Client,
+	validator *validator.Validator,
+) *NotificationPreviewHandler {
+	return &NotificationPreviewHandler{
+		alertService: alertService,
+		userService:  userService,
+		telegram:     telegramClient,
+		validator:    validator,
+	}
+}
+
+// GetPreview handles GET /api/v1/notifications/preview?alert_id=
+// Returns the message the alert would send if it triggered now, rendered
+// with the user's language and timezone
+func (h *NotificationPreviewHandler) GetPreview(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var query dto.NotificationPreviewQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+
+	alert, err := h.alertService.GetOwned(c.UserContext(), userID, query.AlertID)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	user, err := h.userService.GetByID(c.UserContext(), userID)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	notification := telegram.AlertNotification{
+		UserID:         userID,
+		TelegramID:     user.TelegramID,
+		CoinSymbol:     alert.Coin.Symbol,
+		CoinName:       alert.Coin.Name,
+		AlertType:      alert.AlertType,
+		ConditionValue: alert.ConditionValue,
+		TriggeredPrice: previewValue(alert),
+		TriggeredAt:    time.Now(),
+		Timezone:       user.Timezone,
+		Language:       user.LanguageCode,
+		NumberFormat:   user.NumberFormat,
+		IsRecurring:    alert.IsRecurring,
+		Compact:        user.MessageFormat == service.MessageFormatCompact,
+	}
+	if alert.Name != nil {
+		notification.AlertName = *alert.Name
+	}
+
+	return c.JSON(dto.NotificationPreviewResponse{
+		AlertID:   alert.ID,
+		Text:      h.telegram.FormatAlertMessage(notification),
+		ParseMode: "HTML",
+	})
+}
+
+// previewValue is the value shown as current in a preview: the coin's price,
+// or the condition value itself for types not measured in the coin's price
+func previewValue(alert *service.Alert) float64 {
+	if def, ok := alerttype.Lookup(alert.AlertType); ok && def.Source == alerttype.SourceGas {
+		return alert.ConditionValue
+	}
+	if alert.Coin.CurrentPrice != nil {
+		return *alert.Coin.CurrentPrice
+	}
+	return alert.ConditionValue
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// OnboardingHandler handles onboarding endpoints
+type OnboardingHandler struct {
+	onboardingService *service.OnboardingService
+}
+
+// NewOnboardingHandler creates a new OnboardingHandler
+func NewOnboardingHandler(onboardingService *service.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingService: onboardingService,
+	}
+}
+
+// GetOnboarding handles GET /api/v1/users/me/onboarding
+func (h *OnboardingHandler) GetOnboarding(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	onboarding, err := h.onboardingService.Get(c.UserContext(), userID)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	steps := make([]dto.OnboardingStepResponse, len(onboarding.Steps))
+	for i, step := range onboarding.Steps {
+		steps[i] = dto.OnboardingStepResponse{
+			Key:         step.Key,
+			Completed:   step.CompletedAt != nil,
+			CompletedAt: step.CompletedAt,
+		}
+	}
+
+	return c.JSON(dto.OnboardingResponse{
+		Steps:          steps,
+		CompletedSteps: onboarding.CompletedSteps(),
+		TotalSteps:     len(steps),
+		Completed:      onboarding.Completed(),
+		CompletedAt:    onboarding.CompletedAt,
+	})
+}
@@ -1,15 +1,12 @@
 package handlers
 
 import (
-	"encoding/json"
 	"log/slog"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/dto"
 	"github.com/weqory/backend/internal/api/middleware"
 	"github.com/weqory/backend/internal/service"
-	"github.com/weqory/backend/internal/telegram"
 	"github.com/weqory/backend/pkg/errors"
 	"github.com/weqory/backend/pkg/validator"
 )
@@ -37,7 +34,7 @@ func NewPaymentHandler(
 // GetPlans handles GET /api/v1/payments/plans
 // Returns available subscription plans with pricing
 func (h *PaymentHandler) GetPlans(c *fiber.Ctx) error {
-	plans, err := h.paymentService.GetAllPlans(c.Context())
+	plans, err := h.paymentService.GetAllPlans(c.UserContext())
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -51,6 +48,8 @@ func (h *PaymentHandler) GetPlans(c *fiber.Ctx) error {
 			MaxAlerts:            plan.MaxAlerts,
 			MaxNotifications:     plan.MaxNotifications,
 			HistoryRetentionDays: plan.HistoryRetentionDays,
+			PriceHistoryDays:     plan.PriceHistoryDays,
+			AlertCharts:          plan.AlertCharts,
 			PriceMonthly:         plan.PriceMonthly,
 			PriceYearly:          plan.PriceYearly,
 		}
@@ -68,15 +67,11 @@ func (h *PaymentHandler) CreateInvoice(c *fiber.Ctx) error {
 	}
 
 	var req dto.CreateInvoiceRequest
-	if err := c.BodyParser(&req); err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid request body"))
-	}
-
-	if errs := h.validator.Validate(req); errs != nil {
-		return sendValidationError(c, errs)
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
 	}
 
-	result, err := h.paymentService.CreateInvoice(c.Context(), userID, service.CreateInvoiceRequest{
+	result, err := h.paymentService.CreateInvoice(c.UserContext(), userID, service.CreateInvoiceRequest{
 		Plan:   req.Plan,
 		Period: req.Period,
 	})
@@ -90,37 +85,71 @@ func (h *PaymentHandler) CreateInvoice(c *fiber.Ctx) error {
 	})
 }
 
-// GetPaymentHistory handles GET /api/v1/payments/history
-// Returns user's payment history
-func (h *PaymentHandler) GetPaymentHistory(c *fiber.Ctx) error {
+// StartTrial handles POST /api/v1/payments/start-trial
+// Puts the user on a free 7-day Pro trial, redeemable once
+func (h *PaymentHandler) StartTrial(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	// Parse pagination
-	limit := 20
-	offset := 0
+	trial, err := h.paymentService.StartTrial(c.UserContext(), userID)
+	if err != nil {
+		return sendError(c, err)
+	}
 
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	return c.Status(fiber.StatusCreated).JSON(dto.StartTrialResponse{
+		Plan:      trial.Plan,
+		StartedAt: trial.StartedAt,
+		ExpiresAt: trial.ExpiresAt,
+	})
+}
+
+// CancelSubscription handles POST /api/v1/payments/cancel
+// Stops the plan from renewing, or downgrades it, at the end of the period
+func (h *PaymentHandler) CancelSubscription(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
 	}
-	if o := c.Query("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-			offset = parsed
+
+	// The body is optional; without it the plan is cancelled
+	var req dto.CancelSubscriptionRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, h.validator, &req); err != nil {
+			return sendError(c, err)
 		}
 	}
 
-	payments, err := h.paymentService.GetPaymentHistory(c.Context(), userID, limit, offset)
+	change, err := h.paymentService.CancelSubscription(c.UserContext(), userID, req.Plan)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(toPlanChange(change))
+}
+
+// GetPaymentHistory handles GET /api/v1/payments/history
+// Returns user's payment history
+func (h *PaymentHandler) GetPaymentHistory(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	page, err := parsePage(c, h.validator, 20)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	payments, err := h.paymentService.GetPaymentHistory(c.UserContext(), userID, page)
 	if err != nil {
 		return sendError(c, err)
 	}
 
 	// Convert to response
-	items := make([]dto.PaymentResponse, len(payments))
-	for i, p := range payments {
+	items := make([]dto.PaymentResponse, len(payments.Items))
+	for i, p := range payments.Items {
 		items[i] = dto.PaymentResponse{
 			ID:          p.ID,
 			Plan:        p.Plan,
@@ -133,81 +162,9 @@ func (h *PaymentHandler) GetPaymentHistory(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(dto.PaymentHistoryResponse{
-		Items: items,
-		Total: len(items),
+		Items:      items,
+		Total:      payments.Total,
+		Limit:      page.Limit,
+		NextCursor: payments.NextCursor,
 	})
 }
-
-// HandleWebhook handles POST /api/v1/payments/webhook
-// Processes Telegram payment webhooks (pre_checkout_query and successful_payment)
-// This endpoint does NOT require authentication - it receives calls from Telegram
-func (h *PaymentHandler) HandleWebhook(c *fiber.Ctx) error {
-	// Parse the update
-	var update telegram.PaymentUpdate
-	if err := json.Unmarshal(c.Body(), &update); err != nil {
-		h.logger.Error("failed to parse webhook payload",
-			slog.String("error", err.Error()),
-			slog.String("body", string(c.Body())),
-		)
-		// Return 400 for malformed payloads - no point retrying
-		return c.SendStatus(fiber.StatusBadRequest)
-	}
-
-	// Handle pre-checkout query
-	if update.PreCheckoutQuery != nil {
-		h.logger.Info("received pre-checkout query",
-			slog.String("query_id", update.PreCheckoutQuery.ID),
-			slog.Int("amount", update.PreCheckoutQuery.TotalAmount),
-		)
-
-		if err := h.paymentService.HandlePreCheckoutQuery(c.Context(), update.PreCheckoutQuery); err != nil {
-			h.logger.Error("failed to handle pre-checkout query",
-				slog.String("error", err.Error()),
-			)
-			// Still return 200 to Telegram - we've already sent the answer
-			return c.SendStatus(fiber.StatusOK)
-		}
-
-		return c.SendStatus(fiber.StatusOK)
-	}
-
-	// Handle successful payment
-	if update.Message != nil && update.Message.SuccessfulPayment != nil {
-		payment := update.Message.SuccessfulPayment
-
-		h.logger.Info("received successful payment",
-			slog.String("charge_id", payment.TelegramPaymentChargeID),
-			slog.Int("amount", payment.TotalAmount),
-			slog.String("currency", payment.Currency),
-		)
-
-		if err := h.paymentService.HandleSuccessfulPayment(c.Context(), payment); err != nil {
-			h.logger.Error("failed to process successful payment",
-				slog.String("charge_id", payment.TelegramPaymentChargeID),
-				slog.String("error", err.Error()),
-			)
-
-			// Check error type to decide on retry strategy
-			// For transient errors (database), return 500 to trigger Telegram retry
-			// For permanent errors (invalid payload, duplicate), return 200
-			if errors.Is(err, errors.ErrDatabase) {
-				h.logger.Warn("transient error processing payment, returning 500 for retry",
-					slog.String("charge_id", payment.TelegramPaymentChargeID),
-				)
-				return c.SendStatus(fiber.StatusInternalServerError)
-			}
-
-			// Permanent errors - payment data invalid or already processed
-			return c.SendStatus(fiber.StatusOK)
-		}
-
-		return c.SendStatus(fiber.StatusOK)
-	}
-
-	// Unknown update type - log and ignore
-	h.logger.Warn("received unknown webhook update",
-		slog.Int64("update_id", update.UpdateID),
-	)
-
-	return c.SendStatus(fiber.StatusOK)
-}
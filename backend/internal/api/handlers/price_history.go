@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// PriceHistoryHandler handles durable price history endpoints
+type PriceHistoryHandler struct {
+	priceHistoryService *service.PriceHistoryService
+	validator           *validator.Validator
+}
+
+// NewPriceHistoryHandler creates a new PriceHistoryHandler
+func NewPriceHistoryHandler(priceHistoryService *service.PriceHistoryService, validator *validator.Validator) *PriceHistoryHandler {
+	return &PriceHistoryHandler{
+		priceHistoryService: priceHistoryService,
+		validator:           validator,
+	}
+}
+
+// GetPriceHistory handles GET /api/v1/coins/:symbol/history
+// from and to are RFC 3339 times; the default period is the last 24 hours
+func (h *PriceHistoryHandler) GetPriceHistory(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var query dto.PriceHistoryQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	// Both are valid RFC 3339 times or empty, which means the default
+	from, _ := time.Parse(time.RFC3339, query.From)
+	to, _ := time.Parse(time.RFC3339, query.To)
+
+	history, err := h.priceHistoryService.Get(c.UserContext(), userID, c.Params("symbol"), from, to)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	points := make([]dto.PricePoint, len(history.Points))
+	for i, p := range history.Points {
+		points[i] = dto.PricePoint{Time: p.Time, Price: p.Price}
+	}
+
+	return c.JSON(dto.PriceHistoryResponse{
+		Symbol:      history.Symbol,
+		From:        history.From,
+		To:          history.To,
+		HistoryDays: history.HistoryDays,
+		Points:      points,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/rbac"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// roleUserParams is the path of a user's role endpoint
+type roleUserParams struct {
+	UserID int64 `params:"user_id" validate:"gt=0"`
+}
+
+// RolesHandler handles the admin endpoints of user roles
+type RolesHandler struct {
+	userService  *service.UserService
+	auditService *service.AuditService
+	validator    *validator.Validator
+}
+
+// NewRolesHandler creates a new RolesHandler
+func NewRolesHandler(userService *service.UserService, auditService *service.AuditService, validator *validator.Validator) *RolesHandler {
+	return &RolesHandler{
+		userService:  userService,
+		auditService: auditService,
+		validator:    validator,
+	}
+}
+
+// GetStaff handles GET /api/v1/admin/staff
+// Returns the users holding the support or admin role
+func (h *RolesHandler) GetStaff(c *fiber.Ctx) error {
+	users, err := h.userService.ListStaff(c.UserContext())
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.StaffMemberResponse, len(users))
+	for i, u := range users {
+		items[i] = dto.StaffMemberResponse{
+			UserID:     u.ID,
+			TelegramID: u.TelegramID,
+			Username:   u.Username,
+			FirstName:  u.FirstName,
+			Role:       string(u.Role),
+		}
+	}
+
+	return c.JSON(dto.StaffListResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+// SetRole handles PUT /api/v1/admin/users/:user_id/role
+// Grants a user a role; recorded in the audit log
+func (h *RolesHandler) SetRole(c *fiber.Ctx) error {
+	var path roleUserParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+
+	var req dto.SetRoleRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	role := rbac.Role(req.Role)
+	previous, err := h.userService.SetRole(c.UserContext(), path.UserID, role)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	if previous != role {
+		if err := h.auditService.Log(c.UserContext(), path.UserID, middleware.GetOperator(c), service.AuditRoleChanged, map[string]any{
+			"from": string(previous),
+			"to":   string(role),
+		}); err != nil {
+			return sendError(c, err)
+		}
+	}
+
+	return c.JSON(dto.SetRoleResponse{
+		UserID:       path.UserID,
+		Role:         string(role),
+		PreviousRole: string(previous),
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	enginev1 "github.com/weqory/backend/api/proto/engine/v1"
+	notificationv1 "github.com/weqory/backend/api/proto/notification/v1"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/validator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServicesHandler handles admin endpoints backed by the internal gRPC APIs
+// of alert-engine and notification
+type ServicesHandler struct {
+	engine        enginev1.EngineServiceClient
+	notifications notificationv1.NotificationServiceClient
+	validator     *validator.Validator
+}
+
+// NewServicesHandler creates a new ServicesHandler. A nil client makes its
+// endpoints return 503
+func NewServicesHandler(
+	engine enginev1.EngineServiceClient,
+	notifications notificationv1.NotificationServiceClient,
+	validator *validator.Validator,
+) *ServicesHandler {
+	return &ServicesHandler{
+		engine:        engine,
+		notifications: notifications,
+		validator:     validator,
+	}
+}
+
+// GetEngineStats handles GET /api/v1/admin/engine/stats
+func (h *ServicesHandler) GetEngineStats(c *fiber.Ctx) error {
+	if h.engine == nil {
+		return sendError(c, errors.ErrServiceUnavailable.WithMessage("Alert engine gRPC address not configured"))
+	}
+
+	stats, err := h.engine.GetStats(c.UserContext(), &enginev1.GetStatsRequest{})
+	if err != nil {
+		return sendError(c, fromGRPCError(err))
+	}
+
+	return c.JSON(dto.EngineStatsResponse{
+		ActiveAlerts:     stats.GetActiveAlerts(),
+		MonitoredSymbols: stats.GetMonitoredSymbols(),
+		BinanceConnected: stats.GetBinanceConnected(),
+		RetryQueueLength: stats.GetRetryQueueLength(),
+		DeadLetterLength: stats.GetDeadLetterLength(),
+		RejectedTicks:    stats.GetRejectedTicks(),
+	})
+}
+
+// RefreshEngineAlerts handles POST /api/v1/admin/engine/refresh-alerts
+// Makes the engine pick up alert changes without waiting for its next refresh
+func (h *ServicesHandler) RefreshEngineAlerts(c *fiber.Ctx) error {
+	if h.engine == nil {
+		return sendError(c, errors.ErrServiceUnavailable.WithMessage("Alert engine gRPC address not configured"))
+	}
+
+	resp, err := h.engine.RefreshAlerts(c.UserContext(), &enginev1.RefreshAlertsRequest{})
+	if err != nil {
+		return sendError(c, fromGRPCError(err))
+	}
+
+	return c.JSON(dto.RefreshAlertsResponse{
+		ActiveAlerts:     resp.GetActiveAlerts(),
+		MonitoredSymbols: resp.GetMonitoredSymbols(),
+	})
+}
+
+// GetNotificationStats handles GET /api/v1/admin/notifications/stats
+func (h *ServicesHandler) GetNotificationStats(c *fiber.Ctx) error {
+	if h.notifications == nil {
+		return sendError(c, errors.ErrServiceUnavailable.WithMessage("Notification gRPC address not configured"))
+	}
+
+	stats, err := h.notifications.GetStats(c.UserContext(), &notificationv1.GetStatsRequest{})
+	if err != nil {
+		return sendError(c, fromGRPCError(err))
+	}
+
+	return c.JSON(dto.NotificationStatsResponse{
+		NotificationsSent:        stats.GetNotificationsSent(),
+		NotificationsFailed:      stats.GetNotificationsFailed(),
+		NotificationsRateLimited: stats.GetNotificationsRateLimited(),
+		QueueLength:              stats.GetQueueLength(),
+		PendingBatched:           stats.GetPendingBatched(),
+	})
+}
+
+// SendTestNotification handles POST /api/v1/admin/notifications/test
+func (h *ServicesHandler) SendTestNotification(c *fiber.Ctx) error {
+	if h.notifications == nil {
+		return sendError(c, errors.ErrServiceUnavailable.WithMessage("Notification gRPC address not configured"))
+	}
+
+	var req dto.SendTestNotificationRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	_, err := h.notifications.SendTestNotification(c.UserContext(), &notificationv1.SendTestNotificationRequest{
+		UserId: req.UserID,
+	})
+	if err != nil {
+		return sendError(c, fromGRPCError(err))
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Test notification sent",
+	})
+}
+
+// SendMyTestNotification handles POST /api/v1/users/me/test-notification
+// Sends a sample alert through the regular notification path, so the user's
+// rate limit applies, to let users check Telegram delivery
+func (h *ServicesHandler) SendMyTestNotification(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	if h.notifications == nil {
+		return sendError(c, errors.ErrServiceUnavailable.WithMessage("Test notifications are not available"))
+	}
+
+	_, err := h.notifications.SendTestNotification(c.UserContext(), &notificationv1.SendTestNotificationRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return sendError(c, fromGRPCError(err))
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Test notification sent",
+	})
+}
+
+// fromGRPCError converts an internal gRPC error to an AppError
+func fromGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return errors.ErrExternalService.WithCause(err)
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		return errors.ErrNotFound.WithMessage(st.Message())
+	case codes.InvalidArgument:
+		return errors.ErrBadRequest.WithMessage(st.Message())
+	case codes.FailedPrecondition:
+		return errors.ErrBadRequest.WithMessage(st.Message())
+	case codes.ResourceExhausted:
+		return errors.ErrTooManyRequests.WithMessage(st.Message())
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return errors.ErrServiceUnavailable.WithCause(err)
+	default:
+		return errors.ErrExternalService.WithCause(err)
+	}
+}
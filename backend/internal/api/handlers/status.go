@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// incidentParams is the path of an incident
+type incidentParams struct {
+	ID int64 `params:"id" validate:"gt=0"`
+}
+
+// StatusHandler serves the public status page and its admin endpoints
+type StatusHandler struct {
+	health        *HealthHandler
+	statusService *service.StatusService
+	validator     *validator.Validator
+	logger        *slog.Logger
+}
+
+// NewStatusHandler creates a new StatusHandler
+func NewStatusHandler(
+	health *HealthHandler,
+	statusService *service.StatusService,
+	validator *validator.Validator,
+	logger *slog.Logger,
+) *StatusHandler {
+	return &StatusHandler{
+		health:        health,
+		statusService: statusService,
+		validator:     validator,
+		logger:        logger,
+	}
+}
+
+// GetStatus handles GET /api/v1/status
+// Returns component health, Binance stream uptime over 24h, notification
+// delivery rate over the last hour and recent incidents
+func (h *StatusHandler) GetStatus(c *fiber.Ctx) error {
+	return c.JSON(h.status(c.UserContext()))
+}
+
+// GetStatusPage handles GET /status
+// Renders the status as a plain HTML page
+func (h *StatusHandler) GetStatusPage(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := statusPage.Execute(&buf, h.status(c.UserContext())); err != nil {
+		h.logger.Error("failed to render status page", slog.String("error", err.Error()))
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to render status page")
+	}
+
+	c.Type("html", "utf-8")
+	return c.Send(buf.Bytes())
+}
+
+// CreateIncident handles POST /api/v1/admin/status/incidents
+// Posts an incident authored by the calling operator
+func (h *StatusHandler) CreateIncident(c *fiber.Ctx) error {
+	var req dto.CreateIncidentRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	incident, err := h.statusService.CreateIncident(c.UserContext(), req.Title, req.Description, req.Severity, middleware.GetOperator(c))
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toIncidentResponse(incident))
+}
+
+// ResolveIncident handles POST /api/v1/admin/status/incidents/:id/resolve
+func (h *StatusHandler) ResolveIncident(c *fiber.Ctx) error {
+	var path incidentParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+
+	if err := h.statusService.ResolveIncident(c.UserContext(), path.ID); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Incident resolved",
+	})
+}
+
+// status gathers the status page. It is served even when parts of it
+// cannot be read, as that is when it is needed most: those parts are left
+// out and logged
+func (h *StatusHandler) status(ctx context.Context) dto.StatusResponse {
+	overall, components := h.health.checkComponents(ctx)
+
+	resp := dto.StatusResponse{
+		Status:     overall,
+		Components: components,
+		Incidents:  []dto.IncidentResponse{},
+		CheckedAt:  time.Now().UTC(),
+	}
+
+	signals, err := h.statusService.Signals(ctx)
+	if err != nil {
+		h.logger.Warn("failed to read status signals", slog.String("error", err.Error()))
+	} else {
+		if signals.BinanceUptime.Minutes > 0 {
+			pct := signals.BinanceUptime.Ratio * 100
+			resp.BinanceUptimePct = &pct
+		}
+		if rate, ok := signals.Delivery.SuccessRate(); ok {
+			pct := rate * 100
+			resp.DeliverySuccessPct = &pct
+		}
+		resp.NotificationsSent = signals.Delivery.Sent
+		resp.NotificationsFailed = signals.Delivery.Failed
+	}
+
+	incidents, err := h.statusService.RecentIncidents(ctx)
+	if err != nil {
+		h.logger.Warn("failed to read status incidents", slog.String("error", err.Error()))
+	}
+	for i := range incidents {
+		incident := &incidents[i]
+		resp.Incidents = append(resp.Incidents, toIncidentResponse(incident))
+
+		// An open incident marks the service at least degraded, a critical
+		// one down, whatever the checks say
+		if incident.ResolvedAt != nil {
+			continue
+		}
+		switch {
+		case incident.Severity == service.IncidentCritical:
+			resp.Status = HealthStatusUnhealthy
+		case resp.Status == HealthStatusHealthy:
+			resp.Status = HealthStatusDegraded
+		}
+	}
+
+	return resp
+}
+
+// toIncidentResponse converts service.Incident to dto.IncidentResponse
+func toIncidentResponse(i *service.Incident) dto.IncidentResponse {
+	return dto.IncidentResponse{
+		ID:          i.ID,
+		Title:       i.Title,
+		Description: i.Description,
+		Severity:    i.Severity,
+		StartedAt:   i.StartedAt,
+		ResolvedAt:  i.ResolvedAt,
+	}
+}
+
+// statusPage renders a dto.StatusResponse
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"pct": func(v *float64) string {
+		if v == nil {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.2f%%", *v)
+	},
+	"deref": func(t *time.Time) time.Time { return *t },
+	"time": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="60">
+<title>Weqory Status</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 720px; margin: 2rem auto; padding: 0 1rem; color: #17212b; }
+table { width: 100%; border-collapse: collapse; margin-bottom: 1.5rem; }
+td, th { text-align: left; padding: .4rem; border-bottom: 1px solid #e5e7eb; }
+.healthy { color: #16a34a; } .degraded { color: #d97706; } .unhealthy { color: #dc2626; }
+.minor { color: #d97706; } .major, .critical { color: #dc2626; }
+small { color: #6b7280; }
+</style>
+</head>
+<body>
+<h1>Weqory Status: <span class="{{.Status}}">{{.Status}}</span></h1>
+
+<h2>Components</h2>
+<table>
+{{range $name, $c := .Components}}<tr><td>{{$name}}</td><td class="{{$c.Status}}">{{$c.Status}}</td><td>{{$c.LatencyMs}} ms</td></tr>
+{{end}}</table>
+
+<h2>Service levels</h2>
+<table>
+<tr><td>Binance price stream uptime (24h)</td><td>{{pct .BinanceUptimePct}}</td></tr>
+<tr><td>Notification delivery (last hour)</td><td>{{pct .DeliverySuccessPct}} <small>{{.NotificationsSent}} sent, {{.NotificationsFailed}} failed</small></td></tr>
+</table>
+
+<h2>Recent incidents</h2>
+{{range .Incidents}}<p><strong class="{{.Severity}}">{{.Title}}</strong> <small>{{.Severity}}, {{time .StartedAt}}{{with .ResolvedAt}} to {{time (deref .)}}{{else}}, ongoing{{end}}</small><br>{{.Description}}</p>
+{{else}}<p>No recent incidents.</p>
+{{end}}
+<p><small>Updated {{time .CheckedAt}}</small></p>
+</body>
+</html>
+`))
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// TargetsHandler handles price target endpoints
+type TargetsHandler struct {
+	targetService *service.TargetService
+	validator     *validator.Validator
+}
+
+// NewTargetsHandler creates a new TargetsHandler
+func NewTargetsHandler(targetService *service.TargetService, validator *validator.Validator) *TargetsHandler {
+	return &TargetsHandler{
+		targetService: targetService,
+		validator:     validator,
+	}
+}
+
+// GetTargets handles GET /api/v1/targets
+func (h *TargetsHandler) GetTargets(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	page, err := parsePage(c, h.validator, pagination.DefaultLimit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	targets, err := h.targetService.GetByUserID(c.UserContext(), userID, page)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items := make([]dto.PriceTargetResponse, len(targets.Items))
+	for i := range targets.Items {
+		items[i] = toPriceTargetResponse(&targets.Items[i])
+	}
+
+	return c.JSON(dto.PriceTargetsResponse{
+		Items:      items,
+		Total:      targets.Total,
+		Limit:      page.Limit,
+		NextCursor: targets.NextCursor,
+	})
+}
+
+// CreateTarget handles POST /api/v1/targets
+func (h *TargetsHandler) CreateTarget(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var req dto.CreatePriceTargetRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	target, err := h.targetService.Create(c.UserContext(), userID, req.CoinSymbol, req.TargetPrice, req.Note)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toPriceTargetResponse(target))
+}
+
+// UpdateTarget handles PATCH /api/v1/targets/:id
+func (h *TargetsHandler) UpdateTarget(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	targetID := path.ID
+
+	var req dto.UpdatePriceTargetRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	if req.TargetPrice == nil && req.Note == nil {
+		return sendError(c, errors.ErrBadRequest.WithMessage("Nothing to update"))
+	}
+
+	target, err := h.targetService.Update(c.UserContext(), userID, targetID, service.UpdateTargetParams{
+		TargetPrice: req.TargetPrice,
+		Note:        req.Note,
+	})
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(toPriceTargetResponse(target))
+}
+
+// DeleteTarget handles DELETE /api/v1/targets/:id
+func (h *TargetsHandler) DeleteTarget(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var path idParams
+	if err := parseParams(c, h.validator, &path); err != nil {
+		return sendError(c, err)
+	}
+	targetID := path.ID
+
+	if err := h.targetService.Delete(c.UserContext(), userID, targetID); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.SuccessResponse{
+		Message: "Price target deleted",
+	})
+}
+
+// toPriceTargetResponse converts service.PriceTarget to dto.PriceTargetResponse
+func toPriceTargetResponse(t *service.PriceTarget) dto.PriceTargetResponse {
+	return dto.PriceTargetResponse{
+		ID:               t.ID,
+		Coin:             toCoinResponse(&t.Coin),
+		TargetPrice:      t.TargetPrice,
+		PriceWhenCreated: t.PriceWhenCreated,
+		Note:             t.Note,
+		CurrentPrice:     t.Progress.CurrentPrice,
+		ProgressPct:      t.Progress.ProgressPct,
+		DistancePct:      t.Progress.DistancePct,
+		Reached:          t.Progress.Reached,
+		ProjectedAt:      t.Progress.ProjectedAt,
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// TelegramWebhookHandler receives every update Telegram delivers to the
+// bot and routes it: payments to the payment service, button presses to
+// BotCallbackHandler and commands to BotCommandHandler
+type TelegramWebhookHandler struct {
+	paymentService *service.PaymentService
+	callbacks      *BotCallbackHandler
+	commands       *BotCommandHandler
+	logger         *slog.Logger
+}
+
+// NewTelegramWebhookHandler creates a new TelegramWebhookHandler
+func NewTelegramWebhookHandler(
+	paymentService *service.PaymentService,
+	callbacks *BotCallbackHandler,
+	commands *BotCommandHandler,
+	logger *slog.Logger,
+) *TelegramWebhookHandler {
+	return &TelegramWebhookHandler{
+		paymentService: paymentService,
+		callbacks:      callbacks,
+		commands:       commands,
+		logger:         logger,
+	}
+}
+
+// HandleWebhook handles POST /api/v1/telegram/webhook
+// Routes pre_checkout_query, successful_payment, callback_query and
+// command messages. Requests are authenticated by the webhook secret
+// token. Telegram retries an update until it gets a 2xx, so only
+// transient failures return an error status
+func (h *TelegramWebhookHandler) HandleWebhook(c *fiber.Ctx) error {
+	var update telegram.Update
+	if err := json.Unmarshal(c.Body(), &update); err != nil {
+		// Updates carry user messages and payment details, so the body is
+		// not logged. The update ID is read when only a field did not parse
+		h.logger.Error("failed to parse webhook payload",
+			slog.String("error", err.Error()),
+			slog.Int("size", len(c.Body())),
+			slog.Int64("update_id", update.UpdateID),
+		)
+		// Return 400 for malformed payloads - no point retrying
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+
+	switch {
+	case update.PreCheckoutQuery != nil:
+		h.handlePreCheckoutQuery(c, update.PreCheckoutQuery)
+	case update.Message != nil && update.Message.SuccessfulPayment != nil:
+		return h.handleSuccessfulPayment(c, update.Message.SuccessfulPayment)
+	case update.CallbackQuery != nil:
+		// Inline button press, e.g. mute-all under an alert message
+		h.callbacks.HandleCallbackQuery(c.UserContext(), update.CallbackQuery)
+	case update.Message != nil:
+		h.commands.HandleMessage(c.UserContext(), update.Message)
+	default:
+		h.logger.Warn("received unknown webhook update",
+			slog.Int64("update_id", update.UpdateID),
+		)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// handlePreCheckoutQuery approves or rejects a payment about to be made
+func (h *TelegramWebhookHandler) handlePreCheckoutQuery(c *fiber.Ctx, query *telegram.PreCheckoutQuery) {
+	h.logger.Info("received pre-checkout query",
+		slog.String("query_id", query.ID),
+		slog.Int("amount", query.TotalAmount),
+	)
+
+	// The query is answered either way, so errors are not retried
+	if err := h.paymentService.HandlePreCheckoutQuery(c.UserContext(), query); err != nil {
+		h.logger.Error("failed to handle pre-checkout query",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// handleSuccessfulPayment completes a payment and activates its plan
+func (h *TelegramWebhookHandler) handleSuccessfulPayment(c *fiber.Ctx, payment *telegram.SuccessfulPayment) error {
+	h.logger.Info("received successful payment",
+		slog.String("charge_id", payment.TelegramPaymentChargeID),
+		slog.Int("amount", payment.TotalAmount),
+		slog.String("currency", payment.Currency),
+	)
+
+	if err := h.paymentService.HandleSuccessfulPayment(c.UserContext(), payment); err != nil {
+		h.logger.Error("failed to process successful payment",
+			slog.String("charge_id", payment.TelegramPaymentChargeID),
+			slog.String("error", err.Error()),
+		)
+
+		// For transient errors (database), return 500 to trigger Telegram retry
+		// For permanent errors (invalid payload, duplicate), return 200.
+		// Only recording the payment fails here; plan activation is
+		// retried by the payment service itself
+		if errors.Is(err, errors.ErrDatabase) {
+			h.logger.Warn("transient error processing payment, returning 500 for retry",
+				slog.String("charge_id", payment.TelegramPaymentChargeID),
+			)
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
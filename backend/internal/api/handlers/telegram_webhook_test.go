@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramWebhook_Dispatch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewTelegramWebhookHandler(nil, nil, NewBotCommandHandler(nil, nil, "", logger), logger)
+
+	app := fiber.New()
+	app.Post("/telegram/webhook", h.HandleWebhook)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"malformed payload", `{"update_id":`, fiber.StatusBadRequest},
+		{"unknown update", `{"update_id":1,"edited_message":{"message_id":2}}`, fiber.StatusOK},
+		{"group message", `{"update_id":3,"message":{"message_id":4,"from":{"id":5},"chat":{"id":-6,"type":"group"},"text":"/start"}}`, fiber.StatusOK},
+		{"plain text", `{"update_id":7,"message":{"message_id":8,"from":{"id":5},"chat":{"id":5,"type":"private"},"text":"hi"}}`, fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/telegram/webhook", strings.NewReader(tt.body))
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestTelegramWebhook_ParseErrorLog(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	h := NewTelegramWebhookHandler(nil, nil, nil, logger)
+
+	app := fiber.New()
+	app.Post("/telegram/webhook", h.HandleWebhook)
+
+	body := `{"update_id":9,"message":{"message_id":"secret","text":"my card is 4242"}}`
+	req := httptest.NewRequest("POST", "/telegram/webhook", strings.NewReader(body))
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	assert.Contains(t, logs.String(), "update_id=9")
+	assert.Contains(t, logs.String(), fmt.Sprintf("size=%d", len(body)))
+	assert.NotContains(t, logs.String(), "4242")
+}
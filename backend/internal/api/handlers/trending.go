@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/api/dto"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/validator"
+)
+
+// TrendingHandler handles the trending coins endpoint
+type TrendingHandler struct {
+	trendingService *service.TrendingService
+	validator       *validator.Validator
+}
+
+// NewTrendingHandler creates a new TrendingHandler
+func NewTrendingHandler(trendingService *service.TrendingService, v *validator.Validator) *TrendingHandler {
+	return &TrendingHandler{
+		trendingService: trendingService,
+		validator:       v,
+	}
+}
+
+// GetTrending handles GET /api/v1/coins/trending
+func (h *TrendingHandler) GetTrending(c *fiber.Ctx) error {
+	query := dto.TrendingCoinsQuery{Limit: 20}
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	query.Limit = min(query.Limit, 50)
+
+	coins, err := h.trendingService.GetTrending(c.UserContext(), query.Limit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	response := make([]dto.TrendingCoinResponse, len(coins))
+	var computedAt *time.Time
+	for i, coin := range coins {
+		response[i] = dto.TrendingCoinResponse{
+			CoinResponse:  *toCoinResponse(&coin.Coin),
+			Watchers:      coin.Watchers,
+			AlertUsers:    coin.AlertUsers,
+			WatcherGrowth: coin.WatcherGrowth,
+			Triggers24h:   coin.Triggers24h,
+			Score:         coin.Score,
+		}
+		computedAt = &coin.ComputedAt
+	}
+
+	return c.JSON(fiber.Map{
+		"coins":       response,
+		"computed_at": computedAt,
+	})
+}
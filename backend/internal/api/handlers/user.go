@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/dto"
 	"github.com/weqory/backend/internal/api/middleware"
@@ -16,6 +18,7 @@ type UserHandler struct {
 	alertService     *service.AlertService
 	historyService   *service.HistoryService
 	validator        *validator.Validator
+	rateLimits       *middleware.RateLimitRegistry
 }
 
 // NewUserHandler creates a new UserHandler
@@ -25,6 +28,7 @@ func NewUserHandler(
 	alertService *service.AlertService,
 	historyService *service.HistoryService,
 	validator *validator.Validator,
+	rateLimits *middleware.RateLimitRegistry,
 ) *UserHandler {
 	return &UserHandler{
 		userService:      userService,
@@ -32,6 +36,7 @@ func NewUserHandler(
 		alertService:     alertService,
 		historyService:   historyService,
 		validator:        validator,
+		rateLimits:       rateLimits,
 	}
 }
 
@@ -42,15 +47,41 @@ func (h *UserHandler) GetMe(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	user, err := h.userService.GetWithLimits(c.Context(), userID)
+	user, err := h.userService.GetProfile(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
 
-	return c.JSON(toUserResponse(user))
+	resp := toUserResponse(user)
+	resp.RateLimits = h.rateLimitsFor(c)
+	setETag(c, user.Version)
+	return c.JSON(resp)
+}
+
+// rateLimitsFor lists the configured rate limits, with the remaining
+// requests of those that were checked for this request
+func (h *UserHandler) rateLimitsFor(c *fiber.Ctx) []dto.RateLimit {
+	policies := h.rateLimits.Policies()
+	limits := make([]dto.RateLimit, 0, len(policies))
+	for _, p := range policies {
+		limit := dto.RateLimit{
+			Name:          p.Name,
+			Limit:         p.MaxRequests,
+			WindowSeconds: p.WindowSeconds,
+		}
+		if status := middleware.GetRateLimitStatus(c, p.Name); status != nil {
+			resetAt := status.ResetAt / 1000
+			limit.Remaining = &status.Remaining
+			limit.ResetAt = &resetAt
+		}
+		limits = append(limits, limit)
+	}
+	return limits
 }
 
 // UpdateSettings handles PATCH /api/v1/users/me/settings
+// Honours If-Match with the version from GET /users/me and answers 409 when
+// the settings changed meanwhile, as does UpdateLocale
 func (h *UserHandler) UpdateSettings(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 	if userID == 0 {
@@ -58,18 +89,100 @@ func (h *UserHandler) UpdateSettings(c *fiber.Ctx) error {
 	}
 
 	var req dto.UpdateSettingsRequest
-	if err := c.BodyParser(&req); err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid request body"))
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	user, err := h.userService.UpdateSettings(c.UserContext(), userID, service.UpdateSettingsParams{
+		NotificationsEnabled: req.NotificationsEnabled,
+		VibrationEnabled:     req.VibrationEnabled,
+		Timezone:             req.Timezone,
+		MessageFormat:        req.MessageFormat,
+		IncludeChart:         req.IncludeChart,
+		SilentAtNight:        req.SilentAtNight,
+		AllowStablecoins:     req.AllowStablecoins,
+		Version:              version,
+	})
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	setETag(c, user.Version)
+	return c.JSON(toSimpleUserResponse(user))
+}
+
+// UpdateLocale handles PATCH /api/v1/users/me/locale
+// Sets the language and number format of the user's messages, overriding
+// the language reported by Telegram
+func (h *UserHandler) UpdateLocale(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var req dto.UpdateLocaleRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		return sendError(c, err)
 	}
 
-	user, err := h.userService.UpdateSettings(c.Context(), userID, req.NotificationsEnabled, req.VibrationEnabled)
+	user, err := h.userService.UpdateLocale(c.UserContext(), userID, service.UpdateLocaleParams{
+		Language:     req.Language,
+		NumberFormat: req.NumberFormat,
+		Version:      version,
+	})
 	if err != nil {
 		return sendError(c, err)
 	}
 
+	setETag(c, user.Version)
 	return c.JSON(toSimpleUserResponse(user))
 }
 
+// MuteAlerts handles POST /api/v1/users/me/mute
+// Holds back all alert notifications for the requested hours
+func (h *UserHandler) MuteAlerts(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var req dto.MuteAlertsRequest
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
+	}
+
+	until, err := h.userService.MuteAlerts(c.UserContext(), userID, time.Duration(req.Hours)*time.Hour)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.MuteAlertsResponse{AlertsMutedUntil: &until})
+}
+
+// UnmuteAlerts handles DELETE /api/v1/users/me/mute
+func (h *UserHandler) UnmuteAlerts(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	if err := h.userService.UnmuteAlerts(c.UserContext(), userID); err != nil {
+		return sendError(c, err)
+	}
+
+	return c.JSON(dto.MuteAlertsResponse{})
+}
+
 // DeleteWatchlist handles DELETE /api/v1/users/me/watchlist
 func (h *UserHandler) DeleteWatchlist(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -77,7 +190,7 @@ func (h *UserHandler) DeleteWatchlist(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	deleted, err := h.watchlistService.DeleteAllByUser(c.Context(), userID)
+	deleted, err := h.watchlistService.DeleteAllByUser(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -95,7 +208,7 @@ func (h *UserHandler) DeleteAlerts(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	deleted, err := h.alertService.DeleteAllByUser(c.Context(), userID)
+	deleted, err := h.alertService.DeleteAllByUser(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -113,7 +226,7 @@ func (h *UserHandler) DeleteHistory(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	deleted, err := h.historyService.DeleteAllByUser(c.Context(), userID)
+	deleted, err := h.historyService.DeleteAllByUser(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -139,6 +252,17 @@ func toSimpleUserResponse(u *service.User) *dto.UserResponse {
 		Plan:                 u.Plan,
 		NotificationsUsed:    u.NotificationsUsed,
 		NotificationsEnabled: u.NotificationsEnabled,
+		UnreachableReason:    u.UnreachableReason,
 		VibrationEnabled:     u.VibrationEnabled,
+		Timezone:             u.Timezone,
+		AlertsMutedUntil:     activeMute(u),
+		Role:                 string(u.Role),
+		MessageFormat:        u.MessageFormat,
+		IncludeChart:         u.IncludeChart,
+		SilentAtNight:        u.SilentAtNight,
+		LanguageManual:       u.LanguageManual,
+		NumberFormat:         u.NumberFormat,
+		AllowStablecoins:     u.AllowStablecoins,
+		Version:              u.Version,
 	}
 }
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,6 +12,8 @@ import (
 	"github.com/weqory/backend/internal/api/middleware"
 	"github.com/weqory/backend/internal/service"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/sheet"
 	"github.com/weqory/backend/pkg/validator"
 )
 
@@ -16,6 +22,7 @@ type WatchlistHandler struct {
 	watchlistService *service.WatchlistService
 	userService      *service.UserService
 	validator        *validator.Validator
+	logger           *slog.Logger
 }
 
 // NewWatchlistHandler creates a new WatchlistHandler
@@ -23,14 +30,57 @@ func NewWatchlistHandler(
 	watchlistService *service.WatchlistService,
 	userService *service.UserService,
 	validator *validator.Validator,
+	logger *slog.Logger,
 ) *WatchlistHandler {
 	return &WatchlistHandler{
 		watchlistService: watchlistService,
 		userService:      userService,
 		validator:        validator,
+		logger:           logger,
 	}
 }
 
+// exportTimeout bounds writing an export once the response has started
+const exportTimeout = time.Minute
+
+// ExportWatchlist handles GET /api/v1/watchlist/export?format=csv|xlsx
+// Streams the watchlist with current prices and active alerts as a file.
+// The body is written after the handler returns, so errors past this
+// point can only be logged
+func (h *WatchlistHandler) ExportWatchlist(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		return sendError(c, errors.ErrUnauthorized)
+	}
+
+	var query dto.WatchlistExportQuery
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
+	}
+	format := query.Format
+	if format == "" {
+		format = sheet.FormatCSV
+	}
+
+	c.Set(fiber.HeaderContentType, sheet.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="watchlist-%s.%s"`,
+		time.Now().UTC().Format("2006-01-02"), format))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		if err := h.watchlistService.Export(ctx, userID, format, w); err != nil {
+			h.logger.Error("failed to export watchlist",
+				slog.Int64("user_id", userID),
+				slog.String("format", format),
+				slog.String("error", err.Error()),
+			)
+		}
+	})
+	return nil
+}
+
 // GetWatchlist handles GET /api/v1/watchlist
 func (h *WatchlistHandler) GetWatchlist(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -38,20 +88,28 @@ func (h *WatchlistHandler) GetWatchlist(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrUnauthorized)
 	}
 
-	items, err := h.watchlistService.GetByUserID(c.Context(), userID)
+	category := c.Query("category", "")
+
+	// Every plan's watchlist fits in one page by default
+	page, err := parsePage(c, h.validator, pagination.MaxLimit)
+	if err != nil {
+		return sendError(c, err)
+	}
+
+	items, err := h.watchlistService.GetByUserID(c.UserContext(), userID, category, page)
 	if err != nil {
 		return sendError(c, err)
 	}
 
 	// Get user limits
-	user, err := h.userService.GetWithLimits(c.Context(), userID)
+	user, err := h.userService.GetWithLimits(c.UserContext(), userID)
 	if err != nil {
 		return sendError(c, err)
 	}
 
 	// Convert to response
-	responseItems := make([]dto.WatchlistItemResponse, len(items))
-	for i, item := range items {
+	responseItems := make([]dto.WatchlistItemResponse, len(items.Items))
+	for i, item := range items.Items {
 		createdAt, _ := time.Parse(time.RFC3339, item.CreatedAt)
 		responseItems[i] = dto.WatchlistItemResponse{
 			ID:          item.ID,
@@ -62,9 +120,10 @@ func (h *WatchlistHandler) GetWatchlist(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(dto.WatchlistResponse{
-		Items: responseItems,
-		Total: len(items),
-		Limit: user.MaxCoins,
+		Items:      responseItems,
+		Total:      int(items.Total),
+		Limit:      user.MaxCoins,
+		NextCursor: items.NextCursor,
 	})
 }
 
@@ -76,15 +135,11 @@ func (h *WatchlistHandler) AddToWatchlist(c *fiber.Ctx) error {
 	}
 
 	var req dto.AddToWatchlistRequest
-	if err := c.BodyParser(&req); err != nil {
-		return sendError(c, errors.ErrBadRequest.WithMessage("Invalid request body"))
-	}
-
-	if errs := h.validator.Validate(req); errs != nil {
-		return sendValidationError(c, errs)
+	if err := parseBody(c, h.validator, &req); err != nil {
+		return sendError(c, err)
 	}
 
-	item, err := h.watchlistService.AddCoin(c.Context(), userID, req.CoinSymbol)
+	item, err := h.watchlistService.AddCoin(c.UserContext(), userID, req.CoinSymbol)
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -110,7 +165,7 @@ func (h *WatchlistHandler) RemoveFromWatchlist(c *fiber.Ctx) error {
 		return sendError(c, errors.ErrBadRequest.WithMessage("Missing coin symbol"))
 	}
 
-	deletedAlerts, err := h.watchlistService.RemoveCoin(c.Context(), userID, symbol)
+	deletedAlerts, err := h.watchlistService.RemoveCoin(c.UserContext(), userID, symbol)
 	if err != nil {
 		return sendError(c, err)
 	}
@@ -122,14 +177,13 @@ func (h *WatchlistHandler) RemoveFromWatchlist(c *fiber.Ctx) error {
 
 // GetAvailableCoins handles GET /api/v1/watchlist/available-coins
 func (h *WatchlistHandler) GetAvailableCoins(c *fiber.Ctx) error {
-	search := c.Query("search", "")
-	limit := c.QueryInt("limit", 50)
-
-	if limit > 100 {
-		limit = 100
+	query := dto.AvailableCoinsQuery{Limit: 50}
+	if err := parseQuery(c, h.validator, &query); err != nil {
+		return sendError(c, err)
 	}
+	query.Limit = min(query.Limit, 100)
 
-	coins, err := h.watchlistService.GetAvailableCoins(c.Context(), search, limit)
+	coins, err := h.watchlistService.GetAvailableCoins(c.UserContext(), query.Search, query.Category, query.Limit, query.IncludeStablecoins)
 	if err != nil {
 		return sendError(c, err)
 	}
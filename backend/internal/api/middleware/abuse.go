@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/logger"
+)
+
+// AbuseGuard counts a user's changes and throttles the writes of abusive
+// users
+type AbuseGuard interface {
+	ThrottledUntil(ctx context.Context, userID int64) (time.Time, error)
+	RecordChurn(ctx context.Context, userID int64, kind string) error
+}
+
+// WriteThrottle rejects writes of users throttled for abuse with 429 until
+// the throttle ends; reads are always allowed. Must run after the user ID
+// is set. Fails open when the guard is unavailable
+func WriteThrottle(guard AbuseGuard, log *logger.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := GetUserID(c)
+		if guard == nil || userID == 0 || c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			return c.Next()
+		}
+
+		until, err := guard.ThrottledUntil(c.UserContext(), userID)
+		if err != nil {
+			if log != nil {
+				log.Warn("failed to check write throttle",
+					"error", err.Error(),
+					"user_id", userID,
+				)
+			}
+			return c.Next()
+		}
+		if until.IsZero() {
+			return c.Next()
+		}
+
+		retryAfter := retryAfterSeconds(until.UnixMilli(), time.Now())
+		c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error":       errors.ErrTooManyRequests.WithMessage("too many changes, try again later").Error(),
+			"retry_after": retryAfter,
+		})
+	}
+}
+
+// TrackChurn counts successful requests to the route as changes of kind
+// towards abuse detection
+func TrackChurn(guard AbuseGuard, kind string, log *logger.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		userID := GetUserID(c)
+		status := c.Response().StatusCode()
+		if guard == nil || userID == 0 || status < 200 || status >= 300 {
+			return nil
+		}
+
+		if err := guard.RecordChurn(c.UserContext(), userID, kind); err != nil && log != nil {
+			log.Warn("failed to record churn",
+				"error", err.Error(),
+				"user_id", userID,
+				"kind", kind,
+			)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAbuseGuard struct {
+	until time.Time
+	churn int
+}
+
+func (f *fakeAbuseGuard) ThrottledUntil(context.Context, int64) (time.Time, error) {
+	return f.until, nil
+}
+
+func (f *fakeAbuseGuard) RecordChurn(context.Context, int64, string) error {
+	f.churn++
+	return nil
+}
+
+func TestWriteThrottle(t *testing.T) {
+	guard := &fakeAbuseGuard{}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		SetUserID(c, 7)
+		return c.Next()
+	}, WriteThrottle(guard, nil))
+	app.Post("/alerts", TrackChurn(guard, "alerts", nil), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+	app.Get("/alerts", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/alerts", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 1, guard.churn)
+
+	guard.until = time.Now().Add(90 * time.Second)
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/alerts", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "90", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, 1, guard.churn, "rejected writes are not counted")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/alerts", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
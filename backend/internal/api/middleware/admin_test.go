@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/rbac"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+func TestAdminAuth_Roles(t *testing.T) {
+	app := fiber.New()
+	admin := app.Group("/admin", AdminAuth(AdminAuthConfig{
+		APIKey: "admin-key",
+		Staff: func(c *fiber.Ctx) (rbac.Role, error) {
+			switch c.Get("X-Test-Role") {
+			case "":
+				return "", errors.ErrUnauthorized
+			default:
+				SetUserID(c, 42)
+				return rbac.Role(c.Get("X-Test-Role")), nil
+			}
+		},
+	}))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	admin.Get("/audit-log", RequirePermission(rbac.PermViewAdmin), ok)
+	admin.Put("/users/1/role", RequirePermission(rbac.PermManageRoles), ok)
+	admin.Get("/whoami", func(c *fiber.Ctx) error { return c.SendString(GetOperator(c)) })
+
+	request := func(method, path string, header, value string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, request("GET", "/admin/audit-log", AdminKeyHeader, "admin-key"))
+	assert.Equal(t, fiber.StatusOK, request("PUT", "/admin/users/1/role", AdminKeyHeader, "admin-key"))
+	assert.Equal(t, fiber.StatusUnauthorized, request("GET", "/admin/audit-log", AdminKeyHeader, "wrong"))
+
+	assert.Equal(t, fiber.StatusOK, request("GET", "/admin/audit-log", "X-Test-Role", "support"))
+	assert.Equal(t, fiber.StatusForbidden, request("PUT", "/admin/users/1/role", "X-Test-Role", "support"))
+	assert.Equal(t, fiber.StatusOK, request("PUT", "/admin/users/1/role", "X-Test-Role", "admin"))
+	assert.Equal(t, fiber.StatusForbidden, request("GET", "/admin/audit-log", "X-Test-Role", "user"))
+	assert.Equal(t, fiber.StatusUnauthorized, request("GET", "/admin/audit-log", "", ""))
+
+	// The operator comes from the credentials, not the request
+	operator := func(header, value string) string {
+		req := httptest.NewRequest("GET", "/admin/whoami", nil)
+		req.Header.Set(header, value)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, APIKeyOperator, operator(AdminKeyHeader, "admin-key"))
+	assert.Equal(t, "user:42", operator("X-Test-Role", "support"))
+}
@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"context"
+	"crypto/ed25519"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/pkg/crypto"
@@ -12,10 +15,30 @@ import (
 // AuthConfig holds authentication middleware configuration
 type AuthConfig struct {
 	BotToken      string
+	BotTokenFunc  func() string // overrides BotToken so the token can rotate at runtime
 	Logger        *logger.Logger
 	SkipPaths     []string
 	DevMode       bool
 	DevTelegramID int64
+
+	// MaxAge is how old init data may be; crypto.InitDataExpiry when zero
+	MaxAge time.Duration
+	// ThirdPartyBotID, when set, validates init data by the Ed25519
+	// signature Telegram issues for this bot instead of by the bot token
+	ThirdPartyBotID int64
+	// ThirdPartyPublicKey verifies those signatures; Telegram's production
+	// key when nil
+	ThirdPartyPublicKey ed25519.PublicKey
+	// ReplayGuard, when set, rejects init data presented by a client other
+	// than the one that first used it
+	ReplayGuard ReplayGuard
+}
+
+// ReplayGuard detects replayed init data
+type ReplayGuard interface {
+	// Replayed reports whether hash was first presented by a client with a
+	// different fingerprint
+	Replayed(ctx context.Context, hash, fingerprint string) (bool, error)
 }
 
 // Auth creates authentication middleware that validates Telegram InitData
@@ -29,6 +52,11 @@ func Auth(cfg AuthConfig) fiber.Handler {
 			}
 		}
 
+		// Already authenticated by the Impersonation middleware
+		if GetImpersonationID(c) != 0 {
+			return c.Next()
+		}
+
 		// Get InitData from header
 		initData := c.Get("X-Telegram-Init-Data")
 
@@ -51,7 +79,17 @@ func Auth(cfg AuthConfig) fiber.Handler {
 		}
 
 		// Validate InitData
-		data, err := crypto.ValidateInitData(initData, cfg.BotToken)
+		botToken := cfg.BotToken
+		if cfg.BotTokenFunc != nil {
+			botToken = cfg.BotTokenFunc()
+		}
+		validator := crypto.InitDataValidator{
+			BotToken:  botToken,
+			BotID:     cfg.ThirdPartyBotID,
+			PublicKey: cfg.ThirdPartyPublicKey,
+			MaxAge:    cfg.MaxAge,
+		}
+		data, err := validator.Validate(initData)
 		if err != nil {
 			if cfg.Logger != nil {
 				cfg.Logger.Warn("invalid init data",
@@ -66,6 +104,10 @@ func Auth(cfg AuthConfig) fiber.Handler {
 			return sendError(c, errors.ErrInvalidInitData.WithMessage("missing user data"))
 		}
 
+		if err := CheckReplay(c, cfg.ReplayGuard, data, cfg.ThirdPartyBotID != 0, cfg.Logger); err != nil {
+			return sendError(c, err)
+		}
+
 		// Store user info in context
 		c.Locals("telegram_id", data.User.ID)
 		c.Locals("telegram_user", data.User)
@@ -75,6 +117,41 @@ func Auth(cfg AuthConfig) fiber.Handler {
 	}
 }
 
+// CheckReplay returns an error when init data presented with the request
+// was first used by another client. Nothing is checked without a guard,
+// and the request is let through when the guard fails. thirdParty tells
+// whether the init data was validated by its signature instead of its hash
+func CheckReplay(c *fiber.Ctx, guard ReplayGuard, data *crypto.InitData, thirdParty bool, log *logger.Logger) error {
+	if guard == nil {
+		return nil
+	}
+
+	// The hash is not verified under third-party validation, so the
+	// signature identifies the init data there
+	key := data.Hash
+	if thirdParty {
+		key = data.Signature
+	}
+	replayed, err := guard.Replayed(c.UserContext(), key, c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		// On Redis error, allow request but log warning
+		if log != nil {
+			log.Warn("init data replay check failed", "error", err.Error())
+		}
+		return nil
+	}
+	if replayed {
+		if log != nil {
+			log.Warn("replayed init data",
+				"telegram_id", data.User.ID,
+				"path", c.Path(),
+			)
+		}
+		return errors.ErrInvalidInitData.WithMessage("init data was already used by another client")
+	}
+	return nil
+}
+
 // GetTelegramID retrieves Telegram ID from context
 func GetTelegramID(c *fiber.Ctx) int64 {
 	if id, ok := c.Locals("telegram_id").(int64); ok {
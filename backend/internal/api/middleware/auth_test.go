@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/crypto"
+	pkgredis "github.com/weqory/backend/pkg/redis"
+)
+
+func TestAuth_ReplayGuard(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	app := fiber.New()
+	app.Use(Auth(AuthConfig{
+		BotToken:    "bot-token",
+		ReplayGuard: pkgredis.NewReplayGuard(client, time.Hour),
+	}))
+	app.Get("/watchlist", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	initData := crypto.GenerateTestInitData(&crypto.TelegramUser{ID: 42, FirstName: "Test"}, "bot-token")
+	request := func(userAgent string) int {
+		req := httptest.NewRequest("GET", "/watchlist", nil)
+		req.Header.Set("X-Telegram-Init-Data", initData)
+		req.Header.Set(fiber.HeaderUserAgent, userAgent)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, request("TelegramWebView/1"))
+	assert.Equal(t, fiber.StatusOK, request("TelegramWebView/1"), "same client reuses its init data")
+	assert.Equal(t, fiber.StatusUnauthorized, request("curl/8.0"))
+
+	// Once the claim expires the init data is stale anyway
+	mr.FastForward(2 * time.Hour)
+	assert.Equal(t, fiber.StatusOK, request("curl/8.0"))
+}
+
+func TestCheckReplay(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	guard := pkgredis.NewReplayGuard(client, time.Hour)
+
+	// Init data passed outside the Auth middleware, like on WebSocket upgrades
+	app := fiber.New()
+	app.Get("/ws", func(c *fiber.Ctx) error {
+		data, err := crypto.InitDataValidator{BotToken: "bot-token"}.Validate(c.Query("init_data"))
+		if err != nil {
+			return sendError(c, err)
+		}
+		if err := CheckReplay(c, guard, data, false, nil); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	initData := crypto.GenerateTestInitData(&crypto.TelegramUser{ID: 42, FirstName: "Test"}, "bot-token")
+	request := func(userAgent string) int {
+		req := httptest.NewRequest("GET", "/ws?init_data="+url.QueryEscape(initData), nil)
+		req.Header.Set(fiber.HeaderUserAgent, userAgent)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, request("TelegramWebView/1"))
+	assert.Equal(t, fiber.StatusUnauthorized, request("curl/8.0"))
+}
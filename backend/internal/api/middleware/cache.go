@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/pkg/logger"
+	pkgredis "github.com/weqory/backend/pkg/redis"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// CacheStatusHeader reports whether a response came from the cache:
+	// HIT, MISS or BYPASS
+	CacheStatusHeader = "X-Cache"
+
+	// CacheBypassHeader makes a request skip the cache, for debugging; see
+	// CacheConfig for who may use it
+	CacheBypassHeader = "X-Cache-Bypass"
+)
+
+// CacheConfig holds response caching configuration
+type CacheConfig struct {
+	Cache     *pkgredis.ResponseCache
+	TTL       time.Duration
+	KeyPrefix string
+	Log       *logger.Logger
+	// AdminAPIKey lets requests presenting it in X-Admin-Key skip the cache
+	// with X-Cache-Bypass; empty lets no one
+	AdminAPIKey string
+	// AllowBypass honors X-Cache-Bypass from any caller, for development
+	AllowBypass bool
+}
+
+// Cache serves GET responses from Redis for TTL. Only 200 responses are
+// stored and the key covers the path and query, so it suits endpoints
+// whose response is the same for every user. Concurrent misses of the same
+// URL share a single handler run (singleflight), so an expiring entry does
+// not send every waiting request to the database. Admin requests carrying
+// X-Cache-Bypass skip the cache; Cache-Control is ignored, as any client
+// could otherwise send every request to the handler
+func Cache(cfg CacheConfig) fiber.Handler {
+	var group singleflight.Group
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet || cfg.Cache == nil {
+			return c.Next()
+		}
+
+		if cfg.bypass(c) {
+			c.Set(CacheStatusHeader, "BYPASS")
+			return c.Next()
+		}
+
+		key := cacheKey(cfg.KeyPrefix, c)
+		ctx := c.UserContext()
+
+		cached, err := cfg.Cache.Get(ctx, key)
+		if err != nil {
+			cfg.warn("failed to read response cache", key, err)
+		}
+		if cached != nil {
+			return sendCached(c, cached, "HIT")
+		}
+
+		leader := false
+		shared, err, _ := group.Do(key, func() (any, error) {
+			leader = true
+			if err := c.Next(); err != nil {
+				return nil, err
+			}
+
+			resp := &pkgredis.CachedResponse{
+				Status:      c.Response().StatusCode(),
+				ContentType: string(c.Response().Header.ContentType()),
+				Body:        bytes.Clone(c.Response().Body()),
+				StoredAt:    time.Now(),
+			}
+			if resp.Status == fiber.StatusOK {
+				// Stored even if this request was cancelled meanwhile
+				if err := cfg.Cache.Set(context.WithoutCancel(ctx), key, resp, cfg.TTL); err != nil {
+					cfg.warn("failed to write response cache", key, err)
+				}
+			}
+			return resp, nil
+		})
+
+		if leader {
+			c.Set(CacheStatusHeader, "MISS")
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		return sendCached(c, shared.(*pkgredis.CachedResponse), "MISS")
+	}
+}
+
+// warn logs a cache failure; the request is served uncached
+func (cfg CacheConfig) warn(msg, key string, err error) {
+	if cfg.Log != nil {
+		cfg.Log.Warn(msg,
+			"key", key,
+			"error", err.Error(),
+		)
+	}
+}
+
+// bypass reports whether the client asked to skip the cache and may do so
+func (cfg CacheConfig) bypass(c *fiber.Ctx) bool {
+	if c.Get(CacheBypassHeader) == "" {
+		return false
+	}
+	if cfg.AllowBypass {
+		return true
+	}
+	key := c.Get(AdminKeyHeader)
+	return cfg.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(cfg.AdminAPIKey)) == 1
+}
+
+// cacheKey identifies a request by path and query, with query parameters
+// sorted so their order does not matter
+func cacheKey(prefix string, c *fiber.Ctx) string {
+	args := c.Request().URI().QueryArgs()
+	params := make([]string, 0, args.Len())
+	args.VisitAll(func(key, value []byte) {
+		params = append(params, string(key)+"="+string(value))
+	})
+	sort.Strings(params)
+
+	return prefix + ":" + c.Path() + "?" + strings.Join(params, "&")
+}
+
+// sendCached writes a stored response
+func sendCached(c *fiber.Ctx, resp *pkgredis.CachedResponse, status string) error {
+	c.Set(CacheStatusHeader, status)
+	c.Set(fiber.HeaderAge, strconv.Itoa(int(time.Since(resp.StoredAt).Seconds())))
+	c.Set(fiber.HeaderContentType, resp.ContentType)
+	return c.Status(resp.Status).Send(resp.Body)
+}
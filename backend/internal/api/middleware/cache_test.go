@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pkgredis "github.com/weqory/backend/pkg/redis"
+)
+
+// newCacheApp serves GET /coins through the cache, counting handler runs
+func newCacheApp(t *testing.T, delay time.Duration) (*fiber.App, *atomic.Int32) {
+	t.Helper()
+	return newCacheAppWith(t, delay, CacheConfig{})
+}
+
+// newCacheAppWith is newCacheApp with the bypass settings of cfg
+func newCacheAppWith(t *testing.T, delay time.Duration, cfg CacheConfig) (*fiber.App, *atomic.Int32) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	calls := &atomic.Int32{}
+	app := fiber.New()
+	cfg.Cache = pkgredis.NewResponseCache(client)
+	cfg.TTL = time.Minute
+	cfg.KeyPrefix = "coins"
+	app.Get("/coins", Cache(cfg), func(c *fiber.Ctx) error {
+		calls.Add(1)
+		time.Sleep(delay)
+		if c.Query("fail") != "" {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "boom"})
+		}
+		return c.JSON(fiber.Map{"search": c.Query("search")})
+	})
+
+	return app, calls
+}
+
+func getCached(t *testing.T, app *fiber.App, target string, header ...string) (string, string) {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.Header.Get(CacheStatusHeader), string(body)
+}
+
+func TestCache_HitAndKeyIgnoresQueryOrder(t *testing.T) {
+	app, calls := newCacheApp(t, 0)
+
+	status, body := getCached(t, app, "/coins?search=btc&limit=5")
+	assert.Equal(t, "MISS", status)
+	assert.JSONEq(t, `{"search":"btc"}`, body)
+
+	status, body = getCached(t, app, "/coins?limit=5&search=btc")
+	assert.Equal(t, "HIT", status)
+	assert.JSONEq(t, `{"search":"btc"}`, body)
+
+	status, _ = getCached(t, app, "/coins?search=eth&limit=5")
+	assert.Equal(t, "MISS", status)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCache_BypassAndErrorsAreNotStored(t *testing.T) {
+	app, calls := newCacheAppWith(t, 0, CacheConfig{AdminAPIKey: "admin-key"})
+
+	getCached(t, app, "/coins")
+	status, _ := getCached(t, app, "/coins", CacheBypassHeader, "1", AdminKeyHeader, "admin-key")
+	assert.Equal(t, "BYPASS", status)
+	assert.Equal(t, int32(2), calls.Load())
+
+	getCached(t, app, "/coins?fail=1")
+	status, _ = getCached(t, app, "/coins?fail=1")
+	assert.Equal(t, "MISS", status)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestCache_BypassOnlyForAdmins(t *testing.T) {
+	app, calls := newCacheAppWith(t, 0, CacheConfig{AdminAPIKey: "admin-key"})
+	getCached(t, app, "/coins")
+
+	// Anyone could otherwise send every request to the database
+	status, _ := getCached(t, app, "/coins", CacheBypassHeader, "1")
+	assert.Equal(t, "HIT", status)
+	status, _ = getCached(t, app, "/coins", CacheBypassHeader, "1", AdminKeyHeader, "guess")
+	assert.Equal(t, "HIT", status)
+	status, _ = getCached(t, app, "/coins", fiber.HeaderCacheControl, "no-cache")
+	assert.Equal(t, "HIT", status)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Development lets anyone skip it
+	app, calls = newCacheAppWith(t, 0, CacheConfig{AllowBypass: true})
+	getCached(t, app, "/coins")
+	status, _ = getCached(t, app, "/coins", CacheBypassHeader, "1")
+	assert.Equal(t, "BYPASS", status)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCache_ConcurrentMissesRunHandlerOnce(t *testing.T) {
+	app, calls := newCacheApp(t, 100*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, body := getCached(t, app, "/coins?search=sol")
+			assert.JSONEq(t, `{"search":"sol"}`, body)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
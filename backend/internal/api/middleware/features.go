@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/pkg/logger"
+)
+
+// FeatureFlagsKey is the context key for the user's evaluated feature flags
+const FeatureFlagsKey = "feature_flags"
+
+// FlagEvaluator evaluates all feature flags for a user
+type FlagEvaluator interface {
+	EvaluateForUser(ctx context.Context, userID int64) (map[string]bool, error)
+}
+
+// requestFlags evaluates the user's flags the first time a handler asks
+// for them, at most once per request
+type requestFlags struct {
+	evaluator FlagEvaluator
+	userID    int64
+	log       *logger.Logger
+
+	once  sync.Once
+	flags map[string]bool
+}
+
+// get returns the evaluated flags; evaluation errors leave all flags off
+func (f *requestFlags) get(ctx context.Context) map[string]bool {
+	f.once.Do(func() {
+		flags, err := f.evaluator.EvaluateForUser(ctx, f.userID)
+		if err != nil {
+			if f.log != nil {
+				f.log.Warn("failed to evaluate feature flags",
+					"error", err.Error(),
+					"user_id", f.userID,
+				)
+			}
+			flags = map[string]bool{}
+		}
+		f.flags = flags
+	})
+	return f.flags
+}
+
+// FeatureFlags makes the authenticated user's feature flags available to
+// handlers. Must run after the user ID is set. Flags are evaluated only for
+// requests whose handler reads them
+func FeatureFlags(evaluator FlagEvaluator, log *logger.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := GetUserID(c)
+		if userID == 0 {
+			return c.Next()
+		}
+
+		c.Locals(FeatureFlagsKey, &requestFlags{
+			evaluator: evaluator,
+			userID:    userID,
+			log:       log,
+		})
+		return c.Next()
+	}
+}
+
+// GetFeatureFlags retrieves the current user's feature flags, evaluating
+// them on first use
+func GetFeatureFlags(c *fiber.Ctx) map[string]bool {
+	if flags, ok := c.Locals(FeatureFlagsKey).(*requestFlags); ok {
+		return flags.get(c.UserContext())
+	}
+	return map[string]bool{}
+}
+
+// FeatureEnabled reports whether a feature flag is on for the current user
+func FeatureEnabled(c *fiber.Ctx, key string) bool {
+	return GetFeatureFlags(c)[key]
+}
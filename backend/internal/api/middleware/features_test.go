@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingEvaluator struct {
+	calls int
+	err   error
+}
+
+func (e *countingEvaluator) EvaluateForUser(ctx context.Context, userID int64) (map[string]bool, error) {
+	e.calls++
+	if e.err != nil {
+		return nil, e.err
+	}
+	return map[string]bool{"charts": true}, nil
+}
+
+func TestFeatureFlags_EvaluatedOnFirstUse(t *testing.T) {
+	evaluator := &countingEvaluator{}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(UserIDKey, int64(7))
+		return c.Next()
+	}, FeatureFlags(evaluator, nil))
+	app.Get("/plain", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/flagged", func(c *fiber.Ctx) error {
+		first := FeatureEnabled(c, "charts")
+		second := FeatureEnabled(c, "charts")
+		return c.SendString(strconv.FormatBool(first && second))
+	})
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	get("/plain")
+	assert.Zero(t, evaluator.calls, "not evaluated when no handler asks")
+
+	assert.Equal(t, "true", get("/flagged"))
+	assert.Equal(t, 1, evaluator.calls, "evaluated once per request")
+
+	// Evaluation errors leave every flag off
+	evaluator.err = errors.New("redis down")
+	assert.Equal(t, "false", get("/flagged"))
+}
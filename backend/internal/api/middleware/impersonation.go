@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/logger"
+)
+
+const (
+	// ImpersonationHeader carries a token issued to an admin acting as a
+	// user, in place of Telegram InitData
+	ImpersonationHeader = "X-Impersonation-Token"
+
+	// ImpersonationIDKey is the context key for the impersonation a
+	// request is made in
+	ImpersonationIDKey = "impersonation_id"
+)
+
+// Impersonator authorizes impersonation tokens and audits their use
+type Impersonator interface {
+	Authorize(ctx context.Context, token string) (impersonationID, telegramID int64, err error)
+	RecordRequest(ctx context.Context, impersonationID int64, method, path string, status int) error
+}
+
+// Impersonation authenticates requests carrying an impersonation token as
+// the impersonated user, so Auth lets them through. Impersonated requests
+// are read-only and each one is recorded, including rejected writes. A nil
+// impersonator rejects every token
+func Impersonation(impersonator Impersonator, log *logger.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get(ImpersonationHeader)
+		if token == "" {
+			return c.Next()
+		}
+		if impersonator == nil {
+			return sendError(c, errors.ErrForbidden.WithMessage("impersonation is disabled"))
+		}
+
+		impersonationID, telegramID, err := impersonator.Authorize(c.UserContext(), token)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		c.Locals(ImpersonationIDKey, impersonationID)
+		c.Locals("telegram_id", telegramID)
+
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			err = c.Next()
+		} else {
+			err = sendError(c, errors.ErrForbidden.WithMessage("impersonation is read-only"))
+		}
+
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		}
+
+		// Recorded even if the request timed out
+		ctx := context.WithoutCancel(c.UserContext())
+		if recordErr := impersonator.RecordRequest(ctx, impersonationID, c.Method(), c.Path(), status); recordErr != nil && log != nil {
+			log.Error("failed to record impersonated request",
+				"error", recordErr.Error(),
+				"impersonation_id", impersonationID,
+				"path", c.Path(),
+			)
+		}
+
+		return err
+	}
+}
+
+// GetImpersonationID returns the impersonation a request is made in, or 0
+// for requests of the user themselves
+func GetImpersonationID(c *fiber.Ctx) int64 {
+	if id, ok := c.Locals(ImpersonationIDKey).(int64); ok {
+		return id
+	}
+	return 0
+}
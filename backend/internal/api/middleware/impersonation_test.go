@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	status int
+}
+
+type fakeImpersonator struct {
+	recorded []recordedRequest
+}
+
+func (f *fakeImpersonator) Authorize(_ context.Context, token string) (int64, int64, error) {
+	if token != "valid" {
+		return 0, 0, errors.ErrInvalidToken
+	}
+	return 7, 100, nil
+}
+
+func (f *fakeImpersonator) RecordRequest(_ context.Context, _ int64, method, path string, status int) error {
+	// Fiber reuses the memory of method and path after the request
+	f.recorded = append(f.recorded, recordedRequest{strings.Clone(method), strings.Clone(path), status})
+	return nil
+}
+
+func TestImpersonation(t *testing.T) {
+	impersonator := &fakeImpersonator{}
+	app := fiber.New()
+	app.Use(Impersonation(impersonator, nil), Auth(AuthConfig{BotToken: "bot-token"}))
+	handler := func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"telegram_id":      GetTelegramID(c),
+			"impersonation_id": GetImpersonationID(c),
+		})
+	}
+	app.Get("/watchlist", handler)
+	app.Post("/watchlist", handler)
+
+	request := func(method, token string) int {
+		req := httptest.NewRequest(method, "/watchlist", nil)
+		if token != "" {
+			req.Header.Set(ImpersonationHeader, token)
+		}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, request("GET", "valid"))
+	assert.Equal(t, fiber.StatusForbidden, request("POST", "valid"))
+	assert.Equal(t, fiber.StatusUnauthorized, request("GET", "forged"))
+	assert.Equal(t, fiber.StatusUnauthorized, request("GET", ""), "falls back to InitData")
+
+	assert.Equal(t, []recordedRequest{
+		{"GET", "/watchlist", fiber.StatusOK},
+		{"POST", "/watchlist", fiber.StatusForbidden},
+	}, impersonator.recorded)
+}
+
+func TestImpersonation_Disabled(t *testing.T) {
+	app := fiber.New()
+	app.Use(Impersonation(nil, nil))
+	app.Get("/watchlist", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/watchlist", nil)
+	req.Header.Set(ImpersonationHeader, "valid")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"log/slog"
+	"math/rand"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,6 +19,9 @@ func RequestID() fiber.Handler {
 		}
 		c.Set("X-Request-ID", requestID)
 		c.Locals("request_id", requestID)
+		// Alert updates published while handling the request carry it
+		// from the user context, so the gateway's relay logs the same ID
+		c.SetUserContext(logger.WithRequestID(c.UserContext(), requestID))
 		return c.Next()
 	}
 }
@@ -32,9 +36,21 @@ func GetRequestID(c *fiber.Ctx) string {
 
 // LoggingConfig holds logging middleware configuration
 type LoggingConfig struct {
-	Logger     *logger.Logger
-	SkipPaths  []string
+	Logger        *logger.Logger
+	SkipPaths     []string
 	SlowThreshold time.Duration
+
+	// LogBodies adds request and response bodies to the log line, with
+	// RedactFields (DefaultRedactFields if empty) masked and each body cut
+	// to MaxBodySize bytes
+	LogBodies    bool
+	MaxBodySize  int
+	RedactFields []string
+
+	// SampleRates maps a route pattern (e.g. "/api/v1/market/overview") to
+	// the fraction of successful requests that are logged. Errors and slow
+	// requests are always logged
+	SampleRates map[string]float64
 }
 
 // Logging creates request logging middleware
@@ -42,6 +58,13 @@ func Logging(cfg LoggingConfig) fiber.Handler {
 	if cfg.SlowThreshold == 0 {
 		cfg.SlowThreshold = 500 * time.Millisecond
 	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 4096
+	}
+	if len(cfg.RedactFields) == 0 {
+		cfg.RedactFields = DefaultRedactFields
+	}
+	redact := newRedactor(cfg.RedactFields, cfg.MaxBodySize)
 
 	return func(c *fiber.Ctx) error {
 		// Skip logging for certain paths
@@ -61,6 +84,13 @@ func Logging(cfg LoggingConfig) fiber.Handler {
 		duration := time.Since(start)
 		status := c.Response().StatusCode()
 
+		// Sampling only drops successful, fast requests
+		if err == nil && status < 400 && duration <= cfg.SlowThreshold {
+			if rate, ok := cfg.SampleRates[c.Route().Path]; ok && rand.Float64() >= rate {
+				return nil
+			}
+		}
+
 		// Build log attributes
 		attrs := []any{
 			slog.String("method", c.Method()),
@@ -85,6 +115,15 @@ func Logging(cfg LoggingConfig) fiber.Handler {
 			attrs = append(attrs, slog.String("error", err.Error()))
 		}
 
+		if cfg.LogBodies {
+			if body := redact.Body(c.Body(), c.Get(fiber.HeaderContentType)); body != "" {
+				attrs = append(attrs, slog.String("request_body", body))
+			}
+			if body := redact.Body(c.Response().Body(), string(c.Response().Header.ContentType())); body != "" {
+				attrs = append(attrs, slog.String("response_body", body))
+			}
+		}
+
 		// Log based on status and duration
 		switch {
 		case status >= 500:
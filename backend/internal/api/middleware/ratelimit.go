@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,13 +17,131 @@ type RateLimitConfig struct {
 	MaxRequests   int64
 	WindowSeconds int64
 	KeyPrefix     string
+	// Limits, when set, is consulted on every request instead of
+	// MaxRequests/WindowSeconds so limits can change at runtime
+	Limits func() (maxRequests, windowSeconds int64)
+	// Registry, when set, records the limit under KeyPrefix so it can be
+	// reported to clients
+	Registry *RateLimitRegistry
+}
+
+// RateLimitPolicy describes a configured rate limit
+type RateLimitPolicy struct {
+	Name          string
+	MaxRequests   int64
+	WindowSeconds int64
+}
+
+// RateLimitRegistry keeps the rate limits installed on routes
+type RateLimitRegistry struct {
+	mu     sync.RWMutex
+	names  []string
+	limits map[string]func() (int64, int64)
+}
+
+// NewRateLimitRegistry creates an empty RateLimitRegistry
+func NewRateLimitRegistry() *RateLimitRegistry {
+	return &RateLimitRegistry{limits: make(map[string]func() (int64, int64))}
+}
+
+// register records the limit of cfg under its KeyPrefix
+func (r *RateLimitRegistry) register(cfg RateLimitConfig) {
+	if r == nil {
+		return
+	}
+
+	limits := cfg.Limits
+	if limits == nil {
+		maxRequests, windowSeconds := cfg.MaxRequests, cfg.WindowSeconds
+		limits = func() (int64, int64) { return maxRequests, windowSeconds }
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.limits[cfg.KeyPrefix]; !ok {
+		r.names = append(r.names, cfg.KeyPrefix)
+	}
+	r.limits[cfg.KeyPrefix] = limits
+}
+
+// Policies returns the current limits in registration order
+func (r *RateLimitRegistry) Policies() []RateLimitPolicy {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policies := make([]RateLimitPolicy, 0, len(r.names))
+	for _, name := range r.names {
+		maxRequests, windowSeconds := r.limits[name]()
+		policies = append(policies, RateLimitPolicy{
+			Name:          name,
+			MaxRequests:   maxRequests,
+			WindowSeconds: windowSeconds,
+		})
+	}
+	return policies
+}
+
+// RateLimitStatus is the state of a rate limit after a request
+type RateLimitStatus struct {
+	Limit     int64
+	Remaining int64
+	// ResetAt is when the next slot frees up, in Unix milliseconds
+	ResetAt int64
+}
+
+// GetRateLimitStatus returns the status of the named rate limit as checked
+// for this request, or nil if the limit was not applied
+func GetRateLimitStatus(c *fiber.Ctx, name string) *RateLimitStatus {
+	status, _ := c.Locals("rateLimit:" + name).(*RateLimitStatus)
+	return status
+}
+
+// applyRateLimit sets the rate limit headers and stores the status for
+// handlers. A rejected request gets a 429 with Retry-After
+func applyRateLimit(c *fiber.Ctx, name string, allowed bool, status *RateLimitStatus) error {
+	c.Locals("rateLimit:"+name, status)
+
+	c.Set("X-RateLimit-Limit", strconv.FormatInt(status.Limit, 10))
+	c.Set("X-RateLimit-Remaining", strconv.FormatInt(status.Remaining, 10))
+	c.Set("X-RateLimit-Reset", strconv.FormatInt(status.ResetAt/1000, 10))
+
+	if allowed {
+		return c.Next()
+	}
+
+	retryAfter := retryAfterSeconds(status.ResetAt, time.Now())
+	c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       errors.ErrTooManyRequests.Error(),
+		"retry_after": retryAfter,
+	})
+}
+
+// retryAfterSeconds rounds the wait until resetAt up to whole seconds,
+// never less than one
+func retryAfterSeconds(resetAt int64, now time.Time) int64 {
+	wait := resetAt - now.UnixMilli()
+	seconds := (wait + 999) / 1000
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
 }
 
 // RateLimit creates rate limiting middleware
 func RateLimit(cfg RateLimitConfig) fiber.Handler {
-	window := time.Duration(cfg.WindowSeconds) * time.Second
+	cfg.Registry.register(cfg)
 
 	return func(c *fiber.Ctx) error {
+		maxRequests, windowSeconds := cfg.MaxRequests, cfg.WindowSeconds
+		if cfg.Limits != nil {
+			maxRequests, windowSeconds = cfg.Limits()
+		}
+		window := time.Duration(windowSeconds) * time.Second
+
 		// Get user identifier (Telegram ID or IP)
 		var identifier string
 		if telegramID := GetTelegramID(c); telegramID > 0 {
@@ -34,9 +153,9 @@ func RateLimit(cfg RateLimitConfig) fiber.Handler {
 		key := fmt.Sprintf("%s:%s", cfg.KeyPrefix, identifier)
 
 		allowed, remaining, resetAt, err := cfg.Limiter.Allow(
-			c.Context(),
+			c.UserContext(),
 			key,
-			cfg.MaxRequests,
+			maxRequests,
 			window,
 		)
 		if err != nil {
@@ -44,25 +163,18 @@ func RateLimit(cfg RateLimitConfig) fiber.Handler {
 			return c.Next()
 		}
 
-		// Set rate limit headers
-		c.Set("X-RateLimit-Limit", strconv.FormatInt(cfg.MaxRequests, 10))
-		c.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
-		c.Set("X-RateLimit-Reset", strconv.FormatInt(resetAt/1000, 10))
-
-		if !allowed {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":      errors.ErrTooManyRequests.Error(),
-				"retry_after": cfg.WindowSeconds,
-			})
-		}
-
-		return c.Next()
+		return applyRateLimit(c, cfg.KeyPrefix, allowed, &RateLimitStatus{
+			Limit:     maxRequests,
+			Remaining: remaining,
+			ResetAt:   resetAt,
+		})
 	}
 }
 
 // RateLimitByEndpoint creates endpoint-specific rate limiting
 func RateLimitByEndpoint(cfg RateLimitConfig) fiber.Handler {
 	window := time.Duration(cfg.WindowSeconds) * time.Second
+	cfg.Registry.register(cfg)
 
 	return func(c *fiber.Ctx) error {
 		var identifier string
@@ -76,7 +188,7 @@ func RateLimitByEndpoint(cfg RateLimitConfig) fiber.Handler {
 		key := fmt.Sprintf("%s:%s:%s:%s", cfg.KeyPrefix, identifier, c.Method(), c.Path())
 
 		allowed, remaining, resetAt, err := cfg.Limiter.Allow(
-			c.Context(),
+			c.UserContext(),
 			key,
 			cfg.MaxRequests,
 			window,
@@ -85,17 +197,10 @@ func RateLimitByEndpoint(cfg RateLimitConfig) fiber.Handler {
 			return c.Next()
 		}
 
-		c.Set("X-RateLimit-Limit", strconv.FormatInt(cfg.MaxRequests, 10))
-		c.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
-		c.Set("X-RateLimit-Reset", strconv.FormatInt(resetAt/1000, 10))
-
-		if !allowed {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":       errors.ErrTooManyRequests.Error(),
-				"retry_after": cfg.WindowSeconds,
-			})
-		}
-
-		return c.Next()
+		return applyRateLimit(c, cfg.KeyPrefix, allowed, &RateLimitStatus{
+			Limit:     cfg.MaxRequests,
+			Remaining: remaining,
+			ResetAt:   resetAt,
+		})
 	}
 }
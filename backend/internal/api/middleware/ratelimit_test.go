@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pkgredis "github.com/weqory/backend/pkg/redis"
+)
+
+func TestRateLimit_HeadersAndRetryAfter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	registry := NewRateLimitRegistry()
+	app := fiber.New()
+	app.Get("/ping", RateLimitByEndpoint(RateLimitConfig{
+		Limiter:       pkgredis.NewRateLimiter(client),
+		MaxRequests:   2,
+		WindowSeconds: 60,
+		KeyPrefix:     "ping",
+		Registry:      registry,
+	}), func(c *fiber.Ctx) error {
+		status := GetRateLimitStatus(c, "ping")
+		require.NotNil(t, status)
+		return c.JSON(status)
+	})
+
+	for i, remaining := range []string{"1", "0"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/ping", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode, "request %d", i)
+		assert.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, resp.Header.Get("X-RateLimit-Remaining"))
+		assert.Empty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ping", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+
+	retryAfter, err := strconv.Atoi(resp.Header.Get(fiber.HeaderRetryAfter))
+	require.NoError(t, err)
+	assert.InDelta(t, 60, retryAfter, 1)
+
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), reset, 2)
+
+	assert.Equal(t, []RateLimitPolicy{{Name: "ping", MaxRequests: 2, WindowSeconds: 60}}, registry.Policies())
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	now := time.UnixMilli(1_000_000)
+
+	assert.Equal(t, int64(1), retryAfterSeconds(now.UnixMilli()-500, now))
+	assert.Equal(t, int64(1), retryAfterSeconds(now.UnixMilli()+1, now))
+	assert.Equal(t, int64(2), retryAfterSeconds(now.UnixMilli()+1001, now))
+	assert.Equal(t, int64(30), retryAfterSeconds(now.UnixMilli()+30_000, now))
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// redactedValue replaces sensitive values in logged bodies
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactFields are masked in logged bodies. Matching is
+// case-insensitive and ignores "-" / "_"; any key ending in "token" or
+// "secret" is masked as well
+var DefaultRedactFields = []string{
+	"init_data",
+	"password",
+	"api_key",
+	"authorization",
+	"hash",
+	"signature",
+	"invoice_payload",
+}
+
+// redactor masks sensitive fields in JSON and form-encoded bodies
+type redactor struct {
+	fields  map[string]bool
+	maxSize int
+}
+
+func newRedactor(fields []string, maxSize int) *redactor {
+	r := &redactor{
+		fields:  make(map[string]bool, len(fields)),
+		maxSize: maxSize,
+	}
+	for _, f := range fields {
+		r.fields[normalizeFieldName(f)] = true
+	}
+	return r
+}
+
+// Body returns a loggable version of body. Bodies that are neither JSON nor
+// form-encoded are not logged, only their size
+func (r *redactor) Body(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var out string
+	switch {
+	case strings.HasPrefix(contentType, "application/json") || json.Valid(body):
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return fmt.Sprintf("[%d bytes, invalid JSON]", len(body))
+		}
+		encoded, err := json.Marshal(r.redactValue(v))
+		if err != nil {
+			return fmt.Sprintf("[%d bytes]", len(body))
+		}
+		out = string(encoded)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[%d bytes, invalid form]", len(body))
+		}
+		for key := range values {
+			if r.sensitive(key) {
+				values[key] = []string{redactedValue}
+			}
+		}
+		out = values.Encode()
+	default:
+		return fmt.Sprintf("[%d bytes %s]", len(body), contentType)
+	}
+
+	if r.maxSize > 0 && len(out) > r.maxSize {
+		return out[:r.maxSize] + "...(truncated)"
+	}
+	return out
+}
+
+func (r *redactor) redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for key, child := range val {
+			if r.sensitive(key) {
+				val[key] = redactedValue
+				continue
+			}
+			val[key] = r.redactValue(child)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = r.redactValue(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func (r *redactor) sensitive(key string) bool {
+	name := normalizeFieldName(key)
+	return r.fields[name] || strings.HasSuffix(name, "token") || strings.HasSuffix(name, "secret")
+}
+
+// normalizeFieldName makes initData, init_data and Init-Data compare equal
+func normalizeFieldName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "_", "")
+	return strings.ReplaceAll(name, "-", "")
+}
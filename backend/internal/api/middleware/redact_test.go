@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_JSON(t *testing.T) {
+	r := newRedactor(DefaultRedactFields, 4096)
+
+	body := r.Body([]byte(`{"initData":"query_id=1&hash=abc","user":{"id":1,"bot_token":"123:x"},"items":[{"password":"p"}],"symbol":"BTC"}`), "application/json")
+
+	assert.NotContains(t, body, "query_id")
+	assert.NotContains(t, body, "123:x")
+	assert.NotContains(t, body, `"p"`)
+	assert.Contains(t, body, `"symbol":"BTC"`)
+	assert.Contains(t, body, redactedValue)
+}
+
+func TestRedactor_FormAndTruncation(t *testing.T) {
+	r := newRedactor(DefaultRedactFields, 20)
+
+	body := r.Body([]byte("access_token=secret&symbol=BTCUSDT"), "application/x-www-form-urlencoded")
+	assert.NotContains(t, body, "secret")
+	assert.Contains(t, body, "...(truncated)")
+
+	assert.Equal(t, "[3 bytes text/plain]", r.Body([]byte("abc"), "text/plain"))
+	assert.Empty(t, r.Body(nil, "application/json"))
+}
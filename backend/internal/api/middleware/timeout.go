@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Locals key of the user context without any time budget applied
+const timeoutBaseKey = "timeout_base_ctx"
+
+// Timeout gives the rest of the chain a time budget. The deadline is set on
+// the user context that handlers pass to services, so queries and loops
+// stop once it passes and the request is answered with 504 instead of
+// tying up a worker. A Timeout on a route replaces the budget of one on
+// its group, so a route can be given more or less time than the default.
+// A budget of 0 disables the deadline
+func Timeout(budget time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if budget <= 0 {
+			return c.Next()
+		}
+
+		base, ok := c.Locals(timeoutBaseKey).(context.Context)
+		if !ok {
+			base = c.UserContext()
+			c.Locals(timeoutBaseKey, base)
+		}
+
+		ctx, cancel := context.WithTimeout(base, budget)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// Whatever failed after the deadline failed because of it
+		if ctx.Err() == context.DeadlineExceeded && (err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError) {
+			return c.Status(errors.ErrTimeout.StatusCode).JSON(fiber.Map{
+				"error": errors.ErrTimeout.Error(),
+			})
+		}
+		return err
+	}
+}
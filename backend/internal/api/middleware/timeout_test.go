@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowHandler waits for the request context like a slow query would
+func slowHandler(c *fiber.Ctx) error {
+	select {
+	case <-c.UserContext().Done():
+		return c.UserContext().Err()
+	case <-time.After(time.Second):
+		return c.SendString("done")
+	}
+}
+
+func TestTimeout_RespondsWithGatewayTimeout(t *testing.T) {
+	app := fiber.New()
+	app.Get("/slow", Timeout(20*time.Millisecond), slowHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestTimeout_RouteBudgetReplacesGroupBudget(t *testing.T) {
+	app := fiber.New()
+	api := app.Group("/api", Timeout(20*time.Millisecond))
+	api.Get("/slow", Timeout(5*time.Second), slowHandler)
+	api.Get("/deadline", func(c *fiber.Ctx) error {
+		deadline, ok := c.UserContext().Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, 20*time.Millisecond)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/slow", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/deadline", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+}
+
+func TestTimeout_ZeroBudgetSetsNoDeadline(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", Timeout(0), func(c *fiber.Ctx) error {
+		_, ok := c.UserContext().Deadline()
+		assert.False(t, ok)
+		assert.Equal(t, context.Background(), c.UserContext())
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+}
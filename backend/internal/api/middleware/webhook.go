@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// TelegramWebhookAuth creates middleware that accepts only updates carrying
+// the secret_token the webhook was set up with. An empty secret rejects
+// every request, so the webhook is never left open
+func TelegramWebhookAuth(secret string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if secret == "" {
+			return sendError(c, errors.ErrForbidden)
+		}
+
+		token := c.Get(telegram.WebhookSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return sendError(c, errors.ErrUnauthorized)
+		}
+
+		return c.Next()
+	}
+}
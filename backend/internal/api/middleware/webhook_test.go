@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+func TestTelegramWebhookAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		status int
+	}{
+		{"matching secret", "s3cret", "s3cret", fiber.StatusOK},
+		{"missing header", "s3cret", "", fiber.StatusUnauthorized},
+		{"wrong secret", "s3cret", "guess", fiber.StatusUnauthorized},
+		{"no secret configured", "", "", fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/webhook", TelegramWebhookAuth(tt.secret), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/webhook", nil)
+			if tt.header != "" {
+				req.Header.Set(telegram.WebhookSecretHeader, tt.header)
+			}
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
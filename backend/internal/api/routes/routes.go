@@ -1,12 +1,16 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/weqory/backend/internal/api/handlers"
 	"github.com/weqory/backend/internal/api/middleware"
+	"github.com/weqory/backend/internal/rbac"
 	"github.com/weqory/backend/internal/service"
 	ws "github.com/weqory/backend/internal/websocket"
+	"github.com/weqory/backend/pkg/crypto"
 	"github.com/weqory/backend/pkg/errors"
 	"github.com/weqory/backend/pkg/logger"
 	"github.com/weqory/backend/pkg/redis"
@@ -14,23 +18,73 @@ import (
 
 // Config holds route configuration
 type Config struct {
-	BotToken     string
-	RateLimiter  *redis.RateLimiter
-	Log          *logger.Logger
-	UserService  *service.UserService
-	Handlers     *Handlers
-	WSHandler    *ws.Handler
+	BotToken      string
+	BotTokenFunc  func() string
+	AdminAPIKey   string
+	WebhookSecret string
+	RateLimiter   *redis.RateLimiter
+	ResponseCache *redis.ResponseCache
+	RateLimits    func() (maxRequests, windowSeconds int64)
+	// Records the limits installed by Setup for GET /users/me
+	RateLimitRegistry *middleware.RateLimitRegistry
+	// Authenticates impersonation tokens; nil while impersonation is disabled
+	Impersonator middleware.Impersonator
+	// Throttles writes of users caught in abusive loops
+	AbuseGuard middleware.AbuseGuard
+	// Default time budget of API requests; slow routes get their own
+	RequestTimeout time.Duration
+	Log            *logger.Logger
+	UserService    *service.UserService
+	FeatureFlags   *service.FeatureFlagService
+	Handlers       *Handlers
+	WSHandler      *ws.Handler
+
+	// Init data validation settings, see middleware.AuthConfig
+	InitDataMaxAge time.Duration
+	InitDataBotID  int64
+	InitDataReplay middleware.ReplayGuard
+
+	// Honors debugging headers such as X-Cache-Bypass from any caller
+	Development bool
 }
 
 // Handlers holds all HTTP handlers
 type Handlers struct {
-	Auth      *handlers.AuthHandler
-	User      *handlers.UserHandler
-	Watchlist *handlers.WatchlistHandler
-	Alerts    *handlers.AlertsHandler
-	History   *handlers.HistoryHandler
-	Market    *handlers.MarketHandler
-	Payment   *handlers.PaymentHandler
+	Auth        *handlers.AuthHandler
+	User        *handlers.UserHandler
+	Watchlist   *handlers.WatchlistHandler
+	Alerts      *handlers.AlertsHandler
+	History     *handlers.HistoryHandler
+	Market      *handlers.MarketHandler
+	Payment     *handlers.PaymentHandler
+	Telegram    *handlers.TelegramWebhookHandler
+	Admin       *handlers.AdminHandler
+	Health      *handlers.HealthHandler
+	Features    *handlers.FeatureFlagHandler
+	Experiments *handlers.ExperimentHandler
+	Targets     *handlers.TargetsHandler
+	Services    *handlers.ServicesHandler
+	Onboarding  *handlers.OnboardingHandler
+	Exchanges   *handlers.ExchangesHandler
+	Import      *handlers.ImportHandler
+	Prices      *handlers.PriceHistoryHandler
+	CoinStats   *handlers.CoinStatsHandler
+	// Coin detail page with metadata from CoinGecko
+	Coins *handlers.CoinsHandler
+	// Coins gaining watchers and alerts
+	Trending *handlers.TrendingHandler
+	// Admin sessions acting as a user
+	Impersonation *handlers.ImpersonationHandler
+	Abuse         *handlers.AbuseHandler
+	Roles         *handlers.RolesHandler
+	// Renders alert messages without sending them
+	Preview *handlers.NotificationPreviewHandler
+	// Public status page and its incidents
+	Status *handlers.StatusHandler
+	// Opens and clicks of alert messages
+	Engagement *handlers.EngagementHandler
+	// Active alerts per coin and price band
+	AlertDensity *handlers.AlertDensityHandler
 }
 
 // Setup sets up all API routes
@@ -48,21 +102,37 @@ func Setup(app *fiber.App, cfg *Config) {
 		MaxRequests:   100,
 		WindowSeconds: 60,
 		KeyPrefix:     "global",
+		Limits:        cfg.RateLimits,
+		Registry:      cfg.RateLimitRegistry,
 	}))
 
+	// Component-level health (Postgres, Redis, downstream services)
+	app.Get("/health/deep", cfg.Handlers.Health.Deep)
+
+	// Public status page
+	app.Get("/status", statusCache(cfg, "status_page"), cfg.Handlers.Status.GetStatusPage)
+
 	// API v1 routes
-	api := app.Group("/api/v1")
+	api := app.Group("/api/v1", middleware.Timeout(cfg.RequestTimeout))
 
 	// Public routes
 	setupPublicRoutes(api, cfg)
 
+	// Admin routes (require admin API key)
+	setupAdminRoutes(api, cfg)
+
 	// Protected routes (require authentication)
 	authMiddleware := middleware.Auth(middleware.AuthConfig{
-		BotToken:  cfg.BotToken,
-		Logger:    cfg.Log,
-		SkipPaths: []string{"/health", "/api/v1/auth"},
+		BotToken:     cfg.BotToken,
+		BotTokenFunc: cfg.BotTokenFunc,
+		Logger:       cfg.Log,
+		SkipPaths:    []string{"/health", "/api/v1/auth", "/api/v1/admin"},
+
+		MaxAge:          cfg.InitDataMaxAge,
+		ThirdPartyBotID: cfg.InitDataBotID,
+		ReplayGuard:     cfg.InitDataReplay,
 	})
-	protected := api.Group("", authMiddleware, func(c *fiber.Ctx) error {
+	protected := api.Group("", middleware.Impersonation(cfg.Impersonator, cfg.Log), authMiddleware, func(c *fiber.Ctx) error {
 		telegramID := middleware.GetTelegramID(c)
 		if telegramID == 0 {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -71,7 +141,7 @@ func Setup(app *fiber.App, cfg *Config) {
 		}
 
 		// Fetch user from database by Telegram ID
-		user, err := cfg.UserService.GetByTelegramID(c.Context(), telegramID)
+		user, err := cfg.UserService.GetByTelegramID(c.UserContext(), telegramID)
 		if err != nil {
 			if errors.IsAppError(err) {
 				statusCode := errors.GetStatusCode(err)
@@ -87,7 +157,7 @@ func Setup(app *fiber.App, cfg *Config) {
 		// Store database user ID in context
 		middleware.SetUserID(c, user.ID)
 		return c.Next()
-	})
+	}, middleware.WriteThrottle(cfg.AbuseGuard, cfg.Log), middleware.FeatureFlags(cfg.FeatureFlags, cfg.Log))
 	setupProtectedRoutes(protected, cfg)
 
 	// WebSocket route
@@ -102,16 +172,31 @@ func setupPublicRoutes(router fiber.Router, cfg *Config) {
 
 	// Public market routes (same data for all users)
 	market := router.Group("/market")
-	market.Get("/overview", cfg.Handlers.Market.GetMarketOverview)
+	market.Get("/overview", responseCache(cfg, 15*time.Second, "market_overview"), cfg.Handlers.Market.GetMarketOverview)
 	market.Get("/category/:id", cfg.Handlers.Market.GetCategoryCoins)
+	market.Get("/sectors", cfg.Handlers.Market.GetSectors)
 
 	// Public coins list (for market page)
-	router.Get("/coins", cfg.Handlers.Watchlist.GetAvailableCoins)
+	router.Get("/coins", responseCache(cfg, 30*time.Second, "coins"), cfg.Handlers.Watchlist.GetAvailableCoins)
+	// Discovery screen; scores are recomputed hourly
+	router.Get("/coins/trending", responseCache(cfg, 5*time.Minute, "coins_trending"), cfg.Handlers.Trending.GetTrending)
+	// Computed from 30 days of history on a cache miss
+	router.Get("/coins/:symbol/stats", middleware.Timeout(20*time.Second), cfg.Handlers.CoinStats.GetCoinStats)
+	router.Get("/coins/:symbol/suggested-alerts", middleware.Timeout(20*time.Second), cfg.Handlers.CoinStats.GetSuggestedAlerts)
+	router.Get("/coins/:symbol", responseCache(cfg, 30*time.Second, "coin_detail"), cfg.Handlers.Coins.GetCoin)
+
+	// Status page data, also rendered as HTML at /status
+	router.Get("/status", statusCache(cfg, "status"), cfg.Handlers.Status.GetStatus)
 
 	// Payment routes (public)
 	payments := router.Group("/payments")
-	payments.Get("/plans", cfg.Handlers.Payment.GetPlans)      // Get available plans (no auth)
-	payments.Post("/webhook", cfg.Handlers.Payment.HandleWebhook) // Telegram webhook (no auth)
+	payments.Get("/plans", cfg.Handlers.Payment.GetPlans) // Get available plans (no auth)
+	// Webhooks registered before /telegram/webhook existed
+	payments.Post("/webhook", middleware.TelegramWebhookAuth(cfg.WebhookSecret), cfg.Handlers.Telegram.HandleWebhook)
+
+	// Telegram bot updates: commands, button presses and payments
+	bot := router.Group("/telegram")
+	bot.Post("/webhook", middleware.TelegramWebhookAuth(cfg.WebhookSecret), cfg.Handlers.Telegram.HandleWebhook)
 }
 
 // setupProtectedRoutes sets up routes that require authentication
@@ -120,45 +205,287 @@ func setupProtectedRoutes(router fiber.Router, cfg *Config) {
 	users := router.Group("/users")
 	users.Get("/me", cfg.Handlers.User.GetMe)
 	users.Patch("/me/settings", cfg.Handlers.User.UpdateSettings)
+	users.Patch("/me/locale", cfg.Handlers.User.UpdateLocale)
+	users.Post("/me/mute", cfg.Handlers.User.MuteAlerts)
+	users.Delete("/me/mute", cfg.Handlers.User.UnmuteAlerts)
+	users.Get("/me/onboarding", cfg.Handlers.Onboarding.GetOnboarding)
 	users.Delete("/me/watchlist", cfg.Handlers.User.DeleteWatchlist)
 	users.Delete("/me/alerts", cfg.Handlers.User.DeleteAlerts)
 	users.Delete("/me/history", cfg.Handlers.User.DeleteHistory)
+	users.Post("/me/test-notification", middleware.RateLimitByEndpoint(middleware.RateLimitConfig{
+		Limiter:       cfg.RateLimiter,
+		MaxRequests:   3,
+		WindowSeconds: 60,
+		KeyPrefix:     "test-notification",
+		Registry:      cfg.RateLimitRegistry,
+	}), cfg.Handlers.Services.SendMyTestNotification)
+
+	// Notification routes
+	router.Get("/notifications/preview", cfg.Handlers.Preview.GetPreview)
+	router.Post("/notifications/interactions", cfg.Handlers.Engagement.TrackInteraction)
 
 	// Watchlist routes
 	watchlist := router.Group("/watchlist")
 	watchlist.Get("/", cfg.Handlers.Watchlist.GetWatchlist)
-	watchlist.Post("/", cfg.Handlers.Watchlist.AddToWatchlist)
-	watchlist.Delete("/:symbol", cfg.Handlers.Watchlist.RemoveFromWatchlist)
+	watchlistChurn := middleware.TrackChurn(cfg.AbuseGuard, service.ChurnWatchlist, cfg.Log)
+	watchlist.Post("/", watchlistChurn, cfg.Handlers.Watchlist.AddToWatchlist)
+	watchlist.Delete("/:symbol", watchlistChurn, cfg.Handlers.Watchlist.RemoveFromWatchlist)
 	watchlist.Get("/available-coins", cfg.Handlers.Watchlist.GetAvailableCoins)
+	watchlist.Get("/export", middleware.RateLimitByEndpoint(middleware.RateLimitConfig{
+		Limiter:       cfg.RateLimiter,
+		MaxRequests:   10,
+		WindowSeconds: 60,
+		KeyPrefix:     "watchlist-export",
+		Registry:      cfg.RateLimitRegistry,
+	}), cfg.Handlers.Watchlist.ExportWatchlist)
 
 	// Alerts routes
 	alerts := router.Group("/alerts")
 	alerts.Get("/", cfg.Handlers.Alerts.GetAlerts)
-	alerts.Post("/", cfg.Handlers.Alerts.CreateAlert)
+	alertsChurn := middleware.TrackChurn(cfg.AbuseGuard, service.ChurnAlerts, cfg.Log)
+	alerts.Post("/", alertsChurn, cfg.Handlers.Alerts.CreateAlert)
+	alerts.Post("/backtest", middleware.RateLimitByEndpoint(middleware.RateLimitConfig{
+		Limiter:       cfg.RateLimiter,
+		MaxRequests:   10,
+		WindowSeconds: 60,
+		KeyPrefix:     "backtest",
+		Registry:      cfg.RateLimitRegistry,
+	}), middleware.Timeout(30*time.Second), cfg.Handlers.Alerts.BacktestAlert)
+	alerts.Patch("/:id", cfg.Handlers.Alerts.EditAlert)
 	alerts.Patch("/:id/pause", cfg.Handlers.Alerts.UpdateAlert)
-	alerts.Delete("/:id", cfg.Handlers.Alerts.DeleteAlert)
+	alerts.Put("/:id/schedule", cfg.Handlers.Alerts.UpdateAlertSchedule)
+	alerts.Delete("/:id", alertsChurn, cfg.Handlers.Alerts.DeleteAlert)
+
+	// Exchange routes (read-only API keys for watchlist import)
+	exchanges := router.Group("/exchanges")
+	exchanges.Get("/", cfg.Handlers.Exchanges.GetExchanges)
+	exchanges.Post("/", cfg.Handlers.Exchanges.ConnectExchange)
+	exchanges.Delete("/:exchange", cfg.Handlers.Exchanges.DisconnectExchange)
+	exchanges.Post("/:exchange/import", middleware.RateLimitByEndpoint(middleware.RateLimitConfig{
+		Limiter:       cfg.RateLimiter,
+		MaxRequests:   5,
+		WindowSeconds: 300,
+		KeyPrefix:     "exchange-import",
+		Registry:      cfg.RateLimitRegistry,
+	}), middleware.Timeout(60*time.Second), cfg.Handlers.Exchanges.ImportExchange)
+
+	// Durable price history, limited to the plan's lookback
+	router.Get("/coins/:symbol/history", middleware.Timeout(20*time.Second), cfg.Handlers.Prices.GetPriceHistory)
+
+	// Bulk import of coins and alerts from a CSV/JSON file
+	router.Post("/import", middleware.RateLimitByEndpoint(middleware.RateLimitConfig{
+		Limiter:       cfg.RateLimiter,
+		MaxRequests:   5,
+		WindowSeconds: 300,
+		KeyPrefix:     "import",
+		Registry:      cfg.RateLimitRegistry,
+	}), middleware.Timeout(60*time.Second), cfg.Handlers.Import.Import)
+
+	// Price target routes (personal targets, not notified)
+	targets := router.Group("/targets")
+	targets.Get("/", cfg.Handlers.Targets.GetTargets)
+	targets.Post("/", cfg.Handlers.Targets.CreateTarget)
+	targets.Patch("/:id", cfg.Handlers.Targets.UpdateTarget)
+	targets.Delete("/:id", cfg.Handlers.Targets.DeleteTarget)
 
 	// History routes
 	history := router.Group("/history")
 	history.Get("/", cfg.Handlers.History.GetHistory)
 
+	// Feature flags evaluated for the current user
+	router.Get("/features", cfg.Handlers.Features.GetFeatures)
+
 	// Payment routes (protected - require auth)
 	payments := router.Group("/payments")
 	payments.Post("/create-invoice", cfg.Handlers.Payment.CreateInvoice)
+	payments.Post("/start-trial", cfg.Handlers.Payment.StartTrial)
+	payments.Post("/cancel", cfg.Handlers.Payment.CancelSubscription)
 	payments.Get("/history", cfg.Handlers.Payment.GetPaymentHistory)
 }
 
+// setupAdminRoutes sets up internal admin routes
+func setupAdminRoutes(router fiber.Router, cfg *Config) {
+	admin := router.Group("/admin", middleware.AdminAuth(middleware.AdminAuthConfig{
+		APIKey: cfg.AdminAPIKey,
+		Staff:  staffLogin(cfg),
+	}))
+	view := middleware.RequirePermission(rbac.PermViewAdmin)
+	operate := middleware.RequirePermission(rbac.PermOperate)
+
+	// Symbol mapping management
+	mappings := admin.Group("/symbol-mappings")
+	mappings.Get("/", view, cfg.Handlers.Admin.GetSymbolMappings)
+	mappings.Post("/reconcile", operate, middleware.Timeout(2*time.Minute), cfg.Handlers.Admin.ReconcileSymbolMappings)
+	mappings.Put("/:symbol", operate, cfg.Handlers.Admin.UpdateSymbolMapping)
+	mappings.Delete("/:symbol", operate, cfg.Handlers.Admin.DeleteSymbolMapping)
+
+	// Stablecoin and exclusion lists of the watchlist
+	exclusions := admin.Group("/coin-exclusions")
+	exclusions.Get("/", view, cfg.Handlers.Admin.GetCoinExclusions)
+	exclusions.Put("/:symbol", operate, cfg.Handlers.Admin.UpdateCoinExclusion)
+	exclusions.Delete("/:symbol", operate, cfg.Handlers.Admin.DeleteCoinExclusion)
+
+	// Delisted coin report
+	delisted := admin.Group("/delisted-coins")
+	delisted.Get("/", view, cfg.Handlers.Admin.GetDelistedCoins)
+	delisted.Post("/detect", operate, middleware.Timeout(2*time.Minute), cfg.Handlers.Admin.DetectDelistedCoins)
+
+	// Payments missed by the Telegram webhook
+	admin.Post("/payments/reconcile", operate, middleware.Timeout(2*time.Minute), cfg.Handlers.Admin.ReconcilePayments)
+
+	// Revenue from the Star transactions synced from Telegram
+	admin.Post("/payments/sync-transactions", operate, middleware.Timeout(2*time.Minute), cfg.Handlers.Admin.SyncStarTransactions)
+	admin.Get("/revenue", view, cfg.Handlers.Admin.GetRevenue)
+	admin.Get("/users/:user_id/star-transactions", view, cfg.Handlers.Admin.GetUserStarTransactions)
+
+	// Acting as a user through the regular API, audited
+	impersonate := middleware.RequirePermission(rbac.PermImpersonate)
+	admin.Post("/users/:user_id/impersonate", impersonate, cfg.Handlers.Impersonation.StartImpersonation)
+	admin.Get("/impersonations", view, cfg.Handlers.Impersonation.GetImpersonations)
+	admin.Delete("/impersonations/:id", impersonate, cfg.Handlers.Impersonation.RevokeImpersonation)
+
+	// Abuse throttles and the audit log
+	admin.Get("/users/:user_id/abuse", view, cfg.Handlers.Abuse.GetAbuseStatus)
+	admin.Post("/users/:user_id/unlock", middleware.RequirePermission(rbac.PermUnlockUsers), cfg.Handlers.Abuse.UnlockUser)
+	admin.Get("/audit-log", view, cfg.Handlers.Abuse.GetAuditLog)
+
+	// Staff roles
+	admin.Get("/staff", view, cfg.Handlers.Roles.GetStaff)
+	admin.Put("/users/:user_id/role", middleware.RequirePermission(rbac.PermManageRoles), cfg.Handlers.Roles.SetRole)
+
+	// Active alerts per coin and price band, for alert engine capacity
+	admin.Get("/alerts/heatmap", view, cfg.Handlers.AlertDensity.GetAlertHeatmap)
+
+	// Replays an alert over stored prices to debug missed triggers
+	admin.Get("/alerts/:id/replay", view, middleware.Timeout(2*time.Minute), cfg.Handlers.Admin.ReplayAlert)
+
+	// Background jobs
+	admin.Get("/jobs", view, cfg.Handlers.Admin.GetJobs)
+
+	// WebSocket hub metrics (clients, dropped messages, fanout latency)
+	admin.Get("/websocket", view, cfg.Handlers.Admin.GetWebSocketStats)
+
+	// Feature flags and per-user overrides
+	flags := admin.Group("/feature-flags")
+	flags.Get("/", view, cfg.Handlers.Features.GetFeatureFlags)
+	flags.Put("/:key", operate, cfg.Handlers.Features.UpsertFeatureFlag)
+	flags.Delete("/:key", operate, cfg.Handlers.Features.DeleteFeatureFlag)
+	flags.Get("/:key/overrides", view, cfg.Handlers.Features.GetFeatureFlagOverrides)
+	flags.Put("/:key/overrides/:user_id", operate, cfg.Handlers.Features.SetFeatureFlagOverride)
+	flags.Delete("/:key/overrides/:user_id", operate, cfg.Handlers.Features.DeleteFeatureFlagOverride)
+
+	// Experiments and their variant splits
+	experiments := admin.Group("/experiments")
+	experiments.Get("/", view, cfg.Handlers.Experiments.GetExperiments)
+	experiments.Put("/:key", operate, cfg.Handlers.Experiments.UpsertExperiment)
+	experiments.Get("/:key/splits", view, cfg.Handlers.Experiments.GetExperimentSplits)
+
+	// Alert engine and notification service, over their internal gRPC APIs
+	engine := admin.Group("/engine")
+	engine.Get("/stats", view, cfg.Handlers.Services.GetEngineStats)
+	engine.Post("/refresh-alerts", operate, cfg.Handlers.Services.RefreshEngineAlerts)
+
+	notifications := admin.Group("/notifications")
+	notifications.Get("/stats", view, cfg.Handlers.Services.GetNotificationStats)
+	notifications.Post("/test", operate, cfg.Handlers.Services.SendTestNotification)
+	notifications.Get("/engagement", view, cfg.Handlers.Engagement.GetEngagement)
+
+	// Incidents shown on the public status page
+	incidents := admin.Group("/status/incidents")
+	incidents.Post("/", operate, cfg.Handlers.Status.CreateIncident)
+	incidents.Post("/:id/resolve", operate, cfg.Handlers.Status.ResolveIncident)
+}
+
+// responseCache caches the responses of a public route for ttl. Only
+// admins may skip it, or anyone in development
+func responseCache(cfg *Config, ttl time.Duration, keyPrefix string) fiber.Handler {
+	return middleware.Cache(middleware.CacheConfig{
+		Cache:       cfg.ResponseCache,
+		TTL:         ttl,
+		KeyPrefix:   keyPrefix,
+		Log:         cfg.Log,
+		AdminAPIKey: cfg.AdminAPIKey,
+		AllowBypass: cfg.Development,
+	})
+}
+
+// statusCache caches the status page briefly, as it runs every health
+// check and is polled by anyone
+func statusCache(cfg *Config, keyPrefix string) fiber.Handler {
+	return responseCache(cfg, 15*time.Second, keyPrefix)
+}
+
 // setupWebSocketRoutes sets up WebSocket routes
 func setupWebSocketRoutes(app *fiber.App, cfg *Config) {
 	// WebSocket upgrade middleware
 	app.Use("/ws", func(c *fiber.Ctx) error {
-		if websocket.IsWebSocketUpgrade(c) {
-			c.Locals("allowed", true)
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		c.Locals("allowed", true)
+
+		// Browsers cannot set headers on WebSocket requests, so clients that
+		// want private topics pass their init data as a query parameter.
+		// Connections without it stay anonymous
+		initData := c.Query("init_data")
+		if initData == "" {
 			return c.Next()
 		}
-		return fiber.ErrUpgradeRequired
+
+		user, err := loginWithInitData(c, cfg, initData)
+		if err != nil {
+			return c.Status(errors.GetStatusCode(err)).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		c.Locals(ws.UserIDLocal, user.ID)
+		return c.Next()
 	})
 
 	// WebSocket endpoint for price updates
 	app.Get("/ws/prices", cfg.WSHandler.Upgrade())
 }
+
+// loginWithInitData validates init data and looks up the user it belongs to,
+// for routes outside the protected group
+func loginWithInitData(c *fiber.Ctx, cfg *Config, initData string) (*service.User, error) {
+	botToken := cfg.BotToken
+	if cfg.BotTokenFunc != nil {
+		botToken = cfg.BotTokenFunc()
+	}
+	validator := crypto.InitDataValidator{
+		BotToken: botToken,
+		BotID:    cfg.InitDataBotID,
+		MaxAge:   cfg.InitDataMaxAge,
+	}
+	data, err := validator.Validate(initData)
+	if err != nil {
+		return nil, err
+	}
+	if data.User == nil {
+		return nil, errors.ErrInvalidInitData.WithMessage("missing user data")
+	}
+	if err := middleware.CheckReplay(c, cfg.InitDataReplay, data, cfg.InitDataBotID != 0, cfg.Log); err != nil {
+		return nil, err
+	}
+
+	return cfg.UserService.GetByTelegramID(c.UserContext(), data.User.ID)
+}
+
+// staffLogin authenticates staff calling the admin API with their Telegram
+// init data instead of the admin key
+func staffLogin(cfg *Config) middleware.StaffAuthenticator {
+	return func(c *fiber.Ctx) (rbac.Role, error) {
+		initData := c.Get("X-Telegram-Init-Data")
+		if initData == "" {
+			return "", errors.ErrUnauthorized.WithMessage("missing admin key or telegram init data")
+		}
+
+		user, err := loginWithInitData(c, cfg, initData)
+		if err != nil {
+			return "", err
+		}
+		middleware.SetUserID(c, user.ID)
+		return user.Role, nil
+	}
+}
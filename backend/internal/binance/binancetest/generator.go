@@ -0,0 +1,104 @@
+package binancetest
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+)
+
+// Volatility is the standard deviation of the relative price move per tick
+const Volatility = 0.001
+
+// publishInterval is how often Stream publishes the ticks that are due
+const publishInterval = 10 * time.Millisecond
+
+// Generator produces 24hr ticker updates for a set of symbols, each price
+// following its own random walk. Symbols are ticked in turn. Not safe for
+// concurrent use
+type Generator struct {
+	rng     *rand.Rand
+	symbols []string
+	states  []tickerState
+	next    int
+}
+
+// tickerState is the 24h statistics of a symbol
+type tickerState struct {
+	open, last, high, low float64
+	volume                float64
+	trades                int64
+}
+
+// NewGenerator creates a generator for symbols; the same seed yields the
+// same sequence of updates
+func NewGenerator(symbols []string, seed uint64) *Generator {
+	rng := rand.New(rand.NewPCG(seed, seed))
+
+	states := make([]tickerState, len(symbols))
+	for i := range states {
+		price := 1 + rng.Float64()*999
+		states[i] = tickerState{open: price, last: price, high: price, low: price}
+	}
+
+	return &Generator{rng: rng, symbols: symbols, states: states}
+}
+
+// Next moves the price of the next symbol and returns its ticker update
+func (g *Generator) Next() binance.TickerUpdate {
+	i := g.next
+	g.next = (g.next + 1) % len(g.symbols)
+
+	st := &g.states[i]
+	st.last *= 1 + g.rng.NormFloat64()*Volatility
+	st.high = max(st.high, st.last)
+	st.low = min(st.low, st.last)
+	st.volume += g.rng.Float64() * 10
+	st.trades++
+
+	now := time.Now()
+	change := st.last - st.open
+	return binance.TickerUpdate{
+		EventType:          "24hrTicker",
+		EventTime:          now.UnixMilli(),
+		Symbol:             g.symbols[i],
+		PriceChange:        formatFloat(change),
+		PriceChangePercent: formatFloat(change / st.open * 100),
+		LastPrice:          formatFloat(st.last),
+		OpenPrice:          formatFloat(st.open),
+		HighPrice:          formatFloat(st.high),
+		LowPrice:           formatFloat(st.low),
+		Volume:             formatFloat(st.volume),
+		QuoteVolume:        formatFloat(st.volume * st.last),
+		OpenTime:           now.Add(-24 * time.Hour).UnixMilli(),
+		CloseTime:          now.UnixMilli(),
+		TradeCount:         st.trades,
+	}
+}
+
+// Stream publishes ticksPerSec updates from gen to srv until ctx is done
+func Stream(ctx context.Context, srv *Server, gen *Generator, ticksPerSec int) {
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var published int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			due := int64(now.Sub(start).Seconds() * float64(ticksPerSec))
+			for ; published < due; published++ {
+				srv.Publish(gen.Next())
+			}
+		}
+	}
+}
+
+// formatFloat formats a number the way Binance does in ticker updates
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 8, 64)
+}
@@ -0,0 +1,315 @@
+// Package binancetest provides a fake Binance server speaking the subset of
+// the WebSocket stream and REST APIs used by the binance package, for tests
+// and load runs against a local stack
+package binancetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/weqory/backend/internal/binance"
+)
+
+const (
+	// Messages queued per connection before further ones are dropped
+	sendBufferSize = 1024
+
+	writeWait = 10 * time.Second
+)
+
+// Stats counts what the server has sent since it started
+type Stats struct {
+	Connections int
+	Published   int64 // ticker updates passed to Publish
+	Sent        int64 // messages queued to connections
+	Dropped     int64 // messages dropped because a connection fell behind
+}
+
+// Server is a fake Binance endpoint. It serves the combined stream at
+// /stream, honours SUBSCRIBE and UNSUBSCRIBE requests and answers
+// /api/v3/exchangeInfo with the configured symbols. Mount it with
+// httptest.NewServer or http.Server and point the clients at it with
+// SetBaseURL
+type Server struct {
+	symbols  []string
+	upgrader websocket.Upgrader
+
+	mu    sync.RWMutex
+	conns map[*conn]struct{}
+
+	// Stream every symbol to every connection, ignoring subscriptions
+	broadcast atomic.Bool
+
+	published atomic.Int64
+	sent      atomic.Int64
+	dropped   atomic.Int64
+}
+
+// conn is a client connected to the stream endpoint
+type conn struct {
+	ws   *websocket.Conn
+	send chan []byte
+	done chan struct{}
+
+	mu      sync.RWMutex
+	streams map[string]bool
+}
+
+// NewServer creates a fake Binance server listing symbols (e.g. "BTCUSDT")
+// as trading pairs
+func NewServer(symbols []string) *Server {
+	return &Server{
+		symbols: symbols,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+		conns: make(map[*conn]struct{}),
+	}
+}
+
+// WSURL returns the WebSocket base URL of a server listening on httpURL,
+// e.g. the URL of an httptest.Server
+func WSURL(httpURL string) string {
+	return "ws" + strings.TrimPrefix(httpURL, "http")
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/stream", "/ws":
+		s.serveStream(w, r)
+	case "/api/v3/exchangeInfo":
+		s.serveExchangeInfo(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// SetBroadcast makes Publish send every update to every connection, so a
+// stack without any alerts still receives the full tick rate
+func (s *Server) SetBroadcast(broadcast bool) {
+	s.broadcast.Store(broadcast)
+}
+
+// Publish sends a ticker update to every connection subscribed to its
+// symbol and returns the number of connections it was queued for
+func (s *Server) Publish(ticker binance.TickerUpdate) int {
+	s.published.Add(1)
+
+	stream := streamName(ticker.Symbol)
+	data, err := json.Marshal(ticker)
+	if err != nil {
+		return 0
+	}
+	msg, err := json.Marshal(binance.StreamMessage{Stream: stream, Data: data})
+	if err != nil {
+		return 0
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	broadcast := s.broadcast.Load()
+	queued := 0
+	for c := range s.conns {
+		if !broadcast && !c.subscribed(stream) {
+			continue
+		}
+		select {
+		case c.send <- msg:
+			queued++
+		default:
+			s.dropped.Add(1)
+		}
+	}
+	s.sent.Add(int64(queued))
+	return queued
+}
+
+// Stats returns the counters of the server
+func (s *Server) Stats() Stats {
+	s.mu.RLock()
+	connections := len(s.conns)
+	s.mu.RUnlock()
+
+	return Stats{
+		Connections: connections,
+		Published:   s.published.Load(),
+		Sent:        s.sent.Load(),
+		Dropped:     s.dropped.Load(),
+	}
+}
+
+// CloseConnections drops every stream connection, as Binance does on its
+// 24 hour limit; clients are expected to reconnect
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = make(map[*conn]struct{})
+	s.mu.Unlock()
+
+	for c := range conns {
+		c.ws.Close()
+	}
+}
+
+// serveStream upgrades the request and streams ticker updates until the
+// client goes away. Streams listed in ?streams= are subscribed up front
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{
+		ws:      ws,
+		send:    make(chan []byte, sendBufferSize),
+		done:    make(chan struct{}),
+		streams: make(map[string]bool),
+	}
+	if streams := r.URL.Query().Get("streams"); streams != "" {
+		for _, stream := range strings.Split(streams, "/") {
+			c.streams[strings.ToLower(stream)] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	go c.writeLoop()
+	c.readLoop()
+
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+	close(c.done)
+	ws.Close()
+}
+
+// serveExchangeInfo lists the configured symbols as trading pairs
+func (s *Server) serveExchangeInfo(w http.ResponseWriter) {
+	symbols := make([]binance.SymbolInfo, len(s.symbols))
+	for i, symbol := range s.symbols {
+		symbols[i] = binance.SymbolInfo{
+			Symbol:     symbol,
+			Status:     binance.SymbolStatusTrading,
+			BaseAsset:  baseAsset(symbol),
+			QuoteAsset: strings.TrimPrefix(symbol, baseAsset(symbol)),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"symbols": symbols})
+}
+
+// readLoop handles subscription requests until the connection fails
+func (c *conn) readLoop() {
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req binance.SubscribeMessage
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.reply(map[string]any{"error": map[string]any{"code": 3, "msg": "Invalid JSON"}})
+			continue
+		}
+
+		var result any
+		switch req.Method {
+		case "SUBSCRIBE":
+			c.setStreams(req.Params, true)
+		case "UNSUBSCRIBE":
+			c.setStreams(req.Params, false)
+		case "LIST_SUBSCRIPTIONS":
+			result = c.listStreams()
+		default:
+			c.reply(map[string]any{"id": req.ID, "error": map[string]any{"code": 2, "msg": "Invalid request"}})
+			continue
+		}
+		c.reply(map[string]any{"id": req.ID, "result": result})
+	}
+}
+
+// writeLoop writes queued messages until the connection is closed
+func (c *conn) writeLoop() {
+	for {
+		select {
+		case msg := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.ws.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// reply queues a response to a request, dropping it if the connection is
+// behind
+func (c *conn) reply(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+func (c *conn) setStreams(streams []string, subscribed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, stream := range streams {
+		if subscribed {
+			c.streams[strings.ToLower(stream)] = true
+		} else {
+			delete(c.streams, strings.ToLower(stream))
+		}
+	}
+}
+
+func (c *conn) listStreams() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	streams := make([]string, 0, len(c.streams))
+	for stream := range c.streams {
+		streams = append(streams, stream)
+	}
+	return streams
+}
+
+func (c *conn) subscribed(stream string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.streams[stream]
+}
+
+// streamName returns the ticker stream of symbol, e.g. btcusdt@ticker
+func streamName(symbol string) string {
+	return strings.ToLower(symbol) + "@ticker"
+}
+
+// quoteAssets are recognised when splitting a symbol into its assets
+var quoteAssets = []string{"USDT", "USDC", "FDUSD", "BTC", "ETH", "BNB", "EUR", "TRY"}
+
+// baseAsset returns the base asset of symbol, e.g. BTC for BTCUSDT
+func baseAsset(symbol string) string {
+	for _, quote := range quoteAssets {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && base != "" {
+			return base
+		}
+	}
+	return symbol
+}
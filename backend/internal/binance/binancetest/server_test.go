@@ -0,0 +1,118 @@
+package binancetest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+)
+
+func newTestServer(t *testing.T, symbols ...string) (*Server, *httptest.Server) {
+	t.Helper()
+	srv := NewServer(symbols)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.CloseConnections()
+		ts.Close()
+	})
+	return srv, ts
+}
+
+func TestServer_StreamsToBinanceClient(t *testing.T) {
+	srv, ts := newTestServer(t, "BTCUSDT", "ETHUSDT")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan binance.PriceData, 16)
+	client := binance.NewClient(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.SetBaseURL(WSURL(ts.URL))
+	client.SetPriceHandler(func(data binance.PriceData) {
+		select {
+		case received <- data:
+		default:
+		}
+	})
+	require.NoError(t, client.Subscribe([]string{"BTCUSDT"}))
+	go client.Run(ctx)
+	defer client.Close()
+
+	require.Eventually(t, func() bool { return srv.Stats().Connections == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	// Only the subscribed symbol is streamed
+	gen := NewGenerator([]string{"ETHUSDT", "BTCUSDT"}, 1)
+	assert.Equal(t, 0, srv.Publish(gen.Next()))
+	btc := gen.Next()
+	assert.Equal(t, 1, srv.Publish(btc))
+
+	select {
+	case data := <-received:
+		assert.Equal(t, "BTCUSDT", data.Symbol)
+		assert.InDelta(t, mustParse(t, btc.LastPrice), data.Price, 1e-9)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no price update received")
+	}
+
+	// Subscribing on the open connection takes effect too
+	require.NoError(t, client.Subscribe([]string{"ETHUSDT"}))
+	require.Eventually(t, func() bool { return srv.Publish(gen.Next()) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	// Broadcast ignores subscriptions
+	require.NoError(t, client.Unsubscribe([]string{"BTCUSDT", "ETHUSDT"}))
+	require.Eventually(t, func() bool { return srv.Publish(gen.Next()) == 0 }, 2*time.Second, 10*time.Millisecond)
+	srv.SetBroadcast(true)
+	assert.Equal(t, 1, srv.Publish(gen.Next()))
+}
+
+func TestServer_ExchangeInfo(t *testing.T) {
+	_, ts := newTestServer(t, "BTCUSDT", "SOLFDUSD")
+
+	info := binance.NewExchangeInfo(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	info.SetBaseURL(ts.URL)
+
+	symbols, err := info.Symbols(context.Background())
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+	assert.Equal(t, "BTC", symbols["BTCUSDT"].BaseAsset)
+	assert.Equal(t, "USDT", symbols["BTCUSDT"].QuoteAsset)
+	assert.Equal(t, "SOL", symbols["SOLFDUSD"].BaseAsset)
+	assert.Equal(t, binance.SymbolStatusTrading, symbols["SOLFDUSD"].Status)
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	symbols := []string{"BTCUSDT", "ETHUSDT"}
+	a, b := NewGenerator(symbols, 42), NewGenerator(symbols, 42)
+
+	for i := 0; i < 10; i++ {
+		ta, tb := a.Next(), b.Next()
+		assert.Equal(t, symbols[i%2], ta.Symbol)
+		assert.Equal(t, ta.LastPrice, tb.LastPrice)
+		assert.Equal(t, ta.HighPrice, tb.HighPrice)
+	}
+}
+
+func TestStream_PublishesAtRate(t *testing.T) {
+	srv := NewServer([]string{"BTCUSDT"})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	Stream(ctx, srv, NewGenerator([]string{"BTCUSDT"}, 1), 1000)
+
+	// ~200 ticks; the bounds leave room for a slow scheduler
+	published := srv.Stats().Published
+	assert.Greater(t, published, int64(50))
+	assert.LessOrEqual(t, published, int64(200))
+}
+
+func mustParse(t *testing.T, s string) float64 {
+	t.Helper()
+	f, err := strconv.ParseFloat(s, 64)
+	require.NoError(t, err)
+	return f
+}
@@ -30,9 +30,30 @@ const (
 	maxReconnectDelay = 60 * time.Second
 )
 
+// depthStreamSuffix selects the partial book depth stream of a symbol, the
+// top DepthLevels of each side once a second
+const depthStreamSuffix = "@depth10"
+
+// tradeStreamSuffix selects the aggregated trade stream of a symbol, every
+// trade in real time instead of the ticker's once a second
+const tradeStreamSuffix = "@aggTrade"
+
+// bookTickerStreamSuffix selects the best bid and ask stream of a symbol,
+// pushed on every change of the top of the book
+const bookTickerStreamSuffix = "@bookTicker"
+
 // PriceHandler is called when a new price update is received
 type PriceHandler func(data PriceData)
 
+// DepthHandler is called when a new order book snapshot is received
+type DepthHandler func(data DepthData)
+
+// TradeHandler is called when a new aggregated trade is received
+type TradeHandler func(data TradeData)
+
+// BookTickerHandler is called when the best bid or ask of a symbol changes
+type BookTickerHandler func(data BookTickerData)
+
 // Client represents a Binance WebSocket client
 type Client struct {
 	conn          *websocket.Conn
@@ -44,20 +65,47 @@ type Client struct {
 	reconnecting  bool
 	subscriptionID int
 
+	// baseURL is the WebSocket endpoint, wsBaseURL unless overridden
+	baseURL       string
+
 	// pingDone signals the pingLoop to stop
 	pingDone      chan struct{}
 	pingMu        sync.Mutex
+
+	// Symbols streamed with their order book depth, next to the tickers
+	depthSymbols map[string]bool
+	depthHandler DepthHandler
+
+	// Symbols streamed trade by trade
+	tradeSymbols map[string]bool
+	tradeHandler TradeHandler
+
+	// Symbols streamed with their best bid and ask
+	bookTickerSymbols map[string]bool
+	bookTickerHandler BookTickerHandler
 }
 
 // NewClient creates a new Binance WebSocket client
 func NewClient(logger *slog.Logger) *Client {
 	return &Client{
-		symbols: make(map[string]bool),
-		logger:  logger,
-		done:    make(chan struct{}),
+		symbols:           make(map[string]bool),
+		depthSymbols:      make(map[string]bool),
+		tradeSymbols:      make(map[string]bool),
+		bookTickerSymbols: make(map[string]bool),
+		logger:            logger,
+		done:              make(chan struct{}),
+		baseURL:           wsBaseURL,
 	}
 }
 
+// SetBaseURL points the client at another WebSocket endpoint, such as a
+// fake Binance server in tests and load runs. Takes effect on next connect
+func (c *Client) SetBaseURL(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
 // SetPriceHandler sets the handler for price updates
 func (c *Client) SetPriceHandler(handler PriceHandler) {
 	c.mu.Lock()
@@ -65,6 +113,27 @@ func (c *Client) SetPriceHandler(handler PriceHandler) {
 	c.priceHandler = handler
 }
 
+// SetDepthHandler sets the handler for order book depth updates
+func (c *Client) SetDepthHandler(handler DepthHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.depthHandler = handler
+}
+
+// SetTradeHandler sets the handler for aggregated trades
+func (c *Client) SetTradeHandler(handler TradeHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tradeHandler = handler
+}
+
+// SetBookTickerHandler sets the handler for best bid and ask updates
+func (c *Client) SetBookTickerHandler(handler BookTickerHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bookTickerHandler = handler
+}
+
 // Connect establishes connection to Binance WebSocket
 func (c *Client) Connect(ctx context.Context) error {
 	c.mu.Lock()
@@ -83,16 +152,29 @@ func (c *Client) connect(ctx context.Context) error {
 	for s := range c.symbols {
 		symbols = append(symbols, s)
 	}
+	depthSymbols := make([]string, 0, len(c.depthSymbols))
+	for s := range c.depthSymbols {
+		depthSymbols = append(depthSymbols, s)
+	}
+	tradeSymbols := make([]string, 0, len(c.tradeSymbols))
+	for s := range c.tradeSymbols {
+		tradeSymbols = append(tradeSymbols, s)
+	}
+	bookTickerSymbols := make([]string, 0, len(c.bookTickerSymbols))
+	for s := range c.bookTickerSymbols {
+		bookTickerSymbols = append(bookTickerSymbols, s)
+	}
+	baseURL := c.baseURL
 	c.mu.Unlock()
 
 	// Build stream URL
-	url := wsBaseURL + wsStreamPath
-	if len(symbols) > 0 {
-		streams := make([]string, len(symbols))
-		for i, s := range symbols {
-			streams[i] = strings.ToLower(s) + "@ticker"
-		}
-		url = wsBaseURL + wsCombinedPath + strings.Join(streams, "/")
+	url := baseURL + wsStreamPath
+	streams := streamNames(symbols, "@ticker")
+	streams = append(streams, streamNames(depthSymbols, depthStreamSuffix)...)
+	streams = append(streams, streamNames(tradeSymbols, tradeStreamSuffix)...)
+	streams = append(streams, streamNames(bookTickerSymbols, bookTickerStreamSuffix)...)
+	if len(streams) > 0 {
+		url = baseURL + wsCombinedPath + strings.Join(streams, "/")
 	}
 
 	c.logger.Info("connecting to Binance WebSocket", slog.String("url", url))
@@ -137,37 +219,81 @@ func (c *Client) Subscribe(symbols []string) error {
 		return nil // Will subscribe on next connect
 	}
 
-	// Build subscription streams
-	streams := make([]string, len(symbols))
-	for i, s := range symbols {
-		streams[i] = strings.ToLower(s) + "@ticker"
+	if err := writeStreamRequest(conn, "SUBSCRIBE", streamNames(symbols, "@ticker"), id); err != nil {
+		return err
 	}
 
-	msg := SubscribeMessage{
-		Method: "SUBSCRIBE",
-		Params: streams,
-		ID:     id,
+	c.logger.Debug("subscribed to symbols", slog.Any("symbols", symbols))
+	return nil
+}
+
+// Unsubscribe unsubscribes from price updates for given symbols
+func (c *Client) Unsubscribe(symbols []string) error {
+	c.mu.Lock()
+	for _, s := range symbols {
+		delete(c.symbols, s)
 	}
+	conn := c.conn
+	c.subscriptionID++
+	id := c.subscriptionID
+	c.mu.Unlock()
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal subscribe message: %w", err)
+	if conn == nil {
+		return nil
 	}
 
-	conn.SetWriteDeadline(time.Now().Add(writeWait))
-	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		return fmt.Errorf("failed to send subscribe message: %w", err)
+	if err := writeStreamRequest(conn, "UNSUBSCRIBE", streamNames(symbols, "@ticker"), id); err != nil {
+		return err
 	}
 
-	c.logger.Debug("subscribed to symbols", slog.Any("symbols", symbols))
+	c.logger.Debug("unsubscribed from symbols", slog.Any("symbols", symbols))
 	return nil
 }
 
-// Unsubscribe unsubscribes from price updates for given symbols
-func (c *Client) Unsubscribe(symbols []string) error {
+// SubscribeDepth subscribes to order book depth updates for given symbols
+func (c *Client) SubscribeDepth(symbols []string) error {
+	return c.updateStreams("SUBSCRIBE", c.depthSymbols, depthStreamSuffix, symbols)
+}
+
+// UnsubscribeDepth unsubscribes from order book depth updates for given
+// symbols
+func (c *Client) UnsubscribeDepth(symbols []string) error {
+	return c.updateStreams("UNSUBSCRIBE", c.depthSymbols, depthStreamSuffix, symbols)
+}
+
+// SubscribeTrades subscribes to aggregated trades for given symbols
+func (c *Client) SubscribeTrades(symbols []string) error {
+	return c.updateStreams("SUBSCRIBE", c.tradeSymbols, tradeStreamSuffix, symbols)
+}
+
+// UnsubscribeTrades unsubscribes from aggregated trades for given symbols
+func (c *Client) UnsubscribeTrades(symbols []string) error {
+	return c.updateStreams("UNSUBSCRIBE", c.tradeSymbols, tradeStreamSuffix, symbols)
+}
+
+// SubscribeBookTicker subscribes to best bid and ask updates for given
+// symbols
+func (c *Client) SubscribeBookTicker(symbols []string) error {
+	return c.updateStreams("SUBSCRIBE", c.bookTickerSymbols, bookTickerStreamSuffix, symbols)
+}
+
+// UnsubscribeBookTicker unsubscribes from best bid and ask updates for given
+// symbols
+func (c *Client) UnsubscribeBookTicker(symbols []string) error {
+	return c.updateStreams("UNSUBSCRIBE", c.bookTickerSymbols, bookTickerStreamSuffix, symbols)
+}
+
+// updateStreams adds symbols to or removes them from set, the symbols of
+// the stream with suffix, and sends the request if connected. Otherwise
+// the streams are opened on next connect
+func (c *Client) updateStreams(method string, set map[string]bool, suffix string, symbols []string) error {
 	c.mu.Lock()
 	for _, s := range symbols {
-		delete(c.symbols, s)
+		if method == "SUBSCRIBE" {
+			set[s] = true
+		} else {
+			delete(set, s)
+		}
 	}
 	conn := c.conn
 	c.subscriptionID++
@@ -178,28 +304,43 @@ func (c *Client) Unsubscribe(symbols []string) error {
 		return nil
 	}
 
+	if err := writeStreamRequest(conn, method, streamNames(symbols, suffix), id); err != nil {
+		return err
+	}
+
+	c.logger.Debug("updated streams",
+		slog.String("method", method),
+		slog.String("stream", suffix),
+		slog.Any("symbols", symbols),
+	)
+	return nil
+}
+
+// streamNames returns the stream names of symbols for a stream suffix such
+// as "@ticker"
+func streamNames(symbols []string, suffix string) []string {
 	streams := make([]string, len(symbols))
 	for i, s := range symbols {
-		streams[i] = strings.ToLower(s) + "@ticker"
+		streams[i] = strings.ToLower(s) + suffix
 	}
+	return streams
+}
 
-	msg := SubscribeMessage{
-		Method: "UNSUBSCRIBE",
+// writeStreamRequest sends a SUBSCRIBE or UNSUBSCRIBE request for streams
+func writeStreamRequest(conn *websocket.Conn, method string, streams []string, id int) error {
+	data, err := json.Marshal(SubscribeMessage{
+		Method: method,
 		Params: streams,
 		ID:     id,
-	}
-
-	data, err := json.Marshal(msg)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal unsubscribe message: %w", err)
+		return fmt.Errorf("failed to marshal %s message: %w", strings.ToLower(method), err)
 	}
 
 	conn.SetWriteDeadline(time.Now().Add(writeWait))
 	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		return fmt.Errorf("failed to send unsubscribe message: %w", err)
+		return fmt.Errorf("failed to send %s message: %w", strings.ToLower(method), err)
 	}
-
-	c.logger.Debug("unsubscribed from symbols", slog.Any("symbols", symbols))
 	return nil
 }
 
@@ -287,6 +428,18 @@ func (c *Client) handleMessage(data []byte) {
 	// Try to parse as combined stream message
 	var streamMsg StreamMessage
 	if err := json.Unmarshal(data, &streamMsg); err == nil && streamMsg.Stream != "" {
+		if symbol, ok := strings.CutSuffix(streamMsg.Stream, depthStreamSuffix); ok {
+			c.processDepthData(strings.ToUpper(symbol), streamMsg.Data)
+			return
+		}
+		if strings.HasSuffix(streamMsg.Stream, tradeStreamSuffix) {
+			c.processTradeData(streamMsg.Data)
+			return
+		}
+		if strings.HasSuffix(streamMsg.Stream, bookTickerStreamSuffix) {
+			c.processBookTickerData(streamMsg.Data)
+			return
+		}
 		c.processTickerData(streamMsg.Data)
 		return
 	}
@@ -312,6 +465,7 @@ func (c *Client) processTickerData(data json.RawMessage) {
 }
 
 func (c *Client) processTicker(ticker TickerUpdate) {
+	receivedAt := time.Now()
 	price, _ := strconv.ParseFloat(ticker.LastPrice, 64)
 	priceChange, _ := strconv.ParseFloat(ticker.PriceChange, 64)
 	changePercent, _ := strconv.ParseFloat(ticker.PriceChangePercent, 64)
@@ -329,7 +483,8 @@ func (c *Client) processTicker(ticker TickerUpdate) {
 		Low24h:        low24h,
 		Volume24h:     volume24h,
 		QuoteVolume:   quoteVolume,
-		UpdatedAt:     time.Now(),
+		UpdatedAt:     receivedAt,
+		ReceivedAt:    receivedAt,
 	}
 
 	c.mu.RLock()
@@ -341,6 +496,101 @@ func (c *Client) processTicker(ticker TickerUpdate) {
 	}
 }
 
+// processDepthData handles a partial book depth message. The message does
+// not carry its symbol, which comes from the stream name
+func (c *Client) processDepthData(symbol string, data json.RawMessage) {
+	var update PartialDepthUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		c.logger.Error("failed to unmarshal depth", slog.String("error", err.Error()))
+		return
+	}
+
+	depth := DepthData{
+		Symbol:       symbol,
+		LastUpdateID: update.LastUpdateID,
+		Bids:         parseDepthLevels(update.Bids),
+		Asks:         parseDepthLevels(update.Asks),
+		UpdatedAt:    time.Now(),
+	}
+
+	c.mu.RLock()
+	handler := c.depthHandler
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(depth)
+	}
+}
+
+// processTradeData handles an aggregated trade message
+func (c *Client) processTradeData(data json.RawMessage) {
+	receivedAt := time.Now()
+
+	var trade AggTradeUpdate
+	if err := json.Unmarshal(data, &trade); err != nil {
+		c.logger.Error("failed to unmarshal trade", slog.String("error", err.Error()))
+		return
+	}
+
+	price, _ := strconv.ParseFloat(trade.Price, 64)
+	quantity, _ := strconv.ParseFloat(trade.Quantity, 64)
+
+	c.mu.RLock()
+	handler := c.tradeHandler
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(TradeData{
+			Symbol:     trade.Symbol,
+			Price:      price,
+			Quantity:   quantity,
+			TradeTime:  time.UnixMilli(trade.TradeTime),
+			ReceivedAt: receivedAt,
+		})
+	}
+}
+
+// processBookTickerData handles a best bid and ask message
+func (c *Client) processBookTickerData(data json.RawMessage) {
+	receivedAt := time.Now()
+
+	var update BookTickerUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		c.logger.Error("failed to unmarshal book ticker", slog.String("error", err.Error()))
+		return
+	}
+
+	bidPrice, _ := strconv.ParseFloat(update.BidPrice, 64)
+	bidQuantity, _ := strconv.ParseFloat(update.BidQuantity, 64)
+	askPrice, _ := strconv.ParseFloat(update.AskPrice, 64)
+	askQuantity, _ := strconv.ParseFloat(update.AskQuantity, 64)
+
+	c.mu.RLock()
+	handler := c.bookTickerHandler
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(BookTickerData{
+			Symbol:      update.Symbol,
+			BidPrice:    bidPrice,
+			BidQuantity: bidQuantity,
+			AskPrice:    askPrice,
+			AskQuantity: askQuantity,
+			ReceivedAt:  receivedAt,
+		})
+	}
+}
+
+// parseDepthLevels converts [price, quantity] pairs to levels
+func parseDepthLevels(pairs [][2]string) []DepthLevel {
+	levels := make([]DepthLevel, len(pairs))
+	for i, p := range pairs {
+		levels[i].Price, _ = strconv.ParseFloat(p[0], 64)
+		levels[i].Quantity, _ = strconv.ParseFloat(p[1], 64)
+	}
+	return levels
+}
+
 func (c *Client) pingLoop(ctx context.Context, pingDone chan struct{}) {
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
@@ -428,6 +678,21 @@ func (c *Client) handleReconnect(ctx context.Context) {
 				c.logger.Error("resubscribe failed", slog.String("error", err.Error()))
 			}
 		}
+		if depthSymbols := c.GetDepthSymbols(); len(depthSymbols) > 0 {
+			if err := c.SubscribeDepth(depthSymbols); err != nil {
+				c.logger.Error("depth resubscribe failed", slog.String("error", err.Error()))
+			}
+		}
+		if tradeSymbols := c.GetTradeSymbols(); len(tradeSymbols) > 0 {
+			if err := c.SubscribeTrades(tradeSymbols); err != nil {
+				c.logger.Error("trade resubscribe failed", slog.String("error", err.Error()))
+			}
+		}
+		if bookTickerSymbols := c.GetBookTickerSymbols(); len(bookTickerSymbols) > 0 {
+			if err := c.SubscribeBookTicker(bookTickerSymbols); err != nil {
+				c.logger.Error("book ticker resubscribe failed", slog.String("error", err.Error()))
+			}
+		}
 
 		return
 	}
@@ -469,6 +734,44 @@ func (c *Client) GetSubscribedSymbols() []string {
 	return symbols
 }
 
+// GetDepthSymbols returns the symbols currently streamed with their order
+// book depth
+func (c *Client) GetDepthSymbols() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	symbols := make([]string, 0, len(c.depthSymbols))
+	for s := range c.depthSymbols {
+		symbols = append(symbols, s)
+	}
+	return symbols
+}
+
+// GetTradeSymbols returns the symbols currently streamed trade by trade
+func (c *Client) GetTradeSymbols() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	symbols := make([]string, 0, len(c.tradeSymbols))
+	for s := range c.tradeSymbols {
+		symbols = append(symbols, s)
+	}
+	return symbols
+}
+
+// GetBookTickerSymbols returns the symbols currently streamed with their
+// best bid and ask
+func (c *Client) GetBookTickerSymbols() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	symbols := make([]string, 0, len(c.bookTickerSymbols))
+	for s := range c.bookTickerSymbols {
+		symbols = append(symbols, s)
+	}
+	return symbols
+}
+
 // IsConnected returns true if connected
 func (c *Client) IsConnected() bool {
 	c.mu.RLock()
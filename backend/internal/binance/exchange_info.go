@@ -0,0 +1,191 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	restBaseURL = "https://api.binance.com"
+
+	// SymbolStatusTrading is the status of pairs that can currently be traded
+	SymbolStatusTrading = "TRADING"
+
+	// exchangeInfoTTL is how long an exchangeInfo snapshot is reused
+	exchangeInfoTTL = 1 * time.Hour
+)
+
+// SymbolInfo represents a trading pair from exchangeInfo
+type SymbolInfo struct {
+	Symbol     string `json:"symbol"`
+	Status     string `json:"status"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+}
+
+// ExchangeInfo caches the Binance exchangeInfo snapshot
+type ExchangeInfo struct {
+	httpClient *http.Client
+	baseURL    string
+	logger     *slog.Logger
+
+	symbols   map[string]SymbolInfo
+	fetchedAt time.Time
+	mu        sync.RWMutex
+	fetchMu   sync.Mutex
+}
+
+// NewExchangeInfo creates a new exchangeInfo cache
+func NewExchangeInfo(logger *slog.Logger) *ExchangeInfo {
+	return &ExchangeInfo{
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		baseURL: restBaseURL,
+		logger:  logger,
+	}
+}
+
+// SetBaseURL points the cache at another REST endpoint, such as a fake
+// Binance server. Must be called before the first lookup
+func (e *ExchangeInfo) SetBaseURL(baseURL string) {
+	e.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// Symbols returns all pairs from the cached snapshot, refreshing it if stale
+func (e *ExchangeInfo) Symbols(ctx context.Context) (map[string]SymbolInfo, error) {
+	e.mu.RLock()
+	symbols, fetchedAt := e.symbols, e.fetchedAt
+	e.mu.RUnlock()
+
+	if symbols != nil && time.Since(fetchedAt) < exchangeInfoTTL {
+		return symbols, nil
+	}
+
+	// Only one caller refreshes; the rest reuse its result
+	e.fetchMu.Lock()
+	defer e.fetchMu.Unlock()
+
+	e.mu.RLock()
+	symbols, fetchedAt = e.symbols, e.fetchedAt
+	e.mu.RUnlock()
+	if symbols != nil && time.Since(fetchedAt) < exchangeInfoTTL {
+		return symbols, nil
+	}
+
+	fresh, err := e.fetch(ctx)
+	if err != nil {
+		// Serve a stale snapshot rather than failing when Binance is unreachable
+		if symbols != nil {
+			e.logger.Warn("failed to refresh exchange info, using stale snapshot",
+				slog.String("error", err.Error()),
+			)
+			return symbols, nil
+		}
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.symbols = fresh
+	e.fetchedAt = time.Now()
+	e.mu.Unlock()
+
+	return fresh, nil
+}
+
+// Lookup returns a pair from the snapshot
+func (e *ExchangeInfo) Lookup(ctx context.Context, symbol string) (SymbolInfo, bool, error) {
+	symbols, err := e.Symbols(ctx)
+	if err != nil {
+		return SymbolInfo{}, false, err
+	}
+
+	info, ok := symbols[symbol]
+	return info, ok, nil
+}
+
+// DefaultQuoteAsset is the quote of the pairs coins are priced in
+const DefaultQuoteAsset = "USDT"
+
+// QuoteAssets are the quote assets alerts can be set in besides the
+// default, e.g. ETH priced in BTC or BTC in EUR
+var QuoteAssets = []string{"BTC", "ETH", "BNB", "EUR", "TRY", "USDC", "FDUSD"}
+
+// IsQuoteAsset reports whether alerts can be set in quote
+func IsQuoteAsset(quote string) bool {
+	return slices.Contains(QuoteAssets, strings.ToUpper(quote))
+}
+
+// DefaultPairSymbol returns the conventional USDT pair for a coin symbol
+func DefaultPairSymbol(symbol string) string {
+	return PairSymbol(symbol, DefaultQuoteAsset)
+}
+
+// PairSymbol returns the conventional pair of a base and quote asset, e.g.
+// ETHBTC. Listed pairs do not always follow it, see FindPair
+func PairSymbol(base, quote string) string {
+	return strings.ToUpper(base) + strings.ToUpper(quote)
+}
+
+// FindPair returns the pair of base priced in quote from the snapshot. The
+// conventional symbol is tried first, then any pair with those assets
+func (e *ExchangeInfo) FindPair(ctx context.Context, base, quote string) (SymbolInfo, bool, error) {
+	symbols, err := e.Symbols(ctx)
+	if err != nil {
+		return SymbolInfo{}, false, err
+	}
+
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	if info, ok := symbols[PairSymbol(base, quote)]; ok && info.BaseAsset == base && info.QuoteAsset == quote {
+		return info, true, nil
+	}
+	for _, info := range symbols {
+		if info.BaseAsset == base && info.QuoteAsset == quote {
+			return info, true, nil
+		}
+	}
+	return SymbolInfo{}, false, nil
+}
+
+// fetch downloads exchangeInfo from the Binance REST API
+func (e *ExchangeInfo) fetch(ctx context.Context) (map[string]SymbolInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", e.baseURL+"/api/v3/exchangeInfo", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Symbols []SymbolInfo `json:"symbols"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	symbols := make(map[string]SymbolInfo, len(result.Symbols))
+	for _, s := range result.Symbols {
+		symbols[s.Symbol] = s
+	}
+
+	e.logger.Info("fetched binance exchange info", slog.Int("symbols", len(symbols)))
+
+	return symbols, nil
+}
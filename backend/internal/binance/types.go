@@ -64,6 +64,75 @@ type PriceData struct {
 	Volume24h     float64   `json:"volume_24h"`
 	QuoteVolume   float64   `json:"quote_volume"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	// ReceivedAt is when the tick reached this process, the start of the
+	// alert pipeline latency; zero for prices not streamed from Binance
+	ReceivedAt time.Time `json:"-"`
+}
+
+// AggTradeUpdate represents an aggregated trade from Binance
+type AggTradeUpdate struct {
+	EventType    string `json:"e"` // Event type: "aggTrade"
+	EventTime    int64  `json:"E"` // Event time
+	Symbol       string `json:"s"` // Symbol
+	AggTradeID   int64  `json:"a"` // Aggregate trade ID
+	Price        string `json:"p"` // Price
+	Quantity     string `json:"q"` // Quantity
+	TradeTime    int64  `json:"T"` // Trade time
+	IsBuyerMaker bool   `json:"m"` // Is the buyer the market maker?
+}
+
+// TradeData represents a processed aggregated trade
+type TradeData struct {
+	Symbol    string
+	Price     float64
+	Quantity  float64
+	TradeTime time.Time
+	// ReceivedAt is when the trade reached this process
+	ReceivedAt time.Time
+}
+
+// BookTickerUpdate represents a best bid and ask update from Binance
+type BookTickerUpdate struct {
+	UpdateID    int64  `json:"u"` // Order book update ID
+	Symbol      string `json:"s"` // Symbol
+	BidPrice    string `json:"b"` // Best bid price
+	BidQuantity string `json:"B"` // Best bid quantity
+	AskPrice    string `json:"a"` // Best ask price
+	AskQuantity string `json:"A"` // Best ask quantity
+}
+
+// BookTickerData represents a processed best bid and ask
+type BookTickerData struct {
+	Symbol      string
+	BidPrice    float64
+	BidQuantity float64
+	AskPrice    float64
+	AskQuantity float64
+	// ReceivedAt is when the update reached this process
+	ReceivedAt time.Time
+}
+
+// PartialDepthUpdate represents a partial book depth update from Binance,
+// the best bids and asks as [price, quantity] pairs
+type PartialDepthUpdate struct {
+	LastUpdateID int64       `json:"lastUpdateId"`
+	Bids         [][2]string `json:"bids"`
+	Asks         [][2]string `json:"asks"`
+}
+
+// DepthLevel is a price level of the order book
+type DepthLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// DepthData represents a processed order book snapshot, best levels first
+type DepthData struct {
+	Symbol       string       `json:"symbol"`
+	LastUpdateID int64        `json:"last_update_id"`
+	Bids         []DepthLevel `json:"bids"`
+	Asks         []DepthLevel `json:"asks"`
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
 
 // StreamMessage represents a message from Binance WebSocket
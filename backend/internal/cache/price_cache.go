@@ -3,8 +3,12 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,18 +18,27 @@ import (
 const (
 	priceKeyPrefix      = "price:"
 	priceHistoryPrefix  = "price_history:"
+	priceSeriesPrefix   = "price_series:"
 	volumeHistoryPrefix = "volume_history:"
 	priceTTL            = 5 * time.Minute
 	historyTTL          = 24 * time.Hour
 	volumeHistoryTTL    = 7 * 24 * time.Hour // 7 days for volume history
 	historyMaxLen       = 1440               // 24 hours of minute data
+	historyRetention    = 25 * time.Hour     // a 24h change needs a point before its start
 	volumeHistoryMaxLen = 168                // 7 days of hourly data
+
+	// FlushInterval is how often buffered prices are written to Redis
+	FlushInterval = 250 * time.Millisecond
 )
 
 // PriceCache handles price caching in Redis
 type PriceCache struct {
 	client *redis.Client
 	logger *slog.Logger
+
+	// Prices buffered since the last flush, latest per symbol
+	mu    sync.Mutex
+	dirty map[string]binance.PriceData
 }
 
 // NewPriceCache creates a new PriceCache
@@ -33,27 +46,73 @@ func NewPriceCache(client *redis.Client, logger *slog.Logger) *PriceCache {
 	return &PriceCache{
 		client: client,
 		logger: logger,
+		dirty:  make(map[string]binance.PriceData),
 	}
 }
 
-// Set stores a price in cache
+// Set stores a price in cache, encoded by encodePrice
 func (c *PriceCache) Set(ctx context.Context, data binance.PriceData) error {
 	key := priceKeyPrefix + data.Symbol
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal price data: %w", err)
+	if err := c.client.Set(ctx, key, encodePrice(data), priceTTL).Err(); err != nil {
+		return fmt.Errorf("failed to set price in cache: %w", err)
 	}
 
-	if err := c.client.Set(ctx, key, jsonData, priceTTL).Err(); err != nil {
-		return fmt.Errorf("failed to set price in cache: %w", err)
+	return nil
+}
+
+// Buffer stores a price in cache on the next Flush. Until then Get and
+// GetMultiple return it from memory, so readers sharing this PriceCache
+// never see an older price than was buffered
+func (c *PriceCache) Buffer(data binance.PriceData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dirty[data.Symbol] = data
+}
+
+// Flush writes the buffered prices in one pipeline
+func (c *PriceCache) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.dirty) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	prices := make([]binance.PriceData, 0, len(c.dirty))
+	for _, data := range c.dirty {
+		prices = append(prices, data)
+	}
+	c.mu.Unlock()
+
+	if err := c.SetMultiple(ctx, prices); err != nil {
+		// Kept buffered for the next flush
+		return err
 	}
 
+	// Prices buffered again during the write stay dirty
+	c.mu.Lock()
+	for _, data := range prices {
+		if c.dirty[data.Symbol] == data {
+			delete(c.dirty, data.Symbol)
+		}
+	}
+	c.mu.Unlock()
 	return nil
 }
 
+// buffered returns a price buffered since the last flush
+func (c *PriceCache) buffered(symbol string) (binance.PriceData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.dirty[symbol]
+	return data, ok
+}
+
 // Get retrieves a price from cache
 func (c *PriceCache) Get(ctx context.Context, symbol string) (*binance.PriceData, error) {
+	if data, ok := c.buffered(symbol); ok {
+		return &data, nil
+	}
+
 	key := priceKeyPrefix + symbol
 
 	data, err := c.client.Get(ctx, key).Bytes()
@@ -64,9 +123,9 @@ func (c *PriceCache) Get(ctx context.Context, symbol string) (*binance.PriceData
 		return nil, fmt.Errorf("failed to get price from cache: %w", err)
 	}
 
-	var priceData binance.PriceData
-	if err := json.Unmarshal(data, &priceData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal price data: %w", err)
+	priceData, err := decodePrice(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode price data: %w", err)
 	}
 
 	return &priceData, nil
@@ -78,6 +137,20 @@ func (c *PriceCache) GetMultiple(ctx context.Context, symbols []string) (map[str
 		return make(map[string]*binance.PriceData), nil
 	}
 
+	prices := make(map[string]*binance.PriceData)
+	var missing []string
+	for _, s := range symbols {
+		if data, ok := c.buffered(s); ok {
+			prices[s] = &data
+		} else {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) == 0 {
+		return prices, nil
+	}
+	symbols = missing
+
 	keys := make([]string, len(symbols))
 	for i, s := range symbols {
 		keys[i] = priceKeyPrefix + s
@@ -88,7 +161,6 @@ func (c *PriceCache) GetMultiple(ctx context.Context, symbols []string) (map[str
 		return nil, fmt.Errorf("failed to get prices from cache: %w", err)
 	}
 
-	prices := make(map[string]*binance.PriceData)
 	for i, result := range results {
 		if result == nil {
 			continue
@@ -99,9 +171,9 @@ func (c *PriceCache) GetMultiple(ctx context.Context, symbols []string) (map[str
 			continue
 		}
 
-		var priceData binance.PriceData
-		if err := json.Unmarshal([]byte(data), &priceData); err != nil {
-			c.logger.Error("failed to unmarshal price data",
+		priceData, err := decodePrice([]byte(data))
+		if err != nil {
+			c.logger.Error("failed to decode price data",
 				slog.String("symbol", symbols[i]),
 				slog.String("error", err.Error()),
 			)
@@ -123,16 +195,7 @@ func (c *PriceCache) SetMultiple(ctx context.Context, prices []binance.PriceData
 	pipe := c.client.Pipeline()
 
 	for _, data := range prices {
-		key := priceKeyPrefix + data.Symbol
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			c.logger.Error("failed to marshal price data",
-				slog.String("symbol", data.Symbol),
-				slog.String("error", err.Error()),
-			)
-			continue
-		}
-		pipe.Set(ctx, key, jsonData, priceTTL)
+		pipe.Set(ctx, priceKeyPrefix+data.Symbol, encodePrice(data), priceTTL)
 	}
 
 	_, err := pipe.Exec(ctx)
@@ -143,16 +206,15 @@ func (c *PriceCache) SetMultiple(ctx context.Context, prices []binance.PriceData
 	return nil
 }
 
-// AddToHistory adds a price point to the historical data
+// AddToHistory adds a price point to the historical data. History is kept
+// in a sorted set scored by Unix timestamp, so time ranges are looked up in
+// O(log n) instead of walking a list
 func (c *PriceCache) AddToHistory(ctx context.Context, symbol string, price float64, timestamp time.Time) error {
-	key := priceHistoryPrefix + symbol
-
-	// Store as JSON with timestamp and price
-	entry := fmt.Sprintf(`{"t":%d,"p":%f}`, timestamp.Unix(), price)
+	key := priceSeriesPrefix + symbol
 
 	pipe := c.client.Pipeline()
-	pipe.LPush(ctx, key, entry)
-	pipe.LTrim(ctx, key, 0, historyMaxLen-1)
+	pipe.ZAdd(ctx, key, historyMember(timestamp.Unix(), price))
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(timestamp.Add(-historyRetention).Unix(), 10))
 	pipe.Expire(ctx, key, historyTTL)
 
 	_, err := pipe.Exec(ctx)
@@ -169,64 +231,152 @@ type PriceHistoryEntry struct {
 	Price     float64 `json:"p"`
 }
 
-// GetHistory retrieves price history for a symbol
+// historyMember returns the sorted set member of a price point. Members
+// carry the timestamp so equal prices at different times stay distinct
+func historyMember(timestamp int64, price float64) redis.Z {
+	return redis.Z{
+		Score:  float64(timestamp),
+		Member: fmt.Sprintf(`{"t":%d,"p":%f}`, timestamp, price),
+	}
+}
+
+// parseHistory parses sorted set members, skipping malformed ones
+func parseHistory(members []string) []PriceHistoryEntry {
+	history := make([]PriceHistoryEntry, 0, len(members))
+	for _, member := range members {
+		var entry PriceHistoryEntry
+		if err := json.Unmarshal([]byte(member), &entry); err != nil {
+			continue
+		}
+		history = append(history, entry)
+	}
+	return history
+}
+
+// GetHistory retrieves the latest price history for a symbol, newest first
 func (c *PriceCache) GetHistory(ctx context.Context, symbol string, limit int64) ([]PriceHistoryEntry, error) {
-	key := priceHistoryPrefix + symbol
+	key := priceSeriesPrefix + symbol
 
 	if limit <= 0 || limit > historyMaxLen {
 		limit = historyMaxLen
 	}
 
-	results, err := c.client.LRange(ctx, key, 0, limit-1).Result()
+	results, err := c.client.ZRevRange(ctx, key, 0, limit-1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get price history: %w", err)
 	}
 
-	history := make([]PriceHistoryEntry, 0, len(results))
-	for _, result := range results {
-		var entry PriceHistoryEntry
-		if err := json.Unmarshal([]byte(result), &entry); err != nil {
-			continue
-		}
-		history = append(history, entry)
+	return parseHistory(results), nil
+}
+
+// GetHistoryRange retrieves the price history of a symbol between from and
+// to inclusive, oldest first
+func (c *PriceCache) GetHistoryRange(ctx context.Context, symbol string, from, to time.Time) ([]PriceHistoryEntry, error) {
+	key := priceSeriesPrefix + symbol
+
+	results, err := c.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.Unix(), 10),
+		Max: strconv.FormatInt(to.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
 	}
 
-	return history, nil
+	return parseHistory(results), nil
 }
 
-// GetPriceChange calculates price change over a timeframe
+// ErrInsufficientHistory is returned when the price history does not reach
+// back to the start of the requested timeframe
+var ErrInsufficientHistory = errors.New("insufficient price history")
+
+// GetPriceChange calculates price change over a timeframe, from the price at
+// its start to the latest price. The start price is interpolated between the
+// points either side of it, so sparse history does not skew the change
+// towards whichever point happens to be closest
 func (c *PriceCache) GetPriceChange(ctx context.Context, symbol string, duration time.Duration) (float64, error) {
-	history, err := c.GetHistory(ctx, symbol, historyMaxLen)
-	if err != nil {
-		return 0, err
+	key := priceSeriesPrefix + symbol
+	target := time.Now().Add(-duration).Unix()
+	targetScore := strconv.FormatInt(target, 10)
+
+	pipe := c.client.Pipeline()
+	latest := pipe.ZRevRange(ctx, key, 0, 0)
+	before := pipe.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: targetScore, Count: 1})
+	after := pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "(" + targetScore, Max: "+inf", Count: 1})
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("failed to get price history: %w", err)
 	}
 
-	if len(history) == 0 {
-		return 0, nil
+	current := parseHistory(latest.Val())
+	start := parseHistory(before.Val())
+	if len(current) == 0 || len(start) == 0 {
+		return 0, ErrInsufficientHistory
 	}
 
-	currentPrice := history[0].Price
-	targetTime := time.Now().Add(-duration).Unix()
+	startPrice := start[0].Price
+	if next := parseHistory(after.Val()); len(next) > 0 {
+		startPrice = interpolatePrice(start[0], next[0], target)
+	}
+	if startPrice <= 0 {
+		return 0, ErrInsufficientHistory
+	}
 
-	// Find the price closest to the target time
-	var oldPrice float64
-	for _, entry := range history {
-		if entry.Timestamp <= targetTime {
-			oldPrice = entry.Price
-			break
-		}
-		oldPrice = entry.Price // Use oldest available if not enough history
+	return ((current[0].Price - startPrice) / startPrice) * 100, nil
+}
+
+// interpolatePrice returns the price at timestamp on the line between the
+// points a and b bounding it
+func interpolatePrice(a, b PriceHistoryEntry, timestamp int64) float64 {
+	if b.Timestamp <= a.Timestamp {
+		return a.Price
 	}
+	fraction := float64(timestamp-a.Timestamp) / float64(b.Timestamp-a.Timestamp)
+	return a.Price + (b.Price-a.Price)*fraction
+}
 
-	if oldPrice == 0 {
-		return 0, nil
+// MigrateHistoryLists moves price history kept in lists, as written before
+// sorted sets, into the sorted sets and deletes the lists. Returns the
+// number of symbols migrated
+func (c *PriceCache) MigrateHistoryLists(ctx context.Context) (int, error) {
+	migrated := 0
+	iter := c.client.Scan(ctx, 0, priceHistoryPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		symbol := strings.TrimPrefix(key, priceHistoryPrefix)
+
+		results, err := c.client.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("failed to read price history list: %w", err)
+		}
+
+		entries := parseHistory(results)
+		pipe := c.client.TxPipeline()
+		if len(entries) > 0 {
+			members := make([]redis.Z, len(entries))
+			for i, entry := range entries {
+				members[i] = historyMember(entry.Timestamp, entry.Price)
+			}
+			pipe.ZAdd(ctx, priceSeriesPrefix+symbol, members...)
+			pipe.Expire(ctx, priceSeriesPrefix+symbol, historyTTL)
+		}
+		pipe.Del(ctx, key)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return migrated, fmt.Errorf("failed to migrate price history: %w", err)
+		}
+		migrated++
+	}
+	if err := iter.Err(); err != nil {
+		return migrated, fmt.Errorf("failed to scan price history: %w", err)
 	}
 
-	return ((currentPrice - oldPrice) / oldPrice) * 100, nil
+	return migrated, nil
 }
 
 // Delete removes a price from cache
 func (c *PriceCache) Delete(ctx context.Context, symbol string) error {
+	c.mu.Lock()
+	delete(c.dirty, symbol)
+	c.mu.Unlock()
+
 	key := priceKeyPrefix + symbol
 	return c.client.Del(ctx, key).Err()
 }
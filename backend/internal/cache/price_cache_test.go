@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+)
+
+func newTestPriceCache(t *testing.T) (*PriceCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err, "failed to start miniredis")
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+
+	return NewPriceCache(client, slog.New(slog.NewTextHandler(io.Discard, nil))), mr
+}
+
+func TestPriceCache_BufferAndFlush(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestPriceCache(t)
+
+	c.Buffer(binance.PriceData{Symbol: "BTCUSDT", Price: 70000})
+	c.Buffer(binance.PriceData{Symbol: "BTCUSDT", Price: 70100})
+	assert.False(t, mr.Exists(priceKeyPrefix+"BTCUSDT"), "not written before the flush")
+
+	data, err := c.Get(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, 70100.0, data.Price, "buffered prices are readable")
+
+	require.NoError(t, c.Flush(ctx))
+	assert.True(t, mr.Exists(priceKeyPrefix+"BTCUSDT"))
+
+	require.NoError(t, c.Set(ctx, binance.PriceData{Symbol: "ETHUSDT", Price: 3500}))
+	c.Buffer(binance.PriceData{Symbol: "BTCUSDT", Price: 70200})
+	prices, err := c.GetMultiple(ctx, []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"})
+	require.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, 70200.0, prices["BTCUSDT"].Price)
+	assert.Equal(t, 3500.0, prices["ETHUSDT"].Price)
+
+	require.NoError(t, c.Flush(ctx))
+	assert.Empty(t, c.dirty)
+	data, err = c.Get(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, 70200.0, data.Price, "read back from Redis")
+}
+
+func TestPriceCache_HistoryRanges(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestPriceCache(t)
+
+	now := time.Now().Truncate(time.Second)
+	for i, price := range []float64{100, 102, 104, 106, 110} {
+		// 4h, 3h, 2h, 1h and 0h ago
+		at := now.Add(time.Duration(i-4) * time.Hour)
+		require.NoError(t, c.AddToHistory(ctx, "BTCUSDT", price, at))
+	}
+
+	latest, err := c.GetHistory(ctx, "BTCUSDT", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []PriceHistoryEntry{{now.Unix(), 110}, {now.Add(-time.Hour).Unix(), 106}}, latest)
+
+	ranged, err := c.GetHistoryRange(ctx, "BTCUSDT", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []PriceHistoryEntry{{now.Add(-3 * time.Hour).Unix(), 102}, {now.Add(-2 * time.Hour).Unix(), 104}}, ranged)
+
+	change, err := c.GetPriceChange(ctx, "BTCUSDT", 2*time.Hour)
+	require.NoError(t, err)
+	assert.InDelta(t, 5.77, change, 0.01, "from the price 2h ago")
+
+	_, err = c.GetPriceChange(ctx, "BTCUSDT", 7*24*time.Hour)
+	assert.ErrorIs(t, err, ErrInsufficientHistory)
+
+	// Points past the retention are trimmed on the next add
+	require.NoError(t, c.AddToHistory(ctx, "BTCUSDT", 111, now.Add(24*time.Hour)))
+	all, err := c.GetHistory(ctx, "BTCUSDT", 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestPriceCache_MigrateHistoryLists(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestPriceCache(t)
+
+	now := time.Now().Unix()
+	_, err := mr.Lpush(priceHistoryPrefix+"ETHUSDT", fmt.Sprintf(`{"t":%d,"p":3400.000000}`, now-60))
+	require.NoError(t, err)
+	_, err = mr.Lpush(priceHistoryPrefix+"ETHUSDT", fmt.Sprintf(`{"t":%d,"p":3500.000000}`, now))
+	require.NoError(t, err)
+
+	migrated, err := c.MigrateHistoryLists(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.False(t, mr.Exists(priceHistoryPrefix+"ETHUSDT"))
+
+	history, err := c.GetHistory(ctx, "ETHUSDT", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []PriceHistoryEntry{{now, 3500}, {now - 60, 3400}}, history)
+}
+
+func TestPriceCache_GetPriceChangeInterpolates(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestPriceCache(t)
+
+	now := time.Now()
+	require.NoError(t, c.AddToHistory(ctx, "BTCUSDT", 100, now.Add(-4*time.Hour)))
+	require.NoError(t, c.AddToHistory(ctx, "BTCUSDT", 104, now.Add(-2*time.Hour)))
+	require.NoError(t, c.AddToHistory(ctx, "BTCUSDT", 110, now))
+
+	// 102 three hours ago, halfway between the points either side
+	change, err := c.GetPriceChange(ctx, "BTCUSDT", 3*time.Hour)
+	require.NoError(t, err)
+	assert.InDelta(t, 7.84, change, 0.01)
+
+	_, err = c.GetPriceChange(ctx, "ETHUSDT", time.Hour)
+	assert.ErrorIs(t, err, ErrInsufficientHistory)
+}
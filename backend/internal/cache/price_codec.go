@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Cached prices start with a version byte saying how the rest is encoded.
+// Prices cached before versioning are JSON objects, recognised by their
+// opening brace, and are still read
+const (
+	priceEncodingJSON    byte = '{'
+	priceEncodingProtoV1 byte = 0x01
+)
+
+// Field numbers of the protobuf encoding, as if generated from
+//
+//	message PriceData {
+//	  string symbol = 1;
+//	  double price = 2;
+//	  double price_change = 3;
+//	  double change_percent = 4;
+//	  double high_24h = 5;
+//	  double low_24h = 6;
+//	  double volume_24h = 7;
+//	  double quote_volume = 8;
+//	  int64 updated_at_unix_nano = 9;
+//	}
+//
+// Numbers must never be reused; readers skip fields they do not know
+const (
+	priceFieldSymbol        protowire.Number = 1
+	priceFieldPrice         protowire.Number = 2
+	priceFieldPriceChange   protowire.Number = 3
+	priceFieldChangePercent protowire.Number = 4
+	priceFieldHigh24h       protowire.Number = 5
+	priceFieldLow24h        protowire.Number = 6
+	priceFieldVolume24h     protowire.Number = 7
+	priceFieldQuoteVolume   protowire.Number = 8
+	priceFieldUpdatedAt     protowire.Number = 9
+)
+
+var errUnknownPriceEncoding = errors.New("unknown price encoding")
+
+// encodePrice encodes a price for the cache, about a third of its JSON size
+func encodePrice(data binance.PriceData) []byte {
+	b := make([]byte, 1, 96)
+	b[0] = priceEncodingProtoV1
+
+	if data.Symbol != "" {
+		b = protowire.AppendTag(b, priceFieldSymbol, protowire.BytesType)
+		b = protowire.AppendString(b, data.Symbol)
+	}
+	doubles := []struct {
+		num   protowire.Number
+		value float64
+	}{
+		{priceFieldPrice, data.Price},
+		{priceFieldPriceChange, data.PriceChange},
+		{priceFieldChangePercent, data.ChangePercent},
+		{priceFieldHigh24h, data.High24h},
+		{priceFieldLow24h, data.Low24h},
+		{priceFieldVolume24h, data.Volume24h},
+		{priceFieldQuoteVolume, data.QuoteVolume},
+	}
+	for _, d := range doubles {
+		if d.value == 0 {
+			continue
+		}
+		b = protowire.AppendTag(b, d.num, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(d.value))
+	}
+	if !data.UpdatedAt.IsZero() {
+		b = protowire.AppendTag(b, priceFieldUpdatedAt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(data.UpdatedAt.UnixNano()))
+	}
+
+	return b
+}
+
+// decodePrice decodes a cached price in any encoding written so far
+func decodePrice(b []byte) (binance.PriceData, error) {
+	var data binance.PriceData
+	if len(b) == 0 {
+		return data, errUnknownPriceEncoding
+	}
+
+	switch b[0] {
+	case priceEncodingJSON:
+		err := json.Unmarshal(b, &data)
+		return data, err
+	case priceEncodingProtoV1:
+		return decodePriceProto(b[1:])
+	default:
+		return data, fmt.Errorf("%w: version %d", errUnknownPriceEncoding, b[0])
+	}
+}
+
+func decodePriceProto(b []byte) (binance.PriceData, error) {
+	var data binance.PriceData
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return data, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		field := priceDouble(&data, num)
+		switch {
+		case num == priceFieldSymbol && typ == protowire.BytesType:
+			data.Symbol, n = protowire.ConsumeString(b)
+		case field != nil && typ == protowire.Fixed64Type:
+			var bits uint64
+			bits, n = protowire.ConsumeFixed64(b)
+			*field = math.Float64frombits(bits)
+		case num == priceFieldUpdatedAt && typ == protowire.VarintType:
+			var nanos uint64
+			nanos, n = protowire.ConsumeVarint(b)
+			data.UpdatedAt = time.Unix(0, int64(nanos)).UTC()
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return data, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	return data, nil
+}
+
+// priceDouble returns the field of data encoded as double field num
+func priceDouble(data *binance.PriceData, num protowire.Number) *float64 {
+	switch num {
+	case priceFieldPrice:
+		return &data.Price
+	case priceFieldPriceChange:
+		return &data.PriceChange
+	case priceFieldChangePercent:
+		return &data.ChangePercent
+	case priceFieldHigh24h:
+		return &data.High24h
+	case priceFieldLow24h:
+		return &data.Low24h
+	case priceFieldVolume24h:
+		return &data.Volume24h
+	case priceFieldQuoteVolume:
+		return &data.QuoteVolume
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+)
+
+func testPriceData() binance.PriceData {
+	return binance.PriceData{
+		Symbol:        "BTCUSDT",
+		Price:         70123.45,
+		PriceChange:   -812.5,
+		ChangePercent: -1.145,
+		High24h:       71500,
+		Low24h:        69420.01,
+		Volume24h:     18234.567,
+		QuoteVolume:   1.2787e9,
+		UpdatedAt:     time.Date(2026, 5, 1, 12, 0, 0, 123456789, time.UTC),
+	}
+}
+
+func TestPriceCodec_RoundTrip(t *testing.T) {
+	data := testPriceData()
+
+	encoded := encodePrice(data)
+	decoded, err := decodePrice(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	jsonData, err := json.Marshal(data)
+	require.NoError(t, err)
+	assert.Less(t, len(encoded), len(jsonData)/2)
+}
+
+func TestPriceCodec_ReadsJSON(t *testing.T) {
+	data := testPriceData()
+	jsonData, err := json.Marshal(data)
+	require.NoError(t, err)
+
+	decoded, err := decodePrice(jsonData)
+	require.NoError(t, err)
+	assert.Equal(t, data.Price, decoded.Price)
+	assert.True(t, data.UpdatedAt.Equal(decoded.UpdatedAt))
+
+	_, err = decodePrice([]byte{0x7f, 0x01})
+	assert.ErrorIs(t, err, errUnknownPriceEncoding)
+}
+
+// Compare with: go test ./internal/cache -bench PriceCodec -benchmem
+func BenchmarkPriceCodec_JSON(b *testing.B) {
+	data := testPriceData()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encoded, _ := json.Marshal(data)
+		var decoded binance.PriceData
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPriceCodec_Proto(b *testing.B) {
+	data := testPriceData()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodePrice(encodePrice(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package chart draws small price charts attached to alert notifications
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// Default size of a chart, wide enough to read in a Telegram photo preview
+const (
+	DefaultWidth  = 640
+	DefaultHeight = 320
+)
+
+// padding keeps the line off the image edges
+const padding = 16
+
+var (
+	background = color.RGBA{R: 0x17, G: 0x21, B: 0x2b, A: 0xff}
+	rising     = color.RGBA{R: 0x2e, G: 0xbd, B: 0x85, A: 0xff}
+	falling    = color.RGBA{R: 0xf6, G: 0x46, B: 0x5d, A: 0xff}
+)
+
+// ErrNotEnoughPoints is returned for fewer than two prices
+var ErrNotEnoughPoints = errors.New("chart needs at least two prices")
+
+// PNG draws prices, oldest first, as a line chart encoded as PNG. The line
+// is green when the last price is at least the first, red otherwise
+func PNG(prices []float64, width, height int) ([]byte, error) {
+	if len(prices) < 2 {
+		return nil, ErrNotEnoughPoints
+	}
+
+	low, high := prices[0], prices[0]
+	for _, p := range prices {
+		low = math.Min(low, p)
+		high = math.Max(high, p)
+	}
+	if high == low {
+		// A flat line in the middle
+		low, high = low-1, high+1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	line := rising
+	if prices[len(prices)-1] < prices[0] {
+		line = falling
+	}
+	fill := color.RGBA{R: line.R / 4, G: line.G / 4, B: line.B / 4, A: 0xff}
+
+	// Map a point to pixel coordinates
+	plotW := float64(width - 2*padding)
+	plotH := float64(height - 2*padding)
+	point := func(i int) (float64, float64) {
+		x := padding + plotW*float64(i)/float64(len(prices)-1)
+		y := padding + plotH*(high-prices[i])/(high-low)
+		return x, y
+	}
+
+	// Area under the line, then the line on top of it
+	for i := 1; i < len(prices); i++ {
+		x0, y0 := point(i - 1)
+		x1, y1 := point(i)
+		for x := int(x0); x <= int(x1); x++ {
+			t := 0.0
+			if x1 > x0 {
+				t = (float64(x) - x0) / (x1 - x0)
+			}
+			y := int(y0 + (y1-y0)*t)
+			for yy := y; yy < height-padding; yy++ {
+				img.SetRGBA(x, yy, fill)
+			}
+		}
+	}
+	for i := 1; i < len(prices); i++ {
+		x0, y0 := point(i - 1)
+		x1, y1 := point(i)
+		drawLine(img, x0, y0, x1, y1, line)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a 3px wide segment by stamping squares along it
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / float64(steps)
+		x := int(math.Round(x0 + (x1-x0)*t))
+		y := int(math.Round(y0 + (y1-y0)*t))
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+}
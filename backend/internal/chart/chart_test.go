@@ -0,0 +1,30 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	data, err := PNG([]float64{100, 104, 98, 110}, DefaultWidth, DefaultHeight)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWidth, img.Bounds().Dx())
+	assert.Equal(t, DefaultHeight, img.Bounds().Dy())
+
+	// The last point sits at the top right, drawn in the rising color
+	r, g, b, _ := img.At(DefaultWidth-padding, padding).RGBA()
+	assert.Equal(t, [3]uint32{uint32(rising.R), uint32(rising.G), uint32(rising.B)}, [3]uint32{r >> 8, g >> 8, b >> 8})
+
+	_, err = PNG([]float64{5, 5}, 100, 50)
+	assert.NoError(t, err, "flat prices")
+
+	_, err = PNG([]float64{100}, 100, 50)
+	assert.ErrorIs(t, err, ErrNotEnoughPoints)
+}
@@ -0,0 +1,157 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// categoryCoinsLimit is the number of top coins linked to each category
+	categoryCoinsLimit = 100
+
+	// categoryRequestDelay spaces the per-category requests to respect
+	// rate limits
+	categoryRequestDelay = 1500 * time.Millisecond
+)
+
+// trackedCategory is a category row that is kept in sync with CoinGecko
+type trackedCategory struct {
+	ID          string
+	CoinGeckoID string
+}
+
+// SyncCategories refreshes category market data and coin membership
+func (s *SyncService) SyncCategories(ctx context.Context) error {
+	tracked, err := s.getTrackedCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("get tracked categories: %w", err)
+	}
+
+	if len(tracked) == 0 {
+		return nil
+	}
+
+	s.logger.Info("starting category sync", slog.Int("categories", len(tracked)))
+
+	// Aggregated market data for all categories comes in a single request
+	categories, err := s.client.GetCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch categories: %w", err)
+	}
+
+	if err := s.updateCategoryStats(ctx, tracked, categories); err != nil {
+		return fmt.Errorf("update category stats: %w", err)
+	}
+
+	for _, category := range tracked {
+		if err := wait(ctx, categoryRequestDelay); err != nil {
+			return err
+		}
+
+		coins, err := s.client.GetCoinsMarketsByCategory(ctx, "usd", category.CoinGeckoID, categoryCoinsLimit, 1)
+		if err != nil {
+			s.logger.Warn("failed to fetch category coins",
+				slog.String("category", category.ID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		// Keep existing membership rather than wiping it on an empty response
+		if len(coins) == 0 {
+			continue
+		}
+
+		if err := s.replaceCategoryCoins(ctx, category.ID, coins); err != nil {
+			s.logger.Warn("failed to update category coins",
+				slog.String("category", category.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	s.logger.Info("category sync completed")
+	return nil
+}
+
+// getTrackedCategories returns categories configured in the database
+func (s *SyncService) getTrackedCategories(ctx context.Context) ([]trackedCategory, error) {
+	rows, err := s.pool.Query(ctx, `SELECT id, coingecko_id FROM categories`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []trackedCategory
+	for rows.Next() {
+		var c trackedCategory
+		if err := rows.Scan(&c.ID, &c.CoinGeckoID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+// updateCategoryStats stores aggregated market data for tracked categories
+func (s *SyncService) updateCategoryStats(ctx context.Context, tracked []trackedCategory, categories []Category) error {
+	byID := make(map[string]Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	for _, t := range tracked {
+		c, ok := byID[t.CoinGeckoID]
+		if !ok {
+			continue
+		}
+
+		_, err := s.pool.Exec(ctx, `
+			UPDATE categories
+			SET market_cap = $2, market_cap_change_24h = $3, volume_24h = $4, last_updated = NOW()
+			WHERE id = $1
+		`, t.ID, c.MarketCap, c.MarketCapChange24h, c.Volume24h)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceCategoryCoins replaces category membership with the given coins
+// Coins are matched by CoinGecko ID since tickers are not unique; coins
+// that are not in the coins table are skipped
+func (s *SyncService) replaceCategoryCoins(ctx context.Context, categoryID string, coins []CoinMarket) error {
+	ids := make([]string, len(coins))
+	for i, coin := range coins {
+		ids[i] = coin.ID
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM coin_categories WHERE category_id = $1`, categoryID); err != nil {
+		return fmt.Errorf("delete category coins: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO coin_categories (coin_id, category_id)
+		SELECT id, $1 FROM coins WHERE coingecko_id = ANY($2)
+		ON CONFLICT DO NOTHING
+	`, categoryID, ids); err != nil {
+		return fmt.Errorf("insert category coins: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -8,17 +8,19 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 const (
-	baseURL        = "https://api.coingecko.com/api/v3"
+	defaultBaseURL = "https://api.coingecko.com/api/v3"
 	defaultTimeout = 30 * time.Second
 )
 
 // Client is a CoinGecko API client
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 	apiKey     string
 	logger     *slog.Logger
 }
@@ -29,8 +31,29 @@ func NewClient(apiKey string, logger *slog.Logger) *Client {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
-		apiKey: apiKey,
-		logger: logger,
+		baseURL: defaultBaseURL,
+		apiKey:  apiKey,
+		logger:  logger,
+	}
+}
+
+// SetBaseURL points the client at another API endpoint, such as a fake
+// CoinGecko server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// wait pauses for d between requests to respect rate limits. It returns
+// early with the context's error when ctx is done
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
 
@@ -61,6 +84,16 @@ type CoinMarket struct {
 // perPage: 1-250
 // page: 1, 2, 3...
 func (c *Client) GetCoinsMarkets(ctx context.Context, vsCurrency string, perPage, page int) ([]CoinMarket, error) {
+	return c.getCoinsMarkets(ctx, vsCurrency, "", perPage, page)
+}
+
+// GetCoinsMarketsByCategory fetches coin market data for a CoinGecko category
+func (c *Client) GetCoinsMarketsByCategory(ctx context.Context, vsCurrency, category string, perPage, page int) ([]CoinMarket, error) {
+	return c.getCoinsMarkets(ctx, vsCurrency, category, perPage, page)
+}
+
+// getCoinsMarkets fetches coin market data, optionally filtered by category
+func (c *Client) getCoinsMarkets(ctx context.Context, vsCurrency, category string, perPage, page int) ([]CoinMarket, error) {
 	params := url.Values{}
 	params.Set("vs_currency", vsCurrency)
 	params.Set("order", "market_cap_desc")
@@ -68,12 +101,47 @@ func (c *Client) GetCoinsMarkets(ctx context.Context, vsCurrency string, perPage
 	params.Set("page", fmt.Sprintf("%d", page))
 	params.Set("sparkline", "false")
 	params.Set("price_change_percentage", "24h")
+	if category != "" {
+		params.Set("category", category)
+	}
+
+	endpoint := fmt.Sprintf("%s/coins/markets?%s", c.baseURL, params.Encode())
+
+	var coins []CoinMarket
+	if err := c.get(ctx, endpoint, &coins); err != nil {
+		return nil, err
+	}
+
+	return coins, nil
+}
+
+// Category represents a CoinGecko category with aggregated market data
+type Category struct {
+	ID                 string  `json:"id"`
+	Name               string  `json:"name"`
+	MarketCap          float64 `json:"market_cap"`
+	MarketCapChange24h float64 `json:"market_cap_change_24h"`
+	Volume24h          float64 `json:"volume_24h"`
+	UpdatedAt          string  `json:"updated_at"`
+}
+
+// GetCategories fetches all CoinGecko categories with market data
+func (c *Client) GetCategories(ctx context.Context) ([]Category, error) {
+	endpoint := fmt.Sprintf("%s/coins/categories?order=market_cap_desc", c.baseURL)
 
-	endpoint := fmt.Sprintf("%s/coins/markets?%s", baseURL, params.Encode())
+	var categories []Category
+	if err := c.get(ctx, endpoint, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
 
+// get performs a GET request and decodes the JSON response into out
+func (c *Client) get(ctx context.Context, endpoint string, out interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return fmt.Errorf("create request: %w", err)
 	}
 
 	// Add API key header if available (for higher rate limits)
@@ -84,25 +152,64 @@ func (c *Client) GetCoinsMarkets(ctx context.Context, vsCurrency string, perPage
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+		return fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, fmt.Errorf("rate limit exceeded")
+		return fmt.Errorf("rate limit exceeded")
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var coins []CoinMarket
-	if err := json.NewDecoder(resp.Body).Decode(&coins); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
 	}
 
-	return coins, nil
+	return nil
+}
+
+// CoinDetail is the description, links and supply of a coin from
+// CoinGecko's coin endpoint. Supplies are nil when unknown
+type CoinDetail struct {
+	ID          string `json:"id"`
+	Symbol      string `json:"symbol"`
+	Description struct {
+		En string `json:"en"`
+	} `json:"description"`
+	Links struct {
+		Homepage       []string `json:"homepage"`
+		BlockchainSite []string `json:"blockchain_site"`
+	} `json:"links"`
+	MarketData struct {
+		CirculatingSupply *float64 `json:"circulating_supply"`
+		TotalSupply       *float64 `json:"total_supply"`
+		MaxSupply         *float64 `json:"max_supply"`
+	} `json:"market_data"`
+}
+
+// GetCoinDetail fetches the details of a coin by its CoinGecko ID, without
+// tickers and community or developer data
+func (c *Client) GetCoinDetail(ctx context.Context, id string) (*CoinDetail, error) {
+	params := url.Values{}
+	params.Set("localization", "false")
+	params.Set("tickers", "false")
+	params.Set("market_data", "true")
+	params.Set("community_data", "false")
+	params.Set("developer_data", "false")
+	params.Set("sparkline", "false")
+
+	endpoint := fmt.Sprintf("%s/coins/%s?%s", c.baseURL, url.PathEscape(id), params.Encode())
+
+	var detail CoinDetail
+	if err := c.get(ctx, endpoint, &detail); err != nil {
+		return nil, err
+	}
+
+	return &detail, nil
 }
 
 // GlobalData represents global market data
@@ -119,120 +226,12 @@ type GlobalData struct {
 
 // GetGlobalData fetches global market data
 func (c *Client) GetGlobalData(ctx context.Context) (*GlobalData, error) {
-	endpoint := fmt.Sprintf("%s/global", baseURL)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	if c.apiKey != "" {
-		req.Header.Set("x-cg-demo-api-key", c.apiKey)
-	}
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, fmt.Errorf("rate limit exceeded")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-	}
+	endpoint := fmt.Sprintf("%s/global", c.baseURL)
 
 	var data GlobalData
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if err := c.get(ctx, endpoint, &data); err != nil {
+		return nil, err
 	}
 
 	return &data, nil
 }
-
-// BinanceSymbolMap maps common symbols to Binance trading pairs
-var BinanceSymbolMap = map[string]string{
-	"btc":   "BTCUSDT",
-	"eth":   "ETHUSDT",
-	"bnb":   "BNBUSDT",
-	"sol":   "SOLUSDT",
-	"xrp":   "XRPUSDT",
-	"doge":  "DOGEUSDT",
-	"ada":   "ADAUSDT",
-	"avax":  "AVAXUSDT",
-	"shib":  "SHIBUSDT",
-	"dot":   "DOTUSDT",
-	"link":  "LINKUSDT",
-	"trx":   "TRXUSDT",
-	"matic": "MATICUSDT",
-	"uni":   "UNIUSDT",
-	"ltc":   "LTCUSDT",
-	"atom":  "ATOMUSDT",
-	"xlm":   "XLMUSDT",
-	"etc":   "ETCUSDT",
-	"fil":   "FILUSDT",
-	"apt":   "APTUSDT",
-	"near":  "NEARUSDT",
-	"arb":   "ARBUSDT",
-	"op":    "OPUSDT",
-	"sui":   "SUIUSDT",
-	"sei":   "SEIUSDT",
-	"inj":   "INJUSDT",
-	"ftm":   "FTMUSDT",
-	"algo":  "ALGOUSDT",
-	"vet":   "VETUSDT",
-	"sand":  "SANDUSDT",
-	"mana":  "MANAUSDT",
-	"axs":   "AXSUSDT",
-	"aave":  "AAVEUSDT",
-	"mkr":   "MKRUSDT",
-	"snx":   "SNXUSDT",
-	"crv":   "CRVUSDT",
-	"comp":  "COMPUSDT",
-	"ldo":   "LDOUSDT",
-	"rpl":   "RPLUSDT",
-	"grt":   "GRTUSDT",
-	"ens":   "ENSUSDT",
-	"pepe":  "PEPEUSDT",
-	"wif":   "WIFUSDT",
-	"bonk":  "BONKUSDT",
-	"floki": "FLOKIUSDT",
-	"ton":   "TONUSDT",
-	"kas":   "KASUSDT",
-	"ren":   "RENUSDT",
-	"1inch": "1INCHUSDT",
-	"sushi": "SUSHIUSDT",
-	"cake":  "CAKEUSDT",
-}
-
-// GetBinanceSymbol returns Binance trading pair for a symbol
-func GetBinanceSymbol(symbol string) string {
-	if binanceSymbol, ok := BinanceSymbolMap[symbol]; ok {
-		return binanceSymbol
-	}
-	// Default: uppercase symbol + USDT
-	return fmt.Sprintf("%sUSDT", symbol)
-}
-
-// IsStablecoin checks if a symbol is a stablecoin
-func IsStablecoin(symbol string) bool {
-	stablecoins := map[string]bool{
-		"usdt":  true,
-		"usdc":  true,
-		"dai":   true,
-		"busd":  true,
-		"tusd":  true,
-		"usdp":  true,
-		"frax":  true,
-		"usdd":  true,
-		"gusd":  true,
-		"paxg":  true,
-		"xaut":  true,
-		"fdusd": true,
-	}
-	return stablecoins[symbol]
-}
@@ -0,0 +1,20 @@
+package coingecko
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWait(t *testing.T) {
+	assert.NoError(t, wait(context.Background(), time.Millisecond))
+
+	// A cancelled context ends the pause right away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	assert.ErrorIs(t, wait(ctx, time.Hour), context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,166 @@
+package coingecko
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	marketOverviewKey = "market:overview"
+	// Keep the snapshot well past the refresh interval so a few failed
+	// refreshes still serve stale data instead of nothing
+	marketOverviewTTL = 6 * time.Hour
+	fearGreedURL      = "https://api.alternative.me/fng/?limit=1"
+
+	// Fear & Greed served until the index is first fetched, rather than
+	// zero which reads as extreme fear
+	neutralFearGreedValue          = 50
+	neutralFearGreedClassification = "Neutral"
+)
+
+// MarketOverview is the cached global market snapshot
+type MarketOverview struct {
+	TotalMarketCap          float64   `json:"total_market_cap"`
+	TotalVolume24h          float64   `json:"total_volume_24h"`
+	BTCDominance            float64   `json:"btc_dominance"`
+	ETHDominance            float64   `json:"eth_dominance"`
+	MarketCapChange24hPct   float64   `json:"market_cap_change_24h_pct"`
+	ActiveCryptocurrencies  int       `json:"active_cryptocurrencies"`
+	FearGreedValue          int       `json:"fear_greed_value"`
+	FearGreedClassification string    `json:"fear_greed_classification"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// GlobalSyncService periodically refreshes global market data into Redis
+type GlobalSyncService struct {
+	client       *Client
+	redis        *redis.Client
+	httpClient   *http.Client
+	fearGreedURL string
+	logger       *slog.Logger
+}
+
+// NewGlobalSyncService creates a new global market data sync service
+func NewGlobalSyncService(client *Client, redisClient *redis.Client, logger *slog.Logger) *GlobalSyncService {
+	return &GlobalSyncService{
+		client: client,
+		redis:  redisClient,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		fearGreedURL: fearGreedURL,
+		logger:       logger,
+	}
+}
+
+// SyncGlobal fetches global market data and Fear & Greed index and stores the snapshot
+func (s *GlobalSyncService) SyncGlobal(ctx context.Context) error {
+	global, err := s.client.GetGlobalData(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch global data: %w", err)
+	}
+
+	overview := MarketOverview{
+		TotalMarketCap:         global.Data.TotalMarketCap["usd"],
+		TotalVolume24h:         global.Data.TotalVolume["usd"],
+		BTCDominance:           global.Data.MarketCapPercentage["btc"],
+		ETHDominance:           global.Data.MarketCapPercentage["eth"],
+		MarketCapChange24hPct:  global.Data.MarketCapChangePercentage24hUSD,
+		ActiveCryptocurrencies: global.Data.ActiveCryptocurrencies,
+		UpdatedAt:              time.Now().UTC(),
+	}
+
+	// Fear & Greed is best effort - keep the previous value if the API
+	// fails, or neutral until it has been fetched once
+	value, classification, err := s.fetchFearGreedIndex(ctx)
+	if err != nil {
+		s.logger.Warn("failed to fetch fear & greed index", slog.String("error", err.Error()))
+		value, classification = neutralFearGreedValue, neutralFearGreedClassification
+		if prev, _ := s.GetOverview(ctx); prev != nil && prev.FearGreedClassification != "" {
+			value, classification = prev.FearGreedValue, prev.FearGreedClassification
+		}
+	}
+	overview.FearGreedValue = value
+	overview.FearGreedClassification = classification
+
+	return s.store(ctx, &overview)
+}
+
+// GetOverview returns the cached snapshot, or nil if none is available yet
+func (s *GlobalSyncService) GetOverview(ctx context.Context) (*MarketOverview, error) {
+	data, err := s.redis.Get(ctx, marketOverviewKey).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get market overview: %w", err)
+	}
+
+	var overview MarketOverview
+	if err := json.Unmarshal(data, &overview); err != nil {
+		return nil, fmt.Errorf("unmarshal market overview: %w", err)
+	}
+
+	return &overview, nil
+}
+
+// store writes the snapshot to Redis
+func (s *GlobalSyncService) store(ctx context.Context, overview *MarketOverview) error {
+	data, err := json.Marshal(overview)
+	if err != nil {
+		return fmt.Errorf("marshal market overview: %w", err)
+	}
+
+	if err := s.redis.Set(ctx, marketOverviewKey, data, marketOverviewTTL).Err(); err != nil {
+		return fmt.Errorf("set market overview: %w", err)
+	}
+
+	return nil
+}
+
+// fetchFearGreedIndex fetches the Fear & Greed Index from alternative.me API
+func (s *GlobalSyncService) fetchFearGreedIndex(ctx context.Context) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.fearGreedURL, nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data []struct {
+			Value               string `json:"value"`
+			ValueClassification string `json:"value_classification"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, "", fmt.Errorf("decode response: %w", err)
+	}
+
+	if len(result.Data) == 0 {
+		return 0, "", fmt.Errorf("empty fear & greed response")
+	}
+
+	value, err := strconv.Atoi(result.Data[0].Value)
+	if err != nil {
+		return 0, "", fmt.Errorf("parse fear & greed value: %w", err)
+	}
+
+	return value, result.Data[0].ValueClassification, nil
+}
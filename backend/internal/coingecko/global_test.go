@@ -0,0 +1,78 @@
+package coingecko
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalSyncService_SyncGlobal(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	var fearGreedUp atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/global":
+			_, _ = io.WriteString(w, `{"data":{"active_cryptocurrencies":12000,"total_market_cap":{"usd":2.5e12},`+
+				`"total_volume":{"usd":9e10},"market_cap_percentage":{"btc":54.2,"eth":17.1},`+
+				`"market_cap_change_percentage_24h_usd":-1.5}}`)
+		case "/fng/":
+			if !fearGreedUp.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = io.WriteString(w, `{"data":[{"value":"72","value_classification":"Greed"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient("", logger)
+	client.SetBaseURL(srv.URL)
+	svc := NewGlobalSyncService(client, rdb, logger)
+	svc.fearGreedURL = srv.URL + "/fng/"
+
+	overview, err := svc.GetOverview(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, overview, "nothing cached before the first sync")
+
+	// Index unavailable from the start: neutral, not extreme fear
+	require.NoError(t, svc.SyncGlobal(ctx))
+	overview, err = svc.GetOverview(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, overview)
+	assert.Equal(t, 2.5e12, overview.TotalMarketCap)
+	assert.Equal(t, 54.2, overview.BTCDominance)
+	assert.Equal(t, neutralFearGreedValue, overview.FearGreedValue)
+	assert.Equal(t, neutralFearGreedClassification, overview.FearGreedClassification)
+
+	fearGreedUp.Store(true)
+	require.NoError(t, svc.SyncGlobal(ctx))
+	overview, err = svc.GetOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 72, overview.FearGreedValue)
+	assert.Equal(t, "Greed", overview.FearGreedClassification)
+
+	// A later failure keeps the last fetched index
+	fearGreedUp.Store(false)
+	require.NoError(t, svc.SyncGlobal(ctx))
+	overview, err = svc.GetOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 72, overview.FearGreedValue)
+}
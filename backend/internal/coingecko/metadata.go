@@ -0,0 +1,179 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// MetadataRefreshInterval is how old coin metadata gets before it is
+	// fetched again; descriptions and links rarely change
+	MetadataRefreshInterval = 7 * 24 * time.Hour
+
+	// Explorer links kept per coin
+	maxExplorerURLs = 3
+
+	// metadataRequestDelay spaces the per-coin requests to respect rate
+	// limits
+	metadataRequestDelay = 1500 * time.Millisecond
+)
+
+// htmlTag matches the links CoinGecko embeds in descriptions
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// staleCoin is a coin whose metadata is missing or due for a refresh
+type staleCoin struct {
+	ID          int
+	Symbol      string
+	CoinGeckoID string
+}
+
+// SyncMetadata fetches the description, links and supply of up to limit
+// coins whose metadata is missing or older than MetadataRefreshInterval,
+// top ranked first. The coin endpoint takes one request per coin, so the
+// refresh is spread over several runs
+func (s *SyncService) SyncMetadata(ctx context.Context, limit int) error {
+	coins, err := s.getStaleMetadataCoins(ctx, limit)
+	if err != nil {
+		return fmt.Errorf("get stale coins: %w", err)
+	}
+
+	if len(coins) == 0 {
+		return nil
+	}
+
+	s.logger.Info("starting coin metadata sync", slog.Int("coins", len(coins)))
+
+	synced := 0
+	for i, coin := range coins {
+		// Respect rate limits - wait between requests
+		if i > 0 {
+			if err := wait(ctx, metadataRequestDelay); err != nil {
+				return err
+			}
+		}
+
+		detail, err := s.client.GetCoinDetail(ctx, coin.CoinGeckoID)
+		if err != nil {
+			s.logger.Warn("failed to fetch coin metadata",
+				slog.String("symbol", coin.Symbol),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		if err := s.upsertMetadata(ctx, coin.ID, detail); err != nil {
+			s.logger.Warn("failed to store coin metadata",
+				slog.String("symbol", coin.Symbol),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		synced++
+	}
+
+	s.logger.Info("coin metadata sync completed", slog.Int("synced", synced))
+	return nil
+}
+
+// getStaleMetadataCoins returns active coins known to CoinGecko whose
+// metadata is missing or due for a refresh
+func (s *SyncService) getStaleMetadataCoins(ctx context.Context, limit int) ([]staleCoin, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.id, c.symbol, c.coingecko_id
+		FROM coins c
+		LEFT JOIN coin_metadata m ON m.coin_id = c.id
+		WHERE c.is_active = true AND c.coingecko_id IS NOT NULL
+		  AND (m.coin_id IS NULL OR m.updated_at < $1)
+		ORDER BY m.updated_at NULLS FIRST, c.rank_by_market_cap NULLS LAST
+		LIMIT $2
+	`, time.Now().Add(-MetadataRefreshInterval), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coins []staleCoin
+	for rows.Next() {
+		var c staleCoin
+		if err := rows.Scan(&c.ID, &c.Symbol, &c.CoinGeckoID); err != nil {
+			return nil, err
+		}
+		coins = append(coins, c)
+	}
+
+	return coins, rows.Err()
+}
+
+// upsertMetadata stores the metadata of a coin
+func (s *SyncService) upsertMetadata(ctx context.Context, coinID int, detail *CoinDetail) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO coin_metadata (
+			coin_id, description, homepage_url, explorer_urls,
+			circulating_supply, total_supply, max_supply, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (coin_id) DO UPDATE SET
+			description = EXCLUDED.description,
+			homepage_url = EXCLUDED.homepage_url,
+			explorer_urls = EXCLUDED.explorer_urls,
+			circulating_supply = EXCLUDED.circulating_supply,
+			total_supply = EXCLUDED.total_supply,
+			max_supply = EXCLUDED.max_supply,
+			updated_at = NOW()
+	`,
+		coinID,
+		nilIfBlank(plainDescription(detail.Description.En)),
+		nilIfBlank(firstURL(detail.Links.Homepage)),
+		explorerURLs(detail.Links.BlockchainSite),
+		detail.MarketData.CirculatingSupply,
+		detail.MarketData.TotalSupply,
+		detail.MarketData.MaxSupply,
+	)
+	return err
+}
+
+// plainDescription turns a CoinGecko description, which holds HTML links
+// and entities, into plain text
+func plainDescription(description string) string {
+	text := html.UnescapeString(htmlTag.ReplaceAllString(description, ""))
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.TrimSpace(text)
+}
+
+// firstURL returns the first non-empty URL; CoinGecko pads link lists with
+// empty strings
+func firstURL(urls []string) string {
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// explorerURLs returns up to maxExplorerURLs non-empty explorer links
+func explorerURLs(urls []string) []string {
+	explorers := []string{}
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			explorers = append(explorers, u)
+		}
+		if len(explorers) == maxExplorerURLs {
+			break
+		}
+	}
+	return explorers
+}
+
+// nilIfBlank returns nil for an empty string, to store it as NULL
+func nilIfBlank(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,27 @@
+package coingecko
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainDescription(t *testing.T) {
+	assert.Equal(t,
+		"Bitcoin is the first decentralized cryptocurrency.\n\nIt was created by Satoshi Nakamoto & others.",
+		plainDescription(`Bitcoin is the first <a href="https://www.coingecko.com/en?category=cryptocurrency">decentralized cryptocurrency</a>.`+
+			"\r\n\r\nIt was created by Satoshi Nakamoto &amp; others.\r\n"),
+	)
+	assert.Empty(t, plainDescription(""))
+}
+
+func TestLinks(t *testing.T) {
+	assert.Equal(t, "https://bitcoin.org", firstURL([]string{"", " https://bitcoin.org ", "https://other.org"}))
+	assert.Empty(t, firstURL([]string{"", ""}))
+
+	assert.Equal(t,
+		[]string{"https://a.io", "https://b.io", "https://c.io"},
+		explorerURLs([]string{"https://a.io", "", "https://b.io", "https://c.io", "https://d.io"}),
+	)
+	assert.Equal(t, []string{}, explorerURLs(nil))
+}
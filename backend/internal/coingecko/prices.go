@@ -0,0 +1,60 @@
+package coingecko
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// PricePollInterval is how often prices are polled for coins without a
+	// Binance pair. Kept low-frequency to stay within CoinGecko rate limits
+	PricePollInterval = 60 * time.Second
+
+	// maxIDsPerRequest is the number of coin IDs sent per /simple/price call
+	maxIDsPerRequest = 250
+)
+
+// SimplePrice represents price data from the /simple/price endpoint
+type SimplePrice struct {
+	USD          float64 `json:"usd"`
+	USDMarketCap float64 `json:"usd_market_cap"`
+	USD24hVol    float64 `json:"usd_24h_vol"`
+	USD24hChange float64 `json:"usd_24h_change"`
+	LastUpdated  int64   `json:"last_updated_at"`
+}
+
+// GetSimplePrices fetches USD prices for the given CoinGecko coin IDs
+func (c *Client) GetSimplePrices(ctx context.Context, ids []string) (map[string]SimplePrice, error) {
+	result := make(map[string]SimplePrice, len(ids))
+
+	for start := 0; start < len(ids); start += maxIDsPerRequest {
+		end := start + maxIDsPerRequest
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		params := url.Values{}
+		params.Set("ids", strings.Join(ids[start:end], ","))
+		params.Set("vs_currencies", "usd")
+		params.Set("include_market_cap", "true")
+		params.Set("include_24hr_vol", "true")
+		params.Set("include_24hr_change", "true")
+		params.Set("include_last_updated_at", "true")
+
+		endpoint := fmt.Sprintf("%s/simple/price?%s", c.baseURL, params.Encode())
+
+		var prices map[string]SimplePrice
+		if err := c.get(ctx, endpoint, &prices); err != nil {
+			return nil, err
+		}
+
+		for id, price := range prices {
+			result[id] = price
+		}
+	}
+
+	return result, nil
+}
@@ -8,8 +8,13 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/binance"
 )
 
+// marketsPageDelay spaces the requests for pages of top coins to respect
+// rate limits
+const marketsPageDelay = 1500 * time.Millisecond
+
 // SyncService handles synchronization of coin data from CoinGecko
 type SyncService struct {
 	client *Client
@@ -51,7 +56,9 @@ func (s *SyncService) SyncCoins(ctx context.Context, numCoins int) error {
 
 		// Respect rate limits - wait between requests
 		if page < pages {
-			time.Sleep(1500 * time.Millisecond)
+			if err := wait(ctx, marketsPageDelay); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -68,6 +75,11 @@ func (s *SyncService) SyncCoins(ctx context.Context, numCoins int) error {
 
 // upsertCoins inserts or updates coins in the database
 func (s *SyncService) upsertCoins(ctx context.Context, coins []CoinMarket) error {
+	mappings, err := s.getSymbolMappings(ctx)
+	if err != nil {
+		return fmt.Errorf("get symbol mappings: %w", err)
+	}
+
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
@@ -76,18 +88,40 @@ func (s *SyncService) upsertCoins(ctx context.Context, coins []CoinMarket) error
 
 	for _, coin := range coins {
 		symbol := strings.ToUpper(coin.Symbol)
-		binanceSymbol := GetBinanceSymbol(coin.Symbol)
-		isStablecoin := IsStablecoin(coin.Symbol)
 
-		_, err := tx.Exec(ctx, `
+		// Unmapped coins get the conventional pair until the reconciler checks it
+		var binanceSymbol *string
+		if mapped, ok := mappings[symbol]; ok {
+			binanceSymbol = mapped
+		} else {
+			pair := binance.DefaultPairSymbol(symbol)
+			binanceSymbol = &pair
+		}
+
+		// Each coin in a savepoint, so one rejected row does not abort the
+		// transaction for the rest
+		row, err := tx.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("begin savepoint: %w", err)
+		}
+
+		// Stablecoin and exclusion flags follow the admin-managed coin_exclusions list
+		_, err = row.Exec(ctx, `
 			INSERT INTO coins (
-				symbol, name, binance_symbol, is_stablecoin, rank_by_market_cap,
-				current_price, market_cap, volume_24h, price_change_24h_pct, last_updated
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+				symbol, name, binance_symbol, coingecko_id, is_stablecoin, is_excluded,
+				rank_by_market_cap, current_price, market_cap, volume_24h, price_change_24h_pct, last_updated
+			) VALUES (
+				$1, $2, $3, $4,
+				EXISTS (SELECT 1 FROM coin_exclusions WHERE symbol = $1 AND kind = 'stablecoin'),
+				EXISTS (SELECT 1 FROM coin_exclusions WHERE symbol = $1 AND kind = 'excluded'),
+				$5, $6, $7, $8, $9, NOW()
+			)
 			ON CONFLICT (symbol) DO UPDATE SET
 				name = EXCLUDED.name,
 				binance_symbol = EXCLUDED.binance_symbol,
+				coingecko_id = EXCLUDED.coingecko_id,
 				is_stablecoin = EXCLUDED.is_stablecoin,
+				is_excluded = EXCLUDED.is_excluded,
 				rank_by_market_cap = EXCLUDED.rank_by_market_cap,
 				current_price = EXCLUDED.current_price,
 				market_cap = EXCLUDED.market_cap,
@@ -98,7 +132,7 @@ func (s *SyncService) upsertCoins(ctx context.Context, coins []CoinMarket) error
 			symbol,
 			coin.Name,
 			binanceSymbol,
-			isStablecoin,
+			coin.ID,
 			coin.MarketCapRank,
 			coin.CurrentPrice,
 			coin.MarketCap,
@@ -110,8 +144,14 @@ func (s *SyncService) upsertCoins(ctx context.Context, coins []CoinMarket) error
 				slog.String("symbol", symbol),
 				slog.String("error", err.Error()),
 			)
+			if err := row.Rollback(ctx); err != nil {
+				return fmt.Errorf("roll back savepoint: %w", err)
+			}
 			continue
 		}
+		if err := row.Commit(ctx); err != nil {
+			return fmt.Errorf("release savepoint: %w", err)
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -121,33 +161,39 @@ func (s *SyncService) upsertCoins(ctx context.Context, coins []CoinMarket) error
 	return nil
 }
 
-// StartPeriodicSync starts a goroutine that syncs coins periodically
-func (s *SyncService) StartPeriodicSync(ctx context.Context, numCoins int, interval time.Duration) {
-	// Initial sync
-	go func() {
-		if err := s.SyncCoins(ctx, numCoins); err != nil {
-			s.logger.Error("initial coin sync failed", slog.String("error", err.Error()))
-		}
-	}()
-
-	// Periodic sync
-	ticker := time.NewTicker(interval)
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				ticker.Stop()
-				return
-			case <-ticker.C:
-				if err := s.SyncCoins(ctx, numCoins); err != nil {
-					s.logger.Error("periodic coin sync failed", slog.String("error", err.Error()))
-				}
-			}
+// getSymbolMappings returns the Binance pair for every mapped coin symbol
+// A nil pair means the coin is not (validly) traded on Binance
+func (s *SyncService) getSymbolMappings(ctx context.Context) (map[string]*string, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT symbol, CASE WHEN is_valid = false THEN NULL ELSE binance_symbol END
+		FROM symbol_mappings
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := make(map[string]*string)
+	for rows.Next() {
+		var symbol string
+		var binanceSymbol *string
+		if err := rows.Scan(&symbol, &binanceSymbol); err != nil {
+			return nil, err
 		}
-	}()
+		mappings[symbol] = binanceSymbol
+	}
 
-	s.logger.Info("started periodic coin sync",
-		slog.Int("num_coins", numCoins),
-		slog.Duration("interval", interval),
-	)
+	return mappings, rows.Err()
+}
+
+// Sync syncs the top coins and then their categories
+// Categories link to coins, so they are synced after coins are in place
+func (s *SyncService) Sync(ctx context.Context, numCoins int) error {
+	if err := s.SyncCoins(ctx, numCoins); err != nil {
+		return fmt.Errorf("coin sync: %w", err)
+	}
+	if err := s.SyncCategories(ctx); err != nil {
+		return fmt.Errorf("category sync: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,39 @@
+package coingecko
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncCoins_StopsBetweenPages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Shutdown begins while the first page is served
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cancel()
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient("", logger)
+	client.SetBaseURL(server.URL)
+	sync := NewSyncService(client, nil, logger)
+
+	start := time.Now()
+	err := sync.SyncCoins(ctx, 500)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), marketsPageDelay)
+	assert.Equal(t, int32(1), requests.Load())
+}
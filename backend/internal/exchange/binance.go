@@ -0,0 +1,136 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const binanceBaseURL = "https://api.binance.com"
+
+// binanceClient reads spot balances through Binance's signed REST API
+type binanceClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// binanceRestrictions is the response of /sapi/v1/account/apiRestrictions
+type binanceRestrictions struct {
+	EnableReading              bool `json:"enableReading"`
+	EnableWithdrawals          bool `json:"enableWithdrawals"`
+	EnableInternalTransfer     bool `json:"enableInternalTransfer"`
+	PermitsUniversalTransfer   bool `json:"permitsUniversalTransfer"`
+	EnableSpotAndMarginTrading bool `json:"enableSpotAndMarginTrading"`
+	EnableMargin               bool `json:"enableMargin"`
+	EnableFutures              bool `json:"enableFutures"`
+	EnableVanillaOptions       bool `json:"enableVanillaOptions"`
+}
+
+// binanceAccount is the part of /api/v3/account used for balances
+type binanceAccount struct {
+	Balances []struct {
+		Asset  string `json:"asset"`
+		Free   string `json:"free"`
+		Locked string `json:"locked"`
+	} `json:"balances"`
+}
+
+// CheckReadOnly implements Client
+func (c *binanceClient) CheckReadOnly(ctx context.Context, creds Credentials) error {
+	var r binanceRestrictions
+	if err := c.get(ctx, creds, "/sapi/v1/account/apiRestrictions", nil, &r); err != nil {
+		return err
+	}
+
+	if !r.EnableReading {
+		return fmt.Errorf("%w: reading is not enabled", ErrNotReadOnly)
+	}
+	if r.EnableWithdrawals || r.EnableInternalTransfer || r.PermitsUniversalTransfer ||
+		r.EnableSpotAndMarginTrading || r.EnableMargin || r.EnableFutures || r.EnableVanillaOptions {
+		return ErrNotReadOnly
+	}
+
+	return nil
+}
+
+// Balances implements Client
+func (c *binanceClient) Balances(ctx context.Context, creds Credentials) ([]Balance, error) {
+	params := url.Values{}
+	params.Set("omitZeroBalances", "true")
+
+	var account binanceAccount
+	if err := c.get(ctx, creds, "/api/v3/account", params, &account); err != nil {
+		return nil, err
+	}
+
+	balances := make([]Balance, 0, len(account.Balances))
+	for _, b := range account.Balances {
+		free, _ := strconv.ParseFloat(b.Free, 64)
+		locked, _ := strconv.ParseFloat(b.Locked, 64)
+		if free+locked <= 0 {
+			continue
+		}
+		balances = append(balances, Balance{Asset: b.Asset, Free: free, Locked: locked})
+	}
+
+	return balances, nil
+}
+
+// get performs a signed GET request: the query string, including a
+// timestamp, is signed with the secret and the key goes in a header
+func (c *binanceClient) get(ctx context.Context, creds Credentials, path string, params url.Values, out any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	params.Set("recvWindow", recvWindow)
+	query := params.Encode()
+	query += "&signature=" + sign(creds.APISecret, query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-MBX-APIKEY", creds.APIKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("binance request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+
+		// -2014/-2015: bad key format, invalid key, IP or permissions;
+		// -1022: bad signature
+		switch apiErr.Code {
+		case -2014, -2015, -1022:
+			return ErrInvalidCredentials
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("binance API error: status %d, code %d: %s", resp.StatusCode, apiErr.Code, apiErr.Msg)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const bybitBaseURL = "https://api.bybit.com"
+
+// bybitClient reads unified account balances through Bybit's v5 API
+type bybitClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// bybitResponse is the envelope of every v5 response
+type bybitResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// bybitKeyInfo is the part of /v5/user/query-api used for permissions
+type bybitKeyInfo struct {
+	ReadOnly int `json:"readOnly"` // 1 for read-only keys
+}
+
+// bybitWallet is the part of /v5/account/wallet-balance used for balances
+type bybitWallet struct {
+	List []struct {
+		Coin []struct {
+			Coin          string `json:"coin"`
+			WalletBalance string `json:"walletBalance"`
+			Locked        string `json:"locked"`
+		} `json:"coin"`
+	} `json:"list"`
+}
+
+// CheckReadOnly implements Client
+func (c *bybitClient) CheckReadOnly(ctx context.Context, creds Credentials) error {
+	var info bybitKeyInfo
+	if err := c.get(ctx, creds, "/v5/user/query-api", nil, &info); err != nil {
+		return err
+	}
+
+	if info.ReadOnly != 1 {
+		return ErrNotReadOnly
+	}
+
+	return nil
+}
+
+// Balances implements Client
+func (c *bybitClient) Balances(ctx context.Context, creds Credentials) ([]Balance, error) {
+	params := url.Values{}
+	params.Set("accountType", "UNIFIED")
+
+	var wallet bybitWallet
+	if err := c.get(ctx, creds, "/v5/account/wallet-balance", params, &wallet); err != nil {
+		return nil, err
+	}
+
+	var balances []Balance
+	for _, account := range wallet.List {
+		for _, coin := range account.Coin {
+			total, _ := strconv.ParseFloat(coin.WalletBalance, 64)
+			locked, _ := strconv.ParseFloat(coin.Locked, 64)
+			if total <= 0 {
+				continue
+			}
+			// walletBalance includes the locked amount
+			balances = append(balances, Balance{Asset: coin.Coin, Free: total - locked, Locked: locked})
+		}
+	}
+
+	return balances, nil
+}
+
+// get performs a signed GET request: timestamp, key, receive window and
+// query string are signed with the secret and sent in headers
+func (c *bybitClient) get(ctx context.Context, creds Credentials, path string, params url.Values, out any) error {
+	query := ""
+	if params != nil {
+		query = params.Encode()
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	endpoint := c.baseURL + path
+	if query != "" {
+		endpoint += "?" + query
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-BAPI-API-KEY", creds.APIKey)
+	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+	req.Header.Set("X-BAPI-RECV-WINDOW", recvWindow)
+	req.Header.Set("X-BAPI-SIGN", sign(creds.APISecret, timestamp+creds.APIKey+recvWindow+query))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("bybit request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidCredentials
+	}
+
+	var envelope bybitResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("bybit API error: status %d", resp.StatusCode)
+	}
+
+	// 10003: invalid key, 10004: bad signature, 33004: key expired
+	switch envelope.RetCode {
+	case 0:
+	case 10003, 10004, 33004:
+		return ErrInvalidCredentials
+	default:
+		return fmt.Errorf("bybit API error: code %d: %s", envelope.RetCode, envelope.RetMsg)
+	}
+
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
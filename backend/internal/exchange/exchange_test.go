@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCreds = Credentials{APIKey: "key", APISecret: "secret"}
+
+func TestBinanceClient(t *testing.T) {
+	readOnly := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-MBX-APIKEY"))
+
+		// The signature covers the query string before it
+		query := r.URL.RawQuery
+		i := strings.LastIndex(query, "&signature=")
+		require.Positive(t, i)
+		assert.Equal(t, sign("secret", query[:i]), query[i+len("&signature="):])
+
+		switch r.URL.Path {
+		case "/sapi/v1/account/apiRestrictions":
+			if readOnly {
+				w.Write([]byte(`{"enableReading":true,"enableWithdrawals":false,"enableSpotAndMarginTrading":false}`))
+			} else {
+				w.Write([]byte(`{"enableReading":true,"enableSpotAndMarginTrading":true}`))
+			}
+		case "/api/v3/account":
+			w.Write([]byte(`{"balances":[{"asset":"BTC","free":"0.5","locked":"0.1"},{"asset":"DUST","free":"0","locked":"0"}]}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":-2015,"msg":"Invalid API-key"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := &binanceClient{baseURL: srv.URL, httpClient: srv.Client()}
+	ctx := context.Background()
+
+	require.NoError(t, c.CheckReadOnly(ctx, testCreds))
+
+	balances, err := c.Balances(ctx, testCreds)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, "BTC", balances[0].Asset)
+	assert.InDelta(t, 0.6, balances[0].Total(), 1e-9)
+
+	readOnly = false
+	assert.ErrorIs(t, c.CheckReadOnly(ctx, testCreds), ErrNotReadOnly)
+
+	var out struct{}
+	assert.ErrorIs(t, c.get(ctx, testCreds, "/unknown", nil, &out), ErrInvalidCredentials)
+}
+
+func TestBybitClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts := r.Header.Get("X-BAPI-TIMESTAMP")
+		expected := sign("secret", ts+"key"+recvWindow+r.URL.RawQuery)
+		if r.Header.Get("X-BAPI-SIGN") != expected {
+			w.Write([]byte(`{"retCode":10004,"retMsg":"error sign"}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v5/user/query-api":
+			w.Write([]byte(`{"retCode":0,"result":{"readOnly":1}}`))
+		case "/v5/account/wallet-balance":
+			assert.Equal(t, "UNIFIED", r.URL.Query().Get("accountType"))
+			w.Write([]byte(`{"retCode":0,"result":{"list":[{"coin":[{"coin":"ETH","walletBalance":"2","locked":"0.5"},{"coin":"XYZ","walletBalance":"0","locked":"0"}]}]}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := &bybitClient{baseURL: srv.URL, httpClient: srv.Client()}
+	ctx := context.Background()
+
+	require.NoError(t, c.CheckReadOnly(ctx, testCreds))
+
+	balances, err := c.Balances(ctx, testCreds)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, Balance{Asset: "ETH", Free: 1.5, Locked: 0.5}, balances[0])
+
+	_, err = c.Balances(ctx, Credentials{APIKey: "key", APISecret: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestKeyHint(t *testing.T) {
+	assert.Equal(t, "…wxyz", KeyHint("abcdefwxyz"))
+	assert.Equal(t, "****", KeyHint("abc"))
+}
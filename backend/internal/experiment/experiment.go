@@ -0,0 +1,65 @@
+package experiment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// ControlVariant is the variant users see when they are not enrolled
+const ControlVariant = "control"
+
+// Variant is one arm of an experiment. Weights are relative
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// Experiment splits users between variants
+type Experiment struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description"`
+	FlagKey     *string   `json:"flag_key,omitempty"`
+	Variants    []Variant `json:"variants"`
+	IsActive    bool      `json:"is_active"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// VariantSplit counts the users exposed to a variant
+type VariantSplit struct {
+	Variant   string
+	Weight    int
+	Users     int64
+	Exposures int64
+}
+
+// AssignVariant picks a variant for a user. The hash is salted with the
+// experiment key so assignments are independent between experiments and
+// stable as long as the variants don't change
+func AssignVariant(key string, userID int64, variants []Variant) string {
+	total := 0
+	for _, v := range variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return ControlVariant
+	}
+
+	h := fnv.New32a()
+	fmt.Fprintf(h, "experiment:%s:%d", key, userID)
+	point := int(h.Sum32() % uint32(total))
+
+	for _, v := range variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if point < v.Weight {
+			return v.Name
+		}
+		point -= v.Weight
+	}
+
+	return ControlVariant
+}
@@ -0,0 +1,30 @@
+package experiment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignVariant_StableAndWeighted(t *testing.T) {
+	variants := []Variant{{Name: "control", Weight: 75}, {Name: "compact", Weight: 25}}
+	assert.Equal(t, AssignVariant("notification_copy", 42, variants), AssignVariant("notification_copy", 42, variants))
+
+	counts := map[string]int{}
+	for userID := int64(1); userID <= 10000; userID++ {
+		counts[AssignVariant("notification_copy", userID, variants)]++
+	}
+	assert.InDelta(t, 7500, counts["control"], 300)
+	assert.InDelta(t, 2500, counts["compact"], 300)
+}
+
+func TestAssignVariant_NoWeights(t *testing.T) {
+	assert.Equal(t, ControlVariant, AssignVariant("x", 1, nil))
+	assert.Equal(t, ControlVariant, AssignVariant("x", 1, []Variant{{Name: "a", Weight: 0}}))
+
+	// Zero-weight variants are never assigned
+	variants := []Variant{{Name: "a", Weight: 0}, {Name: "b", Weight: 1}}
+	for userID := int64(1); userID <= 100; userID++ {
+		assert.Equal(t, "b", AssignVariant("x", userID, variants))
+	}
+}
@@ -0,0 +1,284 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// Experiments are cached per key; a missing one is cached as "null"
+	cacheKeyPrefix = "experiments:"
+	cacheTTL       = time.Minute
+
+	// AnalyticsStream receives exposure events for the analytics pipeline
+	AnalyticsStream = "analytics:events"
+	// Approximate cap of the analytics stream
+	analyticsStreamMaxLen = 100000
+)
+
+// FlagChecker reports whether a feature flag is on for a user
+type FlagChecker interface {
+	IsEnabled(ctx context.Context, key string, userID int64) (bool, error)
+}
+
+// Service assigns users to experiment variants and records exposures
+type Service struct {
+	pool   *pgxpool.Pool
+	redis  *redis.Client
+	flags  FlagChecker
+	logger *slog.Logger
+}
+
+// NewService creates a new experiment Service
+func NewService(pool *pgxpool.Pool, redisClient *redis.Client, flags FlagChecker, logger *slog.Logger) *Service {
+	return &Service{
+		pool:   pool,
+		redis:  redisClient,
+		flags:  flags,
+		logger: logger,
+	}
+}
+
+// Assign returns the user's variant. enrolled is false when the experiment is
+// missing, inactive or gated by a flag the user doesn't have; callers then
+// fall back to ControlVariant and must not log an exposure
+func (s *Service) Assign(ctx context.Context, key string, userID int64) (variant string, enrolled bool, err error) {
+	exp, err := s.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, errors.ErrExperimentNotFound) {
+			return ControlVariant, false, nil
+		}
+		return ControlVariant, false, err
+	}
+	if !exp.IsActive {
+		return ControlVariant, false, nil
+	}
+
+	if exp.FlagKey != nil && s.flags != nil {
+		on, err := s.flags.IsEnabled(ctx, *exp.FlagKey, userID)
+		if err != nil {
+			return ControlVariant, false, err
+		}
+		if !on {
+			return ControlVariant, false, nil
+		}
+	}
+
+	return AssignVariant(exp.Key, userID, exp.Variants), true, nil
+}
+
+// LogExposure records that a user saw a variant, in Postgres for the split
+// report and on the analytics stream for downstream analysis
+func (s *Service) LogExposure(ctx context.Context, key string, userID int64, variant string) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO experiment_exposures (experiment_key, user_id, variant)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (experiment_key, user_id) DO UPDATE SET
+			variant = EXCLUDED.variant,
+			exposures = experiment_exposures.exposures + 1,
+			last_exposed_at = NOW()
+	`, key, userID, variant)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	err = s.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: AnalyticsStream,
+		MaxLen: analyticsStreamMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"type":       "experiment_exposure",
+			"experiment": key,
+			"variant":    variant,
+			"user_id":    strconv.FormatInt(userID, 10),
+			"ts":         time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	}).Err()
+	if err != nil {
+		// The Postgres row is the source of truth for splits
+		s.logger.Warn("failed to publish exposure event",
+			slog.String("experiment", key),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return nil
+}
+
+// Get returns an experiment, served from cache when possible
+func (s *Service) Get(ctx context.Context, key string) (*Experiment, error) {
+	cacheKey := cacheKeyPrefix + key
+	if data, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+		var exp *Experiment
+		if err := json.Unmarshal(data, &exp); err == nil {
+			if exp == nil {
+				return nil, errors.ErrExperimentNotFound
+			}
+			return exp, nil
+		}
+	} else if err != redis.Nil {
+		s.logger.Warn("experiment cache read failed", slog.String("error", err.Error()))
+	}
+
+	// Missing experiments are cached too so unknown keys don't hit Postgres
+	exp, err := s.load(ctx, key)
+	if err != nil && !errors.Is(err, errors.ErrExperimentNotFound) {
+		return nil, err
+	}
+
+	s.setCache(ctx, cacheKey, exp)
+	return exp, err
+}
+
+// load reads an experiment from Postgres
+func (s *Service) load(ctx context.Context, key string) (*Experiment, error) {
+	var exp Experiment
+	var variants []byte
+	err := s.pool.QueryRow(ctx, `
+		SELECT key, description, flag_key, variants, is_active, updated_at
+		FROM experiments WHERE key = $1
+	`, key).Scan(&exp.Key, &exp.Description, &exp.FlagKey, &variants, &exp.IsActive, &exp.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrExperimentNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := json.Unmarshal(variants, &exp.Variants); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return &exp, nil
+}
+
+// GetAll returns all experiments
+func (s *Service) GetAll(ctx context.Context) ([]Experiment, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT key, description, flag_key, variants, is_active, updated_at
+		FROM experiments
+		ORDER BY key
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	experiments := []Experiment{}
+	for rows.Next() {
+		var exp Experiment
+		var variants []byte
+		if err := rows.Scan(&exp.Key, &exp.Description, &exp.FlagKey, &variants, &exp.IsActive, &exp.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		if err := json.Unmarshal(variants, &exp.Variants); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		experiments = append(experiments, exp)
+	}
+
+	return experiments, nil
+}
+
+// Upsert creates or updates an experiment. Changing variants or weights
+// reshuffles assignments, so running experiments should keep them stable
+func (s *Service) Upsert(ctx context.Context, exp Experiment) (*Experiment, error) {
+	variants, err := json.Marshal(exp.Variants)
+	if err != nil {
+		return nil, errors.ErrInvalidInput.WithCause(err)
+	}
+
+	if exp.FlagKey != nil {
+		var exists bool
+		if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM feature_flags WHERE key = $1)`, *exp.FlagKey).Scan(&exists); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		if !exists {
+			return nil, errors.ErrNotFound.WithMessage("Feature flag not found")
+		}
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO experiments (key, description, flag_key, variants, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO UPDATE SET
+			description = EXCLUDED.description,
+			flag_key = EXCLUDED.flag_key,
+			variants = EXCLUDED.variants,
+			is_active = EXCLUDED.is_active
+	`, exp.Key, exp.Description, exp.FlagKey, variants, exp.IsActive)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := s.redis.Del(ctx, cacheKeyPrefix+exp.Key).Err(); err != nil {
+		s.logger.Warn("experiment cache invalidation failed",
+			slog.String("experiment", exp.Key),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return s.load(ctx, exp.Key)
+}
+
+// GetSplits returns per-variant exposure counts next to the configured weights
+func (s *Service) GetSplits(ctx context.Context, key string) (*Experiment, []VariantSplit, error) {
+	exp, err := s.load(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT variant, COUNT(*), COALESCE(SUM(exposures), 0)
+		FROM experiment_exposures
+		WHERE experiment_key = $1
+		GROUP BY variant
+	`, key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]VariantSplit)
+	for rows.Next() {
+		var split VariantSplit
+		if err := rows.Scan(&split.Variant, &split.Users, &split.Exposures); err != nil {
+			return nil, nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		counts[split.Variant] = split
+	}
+
+	// Configured variants first, then any removed variant that still has exposures
+	splits := make([]VariantSplit, 0, len(exp.Variants))
+	for _, v := range exp.Variants {
+		split := counts[v.Name]
+		split.Variant = v.Name
+		split.Weight = v.Weight
+		splits = append(splits, split)
+		delete(counts, v.Name)
+	}
+	for _, split := range counts {
+		splits = append(splits, split)
+	}
+
+	return exp, splits, nil
+}
+
+// setCache stores a value in Redis; failures only cost a database round trip
+func (s *Service) setCache(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, key, data, cacheTTL).Err(); err != nil {
+		s.logger.Warn("experiment cache write failed", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,57 @@
+package gas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	gasPriceKey = "gas:eth"
+	// Estimates older than this are dropped rather than shown as current
+	gasPriceTTL = 10 * time.Minute
+)
+
+// Cache stores the latest gas price estimate in Redis, shared by the alert
+// engine that polls it and the API that serves it
+type Cache struct {
+	redis *redis.Client
+}
+
+// NewCache creates a new gas price cache
+func NewCache(redisClient *redis.Client) *Cache {
+	return &Cache{redis: redisClient}
+}
+
+// Set stores the latest estimate
+func (c *Cache) Set(ctx context.Context, price *Price) error {
+	data, err := json.Marshal(price)
+	if err != nil {
+		return fmt.Errorf("marshal gas price: %w", err)
+	}
+
+	if err := c.redis.Set(ctx, gasPriceKey, data, gasPriceTTL).Err(); err != nil {
+		return fmt.Errorf("set gas price: %w", err)
+	}
+	return nil
+}
+
+// Get returns the latest estimate, or nil if none is available
+func (c *Cache) Get(ctx context.Context) (*Price, error) {
+	data, err := c.redis.Get(ctx, gasPriceKey).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get gas price: %w", err)
+	}
+
+	var price Price
+	if err := json.Unmarshal(data, &price); err != nil {
+		return nil, fmt.Errorf("unmarshal gas price: %w", err)
+	}
+	return &price, nil
+}
@@ -0,0 +1,222 @@
+// Package gas tracks the Ethereum gas price through a JSON-RPC node
+package gas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+
+	// CoinSymbol is the coin gas alerts are set on
+	CoinSymbol = "ETH"
+
+	// Blocks the fee estimate is averaged over
+	feeHistoryBlocks = 20
+
+	weiPerGwei = 1e9
+)
+
+// Priority fee percentiles of the slow, standard and fast estimates
+var feeHistoryPercentiles = []float64{10, 50, 90}
+
+// Client is an Ethereum JSON-RPC client reading gas prices
+type Client struct {
+	httpClient *http.Client
+	rpcURL     string
+	logger     *slog.Logger
+}
+
+// NewClient creates a new gas price client for the node at rpcURL
+func NewClient(rpcURL string, logger *slog.Logger) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		rpcURL: rpcURL,
+		logger: logger,
+	}
+}
+
+// Price is a gas price estimate in gwei
+type Price struct {
+	// Base fee of the next block; 0 when the node has no fee history
+	BaseFeeGwei  float64   `json:"base_fee_gwei"`
+	SlowGwei     float64   `json:"slow_gwei"`
+	StandardGwei float64   `json:"standard_gwei"`
+	FastGwei     float64   `json:"fast_gwei"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Summary describes the estimate in one line, e.g.
+// "base fee 8.1 gwei · fast 9.4 gwei"
+func (p Price) Summary() string {
+	if p.BaseFeeGwei == 0 {
+		return "gas price " + FormatGwei(p.StandardGwei)
+	}
+	return fmt.Sprintf("base fee %s · fast %s", FormatGwei(p.BaseFeeGwei), FormatGwei(p.FastGwei))
+}
+
+// FormatGwei formats a gas price for display, e.g. "8.1 gwei"
+func FormatGwei(gwei float64) string {
+	if gwei < 10 {
+		return fmt.Sprintf("%.2f gwei", gwei)
+	}
+	return fmt.Sprintf("%.1f gwei", gwei)
+}
+
+// rpcRequest is a JSON-RPC 2.0 request
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// feeHistory is the result of eth_feeHistory
+type feeHistory struct {
+	// One entry per block plus the next block
+	BaseFeePerGas []string `json:"baseFeePerGas"`
+	// Per block, the priority fee at each requested percentile
+	Reward [][]string `json:"reward"`
+}
+
+// Estimate returns the current gas price. Slow, standard and fast are the
+// next base fee plus the 10th, 50th and 90th percentile priority fees of
+// recent blocks; nodes without fee history fall back to eth_gasPrice
+func (c *Client) Estimate(ctx context.Context) (*Price, error) {
+	price, err := c.estimateFromFeeHistory(ctx)
+	if err == nil {
+		return price, nil
+	}
+	if ctx.Err() != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("fee history unavailable, using eth_gasPrice", slog.String("error", err.Error()))
+
+	var hexPrice string
+	if err := c.call(ctx, "eth_gasPrice", nil, &hexPrice); err != nil {
+		return nil, err
+	}
+	gwei, err := hexToGwei(hexPrice)
+	if err != nil {
+		return nil, fmt.Errorf("parse gas price: %w", err)
+	}
+
+	return &Price{
+		SlowGwei:     gwei,
+		StandardGwei: gwei,
+		FastGwei:     gwei,
+		UpdatedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// estimateFromFeeHistory estimates the gas price from eth_feeHistory
+func (c *Client) estimateFromFeeHistory(ctx context.Context) (*Price, error) {
+	var history feeHistory
+	params := []any{fmt.Sprintf("0x%x", feeHistoryBlocks), "latest", feeHistoryPercentiles}
+	if err := c.call(ctx, "eth_feeHistory", params, &history); err != nil {
+		return nil, err
+	}
+	if len(history.BaseFeePerGas) == 0 || len(history.Reward) == 0 {
+		return nil, fmt.Errorf("empty fee history")
+	}
+
+	baseFee, err := hexToGwei(history.BaseFeePerGas[len(history.BaseFeePerGas)-1])
+	if err != nil {
+		return nil, fmt.Errorf("parse base fee: %w", err)
+	}
+
+	var tips [3]float64
+	for _, rewards := range history.Reward {
+		if len(rewards) != len(tips) {
+			return nil, fmt.Errorf("unexpected reward percentiles: %d", len(rewards))
+		}
+		for i, reward := range rewards {
+			gwei, err := hexToGwei(reward)
+			if err != nil {
+				return nil, fmt.Errorf("parse priority fee: %w", err)
+			}
+			tips[i] += gwei
+		}
+	}
+	blocks := float64(len(history.Reward))
+
+	return &Price{
+		BaseFeeGwei:  baseFee,
+		SlowGwei:     baseFee + tips[0]/blocks,
+		StandardGwei: baseFee + tips[1]/blocks,
+		FastGwei:     baseFee + tips[2]/blocks,
+		UpdatedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// call performs a JSON-RPC call and decodes its result into out
+func (c *Client) call(ctx context.Context, method string, params []any, out any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s: rpc error %d: %s", method, rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// hexToGwei converts a hex wei quantity to gwei
+func hexToGwei(hex string) (float64, error) {
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(hex, "0x"), 16)
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity %q", hex)
+	}
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(weiPerGwei)).Float64()
+	return gwei, nil
+}
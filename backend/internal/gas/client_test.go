@@ -0,0 +1,64 @@
+package gas
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Estimate(t *testing.T) {
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		method = req.Method
+		// Base fees 10 then 8 gwei; tips averaged over two blocks
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {
+			"baseFeePerGas": ["0x2540be400", "0x1dcd65000"],
+			"reward": [
+				["0x3b9aca00", "0x77359400", "0xb2d05e00"],
+				["0x1dcd6500", "0x3b9aca00", "0x77359400"]
+			]
+		}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	price, err := c.Estimate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "eth_feeHistory", method)
+	assert.InDelta(t, 8.0, price.BaseFeeGwei, 1e-9)
+	assert.InDelta(t, 8.75, price.SlowGwei, 1e-9)
+	assert.InDelta(t, 9.5, price.StandardGwei, 1e-9)
+	assert.InDelta(t, 10.5, price.FastGwei, 1e-9)
+	assert.Equal(t, "base fee 8.00 gwei · fast 10.5 gwei", price.Summary())
+}
+
+func TestClient_EstimateFallsBackToGasPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Method == "eth_feeHistory" {
+			w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32601, "message": "method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": "0x2cb417800"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	price, err := c.Estimate(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, price.BaseFeeGwei)
+	assert.InDelta(t, 12.0, price.StandardGwei, 1e-9)
+	assert.Equal(t, "gas price 12.0 gwei", price.Summary())
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/coingecko"
+)
+
+// TestSyncCategories_MatchesCoinGeckoID links category coins by CoinGecko
+// ID, so a token sharing one of our tickers does not drag our coin in
+func TestSyncCategories_MatchesCoinGeckoID(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := s.Pool.Exec(ctx, `
+		UPDATE coins SET coingecko_id = CASE symbol WHEN 'BTC' THEN 'bitcoin' ELSE 'ethereum' END
+		WHERE symbol IN ('BTC', 'ETH')
+	`)
+	require.NoError(t, err)
+
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any = []any{}
+		if r.URL.Path == "/coins/markets" && r.URL.Query().Get("category") == "layer-1" {
+			body = []coingecko.CoinMarket{
+				{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin"},
+				// Same ticker as ETH, another coin
+				{ID: "ethereum-wormhole", Symbol: "eth", Name: "Wrapped Ether (Wormhole)"},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer fake.Close()
+
+	client := coingecko.NewClient("", testLogger())
+	client.SetBaseURL(fake.URL)
+	require.NoError(t, coingecko.NewSyncService(client, s.Pool, testLogger()).SyncCategories(ctx))
+
+	rows, err := s.Pool.Query(ctx, `
+		SELECT c.symbol FROM coin_categories cc
+		JOIN coins c ON c.id = cc.coin_id
+		WHERE cc.category_id = 'layer1'
+	`)
+	require.NoError(t, err)
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		require.NoError(t, rows.Scan(&symbol))
+		symbols = append(symbols, symbol)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"BTC"}, symbols)
+}
+
+// TestSyncCoins_SkipsRejectedCoin stores the other coins of a sync when the
+// database rejects one of them
+func TestSyncCoins_SkipsRejectedCoin(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]coingecko.CoinMarket{
+			{ID: "sync-a", Symbol: "sya", Name: "Sync A", CurrentPrice: 1},
+			// Longer than the name column allows
+			{ID: "sync-b", Symbol: "syb", Name: strings.Repeat("b", 150), CurrentPrice: 2},
+			{ID: "sync-c", Symbol: "syc", Name: "Sync C", CurrentPrice: 3},
+		})
+	}))
+	defer fake.Close()
+
+	client := coingecko.NewClient("", testLogger())
+	client.SetBaseURL(fake.URL)
+	require.NoError(t, coingecko.NewSyncService(client, s.Pool, testLogger()).SyncCoins(ctx, 3))
+
+	rows, err := s.Pool.Query(ctx, `SELECT symbol FROM coins WHERE symbol IN ('SYA', 'SYB', 'SYC') ORDER BY symbol`)
+	require.NoError(t, err)
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		require.NoError(t, rows.Scan(&symbol))
+		symbols = append(symbols, symbol)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"SYA", "SYC"}, symbols)
+}
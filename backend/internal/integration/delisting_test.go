@@ -0,0 +1,158 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/binance/binancetest"
+	"github.com/weqory/backend/internal/coingecko"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/internal/telegram/telegramtest"
+	"github.com/weqory/backend/pkg/crypto"
+)
+
+// TestDelistingDetect delists only coins gone from both Binance and
+// CoinGecko, pauses their alerts and tells each owner once
+func TestDelistingDetect(t *testing.T) {
+	s := requireStack(t)
+	log := testLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// CoinGecko still tracks every coin but the ones named delisted-*
+	gecko := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prices := map[string]coingecko.SimplePrice{}
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if id != "" && !strings.HasPrefix(id, "delisted-") {
+				prices[id] = coingecko.SimplePrice{USD: 1, LastUpdated: time.Now().Unix()}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(prices)
+	}))
+	defer gecko.Close()
+
+	exchange := httptest.NewServer(binancetest.NewServer([]string{"DLAUSDT"}))
+	defer exchange.Close()
+
+	tg := telegramtest.NewServer()
+	tgServer := httptest.NewServer(tg)
+	defer tgServer.Close()
+
+	_, err := s.Pool.Exec(ctx, `
+		INSERT INTO coins (symbol, name, binance_symbol, coingecko_id, is_stablecoin, is_active) VALUES
+			('DLA', 'Still on Binance', 'DLAUSDT', 'delisted-a', false, true),
+			('DLB', 'Only on CoinGecko', 'DLBUSDT', 'listed-b', false, true),
+			('DLC', 'Gone', 'DLCUSDT', 'delisted-c', false, true),
+			('DLD', 'Nothing to confirm', 'DLDUSDT', NULL, false, true),
+			('DLS', 'Stablecoin', 'DLSUSDT', 'delisted-s', true, true),
+			('DLR', 'Back again', NULL, 'listed-r', false, false)
+	`)
+	require.NoError(t, err)
+
+	// Alerts on the coin that goes: two owners, one alert already paused
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	var owners []*service.UserWithLimits
+	var alertIDs []int64
+	for i, telegramID := range []int64{970001, 970002} {
+		user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: telegramID, FirstName: "Owner"})
+		require.NoError(t, err)
+		owners = append(owners, user)
+		_, err = watchlist.AddCoin(ctx, user.ID, "DLC")
+		require.NoError(t, err)
+
+		for j := 0; j <= i; j++ {
+			created, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+				CoinSymbol:     "DLC",
+				AlertType:      string(alert.AlertTypePriceAbove),
+				ConditionValue: float64(10 + j),
+			})
+			require.NoError(t, err)
+			alertIDs = append(alertIDs, created.ID)
+		}
+	}
+	_, err = s.Pool.Exec(ctx, `UPDATE alerts SET is_paused = true WHERE id = $1`, alertIDs[2])
+	require.NoError(t, err)
+
+	exchangeInfo := binance.NewExchangeInfo(log)
+	exchangeInfo.SetBaseURL(exchange.URL)
+	geckoClient := coingecko.NewClient("", log)
+	geckoClient.SetBaseURL(gecko.URL)
+	telegramClient := telegram.NewClient("123:integration", log)
+	telegramClient.SetAPIURL(tgServer.URL)
+	delisting := service.NewDelistingService(s.Pool, exchangeInfo, geckoClient, telegramClient, "", log)
+
+	result, err := delisting.Detect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DLC"}, result.Delisted)
+	assert.Contains(t, result.Relisted, "DLR")
+	assert.Equal(t, 2, result.PausedAlerts)
+	assert.Equal(t, 2, result.NotifiedUsers)
+
+	active := func(symbol string) bool {
+		t.Helper()
+		var isActive bool
+		require.NoError(t, s.Pool.QueryRow(ctx, `SELECT is_active FROM coins WHERE symbol = $1`, symbol).Scan(&isActive))
+		return isActive
+	}
+	for _, symbol := range []string{"DLA", "DLB", "DLD", "DLS", "DLR"} {
+		assert.True(t, active(symbol), symbol)
+	}
+	assert.False(t, active("DLC"))
+
+	// Paused by the delisting, except the alert its owner had paused
+	pausedReason := func(id int64) *string {
+		t.Helper()
+		var isPaused bool
+		var reason *string
+		require.NoError(t, s.Pool.QueryRow(ctx, `
+			SELECT is_paused, paused_reason FROM alerts WHERE id = $1
+		`, id).Scan(&isPaused, &reason))
+		assert.True(t, isPaused)
+		return reason
+	}
+	for _, id := range alertIDs[:2] {
+		reason := pausedReason(id)
+		require.NotNil(t, reason)
+		assert.Equal(t, service.PausedReasonCoinDelisted, *reason)
+	}
+	assert.Nil(t, pausedReason(alertIDs[2]))
+
+	messages := tg.Messages()
+	require.Len(t, messages, 2)
+	assert.ElementsMatch(t, []int64{owners[0].TelegramID, owners[1].TelegramID},
+		[]int64{messages[0].ChatID, messages[1].ChatID})
+
+	report, err := delisting.GetDelisted(ctx)
+	require.NoError(t, err)
+	var found bool
+	for _, c := range report {
+		if c.Symbol == "DLC" {
+			found = true
+			assert.Equal(t, int64(2), c.PausedAlerts)
+			assert.Equal(t, int64(2), c.AffectedUsers)
+		}
+	}
+	assert.True(t, found, "DLC in the report")
+
+	// A second run changes nothing
+	tg.Reset()
+	result, err = delisting.Detect(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Delisted)
+	assert.Empty(t, tg.Messages())
+}
@@ -0,0 +1,8 @@
+// Package integration holds end-to-end tests running the services against
+// real Postgres and Redis containers, with fake Binance and Telegram
+// servers. They need Docker and are behind the integration build tag:
+//
+//	go test -tags integration ./internal/integration/...
+//
+// Tests are skipped when Docker cannot be reached
+package integration
@@ -0,0 +1,118 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/binance/binancetest"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+// newTestEngine returns an alert engine on the stack that is not running
+func newTestEngine(s *testStack) *alert.Engine {
+	log := testLogger()
+	return alert.NewEngine(s.Pool, binance.NewClient(log), cache.NewPriceCache(s.Redis, log),
+		alert.NewPricePublisher(eventbus.NewRedisPubSub(s.Redis), log), log)
+}
+
+// TestEngine_RefreshAlerts loads the alerts to evaluate from the migrated
+// schema: active ones, without paused or deleted ones
+func TestEngine_RefreshAlerts(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 990001, FirstName: "Refresh"})
+	require.NoError(t, err)
+	_, err = watchlist.AddCoin(ctx, user.ID, "BNB")
+	require.NoError(t, err)
+
+	engine := newTestEngine(s)
+	require.NoError(t, engine.RefreshAlerts(ctx))
+	before := engine.GetAlertCount()
+
+	var created []int64
+	for _, value := range []float64{700, 800, 900} {
+		a, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+			CoinSymbol:     "BNB",
+			AlertType:      string(alert.AlertTypePriceAbove),
+			ConditionValue: value,
+		})
+		require.NoError(t, err)
+		created = append(created, a.ID)
+	}
+	require.NoError(t, engine.RefreshAlerts(ctx))
+	assert.Equal(t, before+3, engine.GetAlertCount())
+
+	_, err = alerts.UpdatePaused(ctx, user.ID, created[0], true, nil)
+	require.NoError(t, err)
+	require.NoError(t, alerts.Delete(ctx, user.ID, created[1]))
+
+	require.NoError(t, engine.RefreshAlerts(ctx))
+	assert.Equal(t, before+1, engine.GetAlertCount())
+}
+
+// TestEngine_RecordsTriggerHistory writes a history record, not yet
+// notified, for a fired alert
+func TestEngine_RecordsTriggerHistory(t *testing.T) {
+	s := requireStack(t)
+	log := testLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 990002, FirstName: "History"})
+	require.NoError(t, err)
+	_, err = watchlist.AddCoin(ctx, user.ID, "SOL")
+	require.NoError(t, err)
+	created, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+		CoinSymbol:     "SOL",
+		AlertType:      string(alert.AlertTypePriceBelow),
+		ConditionValue: 150,
+	})
+	require.NoError(t, err)
+
+	exchange := binancetest.NewServer([]string{"SOLUSDT"})
+	exchangeServer := httptest.NewServer(exchange)
+	defer exchangeServer.Close()
+
+	binanceClient := binance.NewClient(log)
+	binanceClient.SetBaseURL(binancetest.WSURL(exchangeServer.URL))
+	engine := alert.NewEngine(s.Pool, binanceClient, cache.NewPriceCache(s.Redis, log),
+		alert.NewPricePublisher(eventbus.NewRedisPubSub(s.Redis), log), log)
+	go engine.Run(ctx)
+	defer engine.Stop()
+
+	waitFor(t, 10*time.Second, "engine subscription to SOLUSDT", func() bool {
+		return exchange.Publish(ticker("SOLUSDT", 160)) > 0
+	})
+	exchange.Publish(ticker("SOLUSDT", 145))
+
+	var price float64
+	var sent bool
+	waitFor(t, 10*time.Second, "history record", func() bool {
+		return s.Pool.QueryRow(ctx, `
+			SELECT triggered_price, notification_sent FROM alert_history WHERE alert_id = $1
+		`, created.ID).Scan(&price, &sent) == nil
+	})
+	assert.Equal(t, 145.0, price)
+	assert.False(t, sent)
+}
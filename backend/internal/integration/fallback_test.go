@@ -0,0 +1,116 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/binance/binancetest"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/coingecko"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+// TestFallbackPoller_CoinWithoutBinancePair syncs a coin mapped to no
+// Binance pair and fires its alert on a price polled from CoinGecko
+func TestFallbackPoller_CoinWithoutBinancePair(t *testing.T) {
+	s := requireStack(t)
+	log := testLogger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	gecko := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any = []any{}
+		switch r.URL.Path {
+		case "/coins/markets":
+			if r.URL.Query().Get("page") == "1" {
+				body = []coingecko.CoinMarket{
+					{ID: "obscure-coin", Symbol: "obs", Name: "Obscure", CurrentPrice: 2, MarketCapRank: 900},
+				}
+			}
+		case "/simple/price":
+			body = map[string]coingecko.SimplePrice{
+				"obscure-coin": {USD: 3, LastUpdated: time.Now().Unix()},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer gecko.Close()
+
+	// Not on Binance: the sync must leave the coin without a pair
+	_, err := s.Pool.Exec(ctx, `
+		INSERT INTO symbol_mappings (symbol, binance_symbol, source) VALUES ('OBS', NULL, 'manual')
+	`)
+	require.NoError(t, err)
+
+	client := coingecko.NewClient("", log)
+	client.SetBaseURL(gecko.URL)
+	require.NoError(t, coingecko.NewSyncService(client, s.Pool, log).SyncCoins(ctx, 1))
+
+	var binanceSymbol, coingeckoID *string
+	require.NoError(t, s.Pool.QueryRow(ctx, `
+		SELECT binance_symbol, coingecko_id FROM coins WHERE symbol = 'OBS'
+	`).Scan(&binanceSymbol, &coingeckoID))
+	assert.Nil(t, binanceSymbol)
+	require.NotNil(t, coingeckoID)
+	assert.Equal(t, "obscure-coin", *coingeckoID)
+
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 920001, FirstName: "Fallback"})
+	require.NoError(t, err)
+	_, err = watchlist.AddCoin(ctx, user.ID, "OBS")
+	require.NoError(t, err)
+	created, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+		CoinSymbol:     "OBS",
+		AlertType:      string(alert.AlertTypePriceAbove),
+		ConditionValue: 2.5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, service.PriceSourceCoinGecko, created.Coin.PriceSource())
+
+	exchange := binancetest.NewServer(nil)
+	exchangeServer := httptest.NewServer(exchange)
+	defer exchangeServer.Close()
+
+	binanceClient := binance.NewClient(log)
+	binanceClient.SetBaseURL(binancetest.WSURL(exchangeServer.URL))
+	engine := alert.NewEngine(s.Pool, binanceClient, cache.NewPriceCache(s.Redis, log),
+		alert.NewPricePublisher(eventbus.NewRedisPubSub(s.Redis), log), log)
+	engine.SetFallbackPoller(alert.NewFallbackPoller(client, log))
+
+	triggered := make(chan *alert.TriggerEvent, 1)
+	engine.SetTriggerHandler(func(event *alert.TriggerEvent) {
+		if event.AlertID == created.ID {
+			select {
+			case triggered <- event:
+			default:
+			}
+		}
+	})
+	go engine.Run(ctx)
+	defer engine.Stop()
+
+	// The first poll runs as soon as the engine has loaded its alerts
+	select {
+	case event := <-triggered:
+		assert.Equal(t, "OBS", event.CoinSymbol)
+		assert.Equal(t, 3.0, event.TriggeredPrice)
+	case <-time.After(15 * time.Second):
+		t.Fatal("alert on a CoinGecko-polled coin did not fire")
+	}
+}
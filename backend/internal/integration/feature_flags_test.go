@@ -0,0 +1,49 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+)
+
+// TestFeatureFlags_DeleteDropsOverrides forgets the overrides of a deleted
+// flag, also in cache, so a flag created again under its key starts clean
+func TestFeatureFlags_DeleteDropsOverrides(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	flags := service.NewFeatureFlagService(s.Pool, s.Redis, testLogger())
+	users := service.NewUserService(s.Pool)
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 990006, FirstName: "Flags"})
+	require.NoError(t, err)
+
+	flag := service.FeatureFlag{Key: "it_recreated", Enabled: true, RolloutPercentage: 0}
+	_, err = flags.Upsert(ctx, flag)
+	require.NoError(t, err)
+	require.NoError(t, flags.SetOverride(ctx, flag.Key, user.ID, true))
+
+	// Evaluating caches the user's overrides
+	enabled, err := flags.IsEnabled(ctx, flag.Key, user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled, "forced on")
+
+	require.NoError(t, flags.Delete(ctx, flag.Key))
+	_, err = flags.Upsert(ctx, flag)
+	require.NoError(t, err)
+
+	enabled, err = flags.IsEnabled(ctx, flag.Key, user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled, "rollout applies again")
+
+	overrides, err := flags.GetOverrides(ctx, flag.Key)
+	require.NoError(t, err)
+	assert.Empty(t, overrides)
+}
@@ -0,0 +1,219 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/database"
+	"github.com/weqory/backend/pkg/redis"
+)
+
+const (
+	// Images match docker/docker-compose.yml
+	postgresImage = "postgres"
+	postgresTag   = "16-alpine"
+	redisImage    = "redis"
+	redisTag      = "7-alpine"
+
+	// Containers are killed by Docker after this long even if the run
+	// crashes before purging them
+	containerTTL = 10 * 60
+
+	migrationsDir = "../../db/migrations"
+)
+
+// stack is the shared Postgres and Redis of the run, nil when they could
+// not be started (see stackErr)
+var (
+	stack    *testStack
+	stackErr error
+)
+
+// testStack is a migrated Postgres database and a Redis server
+type testStack struct {
+	Pool  *pgxpool.Pool
+	Redis *goredis.Client
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	var cleanup func()
+	if testing.Short() {
+		stackErr = fmt.Errorf("short mode")
+	} else {
+		stack, cleanup, stackErr = startStack(context.Background())
+	}
+
+	code := m.Run()
+	if cleanup != nil {
+		cleanup()
+	}
+	os.Exit(code)
+}
+
+// requireStack returns the shared stack, skipping the test when Docker is
+// not available
+func requireStack(t *testing.T) *testStack {
+	t.Helper()
+	if stack == nil {
+		t.Skipf("integration stack unavailable: %v", stackErr)
+	}
+	return stack
+}
+
+// startStack starts the containers, connects to them and applies the
+// migrations. cleanup closes the connections and removes the containers
+func startStack(ctx context.Context) (*testStack, func(), error) {
+	dockerPool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to docker: %w", err)
+	}
+	if err := dockerPool.Client.Ping(); err != nil {
+		return nil, nil, fmt.Errorf("ping docker: %w", err)
+	}
+	dockerPool.MaxWait = 2 * time.Minute
+
+	var resources []*dockertest.Resource
+	s := &testStack{}
+	cleanup := func() {
+		if s.Pool != nil {
+			s.Pool.Close()
+		}
+		if s.Redis != nil {
+			s.Redis.Close()
+		}
+		for _, r := range resources {
+			_ = dockerPool.Purge(r)
+		}
+	}
+
+	pg, err := runContainer(dockerPool, &dockertest.RunOptions{
+		Repository: postgresImage,
+		Tag:        postgresTag,
+		Env: []string{
+			"POSTGRES_USER=weqory",
+			"POSTGRES_PASSWORD=weqory",
+			"POSTGRES_DB=weqory_test",
+		},
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("start postgres: %w", err)
+	}
+	resources = append(resources, pg)
+
+	rd, err := runContainer(dockerPool, &dockertest.RunOptions{
+		Repository: redisImage,
+		Tag:        redisTag,
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("start redis: %w", err)
+	}
+	resources = append(resources, rd)
+
+	postgresURL := fmt.Sprintf("postgres://weqory:weqory@%s/weqory_test?sslmode=disable", pg.GetHostPort("5432/tcp"))
+	err = dockerPool.Retry(func() error {
+		s.Pool, err = database.NewPostgresPool(ctx, database.PostgresConfig{
+			URL:      postgresURL,
+			MaxConns: 20,
+		})
+		return err
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+
+	redisURL := fmt.Sprintf("redis://%s/0", rd.GetHostPort("6379/tcp"))
+	err = dockerPool.Retry(func() error {
+		s.Redis, err = redis.NewClient(ctx, redis.Config{URL: redisURL})
+		return err
+	})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("connect to redis: %w", err)
+	}
+
+	if err := migrate(ctx, s.Pool, migrationsDir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return s, cleanup, nil
+}
+
+// runContainer starts a throwaway container that Docker removes once it
+// stops
+func runContainer(pool *dockertest.Pool, opts *dockertest.RunOptions) (*dockertest.Resource, error) {
+	resource, err := pool.RunWithOptions(opts, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := resource.Expire(containerTTL); err != nil {
+		_ = pool.Purge(resource)
+		return nil, err
+	}
+	return resource, nil
+}
+
+// migrate applies the up migrations in dir in order
+func migrate(ctx context.Context, pool *pgxpool.Pool, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations in %s", dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("apply %s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// testLogger logs to the test output when running with -v
+func testLogger() *slog.Logger {
+	if !testing.Verbose() {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// waitFor polls cond until it holds or timeout passes
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %s waiting for %s", timeout, what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,143 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/binance/binancetest"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/notification"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/internal/telegram/telegramtest"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+// Topic the alert engine publishes triggered alerts on
+const notificationsTopic = "alert:notifications"
+
+// ticker builds a 24hr ticker update of symbol at price
+func ticker(symbol string, price float64) binance.TickerUpdate {
+	return binance.TickerUpdate{
+		EventType: "24hrTicker",
+		EventTime: time.Now().UnixMilli(),
+		Symbol:    symbol,
+		LastPrice: fmt.Sprintf("%.2f", price),
+	}
+}
+
+// TestAlertLifecycle creates an alert through the services and follows it
+// through the alert engine, the event bus and the notification service to
+// the Telegram message
+func TestAlertLifecycle(t *testing.T) {
+	s := requireStack(t)
+	log := testLogger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	tg := telegramtest.NewServer()
+	tgServer := httptest.NewServer(tg)
+	defer tgServer.Close()
+
+	exchange := binancetest.NewServer([]string{"BTCUSDT"})
+	exchangeServer := httptest.NewServer(exchange)
+	defer exchangeServer.Close()
+
+	// Create: a user watching BTC with an alert above 70000
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	const telegramID = 900001
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: telegramID, FirstName: "Integration"})
+	require.NoError(t, err)
+	_, err = watchlist.AddCoin(ctx, user.ID, "BTC")
+	require.NoError(t, err)
+
+	created, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+		CoinSymbol:     "BTC",
+		AlertType:      string(alert.AlertTypePriceAbove),
+		ConditionValue: 70000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", created.Coin.BinanceSymbol)
+	_, err = time.Parse(time.RFC3339, created.CreatedAt)
+	assert.NoError(t, err, "created_at should be RFC 3339")
+
+	// Notification service, subscribed before the engine can publish
+	bus := eventbus.NewRedisPubSub(s.Redis)
+	telegramClient := telegram.NewClient("123:integration", log)
+	telegramClient.SetAPIURL(tgServer.URL)
+	notifications := notification.NewService(s.Pool, s.Redis, telegramClient, "https://t.me/weqory_bot/app", log)
+	defer notifications.Stop()
+	subscriber := notification.NewSubscriber(s.Pool, s.Redis, bus, notifications, log)
+	subscriber.SetBatching(0, 0)
+	go subscriber.Run(ctx)
+	defer subscriber.Stop()
+
+	waitFor(t, 10*time.Second, "notification subscription", func() bool {
+		return s.Redis.PubSubNumSub(ctx, notificationsTopic).Val()[notificationsTopic] > 0
+	})
+
+	// Alert engine streaming prices from the fake Binance
+	binanceClient := binance.NewClient(log)
+	binanceClient.SetBaseURL(binancetest.WSURL(exchangeServer.URL))
+	publisher := alert.NewPublisher(s.Redis, bus, log)
+	engine := alert.NewEngine(s.Pool, binanceClient, cache.NewPriceCache(s.Redis, log), alert.NewPricePublisher(bus, log), log)
+	engine.SetTriggerHandler(publisher.CreateTriggerHandler())
+	go engine.Run(ctx)
+	defer engine.Stop()
+
+	// Price tick: below the threshold until the engine has subscribed
+	waitFor(t, 10*time.Second, "engine subscription to BTCUSDT", func() bool {
+		return exchange.Publish(ticker("BTCUSDT", 69000)) > 0
+	})
+	require.Equal(t, 1, engine.GetAlertCount())
+
+	// Trigger
+	exchange.Publish(ticker("BTCUSDT", 71000))
+
+	// Publish and notify
+	waitFor(t, 15*time.Second, "telegram message", func() bool {
+		return len(tg.Messages()) > 0
+	})
+	msg := tg.Messages()[0]
+	assert.Equal(t, int64(telegramID), msg.ChatID)
+	assert.Contains(t, msg.Text, "BTC")
+	assert.NotNil(t, msg.ReplyMarkup)
+
+	// The trigger is recorded and the one-shot alert paused
+	waitFor(t, 5*time.Second, "history marked notified", func() bool {
+		var sent bool
+		err := s.Pool.QueryRow(ctx, `
+			SELECT notification_sent FROM alert_history WHERE alert_id = $1
+		`, created.ID).Scan(&sent)
+		return err == nil && sent
+	})
+
+	stored, err := alerts.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsPaused)
+	assert.Equal(t, 1, stored.TimesTriggered)
+	require.NotNil(t, stored.LastTriggeredAt)
+
+	var used int
+	require.NoError(t, s.Pool.QueryRow(ctx, `SELECT notifications_used FROM users WHERE id = $1`, user.ID).Scan(&used))
+	assert.Equal(t, 1, used)
+
+	// Further ticks do not fire the paused alert again
+	exchange.Publish(ticker("BTCUSDT", 72000))
+	time.Sleep(500 * time.Millisecond)
+	assert.Len(t, tg.Requests("sendMessage"), 1)
+}
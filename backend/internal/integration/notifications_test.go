@@ -0,0 +1,205 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/notification"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/internal/telegram/telegramtest"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+// TestSendNotifications_MonthlyLimit counts every alert of a batch against
+// the plan's monthly limit, cutting batches down to what is left of it
+func TestSendNotifications_MonthlyLimit(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	tg := telegramtest.NewServer()
+	tgServer := httptest.NewServer(tg)
+	defer tgServer.Close()
+	client := telegram.NewClient("123:integration", testLogger())
+	client.SetAPIURL(tgServer.URL)
+	notifications := notification.NewService(s.Pool, s.Redis, client, "", testLogger())
+	defer notifications.Stop()
+
+	// Standard plan, 18 notifications a month
+	users := service.NewUserService(s.Pool)
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 960001, FirstName: "Quota"})
+	require.NoError(t, err)
+
+	setUsed := func(n int) {
+		t.Helper()
+		_, err := s.Pool.Exec(ctx, `UPDATE users SET notifications_used = $2 WHERE id = $1`, user.ID, n)
+		require.NoError(t, err)
+	}
+	used := func() int {
+		t.Helper()
+		var n int
+		require.NoError(t, s.Pool.QueryRow(ctx, `SELECT notifications_used FROM users WHERE id = $1`, user.ID).Scan(&n))
+		return n
+	}
+	batch := func(symbols ...string) []telegram.AlertNotification {
+		batch := make([]telegram.AlertNotification, len(symbols))
+		for i, symbol := range symbols {
+			batch[i] = telegram.AlertNotification{
+				UserID:         user.ID,
+				TelegramID:     user.TelegramID,
+				CoinSymbol:     symbol,
+				AlertType:      "PRICE_ABOVE",
+				ConditionValue: 100,
+				TriggeredPrice: 101,
+				TriggeredAt:    time.Now(),
+			}
+		}
+		return batch
+	}
+
+	t.Run("batch larger than the quota left", func(t *testing.T) {
+		tg.Reset()
+		setUsed(16)
+
+		require.NoError(t, notifications.SendNotifications(ctx, batch("BTC", "ETH", "SOL")))
+		assert.Equal(t, 18, used())
+		require.Len(t, tg.Messages(), 1)
+		text := tg.Messages()[0].Text
+		assert.Contains(t, text, "BTC")
+		assert.Contains(t, text, "ETH")
+		assert.NotContains(t, text, "SOL", "over the limit")
+
+		err := notifications.SendNotifications(ctx, batch("XRP"))
+		assert.ErrorIs(t, err, notification.ErrMonthlyLimitReached)
+		assert.Equal(t, 18, used())
+		assert.Len(t, tg.Messages(), 1)
+	})
+
+	t.Run("concurrent batches", func(t *testing.T) {
+		tg.Reset()
+		setUsed(14)
+
+		var wg sync.WaitGroup
+		for _, symbols := range [][]string{{"BTC", "ETH"}, {"SOL", "XRP"}, {"ADA", "DOT"}} {
+			wg.Add(1)
+			go func(symbols []string) {
+				defer wg.Done()
+				_ = notifications.SendNotifications(ctx, batch(symbols...))
+			}(symbols)
+		}
+		wg.Wait()
+		assert.Equal(t, 18, used(), "never past the limit")
+	})
+
+	t.Run("reservation released when not sent", func(t *testing.T) {
+		tg.Reset()
+		setUsed(10)
+		tg.FailNext("sendMessage", telegramtest.InternalError, telegramtest.InternalError, telegramtest.InternalError)
+
+		err := notifications.SendNotifications(ctx, batch("BTC", "ETH"))
+		assert.Error(t, err)
+		assert.Equal(t, 10, used())
+	})
+}
+
+// TestSendNotifications_MarksHistory marks the newest unsent history record
+// of a notified trigger, leaving older ones alone
+func TestSendNotifications_MarksHistory(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	tg := telegramtest.NewServer()
+	tgServer := httptest.NewServer(tg)
+	defer tgServer.Close()
+	client := telegram.NewClient("123:integration", testLogger())
+	client.SetAPIURL(tgServer.URL)
+	notifications := notification.NewService(s.Pool, s.Redis, client, "", testLogger())
+	defer notifications.Stop()
+
+	users := service.NewUserService(s.Pool)
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 960002, FirstName: "History"})
+	require.NoError(t, err)
+
+	// Two triggers of LTC within the last minute, the newest one notified next
+	triggeredAt := time.Now()
+	var ids []int64
+	for _, at := range []time.Time{triggeredAt.Add(-20 * time.Second), triggeredAt} {
+		var id int64
+		require.NoError(t, s.Pool.QueryRow(ctx, `
+			INSERT INTO alert_history (
+				user_id, coin_id, alert_type, condition_operator, condition_value, triggered_price, triggered_at
+			)
+			SELECT $1, id, 'PRICE_ABOVE', 'above', 100, 101, $2 FROM coins WHERE symbol = 'LTC'
+			RETURNING id
+		`, user.ID, at).Scan(&id))
+		ids = append(ids, id)
+	}
+
+	require.NoError(t, notifications.SendNotifications(ctx, []telegram.AlertNotification{{
+		UserID:         user.ID,
+		TelegramID:     user.TelegramID,
+		CoinSymbol:     "LTC",
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: 100,
+		TriggeredPrice: 101,
+		TriggeredAt:    triggeredAt,
+	}}))
+
+	sent := func(id int64) bool {
+		t.Helper()
+		var sent bool
+		require.NoError(t, s.Pool.QueryRow(ctx, `
+			SELECT notification_sent FROM alert_history WHERE id = $1
+		`, id).Scan(&sent))
+		return sent
+	}
+	assert.False(t, sent(ids[0]), "older record")
+	assert.True(t, sent(ids[1]), "newest record")
+}
+
+// TestSendTestNotification_CoinDetails quotes the sample coin's stored price
+func TestSendTestNotification_CoinDetails(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	tg := telegramtest.NewServer()
+	tgServer := httptest.NewServer(tg)
+	defer tgServer.Close()
+	client := telegram.NewClient("123:integration", testLogger())
+	client.SetAPIURL(tgServer.URL)
+	notifications := notification.NewService(s.Pool, s.Redis, client, "", testLogger())
+	defer notifications.Stop()
+	subscriber := notification.NewSubscriber(s.Pool, s.Redis, eventbus.NewRedisPubSub(s.Redis), notifications, testLogger())
+
+	users := service.NewUserService(s.Pool)
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 960003, FirstName: "Sample"})
+	require.NoError(t, err)
+
+	var price *float64
+	require.NoError(t, s.Pool.QueryRow(ctx, `SELECT current_price FROM coins WHERE symbol = 'BTC'`).Scan(&price))
+	t.Cleanup(func() {
+		_, err := s.Pool.Exec(context.Background(), `UPDATE coins SET current_price = $1 WHERE symbol = 'BTC'`, price)
+		require.NoError(t, err)
+	})
+	_, err = s.Pool.Exec(ctx, `
+		UPDATE coins SET current_price = 987.654321, price_change_24h_pct = 1.5 WHERE symbol = 'BTC'
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, subscriber.SendTestNotification(ctx, user.ID))
+	messages := tg.Messages()
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0].Text, "BTC")
+	assert.Contains(t, messages[0].Text, "987.6543", "price read from the coin")
+}
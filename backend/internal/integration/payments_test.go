@@ -0,0 +1,165 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/internal/telegram/telegramtest"
+	"github.com/weqory/backend/pkg/crypto"
+)
+
+// newPaymentService returns a payment service talking to a fake Bot API,
+// reading its Star transactions from the start
+func newPaymentService(t *testing.T, s *testStack) (*service.PaymentService, *telegramtest.Server) {
+	t.Helper()
+	_, err := s.Pool.Exec(context.Background(), `DELETE FROM star_transaction_cursors`)
+	require.NoError(t, err)
+
+	tg := telegramtest.NewServer()
+	tgServer := httptest.NewServer(tg)
+	t.Cleanup(tgServer.Close)
+
+	client := telegram.NewClient("123:integration", testLogger())
+	client.SetAPIURL(tgServer.URL)
+	return service.NewPaymentService(s.Pool, client, testLogger()), tg
+}
+
+// createPendingPayment inserts a pending payment and returns its ID
+func createPendingPayment(t *testing.T, ctx context.Context, s *testStack, userID int64, plan string, stars int) int64 {
+	t.Helper()
+	var id int64
+	err := s.Pool.QueryRow(ctx, `
+		INSERT INTO payments (user_id, plan, period, stars_amount)
+		VALUES ($1, $2, 'monthly', $3)
+		RETURNING id
+	`, userID, plan, stars).Scan(&id)
+	require.NoError(t, err)
+	return id
+}
+
+// starPayment builds an incoming Stars transaction paying payload
+func starPayment(t *testing.T, id string, telegramID int64, stars int, payload service.InvoicePayload) telegram.StarTransaction {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return telegram.StarTransaction{
+		ID:     id,
+		Amount: stars,
+		Date:   time.Now().Unix(),
+		Source: &telegram.TransactionPartner{
+			Type:           "user",
+			User:           &telegram.User{ID: telegramID},
+			InvoicePayload: string(data),
+		},
+	}
+}
+
+// TestPaymentReconcile_TrustsPaymentRow completes payments missed by the
+// webhook only when the transaction agrees with the stored payment
+func TestPaymentReconcile_TrustsPaymentRow(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	payer, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 910001, FirstName: "Payer"})
+	require.NoError(t, err)
+	other, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 910002, FirstName: "Other"})
+	require.NoError(t, err)
+
+	payments, tg := newPaymentService(t, s)
+
+	forged := createPendingPayment(t, ctx, s, payer.ID, "pro", 250)
+	stolen := createPendingPayment(t, ctx, s, payer.ID, "pro", 250)
+	valid := createPendingPayment(t, ctx, s, payer.ID, "pro", 250)
+	tg.SetStarTransactions([]telegram.StarTransaction{
+		// Payload names another user and a better plan
+		starPayment(t, "charge-forged", payer.TelegramID, 250, service.InvoicePayload{
+			UserID: other.ID, Plan: "ultimate", Period: "monthly", PaymentID: forged,
+		}),
+		// Paid by someone else than the payment's user
+		starPayment(t, "charge-stolen", other.TelegramID, 250, service.InvoicePayload{
+			UserID: payer.ID, Plan: "pro", Period: "monthly", PaymentID: stolen,
+		}),
+		starPayment(t, "charge-valid", payer.TelegramID, 250, service.InvoicePayload{
+			UserID: payer.ID, Plan: "pro", Period: "monthly", PaymentID: valid,
+		}),
+	})
+
+	result, err := payments.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Completed)
+	require.Len(t, result.Discrepancies, 2)
+	assert.Equal(t, forged, result.Discrepancies[0].PaymentID)
+	assert.Equal(t, "invoice payload differs from the payment", result.Discrepancies[0].Reason)
+	assert.Equal(t, stolen, result.Discrepancies[1].PaymentID)
+
+	var status string
+	require.NoError(t, s.Pool.QueryRow(ctx, `SELECT status FROM payments WHERE id = $1`, forged).Scan(&status))
+	assert.Equal(t, "pending", status)
+
+	// Only the payment's own user got the plan it paid for
+	updated, err := users.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "standard", updated.Plan)
+	updated, err = users.GetByID(ctx, payer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pro", updated.Plan)
+}
+
+// TestPaymentReconcile_PagesThroughHistory finds a recent payment behind
+// more than a page of older transactions, and starts the next run at the
+// lookback instead of the oldest transaction
+func TestPaymentReconcile_PagesThroughHistory(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	payer, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 910003, FirstName: "Payer"})
+	require.NoError(t, err)
+
+	payments, tg := newPaymentService(t, s)
+	paymentID := createPendingPayment(t, ctx, s, payer.ID, "pro", 250)
+
+	// 250 transactions from a month ago, then the missed payment
+	var history []telegram.StarTransaction
+	for i := 0; i < 250; i++ {
+		history = append(history, telegram.StarTransaction{
+			ID:     fmt.Sprintf("charge-old-%d", i),
+			Amount: 250,
+			Date:   time.Now().Add(-30 * 24 * time.Hour).Add(time.Duration(i) * time.Minute).Unix(),
+			Source: &telegram.TransactionPartner{Type: "user", User: &telegram.User{ID: 1}},
+		})
+	}
+	history = append(history, starPayment(t, "charge-recent", payer.TelegramID, 250, service.InvoicePayload{
+		UserID: payer.ID, Plan: "pro", Period: "monthly", PaymentID: paymentID,
+	}))
+	tg.SetStarTransactions(history)
+
+	result, err := payments.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Transactions)
+	assert.Equal(t, 1, result.Completed)
+	assert.Empty(t, result.Discrepancies)
+	assert.Len(t, tg.Requests("getStarTransactions"), 3)
+
+	tg.Reset()
+	_, err = payments.Reconcile(ctx)
+	require.NoError(t, err)
+	requests := tg.Requests("getStarTransactions")
+	require.Len(t, requests, 1)
+	var req telegram.GetStarTransactionsRequest
+	require.NoError(t, json.Unmarshal(requests[0].Body, &req))
+	assert.Equal(t, 250, req.Offset, "resumes at the recent payment")
+}
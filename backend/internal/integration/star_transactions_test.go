@@ -0,0 +1,142 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/crypto"
+)
+
+// TestSyncStarTransactions matches each transaction to its payment and
+// user, sums them up as revenue, and resumes the next sync where this one
+// left off
+func TestSyncStarTransactions(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	payer, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 950001, FirstName: "Payer"})
+	require.NoError(t, err)
+	other, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 950002, FirstName: "Other"})
+	require.NoError(t, err)
+
+	payments, tg := newPaymentService(t, s)
+
+	// Completed through the webhook, which stored the charge ID
+	charged := createPendingPayment(t, ctx, s, payer.ID, "pro", 250)
+	_, err = s.Pool.Exec(ctx, `
+		UPDATE payments SET status = 'completed', telegram_payment_id = 'sync-charge-1' WHERE id = $1
+	`, charged)
+	require.NoError(t, err)
+	pending := createPendingPayment(t, ctx, s, payer.ID, "ultimate", 500)
+
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) int64 {
+		return day.Add(time.Duration(minutes) * time.Minute).Unix()
+	}
+	payment := func(id string, telegramID int64, stars, minutes int, payload service.InvoicePayload) telegram.StarTransaction {
+		tx := starPayment(t, id, telegramID, stars, payload)
+		tx.Date = at(minutes)
+		return tx
+	}
+
+	// Matched by charge ID, without a payload to go by
+	byCharge := telegram.StarTransaction{
+		ID: "sync-charge-1", Amount: 250, Date: at(0),
+		Source: &telegram.TransactionPartner{Type: "user", User: &telegram.User{ID: payer.TelegramID}},
+	}
+	history := []telegram.StarTransaction{
+		byCharge,
+		// Matched by the payment ID of the invoice payload
+		payment("sync-charge-2", payer.TelegramID, 500, 1, service.InvoicePayload{
+			UserID: payer.ID, Plan: "ultimate", Period: "monthly", PaymentID: pending,
+		}),
+		// Names a payment already paid with another charge
+		payment("sync-charge-3", other.TelegramID, 100, 2, service.InvoicePayload{
+			UserID: other.ID, Plan: "pro", Period: "monthly", PaymentID: charged,
+		}),
+		// Refund of the first payment
+		{
+			ID: "sync-charge-1", Amount: 250, Date: at(3),
+			Receiver: &telegram.TransactionPartner{Type: "user", User: &telegram.User{ID: payer.TelegramID}},
+		},
+		// Withdrawal
+		{
+			ID: "sync-withdrawal", Amount: 1000, Date: at(4),
+			Receiver: &telegram.TransactionPartner{Type: "fragment"},
+		},
+	}
+	tg.SetStarTransactions(history)
+
+	result, err := payments.SyncStarTransactions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Read)
+	assert.Equal(t, 3, result.Matched)
+	assert.True(t, result.Complete)
+
+	type stored struct {
+		paymentID *int64
+		userID    *int64
+	}
+	load := func(id, direction string) stored {
+		t.Helper()
+		var row stored
+		require.NoError(t, s.Pool.QueryRow(ctx, `
+			SELECT payment_id, user_id FROM star_transactions WHERE id = $1 AND direction = $2
+		`, id, direction).Scan(&row.paymentID, &row.userID))
+		return row
+	}
+	assertStored := func(id, direction string, paymentID, userID *int64) {
+		t.Helper()
+		row := load(id, direction)
+		assert.Equal(t, paymentID, row.paymentID, "%s %s payment", id, direction)
+		assert.Equal(t, userID, row.userID, "%s %s user", id, direction)
+	}
+	assertStored("sync-charge-1", service.StarDirectionIn, &charged, &payer.ID)
+	assertStored("sync-charge-2", service.StarDirectionIn, &pending, &payer.ID)
+	assertStored("sync-charge-3", service.StarDirectionIn, nil, &other.ID)
+	assertStored("sync-charge-1", service.StarDirectionOut, &charged, &payer.ID)
+	assertStored("sync-withdrawal", service.StarDirectionOut, nil, nil)
+
+	revenue, err := payments.Revenue(ctx, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(850), revenue.Gross)
+	assert.Equal(t, int64(250), revenue.Refunded, "withdrawals are no refunds")
+	assert.Equal(t, int64(600), revenue.Net())
+	assert.Equal(t, int64(3), revenue.Payments)
+	assert.Equal(t, int64(1), revenue.Refunds)
+	assert.Equal(t, int64(1), revenue.Unmatched)
+	assert.Equal(t, []service.PlanRevenue{
+		{Plan: "ultimate", Stars: 500, Payments: 1},
+		{Plan: "pro", Stars: 250, Payments: 1},
+		{Plan: "", Stars: 100, Payments: 1},
+	}, revenue.ByPlan)
+
+	// Outside the period
+	revenue, err = payments.Revenue(ctx, day.Add(-24*time.Hour), day)
+	require.NoError(t, err)
+	assert.Zero(t, revenue.Gross)
+	assert.Empty(t, revenue.ByPlan)
+
+	// The next sync reads only what was added since
+	tg.Reset()
+	tg.SetStarTransactions(append(history, starPayment(t, "sync-charge-4", other.TelegramID, 250, service.InvoicePayload{})))
+	result, err = payments.SyncStarTransactions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Read)
+
+	requests := tg.Requests("getStarTransactions")
+	require.Len(t, requests, 1)
+	var req telegram.GetStarTransactionsRequest
+	require.NoError(t, json.Unmarshal(requests[0].Body, &req))
+	assert.Equal(t, len(history), req.Offset)
+}
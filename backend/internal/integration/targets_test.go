@@ -0,0 +1,68 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+)
+
+// TestTargetService_Update returns the updated target with its progress at
+// the current price, leaving the user's other targets alone
+func TestTargetService_Update(t *testing.T) {
+	s := requireStack(t)
+	log := testLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	prices := cache.NewPriceCache(s.Redis, log)
+	targets := service.NewTargetService(s.Pool, prices, log)
+	users := service.NewUserService(s.Pool)
+
+	owner, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 990004, FirstName: "Targets"})
+	require.NoError(t, err)
+	other, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 990005, FirstName: "Other"})
+	require.NoError(t, err)
+
+	require.NoError(t, prices.Set(ctx, binance.PriceData{Symbol: "LINKUSDT", Price: 10}))
+	target, err := targets.Create(ctx, owner.ID, "LINK", 20, "first")
+	require.NoError(t, err)
+	untouched, err := targets.Create(ctx, owner.ID, "LINK", 5, "second")
+	require.NoError(t, err)
+
+	// Progress from 10 towards 30 at 15
+	require.NoError(t, prices.Set(ctx, binance.PriceData{Symbol: "LINKUSDT", Price: 15}))
+	targetPrice, note := 30.0, "moved"
+	updated, err := targets.Update(ctx, owner.ID, target.ID, service.UpdateTargetParams{TargetPrice: &targetPrice, Note: &note})
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, updated.ID)
+	assert.Equal(t, 30.0, updated.TargetPrice)
+	assert.Equal(t, "moved", updated.Note)
+	assert.Equal(t, "LINK", updated.Coin.Symbol)
+	require.NotNil(t, updated.Progress.ProgressPct)
+	assert.InDelta(t, 25, *updated.Progress.ProgressPct, 0.001)
+
+	list, err := targets.GetByUserID(ctx, owner.ID, pagination.Page{})
+	require.NoError(t, err)
+	for _, item := range list.Items {
+		if item.ID == untouched.ID {
+			assert.Equal(t, 5.0, item.TargetPrice)
+			assert.Equal(t, "second", item.Note)
+		}
+	}
+
+	_, err = targets.Update(ctx, other.ID, target.ID, service.UpdateTargetParams{Note: &note})
+	assert.ErrorIs(t, err, errors.ErrNotOwner)
+	_, err = targets.GetByID(ctx, untouched.ID+1000000)
+	assert.ErrorIs(t, err, errors.ErrTargetNotFound)
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/pagination"
+)
+
+// TestTimestamps_RFC3339 reads timestamptz columns into the services' string
+// timestamps as RFC 3339, which also keys the list cursors
+func TestTimestamps_RFC3339(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+	history := service.NewHistoryService(s.Pool, users)
+
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 990003, FirstName: "Timestamps"})
+	require.NoError(t, err)
+
+	rfc3339 := func(what, value string) {
+		t.Helper()
+		_, err := time.Parse(time.RFC3339, value)
+		assert.NoError(t, err, "%s %q", what, value)
+	}
+
+	t.Run("watchlist", func(t *testing.T) {
+		added, err := watchlist.AddCoin(ctx, user.ID, "ATOM")
+		require.NoError(t, err)
+		rfc3339("added created_at", added.CreatedAt)
+
+		items, err := watchlist.GetByUserID(ctx, user.ID, "", pagination.Page{})
+		require.NoError(t, err)
+		require.NotEmpty(t, items.Items)
+		for _, item := range items.Items {
+			rfc3339("listed created_at", item.CreatedAt)
+		}
+	})
+
+	t.Run("alerts", func(t *testing.T) {
+		created, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+			CoinSymbol:     "ATOM",
+			AlertType:      string(alert.AlertTypePriceAbove),
+			ConditionValue: 50,
+		})
+		require.NoError(t, err)
+		_, err = s.Pool.Exec(ctx, `UPDATE alerts SET last_triggered_at = NOW() WHERE id = $1`, created.ID)
+		require.NoError(t, err)
+
+		stored, err := alerts.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		rfc3339("created_at", stored.CreatedAt)
+		rfc3339("updated_at", stored.UpdatedAt)
+		require.NotNil(t, stored.LastTriggeredAt)
+		rfc3339("last_triggered_at", *stored.LastTriggeredAt)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		for _, ago := range []time.Duration{2 * time.Hour, time.Hour} {
+			_, err := s.Pool.Exec(ctx, `
+				INSERT INTO alert_history (
+					user_id, coin_id, alert_type, condition_operator, condition_value, triggered_price, triggered_at
+				)
+				SELECT $1, id, 'PRICE_ABOVE', 'above', 50, 51, $2 FROM coins WHERE symbol = 'ATOM'
+			`, user.ID, time.Now().Add(-ago))
+			require.NoError(t, err)
+		}
+
+		first, err := history.GetByUserID(ctx, user.ID, pagination.Page{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, first.Items, 1)
+		rfc3339("triggered_at", first.Items[0].TriggeredAt)
+		require.NotEmpty(t, first.NextCursor)
+
+		// The next page starts after the first item's timestamp
+		after, err := pagination.Decode(first.NextCursor)
+		require.NoError(t, err)
+		second, err := history.GetByUserID(ctx, user.ID, pagination.Page{Limit: 1, After: after})
+		require.NoError(t, err)
+		require.Len(t, second.Items, 1)
+		rfc3339("triggered_at", second.Items[0].TriggeredAt)
+		assert.NotEqual(t, first.Items[0].ID, second.Items[0].ID)
+	})
+}
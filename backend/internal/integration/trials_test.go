@@ -0,0 +1,119 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// TestStartTrial redeems a trial once per account, never next to a paid
+// plan, and leaves nothing behind when the upgrade fails
+func TestStartTrial(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	payments, _ := newPaymentService(t, s)
+
+	countTrials := func(telegramID int64) int {
+		var n int
+		require.NoError(t, s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM trials WHERE telegram_id = $1`, telegramID).Scan(&n))
+		return n
+	}
+
+	t.Run("second trial", func(t *testing.T) {
+		user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 930001, FirstName: "Trial"})
+		require.NoError(t, err)
+
+		trial, err := payments.StartTrial(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, service.TrialPlan, trial.Plan)
+		assert.WithinDuration(t, trial.StartedAt.Add(service.TrialDuration), trial.ExpiresAt, time.Second)
+
+		// Back on the free plan once the trial ended
+		_, err = s.Pool.Exec(ctx, `UPDATE users SET plan = 'standard', plan_expires_at = NULL WHERE id = $1`, user.ID)
+		require.NoError(t, err)
+
+		_, err = payments.StartTrial(ctx, user.ID)
+		assert.ErrorIs(t, err, errors.ErrTrialUnavailable)
+		assert.Equal(t, 1, countTrials(user.TelegramID))
+	})
+
+	t.Run("paid plan active", func(t *testing.T) {
+		user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 930002, FirstName: "Paying"})
+		require.NoError(t, err)
+		paymentID := createPendingPayment(t, ctx, s, user.ID, "ultimate", 500)
+		_, err = s.Pool.Exec(ctx, `UPDATE payments SET status = 'completed' WHERE id = $1`, paymentID)
+		require.NoError(t, err)
+		_, err = s.Pool.Exec(ctx, `
+			UPDATE users SET plan = 'ultimate', plan_expires_at = NOW() + INTERVAL '30 days' WHERE id = $1
+		`, user.ID)
+		require.NoError(t, err)
+
+		_, err = payments.StartTrial(ctx, user.ID)
+		assert.ErrorIs(t, err, errors.ErrTrialUnavailable)
+		assert.Equal(t, 0, countTrials(user.TelegramID))
+
+		updated, err := users.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "ultimate", updated.Plan, "paid plan not replaced by the trial")
+
+		// Nor once the subscription ran out
+		_, err = s.Pool.Exec(ctx, `UPDATE users SET plan = 'standard', plan_expires_at = NULL WHERE id = $1`, user.ID)
+		require.NoError(t, err)
+		_, err = payments.StartTrial(ctx, user.ID)
+		assert.ErrorIs(t, err, errors.ErrTrialUnavailable)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		const telegramID = 930003
+		user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: telegramID, FirstName: "Rollback"})
+		require.NoError(t, err)
+
+		// Fail the plan change after the trial row is written
+		_, err = s.Pool.Exec(ctx, `
+			CREATE FUNCTION fail_trial_upgrade() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'plan change failed';
+			END
+			$$ LANGUAGE plpgsql;
+
+			CREATE TRIGGER fail_trial_upgrade BEFORE UPDATE OF plan ON users
+			FOR EACH ROW WHEN (NEW.telegram_id = 930003)
+			EXECUTE FUNCTION fail_trial_upgrade();
+		`)
+		require.NoError(t, err)
+		dropTrigger := func() {
+			_, err := s.Pool.Exec(context.Background(), `
+				DROP TRIGGER IF EXISTS fail_trial_upgrade ON users;
+				DROP FUNCTION IF EXISTS fail_trial_upgrade();
+			`)
+			require.NoError(t, err)
+		}
+		t.Cleanup(dropTrigger)
+
+		_, err = payments.StartTrial(ctx, user.ID)
+		assert.ErrorIs(t, err, errors.ErrDatabase)
+		assert.Equal(t, 0, countTrials(telegramID), "trial row rolled back with the plan change")
+
+		updated, err := users.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "standard", updated.Plan)
+
+		// The failed attempt did not use up the trial
+		dropTrigger()
+		trial, err := payments.StartTrial(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, service.TrialPlan, trial.Plan)
+		assert.Equal(t, 1, countTrials(telegramID))
+	})
+}
@@ -0,0 +1,115 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/binance/binancetest"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+// TestEngine_TriggerNotPersisted sends nothing for a trigger that could not
+// be recorded, and fires the alert once the database takes it again
+func TestEngine_TriggerNotPersisted(t *testing.T) {
+	s := requireStack(t)
+	log := testLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 980001, FirstName: "Trigger"})
+	require.NoError(t, err)
+	_, err = watchlist.AddCoin(ctx, user.ID, "ETH")
+	require.NoError(t, err)
+	created, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+		CoinSymbol:     "ETH",
+		AlertType:      string(alert.AlertTypePriceAbove),
+		ConditionValue: 2050,
+	})
+	require.NoError(t, err)
+
+	// Fail recording a trigger of this alert
+	_, err = s.Pool.Exec(ctx, fmt.Sprintf(`
+		CREATE FUNCTION fail_alert_trigger() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'write failed';
+		END
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER fail_alert_trigger BEFORE UPDATE ON alerts
+		FOR EACH ROW WHEN (OLD.id = %d AND NEW.times_triggered <> OLD.times_triggered)
+		EXECUTE FUNCTION fail_alert_trigger();
+	`, created.ID))
+	require.NoError(t, err)
+	dropTrigger := func() {
+		_, err := s.Pool.Exec(context.Background(), `
+			DROP TRIGGER IF EXISTS fail_alert_trigger ON alerts;
+			DROP FUNCTION IF EXISTS fail_alert_trigger();
+		`)
+		require.NoError(t, err)
+	}
+	t.Cleanup(dropTrigger)
+
+	exchange := binancetest.NewServer([]string{"ETHUSDT"})
+	exchangeServer := httptest.NewServer(exchange)
+	defer exchangeServer.Close()
+
+	binanceClient := binance.NewClient(log)
+	binanceClient.SetBaseURL(binancetest.WSURL(exchangeServer.URL))
+	engine := alert.NewEngine(s.Pool, binanceClient, cache.NewPriceCache(s.Redis, log),
+		alert.NewPricePublisher(eventbus.NewRedisPubSub(s.Redis), log), log)
+
+	var fired atomic.Int32
+	engine.SetTriggerHandler(func(event *alert.TriggerEvent) {
+		if event.AlertID == created.ID {
+			fired.Add(1)
+		}
+	})
+	go engine.Run(ctx)
+	defer engine.Stop()
+
+	waitFor(t, 10*time.Second, "engine subscription to ETHUSDT", func() bool {
+		return exchange.Publish(ticker("ETHUSDT", 2000)) > 0
+	})
+
+	// Crossing the threshold while the write fails
+	for i := 0; i < 3; i++ {
+		exchange.Publish(ticker("ETHUSDT", 2100+float64(i)))
+		time.Sleep(200 * time.Millisecond)
+	}
+	assert.Zero(t, fired.Load(), "not notified")
+
+	var history int
+	require.NoError(t, s.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM alert_history WHERE alert_id = $1
+	`, created.ID).Scan(&history))
+	assert.Zero(t, history)
+
+	// Still armed, so the next tick fires it
+	dropTrigger()
+	waitFor(t, 10*time.Second, "alert fired", func() bool {
+		exchange.Publish(ticker("ETHUSDT", 2110))
+		return fired.Load() > 0
+	})
+
+	stored, err := alerts.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.TimesTriggered)
+	assert.Equal(t, int32(1), fired.Load())
+}
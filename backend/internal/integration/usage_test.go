@@ -0,0 +1,102 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/service"
+	"github.com/weqory/backend/pkg/crypto"
+)
+
+// TestUsageCounters keeps the watchlist and alert counters of plan limit
+// checks in step with inserts and deletes, and corrects drift
+func TestUsageCounters(t *testing.T) {
+	s := requireStack(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	users := service.NewUserService(s.Pool)
+	watchlist := service.NewWatchlistService(s.Pool, users)
+	alerts := service.NewAlertService(s.Pool, users, watchlist, nil)
+
+	user, err := users.GetOrCreateByTelegramID(ctx, &crypto.TelegramUser{ID: 940001, FirstName: "Usage"})
+	require.NoError(t, err)
+
+	usage := func() (coins, alerts int64) {
+		t.Helper()
+		require.NoError(t, s.Pool.QueryRow(ctx, `
+			SELECT coins_used, alerts_used FROM users WHERE id = $1
+		`, user.ID).Scan(&coins, &alerts))
+		return coins, alerts
+	}
+	assertUsage := func(coins, alerts int64) {
+		t.Helper()
+		gotCoins, gotAlerts := usage()
+		assert.Equal(t, coins, gotCoins, "coins_used")
+		assert.Equal(t, alerts, gotAlerts, "alerts_used")
+	}
+	assertUsage(0, 0)
+
+	// Inserts
+	for _, symbol := range []string{"BTC", "ETH"} {
+		_, err := watchlist.AddCoin(ctx, user.ID, symbol)
+		require.NoError(t, err)
+	}
+	var created []int64
+	for _, value := range []float64{70000, 80000} {
+		a, err := alerts.Create(ctx, user.ID, service.CreateAlertParams{
+			CoinSymbol:     "BTC",
+			AlertType:      string(alert.AlertTypePriceAbove),
+			ConditionValue: value,
+		})
+		require.NoError(t, err)
+		created = append(created, a.ID)
+	}
+	_, err = alerts.Create(ctx, user.ID, service.CreateAlertParams{
+		CoinSymbol:     "ETH",
+		AlertType:      string(alert.AlertTypePriceBelow),
+		ConditionValue: 2000,
+	})
+	require.NoError(t, err)
+	assertUsage(2, 3)
+
+	// Deletes, including the alerts removed with their coin
+	require.NoError(t, alerts.Delete(ctx, user.ID, created[0]))
+	assertUsage(2, 2)
+
+	deleted, err := watchlist.RemoveCoin(ctx, user.ID, "BTC")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assertUsage(1, 1)
+
+	// A write bypassing the triggers leaves the counters off until reconciled
+	tx, err := s.Pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, `ALTER TABLE alerts DISABLE TRIGGER count_alerts_usage`)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, `DELETE FROM alerts WHERE user_id = $1`, user.ID)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, `ALTER TABLE alerts ENABLE TRIGGER count_alerts_usage`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+
+	_, err = s.Pool.Exec(ctx, `UPDATE users SET coins_used = 5 WHERE id = $1`, user.ID)
+	require.NoError(t, err)
+	assertUsage(5, 1)
+
+	ids, err := users.ReconcileUsageCounters(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, ids, user.ID)
+	assertUsage(1, 0)
+
+	ids, err = users.ReconcileUsageCounters(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, ids, user.ID, "nothing left to correct")
+}
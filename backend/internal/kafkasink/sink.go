@@ -0,0 +1,313 @@
+// Package kafkasink mirrors the alert engine's price ticks and trigger
+// events, and the gateway's notification interactions, to Kafka topics for
+// downstream analytics and warehousing
+package kafkasink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+)
+
+// Time allowed to flush queued messages on Close
+const closeTimeout = 10 * time.Second
+
+// Config configures the sink
+type Config struct {
+	Brokers           []string
+	TicksTopic        string
+	TriggersTopic     string
+	InteractionsTopic string
+	// Messages are sent per partition once BatchSize have been collected
+	// or BatchTimeout has passed
+	BatchSize    int
+	BatchTimeout time.Duration
+	// none, gzip, snappy, lz4 or zstd
+	Compression string
+	// Messages awaiting delivery beyond this are dropped so a slow or
+	// unreachable cluster never grows memory or stalls the engine
+	BufferSize int
+}
+
+// Stats are delivery metrics since the sink started
+type Stats struct {
+	Enqueued  int64 `json:"enqueued"`
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+	Dropped   int64 `json:"dropped"`
+	Pending   int64 `json:"pending"`
+	Batches   int64 `json:"batches"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// TickMessage is the value of a price tick message, keyed by symbol
+type TickMessage struct {
+	Symbol       string    `json:"symbol"`
+	Price        float64   `json:"price"`
+	Change24hPct float64   `json:"change_24h_pct"`
+	Volume24h    float64   `json:"volume_24h"`
+	Time         time.Time `json:"time"`
+}
+
+// TriggerMessage is the value of a trigger event message, keyed by alert ID
+type TriggerMessage struct {
+	AlertID        int64     `json:"alert_id"`
+	UserID         int64     `json:"user_id"`
+	CoinSymbol     string    `json:"coin_symbol"`
+	AlertType      string    `json:"alert_type"`
+	ConditionValue float64   `json:"condition_value"`
+	TriggeredPrice float64   `json:"triggered_price"`
+	QuoteAsset     string    `json:"quote_asset,omitempty"` // empty for USD prices
+	TriggeredAt    time.Time `json:"triggered_at"`
+	Priority       string    `json:"priority,omitempty"`
+	RequestID      string    `json:"request_id,omitempty"`
+}
+
+// InteractionMessage is the value of a notification interaction message,
+// keyed by user ID
+type InteractionMessage struct {
+	Token      string    `json:"token"`
+	UserID     int64     `json:"user_id"`
+	Action     string    `json:"action"`
+	AlertTypes []string  `json:"alert_types"`
+	Time       time.Time `json:"time"`
+}
+
+// Sink produces ticks and trigger events to Kafka in the background. It
+// implements alert.Sink; Run must be running for messages to be sent
+type Sink struct {
+	writer            *kafka.Writer
+	queue             chan kafka.Message
+	ticksTopic        string
+	triggersTopic     string
+	interactionsTopic string
+	batchSize         int
+	bufferSize        int64
+	logger            *slog.Logger
+
+	enqueued  atomic.Int64
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+	batches   atomic.Int64
+	bytes     atomic.Int64
+}
+
+// New creates a sink producing to the configured brokers. Topics are
+// expected to exist
+func New(cfg Config, logger *slog.Logger) (*Sink, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka sink: no brokers")
+	}
+
+	compression, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.BatchSize < 1 || cfg.BufferSize < 1 {
+		return nil, fmt.Errorf("kafka sink: batch and buffer size must be positive")
+	}
+
+	s := &Sink{
+		queue:             make(chan kafka.Message, cfg.BufferSize),
+		ticksTopic:        cfg.TicksTopic,
+		triggersTopic:     cfg.TriggersTopic,
+		interactionsTopic: cfg.InteractionsTopic,
+		batchSize:         cfg.BatchSize,
+		bufferSize:        int64(cfg.BufferSize),
+		logger:            logger,
+	}
+	s.writer = &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     &kafka.Hash{},
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: cfg.BatchTimeout,
+		Compression:  compression,
+		RequiredAcks: kafka.RequireOne,
+		Async:        true,
+		Completion:   s.complete,
+	}
+
+	return s, nil
+}
+
+// Tick implements alert.Sink
+func (s *Sink) Tick(data binance.PriceData) {
+	s.enqueue(s.ticksTopic, data.Symbol, TickMessage{
+		Symbol:       data.Symbol,
+		Price:        data.Price,
+		Change24hPct: data.ChangePercent,
+		Volume24h:    data.Volume24h,
+		Time:         data.UpdatedAt.UTC(),
+	})
+}
+
+// Trigger implements alert.Sink
+func (s *Sink) Trigger(event *alert.TriggerEvent) {
+	s.enqueue(s.triggersTopic, strconv.FormatInt(event.AlertID, 10), triggerMessage(event))
+}
+
+// Interaction records a user opening or acting on an alert message
+func (s *Sink) Interaction(msg InteractionMessage) {
+	msg.Time = msg.Time.UTC()
+	s.enqueue(s.interactionsTopic, strconv.FormatInt(msg.UserID, 10), msg)
+}
+
+// Stats returns the delivery metrics
+func (s *Sink) Stats() Stats {
+	enqueued := s.enqueued.Load()
+	delivered := s.delivered.Load()
+	failed := s.failed.Load()
+
+	return Stats{
+		Enqueued:  enqueued,
+		Delivered: delivered,
+		Failed:    failed,
+		Dropped:   s.dropped.Load(),
+		Pending:   enqueued - delivered - failed,
+		Batches:   s.batches.Load(),
+		Bytes:     s.bytes.Load(),
+	}
+}
+
+// Run hands queued messages to the writer until ctx is done. The writer
+// looks up partitions on the calling goroutine, which blocks while the
+// cluster is unreachable, so this never runs on the engine's hot path
+func (s *Sink) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-s.queue:
+			s.write(ctx, s.collect(msg))
+		}
+	}
+}
+
+// Close hands the remaining queued messages to the writer, then flushes
+// them and closes the connections. Call it after Run has returned
+func (s *Sink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	for len(s.queue) > 0 {
+		s.write(ctx, s.collect(<-s.queue))
+	}
+
+	return s.writer.Close()
+}
+
+// collect returns msg followed by the messages already queued, up to a batch
+func (s *Sink) collect(msg kafka.Message) []kafka.Message {
+	batch := []kafka.Message{msg}
+	for len(batch) < s.batchSize {
+		select {
+		case m := <-s.queue:
+			batch = append(batch, m)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// write hands a batch to the writer, which completes it asynchronously
+func (s *Sink) write(ctx context.Context, batch []kafka.Message) {
+	if err := s.writer.WriteMessages(ctx, batch...); err != nil {
+		s.failed.Add(int64(len(batch)))
+		s.logger.Warn("kafka write failed",
+			slog.Int("messages", len(batch)),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// enqueue queues a message unless the buffer is full
+func (s *Sink) enqueue(topic, key string, value any) {
+	// Pending also counts messages the writer has not completed yet
+	if s.Stats().Pending >= s.bufferSize {
+		s.dropped.Add(1)
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Error("failed to marshal kafka message",
+			slog.String("topic", topic),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	// Counted first so Run never completes a message not yet enqueued
+	s.enqueued.Add(1)
+	select {
+	case s.queue <- kafka.Message{Topic: topic, Key: []byte(key), Value: data}:
+	default:
+		s.enqueued.Add(-1)
+		s.dropped.Add(1)
+	}
+}
+
+// complete records the outcome of a batch
+func (s *Sink) complete(messages []kafka.Message, err error) {
+	s.batches.Add(1)
+	if err != nil {
+		s.failed.Add(int64(len(messages)))
+		s.logger.Warn("kafka batch delivery failed",
+			slog.Int("messages", len(messages)),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	var size int64
+	for _, m := range messages {
+		size += int64(len(m.Key) + len(m.Value))
+	}
+	s.delivered.Add(int64(len(messages)))
+	s.bytes.Add(size)
+}
+
+// triggerMessage converts a trigger event
+func triggerMessage(event *alert.TriggerEvent) TriggerMessage {
+	return TriggerMessage{
+		AlertID:        event.AlertID,
+		UserID:         event.UserID,
+		CoinSymbol:     event.CoinSymbol,
+		AlertType:      string(event.AlertType),
+		ConditionValue: event.ConditionValue,
+		TriggeredPrice: event.TriggeredPrice,
+		QuoteAsset:     event.QuoteAsset,
+		TriggeredAt:    event.TriggeredAt.UTC(),
+		Priority:       event.Priority,
+		RequestID:      event.RequestID,
+	}
+}
+
+// parseCompression maps a codec name to the writer's compression
+func parseCompression(name string) (kafka.Compression, error) {
+	switch name {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("kafka sink: unknown compression %q", name)
+	}
+}
@@ -0,0 +1,89 @@
+package kafkasink
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+)
+
+func TestParseCompression(t *testing.T) {
+	c, err := parseCompression("zstd")
+	require.NoError(t, err)
+	assert.Equal(t, kafka.Zstd, c)
+
+	c, err = parseCompression("none")
+	require.NoError(t, err)
+	assert.Zero(t, c)
+
+	_, err = parseCompression("brotli")
+	assert.Error(t, err)
+}
+
+// newUnreachableSink returns a sink whose broker refuses connections
+func newUnreachableSink(t *testing.T, bufferSize int) *Sink {
+	t.Helper()
+
+	sink, err := New(Config{
+		Brokers:       []string{"127.0.0.1:1"},
+		TicksTopic:    "ticks",
+		TriggersTopic: "triggers",
+		BatchSize:     100,
+		BatchTimeout:  time.Hour,
+		BufferSize:    bufferSize,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return sink
+}
+
+func TestSink_DropsWhenBufferFull(t *testing.T) {
+	// Without Run, messages stay queued
+	sink := newUnreachableSink(t, 2)
+
+	for i := 0; i < 4; i++ {
+		sink.Tick(binance.PriceData{Symbol: "BTCUSDT", Price: 50000, UpdatedAt: time.Now()})
+	}
+	sink.Trigger(&alert.TriggerEvent{AlertID: 7, CoinSymbol: "BTC", AlertType: alert.AlertTypePriceAbove})
+
+	stats := sink.Stats()
+	assert.Equal(t, int64(2), stats.Enqueued)
+	assert.Equal(t, int64(3), stats.Dropped)
+	assert.Equal(t, int64(2), stats.Pending)
+}
+
+func TestSink_CountsFailedWrites(t *testing.T) {
+	sink := newUnreachableSink(t, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sink.Run(ctx)
+
+	sink.Tick(binance.PriceData{Symbol: "ETHUSDT", Price: 3000, UpdatedAt: time.Now()})
+	sink.Tick(binance.PriceData{Symbol: "BTCUSDT", Price: 50000, UpdatedAt: time.Now()})
+
+	require.Eventually(t, func() bool { return sink.Stats().Failed == 2 }, 3*time.Second, 10*time.Millisecond)
+	assert.Zero(t, sink.Stats().Pending)
+}
+
+func TestTriggerMessage(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("X", 3600))
+	msg := triggerMessage(&alert.TriggerEvent{
+		AlertID:        7,
+		UserID:         3,
+		CoinSymbol:     "BTC",
+		AlertType:      alert.AlertTypePriceAbove,
+		ConditionValue: 70000,
+		TriggeredPrice: 70010,
+		TriggeredAt:    at,
+	})
+
+	assert.Equal(t, "PRICE_ABOVE", msg.AlertType)
+	assert.Equal(t, time.UTC, msg.TriggeredAt.Location())
+	assert.True(t, at.Equal(msg.TriggeredAt))
+}
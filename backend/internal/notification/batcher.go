@@ -0,0 +1,164 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/internal/telegram"
+)
+
+const (
+	// Default aggregation window for alerts of the same user
+	defaultBatchWindow = 3 * time.Second
+
+	// Default max alerts combined into one message before flushing early
+	defaultBatchMaxSize = 10
+
+	// Bound on sending a flushed batch (the subscriber context may already
+	// be cancelled when pending batches are flushed on shutdown)
+	batchSendTimeout = 30 * time.Second
+)
+
+// batchItem is a queued notification and the event it came from
+type batchItem struct {
+	eventID      string
+	requestID    string
+	priority     string
+	notification telegram.AlertNotification
+}
+
+// pendingBatch collects a user's notifications until its window closes
+type pendingBatch struct {
+	items []batchItem
+	timer *time.Timer
+}
+
+// batchFunc sends the notifications collected for one user
+type batchFunc func(ctx context.Context, items []batchItem)
+
+// batcher aggregates notifications per user over a short window so several
+// alerts that trigger together are sent as one combined message
+type batcher struct {
+	window  time.Duration
+	maxSize int
+	send    batchFunc
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	pending map[int64]*pendingBatch
+	stopped bool
+
+	inflight sync.WaitGroup
+}
+
+// newBatcher creates a new batcher
+// The window starts with the first notification of a user; maxSize flushes
+// a batch early once it holds that many notifications
+func newBatcher(window time.Duration, maxSize int, send batchFunc, logger *slog.Logger) *batcher {
+	if maxSize < 1 {
+		maxSize = defaultBatchMaxSize
+	}
+
+	return &batcher{
+		window:  window,
+		maxSize: maxSize,
+		send:    send,
+		logger:  logger,
+		pending: make(map[int64]*pendingBatch),
+	}
+}
+
+// Add queues a notification for its user
+func (b *batcher) Add(item batchItem) {
+	userID := item.notification.UserID
+
+	b.mu.Lock()
+	if b.stopped || b.window <= 0 {
+		b.mu.Unlock()
+		b.sendBatch([]batchItem{item})
+		return
+	}
+
+	pb, ok := b.pending[userID]
+	if !ok {
+		pb = &pendingBatch{}
+		pb.timer = time.AfterFunc(b.window, func() { b.flushUser(userID, pb) })
+		b.pending[userID] = pb
+	}
+	pb.items = append(pb.items, item)
+
+	// High-priority alerts are not held back by the window
+	if len(pb.items) < b.maxSize && item.priority != PriorityHigh {
+		b.mu.Unlock()
+		return
+	}
+
+	// Full or urgent batch: send now from the caller's goroutine
+	pb.timer.Stop()
+	delete(b.pending, userID)
+	b.mu.Unlock()
+
+	b.sendBatch(pb.items)
+}
+
+// flushUser sends a user's batch when its window closes
+func (b *batcher) flushUser(userID int64, pb *pendingBatch) {
+	b.mu.Lock()
+	// The batch may already have been sent because it filled up or on Stop
+	if b.pending[userID] != pb {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, userID)
+	b.inflight.Add(1)
+	b.mu.Unlock()
+
+	defer b.inflight.Done()
+	b.sendBatch(pb.items)
+}
+
+// sendBatch sends items with a bounded context
+func (b *batcher) sendBatch(items []batchItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), batchSendTimeout)
+	defer cancel()
+
+	if len(items) > 1 {
+		b.logger.Debug("sending combined notification",
+			slog.Int64("user_id", items[0].notification.UserID),
+			slog.Int("alerts", len(items)),
+		)
+	}
+
+	b.send(ctx, items)
+}
+
+// PendingCount returns the number of notifications waiting in open windows
+func (b *batcher) PendingCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := 0
+	for _, pb := range b.pending {
+		count += len(pb.items)
+	}
+	return count
+}
+
+// Stop flushes all pending batches and waits for in-flight sends
+// Notifications added after Stop are sent immediately
+func (b *batcher) Stop() {
+	b.mu.Lock()
+	b.stopped = true
+	pending := b.pending
+	b.pending = make(map[int64]*pendingBatch)
+	b.mu.Unlock()
+
+	for _, pb := range pending {
+		pb.timer.Stop()
+		b.sendBatch(pb.items)
+	}
+
+	b.inflight.Wait()
+}
@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+// batchRecorder collects the batches a batcher sends
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]batchItem
+}
+
+func (r *batchRecorder) send(ctx context.Context, items []batchItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, items)
+}
+
+func (r *batchRecorder) snapshot() [][]batchItem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]batchItem(nil), r.batches...)
+}
+
+func newTestItem(eventID string, userID int64) batchItem {
+	return batchItem{
+		eventID:      eventID,
+		notification: telegram.AlertNotification{UserID: userID, CoinSymbol: "BTC"},
+	}
+}
+
+func TestBatcher_CombinesAlertsPerUserWithinWindow(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(50*time.Millisecond, 10, rec.send, testLogger())
+
+	b.Add(newTestItem("a", 1))
+	b.Add(newTestItem("b", 1))
+	b.Add(newTestItem("c", 2))
+	b.Add(newTestItem("d", 1))
+
+	assert.Equal(t, 4, b.PendingCount())
+
+	require.Eventually(t, func() bool { return len(rec.snapshot()) == 2 }, time.Second, 10*time.Millisecond)
+
+	sizes := map[int64]int{}
+	for _, batch := range rec.snapshot() {
+		sizes[batch[0].notification.UserID] = len(batch)
+	}
+	assert.Equal(t, map[int64]int{1: 3, 2: 1}, sizes)
+	assert.Zero(t, b.PendingCount())
+}
+
+func TestBatcher_FlushesEarlyWhenFull(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(time.Hour, 3, rec.send, testLogger())
+
+	for _, id := range []string{"a", "b", "c"} {
+		b.Add(newTestItem(id, 1))
+	}
+
+	batches := rec.snapshot()
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 3)
+}
+
+func TestBatcher_StopFlushesPending(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(time.Hour, 10, rec.send, testLogger())
+
+	b.Add(newTestItem("a", 1))
+	b.Add(newTestItem("b", 1))
+	b.Stop()
+
+	batches := rec.snapshot()
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 2)
+
+	// After Stop, notifications are sent immediately
+	b.Add(newTestItem("c", 1))
+	assert.Len(t, rec.snapshot(), 2)
+}
+
+func TestBatcher_ZeroWindowDisablesBatching(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(0, 10, rec.send, testLogger())
+
+	b.Add(newTestItem("a", 1))
+	b.Add(newTestItem("b", 1))
+
+	assert.Len(t, rec.snapshot(), 2)
+}
+
+func TestBatcher_HighPriorityFlushesImmediately(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(time.Hour, 10, rec.send, testLogger())
+
+	b.Add(newTestItem("a", 1))
+	urgent := newTestItem("b", 1)
+	urgent.priority = PriorityHigh
+	b.Add(urgent)
+
+	batches := rec.snapshot()
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 2, "pending alerts ride along with the urgent one")
+}
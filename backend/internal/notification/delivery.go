@@ -0,0 +1,113 @@
+package notification
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/chart"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/alerttype"
+)
+
+const (
+	// Night hours in the user's timezone, when users who asked for it get
+	// alerts without sound
+	nightStartHour = 22
+	nightEndHour   = 8
+
+	// Price history shown in alert charts
+	chartPeriod = 24 * time.Hour
+
+	// Alert message format picked in the user settings
+	messageFormatCompact = "compact"
+)
+
+// applyDeliverySettings sets how n is delivered from the user's settings:
+// the one-line format, no sound at night and a price chart. Charts are
+// only sent on plans that include them; the setting is kept across plan
+// changes
+func (s *Subscriber) applyDeliverySettings(ctx context.Context, n *telegram.AlertNotification, user *UserDetails) {
+	n.Compact = user.MessageFormat == messageFormatCompact
+	n.Silent = user.SilentAtNight && atNight(s.now(), user.Timezone)
+	if user.IncludeChart && user.AlertCharts {
+		n.Chart = s.priceChart(ctx, n)
+	}
+}
+
+// atNight reports whether now is within the night hours of timezone
+// (UTC when empty or unknown)
+func atNight(now time.Time, timezone string) bool {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	hour := now.In(loc).Hour()
+	return hour >= nightStartHour || hour < nightEndHour
+}
+
+// priceChart draws the coin's price over the last day. Returns nil when
+// charts are not configured, the alert is not about the coin's USD price
+// or there is too little history
+func (s *Subscriber) priceChart(ctx context.Context, n *telegram.AlertNotification) []byte {
+	if s.priceHistory == nil {
+		return nil
+	}
+	if def, ok := alerttype.Lookup(n.AlertType); ok && def.Source == alerttype.SourceGas {
+		return nil
+	}
+	if n.QuoteAsset != "" {
+		return nil
+	}
+
+	log := s.logger.With(slog.String("symbol", n.CoinSymbol))
+
+	key, err := s.getPriceKey(ctx, n.CoinSymbol)
+	if err != nil {
+		log.Warn("failed to look up price key for chart", slog.String("error", err.Error()))
+		return nil
+	}
+	if key == "" {
+		return nil
+	}
+
+	now := s.now()
+	points, err := s.priceHistory.Range(ctx, key, now.Add(-chartPeriod), now)
+	if err != nil {
+		log.Warn("failed to fetch price history for chart", slog.String("error", err.Error()))
+		return nil
+	}
+
+	prices := make([]float64, len(points))
+	for i, p := range points {
+		prices[i] = p.Price
+	}
+	png, err := chart.PNG(prices, chart.DefaultWidth, chart.DefaultHeight)
+	if err != nil {
+		log.Debug("no chart for alert", slog.String("reason", err.Error()))
+		return nil
+	}
+	return png
+}
+
+// getPriceKey returns the key a coin's price history is stored under: its
+// Binance pair or its CoinGecko fallback key, empty if it has neither
+func (s *Subscriber) getPriceKey(ctx context.Context, symbol string) (string, error) {
+	var binanceSymbol, coingeckoID *string
+	err := s.pool.QueryRow(ctx, `
+		SELECT binance_symbol, coingecko_id FROM coins WHERE symbol = $1
+	`, symbol).Scan(&binanceSymbol, &coingeckoID)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case binanceSymbol != nil && *binanceSymbol != "":
+		return *binanceSymbol, nil
+	case coingeckoID != nil && *coingeckoID != "":
+		return alert.FallbackSymbol(*coingeckoID), nil
+	default:
+		return "", nil
+	}
+}
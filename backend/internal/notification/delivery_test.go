@@ -0,0 +1,19 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtNight(t *testing.T) {
+	// 21:30 UTC is 23:30 in Berlin (CEST) and 17:30 in New York (EDT)
+	now := time.Date(2026, 7, 1, 21, 30, 0, 0, time.UTC)
+
+	assert.False(t, atNight(now, ""))
+	assert.True(t, atNight(now, "Europe/Berlin"))
+	assert.False(t, atNight(now, "America/New_York"))
+	assert.True(t, atNight(now.Add(8*time.Hour), "America/New_York"), "01:30")
+	assert.False(t, atNight(now, "Not/AZone"), "unknown zones are UTC")
+}
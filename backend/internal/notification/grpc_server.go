@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"context"
+	"errors"
+
+	notificationv1 "github.com/weqory/backend/api/proto/notification/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer implements the notification service's internal gRPC API
+type GRPCServer struct {
+	notificationv1.UnimplementedNotificationServiceServer
+	service    *Service
+	subscriber *Subscriber
+}
+
+// NewGRPCServer creates a new GRPCServer
+func NewGRPCServer(service *Service, subscriber *Subscriber) *GRPCServer {
+	return &GRPCServer{
+		service:    service,
+		subscriber: subscriber,
+	}
+}
+
+// GetStats returns delivery counters and queue lengths
+func (s *GRPCServer) GetStats(_ context.Context, _ *notificationv1.GetStatsRequest) (*notificationv1.GetStatsResponse, error) {
+	sent, failed, rateLimited := s.service.GetStats()
+
+	return &notificationv1.GetStatsResponse{
+		NotificationsSent:        sent,
+		NotificationsFailed:      failed,
+		NotificationsRateLimited: rateLimited,
+		QueueLength:              int64(s.subscriber.GetQueueLength()),
+		PendingBatched:           int64(s.subscriber.GetPendingBatchCount()),
+	}, nil
+}
+
+// SendTestNotification sends a sample alert message to a user
+func (s *GRPCServer) SendTestNotification(ctx context.Context, req *notificationv1.SendTestNotificationRequest) (*notificationv1.SendTestNotificationResponse, error) {
+	if req.GetUserId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	err := s.subscriber.SendTestNotification(ctx, req.GetUserId())
+	switch {
+	case err == nil:
+		return &notificationv1.SendTestNotificationResponse{}, nil
+	case errors.Is(err, ErrUserNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrNotificationsDisabled), errors.Is(err, ErrUserUnreachable):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrUserRateLimited), errors.Is(err, ErrMonthlyLimitReached):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return nil, status.Errorf(codes.Unavailable, "failed to send test notification: %v", err)
+	}
+}
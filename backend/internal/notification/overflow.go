@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis list holding notifications the full queue could not take,
+	// shared by all replicas
+	overflowKey = "notification:overflow"
+
+	// How often the overflow list is drained back into the queue
+	overflowDrainInterval = 500 * time.Millisecond
+)
+
+// pushOverflow parks a notification the queue is too full for in Redis,
+// reporting whether it was saved
+func (s *Subscriber) pushOverflow(ctx context.Context, payload NotificationPayload) bool {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+
+	if err := s.redis.RPush(ctx, overflowKey, data).Err(); err != nil {
+		s.logger.Error("failed to push notification to overflow list",
+			slog.String("event_id", payload.EventID),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+
+	s.overflowed.Add(1)
+	return true
+}
+
+// overflowLoop feeds the overflow list back into the queue until stopped.
+// Entries left at shutdown are drained on the next start or by another
+// replica
+func (s *Subscriber) overflowLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(overflowDrainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.drainOverflow(ctx)
+		}
+	}
+}
+
+// drainOverflow moves overflowed notifications into the queue while it is
+// below one priority level's size, so every level has room for them
+func (s *Subscriber) drainOverflow(ctx context.Context) {
+	for s.queue.Len() < s.queueSize {
+		data, err := s.redis.LPop(ctx, overflowKey).Bytes()
+		if err != nil {
+			if err != redis.Nil && ctx.Err() == nil {
+				s.logger.Error("failed to pop from overflow list", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		var payload NotificationPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			s.logger.Error("dropping malformed overflow entry", slog.String("error", err.Error()))
+			continue
+		}
+
+		if !s.queue.Push(payload) {
+			// Filled up in the meantime; order does not matter
+			if err := s.redis.LPush(ctx, overflowKey, data).Err(); err != nil {
+				s.dropped.Add(1)
+				s.logger.Error("failed to return notification to overflow list, dropping it",
+					slog.String("event_id", payload.EventID),
+					slog.String("error", err.Error()),
+				)
+			}
+			return
+		}
+		s.drained.Add(1)
+	}
+}
+
+// GetOverflowLength returns the number of notifications waiting in the
+// overflow list
+func (s *Subscriber) GetOverflowLength(ctx context.Context) (int64, error) {
+	return s.redis.LLen(ctx, overflowKey).Result()
+}
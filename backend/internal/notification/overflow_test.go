@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+func TestSubscriber_FullQueueOverflowsToRedis(t *testing.T) {
+	_, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	subscriber := &Subscriber{
+		redis:        client,
+		logger:       testLogger(),
+		processedIDs: make(map[string]time.Time),
+	}
+	subscriber.SetQueueSize(2)
+
+	for i := 0; i < 5; i++ {
+		data, err := json.Marshal(NotificationPayload{EventID: fmt.Sprintf("event-%d", i), UserID: 1})
+		require.NoError(t, err)
+		subscriber.handleMessage(ctx, eventbus.Message{Data: data})
+	}
+
+	assert.Equal(t, 2, subscriber.queue.Len())
+	overflow, err := subscriber.GetOverflowLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), overflow, "nothing is dropped")
+
+	// Nothing is drained until the queue has room
+	subscriber.drainOverflow(ctx)
+	assert.Equal(t, 2, subscriber.queue.Len())
+
+	var sent []string
+	for subscriber.queue.Len() > 0 || overflow > 0 {
+		for {
+			payload, ok := subscriber.queue.TryPop()
+			if !ok {
+				break
+			}
+			sent = append(sent, payload.EventID)
+		}
+		subscriber.drainOverflow(ctx)
+		overflow, err = subscriber.GetOverflowLength(ctx)
+		require.NoError(t, err)
+	}
+
+	assert.ElementsMatch(t, []string{"event-0", "event-1", "event-2", "event-3", "event-4"}, sent)
+	stats := subscriber.GetWorkerStats()
+	assert.Equal(t, int64(3), stats.Overflowed)
+	assert.Equal(t, int64(3), stats.Drained)
+	assert.Equal(t, int64(0), stats.Dropped)
+}
+
+func TestSubscriber_OverflowUnavailableDrops(t *testing.T) {
+	mr, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	subscriber := &Subscriber{
+		redis:        client,
+		logger:       testLogger(),
+		processedIDs: make(map[string]time.Time),
+	}
+	subscriber.SetQueueSize(1)
+	require.True(t, subscriber.queue.Push(NotificationPayload{EventID: "queued"}))
+
+	mr.Close()
+	data, err := json.Marshal(NotificationPayload{EventID: "event-1"})
+	require.NoError(t, err)
+	subscriber.handleMessage(ctx, eventbus.Message{Data: data})
+
+	assert.Equal(t, int64(1), subscriber.GetWorkerStats().Dropped)
+	subscriber.processedMu.RLock()
+	_, processed := subscriber.processedIDs["event-1"]
+	subscriber.processedMu.RUnlock()
+	assert.False(t, processed, "a dropped event can be received again")
+}
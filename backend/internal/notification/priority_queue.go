@@ -0,0 +1,94 @@
+package notification
+
+// Alert delivery priorities carried in NotificationPayload.Priority
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// priorityLevels lists queue levels from most to least urgent
+var priorityLevels = []string{PriorityHigh, PriorityNormal, PriorityLow}
+
+// priorityQueue is a bounded FIFO queue per priority level
+// Workers always take from the most urgent non-empty level, so high-priority
+// triggers overtake queued periodic updates while sends are rate limited
+type priorityQueue struct {
+	levels map[string]chan NotificationPayload
+
+	// ready holds one token per pushed payload to wake idle workers; tokens
+	// may outnumber queued payloads, which only causes a spurious wakeup
+	ready chan struct{}
+}
+
+// newPriorityQueue creates a queue holding up to size payloads per level
+func newPriorityQueue(size int) *priorityQueue {
+	q := &priorityQueue{
+		levels: make(map[string]chan NotificationPayload, len(priorityLevels)),
+		ready:  make(chan struct{}, size*len(priorityLevels)),
+	}
+	for _, p := range priorityLevels {
+		q.levels[p] = make(chan NotificationPayload, size)
+	}
+	return q
+}
+
+// normalizePriority maps unknown or missing priorities to normal
+func normalizePriority(priority string) string {
+	switch priority {
+	case PriorityLow, PriorityHigh:
+		return priority
+	default:
+		return PriorityNormal
+	}
+}
+
+// Push enqueues a payload. Returns false if its level is full
+func (q *priorityQueue) Push(payload NotificationPayload) bool {
+	select {
+	case q.levels[normalizePriority(payload.Priority)] <- payload:
+	default:
+		return false
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// TryPop dequeues the most urgent payload without blocking
+func (q *priorityQueue) TryPop() (NotificationPayload, bool) {
+	for _, p := range priorityLevels {
+		select {
+		case payload := <-q.levels[p]:
+			return payload, true
+		default:
+		}
+	}
+	return NotificationPayload{}, false
+}
+
+// Ready is signalled after each Push
+func (q *priorityQueue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Len returns the total number of queued payloads
+func (q *priorityQueue) Len() int {
+	n := 0
+	for _, ch := range q.levels {
+		n += len(ch)
+	}
+	return n
+}
+
+// LenByPriority returns the number of queued payloads per level
+func (q *priorityQueue) LenByPriority() map[string]int {
+	lens := make(map[string]int, len(q.levels))
+	for p, ch := range q.levels {
+		lens[p] = len(ch)
+	}
+	return lens
+}
@@ -0,0 +1,39 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityQueue_PopsMostUrgentFirst(t *testing.T) {
+	q := newPriorityQueue(10)
+
+	require.True(t, q.Push(NotificationPayload{EventID: "periodic", Priority: PriorityLow}))
+	require.True(t, q.Push(NotificationPayload{EventID: "normal-1"}))
+	require.True(t, q.Push(NotificationPayload{EventID: "urgent", Priority: PriorityHigh}))
+	require.True(t, q.Push(NotificationPayload{EventID: "normal-2", Priority: "bogus"}))
+
+	var order []string
+	for {
+		p, ok := q.TryPop()
+		if !ok {
+			break
+		}
+		order = append(order, p.EventID)
+	}
+
+	assert.Equal(t, []string{"urgent", "normal-1", "normal-2", "periodic"}, order)
+}
+
+func TestPriorityQueue_LevelsAreBoundedIndependently(t *testing.T) {
+	q := newPriorityQueue(1)
+
+	assert.True(t, q.Push(NotificationPayload{Priority: PriorityLow}))
+	assert.False(t, q.Push(NotificationPayload{Priority: PriorityLow}), "low level is full")
+	assert.True(t, q.Push(NotificationPayload{Priority: PriorityHigh}), "a full low level does not block high priority")
+
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, map[string]int{PriorityHigh: 1, PriorityNormal: 0, PriorityLow: 1}, q.LenByPriority())
+}
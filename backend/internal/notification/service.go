@@ -2,6 +2,7 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -9,7 +10,10 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/internal/slo"
+	"github.com/weqory/backend/internal/status"
 	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/clock"
 )
 
 const (
@@ -30,6 +34,16 @@ const (
 	globalRateLimitKey = "notification:rate:global"
 )
 
+// Errors returned when a notification is held back by a limit
+var (
+	ErrMonthlyLimitReached = errors.New("monthly notification limit reached")
+	ErrUserRateLimited     = errors.New("user rate limited")
+)
+
+// ErrUserUnreachable is returned when the user blocked the bot or their
+// chat is gone; their notifications are turned off and not retried
+var ErrUserUnreachable = errors.New("user unreachable")
+
 // Service handles sending notifications to users
 type Service struct {
 	pool         *pgxpool.Pool
@@ -44,6 +58,15 @@ type Service struct {
 	rateLimited  int64
 	mu           sync.RWMutex
 
+	// sleep waits between send attempts; replaced in tests
+	sleep func(time.Duration)
+	clock clock.Clock
+
+	// status records delivery for the public status page, optional
+	status *status.Tracker
+	// latency records how long alerts took through the pipeline, optional
+	latency *slo.Recorder
+
 	done chan struct{}
 }
 
@@ -61,27 +84,91 @@ func NewService(
 		telegram:   telegramClient,
 		miniAppURL: miniAppURL,
 		logger:     logger,
+		sleep:      time.Sleep,
+		clock:      clock.Real{},
 		done:       make(chan struct{}),
 	}
 }
 
+// SetClock sets the clock rate limit windows are measured by
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetStatusTracker sets where sent and failed notifications are counted
+// for the public status page
+func (s *Service) SetStatusTracker(tracker *status.Tracker) {
+	s.status = tracker
+}
+
+// SetLatencyRecorder sets where the pipeline latency of sent alerts is
+// recorded
+func (s *Service) SetLatencyRecorder(recorder *slo.Recorder) {
+	s.latency = recorder
+}
+
+// now returns the time of the service clock, the system time if unset
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
 // SendNotification sends a notification to a user with rate limiting
 func (s *Service) SendNotification(ctx context.Context, notification telegram.AlertNotification) error {
-	// Check monthly notification limit based on plan
-	monthlyAllowed, err := s.checkMonthlyNotificationLimit(ctx, notification.UserID)
-	if err != nil {
-		s.logger.Error("monthly limit check failed",
-			slog.Int64("user_id", notification.UserID),
-			slog.String("error", err.Error()),
-		)
-		// Continue anyway - better to send than to fail silently
-	} else if !monthlyAllowed {
-		s.mu.Lock()
-		s.rateLimited++
-		s.mu.Unlock()
+	return s.SendNotifications(ctx, []telegram.AlertNotification{notification})
+}
+
+// SendNotifications sends one or more notifications for the same user as a
+// single Telegram message. The batch counts as one message against the
+// per-minute rate limit and as one notification per alert against the plan;
+// a batch larger than what is left of the monthly limit is cut to fit
+func (s *Service) SendNotifications(ctx context.Context, notifications []telegram.AlertNotification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	notification := notifications[0]
+
+	// Reserve the batch against the monthly limit of the plan before
+	// sending, so concurrent batches cannot overrun it; test notifications
+	// only go through the rate limits
+	reserved, counted := 0, notification.IsTest
+	if !notification.IsTest {
+		granted, err := s.reserveNotifications(ctx, notification.UserID, len(notifications))
+		if err != nil {
+			s.logger.Error("monthly limit check failed",
+				slog.Int64("user_id", notification.UserID),
+				slog.String("error", err.Error()),
+			)
+			// Continue anyway - better to send than to fail silently
+		} else if granted == 0 {
+			s.mu.Lock()
+			s.rateLimited++
+			s.mu.Unlock()
 
-		return fmt.Errorf("monthly notification limit reached")
+			s.logger.Warn("user monthly notification limit reached",
+				slog.Int64("user_id", notification.UserID),
+			)
+			return ErrMonthlyLimitReached
+		} else {
+			if granted < len(notifications) {
+				s.logger.Warn("user monthly notification limit reached, batch cut short",
+					slog.Int64("user_id", notification.UserID),
+					slog.Int("alerts", len(notifications)),
+					slog.Int("sent", granted),
+				)
+				notifications = notifications[:granted]
+			}
+			reserved, counted = granted, true
+		}
 	}
+	// Give the reservation back unless the batch was sent
+	defer func() {
+		if reserved > 0 {
+			s.releaseNotifications(context.WithoutCancel(ctx), notification.UserID, reserved)
+		}
+	}()
 
 	// Check user rate limit (per minute)
 	allowed, err := s.checkUserRateLimit(ctx, notification.UserID)
@@ -99,7 +186,7 @@ func (s *Service) SendNotification(ctx context.Context, notification telegram.Al
 		s.logger.Warn("user rate limited",
 			slog.Int64("user_id", notification.UserID),
 		)
-		return fmt.Errorf("user rate limited")
+		return ErrUserRateLimited
 	}
 
 	// Check global rate limit
@@ -108,7 +195,16 @@ func (s *Service) SendNotification(ctx context.Context, notification telegram.Al
 		s.logger.Error("global rate limit check failed", slog.String("error", err.Error()))
 	} else if !globalAllowed {
 		// Wait and retry
-		time.Sleep(100 * time.Millisecond)
+		s.sleep(100 * time.Millisecond)
+	}
+
+	// Alerts get a Mini App link unique to the message, so opens can be
+	// attributed to it; test notifications are not tracked
+	miniAppURL := s.miniAppURL
+	var token string
+	if !notification.IsTest {
+		token = newDeliveryToken()
+		miniAppURL = trackedURL(s.miniAppURL, token)
 	}
 
 	// Send notification with retry
@@ -122,24 +218,42 @@ func (s *Service) SendNotification(ctx context.Context, notification telegram.Al
 		default:
 		}
 
-		result, err := s.telegram.SendAlertNotification(ctx, notification, s.miniAppURL)
+		result, err := s.telegram.SendAlertBatch(ctx, notifications, miniAppURL)
 		if err == nil && result.Success {
 			// Record success
 			s.mu.Lock()
-			s.sentCount++
+			s.sentCount += int64(len(notifications))
 			s.mu.Unlock()
+			s.recordDelivery(ctx, len(notifications), 0)
+			s.recordLatency(notifications)
 
-			// Update history record as notified
-			if err := s.markHistoryNotified(ctx, notification); err != nil {
-				s.logger.Error("failed to mark history notified",
-					slog.Int64("user_id", notification.UserID),
-					slog.String("error", err.Error()),
-				)
+			if notification.IsTest {
+				return nil
+			}
+
+			// Update history records as notified
+			for _, n := range notifications {
+				if err := s.markHistoryNotified(ctx, n); err != nil {
+					s.logger.Error("failed to mark history notified",
+						slog.Int64("user_id", n.UserID),
+						slog.String("error", err.Error()),
+					)
+				}
 			}
 
-			// Increment user notification count
-			if err := s.incrementUserNotificationCount(ctx, notification.UserID); err != nil {
-				s.logger.Error("failed to increment notification count",
+			// Keep the reservation, or count the batch if none was made
+			reserved = 0
+			if !counted {
+				if err := s.incrementUserNotificationCount(ctx, notification.UserID, len(notifications)); err != nil {
+					s.logger.Error("failed to increment notification count",
+						slog.Int64("user_id", notification.UserID),
+						slog.String("error", err.Error()),
+					)
+				}
+			}
+
+			if err := s.recordDeliveryLink(ctx, token, result.MessageID, notifications); err != nil {
+				s.logger.Error("failed to record notification delivery",
 					slog.Int64("user_id", notification.UserID),
 					slog.String("error", err.Error()),
 				)
@@ -150,12 +264,25 @@ func (s *Service) SendNotification(ctx context.Context, notification telegram.Al
 
 		lastErr = err
 
+		// Retrying cannot reach a user who blocked the bot. Not counted
+		// for the status page, Telegram itself is fine
+		if reason := telegram.UnreachableReason(err); reason != "" {
+			s.mu.Lock()
+			s.failedCount += int64(len(notifications))
+			s.mu.Unlock()
+
+			if !notification.IsTest {
+				s.markUnreachable(ctx, notification.UserID, reason)
+			}
+			return fmt.Errorf("%w: %s", ErrUserUnreachable, reason)
+		}
+
 		// Check if rate limited by Telegram
 		if result != nil && result.RetryAfter > 0 {
 			s.logger.Warn("telegram rate limited",
 				slog.Int("retry_after", result.RetryAfter),
 			)
-			time.Sleep(time.Duration(result.RetryAfter) * time.Second)
+			s.sleep(time.Duration(result.RetryAfter) * time.Second)
 			continue
 		}
 
@@ -167,87 +294,124 @@ func (s *Service) SendNotification(ctx context.Context, notification telegram.Al
 			slog.Duration("delay", delay),
 			slog.String("error", err.Error()),
 		)
-		time.Sleep(delay)
+		s.sleep(delay)
 	}
 
 	// Record failure
 	s.mu.Lock()
-	s.failedCount++
+	s.failedCount += int64(len(notifications))
 	s.mu.Unlock()
+	s.recordDelivery(ctx, 0, len(notifications))
 
 	return fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
 }
 
-// checkUserRateLimit checks if user is within rate limit
-func (s *Service) checkUserRateLimit(ctx context.Context, userID int64) (bool, error) {
-	key := fmt.Sprintf("%s%d", userRateLimitKey, userID)
-	now := time.Now().UnixMilli()
-	windowStart := now - userRateLimitWindow.Milliseconds()
-
-	pipe := s.redis.Pipeline()
-
-	// Remove old entries
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart))
-
-	// Count current entries
-	countCmd := pipe.ZCard(ctx, key)
+// markUnreachable turns off notifications for a user the bot can no longer
+// reach and records why, so they can be turned back on when the user
+// starts the bot again
+func (s *Service) markUnreachable(ctx context.Context, userID int64, reason string) {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE users
+		SET notifications_enabled = false,
+			notifications_disabled_reason = $2,
+			notifications_disabled_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND notifications_enabled = true
+	`, userID, reason)
+	if err != nil {
+		s.logger.Error("failed to disable notifications for unreachable user",
+			slog.Int64("user_id", userID),
+			slog.String("reason", reason),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
 
-	// Set expiry with extended TTL to prevent premature deletion
-	pipe.Expire(ctx, key, 2*userRateLimitWindow)
+	s.logger.Info("disabled notifications for unreachable user",
+		slog.Int64("user_id", userID),
+		slog.String("reason", reason),
+	)
+}
 
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		return false, err
+// recordDelivery counts notifications for the status page. Test
+// notifications are counted too; they go through the same Telegram path
+func (s *Service) recordDelivery(ctx context.Context, sent, failed int) {
+	if s.status == nil {
+		return
+	}
+	if err := s.status.RecordDelivery(ctx, sent, failed); err != nil {
+		s.logger.Warn("failed to record delivery status", slog.String("error", err.Error()))
 	}
+}
 
-	count := countCmd.Val()
-	if count >= userMaxNotifications {
-		return false, nil
+// recordLatency records the pipeline latency of sent alerts that carry
+// the stamps of a price tick
+func (s *Service) recordLatency(notifications []telegram.AlertNotification) {
+	if s.latency == nil {
+		return
 	}
 
-	// Add current request with unique member to handle concurrent requests
-	// Use nanosecond precision to ensure uniqueness
-	member := fmt.Sprintf("%d:%d", now, time.Now().UnixNano())
-	if err := s.redis.ZAdd(ctx, key, redis.Z{
-		Score:  float64(now),
-		Member: member,
-	}).Err(); err != nil {
-		return false, fmt.Errorf("failed to add to user rate limit: %w", err)
+	sentAt := s.now()
+	for _, n := range notifications {
+		if n.TickReceivedAt.IsZero() {
+			continue
+		}
+		s.latency.Observe(slo.StageEndToEnd, sentAt.Sub(n.TickReceivedAt))
+		if !n.PublishedAt.IsZero() {
+			s.latency.Observe(slo.StageEngine, n.PublishedAt.Sub(n.TickReceivedAt))
+			s.latency.Observe(slo.StageNotification, sentAt.Sub(n.PublishedAt))
+		}
 	}
+}
+
+// rateLimitScript trims a sliding window and records a send in it when
+// there is room, in one step so concurrent sends cannot all see room for
+// one more. It returns 1 when the send is allowed
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], 0, now - tonumber(ARGV[2]))
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return 1
+`)
 
-	return true, nil
+// checkUserRateLimit checks if user is within rate limit
+func (s *Service) checkUserRateLimit(ctx context.Context, userID int64) (bool, error) {
+	key := fmt.Sprintf("%s%d", userRateLimitKey, userID)
+	allowed, err := s.takeRateLimitSlot(ctx, key, userMaxNotifications, userRateLimitWindow)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user rate limit: %w", err)
+	}
+	return allowed, nil
 }
 
 // checkGlobalRateLimit checks global Telegram API rate limit
 func (s *Service) checkGlobalRateLimit(ctx context.Context) (bool, error) {
-	key := globalRateLimitKey
-	now := time.Now().UnixMilli()
-	windowStart := now - globalRateLimitWindow.Milliseconds()
-
-	pipe := s.redis.Pipeline()
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart))
-	countCmd := pipe.ZCard(ctx, key)
-	pipe.Expire(ctx, key, 2*globalRateLimitWindow) // Extended TTL to prevent premature expiry
-
-	_, err := pipe.Exec(ctx)
+	allowed, err := s.takeRateLimitSlot(ctx, globalRateLimitKey, globalMaxNotifications, globalRateLimitWindow)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("failed to check global rate limit: %w", err)
 	}
+	return allowed, nil
+}
 
-	count := countCmd.Val()
-	if count >= globalMaxNotifications {
-		return false, nil
-	}
+// takeRateLimitSlot records a send in the sliding window at key if fewer
+// than max were sent within the window
+func (s *Service) takeRateLimitSlot(ctx context.Context, key string, max int, window time.Duration) (bool, error) {
+	now := s.now().UnixMilli()
 
-	// Add current request with error check to prevent race condition
-	if err := s.redis.ZAdd(ctx, key, redis.Z{
-		Score:  float64(now),
-		Member: fmt.Sprintf("%d", now),
-	}).Err(); err != nil {
-		return false, fmt.Errorf("failed to add to global rate limit: %w", err)
-	}
+	// Unique per send, as several can happen in the same millisecond
+	member := fmt.Sprintf("%d:%d", now, time.Now().UnixNano())
 
-	return true, nil
+	// Extended TTL to prevent premature deletion
+	allowed, err := rateLimitScript.Run(ctx, s.redis, []string{key},
+		now, window.Milliseconds(), max, member, (2 * window).Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
 }
 
 // markHistoryNotified marks an alert history record as notified
@@ -255,21 +419,24 @@ func (s *Service) markHistoryNotified(ctx context.Context, notification telegram
 	query := `
 		UPDATE alert_history
 		SET notification_sent = true
-		WHERE user_id = $1
-		  AND coin_id = (SELECT id FROM coins WHERE symbol = $2 LIMIT 1)
-		  AND triggered_at >= $3 - INTERVAL '1 minute'
-		  AND notification_sent = false
-		ORDER BY triggered_at DESC
-		LIMIT 1
+		WHERE id = (
+			SELECT id FROM alert_history
+			WHERE user_id = $1
+			  AND coin_id = (SELECT id FROM coins WHERE symbol = $2 LIMIT 1)
+			  AND triggered_at >= $3::timestamptz - INTERVAL '1 minute'
+			  AND notification_sent = false
+			ORDER BY triggered_at DESC
+			LIMIT 1
+		)
 	`
 	_, err := s.pool.Exec(ctx, query, notification.UserID, notification.CoinSymbol, notification.TriggeredAt)
 	return err
 }
 
 // incrementUserNotificationCount increments user's notification usage
-func (s *Service) incrementUserNotificationCount(ctx context.Context, userID int64) error {
-	query := `UPDATE users SET notifications_used = notifications_used + 1, updated_at = NOW() WHERE id = $1`
-	_, err := s.pool.Exec(ctx, query, userID)
+func (s *Service) incrementUserNotificationCount(ctx context.Context, userID int64, count int) error {
+	query := `UPDATE users SET notifications_used = notifications_used + $2, updated_at = NOW() WHERE id = $1`
+	_, err := s.pool.Exec(ctx, query, userID, count)
 	return err
 }
 
@@ -295,30 +462,51 @@ func (s *Service) GetUserNotificationLimit(ctx context.Context, userID int64) (b
 	return canSend, used, maxNotifications, nil
 }
 
-// checkMonthlyNotificationLimit checks if user is under their monthly notification limit
-func (s *Service) checkMonthlyNotificationLimit(ctx context.Context, userID int64) (bool, error) {
-	canSend, used, max, err := s.GetUserNotificationLimit(ctx, userID)
-	if err != nil {
-		s.logger.Error("failed to get notification limit",
-			slog.Int64("user_id", userID),
-			slog.String("error", err.Error()),
+// reserveNotifications counts up to count notifications against the user's
+// monthly limit and returns how many it counted, 0 once the limit is reached
+// or notifications are disabled. The user row is locked while reading the
+// usage, so concurrent reservations never exceed the limit together
+func (s *Service) reserveNotifications(ctx context.Context, userID int64, count int) (int, error) {
+	query := `
+		WITH quota AS (
+			SELECT u.id, CASE
+				WHEN NOT u.notifications_enabled THEN 0
+				WHEN sp.max_notifications IS NULL THEN $2
+				ELSE GREATEST(LEAST($2, sp.max_notifications - u.notifications_used), 0)
+			END AS granted
+			FROM users u
+			JOIN subscription_plans sp ON sp.name = u.plan
+			WHERE u.id = $1
+			FOR UPDATE OF u
 		)
-		// Continue anyway - better to potentially over-notify than miss important alerts
-		return true, err
-	}
+		UPDATE users u
+		SET notifications_used = u.notifications_used + quota.granted, updated_at = NOW()
+		FROM quota
+		WHERE u.id = quota.id
+		RETURNING quota.granted
+	`
 
-	if !canSend {
-		if max != nil {
-			s.logger.Warn("user monthly notification limit reached",
-				slog.Int64("user_id", userID),
-				slog.Int("used", used),
-				slog.Int("max", *max),
-			)
-		}
-		return false, nil
+	var granted int
+	if err := s.pool.QueryRow(ctx, query, userID, count).Scan(&granted); err != nil {
+		return 0, err
 	}
+	return granted, nil
+}
 
-	return true, nil
+// releaseNotifications gives back notifications reserved for a batch that
+// was not sent
+func (s *Service) releaseNotifications(ctx context.Context, userID int64, count int) {
+	query := `
+		UPDATE users SET notifications_used = GREATEST(notifications_used - $2, 0), updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := s.pool.Exec(ctx, query, userID, count); err != nil {
+		s.logger.Error("failed to release notification reservation",
+			slog.Int64("user_id", userID),
+			slog.Int("count", count),
+			slog.String("error", err.Error()),
+		)
+	}
 }
 
 // GetStats returns notification statistics
@@ -3,6 +3,9 @@ package notification
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -11,6 +14,9 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/internal/telegram/telegramtest"
+	"github.com/weqory/backend/pkg/clock"
 )
 
 // setupTestRedis creates a miniredis instance and returns a client connected to it
@@ -160,13 +166,9 @@ func TestCheckUserRateLimit_ConcurrentRequests(t *testing.T) {
 		}
 	}
 
-	// Without atomic operations (Lua), concurrent requests may slightly exceed limit
-	// Allow small tolerance for race conditions in test environment
-	// Real Redis with Lua script would enforce exact limits
-	maxAllowedWithTolerance := int(userMaxNotifications) + 5 // Allow 50% tolerance for race conditions
-	assert.LessOrEqual(t, allowedCount, maxAllowedWithTolerance,
-		"user rate limit exceeded significantly (possible bug)")
-	assert.Greater(t, allowedCount, 0, "at least some requests should be allowed")
+	// The check and the add are one script, so the limit holds exactly
+	assert.Equal(t, userMaxNotifications, allowedCount,
+		"exactly the user rate limit should be allowed")
 }
 
 // TestCheckGlobalRateLimit_NoErrorOnZAddFailure verifies error handling
@@ -343,6 +345,107 @@ func TestUserRateLimit_WindowExpiry(t *testing.T) {
 	assert.Equal(t, int64(1), count, "should have only the new entry")
 }
 
+// TestUserRateLimit_Clock tests that the window follows the service clock
+func TestUserRateLimit_Clock(t *testing.T) {
+	_, redisClient := setupTestRedis(t)
+	ctx := context.Background()
+	userID := int64(777)
+
+	fake := clock.NewFake(time.Now())
+	service := &Service{redis: redisClient}
+	service.SetClock(fake)
+
+	for i := 0; i < int(userMaxNotifications); i++ {
+		allowed, err := service.checkUserRateLimit(ctx, userID)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := service.checkUserRateLimit(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, allowed, "should be denied within the window")
+
+	fake.Advance(userRateLimitWindow + time.Second)
+	allowed, err = service.checkUserRateLimit(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, allowed, "should be allowed once the window has passed")
+}
+
+// newTelegramTestService returns a service sending through a fake Bot API
+// and recording the waits between attempts instead of sleeping
+func newTelegramTestService(t *testing.T) (*Service, *telegramtest.Server, *[]time.Duration) {
+	_, redisClient := setupTestRedis(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	fake := telegramtest.NewServer()
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	client := telegram.NewClient("123:test", logger)
+	client.SetAPIURL(ts.URL)
+
+	var waits []time.Duration
+	service := NewService(nil, redisClient, client, "", logger)
+	service.sleep = func(d time.Duration) { waits = append(waits, d) }
+	return service, fake, &waits
+}
+
+// testNotification skips the database writes of a real alert
+var testNotification = telegram.AlertNotification{
+	UserID:     1,
+	TelegramID: 42,
+	CoinSymbol: "BTC",
+	AlertType:  "PRICE_ABOVE",
+	IsTest:     true,
+}
+
+func TestSendNotification_Retries(t *testing.T) {
+	service, fake, waits := newTelegramTestService(t)
+
+	// Flood control waits as told; other errors back off exponentially
+	fake.FailNext("sendMessage", telegramtest.RateLimited(3), telegramtest.InternalError)
+
+	err := service.SendNotification(context.Background(), testNotification)
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Duration{3 * time.Second, 2 * retryBaseDelay}, *waits)
+	assert.Len(t, fake.Requests("sendMessage"), 3)
+	require.Len(t, fake.Messages(), 1)
+	assert.Equal(t, int64(42), fake.Messages()[0].ChatID)
+
+	sent, failed, _ := service.GetStats()
+	assert.Equal(t, int64(1), sent)
+	assert.Equal(t, int64(0), failed)
+}
+
+func TestSendNotification_GivesUp(t *testing.T) {
+	service, fake, _ := newTelegramTestService(t)
+	fake.FailNext("sendMessage", telegramtest.InternalError, telegramtest.InternalError, telegramtest.InternalError)
+
+	err := service.SendNotification(context.Background(), testNotification)
+	assert.ErrorContains(t, err, "failed after")
+
+	assert.Len(t, fake.Requests("sendMessage"), maxRetries)
+	assert.Empty(t, fake.Messages())
+
+	_, failed, _ := service.GetStats()
+	assert.Equal(t, int64(1), failed)
+}
+
+func TestSendNotification_StopsForBlockedUser(t *testing.T) {
+	service, fake, waits := newTelegramTestService(t)
+	fake.FailNext("sendMessage", telegramtest.BotBlocked)
+
+	err := service.SendNotification(context.Background(), testNotification)
+	assert.ErrorIs(t, err, ErrUserUnreachable)
+	assert.ErrorContains(t, err, telegram.UnreachableBlocked)
+
+	assert.Len(t, fake.Requests("sendMessage"), 1, "no retries")
+	assert.Empty(t, *waits)
+
+	_, failed, _ := service.GetStats()
+	assert.Equal(t, int64(1), failed)
+}
+
 // BenchmarkCheckGlobalRateLimit benchmarks rate limit checking
 func BenchmarkCheckGlobalRateLimit(b *testing.B) {
 	mr, err := miniredis.Run()
@@ -3,27 +3,47 @@ package notification
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/internal/experiment"
+	"github.com/weqory/backend/internal/pricehistory"
 	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/clock"
+	"github.com/weqory/backend/pkg/eventbus"
 )
 
 const (
-	// Redis channel for alert notifications
+	// Event bus topic for alert notifications
 	alertNotificationChannel = "alert:notifications"
 
-	// Worker pool size
-	workerCount = 5
-
-	// Buffer size for notification queue
-	queueBufferSize = 100
-
 	// Maximum size of processedIDs map to prevent unbounded growth
 	maxProcessedIDsSize = 10000
+
+	// Distributed dedup key shared by all notification replicas
+	eventClaimKey = "notification:event:"
+
+	// How long a claimed event ID is remembered (matches the alert engine's
+	// notification TTL, so retried publishes are still deduplicated)
+	eventClaimTTL = 24 * time.Hour
+
+	// A/B test of the single-alert message text
+	notificationCopyExperiment = "notification_copy"
+
+	// Coin shown in test notifications
+	testNotificationSymbol = "BTC"
+)
+
+// Errors returned by SendTestNotification
+var (
+	ErrUserNotFound          = errors.New("user not found")
+	ErrNotificationsDisabled = errors.New("notifications disabled")
 )
 
 // NotificationPayload represents the notification message from alert engine
@@ -35,133 +55,263 @@ type NotificationPayload struct {
 	AlertType      string    `json:"alert_type"`
 	ConditionValue float64   `json:"condition_value"`
 	TriggeredPrice float64   `json:"triggered_price"`
+	QuoteAsset     string    `json:"quote_asset,omitempty"` // empty for USD prices
 	TriggeredAt    time.Time `json:"triggered_at"`
 	CreatedAt      time.Time `json:"created_at"`
+	Priority       string    `json:"priority,omitempty"`
+	AlertName      string    `json:"alert_name,omitempty"`
+	Detail         string    `json:"detail,omitempty"`
+	// RequestID is set by the alert engine so log lines of both services
+	// can be correlated
+	RequestID string `json:"request_id,omitempty"`
+	// TickReceivedAt is when the price tick that fired the alert reached
+	// the alert engine, nil for alerts not fired by a Binance tick
+	TickReceivedAt *time.Time `json:"tick_received_at,omitempty"`
 }
 
-// Subscriber listens for notification events from Redis
+// Subscriber listens for notification events on the event bus
 type Subscriber struct {
 	pool          *pgxpool.Pool
 	redis         *redis.Client
+	bus           eventbus.Subscriber
 	service       *Service
 	logger        *slog.Logger
-	queue         chan NotificationPayload
+	queue         *priorityQueue
 	processedIDs  map[string]time.Time // For deduplication
 	processedMu   sync.RWMutex
+	batcher       *batcher
+	throttle      *coinThrottle
+	experiments   *experiment.Service
+	priceHistory  *pricehistory.Store
+	clock         clock.Clock
 	wg            sync.WaitGroup
 	done          chan struct{}
+
+	// Worker pool, scaled between minWorkers and maxWorkers with the
+	// queue depth. Workers run with ctx, the context Run was called with
+	ctx          context.Context
+	minWorkers   int
+	maxWorkers   int
+	queueSize    int
+	workers      atomic.Int32
+	busy         atomic.Int32
+	nextWorkerID atomic.Int32
+	retire       chan struct{}
+	scaleMu      sync.Mutex
+	shallowSince time.Time
+	maxQueueLen  atomic.Int64
+	dropped      atomic.Int64
+	overflowed   atomic.Int64
+	drained      atomic.Int64
+	scaleUps     atomic.Int64
+	scaleDowns   atomic.Int64
 }
 
 // NewSubscriber creates a new notification subscriber
 func NewSubscriber(
 	pool *pgxpool.Pool,
 	redisClient *redis.Client,
+	bus eventbus.Subscriber,
 	service *Service,
 	logger *slog.Logger,
 ) *Subscriber {
-	return &Subscriber{
+	s := &Subscriber{
 		pool:         pool,
 		redis:        redisClient,
+		bus:          bus,
 		service:      service,
 		logger:       logger,
-		queue:        make(chan NotificationPayload, queueBufferSize),
+		queue:        newPriorityQueue(DefaultQueueSize),
 		processedIDs: make(map[string]time.Time),
+		clock:        clock.Real{},
 		done:         make(chan struct{}),
+		minWorkers:   DefaultMinWorkers,
+		maxWorkers:   DefaultMaxWorkers,
+		queueSize:    DefaultQueueSize,
+		retire:       make(chan struct{}),
 	}
+	s.batcher = newBatcher(defaultBatchWindow, defaultBatchMaxSize, s.sendBatch, logger)
+
+	return s
+}
+
+// SetBatching configures how long notifications for the same user are
+// collected into one message (0 sends every notification immediately)
+// Must be called before Run
+func (s *Subscriber) SetBatching(window time.Duration, maxSize int) {
+	s.batcher = newBatcher(window, maxSize, s.sendBatch, s.logger)
+}
+
+// SetCoinThrottle limits each user to one message per coin within window
+// (0 disables the throttle). Must be called before Run
+func (s *Subscriber) SetCoinThrottle(window time.Duration) {
+	s.throttle = nil
+	if window > 0 {
+		s.throttle = newCoinThrottle(s.redis, window, s.sendThrottleSummary, s.logger)
+	}
+}
+
+// SetExperiments enables experiment assignment for notification copy
+// Must be called before Run
+func (s *Subscriber) SetExperiments(experiments *experiment.Service) {
+	s.experiments = experiments
+}
+
+// SetPriceHistory enables price charts for users who want them with their
+// alerts. Must be called before Run
+func (s *Subscriber) SetPriceHistory(store *pricehistory.Store) {
+	s.priceHistory = store
+}
+
+// SetClock sets the clock mutes and deduplication are checked against
+// Must be called before Run
+func (s *Subscriber) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// now returns the time of the subscriber clock, the system time if unset
+func (s *Subscriber) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
 }
 
 // Run starts the subscriber
 func (s *Subscriber) Run(ctx context.Context) error {
 	s.logger.Info("starting notification subscriber")
 
-	// Start worker pool
-	for i := 0; i < workerCount; i++ {
+	// Start worker pool, grown with the queue when allowed to
+	s.ctx = ctx
+	s.startWorkers(s.minWorkers)
+	if s.maxWorkers > s.minWorkers {
 		s.wg.Add(1)
-		go s.worker(ctx, i)
+		go s.scaleLoop(ctx)
 	}
 
 	// Start cleanup goroutine for processed IDs
 	s.wg.Add(1)
 	go s.cleanupLoop(ctx)
 
-	// Subscribe to Redis channel
-	pubsub := s.redis.Subscribe(ctx, alertNotificationChannel)
-	defer pubsub.Close()
+	// Feed notifications the full queue parked in Redis back in
+	s.wg.Add(1)
+	go s.overflowLoop(ctx)
+
+	// Stop ends the subscription without cancelling the workers' context
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-subCtx.Done():
+		}
+	}()
+
+	// Scale up ahead of bursts the alert engine announces
+	if s.maxWorkers > s.minWorkers {
+		s.wg.Add(1)
+		go s.burstLoop(subCtx)
+	}
 
-	s.logger.Info("subscribed to alert notifications channel")
+	s.logger.Info("subscribing to alert notifications channel")
 
-	// Receive messages
 	for {
-		select {
-		case <-ctx.Done():
+		err := s.bus.Subscribe(subCtx, alertNotificationChannel, s.handleMessage)
+		if ctx.Err() != nil {
 			return ctx.Err()
+		}
+		select {
 		case <-s.done:
 			return nil
 		default:
 		}
 
-		msg, err := pubsub.ReceiveMessage(ctx)
-		if err != nil {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-			s.logger.Error("failed to receive message", slog.String("error", err.Error()))
-			time.Sleep(time.Second)
-			continue
-		}
+		s.logger.Error("failed to receive message", slog.String("error", err.Error()))
+		time.Sleep(time.Second)
+	}
+}
 
-		var payload NotificationPayload
-		if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
-			s.logger.Error("failed to unmarshal notification",
-				slog.String("error", err.Error()),
-			)
-			continue
-		}
+// handleMessage deduplicates a notification event and queues it
+func (s *Subscriber) handleMessage(ctx context.Context, msg eventbus.Message) {
+	var payload NotificationPayload
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		s.logger.Error("failed to unmarshal notification",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
 
-		// Check for duplicate with atomic mark to prevent race condition
-		if !s.tryMarkProcessed(payload.EventID) {
-			s.logger.Debug("skipping duplicate notification",
-				slog.String("event_id", payload.EventID),
-			)
-			continue
-		}
+	// Check for duplicate with atomic mark to prevent race condition
+	if !s.tryMarkProcessed(payload.EventID) {
+		s.logger.Debug("skipping duplicate notification",
+			slog.String("event_id", payload.EventID),
+		)
+		return
+	}
 
-		// Queue for processing
-		select {
-		case s.queue <- payload:
-		default:
-			s.logger.Warn("notification queue full, dropping message",
-				slog.String("event_id", payload.EventID),
-			)
-			// Remove from processed since we're not processing it
-			s.removeProcessed(payload.EventID)
+	// Every replica receives the message; only the one that claims it sends
+	if !s.claimEvent(ctx, payload.EventID) {
+		s.logger.Debug("notification claimed by another replica",
+			slog.String("event_id", payload.EventID),
+		)
+		return
+	}
+
+	// Queue for processing
+	if s.queue.Push(payload) {
+		if n := int64(s.queue.Len()); n > s.maxQueueLen.Load() {
+			s.maxQueueLen.Store(n)
 		}
+	} else if s.pushOverflow(ctx, payload) {
+		// Still claimed; sent once the queue has room
+		s.logger.Warn("notification queue full, parked message in overflow list",
+			slog.String("event_id", payload.EventID),
+			slog.String("request_id", payload.RequestID),
+			slog.String("priority", normalizePriority(payload.Priority)),
+		)
+	} else {
+		s.dropped.Add(1)
+		s.logger.Error("notification queue full and overflow list unavailable, dropping message",
+			slog.String("event_id", payload.EventID),
+			slog.String("request_id", payload.RequestID),
+			slog.String("priority", normalizePriority(payload.Priority)),
+		)
+		// Remove from processed since we're not processing it
+		s.removeProcessed(payload.EventID)
+		s.releaseEvent(ctx, payload.EventID)
 	}
 }
 
 // worker processes notifications from the queue
 func (s *Subscriber) worker(ctx context.Context, id int) {
 	defer s.wg.Done()
+	defer s.workers.Add(-1)
 
 	s.logger.Debug("notification worker started", slog.Int("worker_id", id))
 
 	for {
+		// Most urgent first; only wait when every level is empty
+		if payload, ok := s.queue.TryPop(); ok {
+			s.busy.Add(1)
+			s.processNotification(ctx, payload)
+			s.busy.Add(-1)
+			continue
+		}
+
 		select {
 		case <-ctx.Done():
 			s.logger.Debug("worker stopped: context cancelled", slog.Int("worker_id", id))
 			return
+		case <-s.retire:
+			s.logger.Debug("worker retired", slog.Int("worker_id", id))
+			return
 		case <-s.done:
 			// Don't return immediately - drain the queue first
 			s.logger.Debug("worker draining queue", slog.Int("worker_id", id))
 			s.drainQueue(ctx)
 			return
-		case payload, ok := <-s.queue:
-			if !ok {
-				// Queue closed, exit gracefully
-				s.logger.Debug("worker stopped: queue closed", slog.Int("worker_id", id))
-				return
-			}
-			s.processNotification(ctx, payload)
+		case <-s.queue.Ready():
 		}
 	}
 }
@@ -169,12 +319,13 @@ func (s *Subscriber) worker(ctx context.Context, id int) {
 // drainQueue processes remaining items in the queue during shutdown
 func (s *Subscriber) drainQueue(ctx context.Context) {
 	for {
-		select {
-		case payload, ok := <-s.queue:
-			if !ok {
-				return
-			}
+		if payload, ok := s.queue.TryPop(); ok {
 			s.processNotification(ctx, payload)
+			continue
+		}
+
+		select {
+		case <-s.queue.Ready():
 		case <-time.After(100 * time.Millisecond):
 			// No more items, exit
 			return
@@ -184,10 +335,12 @@ func (s *Subscriber) drainQueue(ctx context.Context) {
 
 // processNotification handles a single notification
 func (s *Subscriber) processNotification(ctx context.Context, payload NotificationPayload) {
+	log := s.logger.With(slog.String("request_id", payload.RequestID))
+
 	// Fetch user details
 	user, err := s.getUserDetails(ctx, payload.UserID)
 	if err != nil {
-		s.logger.Error("failed to fetch user details",
+		log.Error("failed to fetch user details",
 			slog.Int64("user_id", payload.UserID),
 			slog.String("error", err.Error()),
 		)
@@ -196,16 +349,25 @@ func (s *Subscriber) processNotification(ctx context.Context, payload Notificati
 
 	// Check if user can receive notifications
 	if !user.NotificationsEnabled {
-		s.logger.Debug("user notifications disabled",
+		log.Debug("user notifications disabled",
 			slog.Int64("user_id", payload.UserID),
 		)
 		return
 	}
 
+	// Muted with the mute-all switch; the alert is still in history
+	if user.AlertsMuted(s.now()) {
+		log.Debug("user alerts muted",
+			slog.Int64("user_id", payload.UserID),
+			slog.Time("muted_until", *user.AlertsMutedUntil),
+		)
+		return
+	}
+
 	// Check notification limit
 	canSend, used, max, err := s.service.GetUserNotificationLimit(ctx, payload.UserID)
 	if err != nil {
-		s.logger.Error("failed to check notification limit",
+		log.Error("failed to check notification limit",
 			slog.Int64("user_id", payload.UserID),
 			slog.String("error", err.Error()),
 		)
@@ -215,7 +377,7 @@ func (s *Subscriber) processNotification(ctx context.Context, payload Notificati
 		if max != nil {
 			maxVal = *max
 		}
-		s.logger.Warn("user notification limit reached",
+		log.Warn("user notification limit reached",
 			slog.Int64("user_id", payload.UserID),
 			slog.Int("used", used),
 			slog.Int("max", maxVal),
@@ -226,7 +388,7 @@ func (s *Subscriber) processNotification(ctx context.Context, payload Notificati
 	// Fetch coin details
 	coin, err := s.getCoinDetails(ctx, payload.CoinSymbol)
 	if err != nil {
-		s.logger.Error("failed to fetch coin details",
+		log.Error("failed to fetch coin details",
 			slog.String("symbol", payload.CoinSymbol),
 			slog.String("error", err.Error()),
 		)
@@ -234,37 +396,156 @@ func (s *Subscriber) processNotification(ctx context.Context, payload Notificati
 
 	// Build notification
 	notification := telegram.AlertNotification{
+		AlertID:        payload.AlertID,
 		UserID:         payload.UserID,
 		TelegramID:     user.TelegramID,
 		CoinSymbol:     payload.CoinSymbol,
 		CoinName:       coin.Name,
+		AlertName:      payload.AlertName,
+		Detail:         payload.Detail,
 		AlertType:      payload.AlertType,
 		ConditionValue: payload.ConditionValue,
 		TriggeredPrice: payload.TriggeredPrice,
+		QuoteAsset:     payload.QuoteAsset,
 		TriggeredAt:    payload.TriggeredAt,
+		Timezone:       user.Timezone,
+		Language:       user.LanguageCode,
+		NumberFormat:   user.NumberFormat,
+		PublishedAt:    payload.CreatedAt,
+	}
+	if payload.TickReceivedAt != nil {
+		notification.TickReceivedAt = *payload.TickReceivedAt
 	}
 
-	// Calculate price change if available
-	if coin.CurrentPrice > 0 && coin.PriceChange24h != nil {
+	// Calculate price change if available; the coin's change is in USD
+	if coin.CurrentPrice > 0 && coin.PriceChange24h != nil && payload.QuoteAsset == "" {
 		notification.PriceChange = *coin.PriceChange24h
 	}
 
-	// Send notification
-	if err := s.service.SendNotification(ctx, notification); err != nil {
-		s.logger.Error("failed to send notification",
+	// Users enrolled in the copy experiment get their variant's text
+	if s.experiments != nil {
+		variant, enrolled, err := s.experiments.Assign(ctx, notificationCopyExperiment, payload.UserID)
+		if err != nil {
+			log.Warn("failed to assign experiment variant",
+				slog.String("experiment", notificationCopyExperiment),
+				slog.String("error", err.Error()),
+			)
+		} else if enrolled {
+			notification.CopyVariant = variant
+		}
+	}
+
+	// A coin that keeps triggering alerts gets one message per throttle
+	// window; the rest are summed up when it closes
+	if s.throttle != nil && !s.throttle.Allow(ctx, &notification) {
+		log.Debug("notification held back by coin throttle",
 			slog.Int64("user_id", payload.UserID),
-			slog.String("error", err.Error()),
+			slog.String("symbol", payload.CoinSymbol),
 		)
+		return
 	}
 
+	s.applyDeliverySettings(ctx, &notification, user)
+
+	// Alerts of the same user triggering together are combined into one message
+	s.batcher.Add(batchItem{
+		eventID:      payload.EventID,
+		requestID:    payload.RequestID,
+		priority:     normalizePriority(payload.Priority),
+		notification: notification,
+	})
+
 	// Note: Already marked as processed when event was received
 }
 
+// sendThrottleSummary sends the latest alert held back by the coin
+// throttle, unless the user turned notifications off or muted alerts since
+func (s *Subscriber) sendThrottleSummary(ctx context.Context, n telegram.AlertNotification) {
+	user, err := s.getUserDetails(ctx, n.UserID)
+	if err != nil {
+		s.logger.Error("failed to fetch user details",
+			slog.Int64("user_id", n.UserID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if !user.NotificationsEnabled || user.AlertsMuted(s.now()) {
+		return
+	}
+	s.applyDeliverySettings(ctx, &n, user)
+
+	s.batcher.Add(batchItem{
+		priority:     PriorityNormal,
+		notification: n,
+	})
+}
+
+// sendBatch sends the notifications collected for one user
+func (s *Subscriber) sendBatch(ctx context.Context, items []batchItem) {
+	notifications := make([]telegram.AlertNotification, len(items))
+	requestIDs := make([]string, 0, len(items))
+	for i, item := range items {
+		notifications[i] = item.notification
+		if item.requestID != "" {
+			requestIDs = append(requestIDs, item.requestID)
+		}
+	}
+
+	if err := s.service.SendNotifications(ctx, notifications); err != nil {
+		// Already logged by the service; redelivery would not reach them either
+		if errors.Is(err, ErrUserUnreachable) {
+			return
+		}
+		s.logger.Error("failed to send notification",
+			slog.Int64("user_id", notifications[0].UserID),
+			slog.Int("alerts", len(notifications)),
+			slog.Any("request_ids", requestIDs),
+			slog.String("error", err.Error()),
+		)
+		// Let redelivered events be sent again
+		for _, item := range items {
+			if item.eventID != "" {
+				s.releaseEvent(ctx, item.eventID)
+			}
+		}
+		return
+	}
+
+	// Combined messages use their own format, so only single alerts count
+	// as an exposure to the copy experiment
+	if s.experiments != nil && len(items) == 1 && notifications[0].CopyVariant != "" {
+		n := notifications[0]
+		if err := s.experiments.LogExposure(ctx, notificationCopyExperiment, n.UserID, n.CopyVariant); err != nil {
+			s.logger.Warn("failed to log experiment exposure",
+				slog.String("experiment", notificationCopyExperiment),
+				slog.Int64("user_id", n.UserID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
 // UserDetails holds user information needed for notifications
 type UserDetails struct {
 	ID                   int64
 	TelegramID           int64
 	NotificationsEnabled bool
+	Timezone             string
+	LanguageCode         string
+	NumberFormat         string
+	// All alerts are muted until this time; nil when not muted
+	AlertsMutedUntil *time.Time
+	// Message settings, see applyDeliverySettings
+	MessageFormat string
+	IncludeChart  bool
+	SilentAtNight bool
+	// The user's plan includes alert charts
+	AlertCharts bool
+}
+
+// AlertsMuted reports whether the user muted all alerts until after now
+func (u *UserDetails) AlertsMuted(now time.Time) bool {
+	return u.AlertsMutedUntil != nil && u.AlertsMutedUntil.After(now)
 }
 
 // CoinDetails holds coin information
@@ -275,15 +556,57 @@ type CoinDetails struct {
 	PriceChange24h *float64
 }
 
+// SendTestNotification sends a sample alert to a user through the regular
+// delivery path, rate limits included. It skips the queue and batching so
+// the caller learns whether delivery worked
+func (s *Subscriber) SendTestNotification(ctx context.Context, userID int64) error {
+	user, err := s.getUserDetails(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !user.NotificationsEnabled {
+		return ErrNotificationsDisabled
+	}
+
+	coin, _ := s.getCoinDetails(ctx, testNotificationSymbol)
+
+	n := telegram.AlertNotification{
+		UserID:         user.ID,
+		TelegramID:     user.TelegramID,
+		CoinSymbol:     coin.Symbol,
+		CoinName:       coin.Name,
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: coin.CurrentPrice,
+		TriggeredPrice: coin.CurrentPrice,
+		TriggeredAt:    s.now(),
+		Timezone:       user.Timezone,
+		Language:       user.LanguageCode,
+		NumberFormat:   user.NumberFormat,
+		IsTest:         true,
+	}
+	s.applyDeliverySettings(ctx, &n, user)
+
+	return s.service.SendNotification(ctx, n)
+}
+
 // getUserDetails fetches user details from database
 func (s *Subscriber) getUserDetails(ctx context.Context, userID int64) (*UserDetails, error) {
 	query := `
-		SELECT id, telegram_id, notifications_enabled
-		FROM users WHERE id = $1
+		SELECT u.id, u.telegram_id, u.notifications_enabled, u.timezone, COALESCE(u.language_code, ''), u.number_format,
+		       u.alerts_muted_until, u.message_format, u.include_chart, u.silent_at_night,
+		       COALESCE(sp.alert_charts, FALSE)
+		FROM users u
+		LEFT JOIN subscription_plans sp ON sp.name = u.plan
+		WHERE u.id = $1
 	`
 	var user UserDetails
 	err := s.pool.QueryRow(ctx, query, userID).Scan(
-		&user.ID, &user.TelegramID, &user.NotificationsEnabled,
+		&user.ID, &user.TelegramID, &user.NotificationsEnabled, &user.Timezone, &user.LanguageCode, &user.NumberFormat,
+		&user.AlertsMutedUntil, &user.MessageFormat, &user.IncludeChart, &user.SilentAtNight,
+		&user.AlertCharts,
 	)
 	return &user, err
 }
@@ -291,7 +614,7 @@ func (s *Subscriber) getUserDetails(ctx context.Context, userID int64) (*UserDet
 // getCoinDetails fetches coin details from database
 func (s *Subscriber) getCoinDetails(ctx context.Context, symbol string) (*CoinDetails, error) {
 	query := `
-		SELECT symbol, name, COALESCE(current_price, 0), price_change_24h
+		SELECT symbol, name, COALESCE(current_price, 0), price_change_24h_pct
 		FROM coins WHERE symbol = $1
 	`
 	var coin CoinDetails
@@ -323,7 +646,7 @@ func (s *Subscriber) tryMarkProcessed(eventID string) bool {
 			slog.Int("size", len(s.processedIDs)),
 		)
 
-		cutoff := time.Now().Add(-10 * time.Minute)
+		cutoff := s.now().Add(-10 * time.Minute)
 		for id, processedAt := range s.processedIDs {
 			if processedAt.Before(cutoff) {
 				delete(s.processedIDs, id)
@@ -336,16 +659,41 @@ func (s *Subscriber) tryMarkProcessed(eventID string) bool {
 				slog.Int("size", len(s.processedIDs)),
 			)
 			// Still mark as processed to prevent infinite growth
-			s.processedIDs[eventID] = time.Now()
+			s.processedIDs[eventID] = s.now()
 			return true
 		}
 	}
 
 	// Mark as processed
-	s.processedIDs[eventID] = time.Now()
+	s.processedIDs[eventID] = s.now()
 	return true
 }
 
+// claimEvent atomically claims an event across all replicas (SET NX)
+// Returns true if this replica should send the notification. Fails open when
+// Redis is unavailable; the in-process check still prevents local duplicates
+func (s *Subscriber) claimEvent(ctx context.Context, eventID string) bool {
+	claimed, err := s.redis.SetNX(ctx, eventClaimKey+eventID, time.Now().Unix(), eventClaimTTL).Result()
+	if err != nil {
+		s.logger.Warn("failed to claim notification event",
+			slog.String("event_id", eventID),
+			slog.String("error", err.Error()),
+		)
+		return true
+	}
+	return claimed
+}
+
+// releaseEvent removes a claim so the event can be processed again
+func (s *Subscriber) releaseEvent(ctx context.Context, eventID string) {
+	if err := s.redis.Del(ctx, eventClaimKey+eventID).Err(); err != nil {
+		s.logger.Warn("failed to release notification event",
+			slog.String("event_id", eventID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
 // removeProcessed removes an event from processed map
 func (s *Subscriber) removeProcessed(eventID string) {
 	s.processedMu.Lock()
@@ -374,7 +722,7 @@ func (s *Subscriber) cleanupLoop(ctx context.Context) {
 
 // cleanupProcessedIDs removes processed IDs older than 1 hour
 func (s *Subscriber) cleanupProcessedIDs() {
-	cutoff := time.Now().Add(-1 * time.Hour)
+	cutoff := s.now().Add(-1 * time.Hour)
 
 	s.processedMu.Lock()
 	defer s.processedMu.Unlock()
@@ -396,17 +744,14 @@ func (s *Subscriber) Stop() {
 	close(s.done)
 
 	// Drain the queue before stopping workers
-	queueLen := len(s.queue)
+	queueLen := s.queue.Len()
 	if queueLen > 0 {
 		s.logger.Info("draining notification queue",
 			slog.Int("pending_notifications", queueLen),
 		)
 	}
 
-	// Close the queue after giving workers time to drain it
-	// Workers will exit when queue is closed
-	time.Sleep(100 * time.Millisecond) // Allow last messages to be queued
-	close(s.queue)
+	// Workers drain the queue and exit once it stays empty
 
 	// Wait for workers to finish processing with timeout
 	done := make(chan struct{})
@@ -420,12 +765,31 @@ func (s *Subscriber) Stop() {
 		s.logger.Info("all workers stopped gracefully")
 	case <-time.After(30 * time.Second):
 		s.logger.Warn("timeout waiting for workers to stop",
-			slog.Int("remaining_queue", len(s.queue)),
+			slog.Int("remaining_queue", s.queue.Len()),
 		)
 	}
+
+	// Held-back alerts are reported with the next message about the coin
+	if s.throttle != nil {
+		s.throttle.Stop()
+	}
+
+	// Send batches whose aggregation window is still open
+	s.batcher.Stop()
 }
 
 // GetQueueLength returns the current queue length
 func (s *Subscriber) GetQueueLength() int {
-	return len(s.queue)
+	return s.queue.Len()
+}
+
+// GetQueueLengthByPriority returns the queue length per priority level
+func (s *Subscriber) GetQueueLengthByPriority() map[string]int {
+	return s.queue.LenByPriority()
+}
+
+// GetPendingBatchCount returns the number of notifications waiting to be
+// combined with other alerts of the same user
+func (s *Subscriber) GetPendingBatchCount() int {
+	return s.batcher.PendingCount()
 }
@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"sync"
@@ -237,3 +238,49 @@ func BenchmarkTryMarkProcessed_Concurrent(b *testing.B) {
 		}
 	})
 }
+
+// TestClaimEvent_SingleReplicaWins verifies only one replica can claim an event
+func TestClaimEvent_SingleReplicaWins(t *testing.T) {
+	_, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	replicaA := &Subscriber{redis: client, logger: testLogger()}
+	replicaB := &Subscriber{redis: client, logger: testLogger()}
+
+	assert.True(t, replicaA.claimEvent(ctx, "event-1"), "first replica should claim the event")
+	assert.False(t, replicaB.claimEvent(ctx, "event-1"), "second replica must not send again")
+	assert.True(t, replicaB.claimEvent(ctx, "event-2"), "other events are unaffected")
+}
+
+// TestClaimEvent_ReleaseAllowsRetry verifies a released event can be claimed again
+func TestClaimEvent_ReleaseAllowsRetry(t *testing.T) {
+	_, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	subscriber := &Subscriber{redis: client, logger: testLogger()}
+
+	require.True(t, subscriber.claimEvent(ctx, "event-1"))
+	subscriber.releaseEvent(ctx, "event-1")
+	assert.True(t, subscriber.claimEvent(ctx, "event-1"))
+}
+
+// TestClaimEvent_Expiry verifies claims expire after the TTL
+func TestClaimEvent_Expiry(t *testing.T) {
+	mr, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	subscriber := &Subscriber{redis: client, logger: testLogger()}
+
+	require.True(t, subscriber.claimEvent(ctx, "event-1"))
+	mr.FastForward(eventClaimTTL + time.Second)
+	assert.True(t, subscriber.claimEvent(ctx, "event-1"))
+}
+
+// TestClaimEvent_FailsOpen verifies notifications still go out when Redis is down
+func TestClaimEvent_FailsOpen(t *testing.T) {
+	mr, client := setupTestRedis(t)
+	mr.Close()
+
+	subscriber := &Subscriber{redis: client, logger: testLogger()}
+	assert.True(t, subscriber.claimEvent(context.Background(), "event-1"))
+}
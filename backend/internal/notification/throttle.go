@@ -0,0 +1,189 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+const (
+	// Set while a user was sent a message about a coin; the throttle
+	// window is its TTL. Shared by all notification replicas
+	coinThrottleKey = "notification:coin_throttle:"
+
+	// Hash of the triggers held back during the window: their count and
+	// the latest one
+	coinHeldKey = "notification:coin_held:"
+)
+
+// throttleFlushFunc sends the summary of the triggers held back for a coin
+type throttleFlushFunc func(ctx context.Context, n telegram.AlertNotification)
+
+// coinThrottle lets at most one message per user and coin through within
+// the window. Triggers in between are held back and summed up in a single
+// message, the latest of them, when the window closes. If the replica
+// holding the timer stops first, the count is reported with the next
+// message about the coin instead
+type coinThrottle struct {
+	redis  *redis.Client
+	window time.Duration
+	flush  throttleFlushFunc
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	stopped bool
+}
+
+// newCoinThrottle creates a new coinThrottle
+func newCoinThrottle(client *redis.Client, window time.Duration, flush throttleFlushFunc, logger *slog.Logger) *coinThrottle {
+	return &coinThrottle{
+		redis:  client,
+		window: window,
+		flush:  flush,
+		logger: logger,
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+// Allow reports whether n may be sent now, setting HeldBack to the
+// triggers it stands for. Otherwise n is held back for the summary.
+// Fails open when Redis is unavailable
+func (t *coinThrottle) Allow(ctx context.Context, n *telegram.AlertNotification) bool {
+	key := throttleKey(n.UserID, n.CoinSymbol)
+
+	ok, err := t.redis.SetNX(ctx, coinThrottleKey+key, 1, t.window).Result()
+	if err != nil {
+		t.warn("failed to check coin throttle", key, err)
+		return true
+	}
+
+	if ok {
+		if count, _ := t.takeHeld(ctx, key); count > 0 {
+			n.HeldBack = count
+		}
+		return true
+	}
+
+	if err := t.hold(ctx, key, n); err != nil {
+		t.warn("failed to hold back notification", key, err)
+		return true
+	}
+	return false
+}
+
+// hold records a throttled trigger and, for the first one of a window,
+// schedules the summary for when the window closes
+func (t *coinThrottle) hold(ctx context.Context, key string, n *telegram.AlertNotification) error {
+	latest, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	pipe := t.redis.TxPipeline()
+	countCmd := pipe.HIncrBy(ctx, coinHeldKey+key, "count", 1)
+	pipe.HSet(ctx, coinHeldKey+key, "latest", latest)
+	pipe.PExpire(ctx, coinHeldKey+key, 2*t.window)
+	ttlCmd := pipe.PTTL(ctx, coinThrottleKey+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if countCmd.Val() == 1 {
+		t.schedule(key, max(ttlCmd.Val(), 0))
+	}
+	return nil
+}
+
+// schedule sends the summary of key after delay
+func (t *coinThrottle) schedule(key string, delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+	if timer, ok := t.timers[key]; ok {
+		timer.Stop()
+	}
+	t.timers[key] = time.AfterFunc(delay, func() { t.release(key) })
+}
+
+// release sends the summary of the triggers held back for key, which
+// opens a new window
+func (t *coinThrottle) release(key string) {
+	t.mu.Lock()
+	delete(t.timers, key)
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchSendTimeout)
+	defer cancel()
+
+	count, latest := t.takeHeld(ctx, key)
+	if count == 0 || latest == nil {
+		return
+	}
+
+	if err := t.redis.Set(ctx, coinThrottleKey+key, 1, t.window).Err(); err != nil {
+		t.warn("failed to restart coin throttle", key, err)
+	}
+
+	latest.HeldBack = count - 1
+	t.flush(ctx, *latest)
+}
+
+// takeHeld removes and returns the triggers held back for key
+func (t *coinThrottle) takeHeld(ctx context.Context, key string) (int, *telegram.AlertNotification) {
+	pipe := t.redis.TxPipeline()
+	heldCmd := pipe.HGetAll(ctx, coinHeldKey+key)
+	pipe.Del(ctx, coinHeldKey+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.warn("failed to read held notifications", key, err)
+		return 0, nil
+	}
+
+	held := heldCmd.Val()
+	count, _ := strconv.Atoi(held["count"])
+	if count == 0 {
+		return 0, nil
+	}
+
+	var latest telegram.AlertNotification
+	if err := json.Unmarshal([]byte(held["latest"]), &latest); err != nil {
+		t.warn("failed to decode held notification", key, err)
+		return count, nil
+	}
+	return count, &latest
+}
+
+// Stop cancels pending summaries. Their counts stay in Redis and are
+// reported with the next message about the coin
+func (t *coinThrottle) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	for key, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, key)
+	}
+}
+
+func (t *coinThrottle) warn(msg, key string, err error) {
+	t.logger.Warn(msg,
+		slog.String("key", key),
+		slog.String("error", err.Error()),
+	)
+}
+
+// throttleKey identifies a user's coin
+func throttleKey(userID int64, symbol string) string {
+	return fmt.Sprintf("%d:%s", userID, symbol)
+}
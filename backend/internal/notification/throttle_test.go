@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+// summaryRecorder collects the summaries a coinThrottle sends
+type summaryRecorder struct {
+	mu        sync.Mutex
+	summaries []telegram.AlertNotification
+}
+
+func (r *summaryRecorder) flush(ctx context.Context, n telegram.AlertNotification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summaries = append(r.summaries, n)
+}
+
+func (r *summaryRecorder) snapshot() []telegram.AlertNotification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]telegram.AlertNotification(nil), r.summaries...)
+}
+
+func TestCoinThrottle_SummarisesHeldBackTriggers(t *testing.T) {
+	_, client := setupTestRedis(t)
+	rec := &summaryRecorder{}
+	throttle := newCoinThrottle(client, 50*time.Millisecond, rec.flush, testLogger())
+	defer throttle.Stop()
+	ctx := context.Background()
+
+	first := telegram.AlertNotification{UserID: 1, CoinSymbol: "BTC", TriggeredPrice: 100}
+	assert.True(t, throttle.Allow(ctx, &first))
+	assert.Zero(t, first.HeldBack)
+
+	for _, price := range []float64{101, 102, 103} {
+		n := telegram.AlertNotification{UserID: 1, CoinSymbol: "BTC", TriggeredPrice: price}
+		assert.False(t, throttle.Allow(ctx, &n))
+	}
+
+	// Other coins and users are throttled separately
+	eth := telegram.AlertNotification{UserID: 1, CoinSymbol: "ETH"}
+	assert.True(t, throttle.Allow(ctx, &eth))
+	other := telegram.AlertNotification{UserID: 2, CoinSymbol: "BTC"}
+	assert.True(t, throttle.Allow(ctx, &other))
+
+	require.Eventually(t, func() bool { return len(rec.snapshot()) == 1 }, time.Second, 10*time.Millisecond)
+	summary := rec.snapshot()[0]
+	assert.Equal(t, 103.0, summary.TriggeredPrice)
+	assert.Equal(t, 2, summary.HeldBack)
+
+	// The summary opens a new window
+	n := telegram.AlertNotification{UserID: 1, CoinSymbol: "BTC"}
+	assert.False(t, throttle.Allow(ctx, &n))
+}
+
+func TestCoinThrottle_ReportsCountAfterStop(t *testing.T) {
+	mr, client := setupTestRedis(t)
+	rec := &summaryRecorder{}
+	throttle := newCoinThrottle(client, time.Minute, rec.flush, testLogger())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		n := telegram.AlertNotification{UserID: 1, CoinSymbol: "BTC"}
+		throttle.Allow(ctx, &n)
+	}
+	throttle.Stop()
+	mr.FastForward(time.Minute)
+
+	n := telegram.AlertNotification{UserID: 1, CoinSymbol: "BTC"}
+	assert.True(t, throttle.Allow(ctx, &n))
+	assert.Equal(t, 2, n.HeldBack)
+	assert.Empty(t, rec.snapshot())
+}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/weqory/backend/internal/telegram"
+)
+
+// DeliveryTokenParam is the Mini App URL parameter carrying the token of
+// the alert message the app was opened from. The app reports it back to
+// POST /api/v1/notifications/interactions
+const DeliveryTokenParam = "nid"
+
+// newDeliveryToken returns a random token identifying one sent message
+func newDeliveryToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// trackedURL adds the delivery token to the Mini App URL. The URL is
+// returned unchanged when empty or unparsable, so the button still works
+func trackedURL(miniAppURL, token string) string {
+	if miniAppURL == "" || token == "" {
+		return miniAppURL
+	}
+	u, err := url.Parse(miniAppURL)
+	if err != nil {
+		return miniAppURL
+	}
+	q := u.Query()
+	q.Set(DeliveryTokenParam, token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// recordDeliveryLink stores the sent message under its token with the
+// alert types it carried, so later opens and clicks can be attributed
+func (s *Service) recordDeliveryLink(ctx context.Context, token string, messageID int64, notifications []telegram.AlertNotification) error {
+	if token == "" {
+		return nil
+	}
+
+	alertTypes := make([]string, len(notifications))
+	for i, n := range notifications {
+		alertTypes[i] = n.AlertType
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO notification_deliveries (token, user_id, telegram_message_id, alert_types)
+		VALUES ($1, $2, $3, $4)
+	`, token, notifications[0].UserID, messageID, alertTypes)
+	return err
+}
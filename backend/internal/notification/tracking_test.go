@@ -0,0 +1,18 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackedURL(t *testing.T) {
+	token := newDeliveryToken()
+	assert.Len(t, token, 32)
+	assert.NotEqual(t, token, newDeliveryToken())
+
+	assert.Equal(t, "https://app.example.com/?nid=abc", trackedURL("https://app.example.com/", "abc"))
+	assert.Equal(t, "https://app.example.com/?nid=abc&tab=alerts", trackedURL("https://app.example.com/?tab=alerts", "abc"))
+	assert.Equal(t, "", trackedURL("", "abc"))
+	assert.Equal(t, "https://app.example.com", trackedURL("https://app.example.com", ""))
+}
@@ -0,0 +1,198 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+const (
+	// Worker pool bounds and queue size per priority level, unless set
+	DefaultMinWorkers = 5
+	DefaultMaxWorkers = 20
+	DefaultQueueSize  = 100
+
+	// How often the worker pool is sized to the queue depth
+	workerScaleInterval = time.Second
+
+	// One worker is added above the minimum per this many queued payloads
+	payloadsPerWorker = 10
+
+	// Extra workers are retired one at a time once the queue has been too
+	// shallow for them this long
+	workerScaleDownDelay = 30 * time.Second
+
+	// Event bus topic on which the alert engine announces bursts
+	alertBurstChannel = "alert:bursts"
+)
+
+// BurstAnnouncement is the alert engine's notice of a burst of
+// notifications, published before the first of them
+type BurstAnnouncement struct {
+	Events     int       `json:"events"`
+	RatePerSec float64   `json:"rate_per_sec"`
+	DurationMs int64     `json:"duration_ms"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// WorkerStats are the worker pool and queue saturation metrics
+type WorkerStats struct {
+	Workers       int     `json:"workers"`
+	BusyWorkers   int     `json:"busy_workers"`
+	MinWorkers    int     `json:"min_workers"`
+	MaxWorkers    int     `json:"max_workers"`
+	QueueLength   int     `json:"queue_length"`
+	QueueCapacity int     `json:"queue_capacity"`
+	Saturation    float64 `json:"saturation"` // queue length over capacity, 0 to 1
+	MaxQueueSeen  int     `json:"max_queue_length"`
+	Dropped       int64   `json:"dropped"`    // lost to a full queue with Redis down
+	Overflowed    int64   `json:"overflowed"` // parked in the overflow list
+	Drained       int64   `json:"drained"`    // fed back from the overflow list
+	ScaleUps      int64   `json:"scale_ups"`
+	ScaleDowns    int64   `json:"scale_downs"`
+}
+
+// targetWorkers returns the workers needed for depth queued payloads
+func targetWorkers(depth, minWorkers, maxWorkers int) int {
+	return min(minWorkers+depth/payloadsPerWorker, maxWorkers)
+}
+
+// SetWorkers sets the bounds the worker pool scales between; the pool is
+// fixed when they are equal. Must be called before Run
+func (s *Subscriber) SetWorkers(minWorkers, maxWorkers int) {
+	s.minWorkers = max(minWorkers, 1)
+	s.maxWorkers = max(maxWorkers, s.minWorkers)
+}
+
+// SetQueueSize sets how many notifications the queue holds per priority
+// level. Must be called before Run
+func (s *Subscriber) SetQueueSize(size int) {
+	s.queueSize = max(size, 1)
+	s.queue = newPriorityQueue(s.queueSize)
+}
+
+// startWorkers starts n more workers
+func (s *Subscriber) startWorkers(n int) {
+	for i := 0; i < n; i++ {
+		s.wg.Add(1)
+		s.workers.Add(1)
+		go s.worker(s.ctx, int(s.nextWorkerID.Add(1)))
+	}
+}
+
+// scaleLoop sizes the worker pool to the queue depth until stopped
+func (s *Subscriber) scaleLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(workerScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.scale(s.queue.Len())
+		}
+	}
+}
+
+// scale starts the workers depth queued payloads need at once, and
+// retires one extra worker once the queue has stayed shallow
+func (s *Subscriber) scale(depth int) {
+	s.scaleMu.Lock()
+	defer s.scaleMu.Unlock()
+
+	target := targetWorkers(depth, s.minWorkers, s.maxWorkers)
+	current := int(s.workers.Load())
+
+	switch {
+	case target > current:
+		s.startWorkers(target - current)
+		s.scaleUps.Add(1)
+		s.shallowSince = time.Time{}
+		s.logger.Info("scaled notification workers up",
+			slog.Int("workers", target),
+			slog.Int("queue_length", depth),
+		)
+
+	case target < current:
+		now := time.Now()
+		if s.shallowSince.IsZero() {
+			s.shallowSince = now
+			return
+		}
+		if now.Sub(s.shallowSince) < workerScaleDownDelay {
+			return
+		}
+		// Only an idle worker takes it; a busy pool keeps its workers
+		select {
+		case s.retire <- struct{}{}:
+			s.scaleDowns.Add(1)
+			s.logger.Info("scaled notification workers down", slog.Int("workers", current-1))
+		default:
+		}
+
+	default:
+		s.shallowSince = time.Time{}
+	}
+}
+
+// burstLoop scales the worker pool up ahead of the bursts the alert
+// engine announces, until ctx is done
+func (s *Subscriber) burstLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		err := s.bus.Subscribe(ctx, alertBurstChannel, func(_ context.Context, msg eventbus.Message) {
+			var announcement BurstAnnouncement
+			if err := json.Unmarshal(msg.Data, &announcement); err != nil {
+				s.logger.Error("failed to unmarshal burst announcement", slog.String("error", err.Error()))
+				return
+			}
+
+			s.logger.Info("notification burst announced",
+				slog.Int("events", announcement.Events),
+				slog.Float64("rate_per_sec", announcement.RatePerSec),
+			)
+			s.scale(s.queue.Len() + announcement.Events)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.logger.Error("failed to receive burst announcements", slog.String("error", err.Error()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// GetWorkerStats returns the worker pool and queue saturation metrics
+func (s *Subscriber) GetWorkerStats() WorkerStats {
+	capacity := s.queueSize * len(priorityLevels)
+	length := s.queue.Len()
+
+	return WorkerStats{
+		Workers:       int(s.workers.Load()),
+		BusyWorkers:   int(s.busy.Load()),
+		MinWorkers:    s.minWorkers,
+		MaxWorkers:    s.maxWorkers,
+		QueueLength:   length,
+		QueueCapacity: capacity,
+		Saturation:    float64(length) / float64(capacity),
+		MaxQueueSeen:  int(s.maxQueueLen.Load()),
+		Dropped:       s.dropped.Load(),
+		Overflowed:    s.overflowed.Load(),
+		Drained:       s.drained.Load(),
+		ScaleUps:      s.scaleUps.Load(),
+		ScaleDowns:    s.scaleDowns.Load(),
+	}
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetWorkers(t *testing.T) {
+	assert.Equal(t, 5, targetWorkers(0, 5, 20))
+	assert.Equal(t, 5, targetWorkers(payloadsPerWorker-1, 5, 20))
+	assert.Equal(t, 6, targetWorkers(payloadsPerWorker, 5, 20))
+	assert.Equal(t, 20, targetWorkers(10000, 5, 20), "capped at the maximum")
+	assert.Equal(t, 3, targetWorkers(10000, 3, 3), "fixed pool")
+}
+
+func TestSubscriber_ScaleUpAndDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscriber := &Subscriber{
+		logger: testLogger(),
+		done:   make(chan struct{}),
+		retire: make(chan struct{}),
+		ctx:    ctx,
+	}
+	subscriber.SetWorkers(2, 5)
+	subscriber.SetQueueSize(DefaultQueueSize)
+	subscriber.startWorkers(2)
+
+	// A burst starts the workers it needs at once
+	subscriber.scale(2 * payloadsPerWorker)
+	assert.Equal(t, 4, subscriber.GetWorkerStats().Workers)
+	assert.Equal(t, int64(1), subscriber.GetWorkerStats().ScaleUps)
+
+	// Workers are only retired once the queue stayed shallow long enough
+	subscriber.scale(0)
+	subscriber.scale(0)
+	assert.Equal(t, 4, subscriber.GetWorkerStats().Workers)
+
+	subscriber.shallowSince = time.Now().Add(-workerScaleDownDelay)
+	require.Eventually(t, func() bool {
+		subscriber.scale(0)
+		return subscriber.GetWorkerStats().Workers == 2
+	}, time.Second, 5*time.Millisecond)
+
+	stats := subscriber.GetWorkerStats()
+	assert.Equal(t, int64(2), stats.ScaleDowns)
+	assert.Equal(t, 3*DefaultQueueSize, stats.QueueCapacity)
+
+	cancel()
+	subscriber.wg.Wait()
+}
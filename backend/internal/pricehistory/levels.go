@@ -0,0 +1,83 @@
+package pricehistory
+
+import (
+	"math"
+	"sort"
+)
+
+// levelMergePct merges swing levels closer than this percent of each other
+const levelMergePct = 0.5
+
+// SwingLevels returns the support and resistance levels of a candle
+// series: lows and highs that are the extreme of the window candles on
+// either side. Nearby levels are merged into their average; both lists
+// are sorted ascending
+func SwingLevels(candles []Candle, window int) (supports, resistances []float64) {
+	for i := window; i < len(candles)-window; i++ {
+		isLow, isHigh := true, true
+		for j := i - window; j <= i+window; j++ {
+			if j == i {
+				continue
+			}
+			if candles[j].Low < candles[i].Low {
+				isLow = false
+			}
+			if candles[j].High > candles[i].High {
+				isHigh = false
+			}
+		}
+		if isLow {
+			supports = append(supports, candles[i].Low)
+		}
+		if isHigh {
+			resistances = append(resistances, candles[i].High)
+		}
+	}
+
+	return mergeLevels(supports), mergeLevels(resistances)
+}
+
+// mergeLevels sorts levels and averages runs within levelMergePct
+func mergeLevels(levels []float64) []float64 {
+	if len(levels) == 0 {
+		return nil
+	}
+	sort.Float64s(levels)
+
+	var merged []float64
+	sum, n := levels[0], 1
+	for _, l := range levels[1:] {
+		avg := sum / float64(n)
+		if (l-avg)/avg*100 <= levelMergePct {
+			sum += l
+			n++
+			continue
+		}
+		merged = append(merged, avg)
+		sum, n = l, 1
+	}
+	return append(merged, sum/float64(n))
+}
+
+// RoundLevels returns the nearest psychological round numbers below and
+// above a price, on a step of half its order of magnitude (e.g. 60000 and
+// 65000 for 64312, 0.45 and 0.5 for 0.4523)
+func RoundLevels(price float64) (below, above float64) {
+	if price <= 0 {
+		return 0, 0
+	}
+
+	step := math.Pow(10, math.Floor(math.Log10(price))-1) * 5
+	below = math.Floor(price/step) * step
+	above = below + step
+	if below == price {
+		below -= step
+	}
+	return roundTo(below, step), roundTo(above, step)
+}
+
+// roundTo removes float noise from a multiple of step
+func roundTo(v, step float64) float64 {
+	precision := math.Pow(10, math.Max(0, -math.Floor(math.Log10(step))+1))
+	return math.Round(v*precision) / precision
+}
@@ -0,0 +1,123 @@
+package pricehistory
+
+import (
+	"math"
+	"time"
+)
+
+// Candle is the open, high, low and close price of an interval
+type Candle struct {
+	Time  time.Time // start of the interval
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// Volatility returns the standard deviation of the log returns between
+// consecutive closes, annualized for candles of the given interval. It is
+// false with fewer than three candles
+func Volatility(candles []Candle, interval time.Duration) (float64, bool) {
+	returns := logReturns(candles)
+	if len(returns) < 2 {
+		return 0, false
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+	return math.Sqrt(variance * periodsPerYear), true
+}
+
+// ATR returns the average true range over the last period candles, using
+// Wilder's smoothing. It is false with period candles or fewer
+func ATR(candles []Candle, period int) (float64, bool) {
+	if period <= 0 || len(candles) <= period {
+		return 0, false
+	}
+
+	trueRange := func(i int) float64 {
+		c, prevClose := candles[i], candles[i-1].Close
+		return math.Max(c.High-c.Low, math.Max(math.Abs(c.High-prevClose), math.Abs(c.Low-prevClose)))
+	}
+
+	var atr float64
+	for i := 1; i <= period; i++ {
+		atr += trueRange(i)
+	}
+	atr /= float64(period)
+
+	for i := period + 1; i < len(candles); i++ {
+		atr = (atr*float64(period-1) + trueRange(i)) / float64(period)
+	}
+
+	return atr, true
+}
+
+// Correlation returns the Pearson correlation of the log returns of two
+// candle series, over the intervals both have. It is false with fewer than
+// three common returns or when either series is flat
+func Correlation(a, b []Candle) (float64, bool) {
+	closes := make(map[int64]float64, len(b))
+	for _, c := range b {
+		closes[c.Time.Unix()] = c.Close
+	}
+
+	var xs, ys []float64
+	for i := 1; i < len(a); i++ {
+		prevB, ok1 := closes[a[i-1].Time.Unix()]
+		curB, ok2 := closes[a[i].Time.Unix()]
+		if !ok1 || !ok2 || a[i-1].Close <= 0 || prevB <= 0 {
+			continue
+		}
+		xs = append(xs, math.Log(a[i].Close/a[i-1].Close))
+		ys = append(ys, math.Log(curB/prevB))
+	}
+	if len(xs) < 3 {
+		return 0, false
+	}
+
+	n := float64(len(xs))
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+
+	return cov / math.Sqrt(varX*varY), true
+}
+
+// logReturns returns the log returns between consecutive closes
+func logReturns(candles []Candle) []float64 {
+	returns := make([]float64, 0, len(candles))
+	for i := 1; i < len(candles); i++ {
+		if candles[i-1].Close <= 0 || candles[i].Close <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(candles[i].Close/candles[i-1].Close))
+	}
+	return returns
+}
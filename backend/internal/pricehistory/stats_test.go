@@ -0,0 +1,93 @@
+package pricehistory
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hourlyCandles returns candles one hour apart with the given closes and
+// a high/low spread of spread around the close
+func hourlyCandles(spread float64, closes ...float64) []Candle {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]Candle, len(closes))
+	for i, c := range closes {
+		candles[i] = Candle{Time: start.Add(time.Duration(i) * time.Hour), Open: c, High: c + spread, Low: c - spread, Close: c}
+	}
+	return candles
+}
+
+func TestVolatility(t *testing.T) {
+	_, ok := Volatility(hourlyCandles(0, 100, 101), time.Hour)
+	assert.False(t, ok)
+
+	// Constant returns have no volatility
+	v, ok := Volatility(hourlyCandles(0, 100, 110, 121, 133.1), time.Hour)
+	require.True(t, ok)
+	assert.InDelta(t, 0, v, 1e-9)
+
+	// Alternating ±ln(1.1) hourly returns, annualized
+	v, ok = Volatility(hourlyCandles(0, 100, 110, 100, 110, 100), time.Hour)
+	require.True(t, ok)
+	r := math.Log(1.1)
+	assert.InDelta(t, math.Sqrt(4*r*r/3*365*24), v, 1e-6)
+}
+
+func TestATR(t *testing.T) {
+	_, ok := ATR(hourlyCandles(1, 100, 100), 2)
+	assert.False(t, ok)
+
+	// Flat closes: the true range is the high-low spread
+	atr, ok := ATR(hourlyCandles(1, 100, 100, 100, 100, 100), 3)
+	require.True(t, ok)
+	assert.InDelta(t, 2, atr, 1e-9)
+
+	// A gap counts from the previous close
+	atr, ok = ATR(hourlyCandles(0, 100, 110, 110), 2)
+	require.True(t, ok)
+	assert.InDelta(t, 5, atr, 1e-9)
+}
+
+func TestCorrelation(t *testing.T) {
+	a := hourlyCandles(0, 100, 110, 105, 120, 115)
+	b := hourlyCandles(0, 50, 55, 52.5, 60, 57.5)
+
+	c, ok := Correlation(a, b)
+	require.True(t, ok)
+	assert.InDelta(t, 1, c, 1e-9)
+
+	inverse := hourlyCandles(0, 100, 90, 95, 80, 85)
+	c, ok = Correlation(a, inverse)
+	require.True(t, ok)
+	assert.Less(t, c, -0.9)
+
+	_, ok = Correlation(a, hourlyCandles(0, 1, 1, 1, 1, 1))
+	assert.False(t, ok)
+}
+
+func TestSwingLevels(t *testing.T) {
+	candles := hourlyCandles(1, 100, 104, 110, 104, 100, 96, 90, 96, 100, 104, 110.2, 104, 100)
+
+	supports, resistances := SwingLevels(candles, 2)
+	assert.Equal(t, []float64{89}, supports)
+	// 111 and 111.2 are merged
+	require.Len(t, resistances, 1)
+	assert.InDelta(t, 111.1, resistances[0], 1e-9)
+}
+
+func TestRoundLevels(t *testing.T) {
+	below, above := RoundLevels(64312)
+	assert.Equal(t, 60000.0, below)
+	assert.Equal(t, 65000.0, above)
+
+	below, above = RoundLevels(0.4523)
+	assert.Equal(t, 0.45, below)
+	assert.Equal(t, 0.5, above)
+
+	below, above = RoundLevels(65000)
+	assert.Equal(t, 60000.0, below)
+	assert.Equal(t, 70000.0, above)
+}
@@ -0,0 +1,129 @@
+// Package pricehistory persists minute price history to Postgres (a
+// TimescaleDB hypertable when available) beyond the 24h kept in Redis
+package pricehistory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MaxPoints caps the points returned by one Range query
+const MaxPoints = 10000
+
+// Point is a price at a moment
+type Point struct {
+	Time  time.Time
+	Price float64
+}
+
+// Store reads and writes the price_history table
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// NewStore creates a new Store
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+// Save writes one price per symbol recorded at the same moment. Points
+// already stored for that moment are kept
+func (s *Store) Save(ctx context.Context, at time.Time, prices map[string]float64) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(prices))
+	values := make([]float64, 0, len(prices))
+	for symbol, price := range prices {
+		symbols = append(symbols, symbol)
+		values = append(values, price)
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO price_history (symbol, recorded_at, price)
+		SELECT symbol, $2, price
+		FROM UNNEST($1::TEXT[], $3::DOUBLE PRECISION[]) AS t(symbol, price)
+		ON CONFLICT (symbol, recorded_at) DO NOTHING
+	`, symbols, at.Truncate(time.Second), values)
+	if err != nil {
+		return fmt.Errorf("failed to save price history: %w", err)
+	}
+
+	return nil
+}
+
+// Range returns the points of a symbol in [from, to), oldest first. Long
+// ranges are downsampled to at most MaxPoints by keeping the last price of
+// each step
+func (s *Store) Range(ctx context.Context, symbol string, from, to time.Time) ([]Point, error) {
+	step := to.Sub(from) / MaxPoints
+	if step < time.Minute {
+		step = time.Minute
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT ON (bucket) bucket, price
+		FROM (
+			SELECT
+				to_timestamp(floor(extract(epoch FROM recorded_at) / $4) * $4) AS bucket,
+				recorded_at, price
+			FROM price_history
+			WHERE symbol = $1 AND recorded_at >= $2 AND recorded_at < $3
+		) p
+		ORDER BY bucket, recorded_at DESC
+	`, symbol, from, to, int64(step/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price history: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]Point, 0)
+	for rows.Next() {
+		var p Point
+		if err := rows.Scan(&p.Time, &p.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan price history: %w", err)
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// Candles returns OHLC candles of a symbol in [from, to), oldest first,
+// aggregated from the stored points. Intervals without points are skipped
+func (s *Store) Candles(ctx context.Context, symbol string, interval time.Duration, from, to time.Time) ([]Candle, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT bucket,
+		       (array_agg(price ORDER BY recorded_at))[1],
+		       MAX(price), MIN(price),
+		       (array_agg(price ORDER BY recorded_at DESC))[1]
+		FROM (
+			SELECT
+				to_timestamp(floor(extract(epoch FROM recorded_at) / $4) * $4) AS bucket,
+				recorded_at, price
+			FROM price_history
+			WHERE symbol = $1 AND recorded_at >= $2 AND recorded_at < $3
+		) p
+		GROUP BY bucket
+		ORDER BY bucket
+	`, symbol, from, to, int64(interval/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query candles: %w", err)
+	}
+	defer rows.Close()
+
+	candles := make([]Candle, 0)
+	for rows.Next() {
+		var c Candle
+		if err := rows.Scan(&c.Time, &c.Open, &c.High, &c.Low, &c.Close); err != nil {
+			return nil, fmt.Errorf("failed to scan candle: %w", err)
+		}
+		candles = append(candles, c)
+	}
+
+	return candles, rows.Err()
+}
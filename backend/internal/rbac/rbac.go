@@ -0,0 +1,64 @@
+// Package rbac defines the roles users can hold and what each role may do
+package rbac
+
+// Role is the access level of a user
+type Role string
+
+// Roles, from least to most privileged
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// Permission is an action guarded by role
+type Permission string
+
+// Permissions
+const (
+	// Read the admin reports: abuse status, audit log, jobs, service stats,
+	// symbol mappings, feature flags and experiments
+	PermViewAdmin Permission = "admin:view"
+	// Lift the write throttle of users caught by abuse detection
+	PermUnlockUsers Permission = "users:unlock"
+	// Act as another user through the regular API
+	PermImpersonate Permission = "users:impersonate"
+	// Grant and revoke roles
+	PermManageRoles Permission = "users:roles"
+	// Change operational state: mappings, delistings, payments, feature
+	// flags, experiments and the alert engine
+	PermOperate Permission = "ops:manage"
+)
+
+// permissions maps each role to what it may do. Regular users hold none:
+// everything they can do is scoped to their own data by the API itself
+var permissions = map[Role]map[Permission]bool{
+	RoleUser: {},
+	RoleSupport: {
+		PermViewAdmin:   true,
+		PermUnlockUsers: true,
+	},
+	RoleAdmin: {
+		PermViewAdmin:   true,
+		PermUnlockUsers: true,
+		PermImpersonate: true,
+		PermManageRoles: true,
+		PermOperate:     true,
+	},
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := permissions[r]
+	return ok
+}
+
+// Can reports whether r grants p. Unknown roles grant nothing
+func (r Role) Can(p Permission) bool {
+	return permissions[r][p]
+}
+
+// Staff reports whether r grants access to the admin API at all
+func (r Role) Staff() bool {
+	return r.Can(PermViewAdmin)
+}
@@ -0,0 +1,25 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_Can(t *testing.T) {
+	assert.False(t, RoleUser.Can(PermViewAdmin))
+	assert.False(t, RoleUser.Staff())
+
+	assert.True(t, RoleSupport.Can(PermViewAdmin))
+	assert.True(t, RoleSupport.Can(PermUnlockUsers))
+	assert.False(t, RoleSupport.Can(PermImpersonate))
+	assert.False(t, RoleSupport.Can(PermManageRoles))
+
+	for _, p := range []Permission{PermViewAdmin, PermUnlockUsers, PermImpersonate, PermManageRoles, PermOperate} {
+		assert.True(t, RoleAdmin.Can(p), p)
+	}
+
+	assert.False(t, Role("root").Valid())
+	assert.False(t, Role("root").Can(PermViewAdmin))
+	assert.False(t, Role("").Staff())
+}
@@ -0,0 +1,19 @@
+package rpc
+
+import (
+	"context"
+
+	enginev1 "github.com/weqory/backend/api/proto/engine/v1"
+)
+
+// EngineRefresher asks the alert engine to reload alerts, so changes made
+// by the api-gateway apply without waiting for the engine's own refresh
+type EngineRefresher struct {
+	Client enginev1.EngineServiceClient
+}
+
+// RefreshAlerts implements service.AlertRefresher
+func (r EngineRefresher) RefreshAlerts(ctx context.Context) error {
+	_, err := r.Client.RefreshAlerts(ctx, &enginev1.RefreshAlertsRequest{})
+	return err
+}
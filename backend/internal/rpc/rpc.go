@@ -0,0 +1,108 @@
+// Package rpc contains the shared setup of the internal gRPC servers and
+// clients (alert-engine and notification, called by the api-gateway)
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenMetadataKey carries the shared internal token on every call
+const tokenMetadataKey = "x-internal-token"
+
+// DefaultCallTimeout bounds calls made without a deadline
+const DefaultCallTimeout = 10 * time.Second
+
+// NewServer creates a gRPC server. Calls must carry token; without a token
+// every call is rejected. Failed calls are logged
+func NewServer(token string, logger *slog.Logger) *grpc.Server {
+	return grpc.NewServer(grpc.ChainUnaryInterceptor(
+		authInterceptor(token),
+		loggingInterceptor(logger),
+	))
+}
+
+// Serve listens on port and serves until ctx is done
+func Serve(ctx context.Context, server *grpc.Server, port string, logger *slog.Logger) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on grpc port: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	logger.Info("grpc server starting", slog.String("port", port))
+	return server.Serve(lis)
+}
+
+// Dial creates a client connection to an internal service. Connecting is
+// lazy, so an unreachable service only fails the calls made to it
+func Dial(addr, token string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(tokenInterceptor(token)),
+	)
+}
+
+// tokenInterceptor attaches the internal token and a default deadline
+func tokenInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, tokenMetadataKey, token)
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, DefaultCallTimeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// authInterceptor rejects calls without the internal token. A server
+// without a token fails closed
+func authInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "internal token not configured")
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(tokenMetadataKey)
+		if len(values) == 0 || subtle.ConstantTimeCompare([]byte(values[0]), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid internal token")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// loggingInterceptor logs calls that return an error
+func loggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			logger.Warn("grpc call failed",
+				slog.String("method", info.FullMethod),
+				slog.String("code", status.Code(err).String()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("error", err.Error()),
+			)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		sent     []string
+		wantCode codes.Code
+	}{
+		{name: "no token configured", token: "", sent: nil, wantCode: codes.Unauthenticated},
+		{name: "empty token sent to unconfigured server", token: "", sent: []string{""}, wantCode: codes.Unauthenticated},
+		{name: "matching token", token: "secret", sent: []string{"secret"}, wantCode: codes.OK},
+		{name: "wrong token", token: "secret", sent: []string{"other"}, wantCode: codes.Unauthenticated},
+		{name: "missing token", token: "secret", sent: nil, wantCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.sent != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(tokenMetadataKey, tt.sent[0]))
+			}
+
+			resp, err := authInterceptor(tt.token)(ctx, nil, info, handler)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "ok", resp)
+			}
+		})
+	}
+}
+
+func TestTokenInterceptor_AddsTokenAndDeadline(t *testing.T) {
+	var got context.Context
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		got = ctx
+		return nil
+	}
+
+	err := tokenInterceptor("secret")(context.Background(), "/test.Service/Call", nil, nil, nil, invoker)
+	require.NoError(t, err)
+
+	md, ok := metadata.FromOutgoingContext(got)
+	require.True(t, ok)
+	assert.Equal(t, []string{"secret"}, md.Get(tokenMetadataKey))
+
+	_, hasDeadline := got.Deadline()
+	assert.True(t, hasDeadline)
+}
@@ -0,0 +1,395 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/weqory/backend/pkg/leader"
+)
+
+// Disabled is the schedule override that turns a job off
+const Disabled = "off"
+
+// JobFunc is the work performed by a job
+type JobFunc func(ctx context.Context) error
+
+// Job describes a periodic task
+type Job struct {
+	// Name identifies the job in logs, metrics and schedule overrides
+	Name string
+	// Schedule is a cron expression, a descriptor (@hourly) or "@every 30s"
+	Schedule string
+	// Jitter delays each run by a random amount up to this duration so
+	// replicas and neighbouring jobs do not fire in lockstep
+	Jitter time.Duration
+	// Timeout bounds a single run (0 means no limit)
+	Timeout time.Duration
+	// LeaderOnly runs the job only on the replica holding leadership
+	LeaderOnly bool
+	// RunOnStart runs the job once immediately after Start
+	RunOnStart bool
+	// Run is the work to perform
+	Run JobFunc
+}
+
+// JobStats is a snapshot of a job's metrics
+type JobStats struct {
+	Name         string        `json:"name"`
+	Schedule     string        `json:"schedule"`
+	LeaderOnly   bool          `json:"leader_only"`
+	Running      bool          `json:"running"`
+	Runs         int64         `json:"runs"`
+	Failures     int64         `json:"failures"`
+	Skipped      int64         `json:"skipped"` // previous run was still going
+	LastRunAt    *time.Time    `json:"last_run_at,omitempty"`
+	LastDuration time.Duration `json:"last_duration_ns"`
+	LastError    string        `json:"last_error,omitempty"`
+	NextRunAt    *time.Time    `json:"next_run_at,omitempty"`
+}
+
+// entry is a registered job with its runtime state
+type entry struct {
+	job Job
+
+	// reset wakes the job loop after its schedule changed
+	reset chan struct{}
+
+	running  atomic.Bool
+	runs     atomic.Int64
+	failures atomic.Int64
+	skipped  atomic.Int64
+
+	mu           sync.RWMutex
+	spec         string
+	schedule     Schedule // nil while the job is switched off
+	lastRunAt    time.Time
+	lastDuration time.Duration
+	lastError    string
+	nextRunAt    time.Time
+}
+
+// Scheduler runs registered jobs on their schedules
+type Scheduler struct {
+	elector   *leader.Elector
+	overrides map[string]string
+	logger    *slog.Logger
+
+	entries []*entry
+	mu      sync.RWMutex
+
+	wg   sync.WaitGroup
+	done chan struct{}
+}
+
+// New creates a new Scheduler
+// overrides maps job names to schedules that replace the registered defaults
+func New(elector *leader.Elector, overrides map[string]string, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		elector:   elector,
+		overrides: overrides,
+		logger:    logger,
+		done:      make(chan struct{}),
+	}
+}
+
+// Register adds a job. The job's schedule can be replaced (or set to "off")
+// through the overrides passed to New
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job name and run function are required")
+	}
+
+	spec := job.Schedule
+	if override, ok := s.overrides[job.Name]; ok && override != "" {
+		spec = override
+	}
+
+	if strings.EqualFold(spec, Disabled) {
+		s.logger.Info("job disabled", slog.String("job", job.Name))
+		return nil
+	}
+
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.job.Name == job.Name {
+			return fmt.Errorf("job %s already registered", job.Name)
+		}
+	}
+
+	s.entries = append(s.entries, &entry{
+		job:      job,
+		reset:    make(chan struct{}, 1),
+		spec:     spec,
+		schedule: schedule,
+	})
+
+	return nil
+}
+
+// UpdateOverrides applies new schedule overrides to registered jobs, e.g.
+// after a config reload. Jobs without an override return to their default
+// schedule; "off" pauses a job until it gets a schedule again. Jobs that were
+// disabled at registration are not affected
+func (s *Scheduler) UpdateOverrides(overrides map[string]string) error {
+	s.mu.Lock()
+	s.overrides = overrides
+	entries := append([]*entry(nil), s.entries...)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, e := range entries {
+		spec := e.job.Schedule
+		if override, ok := overrides[e.job.Name]; ok && override != "" {
+			spec = override
+		}
+
+		e.mu.RLock()
+		unchanged := spec == e.spec
+		e.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		var schedule Schedule
+		if !strings.EqualFold(spec, Disabled) {
+			parsed, err := Parse(spec)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("job %s: %w", e.job.Name, err))
+				continue
+			}
+			schedule = parsed
+		}
+
+		e.mu.Lock()
+		e.spec = spec
+		e.schedule = schedule
+		e.nextRunAt = time.Time{}
+		e.mu.Unlock()
+
+		select {
+		case e.reset <- struct{}{}:
+		default:
+		}
+
+		s.logger.Info("job schedule updated",
+			slog.String("job", e.job.Name),
+			slog.String("schedule", spec),
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Start starts all registered jobs
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.RLock()
+	entries := append([]*entry(nil), s.entries...)
+	s.mu.RUnlock()
+
+	for _, e := range entries {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+
+	s.logger.Info("scheduler started", slog.Int("jobs", len(entries)))
+}
+
+// Stop stops scheduling and waits for running jobs to finish
+func (s *Scheduler) Stop() {
+	close(s.done)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("scheduler stopped")
+	case <-time.After(30 * time.Second):
+		s.logger.Warn("timeout waiting for scheduled jobs to finish")
+	}
+}
+
+// Stats returns a snapshot of all job metrics, sorted by name
+func (s *Scheduler) Stats() []JobStats {
+	s.mu.RLock()
+	entries := append([]*entry(nil), s.entries...)
+	s.mu.RUnlock()
+
+	stats := make([]JobStats, 0, len(entries))
+	for _, e := range entries {
+		e.mu.RLock()
+		st := JobStats{
+			Name:         e.job.Name,
+			Schedule:     e.spec,
+			LeaderOnly:   e.job.LeaderOnly,
+			Running:      e.running.Load(),
+			Runs:         e.runs.Load(),
+			Failures:     e.failures.Load(),
+			Skipped:      e.skipped.Load(),
+			LastDuration: e.lastDuration,
+			LastError:    e.lastError,
+		}
+		if !e.lastRunAt.IsZero() {
+			t := e.lastRunAt
+			st.LastRunAt = &t
+		}
+		if !e.nextRunAt.IsZero() {
+			t := e.nextRunAt
+			st.NextRunAt = &t
+		}
+		e.mu.RUnlock()
+
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// loop waits for each scheduled time and dispatches the job
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	if e.job.RunOnStart {
+		s.dispatch(ctx, e)
+	}
+
+	for {
+		e.mu.RLock()
+		schedule := e.schedule
+		e.mu.RUnlock()
+
+		// A switched-off job waits for a new schedule
+		var fire <-chan time.Time
+		var timer *time.Timer
+		if schedule != nil {
+			now := time.Now()
+			next := schedule.Next(now)
+			if next.IsZero() {
+				s.logger.Error("job schedule has no future runs", slog.String("job", e.job.Name))
+			} else {
+				if e.job.Jitter > 0 {
+					next = next.Add(time.Duration(rand.Int63n(int64(e.job.Jitter))))
+				}
+
+				e.mu.Lock()
+				e.nextRunAt = next
+				e.mu.Unlock()
+
+				timer = time.NewTimer(next.Sub(now))
+				fire = timer.C
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-s.done:
+			stopTimer(timer)
+			return
+		case <-e.reset:
+			stopTimer(timer)
+		case <-fire:
+			s.dispatch(ctx, e)
+		}
+	}
+}
+
+// stopTimer stops a timer that may be nil
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// dispatch starts a run unless the previous one is still going
+func (s *Scheduler) dispatch(ctx context.Context, e *entry) {
+	if e.job.LeaderOnly && !s.elector.IsLeader() {
+		return
+	}
+
+	if !e.running.CompareAndSwap(false, true) {
+		e.skipped.Add(1)
+		s.logger.Warn("skipping job run, previous run still in progress",
+			slog.String("job", e.job.Name),
+		)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer e.running.Store(false)
+		s.run(ctx, e)
+	}()
+}
+
+// run executes a job once and records its metrics
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := s.safeRun(ctx, e)
+	duration := time.Since(start)
+
+	e.runs.Add(1)
+
+	e.mu.Lock()
+	e.lastRunAt = start
+	e.lastDuration = duration
+	e.lastError = ""
+	if err != nil {
+		e.lastError = err.Error()
+	}
+	e.mu.Unlock()
+
+	if err != nil {
+		e.failures.Add(1)
+		if ctx.Err() == nil || e.job.Timeout > 0 {
+			s.logger.Error("job failed",
+				slog.String("job", e.job.Name),
+				slog.Duration("duration", duration),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	s.logger.Debug("job completed",
+		slog.String("job", e.job.Name),
+		slog.Duration("duration", duration),
+	)
+}
+
+// safeRun runs the job, converting panics into errors
+func (s *Scheduler) safeRun(ctx context.Context, e *entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return e.job.Run(ctx)
+}
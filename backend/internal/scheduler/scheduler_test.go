@@ -0,0 +1,185 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestParse_Next(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 7, 30, 0, time.UTC) // Monday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2024, 1, 15, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 1, 16, 3, 30, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"15,45 9-17 * * 1-5", time.Date(2024, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@every 30s", base.Add(30 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(base))
+		})
+	}
+}
+
+func TestParse_DayOfMonthOrDayOfWeek(t *testing.T) {
+	// Both day fields restricted: runs on the 20th OR on Wednesdays
+	schedule, err := Parse("0 0 20 * 3")
+	require.NoError(t, err)
+
+	base := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC), schedule.Next(base))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 10ms",
+		"@every soon",
+	}
+
+	for _, spec := range specs {
+		_, err := Parse(spec)
+		assert.Error(t, err, "spec %q should be rejected", spec)
+	}
+}
+
+func TestParse_ImpossibleScheduleTerminates(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestScheduler_OverridesAndDisable(t *testing.T) {
+	s := New(nil, map[string]string{
+		"sync":    "*/10 * * * *",
+		"cleanup": "off",
+	}, testLogger())
+
+	noop := func(ctx context.Context) error { return nil }
+
+	require.NoError(t, s.Register(Job{Name: "sync", Schedule: "@hourly", Run: noop}))
+	require.NoError(t, s.Register(Job{Name: "cleanup", Schedule: "@daily", Run: noop}))
+	assert.Error(t, s.Register(Job{Name: "sync", Schedule: "@hourly", Run: noop}), "duplicate names are rejected")
+	assert.Error(t, s.Register(Job{Name: "bad", Schedule: "nope", Run: noop}))
+
+	stats := s.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "sync", stats[0].Name)
+	assert.Equal(t, "*/10 * * * *", stats[0].Schedule)
+}
+
+func TestScheduler_PreventsOverlap(t *testing.T) {
+	s := New(nil, nil, testLogger())
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	require.NoError(t, s.Register(Job{
+		Name:     "slow",
+		Schedule: "@hourly",
+		Run: func(ctx context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+	}))
+
+	e := s.entries[0]
+	ctx := context.Background()
+
+	s.wg.Add(1) // held by the job loop in production
+	s.dispatch(ctx, e)
+	<-started
+	s.dispatch(ctx, e)
+
+	assert.Equal(t, int64(1), e.skipped.Load())
+
+	close(release)
+	s.wg.Done()
+	s.wg.Wait()
+
+	assert.Equal(t, int64(1), e.runs.Load())
+	assert.False(t, e.running.Load())
+}
+
+func TestScheduler_RecordsFailuresAndPanics(t *testing.T) {
+	s := New(nil, nil, testLogger())
+
+	require.NoError(t, s.Register(Job{
+		Name:     "failing",
+		Schedule: "@hourly",
+		Run:      func(ctx context.Context) error { return errors.New("boom") },
+	}))
+	require.NoError(t, s.Register(Job{
+		Name:     "panicking",
+		Schedule: "@hourly",
+		Run:      func(ctx context.Context) error { panic("oops") },
+	}))
+
+	for _, e := range s.entries {
+		s.run(context.Background(), e)
+	}
+
+	stats := s.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, int64(1), stats[0].Failures)
+	assert.Equal(t, "boom", stats[0].LastError)
+	assert.Equal(t, int64(1), stats[1].Failures)
+	assert.Contains(t, stats[1].LastError, "panic")
+}
+
+func TestScheduler_UpdateOverrides(t *testing.T) {
+	s := New(nil, nil, testLogger())
+	noop := func(ctx context.Context) error { return nil }
+
+	require.NoError(t, s.Register(Job{Name: "sync", Schedule: "@hourly", Run: noop}))
+	require.NoError(t, s.Register(Job{Name: "cleanup", Schedule: "@daily", Run: noop}))
+
+	require.NoError(t, s.UpdateOverrides(map[string]string{
+		"sync":    "*/5 * * * *",
+		"cleanup": "off",
+	}))
+
+	stats := s.Stats()
+	assert.Equal(t, "off", stats[0].Schedule)
+	assert.Equal(t, "*/5 * * * *", stats[1].Schedule)
+	assert.Nil(t, s.entries[1].schedule, "switched-off job has no schedule")
+
+	// Invalid override keeps the current schedule
+	assert.Error(t, s.UpdateOverrides(map[string]string{"sync": "bogus"}))
+	assert.Equal(t, "@daily", s.Stats()[0].Schedule, "jobs without an override return to their default")
+	assert.Equal(t, "*/5 * * * *", s.Stats()[1].Schedule)
+}
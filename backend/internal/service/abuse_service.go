@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Kinds of changes counted towards abuse detection
+const (
+	ChurnAlerts    = "alerts"
+	ChurnWatchlist = "watchlist"
+)
+
+// churnKinds are all kinds of changes counted
+var churnKinds = []string{ChurnAlerts, ChurnWatchlist}
+
+const (
+	// Changes of a kind by a user in the current window, + kind:userID
+	abuseChurnKey = "abuse:churn:"
+
+	// Set while a user's writes are throttled, to the reason; + userID
+	abuseThrottleKey = "abuse:throttle:"
+)
+
+// auditLogger records audit log events; implemented by AuditService
+type auditLogger interface {
+	Log(ctx context.Context, userID int64, actor, action string, details map[string]any) error
+}
+
+// AbuseService detects users creating and deleting alerts or watchlist
+// coins in a loop, typically scripts, and throttles their writes for a
+// while. Throttles and unlocks are recorded in the audit log
+type AbuseService struct {
+	redis            *redis.Client
+	audit            auditLogger
+	churnLimit       int
+	churnWindow      time.Duration
+	throttleDuration time.Duration
+	logger           *slog.Logger
+}
+
+// NewAbuseService creates a new AbuseService. A user making churnLimit
+// changes of one kind within churnWindow is throttled for
+// throttleDuration; a churnLimit of 0 disables detection
+func NewAbuseService(client *redis.Client, audit *AuditService, churnLimit int, churnWindow, throttleDuration time.Duration, logger *slog.Logger) *AbuseService {
+	return &AbuseService{
+		redis:            client,
+		audit:            audit,
+		churnLimit:       churnLimit,
+		churnWindow:      churnWindow,
+		throttleDuration: throttleDuration,
+		logger:           logger,
+	}
+}
+
+// AbuseStatus is a user's standing with abuse detection
+type AbuseStatus struct {
+	ThrottledUntil *time.Time
+	Reason         string
+	// Changes of each kind in the current window
+	Churn map[string]int
+}
+
+// RecordChurn counts a change of kind by userID and throttles the user
+// once the limit is reached
+func (s *AbuseService) RecordChurn(ctx context.Context, userID int64, kind string) error {
+	if s.churnLimit == 0 {
+		return nil
+	}
+
+	key := churnCountKey(kind, userID)
+	count, err := s.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrRedis)
+	}
+	if count == 1 {
+		if err := s.redis.Expire(ctx, key, s.churnWindow).Err(); err != nil {
+			return errors.Wrap(err, errors.ErrRedis)
+		}
+	}
+
+	if count != int64(s.churnLimit) {
+		return nil
+	}
+
+	reason := fmt.Sprintf("%d %s changes within %s", count, kind, s.churnWindow)
+	return s.Throttle(ctx, userID, AuditActorSystem, reason)
+}
+
+// Throttle rejects the writes of userID for the throttle duration
+func (s *AbuseService) Throttle(ctx context.Context, userID int64, actor, reason string) error {
+	until := time.Now().Add(s.throttleDuration)
+	if err := s.redis.Set(ctx, writeThrottleKey(userID), reason, s.throttleDuration).Err(); err != nil {
+		return errors.Wrap(err, errors.ErrRedis)
+	}
+
+	s.logger.Warn("throttled user writes for abuse",
+		slog.Int64("user_id", userID),
+		slog.String("reason", reason),
+		slog.Time("until", until),
+	)
+
+	return s.audit.Log(ctx, userID, actor, AuditAbuseThrottled, map[string]any{
+		"reason": reason,
+		"until":  until,
+	})
+}
+
+// ThrottledUntil returns when the write throttle of userID ends, or the
+// zero time if the user is not throttled
+func (s *AbuseService) ThrottledUntil(ctx context.Context, userID int64) (time.Time, error) {
+	ttl, err := s.redis.PTTL(ctx, writeThrottleKey(userID)).Result()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errors.ErrRedis)
+	}
+	if ttl <= 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().Add(ttl), nil
+}
+
+// Status returns the throttle and change counts of userID
+func (s *AbuseService) Status(ctx context.Context, userID int64) (*AbuseStatus, error) {
+	pipe := s.redis.Pipeline()
+	reasonCmd := pipe.Get(ctx, writeThrottleKey(userID))
+	ttlCmd := pipe.PTTL(ctx, writeThrottleKey(userID))
+	churnCmds := make(map[string]*redis.StringCmd, len(churnKinds))
+	for _, kind := range churnKinds {
+		churnCmds[kind] = pipe.Get(ctx, churnCountKey(kind, userID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, errors.ErrRedis)
+	}
+
+	status := &AbuseStatus{Churn: make(map[string]int, len(churnKinds))}
+	if ttl := ttlCmd.Val(); ttl > 0 {
+		until := time.Now().Add(ttl)
+		status.ThrottledUntil = &until
+		status.Reason = reasonCmd.Val()
+	}
+	for kind, cmd := range churnCmds {
+		count, _ := cmd.Int()
+		status.Churn[kind] = count
+	}
+
+	return status, nil
+}
+
+// Unlock lifts the write throttle of userID and resets the change counts.
+// Returns whether the user was throttled
+func (s *AbuseService) Unlock(ctx context.Context, userID int64, operator string) (bool, error) {
+	keys := []string{writeThrottleKey(userID)}
+	for _, kind := range churnKinds {
+		keys = append(keys, churnCountKey(kind, userID))
+	}
+
+	pipe := s.redis.TxPipeline()
+	throttleCmd := pipe.Del(ctx, keys[0])
+	pipe.Del(ctx, keys[1:]...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, errors.Wrap(err, errors.ErrRedis)
+	}
+	wasThrottled := throttleCmd.Val() > 0
+
+	s.logger.Info("unlocked user writes",
+		slog.Int64("user_id", userID),
+		slog.String("operator", operator),
+		slog.Bool("was_throttled", wasThrottled),
+	)
+
+	err := s.audit.Log(ctx, userID, operator, AuditAbuseUnlocked, map[string]any{
+		"was_throttled": wasThrottled,
+	})
+	return wasThrottled, err
+}
+
+// churnCountKey counts the changes of kind by userID
+func churnCountKey(kind string, userID int64) string {
+	return fmt.Sprintf("%s%s:%d", abuseChurnKey, kind, userID)
+}
+
+// writeThrottleKey marks userID as throttled
+func writeThrottleKey(userID int64) string {
+	return fmt.Sprintf("%s%d", abuseThrottleKey, userID)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditLog struct {
+	actions []string
+}
+
+func (f *fakeAuditLog) Log(_ context.Context, _ int64, _, action string, _ map[string]any) error {
+	f.actions = append(f.actions, action)
+	return nil
+}
+
+func newTestAbuseService(t *testing.T) (*AbuseService, *fakeAuditLog, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	s := NewAbuseService(client, nil, 3, time.Minute, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	audit := &fakeAuditLog{}
+	s.audit = audit
+	return s, audit, mr
+}
+
+func TestAbuseService_ThrottlesAtChurnLimit(t *testing.T) {
+	s, audit, mr := newTestAbuseService(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, s.RecordChurn(ctx, 7, ChurnAlerts))
+	}
+	require.NoError(t, s.RecordChurn(ctx, 7, ChurnWatchlist))
+
+	until, err := s.ThrottledUntil(ctx, 7)
+	require.NoError(t, err)
+	assert.True(t, until.IsZero(), "kinds are counted separately")
+
+	require.NoError(t, s.RecordChurn(ctx, 7, ChurnAlerts))
+	until, err = s.ThrottledUntil(ctx, 7)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), until, time.Second)
+	assert.Equal(t, []string{AuditAbuseThrottled}, audit.actions)
+
+	status, err := s.Status(ctx, 7)
+	require.NoError(t, err)
+	require.NotNil(t, status.ThrottledUntil)
+	assert.Equal(t, "3 alerts changes within 1m0s", status.Reason)
+	assert.Equal(t, map[string]int{ChurnAlerts: 3, ChurnWatchlist: 1}, status.Churn)
+
+	// Counts start over when the window ends
+	mr.FastForward(time.Minute)
+	status, err = s.Status(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Churn[ChurnAlerts])
+}
+
+func TestAbuseService_Unlock(t *testing.T) {
+	s, audit, _ := newTestAbuseService(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Throttle(ctx, 7, "admin", "manual"))
+
+	wasThrottled, err := s.Unlock(ctx, 7, "admin")
+	require.NoError(t, err)
+	assert.True(t, wasThrottled)
+
+	until, err := s.ThrottledUntil(ctx, 7)
+	require.NoError(t, err)
+	assert.True(t, until.IsZero())
+
+	wasThrottled, err = s.Unlock(ctx, 7, "admin")
+	require.NoError(t, err)
+	assert.False(t, wasThrottled)
+	assert.Equal(t, []string{AuditAbuseThrottled, AuditAbuseUnlocked, AuditAbuseUnlocked}, audit.actions)
+}
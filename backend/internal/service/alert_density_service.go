@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// DefaultDensityBandPct is the width of a price band, in percent of
+	// the coin's price
+	DefaultDensityBandPct = 1.0
+
+	// A price level is reported once this many alerts share it; they all
+	// trigger on the same tick
+	densityLevelMinAlerts = 2
+
+	// Price levels reported per coin, the most crowded first
+	densityTopLevels = 5
+)
+
+// AlertDensityService reports how active alerts spread over coins and
+// price levels, for capacity planning of the alert engine
+type AlertDensityService struct {
+	pool *pgxpool.Pool
+}
+
+// NewAlertDensityService creates a new AlertDensityService
+func NewAlertDensityService(pool *pgxpool.Pool) *AlertDensityService {
+	return &AlertDensityService{pool: pool}
+}
+
+// AlertDensity is the alert heatmap of the coins with the most alerts
+type AlertDensity struct {
+	BandPct     float64
+	TotalAlerts int64
+	Coins       []CoinAlertDensity
+}
+
+// CoinAlertDensity counts the active alerts of a coin, with its price
+// alerts bucketed by distance from the current price
+type CoinAlertDensity struct {
+	Symbol    string
+	Price     *float64
+	Alerts    int64
+	ByType    map[string]int64
+	Bands     []AlertBand  // non-empty bands, lowest first; none without a price
+	TopLevels []AlertLevel // exact prices shared by several alerts
+}
+
+// AlertBand counts the price alerts whose threshold is FromPct to ToPct
+// away from the current price
+type AlertBand struct {
+	FromPct   float64
+	ToPct     float64
+	FromPrice float64
+	ToPrice   float64
+	Alerts    int64
+}
+
+// AlertLevel counts the price alerts sharing a threshold
+type AlertLevel struct {
+	Price  float64
+	Alerts int64
+}
+
+// densityBand returns the index of the band of width bandPct that value
+// falls in, relative to price: 0 is [price, price+bandPct%)
+func densityBand(price, value, bandPct float64) int {
+	return int(math.Floor((value/price - 1) * 100 / bandPct))
+}
+
+// Density returns the alert heatmap of the limit coins with the most
+// active alerts, or of symbol alone when given
+func (s *AlertDensityService) Density(ctx context.Context, symbol string, bandPct float64, limit int) (*AlertDensity, error) {
+	if bandPct <= 0 {
+		bandPct = DefaultDensityBandPct
+	}
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	// Identical alerts are grouped, so exact levels come out of the query
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.symbol, c.current_price::float8, a.alert_type, a.condition_value::float8, COUNT(*)
+		FROM alerts a
+		JOIN coins c ON c.id = a.coin_id
+		WHERE a.is_paused = false
+		  AND ($1 = '' OR c.symbol = $1)
+		GROUP BY 1, 2, 3, 4
+	`, symbol)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	density := &AlertDensity{BandPct: bandPct, Coins: []CoinAlertDensity{}}
+	coins := make(map[string]*CoinAlertDensity)
+	bands := make(map[string]map[int]int64)
+	levels := make(map[string]map[float64]int64)
+	for rows.Next() {
+		var sym, alertType string
+		var price *float64
+		var value float64
+		var count int64
+		if err := rows.Scan(&sym, &price, &alertType, &value, &count); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+
+		coin := coins[sym]
+		if coin == nil {
+			coin = &CoinAlertDensity{Symbol: sym, Price: price, ByType: make(map[string]int64)}
+			coins[sym] = coin
+			bands[sym] = make(map[int]int64)
+			levels[sym] = make(map[float64]int64)
+		}
+		coin.Alerts += count
+		coin.ByType[alertType] += count
+		density.TotalAlerts += count
+
+		if alertType != "PRICE_ABOVE" && alertType != "PRICE_BELOW" {
+			continue
+		}
+		levels[sym][value] += count
+		if price != nil && *price > 0 {
+			bands[sym][densityBand(*price, value, bandPct)] += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	for sym, coin := range coins {
+		for band, count := range bands[sym] {
+			from, to := float64(band)*bandPct, float64(band+1)*bandPct
+			coin.Bands = append(coin.Bands, AlertBand{
+				FromPct:   from,
+				ToPct:     to,
+				FromPrice: *coin.Price * (1 + from/100),
+				ToPrice:   *coin.Price * (1 + to/100),
+				Alerts:    count,
+			})
+		}
+		sort.Slice(coin.Bands, func(i, j int) bool { return coin.Bands[i].FromPct < coin.Bands[j].FromPct })
+
+		for price, count := range levels[sym] {
+			if count >= densityLevelMinAlerts {
+				coin.TopLevels = append(coin.TopLevels, AlertLevel{Price: price, Alerts: count})
+			}
+		}
+		sort.Slice(coin.TopLevels, func(i, j int) bool {
+			if coin.TopLevels[i].Alerts != coin.TopLevels[j].Alerts {
+				return coin.TopLevels[i].Alerts > coin.TopLevels[j].Alerts
+			}
+			return coin.TopLevels[i].Price < coin.TopLevels[j].Price
+		})
+		if len(coin.TopLevels) > densityTopLevels {
+			coin.TopLevels = coin.TopLevels[:densityTopLevels]
+		}
+
+		density.Coins = append(density.Coins, *coin)
+	}
+
+	sort.Slice(density.Coins, func(i, j int) bool {
+		if density.Coins[i].Alerts != density.Coins[j].Alerts {
+			return density.Coins[i].Alerts > density.Coins[j].Alerts
+		}
+		return density.Coins[i].Symbol < density.Coins[j].Symbol
+	})
+	if limit > 0 && len(density.Coins) > limit {
+		density.Coins = density.Coins[:limit]
+	}
+
+	return density, nil
+}
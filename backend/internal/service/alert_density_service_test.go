@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDensityBand(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   float64
+		bandPct float64
+		want    int
+	}{
+		{"at price", 100, 1, 0},
+		{"just above", 100.5, 1, 0},
+		{"next band up", 101, 1, 1},
+		{"just below", 99.5, 1, -1},
+		{"far below", 80, 5, -4},
+		{"wide bands", 112, 5, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, densityBand(100, tt.value, tt.bandPct))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/schedule"
+)
+
+// Longest period an alert can be replayed over. Longer periods would be
+// downsampled by the price history store, hiding short crossings
+const maxReplayPeriod = 7 * 24 * time.Hour
+
+// AlertReplayService replays stored alerts over price history for support,
+// to explain why an alert did or did not fire
+type AlertReplayService struct {
+	pool   *pgxpool.Pool
+	store  *pricehistory.Store
+	logger *slog.Logger
+}
+
+// NewAlertReplayService creates a new AlertReplayService
+func NewAlertReplayService(pool *pgxpool.Pool, store *pricehistory.Store, logger *slog.Logger) *AlertReplayService {
+	return &AlertReplayService{
+		pool:   pool,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// AlertReplay is the trace of replaying a stored alert
+type AlertReplay struct {
+	Alert       alert.Alert // as stored now
+	PriceSymbol string      // key the replayed prices are stored under
+	From        time.Time
+	To          time.Time
+	alert.ReplayResult
+}
+
+// Replay runs the alert through the engine's evaluator at each stored price
+// in [from, to). The period defaults to the 24 hours before to, which
+// defaults to now
+func (s *AlertReplayService) Replay(ctx context.Context, alertID int64, from, to time.Time) (*AlertReplay, error) {
+	now := time.Now()
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-24 * time.Hour)
+	}
+	if !from.Before(to) {
+		return nil, errors.ErrValidationFailed.WithMessage("from must be before to")
+	}
+	if to.Sub(from) > maxReplayPeriod {
+		return nil, errors.ErrValidationFailed.WithMessage("Alerts can be replayed over at most 7 days")
+	}
+
+	a, err := s.loadAlert(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.store.Range(ctx, a.BinanceSymbol, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	result, err := alert.Replay(ctx, *a, points, s.logger)
+	if err != nil {
+		if errors.Is(err, alert.ErrBacktestUnsupported) {
+			return nil, errors.ErrBadRequest.WithMessage("Only price alerts can be replayed")
+		}
+		return nil, errors.ErrInternal.WithCause(err)
+	}
+
+	return &AlertReplay{
+		Alert:        *a,
+		PriceSymbol:  a.BinanceSymbol,
+		From:         from,
+		To:           to,
+		ReplayResult: *result,
+	}, nil
+}
+
+// loadAlert reads an alert the way the alert engine does, whether or not
+// the engine currently evaluates it
+func (s *AlertReplayService) loadAlert(ctx context.Context, alertID int64) (*alert.Alert, error) {
+	var a alert.Alert
+	var binanceSymbol, coingeckoID, pairSymbol *string
+	var timezone string
+
+	err := s.pool.QueryRow(ctx, `
+		SELECT a.id, a.user_id, c.symbol, c.binance_symbol, c.coingecko_id, a.alert_type,
+		       a.condition_operator, a.condition_value, COALESCE(a.condition_timeframe, ''),
+		       a.is_recurring, a.is_paused, COALESCE(a.periodic_interval, ''), a.times_triggered,
+		       a.last_triggered_at, COALESCE(a.price_when_created, 0), a.created_at,
+		       a.trigger_state, a.priority, a.schedule, COALESCE(a.name, ''),
+		       a.expires_at, a.max_triggers, u.timezone,
+		       a.pair_symbol, COALESCE(a.quote_asset, '')
+		FROM alerts a
+		JOIN coins c ON a.coin_id = c.id
+		JOIN users u ON a.user_id = u.id
+		WHERE a.id = $1
+	`, alertID).Scan(
+		&a.ID, &a.UserID, &a.CoinSymbol, &binanceSymbol, &coingeckoID, &a.AlertType,
+		&a.ConditionOperator, &a.ConditionValue, &a.ConditionTimeframe,
+		&a.IsRecurring, &a.IsPaused, &a.PeriodicInterval, &a.TimesTriggered,
+		&a.LastTriggeredAt, &a.PriceWhenCreated, &a.CreatedAt,
+		&a.TriggerState, &a.Priority, &a.Schedule, &a.Name,
+		&a.ExpiresAt, &a.MaxTriggers, &timezone,
+		&pairSymbol, &a.QuoteAsset,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrAlertNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Same price key as the engine subscribes to
+	switch {
+	case pairSymbol != nil && *pairSymbol != "":
+		a.BinanceSymbol = *pairSymbol
+	case binanceSymbol != nil && *binanceSymbol != "":
+		a.BinanceSymbol = *binanceSymbol
+	case coingeckoID != nil && *coingeckoID != "":
+		a.BinanceSymbol = alert.FallbackSymbol(*coingeckoID)
+	default:
+		a.BinanceSymbol = a.CoinSymbol + "USDT"
+	}
+	a.Location = schedule.LoadLocation(timezone)
+
+	return &a, nil
+}
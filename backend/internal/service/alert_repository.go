@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/schedule"
+)
+
+// alertColumns are scanned by scanAlert
+var alertColumns = `
+	a.id, a.user_id, a.coin_id,
+	a.alert_type, a.condition_operator, a.condition_value, a.condition_timeframe,
+	a.is_recurring, a.is_paused, a.paused_reason, a.priority, a.periodic_interval,
+	a.times_triggered, ` + textTime("a.last_triggered_at") + `, a.price_when_created,
+	a.quote_asset, a.pair_symbol, a.schedule,
+	a.name, a.notes, ` + textTime("a.expires_at") + `, a.max_triggers,
+	` + textTime("a.created_at") + `, ` + textTime("a.updated_at") + `, a.version,
+	c.id, c.symbol, c.name, c.binance_symbol, c.current_price`
+
+// textTime selects a timestamptz column as an RFC 3339 string in UTC, for
+// the models keeping timestamps as strings; pgx does not scan timestamptz
+// into a string
+func textTime(column string) string {
+	return `to_char(` + column + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')`
+}
+
+// pgAlertRepository is the AlertRepository on Postgres
+type pgAlertRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *pgAlertRepository) CountByUser(ctx context.Context, userID int64) (int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM alerts WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return total, nil
+}
+
+func (r *pgAlertRepository) ListByUser(ctx context.Context, userID int64, page pagination.Page) ([]Alert, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts a
+		JOIN coins c ON c.id = a.coin_id
+		WHERE a.user_id = $1
+		  AND ($2::text IS NULL OR (a.created_at, a.id) < ($2::timestamptz, $3::bigint))
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $4
+	`, userID, page.AfterKey(), page.AfterID(), page.FetchLimit())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var alerts []Alert
+	for rows.Next() {
+		var alert Alert
+		if err := scanAlert(rows, &alert); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return alerts, nil
+}
+
+func (r *pgAlertRepository) GetByID(ctx context.Context, alertID int64) (*Alert, error) {
+	var alert Alert
+	err := scanAlert(r.pool.QueryRow(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts a
+		JOIN coins c ON c.id = a.coin_id
+		WHERE a.id = $1
+	`, alertID), &alert)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrAlertNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return &alert, nil
+}
+
+func (r *pgAlertRepository) GetOwner(ctx context.Context, alertID int64) (int64, bool, error) {
+	var ownerID int64
+	var coinActive bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT a.user_id, COALESCE(c.is_active, false)
+		FROM alerts a
+		LEFT JOIN coins c ON c.id = a.coin_id
+		WHERE a.id = $1
+	`, alertID).Scan(&ownerID, &coinActive)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, false, errors.ErrAlertNotFound
+		}
+		return 0, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return ownerID, coinActive, nil
+}
+
+func (r *pgAlertRepository) GetWatchedCoin(ctx context.Context, userID int64, coinSymbol string) (*Coin, bool, error) {
+	coin := Coin{Symbol: coinSymbol}
+	var active bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT c.id, c.binance_symbol, c.current_price, c.is_active
+		FROM coins c
+		JOIN watchlist w ON w.coin_id = c.id AND w.user_id = $1
+		WHERE c.symbol = $2
+	`, userID, coinSymbol).Scan(&coin.ID, &coin.BinanceSymbol, &coin.CurrentPrice, &active)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, false, errors.ErrCoinNotFound
+		}
+		return nil, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return &coin, active, nil
+}
+
+func (r *pgAlertRepository) FindDuplicate(ctx context.Context, alert *Alert) (int64, bool, error) {
+	// Matches idx_alerts_unique_condition
+	var alertID int64
+	err := r.pool.QueryRow(ctx, `
+		SELECT id FROM alerts
+		WHERE user_id = $1 AND coin_id = $2 AND alert_type = $3
+		  AND condition_value = $4
+		  AND COALESCE(condition_timeframe, '') = COALESCE($5, '')
+		  AND COALESCE(periodic_interval, '') = COALESCE($6, '')
+		  AND COALESCE(quote_asset, '') = COALESCE($8, '')
+		  AND id <> $7
+		ORDER BY id
+		LIMIT 1
+	`,
+		alert.UserID, alert.CoinID, alert.AlertType, alert.ConditionValue,
+		alert.ConditionTimeframe, alert.PeriodicInterval, alert.ID, alert.QuoteAsset,
+	).Scan(&alertID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return alertID, true, nil
+}
+
+func (r *pgAlertRepository) Create(ctx context.Context, alert *Alert) (int64, error) {
+	// Nothing is inserted when an identical alert won a concurrent create
+	var alertID int64
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO alerts (
+			user_id, coin_id, alert_type, condition_operator,
+			condition_value, condition_timeframe, is_recurring,
+			periodic_interval, price_when_created, priority, schedule,
+			name, notes, allow_duplicate, expires_at, max_triggers,
+			quote_asset, pair_symbol
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15::timestamptz, $16, $17, $18)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`,
+		alert.UserID, alert.CoinID, alert.AlertType, alert.ConditionOperator,
+		alert.ConditionValue, alert.ConditionTimeframe, alert.IsRecurring,
+		alert.PeriodicInterval, alert.PriceWhenCreated, alert.Priority, alert.Schedule,
+		alert.Name, alert.Notes, alert.AllowDuplicate, alert.ExpiresAt, alert.MaxTriggers,
+		alert.QuoteAsset, alert.PairSymbol,
+	).Scan(&alertID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.ErrAlertDuplicate
+		}
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return alertID, nil
+}
+
+func (r *pgAlertRepository) SetPaused(ctx context.Context, alertID int64, paused bool, version *int) (bool, error) {
+	// Resuming re-arms the alert so it can trigger again
+	result, err := r.pool.Exec(ctx, `
+		UPDATE alerts
+		SET is_paused = $2,
+		    paused_reason = NULL,
+		    trigger_state = CASE WHEN $2 THEN trigger_state ELSE 'armed' END,
+		    version = version + 1,
+		    updated_at = NOW()
+		WHERE id = $1 AND ($3::int IS NULL OR version = $3)
+	`, alertID, paused, version)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *pgAlertRepository) SetSchedule(ctx context.Context, userID, alertID int64, sched *schedule.Schedule, version *int) (bool, error) {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE alerts SET schedule = $3, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND ($4::int IS NULL OR version = $4)
+	`, alertID, userID, sched, version)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *pgAlertRepository) Update(ctx context.Context, userID, alertID int64, params UpdateAlertParams) (bool, error) {
+	// Unset params keep their value; a blank name or notes clears them
+	result, err := r.pool.Exec(ctx, `
+		UPDATE alerts
+		SET condition_value = COALESCE($3, condition_value),
+		    condition_timeframe = COALESCE($4, condition_timeframe),
+		    is_recurring = COALESCE($5, is_recurring),
+		    periodic_interval = COALESCE($6, periodic_interval),
+		    name = CASE WHEN $7::text IS NULL THEN name ELSE NULLIF($7, '') END,
+		    notes = CASE WHEN $8::text IS NULL THEN notes ELSE NULLIF($8, '') END,
+		    trigger_state = 'armed',
+		    version = version + 1,
+		    updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND ($9::int IS NULL OR version = $9)
+	`,
+		alertID, userID, params.ConditionValue, params.ConditionTimeframe,
+		params.IsRecurring, params.PeriodicInterval, params.Name, params.Notes,
+		params.Version,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, errors.ErrAlertDuplicate
+		}
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *pgAlertRepository) Delete(ctx context.Context, alertID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM alerts WHERE id = $1`, alertID); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+func (r *pgAlertRepository) DeleteByUser(ctx context.Context, userID int64) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM alerts WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return result.RowsAffected(), nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// scanAlert scans alertColumns into alert
+func scanAlert(row pgx.Row, alert *Alert) error {
+	return row.Scan(
+		&alert.ID, &alert.UserID, &alert.CoinID,
+		&alert.AlertType, &alert.ConditionOperator, &alert.ConditionValue, &alert.ConditionTimeframe,
+		&alert.IsRecurring, &alert.IsPaused, &alert.PausedReason, &alert.Priority, &alert.PeriodicInterval,
+		&alert.TimesTriggered, &alert.LastTriggeredAt, &alert.PriceWhenCreated,
+		&alert.QuoteAsset, &alert.PairSymbol, &alert.Schedule,
+		&alert.Name, &alert.Notes, &alert.ExpiresAt, &alert.MaxTriggers,
+		&alert.CreatedAt, &alert.UpdatedAt, &alert.Version,
+		&alert.Coin.ID, &alert.Coin.Symbol, &alert.Coin.Name, &alert.Coin.BinanceSymbol, &alert.Coin.CurrentPrice,
+	)
+}
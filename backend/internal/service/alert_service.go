@@ -2,29 +2,97 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/alerttype"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/logger"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/schedule"
+)
+
+// Alert delivery priorities
+const (
+	AlertPriorityLow    = "low"
+	AlertPriorityNormal = "normal"
+	AlertPriorityHigh   = "high"
 )
 
 // AlertService handles alert-related business logic
 type AlertService struct {
-	pool             *pgxpool.Pool
-	userService      *UserService
+	alerts           AlertRepository
+	userService      PlanLimits
 	watchlistService *WatchlistService
+	exchangeInfo     *binance.ExchangeInfo
+	onboarding       *OnboardingService
+	usage            *UsageWarningService
+	refresher        AlertRefresher
+	updates          AlertUpdatePublisher
+	profiles         *UserProfileCache
+}
+
+// AlertRefresher makes the alert engine reload alerts from the database
+type AlertRefresher interface {
+	RefreshAlerts(ctx context.Context) error
+}
+
+// AlertUpdatePublisher announces alert changes to the owner's open Mini
+// App sessions
+type AlertUpdatePublisher interface {
+	Publish(ctx context.Context, update alert.AlertUpdate)
 }
 
+// Bound on an engine refresh triggered by an alert change
+const engineRefreshTimeout = 10 * time.Second
+
 // NewAlertService creates a new AlertService
-func NewAlertService(pool *pgxpool.Pool, userService *UserService, watchlistService *WatchlistService) *AlertService {
+func NewAlertService(
+	pool *pgxpool.Pool,
+	userService *UserService,
+	watchlistService *WatchlistService,
+	exchangeInfo *binance.ExchangeInfo,
+) *AlertService {
 	return &AlertService{
-		pool:             pool,
+		alerts:           &pgAlertRepository{pool: pool},
 		userService:      userService,
 		watchlistService: watchlistService,
+		exchangeInfo:     exchangeInfo,
 	}
 }
 
+// SetOnboarding makes creating an alert complete the onboarding step
+func (s *AlertService) SetOnboarding(onboarding *OnboardingService) {
+	s.onboarding = onboarding
+}
+
+// SetUsageWarnings warns users nearing their plan's alert limit
+func (s *AlertService) SetUsageWarnings(usage *UsageWarningService) {
+	s.usage = usage
+}
+
+// SetRefresher makes alert edits reach the alert engine immediately instead
+// of on its next periodic refresh
+func (s *AlertService) SetRefresher(refresher AlertRefresher) {
+	s.refresher = refresher
+}
+
+// SetUpdatePublisher makes pausing and resuming an alert reach the
+// owner's open Mini App sessions
+func (s *AlertService) SetUpdatePublisher(updates AlertUpdatePublisher) {
+	s.updates = updates
+}
+
+// SetProfileCache makes creating and deleting alerts invalidate the cached
+// profile, which counts the alerts used
+func (s *AlertService) SetProfileCache(profiles *UserProfileCache) {
+	s.profiles = profiles
+}
+
 // Alert represents an alert from the database
 type Alert struct {
 	ID                 int64
@@ -37,12 +105,23 @@ type Alert struct {
 	ConditionTimeframe *string
 	IsRecurring        bool
 	IsPaused           bool
+	PausedReason       *string // set when paused by the system, e.g. coin_delisted
+	Priority           string  // low, normal or high
 	PeriodicInterval   *string
 	TimesTriggered     int
 	LastTriggeredAt    *string
-	PriceWhenCreated   *float64
+	PriceWhenCreated   *float64           // in USD; nil for alerts in another quote
+	QuoteAsset         *string            // quote of the condition, e.g. BTC; nil for USD
+	PairSymbol         *string            // Binance pair of an alert in QuoteAsset, e.g. ETHBTC
+	Schedule           *schedule.Schedule // nil when always active
+	Name               *string            // user label, e.g. "Stop loss"
+	Notes              *string
+	AllowDuplicate     bool // created on purpose as a copy of another alert
+	ExpiresAt          *string
+	MaxTriggers        *int
 	CreatedAt          string
 	UpdatedAt          string
+	Version            int // counts edits through the API, see AlertService.Update
 }
 
 // CreateAlertParams represents parameters for creating an alert
@@ -53,86 +132,71 @@ type CreateAlertParams struct {
 	ConditionTimeframe *string
 	IsRecurring        bool
 	PeriodicInterval   *string
+	QuoteAsset         string // quote of the condition, e.g. BTC; empty or USDT for USD
+	Priority           string // empty picks the default for the alert type
+	Schedule           *schedule.Schedule
+	Name               *string
+	Notes              *string
+	// ExpiresAt and MaxTriggers end the alert at a date or after a number
+	// of triggers; nil means never
+	ExpiresAt   *time.Time
+	MaxTriggers *int
+	// Force creates the alert even when an identical one exists
+	Force bool
+}
+
+// UpdateAlertParams represents the changes to an alert; nil fields are left
+// as they are, and an empty name or notes clears them
+type UpdateAlertParams struct {
+	ConditionValue     *float64
+	ConditionTimeframe *string
+	IsRecurring        *bool
+	PeriodicInterval   *string
+	Name               *string
+	Notes              *string
+	Version            *int // the change applies only at this version when set
 }
 
-// GetByUserID retrieves all alerts for a user
-func (s *AlertService) GetByUserID(ctx context.Context, userID int64) ([]Alert, error) {
-	query := `
-		SELECT
-			a.id, a.user_id, a.coin_id,
-			a.alert_type, a.condition_operator, a.condition_value, a.condition_timeframe,
-			a.is_recurring, a.is_paused, a.periodic_interval,
-			a.times_triggered, a.last_triggered_at, a.price_when_created,
-			a.created_at, a.updated_at,
-			c.id, c.symbol, c.name, c.binance_symbol, c.current_price
-		FROM alerts a
-		JOIN coins c ON c.id = a.coin_id
-		WHERE a.user_id = $1
-		ORDER BY a.created_at DESC
-	`
-
-	rows, err := s.pool.Query(ctx, query, userID)
+// empty reports whether params change nothing
+func (p UpdateAlertParams) empty() bool {
+	return p.ConditionValue == nil && p.ConditionTimeframe == nil && p.IsRecurring == nil &&
+		p.PeriodicInterval == nil && p.Name == nil && p.Notes == nil
+}
+
+// GetByUserID retrieves a page of a user's alerts, newest first
+func (s *AlertService) GetByUserID(ctx context.Context, userID int64, page pagination.Page) (*pagination.Result[Alert], error) {
+	total, err := s.alerts.CountByUser(ctx, userID)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
-	}
-	defer rows.Close()
-
-	var alerts []Alert
-	for rows.Next() {
-		var alert Alert
-		err := rows.Scan(
-			&alert.ID, &alert.UserID, &alert.CoinID,
-			&alert.AlertType, &alert.ConditionOperator, &alert.ConditionValue, &alert.ConditionTimeframe,
-			&alert.IsRecurring, &alert.IsPaused, &alert.PeriodicInterval,
-			&alert.TimesTriggered, &alert.LastTriggeredAt, &alert.PriceWhenCreated,
-			&alert.CreatedAt, &alert.UpdatedAt,
-			&alert.Coin.ID, &alert.Coin.Symbol, &alert.Coin.Name, &alert.Coin.BinanceSymbol, &alert.Coin.CurrentPrice,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase)
-		}
-		alerts = append(alerts, alert)
+		return nil, err
 	}
 
-	if alerts == nil {
-		alerts = []Alert{}
+	alerts, err := s.alerts.ListByUser(ctx, userID, page)
+	if err != nil {
+		return nil, err
 	}
 
-	return alerts, nil
+	return pagination.NewResult(alerts, total, page, func(a Alert) pagination.Cursor {
+		return pagination.Cursor{Key: a.CreatedAt, ID: a.ID}
+	}), nil
 }
 
 // GetByID retrieves an alert by ID
 func (s *AlertService) GetByID(ctx context.Context, alertID int64) (*Alert, error) {
-	query := `
-		SELECT
-			a.id, a.user_id, a.coin_id,
-			a.alert_type, a.condition_operator, a.condition_value, a.condition_timeframe,
-			a.is_recurring, a.is_paused, a.periodic_interval,
-			a.times_triggered, a.last_triggered_at, a.price_when_created,
-			a.created_at, a.updated_at,
-			c.id, c.symbol, c.name, c.binance_symbol, c.current_price
-		FROM alerts a
-		JOIN coins c ON c.id = a.coin_id
-		WHERE a.id = $1
-	`
-
-	var alert Alert
-	err := s.pool.QueryRow(ctx, query, alertID).Scan(
-		&alert.ID, &alert.UserID, &alert.CoinID,
-		&alert.AlertType, &alert.ConditionOperator, &alert.ConditionValue, &alert.ConditionTimeframe,
-		&alert.IsRecurring, &alert.IsPaused, &alert.PeriodicInterval,
-		&alert.TimesTriggered, &alert.LastTriggeredAt, &alert.PriceWhenCreated,
-		&alert.CreatedAt, &alert.UpdatedAt,
-		&alert.Coin.ID, &alert.Coin.Symbol, &alert.Coin.Name, &alert.Coin.BinanceSymbol, &alert.Coin.CurrentPrice,
-	)
+	return s.alerts.GetByID(ctx, alertID)
+}
+
+// GetOwned retrieves an alert of userID
+func (s *AlertService) GetOwned(ctx context.Context, userID, alertID int64) (*Alert, error) {
+	alert, err := s.alerts.GetByID(ctx, alertID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, errors.ErrAlertNotFound
-		}
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		return nil, err
+	}
+
+	if alert.UserID != userID {
+		return nil, errors.ErrNotOwner
 	}
 
-	return &alert, nil
+	return alert, nil
 }
 
 // Create creates a new alert
@@ -159,113 +223,471 @@ func (s *AlertService) Create(ctx context.Context, userID int64, params CreateAl
 	}
 
 	// Get coin and verify it's in watchlist
-	var coinID int
-	var currentPrice *float64
-	err = s.pool.QueryRow(ctx, `
-		SELECT c.id, c.current_price
-		FROM coins c
-		JOIN watchlist w ON w.coin_id = c.id AND w.user_id = $1
-		WHERE c.symbol = $2
-	`, userID, coinSymbol).Scan(&coinID, &currentPrice)
+	coin, isActive, err := s.alerts.GetWatchedCoin(ctx, userID, coinSymbol)
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		if errors.Is(err, errors.ErrCoinNotFound) {
 			return nil, errors.ErrBadRequest.WithMessage("Coin not in watchlist. Add it first.")
 		}
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		return nil, err
+	}
+
+	if !isActive {
+		return nil, errors.ErrCoinDelisted
+	}
+
+	if params.Schedule != nil {
+		if err := params.Schedule.Validate(); err != nil {
+			return nil, errors.ErrValidationFailed.WithMessage(err.Error())
+		}
+	}
+
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, errors.ErrValidationFailed.WithMessage("expires_at must be in the future")
+	}
+	if params.MaxTriggers != nil && *params.MaxTriggers < 1 {
+		return nil, errors.ErrValidationFailed.WithMessage("max_triggers must be at least 1")
+	}
+	if err := validateCondition(params.AlertType, coinSymbol, params.ConditionValue, params.ConditionTimeframe, params.PeriodicInterval); err != nil {
+		return nil, err
+	}
+
+	// Make sure the alert can actually trigger, on the pair of its quote
+	// when it is not set in USD
+	quote := strings.ToUpper(strings.TrimSpace(params.QuoteAsset))
+	var pairSymbol string
+	if quote != "" && quote != binance.DefaultQuoteAsset {
+		pairSymbol, err = s.resolveQuotePair(ctx, coin, params.AlertType, quote)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := s.validateTradingPair(ctx, coin.BinanceSymbol); err != nil {
+		return nil, err
 	}
 
 	// Determine condition operator based on alert type
 	conditionOperator := getConditionOperator(params.AlertType)
 
+	priority := params.Priority
+	if priority == "" {
+		priority = defaultAlertPriority(params.AlertType)
+	}
+
+	alert := &Alert{
+		UserID:             userID,
+		CoinID:             coin.ID,
+		AlertType:          params.AlertType,
+		ConditionOperator:  conditionOperator,
+		ConditionValue:     params.ConditionValue,
+		ConditionTimeframe: params.ConditionTimeframe,
+		IsRecurring:        params.IsRecurring,
+		PeriodicInterval:   params.PeriodicInterval,
+		PriceWhenCreated:   coin.CurrentPrice,
+		Priority:           priority,
+		Schedule:           params.Schedule,
+		Name:               optionalText(params.Name),
+		Notes:              optionalText(params.Notes),
+		AllowDuplicate:     params.Force,
+		MaxTriggers:        params.MaxTriggers,
+	}
+	if pairSymbol != "" {
+		// The coin's price is in USD
+		alert.QuoteAsset = &quote
+		alert.PairSymbol = &pairSymbol
+		alert.PriceWhenCreated = nil
+	}
+	if params.ExpiresAt != nil {
+		expiresAt := params.ExpiresAt.UTC().Format(time.RFC3339)
+		alert.ExpiresAt = &expiresAt
+	}
+
+	if !params.Force {
+		if err := s.checkDuplicate(ctx, alert); err != nil {
+			return nil, err
+		}
+	}
+
 	// Insert alert
-	var alertID int64
-	var createdAt, updatedAt string
-	err = s.pool.QueryRow(ctx, `
-		INSERT INTO alerts (
-			user_id, coin_id, alert_type, condition_operator,
-			condition_value, condition_timeframe, is_recurring,
-			periodic_interval, price_when_created
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at, updated_at
-	`,
-		userID, coinID, params.AlertType, conditionOperator,
-		params.ConditionValue, params.ConditionTimeframe, params.IsRecurring,
-		params.PeriodicInterval, currentPrice,
-	).Scan(&alertID, &createdAt, &updatedAt)
+	alertID, err := s.alerts.Create(ctx, alert)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		if errors.Is(err, errors.ErrAlertDuplicate) {
+			// An identical alert was created concurrently
+			if dupErr := s.checkDuplicate(ctx, alert); dupErr != nil {
+				return nil, dupErr
+			}
+		}
+		return nil, err
 	}
 
+	s.profiles.Invalidate(ctx, userID)
+	s.onboarding.CompleteStep(ctx, userID, OnboardingStepCreatedFirstAlert)
+	s.usage.Check(ctx, user, UsageResourceAlerts, user.AlertsUsed+1)
+
 	return s.GetByID(ctx, alertID)
 }
 
-// UpdatePaused updates alert paused status
-func (s *AlertService) UpdatePaused(ctx context.Context, userID, alertID int64, isPaused bool) (*Alert, error) {
-	// Verify ownership
-	var ownerID int64
-	err := s.pool.QueryRow(ctx, `SELECT user_id FROM alerts WHERE id = $1`, alertID).Scan(&ownerID)
+// checkDuplicate returns ErrAlertDuplicate with the ID of the existing alert
+// when alert has the same condition as another alert of its user
+func (s *AlertService) checkDuplicate(ctx context.Context, alert *Alert) error {
+	existingID, found, err := s.alerts.FindDuplicate(ctx, alert)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	return errors.ErrAlertDuplicate.WithMessage(
+		"An identical alert already exists.",
+	).WithDetails(map[string]int64{"existing_alert_id": existingID})
+}
+
+// validateTradingPair checks that a Binance pair exists and is trading
+// Coins without a pair are priced from CoinGecko and always pass. If no
+// exchangeInfo snapshot can be had the alert is refused, as it might never
+// trigger; a stale snapshot is used while Binance is briefly unreachable
+func (s *AlertService) validateTradingPair(ctx context.Context, binanceSymbol *string) error {
+	if binanceSymbol == nil || s.exchangeInfo == nil {
+		return nil
+	}
+
+	info, ok, err := s.exchangeInfo.Lookup(ctx, *binanceSymbol)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, errors.ErrAlertNotFound
+		return errors.Wrap(err, errors.ErrServiceUnavailable).WithMessage(
+			fmt.Sprintf("Binance cannot be reached to check %s. Please try again shortly.", *binanceSymbol),
+		)
+	}
+
+	if !ok {
+		return errors.ErrTradingPairUnavailable.WithMessage(
+			fmt.Sprintf("%s is not listed on Binance. Alerts for this coin cannot trigger.", *binanceSymbol),
+		)
+	}
+
+	if info.Status != binance.SymbolStatusTrading {
+		return errors.ErrTradingPairUnavailable.WithMessage(
+			fmt.Sprintf("Trading for %s is currently suspended on Binance (status: %s).", *binanceSymbol, info.Status),
+		)
+	}
+
+	return nil
+}
+
+// resolveQuotePair returns the Binance pair an alert of alertType on coin
+// is evaluated on when set in quote. The base asset is taken from the
+// coin's own pair, as listed pairs do not always use the coin symbol.
+// Unlike validateTradingPair this needs exchangeInfo, so it fails when the
+// snapshot is unavailable
+func (s *AlertService) resolveQuotePair(ctx context.Context, coin *Coin, alertType, quote string) (string, error) {
+	if def, ok := alerttype.Lookup(alertType); ok && !def.Quotable {
+		return "", errors.ErrValidationFailed.WithMessage(
+			fmt.Sprintf("%s alerts can only be set in USD", def.Name()),
+		)
+	}
+	if !binance.IsQuoteAsset(quote) {
+		return "", errors.ErrValidationFailed.WithMessage(
+			fmt.Sprintf("quote_asset must be one of %s", strings.Join(binance.QuoteAssets, ", ")),
+		)
+	}
+	if s.exchangeInfo == nil {
+		return "", errors.ErrTradingPairUnavailable.WithMessage("Trading pairs cannot be checked right now.")
+	}
+
+	base := coin.Symbol
+	if coin.BinanceSymbol != nil {
+		info, ok, err := s.exchangeInfo.Lookup(ctx, *coin.BinanceSymbol)
+		if err != nil {
+			return "", errors.Wrap(err, errors.ErrExternalService)
+		}
+		if ok {
+			base = info.BaseAsset
 		}
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	info, ok, err := s.exchangeInfo.FindPair(ctx, base, quote)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrExternalService)
+	}
+	if !ok {
+		return "", errors.ErrTradingPairUnavailable.WithMessage(
+			fmt.Sprintf("%s is not traded against %s on Binance.", coin.Symbol, quote),
+		)
+	}
+	if info.Status != binance.SymbolStatusTrading {
+		return "", errors.ErrTradingPairUnavailable.WithMessage(
+			fmt.Sprintf("Trading for %s is currently suspended on Binance (status: %s).", info.Symbol, info.Status),
+		)
+	}
+
+	return info.Symbol, nil
+}
+
+// UpdatePaused updates alert paused status. With a version, the change
+// applies only if the alert is still at it, see Update
+func (s *AlertService) UpdatePaused(ctx context.Context, userID, alertID int64, isPaused bool, version *int) (*Alert, error) {
+	// Verify ownership
+	ownerID, coinActive, err := s.alerts.GetOwner(ctx, alertID)
+	if err != nil {
+		return nil, err
 	}
 
 	if ownerID != userID {
 		return nil, errors.ErrNotOwner
 	}
 
-	// Update
-	_, err = s.pool.Exec(ctx, `
-		UPDATE alerts SET is_paused = $2, updated_at = NOW() WHERE id = $1
-	`, alertID, isPaused)
+	// Alerts for delisted coins would never trigger
+	if !isPaused && !coinActive {
+		return nil, errors.ErrCoinDelisted
+	}
+
+	// Update (a manual change clears any system pause reason; resuming
+	// re-arms the alert so it can trigger again)
+	changed, err := s.alerts.SetPaused(ctx, alertID, isPaused, version)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, s.updateMissed(ctx, userID, alertID)
+	}
+
+	updated, err := s.GetByID(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+	s.publishPaused(ctx, updated)
+
+	return updated, nil
+}
+
+// publishPaused announces that an alert was paused or resumed
+func (s *AlertService) publishPaused(ctx context.Context, a *Alert) {
+	if s.updates == nil {
+		return
+	}
+
+	reason := alert.UpdateResumed
+	if a.IsPaused {
+		reason = alert.UpdatePaused
+	}
+	s.updates.Publish(ctx, alert.AlertUpdate{
+		AlertID:        a.ID,
+		UserID:         a.UserID,
+		Reason:         reason,
+		IsPaused:       a.IsPaused,
+		TimesTriggered: a.TimesTriggered,
+		UpdatedAt:      time.Now(),
+		RequestID:      logger.GetRequestID(ctx),
+	})
+}
+
+// UpdateSchedule sets the windows during which an alert is evaluated;
+// a nil schedule makes the alert always active. With a version, the
+// schedule is set only if the alert is still at it, see Update
+func (s *AlertService) UpdateSchedule(ctx context.Context, userID, alertID int64, sched *schedule.Schedule, version *int) (*Alert, error) {
+	if sched != nil {
+		if err := sched.Validate(); err != nil {
+			return nil, errors.ErrValidationFailed.WithMessage(err.Error())
+		}
+	}
+
+	updated, err := s.alerts.SetSchedule(ctx, userID, alertID, sched, version)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		return nil, err
+	}
+	if !updated {
+		return nil, s.updateMissed(ctx, userID, alertID)
 	}
 
 	return s.GetByID(ctx, alertID)
 }
 
+// Update edits the condition, recurrence, name or notes of an alert,
+// keeping its trigger history. The alert is re-armed so it can trigger on
+// the new condition. Every edit through the API bumps the alert's version;
+// with params.Version set, an alert edited since the client read it is
+// left alone and ErrVersionConflict is returned
+func (s *AlertService) Update(ctx context.Context, userID, alertID int64, params UpdateAlertParams) (*Alert, error) {
+	if params.empty() {
+		return nil, errors.ErrBadRequest.WithMessage("Nothing to update")
+	}
+	if params.ConditionValue != nil && *params.ConditionValue <= 0 {
+		return nil, errors.ErrValidationFailed.WithMessage("condition_value must be greater than 0")
+	}
+	params.Name = trimmedText(params.Name)
+	params.Notes = trimmedText(params.Notes)
+
+	if params.ConditionValue != nil || params.ConditionTimeframe != nil || params.PeriodicInterval != nil {
+		if err := s.validateEdit(ctx, alertID, params); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.alerts.Update(ctx, userID, alertID, params)
+	if err != nil {
+		if errors.Is(err, errors.ErrAlertDuplicate) {
+			return nil, s.editDuplicateError(ctx, alertID, params, err)
+		}
+		return nil, err
+	}
+
+	if !updated {
+		return nil, s.updateMissed(ctx, userID, alertID)
+	}
+
+	s.refreshEngine()
+
+	return s.GetByID(ctx, alertID)
+}
+
+// updateMissed tells why an update of an alert of userID changed no row:
+// the alert is missing, someone else's, or no longer at the version the
+// update expected
+func (s *AlertService) updateMissed(ctx context.Context, userID, alertID int64) error {
+	a, err := s.GetByID(ctx, alertID)
+	if err != nil {
+		return err
+	}
+	if a.UserID != userID {
+		return errors.ErrNotOwner
+	}
+	return errors.ErrVersionConflict
+}
+
+// editDuplicateError tells which alert an edit would have duplicated,
+// falling back to err when it cannot be found
+func (s *AlertService) editDuplicateError(ctx context.Context, alertID int64, params UpdateAlertParams, err error) error {
+	edited, getErr := s.GetByID(ctx, alertID)
+	if getErr != nil {
+		return err
+	}
+
+	if params.ConditionValue != nil {
+		edited.ConditionValue = *params.ConditionValue
+	}
+	if params.ConditionTimeframe != nil {
+		edited.ConditionTimeframe = params.ConditionTimeframe
+	}
+	if params.PeriodicInterval != nil {
+		edited.PeriodicInterval = params.PeriodicInterval
+	}
+
+	if dupErr := s.checkDuplicate(ctx, edited); dupErr != nil {
+		return dupErr
+	}
+	return err
+}
+
+// refreshEngine asks the alert engine to reload alerts in the background.
+// A failed refresh is harmless: the engine reloads periodically anyway
+func (s *AlertService) refreshEngine() {
+	if s.refresher == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), engineRefreshTimeout)
+		defer cancel()
+		_ = s.refresher.RefreshAlerts(ctx)
+	}()
+}
+
 // Delete deletes an alert
 func (s *AlertService) Delete(ctx context.Context, userID, alertID int64) error {
 	// Verify ownership
-	var ownerID int64
-	err := s.pool.QueryRow(ctx, `SELECT user_id FROM alerts WHERE id = $1`, alertID).Scan(&ownerID)
+	ownerID, _, err := s.alerts.GetOwner(ctx, alertID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return errors.ErrAlertNotFound
-		}
-		return errors.Wrap(err, errors.ErrDatabase)
+		return err
 	}
 
 	if ownerID != userID {
 		return errors.ErrNotOwner
 	}
 
-	_, err = s.pool.Exec(ctx, `DELETE FROM alerts WHERE id = $1`, alertID)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
+	if err := s.alerts.Delete(ctx, alertID); err != nil {
+		return err
 	}
-
+	s.profiles.Invalidate(ctx, userID)
 	return nil
 }
 
 // DeleteAllByUser deletes all alerts for a user
 func (s *AlertService) DeleteAllByUser(ctx context.Context, userID int64) (int64, error) {
-	result, err := s.pool.Exec(ctx, `DELETE FROM alerts WHERE user_id = $1`, userID)
+	deleted, err := s.alerts.DeleteByUser(ctx, userID)
 	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
+		return 0, err
+	}
+	s.profiles.Invalidate(ctx, userID)
+	return deleted, nil
+}
+
+// validateCondition checks an alert's condition against the rules of its
+// type in the alert type registry. Request validation rejects unknown types
+func validateCondition(alertType, coinSymbol string, conditionValue float64, timeframe, interval *string) error {
+	def, ok := alerttype.Lookup(alertType)
+	if !ok {
+		return nil
+	}
+	if err := def.Validate(coinSymbol, conditionValue, timeframe != nil, interval != nil); err != nil {
+		return errors.ErrValidationFailed.WithMessage(err.Error())
 	}
-	return result.RowsAffected(), nil
+	return nil
 }
 
+// validateEdit applies validateCondition to an alert as it will be after
+// the edit
+func (s *AlertService) validateEdit(ctx context.Context, alertID int64, params UpdateAlertParams) error {
+	alert, err := s.alerts.GetByID(ctx, alertID)
+	if err != nil {
+		// Reported by the update itself
+		return nil
+	}
+
+	value := alert.ConditionValue
+	if params.ConditionValue != nil {
+		value = *params.ConditionValue
+	}
+	timeframe := alert.ConditionTimeframe
+	if params.ConditionTimeframe != nil {
+		timeframe = params.ConditionTimeframe
+	}
+	interval := alert.PeriodicInterval
+	if params.PeriodicInterval != nil {
+		interval = params.PeriodicInterval
+	}
+	return validateCondition(alert.AlertType, alert.Coin.Symbol, value, timeframe, interval)
+}
+
+// defaultAlertPriority returns the delivery priority for alerts created
+// without one, as set in the alert type registry
+func defaultAlertPriority(alertType string) string {
+	if def, ok := alerttype.Lookup(alertType); ok && def.Priority != "" {
+		return def.Priority
+	}
+	return AlertPriorityNormal
+}
+
+// getConditionOperator returns the condition operator stored with alerts
+// of alertType
 func getConditionOperator(alertType string) string {
-	switch alertType {
-	case "PRICE_ABOVE", "MARKET_CAP_ABOVE":
-		return "above"
-	case "PRICE_BELOW", "MARKET_CAP_BELOW":
-		return "below"
-	default:
-		return "change"
+	if def, ok := alerttype.Lookup(alertType); ok {
+		return def.Operator
+	}
+	return alerttype.OperatorChange
+}
+
+// optionalText trims user-entered text, treating blank text as unset
+func optionalText(s *string) *string {
+	s = trimmedText(s)
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+// trimmedText trims user-entered text, keeping nil as nil
+func trimmedText(s *string) *string {
+	if s == nil {
+		return nil
 	}
+	trimmed := strings.TrimSpace(*s)
+	return &trimmed
 }
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/binance/binancetest"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/logger"
+)
+
+func newTestAlertService(t *testing.T) (*AlertService, *mockAlertRepository, *mockPlanLimits) {
+	alerts := newMockAlertRepository(t)
+	limits := newMockPlanLimits(t)
+	return &AlertService{alerts: alerts, userService: limits}, alerts, limits
+}
+
+// withAlertsUsed makes the user of limits have used of max alerts
+func withAlertsUsed(limits *mockPlanLimits, userID int64, used int64, max int) {
+	limits.On("CheckAndDowngradeExpiredPlan", mock.Anything, userID).Return(false, nil)
+	limits.On("GetWithLimits", mock.Anything, userID).Return(&UserWithLimits{
+		User:       User{ID: userID},
+		MaxAlerts:  max,
+		AlertsUsed: used,
+	}, nil)
+}
+
+func TestAlertService_Create(t *testing.T) {
+	ctx := context.Background()
+	svc, alerts, limits := newTestAlertService(t)
+	withAlertsUsed(limits, 1, 2, 10)
+
+	price := 64000.0
+	alerts.On("GetWatchedCoin", ctx, int64(1), "BTC").Return(&Coin{ID: 7, Symbol: "BTC", CurrentPrice: &price}, true, nil)
+	alerts.On("FindDuplicate", ctx, mock.Anything).Return(int64(0), false, nil)
+	alerts.On("Create", ctx, mock.MatchedBy(func(a *Alert) bool {
+		return a.UserID == 1 && a.CoinID == 7 &&
+			a.ConditionOperator == "above" && a.ConditionValue == 70000 &&
+			a.Priority == AlertPriorityNormal &&
+			a.PriceWhenCreated != nil && *a.PriceWhenCreated == price
+	})).Return(int64(42), nil)
+	alerts.On("GetByID", ctx, int64(42)).Return(&Alert{ID: 42, UserID: 1}, nil)
+
+	alert, err := svc.Create(ctx, 1, CreateAlertParams{
+		CoinSymbol:     " btc ",
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: 70000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), alert.ID)
+}
+
+func TestAlertService_Create_QuoteAsset(t *testing.T) {
+	ctx := context.Background()
+	exchange := httptest.NewServer(binancetest.NewServer([]string{"ETHUSDT", "ETHBTC"}))
+	t.Cleanup(exchange.Close)
+	exchangeInfo := binance.NewExchangeInfo(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	exchangeInfo.SetBaseURL(exchange.URL)
+
+	svc, alerts, limits := newTestAlertService(t)
+	svc.exchangeInfo = exchangeInfo
+	withAlertsUsed(limits, 1, 2, 10)
+
+	price, pair := 3000.0, "ETHUSDT"
+	alerts.On("GetWatchedCoin", ctx, int64(1), "ETH").Return(&Coin{ID: 8, Symbol: "ETH", BinanceSymbol: &pair, CurrentPrice: &price}, true, nil)
+	alerts.On("FindDuplicate", ctx, mock.Anything).Return(int64(0), false, nil)
+	alerts.On("Create", ctx, mock.MatchedBy(func(a *Alert) bool {
+		return a.QuoteAsset != nil && *a.QuoteAsset == "BTC" &&
+			a.PairSymbol != nil && *a.PairSymbol == "ETHBTC" &&
+			a.PriceWhenCreated == nil
+	})).Return(int64(43), nil)
+	alerts.On("GetByID", ctx, int64(43)).Return(&Alert{ID: 43, UserID: 1}, nil)
+
+	params := CreateAlertParams{CoinSymbol: "ETH", AlertType: "PRICE_ABOVE", ConditionValue: 0.05, QuoteAsset: "btc"}
+	_, err := svc.Create(ctx, 1, params)
+	require.NoError(t, err)
+
+	// Not listed against EUR
+	params.QuoteAsset = "EUR"
+	_, err = svc.Create(ctx, 1, params)
+	assert.ErrorIs(t, err, errors.ErrTradingPairUnavailable)
+
+	// Not a supported quote
+	params.QuoteAsset = "DOGE"
+	_, err = svc.Create(ctx, 1, params)
+	assert.ErrorIs(t, err, errors.ErrValidationFailed)
+}
+
+func TestAlertService_ValidateTradingPair(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	exchange := httptest.NewServer(binancetest.NewServer([]string{"BTCUSDT"}))
+	t.Cleanup(exchange.Close)
+	exchangeInfo := binance.NewExchangeInfo(logger)
+	exchangeInfo.SetBaseURL(exchange.URL)
+	svc := &AlertService{exchangeInfo: exchangeInfo}
+
+	listed, unlisted := "BTCUSDT", "XYZUSDT"
+	assert.NoError(t, svc.validateTradingPair(ctx, &listed))
+	assert.ErrorIs(t, svc.validateTradingPair(ctx, &unlisted), errors.ErrTradingPairUnavailable)
+	assert.NoError(t, svc.validateTradingPair(ctx, nil), "priced from CoinGecko")
+
+	// No snapshot to check against: refused rather than created unchecked
+	down := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(down.Close)
+	unreachable := binance.NewExchangeInfo(logger)
+	unreachable.SetBaseURL(down.URL)
+	svc = &AlertService{exchangeInfo: unreachable}
+
+	err := svc.validateTradingPair(ctx, &listed)
+	assert.ErrorIs(t, err, errors.ErrServiceUnavailable)
+	assert.ErrorContains(t, err, "BTCUSDT")
+}
+
+func TestAlertService_Create_Duplicate(t *testing.T) {
+	ctx := context.Background()
+	params := CreateAlertParams{CoinSymbol: "BTC", AlertType: "PRICE_ABOVE", ConditionValue: 70000}
+
+	svc, alerts, limits := newTestAlertService(t)
+	withAlertsUsed(limits, 1, 2, 10)
+	alerts.On("GetWatchedCoin", ctx, int64(1), "BTC").Return(&Coin{ID: 7, Symbol: "BTC"}, true, nil)
+	alerts.On("FindDuplicate", ctx, mock.Anything).Return(int64(5), true, nil)
+
+	_, err := svc.Create(ctx, 1, params)
+	require.ErrorIs(t, err, errors.ErrAlertDuplicate)
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, map[string]int64{"existing_alert_id": 5}, appErr.Details)
+	alerts.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// Forced: created as an allowed duplicate without looking one up
+	svc, alerts, limits = newTestAlertService(t)
+	withAlertsUsed(limits, 1, 2, 10)
+	alerts.On("GetWatchedCoin", ctx, int64(1), "BTC").Return(&Coin{ID: 7, Symbol: "BTC"}, true, nil)
+	alerts.On("Create", ctx, mock.MatchedBy(func(a *Alert) bool { return a.AllowDuplicate })).Return(int64(6), nil)
+	alerts.On("GetByID", ctx, int64(6)).Return(&Alert{ID: 6, UserID: 1}, nil)
+
+	params.Force = true
+	alert, err := svc.Create(ctx, 1, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), alert.ID)
+	alerts.AssertNotCalled(t, "FindDuplicate", mock.Anything, mock.Anything)
+}
+
+func TestAlertService_Create_Rejected(t *testing.T) {
+	ctx := context.Background()
+	params := CreateAlertParams{CoinSymbol: "BTC", AlertType: "PERIODIC"}
+
+	// Limit reached: nothing is looked up
+	svc, _, limits := newTestAlertService(t)
+	withAlertsUsed(limits, 1, 5, 5)
+	_, err := svc.Create(ctx, 1, params)
+	assert.ErrorIs(t, err, errors.ErrAlertLimitExceeded)
+
+	// Coin not on the watchlist
+	svc, alerts, limits := newTestAlertService(t)
+	withAlertsUsed(limits, 1, 0, 5)
+	alerts.On("GetWatchedCoin", ctx, int64(1), "BTC").Return(nil, false, errors.ErrCoinNotFound)
+	_, err = svc.Create(ctx, 1, params)
+	assert.ErrorIs(t, err, errors.ErrBadRequest)
+	assert.Contains(t, err.Error(), "Add it first")
+
+	// Delisted coin
+	svc, alerts, limits = newTestAlertService(t)
+	withAlertsUsed(limits, 1, 0, 5)
+	alerts.On("GetWatchedCoin", ctx, int64(1), "BTC").Return(&Coin{ID: 7}, false, nil)
+	_, err = svc.Create(ctx, 1, params)
+	assert.ErrorIs(t, err, errors.ErrCoinDelisted)
+}
+
+func TestAlertService_UpdatePaused(t *testing.T) {
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	svc, alerts, _ := newTestAlertService(t)
+
+	// Someone else's alert
+	alerts.On("GetOwner", ctx, int64(1)).Return(int64(2), true, nil)
+	_, err := svc.UpdatePaused(ctx, 1, 1, true, nil)
+	assert.ErrorIs(t, err, errors.ErrNotOwner)
+
+	// Alerts of delisted coins can be paused but not resumed
+	alerts.On("GetOwner", ctx, int64(2)).Return(int64(1), false, nil)
+	_, err = svc.UpdatePaused(ctx, 1, 2, false, nil)
+	assert.ErrorIs(t, err, errors.ErrCoinDelisted)
+
+	updates := &updateRecorder{}
+	svc.SetUpdatePublisher(updates)
+	alerts.On("SetPaused", ctx, int64(2), true, (*int)(nil)).Return(true, nil)
+	alerts.On("GetByID", ctx, int64(2)).Return(&Alert{ID: 2, UserID: 1, IsPaused: true, TimesTriggered: 3}, nil)
+	alert, err := svc.UpdatePaused(ctx, 1, 2, true, nil)
+	require.NoError(t, err)
+	assert.True(t, alert.IsPaused)
+
+	// Open sessions of the owner learn about it
+	require.Len(t, updates.updates, 1)
+	update := updates.updates[0]
+	assert.Equal(t, int64(2), update.AlertID)
+	assert.Equal(t, int64(1), update.UserID)
+	assert.Equal(t, "paused", update.Reason)
+	assert.True(t, update.IsPaused)
+	assert.Equal(t, 3, update.TimesTriggered)
+	assert.Equal(t, "req-1", update.RequestID, "relay logs the request that paused it")
+}
+
+// updateRecorder is an AlertUpdatePublisher recording what it publishes
+type updateRecorder struct {
+	updates []alert.AlertUpdate
+}
+
+func (r *updateRecorder) Publish(_ context.Context, update alert.AlertUpdate) {
+	r.updates = append(r.updates, update)
+}
+
+func TestAlertService_UpdateSchedule_NotUpdated(t *testing.T) {
+	ctx := context.Background()
+	svc, alerts, _ := newTestAlertService(t)
+
+	// A missing alert is told apart from someone else's
+	alerts.On("SetSchedule", ctx, int64(1), mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	alerts.On("GetByID", ctx, int64(10)).Return(nil, errors.ErrAlertNotFound)
+	alerts.On("GetByID", ctx, int64(11)).Return(&Alert{ID: 11, UserID: 2}, nil)
+
+	_, err := svc.UpdateSchedule(ctx, 1, 10, nil, nil)
+	assert.ErrorIs(t, err, errors.ErrAlertNotFound)
+
+	_, err = svc.UpdateSchedule(ctx, 1, 11, nil, nil)
+	assert.ErrorIs(t, err, errors.ErrNotOwner)
+
+	// An own alert edited since the client read it
+	alerts.On("GetByID", ctx, int64(12)).Return(&Alert{ID: 12, UserID: 1, Version: 3}, nil)
+	version := 2
+	_, err = svc.UpdateSchedule(ctx, 1, 12, nil, &version)
+	assert.ErrorIs(t, err, errors.ErrVersionConflict)
+}
+
+// refreshRecorder is an AlertRefresher signalling each refresh
+type refreshRecorder chan struct{}
+
+func (r refreshRecorder) RefreshAlerts(context.Context) error {
+	r <- struct{}{}
+	return nil
+}
+
+func TestAlertService_Update(t *testing.T) {
+	ctx := context.Background()
+	svc, alerts, _ := newTestAlertService(t)
+	refreshed := make(refreshRecorder, 1)
+	svc.SetRefresher(refreshed)
+
+	_, err := svc.Update(ctx, 1, 2, UpdateAlertParams{})
+	assert.ErrorIs(t, err, errors.ErrBadRequest)
+
+	value := -1.0
+	_, err = svc.Update(ctx, 1, 2, UpdateAlertParams{ConditionValue: &value})
+	assert.ErrorIs(t, err, errors.ErrValidationFailed)
+
+	value = 72000
+	name := "  Take profit "
+	alerts.On("Update", ctx, int64(1), int64(2), mock.MatchedBy(func(p UpdateAlertParams) bool {
+		return *p.ConditionValue == 72000 && *p.Name == "Take profit" && p.Notes == nil
+	})).Return(true, nil)
+	alerts.On("GetByID", ctx, int64(2)).Return(&Alert{ID: 2, UserID: 1, ConditionValue: 72000}, nil)
+
+	alert, err := svc.Update(ctx, 1, 2, UpdateAlertParams{ConditionValue: &value, Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, 72000.0, alert.ConditionValue)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("engine was not refreshed")
+	}
+}
+
+func TestAlertService_Delete(t *testing.T) {
+	ctx := context.Background()
+	svc, alerts, _ := newTestAlertService(t)
+
+	alerts.On("GetOwner", ctx, int64(1)).Return(int64(2), true, nil)
+	assert.ErrorIs(t, svc.Delete(ctx, 1, 1), errors.ErrNotOwner)
+	alerts.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+	alerts.On("GetOwner", ctx, int64(2)).Return(int64(1), true, nil)
+	alerts.On("Delete", ctx, int64(2)).Return(nil)
+	assert.NoError(t, svc.Delete(ctx, 1, 2))
+}
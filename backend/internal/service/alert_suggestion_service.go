@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	alertSuggestionsCacheKeyPrefix = "alert_suggestions:"
+	alertSuggestionsCacheTTL       = 15 * time.Minute
+
+	// Swing levels come from hourly candles of the last 14 days; a swing
+	// is the extreme of 12 hours on either side
+	suggestionPeriod      = 14 * 24 * time.Hour
+	suggestionSwingWindow = 12
+
+	// Nearest levels suggested on each side of the price
+	suggestionsPerSide = 2
+)
+
+// Kinds of suggested alert levels
+const (
+	SuggestionSupport     = "support"
+	SuggestionResistance  = "resistance"
+	SuggestionRoundNumber = "round_number"
+	SuggestionATR         = "atr"
+)
+
+// AlertSuggestionService proposes alert levels for a coin from its price
+// history: recent support and resistance, round numbers and ±1 daily ATR
+type AlertSuggestionService struct {
+	priceHistoryService *PriceHistoryService
+	coinStatsService    *CoinStatsService
+	redis               *redis.Client
+	logger              *slog.Logger
+}
+
+// NewAlertSuggestionService creates a new AlertSuggestionService
+func NewAlertSuggestionService(
+	priceHistoryService *PriceHistoryService,
+	coinStatsService *CoinStatsService,
+	redisClient *redis.Client,
+	logger *slog.Logger,
+) *AlertSuggestionService {
+	return &AlertSuggestionService{
+		priceHistoryService: priceHistoryService,
+		coinStatsService:    coinStatsService,
+		redis:               redisClient,
+		logger:              logger,
+	}
+}
+
+// AlertSuggestion is a proposed price alert
+type AlertSuggestion struct {
+	AlertType      string  `json:"alert_type"` // PRICE_ABOVE or PRICE_BELOW
+	ConditionValue float64 `json:"condition_value"`
+	Kind           string  `json:"kind"`
+	DistancePct    float64 `json:"distance_pct"` // from the current price
+}
+
+// AlertSuggestions are the suggestions for a coin, nearest first
+type AlertSuggestions struct {
+	Symbol      string            `json:"symbol"`
+	Price       float64           `json:"price"`
+	Suggestions []AlertSuggestion `json:"suggestions"`
+	ComputedAt  time.Time         `json:"computed_at"`
+}
+
+// Get returns the suggestions for a coin, computing them on a cache miss
+func (s *AlertSuggestionService) Get(ctx context.Context, coinSymbol string) (*AlertSuggestions, error) {
+	coinSymbol = strings.ToUpper(strings.TrimSpace(coinSymbol))
+	cacheKey := alertSuggestionsCacheKeyPrefix + coinSymbol
+
+	if data, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+		var suggestions AlertSuggestions
+		if json.Unmarshal(data, &suggestions) == nil {
+			return &suggestions, nil
+		}
+	}
+
+	suggestions, err := s.compute(ctx, coinSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(suggestions); err == nil {
+		if err := s.redis.Set(ctx, cacheKey, data, alertSuggestionsCacheTTL).Err(); err != nil {
+			s.logger.Warn("alert suggestions cache write failed", slog.String("error", err.Error()))
+		}
+	}
+
+	return suggestions, nil
+}
+
+// compute derives the suggestions from the coin's stats and candles
+func (s *AlertSuggestionService) compute(ctx context.Context, coinSymbol string) (*AlertSuggestions, error) {
+	stats, err := s.coinStatsService.Get(ctx, coinSymbol)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Price == nil {
+		return nil, errors.ErrNotFound.WithMessage("Not enough price history for suggestions")
+	}
+
+	key, err := s.priceHistoryService.PriceKey(ctx, coinSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	candles, err := s.priceHistoryService.store.Candles(ctx, key, time.Hour, now.Add(-suggestionPeriod), now)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	supports, resistances := pricehistory.SwingLevels(candles, suggestionSwingWindow)
+
+	return &AlertSuggestions{
+		Symbol:      coinSymbol,
+		Price:       *stats.Price,
+		Suggestions: buildSuggestions(*stats.Price, supports, resistances, stats.ATR1d),
+		ComputedAt:  now,
+	}, nil
+}
+
+// buildSuggestions combines the nearest swing levels on each side of the
+// price with round numbers and ±1 ATR, nearest first without duplicates
+func buildSuggestions(price float64, supports, resistances []float64, atr *float64) []AlertSuggestion {
+	var suggestions []AlertSuggestion
+	add := func(level float64, kind string) {
+		if level <= 0 || level == price {
+			return
+		}
+		alertType := alerttype.PriceAbove
+		if level < price {
+			alertType = alerttype.PriceBelow
+		}
+		suggestions = append(suggestions, AlertSuggestion{
+			AlertType:      alertType,
+			ConditionValue: level,
+			Kind:           kind,
+			DistancePct:    (level - price) / price * 100,
+		})
+	}
+
+	// Supports below the price, nearest first; a broken support above
+	// the price acts as resistance and is skipped here
+	below := 0
+	for i := len(supports) - 1; i >= 0 && below < suggestionsPerSide; i-- {
+		if supports[i] < price {
+			add(supports[i], SuggestionSupport)
+			below++
+		}
+	}
+	above := 0
+	for i := 0; i < len(resistances) && above < suggestionsPerSide; i++ {
+		if resistances[i] > price {
+			add(resistances[i], SuggestionResistance)
+			above++
+		}
+	}
+
+	roundBelow, roundAbove := pricehistory.RoundLevels(price)
+	add(roundBelow, SuggestionRoundNumber)
+	add(roundAbove, SuggestionRoundNumber)
+
+	if atr != nil && *atr > 0 {
+		add(price-*atr, SuggestionATR)
+		add(price+*atr, SuggestionATR)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return math.Abs(suggestions[i].DistancePct) < math.Abs(suggestions[j].DistancePct)
+	})
+
+	// Drop levels within 0.1% of a nearer suggestion
+	unique := suggestions[:0]
+	for _, sg := range suggestions {
+		duplicate := false
+		for _, u := range unique {
+			if math.Abs(sg.ConditionValue-u.ConditionValue)/price*100 < 0.1 {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			unique = append(unique, sg)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSuggestions(t *testing.T) {
+	atr := 1500.0
+	suggestions := buildSuggestions(64312,
+		[]float64{58000, 61000, 63000, 64500},
+		[]float64{63900, 66000, 68000, 69000},
+		&atr,
+	)
+
+	require.NotEmpty(t, suggestions)
+	values := make([]float64, len(suggestions))
+	for i, s := range suggestions {
+		values[i] = s.ConditionValue
+		if s.ConditionValue > 64312 {
+			assert.Equal(t, "PRICE_ABOVE", s.AlertType)
+		} else {
+			assert.Equal(t, "PRICE_BELOW", s.AlertType)
+		}
+	}
+
+	// Nearest first; swing levels on the wrong side of the price are skipped
+	assert.Equal(t, []float64{65000, 63000, 62812, 65812, 66000, 61000, 68000, 60000}, values)
+	assert.Equal(t, SuggestionRoundNumber, suggestions[0].Kind)
+	assert.Equal(t, SuggestionSupport, suggestions[1].Kind)
+	assert.InDelta(t, -2.04, suggestions[1].DistancePct, 0.01)
+}
+
+func TestBuildSuggestions_DropsNearDuplicates(t *testing.T) {
+	// The 65010 resistance is within 0.1% of the nearer 65000 round number
+	suggestions := buildSuggestions(64312, []float64{63000}, []float64{65010}, nil)
+
+	values := make([]float64, len(suggestions))
+	for i, s := range suggestions {
+		values[i] = s.ConditionValue
+	}
+	assert.Equal(t, []float64{65000, 63000, 60000}, values)
+}
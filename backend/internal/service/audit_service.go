@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Audit log actions
+const (
+	AuditAbuseThrottled = "abuse.throttled"
+	AuditAbuseUnlocked  = "abuse.unlocked"
+	AuditRoleChanged    = "user.role_changed"
+)
+
+// AuditActorSystem is the actor of events raised by the service itself
+const AuditActorSystem = "system"
+
+// AuditService records security-relevant events about users
+type AuditService struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(pool *pgxpool.Pool) *AuditService {
+	return &AuditService{pool: pool}
+}
+
+// AuditEntry is an event in the audit log
+type AuditEntry struct {
+	ID        int64
+	UserID    *int64 // nil once the user is deleted
+	Actor     string
+	Action    string
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// Log appends an event about userID to the audit log
+func (s *AuditService) Log(ctx context.Context, userID int64, actor, action string, details map[string]any) error {
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO audit_log (user_id, actor, action, details)
+		VALUES ($1, $2, $3, $4)
+	`, userID, actor, action, details)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+// List returns the latest events, of userID only unless it is 0
+func (s *AuditService) List(ctx context.Context, userID int64, limit int) ([]AuditEntry, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, user_id, actor, action, details, created_at
+		FROM audit_log
+		WHERE $1 = 0 OR user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Actor, &e.Action, &e.Details, &e.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return entries, nil
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +17,7 @@ type AuthService struct {
 	jwtSecret   string
 	jwtExpiry   time.Duration
 	botToken    string
+	mu          sync.RWMutex
 }
 
 // NewAuthService creates a new AuthService
@@ -27,23 +30,59 @@ func NewAuthService(userService *UserService, jwtSecret, botToken string, jwtExp
 	}
 }
 
+// SetBotToken replaces the bot token used to validate InitData
+func (s *AuthService) SetBotToken(botToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.botToken = botToken
+}
+
+// BotToken returns the current bot token
+func (s *AuthService) BotToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.botToken
+}
+
+// SetJWTSecret replaces the JWT signing secret. Tokens signed with the
+// previous secret stop validating, so clients re-authenticate via InitData
+func (s *AuthService) SetJWTSecret(jwtSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jwtSecret = jwtSecret
+}
+
+func (s *AuthService) secret() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return []byte(s.jwtSecret)
+}
+
 // JWTClaims represents JWT token claims
 type JWTClaims struct {
 	UserID     int64 `json:"user_id"`
 	TelegramID int64 `json:"telegram_id"`
+	// Set on tokens issued to an admin acting as the user
+	ImpersonationID int64 `json:"impersonation_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// impersonationAudience marks impersonation tokens, which are never
+// accepted as regular user tokens and vice versa
+const impersonationAudience = "impersonation"
+
 // AuthResult represents authentication result
 type AuthResult struct {
 	User  *UserWithLimits
 	Token string
 }
 
-// Authenticate validates Telegram InitData and returns user with JWT token
-func (s *AuthService) Authenticate(ctx context.Context, initData string) (*AuthResult, error) {
+// Authenticate validates Telegram InitData and returns user with JWT token.
+// timezone is the IANA zone detected by the Mini App; empty or unknown
+// values are ignored
+func (s *AuthService) Authenticate(ctx context.Context, initData, timezone string) (*AuthResult, error) {
 	// Validate InitData
-	data, err := crypto.ValidateInitData(initData, s.botToken)
+	data, err := crypto.ValidateInitData(initData, s.BotToken())
 	if err != nil {
 		return nil, err
 	}
@@ -58,6 +97,18 @@ func (s *AuthService) Authenticate(ctx context.Context, initData string) (*AuthR
 		return nil, err
 	}
 
+	if timezone != "" && timezone != "Local" && timezone != user.Timezone {
+		if _, err := time.LoadLocation(timezone); err == nil {
+			changed, err := s.userService.DetectTimezone(ctx, user.ID, timezone)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				user.Timezone = timezone
+			}
+		}
+	}
+
 	// Generate JWT token
 	token, err := s.generateToken(user.ID, user.TelegramID)
 	if err != nil {
@@ -72,11 +123,36 @@ func (s *AuthService) Authenticate(ctx context.Context, initData string) (*AuthR
 
 // ValidateToken validates a JWT token and returns claims
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ImpersonationID != 0 {
+		return nil, errors.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ValidateImpersonationToken validates a token issued by
+// GenerateImpersonationToken and returns its claims
+func (s *AuthService) ValidateImpersonationToken(tokenString string) (*JWTClaims, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ImpersonationID == 0 || !slices.Contains(claims.Audience, impersonationAudience) {
+		return nil, errors.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// parseToken verifies a token's signature and lifetime
+func (s *AuthService) parseToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.ErrInvalidToken
 		}
-		return []byte(s.jwtSecret), nil
+		return s.secret(), nil
 	})
 
 	if err != nil {
@@ -106,5 +182,26 @@ func (s *AuthService) generateToken(userID, telegramID int64) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return token.SignedString(s.secret())
+}
+
+// GenerateImpersonationToken issues a token acting as a user for ttl, tied
+// to the audited impersonation it was issued for
+func (s *AuthService) GenerateImpersonationToken(userID, telegramID, impersonationID int64, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &JWTClaims{
+		UserID:          userID,
+		TelegramID:      telegramID,
+		ImpersonationID: impersonationID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "weqory",
+			Audience:  jwt.ClaimStrings{impersonationAudience},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.secret())
 }
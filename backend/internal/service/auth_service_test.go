@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationToken_NotInterchangeable(t *testing.T) {
+	s := NewAuthService(nil, "secret", "bot-token", time.Hour)
+
+	impersonation, err := s.GenerateImpersonationToken(1, 100, 7, time.Minute)
+	require.NoError(t, err)
+
+	claims, err := s.ValidateImpersonationToken(impersonation)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+	assert.Equal(t, int64(100), claims.TelegramID)
+	assert.Equal(t, int64(7), claims.ImpersonationID)
+
+	_, err = s.ValidateToken(impersonation)
+	assert.Error(t, err, "impersonation tokens are not user tokens")
+
+	user, err := s.generateToken(1, 100)
+	require.NoError(t, err)
+	_, err = s.ValidateImpersonationToken(user)
+	assert.Error(t, err, "user tokens are not impersonation tokens")
+
+	expired, err := s.GenerateImpersonationToken(1, 100, 7, -time.Minute)
+	require.NoError(t, err)
+	_, err = s.ValidateImpersonationToken(expired)
+	assert.Error(t, err)
+}
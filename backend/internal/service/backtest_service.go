@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/schedule"
+)
+
+// BacktestService simulates alert definitions against stored price history
+type BacktestService struct {
+	priceHistoryService *PriceHistoryService
+	userService         *UserService
+	logger              *slog.Logger
+}
+
+// NewBacktestService creates a new BacktestService
+func NewBacktestService(priceHistoryService *PriceHistoryService, userService *UserService, logger *slog.Logger) *BacktestService {
+	return &BacktestService{
+		priceHistoryService: priceHistoryService,
+		userService:         userService,
+		logger:              logger,
+	}
+}
+
+// BacktestParams is an alert definition and the period to replay it over
+type BacktestParams struct {
+	CreateAlertParams
+	From time.Time
+	To   time.Time
+}
+
+// Backtest is the outcome of a backtest
+type Backtest struct {
+	CoinSymbol string
+	From       time.Time
+	To         time.Time
+	Points     int
+	alert.BacktestResult
+}
+
+// Run replays an alert definition over the period, within the plan's price
+// history lookback. Schedule windows are evaluated in the user's timezone
+func (s *BacktestService) Run(ctx context.Context, userID int64, params BacktestParams) (*Backtest, error) {
+	// Only price conditions can be replayed from price history
+	if def, ok := alerttype.Lookup(params.AlertType); ok && def.Source != alerttype.SourcePrice {
+		return nil, errors.ErrValidationFailed.WithMessage(def.Name() + " alerts cannot be backtested")
+	}
+
+	if params.Schedule != nil {
+		if err := params.Schedule.Validate(); err != nil {
+			return nil, errors.ErrValidationFailed.WithMessage(err.Error())
+		}
+	}
+
+	user, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.priceHistoryService.Get(ctx, userID, params.CoinSymbol, params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+
+	a := alert.Alert{
+		CoinSymbol:        history.Symbol,
+		AlertType:         alert.AlertType(params.AlertType),
+		ConditionOperator: alert.ConditionOperator(getConditionOperator(params.AlertType)),
+		ConditionValue:    params.ConditionValue,
+		IsRecurring:       params.IsRecurring,
+		Schedule:          params.Schedule,
+		Location:          schedule.LoadLocation(user.Timezone),
+	}
+	if params.ConditionTimeframe != nil {
+		a.ConditionTimeframe = *params.ConditionTimeframe
+	}
+	if params.PeriodicInterval != nil {
+		a.PeriodicInterval = *params.PeriodicInterval
+	}
+
+	result, err := alert.Backtest(ctx, a, history.Points, s.logger)
+	if err != nil {
+		if errors.Is(err, alert.ErrBacktestUnsupported) {
+			return nil, errors.ErrBadRequest.WithMessage("Only price alerts can be backtested")
+		}
+		return nil, errors.ErrInternal.WithCause(err)
+	}
+
+	return &Backtest{
+		CoinSymbol:     history.Symbol,
+		From:           history.From,
+		To:             history.To,
+		Points:         len(history.Points),
+		BacktestResult: *result,
+	}, nil
+}
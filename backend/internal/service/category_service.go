@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// CategoryService handles coin categories (market sectors)
+type CategoryService struct {
+	pool *pgxpool.Pool
+}
+
+// NewCategoryService creates a new CategoryService
+func NewCategoryService(pool *pgxpool.Pool) *CategoryService {
+	return &CategoryService{pool: pool}
+}
+
+// Category represents a market sector with aggregated performance
+type Category struct {
+	ID                 string
+	Name               string
+	MarketCap          *float64
+	MarketCapChange24h *float64
+	Volume24h          *float64
+	CoinsCount         int64
+	// Market cap weighted average 24h change of tracked coins
+	AvgPriceChange24hPct *float64
+}
+
+// GetAll returns all categories with sector performance, best performing first
+func (s *CategoryService) GetAll(ctx context.Context) ([]Category, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT
+			cat.id, cat.name, cat.market_cap, cat.market_cap_change_24h, cat.volume_24h,
+			COUNT(c.id) as coins_count,
+			SUM(c.price_change_24h_pct * c.market_cap) / NULLIF(SUM(c.market_cap), 0) as avg_change
+		FROM categories cat
+		LEFT JOIN coin_categories cc ON cc.category_id = cat.id
+		LEFT JOIN coins c ON c.id = cc.coin_id AND c.is_stablecoin = false AND c.is_excluded = false
+		GROUP BY cat.id
+		ORDER BY COALESCE(cat.market_cap_change_24h,
+			SUM(c.price_change_24h_pct * c.market_cap) / NULLIF(SUM(c.market_cap), 0)) DESC NULLS LAST
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var c Category
+		err := rows.Scan(
+			&c.ID, &c.Name, &c.MarketCap, &c.MarketCapChange24h, &c.Volume24h,
+			&c.CoinsCount, &c.AvgPriceChange24hPct,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if categories == nil {
+		categories = []Category{}
+	}
+
+	return categories, nil
+}
+
+// GetByID returns a category by ID
+func (s *CategoryService) GetByID(ctx context.Context, id string) (*Category, error) {
+	var c Category
+	err := s.pool.QueryRow(ctx, `
+		SELECT id, name, market_cap, market_cap_change_24h, volume_24h
+		FROM categories WHERE id = $1
+	`, id).Scan(&c.ID, &c.Name, &c.MarketCap, &c.MarketCapChange24h, &c.Volume24h)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrCategoryNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return &c, nil
+}
@@ -2,18 +2,29 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"html"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/alerttype"
+	"github.com/weqory/backend/pkg/clock"
 )
 
+// expiryNotifyDelay spaces out expiry notifications to stay well below the
+// Telegram global rate limit
+const expiryNotifyDelay = 50 * time.Millisecond
+
 // CleanupService handles scheduled cleanup tasks
 type CleanupService struct {
 	pool        *pgxpool.Pool
 	userService *UserService
+	telegram    *telegram.Client
+	clock       clock.Clock
 	logger      *slog.Logger
-	done        chan struct{}
 }
 
 // NewCleanupService creates a new CleanupService
@@ -21,53 +32,34 @@ func NewCleanupService(pool *pgxpool.Pool, userService *UserService, logger *slo
 	return &CleanupService{
 		pool:        pool,
 		userService: userService,
+		clock:       clock.Real{},
 		logger:      logger,
-		done:        make(chan struct{}),
 	}
 }
 
-// Start starts the background cleanup workers
-func (s *CleanupService) Start(ctx context.Context) {
-	// Run daily cleanup at startup and then every 24 hours
-	go s.runDailyCleanup(ctx)
-
-	// Run monthly reset at startup (will only actually reset if needed)
-	go s.runMonthlyReset(ctx)
+// SetClock sets the clock retention periods are measured from
+func (s *CleanupService) SetClock(c clock.Clock) {
+	s.clock = c
 }
 
-// Stop stops the cleanup service
-func (s *CleanupService) Stop() {
-	close(s.done)
-}
-
-// runDailyCleanup runs daily cleanup tasks
-func (s *CleanupService) runDailyCleanup(ctx context.Context) {
-	// Run immediately on startup
-	s.performDailyCleanup(ctx)
-
-	ticker := time.NewTicker(24 * time.Hour)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-s.done:
-			return
-		case <-ticker.C:
-			s.performDailyCleanup(ctx)
-		}
-	}
+// SetTelegram makes RunAlertExpiry tell users about alerts that expired
+// without triggering
+func (s *CleanupService) SetTelegram(client *telegram.Client) {
+	s.telegram = client
 }
 
-// performDailyCleanup performs all daily cleanup tasks
-func (s *CleanupService) performDailyCleanup(ctx context.Context) {
+// RunDailyCleanup performs all daily cleanup tasks
+// Every task runs even if an earlier one fails; the first error is returned
+func (s *CleanupService) RunDailyCleanup(ctx context.Context) error {
 	s.logger.Info("starting daily cleanup")
 
+	var firstErr error
+
 	// 1. Check for expired plans and downgrade
 	expiredCount, err := s.processExpiredPlans(ctx)
 	if err != nil {
 		s.logger.Error("failed to process expired plans", slog.String("error", err.Error()))
+		firstErr = err
 	} else if expiredCount > 0 {
 		s.logger.Info("downgraded expired plans", slog.Int("count", expiredCount))
 	}
@@ -76,11 +68,37 @@ func (s *CleanupService) performDailyCleanup(ctx context.Context) {
 	historyDeleted, err := s.cleanupHistory(ctx)
 	if err != nil {
 		s.logger.Error("failed to cleanup history", slog.String("error", err.Error()))
+		if firstErr == nil {
+			firstErr = err
+		}
 	} else if historyDeleted > 0 {
 		s.logger.Info("cleaned up old history records", slog.Int64("deleted", historyDeleted))
 	}
 
+	// 3. Cleanup price history older than the longest plan can read
+	pricesDeleted, err := s.cleanupPriceHistory(ctx)
+	if err != nil {
+		s.logger.Error("failed to cleanup price history", slog.String("error", err.Error()))
+		if firstErr == nil {
+			firstErr = err
+		}
+	} else if pricesDeleted > 0 {
+		s.logger.Info("cleaned up old price history", slog.Int64("deleted", pricesDeleted))
+	}
+
+	// 4. Cleanup sent messages past the engagement stats window
+	deliveriesDeleted, err := s.cleanupNotificationDeliveries(ctx)
+	if err != nil {
+		s.logger.Error("failed to cleanup notification deliveries", slog.String("error", err.Error()))
+		if firstErr == nil {
+			firstErr = err
+		}
+	} else if deliveriesDeleted > 0 {
+		s.logger.Info("cleaned up old notification deliveries", slog.Int64("deleted", deliveriesDeleted))
+	}
+
 	s.logger.Info("daily cleanup completed")
+	return firstErr
 }
 
 // processExpiredPlans finds and downgrades all expired plans
@@ -92,6 +110,9 @@ func (s *CleanupService) processExpiredPlans(ctx context.Context) (int, error) {
 
 	count := 0
 	for _, user := range expiredUsers {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
 		if err := s.userService.DowngradePlan(ctx, user.ID); err != nil {
 			s.logger.Error("failed to downgrade user plan",
 				slog.Int64("user_id", user.ID),
@@ -120,8 +141,8 @@ func (s *CleanupService) cleanupHistory(ctx context.Context) (int64, error) {
 		DELETE FROM alert_history h
 		USING user_retention ur
 		WHERE h.user_id = ur.user_id
-		  AND h.triggered_at < NOW() - (ur.history_retention_days || ' days')::INTERVAL
-	`)
+		  AND h.triggered_at < $1::timestamptz - (ur.history_retention_days || ' days')::INTERVAL
+	`, s.clock.Now())
 	if err != nil {
 		return 0, err
 	}
@@ -129,34 +150,195 @@ func (s *CleanupService) cleanupHistory(ctx context.Context) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
-// runMonthlyReset runs monthly reset tasks
-func (s *CleanupService) runMonthlyReset(ctx context.Context) {
-	// Run immediately on startup (will check if reset is needed)
-	s.performMonthlyReset(ctx)
+// cleanupPriceHistory removes price points no plan can read anymore
+func (s *CleanupService) cleanupPriceHistory(ctx context.Context) (int64, error) {
+	result, err := s.pool.Exec(ctx, `
+		DELETE FROM price_history
+		WHERE recorded_at < $1::timestamptz - (
+			(SELECT MAX(price_history_days) FROM subscription_plans) || ' days'
+		)::INTERVAL
+	`, s.clock.Now())
+	if err != nil {
+		return 0, err
+	}
 
-	// Check every hour if we need to reset (handles month boundaries)
-	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
+	return result.RowsAffected(), nil
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-s.done:
-			return
-		case <-ticker.C:
-			s.performMonthlyReset(ctx)
-		}
+// cleanupNotificationDeliveries removes sent messages older than the
+// engagement stats keep
+func (s *CleanupService) cleanupNotificationDeliveries(ctx context.Context) (int64, error) {
+	result, err := s.pool.Exec(ctx, `
+		DELETE FROM notification_deliveries WHERE sent_at < $1
+	`, s.clock.Now().Add(-notificationDeliveryRetention))
+	if err != nil {
+		return 0, err
 	}
+
+	return result.RowsAffected(), nil
 }
 
-// performMonthlyReset resets monthly notification counters
-func (s *CleanupService) performMonthlyReset(ctx context.Context) {
+// RunMonthlyReset resets monthly notification counters (only when a new
+// month has started, so it is safe to run frequently)
+func (s *CleanupService) RunMonthlyReset(ctx context.Context) error {
 	if err := s.userService.ResetMonthlyNotifications(ctx); err != nil {
-		s.logger.Error("failed to reset monthly notifications", slog.String("error", err.Error()))
-		return
+		return err
 	}
 	s.logger.Debug("monthly notification reset check completed")
+	return nil
+}
+
+// RunUsageReconcile corrects drifted watchlist and alert counters used by
+// plan limit checks. Drift means a write path bypassed the triggers that
+// keep them, so it is logged as a warning
+func (s *CleanupService) RunUsageReconcile(ctx context.Context) error {
+	ids, err := s.userService.ReconcileUsageCounters(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		s.logger.Warn("corrected drifted usage counters",
+			slog.Int("users", len(ids)),
+			slog.Any("user_ids", ids),
+		)
+	}
+	return nil
+}
+
+// expiredAlert is an alert deleted by RunAlertExpiry
+type expiredAlert struct {
+	userID         int64
+	telegramID     int64
+	notify         bool // owner has notifications enabled
+	symbol         string
+	name           *string
+	alertType      string
+	conditionValue float64
+	quoteAsset     string // empty for USD
+	timesTriggered int
+	language       string // of the owner, for the notice
+	numberFormat   string
+}
+
+// RunAlertExpiry deletes alerts past their expiry date or out of triggers
+// and tells the owners about the ones that expired without ever firing.
+// The alert engine already stopped evaluating them; their history is kept
+func (s *CleanupService) RunAlertExpiry(ctx context.Context) error {
+	expired, err := s.deleteExpiredAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	s.logger.Info("deleted expired alerts", slog.Int("count", len(expired)))
+
+	if s.telegram == nil {
+		return nil
+	}
+
+	// Notifications are best-effort; the alerts are already gone
+	byUser := make(map[int64][]expiredAlert)
+	var users []int64
+	for _, a := range expired {
+		if a.timesTriggered > 0 || !a.notify {
+			continue
+		}
+		if _, ok := byUser[a.userID]; !ok {
+			users = append(users, a.userID)
+		}
+		byUser[a.userID] = append(byUser[a.userID], a)
+	}
+
+	for i, userID := range users {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(expiryNotifyDelay):
+			}
+		}
+		alerts := byUser[userID]
+		_, err := s.telegram.SendMessage(ctx, telegram.SendMessageRequest{
+			ChatID:    alerts[0].telegramID,
+			Text:      expiredAlertsText(alerts),
+			ParseMode: "HTML",
+		})
+		if err != nil {
+			s.logger.Error("failed to notify user about expired alerts",
+				slog.Int64("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return nil
+}
+
+// deleteExpiredAlerts deletes the expired alerts and returns them
+func (s *CleanupService) deleteExpiredAlerts(ctx context.Context) ([]expiredAlert, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH expired AS (
+			DELETE FROM alerts
+			WHERE expires_at <= $1::timestamptz
+			   OR (max_triggers IS NOT NULL AND times_triggered >= max_triggers)
+			RETURNING user_id, coin_id, name, alert_type, condition_value, quote_asset, times_triggered
+		)
+		SELECT e.user_id, u.telegram_id, u.notifications_enabled, c.symbol,
+		       e.name, e.alert_type, e.condition_value, COALESCE(e.quote_asset, ''), e.times_triggered,
+		       COALESCE(u.language_code, ''), u.number_format
+		FROM expired e
+		JOIN users u ON u.id = e.user_id
+		JOIN coins c ON c.id = e.coin_id
+		ORDER BY e.user_id
+	`, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expired []expiredAlert
+	for rows.Next() {
+		var a expiredAlert
+		if err := rows.Scan(
+			&a.userID, &a.telegramID, &a.notify, &a.symbol,
+			&a.name, &a.alertType, &a.conditionValue, &a.quoteAsset, &a.timesTriggered,
+			&a.language, &a.numberFormat,
+		); err != nil {
+			return nil, err
+		}
+		expired = append(expired, a)
+	}
+
+	return expired, rows.Err()
+}
+
+// expiredAlertsText tells a user that alerts expired without triggering
+func expiredAlertsText(alerts []expiredAlert) string {
+	var b strings.Builder
+	if len(alerts) == 1 {
+		b.WriteString("⌛ <b>Alert expired without triggering</b>\n")
+	} else {
+		fmt.Fprintf(&b, "⌛ <b>%d alerts expired without triggering</b>\n", len(alerts))
+	}
+
+	for _, a := range alerts {
+		b.WriteString("\n• ")
+		if a.name != nil {
+			b.WriteString("<b>" + html.EscapeString(*a.name) + "</b>: ")
+		}
+		b.WriteString(html.EscapeString(a.symbol))
+
+		if def, ok := alerttype.Lookup(a.alertType); ok {
+			b.WriteString(" " + telegram.DescribeCondition(def, a.conditionValue, a.quoteAsset, a.numberFormat, a.language))
+		} else {
+			b.WriteString(" " + strings.ToLower(strings.ReplaceAll(a.alertType, "_", " ")))
+		}
+	}
+
+	b.WriteString("\n\nExpired alerts are removed; your alert history is kept.")
+	return b.String()
 }
 
 // CleanupHistoryForUser cleans up old history for a specific user
@@ -164,8 +346,8 @@ func (s *CleanupService) CleanupHistoryForUser(ctx context.Context, userID int64
 	result, err := s.pool.Exec(ctx, `
 		DELETE FROM alert_history
 		WHERE user_id = $1
-		  AND triggered_at < NOW() - ($2 || ' days')::INTERVAL
-	`, userID, retentionDays)
+		  AND triggered_at < $3::timestamptz - ($2 || ' days')::INTERVAL
+	`, userID, retentionDays, s.clock.Now())
 	if err != nil {
 		return 0, err
 	}
@@ -182,14 +364,15 @@ type CleanupStats struct {
 
 func (s *CleanupService) GetCleanupStats(ctx context.Context) (*CleanupStats, error) {
 	stats := &CleanupStats{}
+	now := s.clock.Now()
 
 	// Count expired plans
 	err := s.pool.QueryRow(ctx, `
 		SELECT COUNT(*) FROM users
 		WHERE plan != 'standard'
 		  AND plan_expires_at IS NOT NULL
-		  AND plan_expires_at < NOW()
-	`).Scan(&stats.ExpiredPlans)
+		  AND plan_expires_at < $1
+	`, now).Scan(&stats.ExpiredPlans)
 	if err != nil {
 		return nil, err
 	}
@@ -203,8 +386,8 @@ func (s *CleanupService) GetCleanupStats(ctx context.Context) (*CleanupStats, er
 		)
 		SELECT COUNT(*) FROM alert_history h
 		JOIN user_retention ur ON h.user_id = ur.user_id
-		WHERE h.triggered_at < NOW() - (ur.history_retention_days || ' days')::INTERVAL
-	`).Scan(&stats.HistoryToDelete)
+		WHERE h.triggered_at < $1::timestamptz - (ur.history_retention_days || ' days')::INTERVAL
+	`, now).Scan(&stats.HistoryToDelete)
 	if err != nil {
 		return nil, err
 	}
@@ -212,9 +395,9 @@ func (s *CleanupService) GetCleanupStats(ctx context.Context) (*CleanupStats, er
 	// Count users needing notification reset
 	err = s.pool.QueryRow(ctx, `
 		SELECT COUNT(*) FROM users
-		WHERE notifications_reset_at < DATE_TRUNC('month', NOW())
+		WHERE notifications_reset_at < DATE_TRUNC('month', $1::timestamptz)
 		   OR notifications_reset_at IS NULL
-	`).Scan(&stats.UsersNeedingReset)
+	`, now).Scan(&stats.UsersNeedingReset)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiredAlertsText(t *testing.T) {
+	name := "CPI <print>"
+	text := expiredAlertsText([]expiredAlert{
+		{symbol: "BTC", name: &name, alertType: "PRICE_ABOVE", conditionValue: 70000},
+		{symbol: "ETH", alertType: "VOLUME_SPIKE", conditionValue: 3},
+	})
+
+	assert.Contains(t, text, "2 alerts expired without triggering")
+	assert.Contains(t, text, "<b>CPI &lt;print&gt;</b>: BTC above $70000")
+	assert.Contains(t, text, "ETH volume spike")
+
+	text = expiredAlertsText([]expiredAlert{{symbol: "SOL", alertType: "PRICE_BELOW", conditionValue: 99.5}})
+	assert.Contains(t, text, "Alert expired without triggering")
+	assert.Contains(t, text, "SOL below $99.5")
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Kinds of coin exclusions
+const (
+	// ExclusionStablecoin keeps a coin out of the watchlist unless the user
+	// allows stablecoins
+	ExclusionStablecoin = "stablecoin"
+	// ExclusionExcluded keeps a coin out of the watchlist for everyone
+	ExclusionExcluded = "excluded"
+)
+
+// CoinExclusionService manages the stablecoin and exclusion lists. Coins
+// that are not synced yet can be listed too; the coin sync picks them up
+type CoinExclusionService struct {
+	pool *pgxpool.Pool
+}
+
+// NewCoinExclusionService creates a new CoinExclusionService
+func NewCoinExclusionService(pool *pgxpool.Pool) *CoinExclusionService {
+	return &CoinExclusionService{pool: pool}
+}
+
+// CoinExclusion is a coin symbol on the stablecoin or exclusion list
+type CoinExclusion struct {
+	Symbol    string
+	Kind      string
+	Note      *string
+	CreatedAt time.Time
+}
+
+// GetAll returns all listed coins, grouped by kind
+func (s *CoinExclusionService) GetAll(ctx context.Context) ([]CoinExclusion, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT symbol, kind, note, created_at
+		FROM coin_exclusions
+		ORDER BY kind, symbol
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	exclusions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (CoinExclusion, error) {
+		var e CoinExclusion
+		err := row.Scan(&e.Symbol, &e.Kind, &e.Note, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return exclusions, nil
+}
+
+// Set lists a coin as kind, replacing its previous entry, and updates the
+// coin right away. Coins already in watchlists stay there
+func (s *CoinExclusionService) Set(ctx context.Context, symbol, kind string, note *string) (*CoinExclusion, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	var e CoinExclusion
+	err = tx.QueryRow(ctx, `
+		INSERT INTO coin_exclusions (symbol, kind, note)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (symbol) DO UPDATE SET
+			kind = EXCLUDED.kind,
+			note = EXCLUDED.note
+		RETURNING symbol, kind, note, created_at
+	`, symbol, kind, note).Scan(&e.Symbol, &e.Kind, &e.Note, &e.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := applyExclusion(ctx, tx, symbol, kind); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return &e, nil
+}
+
+// Delete takes a coin off the lists, making it watchable by everyone
+func (s *CoinExclusionService) Delete(ctx context.Context, symbol string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM coin_exclusions WHERE symbol = $1`, symbol)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return errors.ErrNotFound.WithMessage("Coin is not listed")
+	}
+
+	if err := applyExclusion(ctx, tx, symbol, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return nil
+}
+
+// applyExclusion sets the stablecoin and exclusion flags of a coin for
+// kind; an empty kind clears both
+func applyExclusion(ctx context.Context, tx pgx.Tx, symbol, kind string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE coins SET
+			is_stablecoin = $2,
+			is_excluded = $3
+		WHERE symbol = $1
+	`, symbol, kind == ExclusionStablecoin, kind == ExclusionExcluded)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// CoinService serves the coin detail page
+type CoinService struct {
+	pool *pgxpool.Pool
+}
+
+// NewCoinService creates a new CoinService
+func NewCoinService(pool *pgxpool.Pool) *CoinService {
+	return &CoinService{pool: pool}
+}
+
+// CoinMetadata is the description, links and supply of a coin, synced
+// weekly from CoinGecko
+type CoinMetadata struct {
+	Description       *string
+	HomepageURL       *string
+	ExplorerURLs      []string
+	CirculatingSupply *float64
+	TotalSupply       *float64
+	MaxSupply         *float64 // nil for uncapped coins
+	UpdatedAt         time.Time
+}
+
+// CoinDetail is a coin with its metadata, which is nil until it has been
+// synced
+type CoinDetail struct {
+	Coin
+	Metadata *CoinMetadata
+}
+
+// GetDetail returns an active coin with its metadata, or ErrCoinNotFound
+func (s *CoinService) GetDetail(ctx context.Context, symbol string) (*CoinDetail, error) {
+	var detail CoinDetail
+	var metadata CoinMetadata
+	var updatedAt *time.Time
+	err := s.pool.QueryRow(ctx, `
+		SELECT `+coinColumns+`,
+			m.description, m.homepage_url, m.explorer_urls,
+			m.circulating_supply, m.total_supply, m.max_supply, m.updated_at
+		FROM coins
+		LEFT JOIN coin_metadata m ON m.coin_id = coins.id
+		WHERE coins.symbol = $1 AND coins.is_active = true
+	`, strings.ToUpper(strings.TrimSpace(symbol))).Scan(
+		&detail.ID, &detail.Symbol, &detail.Name, &detail.BinanceSymbol, &detail.Rank,
+		&detail.CurrentPrice, &detail.MarketCap, &detail.Volume24h, &detail.PriceChange24hPct,
+		&metadata.Description, &metadata.HomepageURL, &metadata.ExplorerURLs,
+		&metadata.CirculatingSupply, &metadata.TotalSupply, &metadata.MaxSupply, &updatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrCoinNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if updatedAt != nil {
+		metadata.UpdatedAt = *updatedAt
+		detail.Metadata = &metadata
+	}
+
+	return &detail, nil
+}
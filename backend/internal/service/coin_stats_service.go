@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	coinStatsCacheKeyPrefix = "coin_stats:"
+	coinStatsCacheTTL       = 15 * time.Minute
+
+	// Stats are computed from hourly candles of the last 30 days, with
+	// daily candles for the daily ATR
+	coinStatsPeriod    = 30 * 24 * time.Hour
+	coinStatsATRPeriod = 14
+
+	// Correlation is measured against BTC
+	coinStatsBenchmark = "BTC"
+)
+
+// CoinStatsService computes volatility, ATR and correlation statistics of
+// a coin from the price history, cached per symbol
+type CoinStatsService struct {
+	priceHistoryService *PriceHistoryService
+	redis               *redis.Client
+	logger              *slog.Logger
+}
+
+// NewCoinStatsService creates a new CoinStatsService
+func NewCoinStatsService(priceHistoryService *PriceHistoryService, redisClient *redis.Client, logger *slog.Logger) *CoinStatsService {
+	return &CoinStatsService{
+		priceHistoryService: priceHistoryService,
+		redis:               redisClient,
+		logger:              logger,
+	}
+}
+
+// CoinStats are statistics of a coin; values are nil when there is not
+// enough history to compute them
+type CoinStats struct {
+	Symbol         string    `json:"symbol"`
+	Price          *float64  `json:"price"`           // last hourly close
+	Volatility7d   *float64  `json:"volatility_7d"`   // annualized, from hourly returns
+	Volatility30d  *float64  `json:"volatility_30d"`  // annualized, from hourly returns
+	ATR1h          *float64  `json:"atr_1h"`          // 14-period ATR of hourly candles
+	ATR1d          *float64  `json:"atr_1d"`          // 14-period ATR of daily candles
+	ATR1dPct       *float64  `json:"atr_1d_pct"`      // daily ATR as percent of price
+	CorrelationBTC *float64  `json:"correlation_btc"` // of hourly returns over 30 days
+	Candles        int       `json:"candles"`         // hourly candles used
+	ComputedAt     time.Time `json:"computed_at"`
+}
+
+// Get returns the stats of a coin, computing them on a cache miss
+func (s *CoinStatsService) Get(ctx context.Context, coinSymbol string) (*CoinStats, error) {
+	coinSymbol = strings.ToUpper(strings.TrimSpace(coinSymbol))
+	cacheKey := coinStatsCacheKeyPrefix + coinSymbol
+
+	if data, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+		var stats CoinStats
+		if json.Unmarshal(data, &stats) == nil {
+			return &stats, nil
+		}
+	}
+
+	stats, err := s.compute(ctx, coinSymbol, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(stats); err == nil {
+		if err := s.redis.Set(ctx, cacheKey, data, coinStatsCacheTTL).Err(); err != nil {
+			s.logger.Warn("coin stats cache write failed", slog.String("error", err.Error()))
+		}
+	}
+
+	return stats, nil
+}
+
+// compute loads the candles of a coin and BTC and derives the stats
+func (s *CoinStatsService) compute(ctx context.Context, coinSymbol string, now time.Time) (*CoinStats, error) {
+	key, err := s.priceHistoryService.PriceKey(ctx, coinSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	store := s.priceHistoryService.store
+	from := now.Add(-coinStatsPeriod)
+
+	hourly, err := store.Candles(ctx, key, time.Hour, from, now)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	daily, err := store.Candles(ctx, key, 24*time.Hour, from, now)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	stats := &CoinStats{Symbol: coinSymbol, Candles: len(hourly), ComputedAt: now}
+	if len(hourly) > 0 {
+		stats.Price = floatPtr(hourly[len(hourly)-1].Close)
+	}
+
+	if v, ok := pricehistory.Volatility(candlesSince(hourly, now.Add(-7*24*time.Hour)), time.Hour); ok {
+		stats.Volatility7d = &v
+	}
+	if v, ok := pricehistory.Volatility(hourly, time.Hour); ok {
+		stats.Volatility30d = &v
+	}
+	if atr, ok := pricehistory.ATR(hourly, coinStatsATRPeriod); ok {
+		stats.ATR1h = &atr
+	}
+	if atr, ok := pricehistory.ATR(daily, coinStatsATRPeriod); ok {
+		stats.ATR1d = &atr
+		if stats.Price != nil && *stats.Price > 0 {
+			stats.ATR1dPct = floatPtr(atr / *stats.Price * 100)
+		}
+	}
+
+	if coinSymbol == coinStatsBenchmark {
+		stats.CorrelationBTC = floatPtr(1)
+		return stats, nil
+	}
+
+	btcKey, err := s.priceHistoryService.PriceKey(ctx, coinStatsBenchmark)
+	if err != nil {
+		s.logger.Warn("no price key for correlation benchmark", slog.String("error", err.Error()))
+		return stats, nil
+	}
+	btc, err := store.Candles(ctx, btcKey, time.Hour, from, now)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if c, ok := pricehistory.Correlation(hourly, btc); ok {
+		stats.CorrelationBTC = &c
+	}
+
+	return stats, nil
+}
+
+// candlesSince returns the candles starting at or after t
+func candlesSince(candles []pricehistory.Candle, t time.Time) []pricehistory.Candle {
+	for i, c := range candles {
+		if !c.Time.Before(t) {
+			return candles[i:]
+		}
+	}
+	return nil
+}
+
+// floatPtr returns a pointer to v
+func floatPtr(v float64) *float64 {
+	return &v
+}
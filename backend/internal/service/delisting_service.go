@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/internal/coingecko"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// PausedReasonCoinDelisted marks alerts paused because their coin was delisted
+const PausedReasonCoinDelisted = "coin_delisted"
+
+// delistingNotifyDelay spaces out owner notifications to stay well below
+// the Telegram global rate limit
+const delistingNotifyDelay = 50 * time.Millisecond
+
+// DelistingService detects coins that are no longer priced by any source,
+// marks them inactive, pauses their alerts and notifies the owners
+type DelistingService struct {
+	pool         *pgxpool.Pool
+	exchangeInfo *binance.ExchangeInfo
+	coingecko    *coingecko.Client
+	telegram     *telegram.Client
+	miniAppURL   string
+	logger       *slog.Logger
+}
+
+// NewDelistingService creates a new DelistingService
+func NewDelistingService(
+	pool *pgxpool.Pool,
+	exchangeInfo *binance.ExchangeInfo,
+	coingeckoClient *coingecko.Client,
+	telegramClient *telegram.Client,
+	miniAppURL string,
+	logger *slog.Logger,
+) *DelistingService {
+	return &DelistingService{
+		pool:         pool,
+		exchangeInfo: exchangeInfo,
+		coingecko:    coingeckoClient,
+		telegram:     telegramClient,
+		miniAppURL:   miniAppURL,
+		logger:       logger,
+	}
+}
+
+// DelistedCoin represents an inactive coin in the admin report
+type DelistedCoin struct {
+	Symbol        string
+	Name          string
+	BinanceSymbol *string
+	CoingeckoID   *string
+	Reason        *string
+	DelistedAt    *time.Time
+	PausedAlerts  int64
+	AffectedUsers int64
+}
+
+// DelistingResult summarizes a detection run
+type DelistingResult struct {
+	Checked       int
+	Delisted      []string
+	Relisted      []string
+	PausedAlerts  int
+	NotifiedUsers int
+}
+
+// delistingCandidate is a coin loaded for a detection run
+type delistingCandidate struct {
+	id            int
+	symbol        string
+	name          string
+	binanceSymbol *string
+	coingeckoID   *string
+	isActive      bool
+}
+
+// pausedAlertOwner is an owner of alerts paused by a delisting
+type pausedAlertOwner struct {
+	userID     int64
+	telegramID int64
+	alerts     int
+}
+
+// Detect checks every coin against Binance exchangeInfo and CoinGecko
+// A coin is delisted only when both sources confirm it is gone; a coin that
+// merely lost its Binance pair keeps working through the CoinGecko fallback
+func (s *DelistingService) Detect(ctx context.Context) (*DelistingResult, error) {
+	// Both lookups must succeed; an outage must never look like a delisting
+	pairs, err := s.exchangeInfo.Symbols(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrExternalService)
+	}
+
+	coins, err := s.loadCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, c := range coins {
+		if c.coingeckoID != nil {
+			ids = append(ids, *c.coingeckoID)
+		}
+	}
+
+	prices, err := s.coingecko.GetSimplePrices(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrExternalService)
+	}
+
+	result := &DelistingResult{}
+	for _, c := range coins {
+		// Stop instead of logging a failure per remaining coin
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result.Checked++
+
+		onBinance := false
+		if c.binanceSymbol != nil {
+			info, ok := pairs[*c.binanceSymbol]
+			onBinance = ok && info.Status == binance.SymbolStatusTrading
+		}
+
+		// Without a CoinGecko ID there is nothing to confirm the coin is gone
+		if c.coingeckoID == nil {
+			continue
+		}
+		_, onCoinGecko := prices[*c.coingeckoID]
+
+		listed := onBinance || onCoinGecko
+		switch {
+		case c.isActive && !listed:
+			reason := "Delisted from Binance and no longer tracked by CoinGecko"
+			if c.binanceSymbol == nil {
+				reason = "Not traded on Binance and no longer tracked by CoinGecko"
+			}
+
+			paused, notified, err := s.delist(ctx, c, reason)
+			if err != nil {
+				s.logger.Error("failed to delist coin",
+					slog.String("symbol", c.symbol),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+
+			result.Delisted = append(result.Delisted, c.symbol)
+			result.PausedAlerts += paused
+			result.NotifiedUsers += notified
+
+		case !c.isActive && listed:
+			// Alerts stay paused; owners resume them deliberately
+			if _, err := s.pool.Exec(ctx, `
+				UPDATE coins SET is_active = true, delisted_at = NULL, delist_reason = NULL WHERE id = $1
+			`, c.id); err != nil {
+				s.logger.Error("failed to reactivate coin",
+					slog.String("symbol", c.symbol),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+
+			result.Relisted = append(result.Relisted, c.symbol)
+			s.logger.Info("coin relisted", slog.String("symbol", c.symbol))
+		}
+	}
+
+	return result, nil
+}
+
+// GetDelisted returns the admin report of inactive coins
+func (s *DelistingService) GetDelisted(ctx context.Context) ([]DelistedCoin, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.symbol, c.name, c.binance_symbol, c.coingecko_id, c.delist_reason, c.delisted_at,
+		       COUNT(a.id) AS paused_alerts,
+		       COUNT(DISTINCT a.user_id) AS affected_users
+		FROM coins c
+		LEFT JOIN alerts a ON a.coin_id = c.id AND a.paused_reason = 'coin_delisted'
+		WHERE c.is_active = false
+		GROUP BY c.id
+		ORDER BY c.delisted_at DESC NULLS LAST, c.symbol ASC
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var coins []DelistedCoin
+	for rows.Next() {
+		var c DelistedCoin
+		if err := rows.Scan(
+			&c.Symbol, &c.Name, &c.BinanceSymbol, &c.CoingeckoID, &c.Reason, &c.DelistedAt,
+			&c.PausedAlerts, &c.AffectedUsers,
+		); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		coins = append(coins, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if coins == nil {
+		coins = []DelistedCoin{}
+	}
+
+	return coins, nil
+}
+
+// RunDetect runs a scheduled detection and logs the outcome
+func (s *DelistingService) RunDetect(ctx context.Context) error {
+	result, err := s.Detect(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("delisting detection completed",
+		slog.Int("checked", result.Checked),
+		slog.Int("delisted", len(result.Delisted)),
+		slog.Int("relisted", len(result.Relisted)),
+		slog.Int("paused_alerts", result.PausedAlerts),
+	)
+
+	return nil
+}
+
+// loadCandidates loads all non-stablecoin coins
+func (s *DelistingService) loadCandidates(ctx context.Context) ([]delistingCandidate, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, symbol, name, binance_symbol, coingecko_id, is_active
+		FROM coins
+		WHERE is_stablecoin = false
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var coins []delistingCandidate
+	for rows.Next() {
+		var c delistingCandidate
+		if err := rows.Scan(&c.id, &c.symbol, &c.name, &c.binanceSymbol, &c.coingeckoID, &c.isActive); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		coins = append(coins, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return coins, nil
+}
+
+// delist marks a coin inactive, pauses its alerts and notifies the owners
+// Returns the number of paused alerts and notified users
+func (s *DelistingService) delist(ctx context.Context, c delistingCandidate, reason string) (int, int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE coins SET is_active = false, delisted_at = NOW(), delist_reason = $2 WHERE id = $1
+	`, c.id, reason)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH paused AS (
+			UPDATE alerts SET is_paused = true, paused_reason = 'coin_delisted', updated_at = NOW()
+			WHERE coin_id = $1 AND is_paused = false
+			RETURNING user_id
+		)
+		SELECT u.id, u.telegram_id, COUNT(*)
+		FROM paused p
+		JOIN users u ON u.id = p.user_id
+		GROUP BY u.id, u.telegram_id
+	`, c.id)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	var owners []pausedAlertOwner
+	paused := 0
+	for rows.Next() {
+		var o pausedAlertOwner
+		if err := rows.Scan(&o.userID, &o.telegramID, &o.alerts); err != nil {
+			rows.Close()
+			return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+		}
+		owners = append(owners, o)
+		paused += o.alerts
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.logger.Warn("coin delisted",
+		slog.String("symbol", c.symbol),
+		slog.String("reason", reason),
+		slog.Int("paused_alerts", paused),
+		slog.Int("owners", len(owners)),
+	)
+
+	// Notifications are best-effort; the coin and alerts are already updated,
+	// so a shutdown only skips the owners not notified yet
+	notified := 0
+	for i, o := range owners {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return paused, notified, nil
+			case <-time.After(delistingNotifyDelay):
+			}
+		}
+		if err := s.notifyOwner(ctx, c, reason, o); err != nil {
+			s.logger.Error("failed to notify owner about delisting",
+				slog.Int64("user_id", o.userID),
+				slog.String("symbol", c.symbol),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		notified++
+	}
+
+	return paused, notified, nil
+}
+
+// notifyOwner tells a user that their alerts for a coin were paused
+func (s *DelistingService) notifyOwner(ctx context.Context, c delistingCandidate, reason string, o pausedAlertOwner) error {
+	alertsText := "alert"
+	if o.alerts != 1 {
+		alertsText = "alerts"
+	}
+
+	text := fmt.Sprintf(
+		"⚠️ <b>%s is no longer available</b>\n\n%s (%s): %s.\n\nYour %d %s for this coin have been paused.",
+		html.EscapeString(c.symbol),
+		html.EscapeString(c.name),
+		html.EscapeString(c.symbol),
+		reason,
+		o.alerts,
+		alertsText,
+	)
+
+	var replyMarkup *telegram.InlineKeyboardMarkup
+	if s.miniAppURL != "" {
+		replyMarkup = &telegram.InlineKeyboardMarkup{
+			InlineKeyboard: [][]telegram.InlineKeyboardButton{
+				{
+					{
+						Text:   "📱 Open Weqory",
+						WebApp: &telegram.WebAppInfo{URL: s.miniAppURL},
+					},
+				},
+			},
+		}
+	}
+
+	_, err := s.telegram.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:                o.telegramID,
+		Text:                  text,
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+		ReplyMarkup:           replyMarkup,
+	})
+	return err
+}
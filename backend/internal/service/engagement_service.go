@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/kafkasink"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Interactions with an alert message
+const (
+	// InteractionOpen is the Mini App opened from the message's link
+	InteractionOpen = "open"
+	// InteractionClick is a button of the message pressed, or an action
+	// taken in the app opened from it
+	InteractionClick = "click"
+)
+
+// notificationDeliveryRetention is how long sent messages are kept for
+// engagement stats
+const notificationDeliveryRetention = 90 * 24 * time.Hour
+
+// InteractionSink receives interactions for the analytics pipeline
+type InteractionSink interface {
+	Interaction(msg kafkasink.InteractionMessage)
+}
+
+// EngagementService records how users interact with alert messages, to
+// measure which alert types they act on
+type EngagementService struct {
+	pool   *pgxpool.Pool
+	sink   InteractionSink
+	logger *slog.Logger
+}
+
+// NewEngagementService creates a new EngagementService
+func NewEngagementService(pool *pgxpool.Pool, logger *slog.Logger) *EngagementService {
+	return &EngagementService{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// SetSink mirrors interactions to the analytics pipeline
+func (s *EngagementService) SetSink(sink InteractionSink) {
+	s.sink = sink
+}
+
+// AlertTypeEngagement counts the messages of an alert type and how many
+// were opened or clicked. A message combining several alerts counts for
+// each of their types
+type AlertTypeEngagement struct {
+	AlertType string
+	Sent      int64
+	Opened    int64
+	Clicked   int64
+}
+
+// RecordInteraction records an interaction with the message sent under
+// token, which must belong to userID
+func (s *EngagementService) RecordInteraction(ctx context.Context, userID int64, token, action string) error {
+	var alertTypes []string
+	err := s.pool.QueryRow(ctx, `
+		UPDATE notification_deliveries SET
+			opened_at = CASE WHEN $3 = 'open' THEN COALESCE(opened_at, NOW()) ELSE opened_at END,
+			clicked_at = CASE WHEN $3 = 'click' THEN COALESCE(clicked_at, NOW()) ELSE clicked_at END
+		WHERE token = $1 AND user_id = $2
+		RETURNING alert_types
+	`, token, userID, action).Scan(&alertTypes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.ErrNotFound.WithMessage("notification not found")
+		}
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.emit(token, userID, action, alertTypes)
+	return nil
+}
+
+// RecordMessageClick records a button press under the alert message
+// telegramMessageID in the chat of the user with telegramID. Messages
+// that are not tracked alerts are ignored
+func (s *EngagementService) RecordMessageClick(ctx context.Context, telegramID, telegramMessageID int64) error {
+	var (
+		token      string
+		userID     int64
+		alertTypes []string
+	)
+	err := s.pool.QueryRow(ctx, `
+		UPDATE notification_deliveries d SET clicked_at = COALESCE(d.clicked_at, NOW())
+		FROM users u
+		WHERE u.id = d.user_id AND u.telegram_id = $1 AND d.telegram_message_id = $2
+		RETURNING d.token, d.user_id, d.alert_types
+	`, telegramID, telegramMessageID).Scan(&token, &userID, &alertTypes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.emit(token, userID, InteractionClick, alertTypes)
+	return nil
+}
+
+// Stats returns the engagement per alert type of the messages sent since
+func (s *EngagementService) Stats(ctx context.Context, since time.Time) ([]AlertTypeEngagement, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.alert_type,
+		       COUNT(*),
+		       COUNT(d.opened_at),
+		       COUNT(d.clicked_at)
+		FROM notification_deliveries d
+		CROSS JOIN LATERAL unnest(d.alert_types) AS t(alert_type)
+		WHERE d.sent_at >= $1
+		GROUP BY t.alert_type
+		ORDER BY COUNT(*) DESC
+	`, since)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var stats []AlertTypeEngagement
+	for rows.Next() {
+		var e AlertTypeEngagement
+		if err := rows.Scan(&e.AlertType, &e.Sent, &e.Opened, &e.Clicked); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		stats = append(stats, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return stats, nil
+}
+
+// emit mirrors an interaction to the analytics pipeline, if set
+func (s *EngagementService) emit(token string, userID int64, action string, alertTypes []string) {
+	if s.sink == nil {
+		return
+	}
+	s.sink.Interaction(kafkasink.InteractionMessage{
+		Token:      token,
+		UserID:     userID,
+		Action:     action,
+		AlertTypes: alertTypes,
+		Time:       time.Now(),
+	})
+}
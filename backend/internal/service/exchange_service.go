@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/exchange"
+	"github.com/weqory/backend/pkg/crypto"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Per-asset outcomes of an exchange import
+const (
+	ImportStatusAdded       = "added"
+	ImportStatusExisting    = "already_in_watchlist"
+	ImportStatusUnsupported = "unsupported"
+	ImportStatusLimit       = "limit_reached"
+	ImportStatusFailed      = "failed"
+	ImportStatusDuplicate   = "duplicate"
+)
+
+// ExchangeService stores users' read-only exchange API keys and imports
+// the coins they hold into their watchlist
+type ExchangeService struct {
+	pool             *pgxpool.Pool
+	cipher           *crypto.Cipher
+	watchlistService *WatchlistService
+	logger           *slog.Logger
+
+	// NewClientFunc returns the client of an exchange; tests replace it
+	NewClientFunc func(name string) (exchange.Client, error)
+}
+
+// NewExchangeService creates a new ExchangeService. Without a cipher, key
+// storage and imports return ErrServiceUnavailable
+func NewExchangeService(pool *pgxpool.Pool, cipher *crypto.Cipher, watchlistService *WatchlistService, logger *slog.Logger) *ExchangeService {
+	return &ExchangeService{
+		pool:             pool,
+		cipher:           cipher,
+		watchlistService: watchlistService,
+		logger:           logger,
+		NewClientFunc:    exchange.NewClient,
+	}
+}
+
+// ExchangeConnection represents a stored exchange API key
+type ExchangeConnection struct {
+	Exchange     string
+	KeyHint      string
+	LastSyncedAt *time.Time
+	CreatedAt    time.Time
+}
+
+// ExchangeImportItem is the outcome for one asset held on the exchange
+type ExchangeImportItem struct {
+	Asset  string // as named by the exchange
+	Symbol string // matching coin, empty when unsupported
+	Status string // one of the ImportStatus constants
+}
+
+// ExchangeImportResult is the report of an import
+type ExchangeImportResult struct {
+	Items    []ExchangeImportItem
+	Added    int
+	Existing int
+	Skipped  int
+}
+
+// Connect verifies that a key is read-only and stores it encrypted,
+// replacing any key the user had for the exchange
+func (s *ExchangeService) Connect(ctx context.Context, userID int64, name string, creds exchange.Credentials) (*ExchangeConnection, error) {
+	if s.cipher == nil {
+		return nil, errors.ErrServiceUnavailable.WithMessage("Exchange import is not configured")
+	}
+
+	client, err := s.NewClientFunc(name)
+	if err != nil {
+		return nil, errors.ErrBadRequest.WithMessage("Unsupported exchange")
+	}
+
+	creds.APIKey = strings.TrimSpace(creds.APIKey)
+	creds.APISecret = strings.TrimSpace(creds.APISecret)
+	if err := client.CheckReadOnly(ctx, creds); err != nil {
+		return nil, exchangeError(err)
+	}
+
+	encKey, err := s.cipher.Encrypt(creds.APIKey)
+	if err != nil {
+		return nil, errors.ErrInternal.WithCause(err)
+	}
+	encSecret, err := s.cipher.Encrypt(creds.APISecret)
+	if err != nil {
+		return nil, errors.ErrInternal.WithCause(err)
+	}
+
+	conn := ExchangeConnection{Exchange: name, KeyHint: exchange.KeyHint(creds.APIKey)}
+	err = s.pool.QueryRow(ctx, `
+		INSERT INTO exchange_connections (user_id, exchange, api_key_encrypted, api_secret_encrypted, key_hint)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, exchange) DO UPDATE SET
+			api_key_encrypted = EXCLUDED.api_key_encrypted,
+			api_secret_encrypted = EXCLUDED.api_secret_encrypted,
+			key_hint = EXCLUDED.key_hint,
+			last_synced_at = NULL
+		RETURNING last_synced_at, created_at
+	`, userID, name, encKey, encSecret, conn.KeyHint).Scan(&conn.LastSyncedAt, &conn.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return &conn, nil
+}
+
+// List returns the user's connected exchanges
+func (s *ExchangeService) List(ctx context.Context, userID int64) ([]ExchangeConnection, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT exchange, key_hint, last_synced_at, created_at
+		FROM exchange_connections
+		WHERE user_id = $1
+		ORDER BY exchange
+	`, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	connections := []ExchangeConnection{}
+	for rows.Next() {
+		var c ExchangeConnection
+		if err := rows.Scan(&c.Exchange, &c.KeyHint, &c.LastSyncedAt, &c.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		connections = append(connections, c)
+	}
+
+	return connections, rows.Err()
+}
+
+// Disconnect deletes the stored key of an exchange
+func (s *ExchangeService) Disconnect(ctx context.Context, userID int64, name string) error {
+	result, err := s.pool.Exec(ctx, `
+		DELETE FROM exchange_connections WHERE user_id = $1 AND exchange = $2
+	`, userID, name)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.ErrExchangeNotConnected
+	}
+
+	return nil
+}
+
+// Import fetches the balances held on an exchange and adds every supported
+// coin to the watchlist, within the plan's watchlist limit
+func (s *ExchangeService) Import(ctx context.Context, userID int64, name string) (*ExchangeImportResult, error) {
+	if s.cipher == nil {
+		return nil, errors.ErrServiceUnavailable.WithMessage("Exchange import is not configured")
+	}
+
+	creds, err := s.credentials(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.NewClientFunc(name)
+	if err != nil {
+		return nil, errors.ErrBadRequest.WithMessage("Unsupported exchange")
+	}
+
+	balances, err := client.Balances(ctx, *creds)
+	if err != nil {
+		return nil, exchangeError(err)
+	}
+
+	items, err := s.matchCoins(ctx, balances)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExchangeImportResult{Items: items}
+	limitReached := false
+	for i := range result.Items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		item := &result.Items[i]
+		if item.Status != "" {
+			result.Skipped++
+			continue
+		}
+		if limitReached {
+			item.Status = ImportStatusLimit
+			result.Skipped++
+			continue
+		}
+
+		_, err := s.watchlistService.AddCoin(ctx, userID, item.Symbol)
+		switch {
+		case err == nil:
+			item.Status = ImportStatusAdded
+			result.Added++
+		case errors.Is(err, errors.ErrCoinInWatchlist):
+			item.Status = ImportStatusExisting
+			result.Existing++
+		case errors.Is(err, errors.ErrWatchlistLimitExceeded):
+			item.Status = ImportStatusLimit
+			result.Skipped++
+			limitReached = true
+		default:
+			s.logger.Warn("failed to import coin",
+				slog.Int64("user_id", userID),
+				slog.String("symbol", item.Symbol),
+				slog.String("error", err.Error()),
+			)
+			item.Status = ImportStatusFailed
+			result.Skipped++
+		}
+	}
+
+	if _, err := s.pool.Exec(ctx, `
+		UPDATE exchange_connections SET last_synced_at = NOW()
+		WHERE user_id = $1 AND exchange = $2
+	`, userID, name); err != nil {
+		s.logger.Error("failed to record exchange sync",
+			slog.Int64("user_id", userID),
+			slog.String("exchange", name),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return result, nil
+}
+
+// credentials loads and decrypts the stored key of an exchange
+func (s *ExchangeService) credentials(ctx context.Context, userID int64, name string) (*exchange.Credentials, error) {
+	var encKey, encSecret string
+	err := s.pool.QueryRow(ctx, `
+		SELECT api_key_encrypted, api_secret_encrypted
+		FROM exchange_connections
+		WHERE user_id = $1 AND exchange = $2
+	`, userID, name).Scan(&encKey, &encSecret)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrExchangeNotConnected
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	apiKey, err := s.cipher.Decrypt(encKey)
+	if err != nil {
+		return nil, errors.ErrInternal.WithCause(err)
+	}
+	apiSecret, err := s.cipher.Decrypt(encSecret)
+	if err != nil {
+		return nil, errors.ErrInternal.WithCause(err)
+	}
+
+	return &exchange.Credentials{APIKey: apiKey, APISecret: apiSecret}, nil
+}
+
+// matchCoins maps exchange assets to supported coins, most valuable coins
+// (by market cap rank) first so they win when the watchlist limit is hit
+func (s *ExchangeService) matchCoins(ctx context.Context, balances []exchange.Balance) ([]ExchangeImportItem, error) {
+	candidates := make([]string, 0, 2*len(balances))
+	for _, b := range balances {
+		candidates = append(candidates, assetCandidates(b.Asset)...)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT symbol, COALESCE(rank_by_market_cap, 2147483647)
+		FROM coins
+		WHERE symbol = ANY($1) AND is_stablecoin = false AND is_excluded = false AND is_active = true
+	`, candidates)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	ranks := make(map[string]int)
+	for rows.Next() {
+		var symbol string
+		var rank int
+		if err := rows.Scan(&symbol, &rank); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		ranks[symbol] = rank
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return buildImportItems(balances, ranks), nil
+}
+
+// buildImportItems matches each asset to a coin and orders supported coins
+// by rank. Items still to be added have an empty Status; the same coin held
+// in several forms (e.g. BTC and LDBTC) is added once
+func buildImportItems(balances []exchange.Balance, ranks map[string]int) []ExchangeImportItem {
+	items := make([]ExchangeImportItem, 0, len(balances))
+	seen := make(map[string]bool)
+	for _, b := range balances {
+		item := ExchangeImportItem{Asset: b.Asset, Status: ImportStatusUnsupported}
+		for _, candidate := range assetCandidates(b.Asset) {
+			if _, ok := ranks[candidate]; !ok {
+				continue
+			}
+			item.Symbol = candidate
+			item.Status = ""
+			if seen[candidate] {
+				item.Status = ImportStatusDuplicate
+			}
+			seen[candidate] = true
+			break
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		ri, iok := ranks[items[i].Symbol]
+		rj, jok := ranks[items[j].Symbol]
+		if iok != jok {
+			return iok
+		}
+		return ri < rj
+	})
+
+	return items
+}
+
+// assetCandidates returns the coin symbols an exchange asset may stand for.
+// Binance names Simple Earn positions "LD" + symbol (e.g. LDBTC)
+func assetCandidates(asset string) []string {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	candidates := []string{asset}
+	if strings.HasPrefix(asset, "LD") && len(asset) > 3 {
+		candidates = append(candidates, asset[2:])
+	}
+	return candidates
+}
+
+// exchangeError converts exchange client errors to AppErrors
+func exchangeError(err error) error {
+	switch {
+	case errors.Is(err, exchange.ErrInvalidCredentials):
+		return errors.ErrBadRequest.WithMessage("The exchange rejected the API key")
+	case errors.Is(err, exchange.ErrNotReadOnly):
+		return errors.ErrBadRequest.WithMessage("API key must be read-only: disable trading, transfers and withdrawals")
+	default:
+		return errors.ErrExternalService.WithCause(err)
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weqory/backend/internal/exchange"
+)
+
+func TestBuildImportItems(t *testing.T) {
+	balances := []exchange.Balance{
+		{Asset: "ETH", Free: 2},
+		{Asset: "SHIBAINU", Free: 1000},
+		{Asset: "LDBTC", Free: 0.1},
+		{Asset: "BTC", Free: 0.5},
+	}
+	ranks := map[string]int{"BTC": 1, "ETH": 2}
+
+	items := buildImportItems(balances, ranks)
+	assert.Equal(t, []ExchangeImportItem{
+		{Asset: "LDBTC", Symbol: "BTC"},
+		{Asset: "BTC", Symbol: "BTC", Status: ImportStatusDuplicate},
+		{Asset: "ETH", Symbol: "ETH"},
+		{Asset: "SHIBAINU", Status: ImportStatusUnsupported},
+	}, items)
+}
+
+func TestAssetCandidates(t *testing.T) {
+	assert.Equal(t, []string{"BTC"}, assetCandidates(" btc "))
+	assert.Equal(t, []string{"LDETH", "ETH"}, assetCandidates("LDETH"))
+	assert.Equal(t, []string{"LDO"}, assetCandidates("LDO"))
+}
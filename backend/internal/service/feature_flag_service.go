@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Redis cache keys for feature flags
+const (
+	featureFlagsCacheKey       = "feature_flags:all"
+	featureFlagOverridesKeyFmt = "feature_flags:overrides:%d"
+	featureFlagsCacheTTL       = time.Minute
+)
+
+// FeatureFlagService evaluates feature flags per user. Flags and overrides
+// live in Postgres and are cached in Redis for a minute
+type FeatureFlagService struct {
+	pool   *pgxpool.Pool
+	redis  *redis.Client
+	logger *slog.Logger
+}
+
+// NewFeatureFlagService creates a new FeatureFlagService
+func NewFeatureFlagService(pool *pgxpool.Pool, redisClient *redis.Client, logger *slog.Logger) *FeatureFlagService {
+	return &FeatureFlagService{
+		pool:   pool,
+		redis:  redisClient,
+		logger: logger,
+	}
+}
+
+// FeatureFlag represents a feature flag
+type FeatureFlag struct {
+	Key               string    `json:"key"`
+	Description       string    `json:"description"`
+	Enabled           bool      `json:"enabled"`
+	RolloutPercentage int       `json:"rollout_percentage"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// FeatureFlagOverride forces a flag on or off for one user
+type FeatureFlagOverride struct {
+	FlagKey string
+	UserID  int64
+	Enabled bool
+}
+
+// RolloutBucket maps a user to a stable bucket in [0, 100) for the given
+// key, so the same user stays in or out of a rollout as the percentage grows
+func RolloutBucket(key string, userID int64) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", key, userID)
+	return int(h.Sum32() % 100)
+}
+
+// IsEnabledFor applies the flag's kill switch and rollout to a user
+func (f *FeatureFlag) IsEnabledFor(userID int64) bool {
+	return f.Enabled && RolloutBucket(f.Key, userID) < f.RolloutPercentage
+}
+
+// EvaluateForUser returns every flag's state for a user
+func (s *FeatureFlagService) EvaluateForUser(ctx context.Context, userID int64) (map[string]bool, error) {
+	flags, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.getUserOverrides(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(flags))
+	for i := range flags {
+		if enabled, ok := overrides[flags[i].Key]; ok {
+			result[flags[i].Key] = enabled
+			continue
+		}
+		result[flags[i].Key] = flags[i].IsEnabledFor(userID)
+	}
+
+	return result, nil
+}
+
+// IsEnabled reports whether a single flag is on for a user. Unknown flags are off
+func (s *FeatureFlagService) IsEnabled(ctx context.Context, key string, userID int64) (bool, error) {
+	flags, err := s.EvaluateForUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return flags[key], nil
+}
+
+// GetAll returns all flags, served from cache when possible
+func (s *FeatureFlagService) GetAll(ctx context.Context) ([]FeatureFlag, error) {
+	if data, err := s.redis.Get(ctx, featureFlagsCacheKey).Bytes(); err == nil {
+		var flags []FeatureFlag
+		if err := json.Unmarshal(data, &flags); err == nil {
+			return flags, nil
+		}
+	} else if err != redis.Nil {
+		s.logger.Warn("feature flag cache read failed", slog.String("error", err.Error()))
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT key, description, enabled, rollout_percentage, updated_at
+		FROM feature_flags
+		ORDER BY key
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	flags := []FeatureFlag{}
+	for rows.Next() {
+		var f FeatureFlag
+		if err := rows.Scan(&f.Key, &f.Description, &f.Enabled, &f.RolloutPercentage, &f.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		flags = append(flags, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.setCache(ctx, featureFlagsCacheKey, flags)
+	return flags, nil
+}
+
+// getUserOverrides returns flag key -> forced state for a user
+func (s *FeatureFlagService) getUserOverrides(ctx context.Context, userID int64) (map[string]bool, error) {
+	key := fmt.Sprintf(featureFlagOverridesKeyFmt, userID)
+	if data, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+		var overrides map[string]bool
+		if err := json.Unmarshal(data, &overrides); err == nil {
+			return overrides, nil
+		}
+	} else if err != redis.Nil {
+		s.logger.Warn("feature flag cache read failed", slog.String("error", err.Error()))
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT flag_key, enabled FROM feature_flag_overrides WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	overrides := map[string]bool{}
+	for rows.Next() {
+		var flagKey string
+		var enabled bool
+		if err := rows.Scan(&flagKey, &enabled); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		overrides[flagKey] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.setCache(ctx, key, overrides)
+	return overrides, nil
+}
+
+// Upsert creates or updates a flag
+func (s *FeatureFlagService) Upsert(ctx context.Context, flag FeatureFlag) (*FeatureFlag, error) {
+	flag.Key = strings.ToLower(strings.TrimSpace(flag.Key))
+
+	var f FeatureFlag
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO feature_flags (key, description, enabled, rollout_percentage)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			description = EXCLUDED.description,
+			enabled = EXCLUDED.enabled,
+			rollout_percentage = EXCLUDED.rollout_percentage
+		RETURNING key, description, enabled, rollout_percentage, updated_at
+	`, flag.Key, flag.Description, flag.Enabled, flag.RolloutPercentage).Scan(
+		&f.Key, &f.Description, &f.Enabled, &f.RolloutPercentage, &f.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.invalidate(ctx, featureFlagsCacheKey)
+	s.logger.Info("feature flag updated",
+		slog.String("key", f.Key),
+		slog.Bool("enabled", f.Enabled),
+		slog.Int("rollout_percentage", f.RolloutPercentage),
+	)
+
+	return &f, nil
+}
+
+// Delete removes a flag and its overrides
+func (s *FeatureFlagService) Delete(ctx context.Context, key string) error {
+	key = strings.ToLower(key)
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	// The users whose cached overrides name the flag
+	rows, err := tx.Query(ctx, `
+		DELETE FROM feature_flag_overrides WHERE flag_key = $1 RETURNING user_id
+	`, key)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return errors.Wrap(err, errors.ErrDatabase)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return errors.ErrNotFound.WithMessage("Feature flag not found")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// A flag created again under the key must not pick up the old overrides
+	s.invalidate(ctx, featureFlagsCacheKey)
+	for _, userID := range userIDs {
+		s.invalidate(ctx, fmt.Sprintf(featureFlagOverridesKeyFmt, userID))
+	}
+	return nil
+}
+
+// SetOverride forces a flag on or off for one user
+func (s *FeatureFlagService) SetOverride(ctx context.Context, key string, userID int64, enabled bool) error {
+	key = strings.ToLower(key)
+
+	var exists bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM feature_flags WHERE key = $1)`, key).Scan(&exists); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if !exists {
+		return errors.ErrNotFound.WithMessage("Feature flag not found")
+	}
+
+	var userExists bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&userExists); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if !userExists {
+		return errors.ErrUserNotFound
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO feature_flag_overrides (flag_key, user_id, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (flag_key, user_id) DO UPDATE SET enabled = EXCLUDED.enabled
+	`, key, userID, enabled)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.invalidate(ctx, fmt.Sprintf(featureFlagOverridesKeyFmt, userID))
+	return nil
+}
+
+// DeleteOverride removes a user's override so the rollout applies again
+func (s *FeatureFlagService) DeleteOverride(ctx context.Context, key string, userID int64) error {
+	result, err := s.pool.Exec(ctx, `
+		DELETE FROM feature_flag_overrides WHERE flag_key = $1 AND user_id = $2
+	`, strings.ToLower(key), userID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return errors.ErrNotFound.WithMessage("Override not found")
+	}
+
+	s.invalidate(ctx, fmt.Sprintf(featureFlagOverridesKeyFmt, userID))
+	return nil
+}
+
+// GetOverrides returns all overrides of a flag
+func (s *FeatureFlagService) GetOverrides(ctx context.Context, key string) ([]FeatureFlagOverride, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT flag_key, user_id, enabled
+		FROM feature_flag_overrides
+		WHERE flag_key = $1
+		ORDER BY user_id
+	`, strings.ToLower(key))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	overrides := []FeatureFlagOverride{}
+	for rows.Next() {
+		var o FeatureFlagOverride
+		if err := rows.Scan(&o.FlagKey, &o.UserID, &o.Enabled); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
+
+// setCache stores a value in Redis; failures only cost a database round trip
+func (s *FeatureFlagService) setCache(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, key, data, featureFlagsCacheTTL).Err(); err != nil {
+		s.logger.Warn("feature flag cache write failed", slog.String("error", err.Error()))
+	}
+}
+
+// invalidate drops a cached entry after a change
+func (s *FeatureFlagService) invalidate(ctx context.Context, key string) {
+	if err := s.redis.Del(ctx, key).Err(); err != nil {
+		s.logger.Warn("feature flag cache invalidation failed",
+			slog.String("key", key),
+			slog.String("error", err.Error()),
+		)
+	}
+}
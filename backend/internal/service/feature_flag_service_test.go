@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolloutBucket_StableAndSpread(t *testing.T) {
+	assert.Equal(t, RolloutBucket("new_alerts", 42), RolloutBucket("new_alerts", 42))
+
+	enabled := 0
+	for userID := int64(1); userID <= 10000; userID++ {
+		bucket := RolloutBucket("new_alerts", userID)
+		assert.True(t, bucket >= 0 && bucket < 100)
+		if bucket < 25 {
+			enabled++
+		}
+	}
+	// Roughly a quarter of users fall into a 25% rollout
+	assert.InDelta(t, 2500, enabled, 300)
+}
+
+func TestFeatureFlag_IsEnabledFor(t *testing.T) {
+	flag := FeatureFlag{Key: "new_alerts", Enabled: true, RolloutPercentage: 100}
+	assert.True(t, flag.IsEnabledFor(7))
+
+	flag.RolloutPercentage = 0
+	assert.False(t, flag.IsEnabledFor(7))
+
+	// Users in a rollout stay in as it grows
+	flag.RolloutPercentage = 30
+	var inRollout []int64
+	for userID := int64(1); userID <= 200; userID++ {
+		if flag.IsEnabledFor(userID) {
+			inRollout = append(inRollout, userID)
+		}
+	}
+	flag.RolloutPercentage = 60
+	for _, userID := range inRollout {
+		assert.True(t, flag.IsEnabledFor(userID))
+	}
+
+	// Kill switch wins over the rollout
+	flag.Enabled = false
+	flag.RolloutPercentage = 100
+	assert.False(t, flag.IsEnabledFor(7))
+}
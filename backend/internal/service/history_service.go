@@ -5,6 +5,7 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
 )
 
 // HistoryService handles alert history-related business logic
@@ -33,17 +34,19 @@ type AlertHistory struct {
 	ConditionValue     float64
 	ConditionTimeframe *string
 	TriggeredPrice     float64
+	QuoteAsset         *string // quote of the prices, nil for USD
 	TriggeredAt        string
 	NotificationSent   bool
 	NotificationError  *string
 }
 
-// GetByUserID retrieves alert history for a user
-func (s *HistoryService) GetByUserID(ctx context.Context, userID int64, limit, offset int) ([]AlertHistory, int64, error) {
+// GetByUserID retrieves a page of a user's alert history within the plan's
+// retention, newest first
+func (s *HistoryService) GetByUserID(ctx context.Context, userID int64, page pagination.Page) (*pagination.Result[AlertHistory], error) {
 	// Get user to check retention
 	user, err := s.userService.GetWithLimits(ctx, userID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	// Get total count
@@ -54,7 +57,7 @@ func (s *HistoryService) GetByUserID(ctx context.Context, userID int64, limit, o
 		  AND triggered_at > NOW() - INTERVAL '1 day' * $2
 	`, userID, user.HistoryRetentionDays).Scan(&total)
 	if err != nil {
-		return nil, 0, errors.Wrap(err, errors.ErrDatabase)
+		return nil, errors.Wrap(err, errors.ErrDatabase)
 	}
 
 	// Get history items
@@ -62,20 +65,21 @@ func (s *HistoryService) GetByUserID(ctx context.Context, userID int64, limit, o
 		SELECT
 			h.id, h.user_id, h.alert_id, h.coin_id,
 			h.alert_type, h.condition_operator, h.condition_value, h.condition_timeframe,
-			h.triggered_price, h.triggered_at,
+			h.triggered_price, h.quote_asset, ` + textTime("h.triggered_at") + `,
 			h.notification_sent, h.notification_error,
 			c.id, c.symbol, c.name, c.binance_symbol
 		FROM alert_history h
 		JOIN coins c ON c.id = h.coin_id
 		WHERE h.user_id = $1
 		  AND h.triggered_at > NOW() - INTERVAL '1 day' * $2
-		ORDER BY h.triggered_at DESC
-		LIMIT $3 OFFSET $4
+		  AND ($3::text IS NULL OR (h.triggered_at, h.id) < ($3::timestamptz, $4::bigint))
+		ORDER BY h.triggered_at DESC, h.id DESC
+		LIMIT $5
 	`
 
-	rows, err := s.pool.Query(ctx, query, userID, user.HistoryRetentionDays, limit, offset)
+	rows, err := s.pool.Query(ctx, query, userID, user.HistoryRetentionDays, page.AfterKey(), page.AfterID(), page.FetchLimit())
 	if err != nil {
-		return nil, 0, errors.Wrap(err, errors.ErrDatabase)
+		return nil, errors.Wrap(err, errors.ErrDatabase)
 	}
 	defer rows.Close()
 
@@ -85,21 +89,19 @@ func (s *HistoryService) GetByUserID(ctx context.Context, userID int64, limit, o
 		err := rows.Scan(
 			&h.ID, &h.UserID, &h.AlertID, &h.CoinID,
 			&h.AlertType, &h.ConditionOperator, &h.ConditionValue, &h.ConditionTimeframe,
-			&h.TriggeredPrice, &h.TriggeredAt,
+			&h.TriggeredPrice, &h.QuoteAsset, &h.TriggeredAt,
 			&h.NotificationSent, &h.NotificationError,
 			&h.Coin.ID, &h.Coin.Symbol, &h.Coin.Name, &h.Coin.BinanceSymbol,
 		)
 		if err != nil {
-			return nil, 0, errors.Wrap(err, errors.ErrDatabase)
+			return nil, errors.Wrap(err, errors.ErrDatabase)
 		}
 		history = append(history, h)
 	}
 
-	if history == nil {
-		history = []AlertHistory{}
-	}
-
-	return history, total, nil
+	return pagination.NewResult(history, total, page, func(h AlertHistory) pagination.Cursor {
+		return pagination.Cursor{Key: h.TriggeredAt, ID: h.ID}
+	}), nil
 }
 
 // DeleteAllByUser deletes all history for a user
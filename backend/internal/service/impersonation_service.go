@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// DefaultImpersonationTTL is how long an impersonation token lasts
+	// unless asked otherwise
+	DefaultImpersonationTTL = 15 * time.Minute
+
+	// MaxImpersonationTTL caps the lifetime of impersonation tokens
+	MaxImpersonationTTL = time.Hour
+)
+
+// ImpersonationService lets admins act as a user through the regular API
+// to reproduce support reports. Each impersonation is recorded with who
+// started it and why, and so is every request made with its token
+type ImpersonationService struct {
+	pool        *pgxpool.Pool
+	authService *AuthService
+	logger      *slog.Logger
+}
+
+// NewImpersonationService creates a new ImpersonationService
+func NewImpersonationService(pool *pgxpool.Pool, authService *AuthService, logger *slog.Logger) *ImpersonationService {
+	return &ImpersonationService{
+		pool:        pool,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Impersonation is an audited admin session acting as a user
+type Impersonation struct {
+	ID        int64
+	UserID    *int64 // nil once the user is deleted
+	Operator  string
+	Reason    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	Requests  int64
+}
+
+// Active reports whether the impersonation's token is still accepted
+func (i *Impersonation) Active(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// Start records an impersonation of userID by operator and issues its
+// token, valid for ttl capped at MaxImpersonationTTL
+func (s *ImpersonationService) Start(ctx context.Context, userID int64, operator, reason string, ttl time.Duration) (*Impersonation, string, error) {
+	if ttl <= 0 {
+		ttl = DefaultImpersonationTTL
+	}
+	ttl = min(ttl, MaxImpersonationTTL)
+
+	var telegramID int64
+	err := s.pool.QueryRow(ctx, `SELECT telegram_id FROM users WHERE id = $1`, userID).Scan(&telegramID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, "", errors.ErrUserNotFound
+		}
+		return nil, "", errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	imp := Impersonation{UserID: &userID, Operator: operator, Reason: reason}
+	err = s.pool.QueryRow(ctx, `
+		INSERT INTO impersonations (user_id, operator, reason, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+		RETURNING id, created_at, expires_at
+	`, userID, operator, reason, ttl.Seconds()).Scan(&imp.ID, &imp.CreatedAt, &imp.ExpiresAt)
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	token, err := s.authService.GenerateImpersonationToken(userID, telegramID, imp.ID, time.Until(imp.ExpiresAt))
+	if err != nil {
+		return nil, "", errors.ErrInternal.WithCause(err)
+	}
+
+	s.logger.Warn("impersonation started",
+		slog.Int64("impersonation_id", imp.ID),
+		slog.Int64("user_id", userID),
+		slog.String("operator", operator),
+		slog.String("reason", reason),
+		slog.Time("expires_at", imp.ExpiresAt),
+	)
+
+	return &imp, token, nil
+}
+
+// Authorize validates an impersonation token and returns the
+// impersonation and Telegram ID it acts for. Tokens of revoked
+// impersonations are rejected
+func (s *ImpersonationService) Authorize(ctx context.Context, token string) (impersonationID, telegramID int64, err error) {
+	claims, err := s.authService.ValidateImpersonationToken(token)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var revoked bool
+	err = s.pool.QueryRow(ctx, `
+		SELECT revoked_at IS NOT NULL FROM impersonations WHERE id = $1
+	`, claims.ImpersonationID).Scan(&revoked)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, 0, errors.ErrInvalidToken
+		}
+		return 0, 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if revoked {
+		return 0, 0, errors.ErrInvalidToken.WithMessage("impersonation revoked")
+	}
+
+	return claims.ImpersonationID, claims.TelegramID, nil
+}
+
+// RecordRequest adds a request made with an impersonation token to its
+// audit trail
+func (s *ImpersonationService) RecordRequest(ctx context.Context, impersonationID int64, method, path string, status int) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO impersonation_requests (impersonation_id, method, path, status)
+		VALUES ($1, $2, $3, $4)
+	`, impersonationID, method, path, status)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+// Revoke stops an impersonation's token from being accepted
+func (s *ImpersonationService) Revoke(ctx context.Context, id int64) error {
+	result, err := s.pool.Exec(ctx, `
+		UPDATE impersonations SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return errors.ErrNotFound.WithMessage("impersonation not found or already revoked")
+	}
+
+	s.logger.Warn("impersonation revoked", slog.Int64("impersonation_id", id))
+	return nil
+}
+
+// List returns the latest impersonations, of userID only unless it is 0
+func (s *ImpersonationService) List(ctx context.Context, userID int64, limit int) ([]Impersonation, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT i.id, i.user_id, i.operator, i.reason, i.created_at, i.expires_at, i.revoked_at,
+		       (SELECT COUNT(*) FROM impersonation_requests r WHERE r.impersonation_id = i.id)
+		FROM impersonations i
+		WHERE $1 = 0 OR i.user_id = $1
+		ORDER BY i.created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	impersonations := []Impersonation{}
+	for rows.Next() {
+		var imp Impersonation
+		err := rows.Scan(&imp.ID, &imp.UserID, &imp.Operator, &imp.Reason,
+			&imp.CreatedAt, &imp.ExpiresAt, &imp.RevokedAt, &imp.Requests)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		impersonations = append(impersonations, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return impersonations, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+)
+
+// ImportStatusInvalid marks an import file row that failed validation
+const ImportStatusInvalid = "invalid"
+
+// ImportService adds coins and alerts in bulk from an uploaded file
+type ImportService struct {
+	watchlistService *WatchlistService
+	alertService     *AlertService
+	logger           *slog.Logger
+}
+
+// NewImportService creates a new ImportService
+func NewImportService(watchlistService *WatchlistService, alertService *AlertService, logger *slog.Logger) *ImportService {
+	return &ImportService{
+		watchlistService: watchlistService,
+		alertService:     alertService,
+		logger:           logger,
+	}
+}
+
+// ImportRow is one row of an import file: a coin, plus an alert on it when
+// Alert is set. Rows that failed validation carry the reason in Invalid and
+// are only reported
+type ImportRow struct {
+	Line       int
+	CoinSymbol string
+	Alert      *CreateAlertParams
+	Invalid    string
+}
+
+// ImportRowResult is the outcome of one row
+type ImportRowResult struct {
+	Line       int
+	CoinSymbol string
+	AlertType  string
+	Status     string // one of the ImportStatus constants
+	Error      string
+	AlertID    *int64
+}
+
+// ImportReport is the per-row report of an import
+type ImportReport struct {
+	Rows          []ImportRowResult
+	CoinsAdded    int
+	AlertsCreated int
+	Failed        int
+}
+
+// Import processes rows in file order. Each coin is added to the watchlist
+// if missing, then its alert is created; rows beyond the plan's watchlist
+// or alert limit are reported as limit_reached
+func (s *ImportService) Import(ctx context.Context, userID int64, rows []ImportRow) (*ImportReport, error) {
+	watchlist, err := s.watchlistService.GetByUserID(ctx, userID, "", pagination.Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[string]bool, len(watchlist.Items))
+	for _, item := range watchlist.Items {
+		watched[item.Coin.Symbol] = true
+	}
+
+	report := &ImportReport{Rows: make([]ImportRowResult, 0, len(rows))}
+	coinLimit, alertLimit := false, false
+
+	for _, row := range rows {
+		// Rows already imported stay; a retry reports them as existing
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		symbol := strings.ToUpper(strings.TrimSpace(row.CoinSymbol))
+		result := ImportRowResult{Line: row.Line, CoinSymbol: symbol, Status: ImportStatusExisting}
+		if row.Alert != nil {
+			result.AlertType = row.Alert.AlertType
+		}
+
+		if row.Invalid != "" {
+			result.Status = ImportStatusInvalid
+			result.Error = row.Invalid
+			report.add(result)
+			continue
+		}
+
+		if !watched[symbol] {
+			if coinLimit {
+				report.add(result.fail(ImportStatusLimit, errors.ErrWatchlistLimitExceeded))
+				continue
+			}
+
+			if _, err := s.watchlistService.AddCoin(ctx, userID, symbol); err != nil {
+				if errors.Is(err, errors.ErrWatchlistLimitExceeded) {
+					coinLimit = true
+					report.add(result.fail(ImportStatusLimit, err))
+					continue
+				}
+				if !errors.Is(err, errors.ErrCoinInWatchlist) {
+					report.add(s.failRow(userID, result, err))
+					continue
+				}
+			} else {
+				report.CoinsAdded++
+				result.Status = ImportStatusAdded
+			}
+			watched[symbol] = true
+		}
+
+		if row.Alert != nil {
+			if alertLimit {
+				report.add(result.fail(ImportStatusLimit, errors.ErrAlertLimitExceeded))
+				continue
+			}
+
+			params := *row.Alert
+			params.CoinSymbol = symbol
+			alert, err := s.alertService.Create(ctx, userID, params)
+			if err != nil {
+				if errors.Is(err, errors.ErrAlertLimitExceeded) {
+					alertLimit = true
+					report.add(result.fail(ImportStatusLimit, err))
+					continue
+				}
+				report.add(s.failRow(userID, result, err))
+				continue
+			}
+
+			report.AlertsCreated++
+			result.Status = ImportStatusAdded
+			result.AlertID = &alert.ID
+		}
+
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+// add appends a row result, counting failures
+func (r *ImportReport) add(result ImportRowResult) {
+	switch result.Status {
+	case ImportStatusAdded, ImportStatusExisting:
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// fail sets the status and the user-facing message of err
+func (r ImportRowResult) fail(status string, err error) ImportRowResult {
+	r.Status = status
+	r.Error = err.Error()
+	var appErr *errors.AppError
+	if errors.As(err, &appErr) {
+		r.Error = appErr.Message
+	}
+	return r
+}
+
+// failRow reports a row that failed; internal errors are logged rather
+// than shown to the user
+func (s *ImportService) failRow(userID int64, result ImportRowResult, err error) ImportRowResult {
+	if errors.GetStatusCode(err) >= 500 {
+		s.logger.Warn("failed to import row",
+			slog.Int64("user_id", userID),
+			slog.Int("line", result.Line),
+			slog.String("error", err.Error()),
+		)
+		result.Status = ImportStatusFailed
+		result.Error = "Internal error"
+		return result
+	}
+	return result.fail(ImportStatusFailed, err)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Onboarding steps, in the order the Mini App presents them
+const (
+	OnboardingStepAddedFirstCoin       = "added_first_coin"
+	OnboardingStepCreatedFirstAlert    = "created_first_alert"
+	OnboardingStepEnabledNotifications = "enabled_notifications"
+)
+
+// onboardingSteps maps each step to its user_onboarding column
+var onboardingSteps = []struct {
+	key    string
+	column string
+}{
+	{OnboardingStepAddedFirstCoin, "added_first_coin_at"},
+	{OnboardingStepCreatedFirstAlert, "created_first_alert_at"},
+	{OnboardingStepEnabledNotifications, "enabled_notifications_at"},
+}
+
+// Bounds sending the congratulation, which runs after the request is done
+const onboardingMessageTimeout = 10 * time.Second
+
+// OnboardingService tracks the onboarding steps of new users and
+// congratulates them on Telegram once all steps are done
+type OnboardingService struct {
+	pool       *pgxpool.Pool
+	telegram   *telegram.Client
+	miniAppURL string
+	logger     *slog.Logger
+}
+
+// NewOnboardingService creates a new OnboardingService
+func NewOnboardingService(pool *pgxpool.Pool, telegramClient *telegram.Client, miniAppURL string, logger *slog.Logger) *OnboardingService {
+	return &OnboardingService{
+		pool:       pool,
+		telegram:   telegramClient,
+		miniAppURL: miniAppURL,
+		logger:     logger,
+	}
+}
+
+// Onboarding represents a user's onboarding progress
+type Onboarding struct {
+	Steps       []OnboardingStep
+	CompletedAt *time.Time
+}
+
+// OnboardingStep represents one onboarding step
+type OnboardingStep struct {
+	Key         string
+	CompletedAt *time.Time
+}
+
+// Completed reports whether all steps are done
+func (o *Onboarding) Completed() bool {
+	return o.CompletedAt != nil
+}
+
+// CompletedSteps returns the number of steps done
+func (o *Onboarding) CompletedSteps() int {
+	n := 0
+	for _, step := range o.Steps {
+		if step.CompletedAt != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Get returns the user's onboarding progress
+func (s *OnboardingService) Get(ctx context.Context, userID int64) (*Onboarding, error) {
+	stepTimes := make([]*time.Time, len(onboardingSteps))
+	var completedAt *time.Time
+
+	err := s.pool.QueryRow(ctx, `
+		SELECT added_first_coin_at, created_first_alert_at, enabled_notifications_at, completed_at
+		FROM user_onboarding
+		WHERE user_id = $1
+	`, userID).Scan(&stepTimes[0], &stepTimes[1], &stepTimes[2], &completedAt)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	onboarding := &Onboarding{
+		Steps:       make([]OnboardingStep, len(onboardingSteps)),
+		CompletedAt: completedAt,
+	}
+	for i, step := range onboardingSteps {
+		onboarding.Steps[i] = OnboardingStep{
+			Key:         step.key,
+			CompletedAt: stepTimes[i],
+		}
+	}
+
+	return onboarding, nil
+}
+
+// CompleteStep marks a step as done; completing a step again is a no-op.
+// When it was the last open step the user gets a congratulation message.
+// Onboarding is best effort, so failures are logged and never returned to
+// the action that completed the step. Safe to call on a nil service
+func (s *OnboardingService) CompleteStep(ctx context.Context, userID int64, step string) {
+	if s == nil {
+		return
+	}
+
+	column := ""
+	for _, st := range onboardingSteps {
+		if st.key == step {
+			column = st.column
+		}
+	}
+	if column == "" {
+		s.logger.Error("unknown onboarding step", slog.String("step", step))
+		return
+	}
+
+	// The column comes from onboardingSteps, never from input
+	_, err := s.pool.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO user_onboarding (user_id, %[1]s)
+		VALUES ($1, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET %[1]s = COALESCE(user_onboarding.%[1]s, EXCLUDED.%[1]s)
+	`, column), userID)
+	if err != nil {
+		s.logger.Error("failed to complete onboarding step",
+			slog.Int64("user_id", userID),
+			slog.String("step", step),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	// Only the call that sets completed_at sends the congratulation
+	var telegramID int64
+	var firstName string
+	err = s.pool.QueryRow(ctx, `
+		UPDATE user_onboarding o
+		SET completed_at = NOW()
+		FROM users u
+		WHERE o.user_id = $1
+		  AND u.id = o.user_id
+		  AND o.completed_at IS NULL
+		  AND o.added_first_coin_at IS NOT NULL
+		  AND o.created_first_alert_at IS NOT NULL
+		  AND o.enabled_notifications_at IS NOT NULL
+		RETURNING u.telegram_id, u.first_name
+	`, userID).Scan(&telegramID, &firstName)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			s.logger.Error("failed to complete onboarding",
+				slog.Int64("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	s.logger.Info("onboarding completed", slog.Int64("user_id", userID))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), onboardingMessageTimeout)
+		defer cancel()
+
+		if err := s.congratulate(ctx, telegramID, firstName); err != nil {
+			s.logger.Error("failed to send onboarding message",
+				slog.Int64("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+}
+
+// congratulate tells a user that onboarding is complete
+func (s *OnboardingService) congratulate(ctx context.Context, telegramID int64, firstName string) error {
+	if s.telegram == nil {
+		return nil
+	}
+
+	text := fmt.Sprintf(
+		"🎉 <b>You're all set, %s!</b>\n\nYour watchlist is ready, your first alert is active and notifications are on. We'll message you here as soon as an alert triggers.",
+		html.EscapeString(firstName),
+	)
+
+	var replyMarkup *telegram.InlineKeyboardMarkup
+	if s.miniAppURL != "" {
+		replyMarkup = &telegram.InlineKeyboardMarkup{
+			InlineKeyboard: [][]telegram.InlineKeyboardButton{
+				{
+					{
+						Text:   "📱 Open Weqory",
+						WebApp: &telegram.WebAppInfo{URL: s.miniAppURL},
+					},
+				},
+			},
+		}
+	}
+
+	_, err := s.telegram.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:                telegramID,
+		Text:                  text,
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+		ReplyMarkup:           replyMarkup,
+	})
+	return err
+}
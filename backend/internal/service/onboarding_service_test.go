@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnboarding_Progress(t *testing.T) {
+	now := time.Now()
+
+	o := &Onboarding{
+		Steps: []OnboardingStep{
+			{Key: OnboardingStepAddedFirstCoin, CompletedAt: &now},
+			{Key: OnboardingStepCreatedFirstAlert},
+			{Key: OnboardingStepEnabledNotifications, CompletedAt: &now},
+		},
+	}
+	assert.Equal(t, 2, o.CompletedSteps())
+	assert.False(t, o.Completed())
+
+	o.Steps[1].CompletedAt = &now
+	o.CompletedAt = &now
+	assert.Equal(t, 3, o.CompletedSteps())
+	assert.True(t, o.Completed())
+}
+
+func TestOnboardingService_NilIsNoop(t *testing.T) {
+	var s *OnboardingService
+	assert.NotPanics(t, func() {
+		s.CompleteStep(context.Background(), 1, OnboardingStepAddedFirstCoin)
+	})
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// Delay before the first activation retry, doubled on every attempt
+	activationRetryBase = 30 * time.Second
+
+	// Longest delay between activation retries; a paid plan is retried
+	// until it is active
+	activationRetryMax = time.Hour
+
+	// Max payments activated per retry run
+	activationBatchSize = 50
+)
+
+// activationRetryDelay returns how long to wait before retrying an
+// activation that failed attempts times before
+func activationRetryDelay(attempts int) time.Duration {
+	delay := activationRetryBase
+	for i := 0; i < attempts && delay < activationRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, activationRetryMax)
+}
+
+// planExpiry returns when a plan paid for at paidAt runs out
+func planExpiry(period string, paidAt time.Time) time.Time {
+	if period == "yearly" {
+		return paidAt.AddDate(1, 0, 0)
+	}
+	return paidAt.AddDate(0, 1, 0)
+}
+
+// activateOrQueue activates the plan of a payment just completed. A
+// failure is queued for RetryActivations instead of returned, as the
+// payment itself is already recorded
+func (s *PaymentService) activateOrQueue(ctx context.Context, paymentID int64) {
+	err := s.activatePayment(ctx, paymentID)
+	if err == nil {
+		return
+	}
+
+	s.logger.Warn("failed to activate paid plan, queued for retry",
+		slog.Int64("payment_id", paymentID),
+		slog.String("error", err.Error()),
+	)
+	s.queueActivation(ctx, paymentID, 0, err)
+}
+
+// activatePayment puts the user of a completed payment on its plan, from
+// the time they paid. A payment superseded by a later activated one only
+// counts as activated, so a retry cannot undo a newer purchase. Payments
+// already activated or refunded are left alone
+func (s *PaymentService) activatePayment(ctx context.Context, paymentID int64) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the payment keeps the webhook and the retry job from
+	// activating it twice
+	var userID int64
+	var plan, period string
+	var completedAt time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT user_id, plan, period, completed_at
+		FROM payments
+		WHERE id = $1 AND status = 'completed' AND activated_at IS NULL
+		FOR UPDATE
+	`, paymentID).Scan(&userID, &plan, &period, &completedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	expiresAt := planExpiry(period, completedAt)
+	result, err := tx.Exec(ctx, `
+		UPDATE users SET
+			plan = $2,
+			plan_expires_at = $3,
+			plan_period = $4,
+			scheduled_plan = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM payments
+			WHERE user_id = $1 AND id <> $5
+			  AND activated_at IS NOT NULL AND completed_at > $6
+		  )
+	`, userID, plan, expiresAt, period, paymentID, completedAt)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payments SET
+			activated_at = NOW(),
+			activation_retry_at = NULL,
+			activation_error = NULL
+		WHERE id = $1
+	`, paymentID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+
+	if result.RowsAffected() == 0 {
+		s.logger.Info("skipped plan of superseded payment",
+			slog.Int64("payment_id", paymentID),
+			slog.Int64("user_id", userID),
+		)
+		return nil
+	}
+
+	s.logger.Info("activated subscription",
+		slog.Int64("payment_id", paymentID),
+		slog.Int64("user_id", userID),
+		slog.String("plan", plan),
+		slog.String("period", period),
+		slog.Time("expires_at", expiresAt),
+	)
+
+	return nil
+}
+
+// queueActivation records a failed activation and when to retry it. If
+// even that fails, the payment is still picked up by the next retry run
+func (s *PaymentService) queueActivation(ctx context.Context, paymentID int64, attempts int, cause error) {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE payments SET
+			activation_attempts = $2,
+			activation_retry_at = NOW() + $3 * INTERVAL '1 second',
+			activation_error = $4
+		WHERE id = $1
+	`, paymentID, attempts+1, activationRetryDelay(attempts).Seconds(), cause.Error())
+	if err != nil {
+		s.logger.Error("failed to queue plan activation",
+			slog.Int64("payment_id", paymentID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// RetryActivations activates the plans of completed payments whose
+// activation failed and is due for a retry, oldest first. Returns how many
+// were activated; failures are queued again with a longer delay
+func (s *PaymentService) RetryActivations(ctx context.Context) (int, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, activation_attempts
+		FROM payments
+		WHERE status = 'completed' AND activated_at IS NULL
+		  AND (activation_retry_at IS NULL OR activation_retry_at <= NOW())
+		ORDER BY completed_at
+		LIMIT $1
+	`, activationBatchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	type queued struct {
+		paymentID int64
+		attempts  int
+	}
+	due, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (queued, error) {
+		var q queued
+		err := row.Scan(&q.paymentID, &q.attempts)
+		return q, err
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	activated := 0
+	for _, q := range due {
+		if err := ctx.Err(); err != nil {
+			return activated, err
+		}
+
+		if err := s.activatePayment(ctx, q.paymentID); err != nil {
+			s.logger.Warn("plan activation retry failed",
+				slog.Int64("payment_id", q.paymentID),
+				slog.Int("attempts", q.attempts+1),
+				slog.String("error", err.Error()),
+			)
+			s.queueActivation(ctx, q.paymentID, q.attempts, err)
+			continue
+		}
+		activated++
+	}
+
+	return activated, nil
+}
+
+// RunRetryActivations runs a scheduled activation retry and logs the outcome
+func (s *PaymentService) RunRetryActivations(ctx context.Context) error {
+	activated, err := s.RetryActivations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if activated > 0 {
+		s.logger.Info("activated queued paid plans", slog.Int("count", activated))
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivationRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, activationRetryDelay(0))
+	assert.Equal(t, time.Minute, activationRetryDelay(1))
+	assert.Equal(t, 32*time.Minute, activationRetryDelay(6))
+	assert.Equal(t, time.Hour, activationRetryDelay(7))
+	assert.Equal(t, time.Hour, activationRetryDelay(1000))
+}
+
+func TestPlanExpiry(t *testing.T) {
+	paidAt := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2027, 1, 31, 12, 0, 0, 0, time.UTC), planExpiry("yearly", paidAt))
+	assert.Equal(t, time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC), planExpiry("monthly", paidAt))
+}
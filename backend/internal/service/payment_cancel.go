@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// ScheduledPlanChange is the plan a subscriber moves to when the current
+// period ends
+type ScheduledPlanChange struct {
+	Plan        string
+	EffectiveAt time.Time
+}
+
+// ScheduledChange returns the plan change scheduled for the end of the
+// user's period, or nil when the plan renews
+func (u *User) ScheduledChange() *ScheduledPlanChange {
+	if u.ScheduledPlan == nil || u.PlanExpiresAt == nil {
+		return nil
+	}
+	return &ScheduledPlanChange{Plan: *u.ScheduledPlan, EffectiveAt: *u.PlanExpiresAt}
+}
+
+// CancelSubscription stops the user's plan from renewing and moves them to
+// plan when the current period ends: standard to cancel, or a cheaper paid
+// plan to downgrade. The plan stays unchanged until then. Paying for a plan
+// again clears the change.
+//
+// Renewals with recurring Stars will honour a scheduled downgrade; until
+// then every expired plan falls back to standard
+func (s *PaymentService) CancelSubscription(ctx context.Context, userID int64, plan string) (*ScheduledPlanChange, error) {
+	if plan == "" {
+		plan = "standard"
+	}
+	target, err := s.GetPlanByName(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user so that a concurrent payment is not undone
+	var currentPlan string
+	var expiresAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT plan, plan_expires_at FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&currentPlan, &expiresAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if currentPlan == "standard" || expiresAt == nil || !expiresAt.After(time.Now()) {
+		return nil, errors.ErrNoSubscription
+	}
+
+	current, err := s.GetPlanByName(ctx, currentPlan)
+	if err != nil {
+		return nil, err
+	}
+	if planPrice(target) >= planPrice(current) {
+		return nil, errors.ErrBadRequest.WithMessage("can only move to a cheaper plan")
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET scheduled_plan = $2, updated_at = NOW() WHERE id = $1
+	`, userID, target.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+
+	s.logger.Info("scheduled plan change",
+		slog.Int64("user_id", userID),
+		slog.String("plan", currentPlan),
+		slog.String("scheduled_plan", target.Name),
+		slog.Time("effective_at", *expiresAt),
+	)
+
+	return &ScheduledPlanChange{Plan: target.Name, EffectiveAt: *expiresAt}, nil
+}
+
+// planPrice returns the monthly price of a plan in Stars, 0 when free
+func planPrice(plan *Plan) int {
+	if plan.PriceMonthly == nil {
+		return 0
+	}
+	return *plan.PriceMonthly
+}
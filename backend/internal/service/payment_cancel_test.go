@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_ScheduledChange(t *testing.T) {
+	expiresAt := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	standard := "standard"
+
+	user := User{Plan: "pro", PlanExpiresAt: &expiresAt}
+	assert.Nil(t, user.ScheduledChange(), "renews")
+
+	user.ScheduledPlan = &standard
+	assert.Equal(t, &ScheduledPlanChange{Plan: "standard", EffectiveAt: expiresAt}, user.ScheduledChange())
+
+	user.PlanExpiresAt = nil
+	assert.Nil(t, user.ScheduledChange(), "no period to end")
+}
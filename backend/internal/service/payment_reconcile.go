@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// Pending payments older than this are expired unless Telegram has a
+	// matching transaction
+	pendingPaymentTTL = time.Hour
+
+	// Star transactions this old are not matched any more
+	reconcileLookback = 7 * 24 * time.Hour
+
+	// Page size of getStarTransactions (Telegram's maximum)
+	starTransactionsPageSize = 100
+
+	// Bound on the transactions read per run
+	maxStarTransactionPages = 100
+)
+
+// PaymentDiscrepancy is a mismatch between Telegram and our payments that
+// reconciliation could not resolve and needs a look
+type PaymentDiscrepancy struct {
+	PaymentID int64
+	ChargeID  string
+	Reason    string
+}
+
+// PaymentReconcileResult summarises a reconciliation run
+type PaymentReconcileResult struct {
+	Transactions  int
+	Matched       int
+	Completed     int
+	Expired       int64
+	Discrepancies []PaymentDiscrepancy
+}
+
+// Reconcile matches recent Telegram Stars transactions to our payments,
+// since webhooks can be missed. Paid payments still pending are completed
+// and their plan activated; pending payments without a transaction are
+// expired after an hour. Anything else that does not add up is reported
+func (s *PaymentService) Reconcile(ctx context.Context) (*PaymentReconcileResult, error) {
+	// The lookback only moves forward, so the next run can start at the
+	// first transaction within this one's
+	cutoff := time.Now().Add(-reconcileLookback)
+	read, err := s.readStarTransactionsFrom(ctx, starCursorReconcile, cutoff, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	transactions, complete := read.Transactions, read.Complete
+
+	result := &PaymentReconcileResult{
+		Transactions:  len(transactions),
+		Discrepancies: []PaymentDiscrepancy{},
+	}
+
+	for _, tx := range transactions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var discrepancy *PaymentDiscrepancy
+		switch {
+		case tx.Source != nil && tx.Source.Type == "user":
+			discrepancy, err = s.reconcilePayment(ctx, tx, result)
+		case tx.Receiver != nil && tx.Receiver.Type == "user":
+			discrepancy, err = s.reconcileRefund(ctx, tx)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if discrepancy != nil {
+			result.Discrepancies = append(result.Discrepancies, *discrepancy)
+		}
+	}
+
+	// Every payment made in time was completed above, unless some
+	// transactions were not read
+	if complete {
+		expired, err := s.pool.Exec(ctx, `
+			UPDATE payments SET status = 'expired'
+			WHERE status = 'pending' AND created_at < $1
+		`, time.Now().Add(-pendingPaymentTTL))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		result.Expired = expired.RowsAffected()
+	} else {
+		s.logger.Warn("not expiring pending payments, star transactions were read partially")
+	}
+
+	if err := s.saveStarCursor(ctx, starCursorReconcile, read.Next); err != nil {
+		return nil, err
+	}
+
+	for _, d := range result.Discrepancies {
+		s.logger.Warn("payment discrepancy",
+			slog.Int64("payment_id", d.PaymentID),
+			slog.String("charge_id", d.ChargeID),
+			slog.String("reason", d.Reason),
+		)
+	}
+
+	return result, nil
+}
+
+// RunReconcile runs a scheduled reconciliation and logs the outcome
+func (s *PaymentService) RunReconcile(ctx context.Context) error {
+	result, err := s.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("payment reconciliation completed",
+		slog.Int("transactions", result.Transactions),
+		slog.Int("matched", result.Matched),
+		slog.Int("completed", result.Completed),
+		slog.Int64("expired", result.Expired),
+		slog.Int("discrepancies", len(result.Discrepancies)),
+	)
+
+	return nil
+}
+
+// reconcilePayment matches an incoming Stars payment to its payment and
+// completes it if the webhook was missed
+func (s *PaymentService) reconcilePayment(ctx context.Context, tx telegram.StarTransaction, result *PaymentReconcileResult) (*PaymentDiscrepancy, error) {
+	var payload InvoicePayload
+	if err := json.Unmarshal([]byte(tx.Source.InvoicePayload), &payload); err != nil || payload.PaymentID == 0 {
+		return &PaymentDiscrepancy{ChargeID: tx.ID, Reason: "transaction has no payment in its invoice payload"}, nil
+	}
+
+	// The payment row is authoritative; the payload only has to agree with it
+	var payment InvoicePayload
+	var telegramID int64
+	var status string
+	var chargeID *string
+	var stars int
+	err := s.pool.QueryRow(ctx, `
+		SELECT p.id, p.user_id, p.plan, p.period, u.telegram_id,
+		       p.status, p.telegram_payment_id, p.stars_amount
+		FROM payments p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1
+	`, payload.PaymentID).Scan(
+		&payment.PaymentID, &payment.UserID, &payment.Plan, &payment.Period, &telegramID,
+		&status, &chargeID, &stars,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return &PaymentDiscrepancy{PaymentID: payload.PaymentID, ChargeID: tx.ID, Reason: "paid payment does not exist"}, nil
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	discrepancy := &PaymentDiscrepancy{PaymentID: payment.PaymentID, ChargeID: tx.ID}
+	switch {
+	case payload.UserID != payment.UserID || payload.Plan != payment.Plan || payload.Period != payment.Period:
+		discrepancy.Reason = "invoice payload differs from the payment"
+		return discrepancy, nil
+	case tx.Source.User == nil || tx.Source.User.ID != telegramID:
+		discrepancy.Reason = "paid by another user than the payment's"
+		return discrepancy, nil
+	case tx.Amount != stars:
+		discrepancy.Reason = "paid amount differs from the invoice"
+		return discrepancy, nil
+	case chargeID != nil && *chargeID != tx.ID:
+		discrepancy.Reason = "payment was completed with another transaction"
+		return discrepancy, nil
+	case status == "completed" || status == "refunded":
+		result.Matched++
+		return nil, nil
+	case status == "failed":
+		discrepancy.Reason = "failed payment was paid"
+		return discrepancy, nil
+	}
+
+	// Pending or expired: the webhook never arrived
+	activated, err := s.completePayment(ctx, payment, tx.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !activated {
+		// Completed concurrently by the webhook
+		result.Matched++
+		return nil, nil
+	}
+
+	s.logger.Warn("completed payment missed by webhook",
+		slog.Int64("payment_id", payment.PaymentID),
+		slog.String("charge_id", tx.ID),
+	)
+	result.Completed++
+	result.Matched++
+	return nil, nil
+}
+
+// reconcileRefund checks that a payment refunded in Telegram is refunded
+// here too
+func (s *PaymentService) reconcileRefund(ctx context.Context, tx telegram.StarTransaction) (*PaymentDiscrepancy, error) {
+	var paymentID int64
+	var status string
+	err := s.pool.QueryRow(ctx, `
+		SELECT id, status FROM payments WHERE telegram_payment_id = $1
+	`, tx.ID).Scan(&paymentID, &status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return &PaymentDiscrepancy{ChargeID: tx.ID, Reason: "refunded transaction has no payment"}, nil
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if status != "refunded" {
+		return &PaymentDiscrepancy{PaymentID: paymentID, ChargeID: tx.ID, Reason: "refunded in Telegram but " + status + " here"}, nil
+	}
+	return nil, nil
+}
+
+// Cursors of the readers of the bot's Star transactions
+const (
+	starCursorReconcile = "reconcile"
+	starCursorSync      = "sync"
+)
+
+// starPageFunc fetches a page of Star transactions starting at offset
+type starPageFunc func(ctx context.Context, offset int) ([]telegram.StarTransaction, error)
+
+// starTransactionRead is the outcome of reading Star transactions from a
+// cursor
+type starTransactionRead struct {
+	Transactions []telegram.StarTransaction // made since the cutoff
+	Complete     bool                       // false if the page limit cut the read short
+	Next         int                        // offset the next read starts at
+}
+
+// readStarTransactions reads Star transactions from offset start and keeps
+// those made since cutoff. Telegram documents the order as chronological,
+// so the next read starts at the first transaction made since keep, or
+// where this read stopped, and older transactions are not read again.
+// Should the order turn out to be newest first, offsets shift as
+// transactions are added: the read restarts at the newest and stops once
+// past cutoff, and the next read starts there too
+func readStarTransactions(ctx context.Context, fetch starPageFunc, start int, cutoff, keep time.Time, logger *slog.Logger) (*starTransactionRead, error) {
+	read := &starTransactionRead{Next: -1}
+	offset := start
+	for page := 0; page < maxStarTransactionPages; page++ {
+		txs, err := fetch(ctx, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(txs) > 1 && txs[0].Date > txs[len(txs)-1].Date {
+			if start != 0 {
+				logger.Warn("star transactions are listed newest first, reading from the start")
+				return readStarTransactions(ctx, fetch, 0, cutoff, keep, logger)
+			}
+			read.Next = 0
+			for _, tx := range txs {
+				if tx.Date >= cutoff.Unix() {
+					read.Transactions = append(read.Transactions, tx)
+				}
+			}
+			if len(txs) < starTransactionsPageSize || txs[len(txs)-1].Date < cutoff.Unix() {
+				read.Complete = true
+				return read, nil
+			}
+			offset += len(txs)
+			continue
+		}
+
+		for i, tx := range txs {
+			if tx.Date >= cutoff.Unix() {
+				read.Transactions = append(read.Transactions, tx)
+			}
+			if read.Next < 0 && tx.Date >= keep.Unix() {
+				read.Next = offset + i
+			}
+		}
+		offset += len(txs)
+
+		if len(txs) < starTransactionsPageSize {
+			read.Complete = true
+			break
+		}
+	}
+
+	if !read.Complete {
+		logger.Warn("stopped reading star transactions at page limit",
+			slog.Int("pages", maxStarTransactionPages),
+			slog.Int("offset", offset),
+		)
+	}
+	if read.Next < 0 {
+		read.Next = offset
+	}
+	return read, nil
+}
+
+// readStarTransactionsFrom reads the bot's Star transactions from the
+// offset saved under cursor. The caller saves read.Next once it has
+// handled the transactions
+func (s *PaymentService) readStarTransactionsFrom(ctx context.Context, cursor string, cutoff, keep time.Time) (*starTransactionRead, error) {
+	var start int
+	err := s.pool.QueryRow(ctx, `
+		SELECT next_offset FROM star_transaction_cursors WHERE name = $1
+	`, cursor).Scan(&start)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	fetch := func(ctx context.Context, offset int) ([]telegram.StarTransaction, error) {
+		resp, err := s.telegramBot.GetStarTransactions(ctx, telegram.GetStarTransactionsRequest{
+			Offset: offset,
+			Limit:  starTransactionsPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Transactions, nil
+	}
+	read, err := readStarTransactions(ctx, fetch, start, cutoff, keep, s.logger)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrExternalService)
+	}
+	return read, nil
+}
+
+// saveStarCursor saves the offset the next read of cursor starts at
+func (s *PaymentService) saveStarCursor(ctx context.Context, cursor string, next int) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO star_transaction_cursors (name, next_offset) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET next_offset = EXCLUDED.next_offset, updated_at = NOW()
+	`, cursor, next)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
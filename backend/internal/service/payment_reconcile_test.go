@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+// starHistory returns n transactions a minute apart, oldest first, ending
+// at end
+func starHistory(n int, end time.Time) []telegram.StarTransaction {
+	txs := make([]telegram.StarTransaction, n)
+	for i := range txs {
+		txs[i] = telegram.StarTransaction{
+			ID:   strconv.Itoa(i),
+			Date: end.Add(-time.Duration(n-1-i) * time.Minute).Unix(),
+		}
+	}
+	return txs
+}
+
+// pages serves txs like getStarTransactions and records the offsets read
+func pages(txs []telegram.StarTransaction, offsets *[]int) starPageFunc {
+	return func(ctx context.Context, offset int) ([]telegram.StarTransaction, error) {
+		*offsets = append(*offsets, offset)
+		start := min(offset, len(txs))
+		return txs[start:min(start+starTransactionsPageSize, len(txs))], nil
+	}
+}
+
+func TestReadStarTransactions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	// 250 transactions, the last 30 within the lookback
+	history := starHistory(250, now)
+	cutoff := now.Add(-29 * time.Minute)
+
+	var offsets []int
+	read, err := readStarTransactions(ctx, pages(history, &offsets), 0, cutoff, cutoff, logger)
+	require.NoError(t, err)
+	assert.True(t, read.Complete)
+	assert.Len(t, read.Transactions, 30)
+	assert.Equal(t, []int{0, 100, 200}, offsets)
+	assert.Equal(t, 220, read.Next, "first transaction within the lookback")
+
+	// The next run starts there and sees the transactions added since
+	history = append(history, starHistory(120, now.Add(2*time.Hour))...)
+	offsets = nil
+	read, err = readStarTransactions(ctx, pages(history, &offsets), read.Next, cutoff, now.Add(time.Hour), logger)
+	require.NoError(t, err)
+	assert.True(t, read.Complete)
+	assert.Equal(t, []int{220, 320}, offsets)
+	assert.Len(t, read.Transactions, 150)
+	assert.Equal(t, 309, read.Next, "first transaction made since keep")
+}
+
+func TestReadStarTransactions_PageLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	limit := maxStarTransactionPages * starTransactionsPageSize
+
+	// More history than one run reads, the recent part past the limit
+	history := starHistory(limit+150, now)
+	cutoff := now.Add(-time.Hour)
+
+	var offsets []int
+	read, err := readStarTransactions(ctx, pages(history, &offsets), 0, cutoff, cutoff, logger)
+	require.NoError(t, err)
+	assert.False(t, read.Complete)
+	assert.Empty(t, read.Transactions)
+	assert.Equal(t, limit, read.Next, "resumes where the read stopped")
+
+	read, err = readStarTransactions(ctx, pages(history, &offsets), read.Next, cutoff, cutoff, logger)
+	require.NoError(t, err)
+	assert.True(t, read.Complete)
+	assert.Len(t, read.Transactions, 61)
+}
+
+func TestReadStarTransactions_NewestFirst(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	history := starHistory(250, now)
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	cutoff := now.Add(-119 * time.Minute)
+
+	var offsets []int
+	read, err := readStarTransactions(ctx, pages(history, &offsets), 220, cutoff, cutoff, logger)
+	require.NoError(t, err)
+	assert.True(t, read.Complete)
+	assert.Equal(t, []int{220, 0, 100}, offsets, "restarted at the newest")
+	assert.Len(t, read.Transactions, 120)
+	assert.Equal(t, 0, read.Next)
+}
@@ -10,12 +10,14 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/weqory/backend/internal/telegram"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
 )
 
 // PaymentService handles payment-related business logic
 type PaymentService struct {
 	pool        *pgxpool.Pool
 	telegramBot *telegram.Client
+	profiles    *UserProfileCache
 	logger      *slog.Logger
 }
 
@@ -28,6 +30,12 @@ func NewPaymentService(pool *pgxpool.Pool, telegramBot *telegram.Client, logger
 	}
 }
 
+// SetProfileCache makes plan changes invalidate the cached profile, which
+// shows the plan and its limits
+func (s *PaymentService) SetProfileCache(profiles *UserProfileCache) {
+	s.profiles = profiles
+}
+
 // Plan represents a subscription plan
 type Plan struct {
 	ID                   int    `json:"id"`
@@ -36,6 +44,8 @@ type Plan struct {
 	MaxAlerts            int    `json:"max_alerts"`
 	MaxNotifications     *int   `json:"max_notifications"`
 	HistoryRetentionDays int    `json:"history_retention_days"`
+	PriceHistoryDays     int    `json:"price_history_days"`
+	AlertCharts          bool   `json:"alert_charts"`
 	PriceMonthly         *int   `json:"price_monthly"`
 	PriceYearly          *int   `json:"price_yearly"`
 }
@@ -77,7 +87,7 @@ type CreateInvoiceResponse struct {
 func (s *PaymentService) GetAllPlans(ctx context.Context) ([]Plan, error) {
 	query := `
 		SELECT id, name, max_coins, max_alerts, max_notifications,
-		       history_retention_days, price_monthly, price_yearly
+		       history_retention_days, price_history_days, alert_charts, price_monthly, price_yearly
 		FROM subscription_plans
 		ORDER BY max_coins ASC
 	`
@@ -93,7 +103,7 @@ func (s *PaymentService) GetAllPlans(ctx context.Context) ([]Plan, error) {
 		var plan Plan
 		if err := rows.Scan(
 			&plan.ID, &plan.Name, &plan.MaxCoins, &plan.MaxAlerts,
-			&plan.MaxNotifications, &plan.HistoryRetentionDays,
+			&plan.MaxNotifications, &plan.HistoryRetentionDays, &plan.PriceHistoryDays, &plan.AlertCharts,
 			&plan.PriceMonthly, &plan.PriceYearly,
 		); err != nil {
 			return nil, errors.Wrap(err, errors.ErrDatabase)
@@ -108,7 +118,7 @@ func (s *PaymentService) GetAllPlans(ctx context.Context) ([]Plan, error) {
 func (s *PaymentService) GetPlanByName(ctx context.Context, name string) (*Plan, error) {
 	query := `
 		SELECT id, name, max_coins, max_alerts, max_notifications,
-		       history_retention_days, price_monthly, price_yearly
+		       history_retention_days, price_history_days, alert_charts, price_monthly, price_yearly
 		FROM subscription_plans
 		WHERE name = $1
 	`
@@ -116,7 +126,7 @@ func (s *PaymentService) GetPlanByName(ctx context.Context, name string) (*Plan,
 	var plan Plan
 	err := s.pool.QueryRow(ctx, query, name).Scan(
 		&plan.ID, &plan.Name, &plan.MaxCoins, &plan.MaxAlerts,
-		&plan.MaxNotifications, &plan.HistoryRetentionDays,
+		&plan.MaxNotifications, &plan.HistoryRetentionDays, &plan.PriceHistoryDays, &plan.AlertCharts,
 		&plan.PriceMonthly, &plan.PriceYearly,
 	)
 	if err != nil {
@@ -206,7 +216,9 @@ func (s *PaymentService) CreateInvoice(ctx context.Context, userID int64, req Cr
 	}, nil
 }
 
-// HandleSuccessfulPayment processes a successful payment from Telegram webhook
+// HandleSuccessfulPayment processes a successful payment from Telegram webhook.
+// Errors mean the payment could not be recorded and the webhook should be
+// retried; activating the plan is retried internally, see completePayment
 func (s *PaymentService) HandleSuccessfulPayment(ctx context.Context, payment *telegram.SuccessfulPayment) error {
 	// Parse payload
 	var payload InvoicePayload
@@ -237,69 +249,47 @@ func (s *PaymentService) HandleSuccessfulPayment(ctx context.Context, payment *t
 		)
 	}
 
-	// Begin transaction
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
-	}
-	defer tx.Rollback(ctx)
-
-	// Update payment status - use row locking to prevent race conditions
-	result, err := tx.Exec(ctx, `
-		UPDATE payments SET
-			status = 'completed',
-			telegram_payment_id = $2,
-			completed_at = NOW()
-		WHERE id = $1 AND status = 'pending'
-	`, payload.PaymentID, payment.TelegramPaymentChargeID)
+	activated, err := s.completePayment(ctx, payload, payment.TelegramPaymentChargeID)
 	if err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
+		return err
 	}
-
-	// Check if the update affected any rows
-	if result.RowsAffected() == 0 {
+	if !activated {
 		// Payment was already processed or doesn't exist
 		s.logger.Warn("payment not found or already processed",
 			slog.Int64("payment_id", payload.PaymentID),
 			slog.String("charge_id", payment.TelegramPaymentChargeID),
 		)
-		return nil // Return nil since this is idempotent
 	}
 
-	// Calculate expiration date
-	var expiresAt time.Time
-	if payload.Period == "yearly" {
-		expiresAt = time.Now().AddDate(1, 0, 0) // 1 year
-	} else {
-		expiresAt = time.Now().AddDate(0, 1, 0) // 1 month
-	}
+	// Return nil when nothing changed since this is idempotent
+	return nil
+}
 
-	// Activate subscription
-	_, err = tx.Exec(ctx, `
-		UPDATE users SET
-			plan = $2,
-			plan_expires_at = $3,
-			plan_period = $4,
-			updated_at = NOW()
-		WHERE id = $1
-	`, payload.UserID, payload.Plan, expiresAt, payload.Period)
+// completePayment marks a pending payment paid and then activates its
+// plan. Payments expired by reconciliation are completed too, as the user
+// was charged after all. Only recording the payment can fail; a failed
+// activation is queued for RetryActivations. Returns false if the payment
+// was not open
+func (s *PaymentService) completePayment(ctx context.Context, payload InvoicePayload, chargeID string) (bool, error) {
+	result, err := s.pool.Exec(ctx, `
+		UPDATE payments SET
+			status = 'completed',
+			telegram_payment_id = $2,
+			completed_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'expired')
+	`, payload.PaymentID, chargeID)
 	if err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
+		return false, errors.Wrap(err, errors.ErrDatabase)
 	}
 
-	// Commit transaction
-	if err := tx.Commit(ctx); err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
+	// Check if the update affected any rows
+	if result.RowsAffected() == 0 {
+		return false, nil
 	}
 
-	s.logger.Info("activated subscription",
-		slog.Int64("user_id", payload.UserID),
-		slog.String("plan", payload.Plan),
-		slog.String("period", payload.Period),
-		slog.Time("expires_at", expiresAt),
-	)
+	s.activateOrQueue(ctx, payload.PaymentID)
 
-	return nil
+	return true, nil
 }
 
 // HandlePreCheckoutQuery responds to a pre-checkout query
@@ -337,18 +327,24 @@ func (s *PaymentService) HandlePreCheckoutQuery(ctx context.Context, query *tele
 	})
 }
 
-// GetPaymentHistory retrieves payment history for a user
-func (s *PaymentService) GetPaymentHistory(ctx context.Context, userID int64, limit, offset int) ([]Payment, error) {
+// GetPaymentHistory retrieves a page of a user's payments, newest first
+func (s *PaymentService) GetPaymentHistory(ctx context.Context, userID int64, page pagination.Page) (*pagination.Result[Payment], error) {
+	var total int64
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM payments WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
 	query := `
 		SELECT id, user_id, telegram_payment_id, plan, period,
 		       stars_amount, status, created_at, completed_at
 		FROM payments
 		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		  AND ($2::text IS NULL OR (created_at, id) < ($2::timestamptz, $3::bigint))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
 	`
 
-	rows, err := s.pool.Query(ctx, query, userID, limit, offset)
+	rows, err := s.pool.Query(ctx, query, userID, page.AfterKey(), page.AfterID(), page.FetchLimit())
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrDatabase)
 	}
@@ -366,7 +362,9 @@ func (s *PaymentService) GetPaymentHistory(ctx context.Context, userID int64, li
 		payments = append(payments, p)
 	}
 
-	return payments, nil
+	return pagination.NewResult(payments, total, page, func(p Payment) pagination.Cursor {
+		return pagination.TimeCursor(p.CreatedAt, p.ID)
+	}), nil
 }
 
 // GetPaymentByID retrieves a payment by ID
@@ -425,6 +423,7 @@ func (s *PaymentService) RefundPayment(ctx context.Context, paymentID int64) err
 			plan = 'standard',
 			plan_expires_at = NULL,
 			plan_period = NULL,
+			scheduled_plan = NULL,
 			updated_at = NOW()
 		WHERE id = $1
 	`, payment.UserID)
@@ -435,6 +434,7 @@ func (s *PaymentService) RefundPayment(ctx context.Context, paymentID int64) err
 	if err := tx.Commit(ctx); err != nil {
 		return errors.Wrap(err, errors.ErrDatabase)
 	}
+	s.profiles.Invalidate(ctx, payment.UserID)
 
 	s.logger.Info("refunded payment",
 		slog.Int64("payment_id", paymentID),
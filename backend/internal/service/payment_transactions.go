@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/weqory/backend/internal/telegram"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Star transaction directions, from the bot's point of view
+const (
+	StarDirectionIn  = "in"
+	StarDirectionOut = "out"
+)
+
+// Transactions made this recently are read again by the next sync, in case
+// Telegram lists them late
+const starSyncOverlap = 24 * time.Hour
+
+// StarSyncResult summarises a Star transaction sync
+type StarSyncResult struct {
+	Read     int
+	Matched  int  // matched to a payment
+	Complete bool // false if the page limit cut the read short
+}
+
+// StarTransactionRecord is a stored Star transaction with the payment it
+// was matched to
+type StarTransactionRecord struct {
+	ID            string
+	Direction     string
+	Amount        int
+	Date          time.Time
+	PaymentID     *int64
+	PaymentStatus *string
+	Plan          *string
+}
+
+// Revenue is the Stars the bot took in a period, from synced transactions
+type Revenue struct {
+	From      time.Time
+	To        time.Time
+	Gross     int64 // Stars paid by users
+	Refunded  int64 // Stars refunded to users
+	Payments  int64
+	Refunds   int64
+	Unmatched int64 // payments without a payment record
+	ByPlan    []PlanRevenue
+	SyncedAt  *time.Time // date of the newest transaction synced
+}
+
+// Net returns the Stars kept after refunds
+func (r *Revenue) Net() int64 {
+	return r.Gross - r.Refunded
+}
+
+// PlanRevenue is the Stars paid for a plan; Plan is empty for payments that
+// were not matched
+type PlanRevenue struct {
+	Plan     string
+	Stars    int64
+	Payments int64
+}
+
+// SyncStarTransactions stores the bot's Star transactions from where the
+// last sync left off, or all of them on the first, and matches each to its
+// payment and user. A sync cut short by the page limit is resumed by the
+// next one, so no transaction is skipped
+func (s *PaymentService) SyncStarTransactions(ctx context.Context) (*StarSyncResult, error) {
+	read, err := s.readStarTransactionsFrom(ctx, starCursorSync, time.Time{}, time.Now().Add(-starSyncOverlap))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StarSyncResult{Read: len(read.Transactions), Complete: read.Complete}
+	for _, tx := range read.Transactions {
+		matched, err := s.storeStarTransaction(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			result.Matched++
+		}
+	}
+
+	if err := s.saveStarCursor(ctx, starCursorSync, read.Next); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RunSyncStarTransactions runs a scheduled sync and logs the outcome
+func (s *PaymentService) RunSyncStarTransactions(ctx context.Context) error {
+	result, err := s.SyncStarTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("star transactions synced",
+		slog.Int("read", result.Read),
+		slog.Int("matched", result.Matched),
+		slog.Bool("complete", result.Complete),
+	)
+
+	return nil
+}
+
+// storeStarTransaction upserts a transaction and reports whether it matches
+// a payment: by charge ID once completed, else by the payment ID of the
+// invoice payload
+func (s *PaymentService) storeStarTransaction(ctx context.Context, tx telegram.StarTransaction) (bool, error) {
+	direction, partner := StarDirectionIn, tx.Source
+	if partner == nil {
+		direction, partner = StarDirectionOut, tx.Receiver
+	}
+
+	var partnerType, payload string
+	var telegramUserID *int64
+	if partner != nil {
+		partnerType, payload = partner.Type, partner.InvoicePayload
+		if partner.User != nil {
+			telegramUserID = &partner.User.ID
+		}
+	}
+	var invoice InvoicePayload
+	_ = json.Unmarshal([]byte(payload), &invoice)
+
+	var matched bool
+	err := s.pool.QueryRow(ctx, `
+		WITH p AS (
+			SELECT id, user_id FROM payments
+			WHERE telegram_payment_id = $1 OR (id = $8 AND telegram_payment_id IS NULL)
+			ORDER BY telegram_payment_id IS NULL
+			LIMIT 1
+		)
+		INSERT INTO star_transactions (
+			id, direction, amount, partner_type, telegram_user_id, invoice_payload, transaction_date,
+			user_id, payment_id
+		)
+		VALUES (
+			$1, $2, $3, $4, $5, NULLIF($6, ''), $7,
+			COALESCE((SELECT user_id FROM p), (SELECT id FROM users WHERE telegram_id = $5)),
+			(SELECT id FROM p)
+		)
+		ON CONFLICT (id, direction) DO UPDATE SET
+			user_id = COALESCE(EXCLUDED.user_id, star_transactions.user_id),
+			payment_id = COALESCE(EXCLUDED.payment_id, star_transactions.payment_id),
+			synced_at = NOW()
+		RETURNING payment_id IS NOT NULL
+	`, tx.ID, direction, tx.Amount, partnerType, telegramUserID, payload,
+		time.Unix(tx.Date, 0).UTC(), invoice.PaymentID,
+	).Scan(&matched)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return matched, nil
+}
+
+// Revenue sums the synced Star transactions made in [from, to)
+func (s *PaymentService) Revenue(ctx context.Context, from, to time.Time) (*Revenue, error) {
+	revenue := &Revenue{From: from, To: to, ByPlan: []PlanRevenue{}}
+
+	// Outgoing transactions to users are refunds; others are withdrawals
+	err := s.pool.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE direction = 'in'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE direction = 'out' AND partner_type = 'user'), 0),
+			COUNT(*) FILTER (WHERE direction = 'in'),
+			COUNT(*) FILTER (WHERE direction = 'out' AND partner_type = 'user'),
+			COUNT(*) FILTER (WHERE direction = 'in' AND payment_id IS NULL),
+			(SELECT MAX(transaction_date) FROM star_transactions)
+		FROM star_transactions
+		WHERE transaction_date >= $1 AND transaction_date < $2
+	`, from, to).Scan(
+		&revenue.Gross, &revenue.Refunded,
+		&revenue.Payments, &revenue.Refunds, &revenue.Unmatched,
+		&revenue.SyncedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT COALESCE(p.plan, ''), SUM(t.amount), COUNT(*)
+		FROM star_transactions t
+		LEFT JOIN payments p ON p.id = t.payment_id
+		WHERE t.direction = 'in' AND t.transaction_date >= $1 AND t.transaction_date < $2
+		GROUP BY 1
+		ORDER BY 2 DESC
+	`, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p PlanRevenue
+		if err := rows.Scan(&p.Plan, &p.Stars, &p.Payments); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		revenue.ByPlan = append(revenue.ByPlan, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return revenue, nil
+}
+
+// UserStarTransactions returns the latest synced Star transactions of a
+// user, newest first, with the payments they were matched to
+func (s *PaymentService) UserStarTransactions(ctx context.Context, userID int64, limit int) ([]StarTransactionRecord, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.id, t.direction, t.amount, t.transaction_date, t.payment_id, p.status, p.plan
+		FROM star_transactions t
+		LEFT JOIN payments p ON p.id = t.payment_id
+		WHERE t.user_id = $1
+		ORDER BY t.transaction_date DESC, t.direction
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	records := []StarTransactionRecord{}
+	for rows.Next() {
+		var r StarTransactionRecord
+		if err := rows.Scan(&r.ID, &r.Direction, &r.Amount, &r.Date, &r.PaymentID, &r.PaymentStatus, &r.Plan); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// TrialPlan is the plan a trial unlocks
+	TrialPlan = "pro"
+
+	// TrialDuration is how long a trial lasts. The plan is downgraded by
+	// the cleanup job like any expired subscription
+	TrialDuration = 7 * 24 * time.Hour
+)
+
+// Trial represents a redeemed trial of a paid plan
+type Trial struct {
+	Plan      string
+	StartedAt time.Time
+	ExpiresAt time.Time
+}
+
+// StartTrial puts a user on TrialPlan for TrialDuration. A trial is
+// redeemable once per Telegram account, even if the account was deleted
+// and signed up again, and only by users who have never paid for a plan
+func (s *PaymentService) StartTrial(ctx context.Context, userID int64) (*Trial, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user so that a concurrent payment cannot be overwritten
+	var telegramID int64
+	var plan string
+	err = tx.QueryRow(ctx, `
+		SELECT telegram_id, plan FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&telegramID, &plan)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if plan != "standard" {
+		return nil, errors.ErrTrialUnavailable.WithMessage("already on a paid plan")
+	}
+
+	var paid bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM payments WHERE user_id = $1 AND status IN ('completed', 'refunded'))
+	`, userID).Scan(&paid)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if paid {
+		return nil, errors.ErrTrialUnavailable.WithMessage("trial is only available before the first subscription")
+	}
+
+	trial := Trial{Plan: TrialPlan}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO trials (user_id, telegram_id, plan, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+		ON CONFLICT (telegram_id) DO NOTHING
+		RETURNING started_at, expires_at
+	`, userID, telegramID, TrialPlan, TrialDuration.Seconds()).Scan(&trial.StartedAt, &trial.ExpiresAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrTrialUnavailable.WithMessage("trial already used")
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET
+			plan = $2,
+			plan_expires_at = $3,
+			plan_period = NULL,
+			scheduled_plan = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`, userID, trial.Plan, trial.ExpiresAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+
+	s.logger.Info("started trial",
+		slog.Int64("user_id", userID),
+		slog.String("plan", trial.Plan),
+		slog.Time("expires_at", trial.ExpiresAt),
+	)
+
+	return &trial, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/alert"
+	"github.com/weqory/backend/internal/pricehistory"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// PriceHistoryService reads durable price history within the lookback
+// allowed by the user's plan
+type PriceHistoryService struct {
+	pool        *pgxpool.Pool
+	store       *pricehistory.Store
+	userService *UserService
+}
+
+// NewPriceHistoryService creates a new PriceHistoryService
+func NewPriceHistoryService(pool *pgxpool.Pool, store *pricehistory.Store, userService *UserService) *PriceHistoryService {
+	return &PriceHistoryService{
+		pool:        pool,
+		store:       store,
+		userService: userService,
+	}
+}
+
+// PriceHistory is the price history of a coin over a period
+type PriceHistory struct {
+	Symbol      string
+	From        time.Time
+	To          time.Time
+	HistoryDays int // lookback allowed by the plan
+	Points      []pricehistory.Point
+}
+
+// Get returns the history of a coin in [from, to). A start before the
+// plan's lookback is moved up to it
+func (s *PriceHistoryService) Get(ctx context.Context, userID int64, coinSymbol string, from, to time.Time) (*PriceHistory, error) {
+	user, err := s.userService.GetWithLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err = clampHistoryRange(from, to, user.PriceHistoryDays, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	coinSymbol = strings.ToUpper(strings.TrimSpace(coinSymbol))
+	key, err := s.PriceKey(ctx, coinSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.store.Range(ctx, key, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return &PriceHistory{
+		Symbol:      coinSymbol,
+		From:        from,
+		To:          to,
+		HistoryDays: user.PriceHistoryDays,
+		Points:      points,
+	}, nil
+}
+
+// PriceKey returns the key a coin's prices are stored under: its Binance
+// pair, or the CoinGecko fallback key for coins without one
+func (s *PriceHistoryService) PriceKey(ctx context.Context, coinSymbol string) (string, error) {
+	var binanceSymbol, coingeckoID *string
+	err := s.pool.QueryRow(ctx, `
+		SELECT binance_symbol, coingecko_id FROM coins WHERE symbol = $1
+	`, coinSymbol).Scan(&binanceSymbol, &coingeckoID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errors.ErrCoinNotFound
+		}
+		return "", errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	switch {
+	case binanceSymbol != nil && *binanceSymbol != "":
+		return *binanceSymbol, nil
+	case coingeckoID != nil && *coingeckoID != "":
+		return alert.FallbackSymbol(*coingeckoID), nil
+	default:
+		return "", errors.ErrBadRequest.WithMessage("No price history for this coin")
+	}
+}
+
+// clampHistoryRange validates a period and limits its start to the plan's
+// lookback
+func clampHistoryRange(from, to time.Time, historyDays int, now time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-24 * time.Hour)
+	}
+
+	earliest := now.AddDate(0, 0, -historyDays)
+	if from.Before(earliest) {
+		from = earliest
+	}
+
+	if !from.Before(to) {
+		return from, to, errors.ErrBadRequest.WithMessage("Invalid period: from must be before to and within your plan's price history")
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClampHistoryRange(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// Defaults to the last 24 hours
+	from, to, err := clampHistoryRange(time.Time{}, time.Time{}, 7, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-24*time.Hour), from)
+	assert.Equal(t, now, to)
+
+	// Start moved up to the plan's lookback, end capped at now
+	from, to, err = clampHistoryRange(now.AddDate(0, 0, -30), now.Add(time.Hour), 7, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -7), from)
+	assert.Equal(t, now, to)
+
+	// A period entirely before the lookback is rejected
+	_, _, err = clampHistoryRange(now.AddDate(0, 0, -30), now.AddDate(0, 0, -20), 7, now)
+	assert.Error(t, err)
+}
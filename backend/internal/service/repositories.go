@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/schedule"
+)
+
+// Storage used by AlertService and WatchlistService, so their logic can be
+// unit tested without Postgres. Mocks live next to the tests; regenerate
+// them with `go generate ./internal/service` (needs mockery on PATH)
+//
+//go:generate mockery --config ../../.mockery.yaml
+
+// AlertRepository stores alerts; pgAlertRepository is the Postgres one
+type AlertRepository interface {
+	// CountByUser returns the number of alerts of userID
+	CountByUser(ctx context.Context, userID int64) (int64, error)
+	// ListByUser returns a page of the alerts of userID, newest first
+	ListByUser(ctx context.Context, userID int64, page pagination.Page) ([]Alert, error)
+	// GetByID returns an alert with its coin, or ErrAlertNotFound
+	GetByID(ctx context.Context, alertID int64) (*Alert, error)
+	// GetOwner returns the owner of an alert and whether its coin is still
+	// active, or ErrAlertNotFound
+	GetOwner(ctx context.Context, alertID int64) (ownerID int64, coinActive bool, err error)
+	// GetWatchedCoin returns a coin on the watchlist of userID and whether
+	// it is active, or ErrCoinNotFound when it is not on the watchlist
+	GetWatchedCoin(ctx context.Context, userID int64, coinSymbol string) (coin *Coin, active bool, err error)
+	// FindDuplicate returns another alert of the same user with the
+	// condition of alert, ignoring alert itself when it is stored
+	FindDuplicate(ctx context.Context, alert *Alert) (alertID int64, found bool, err error)
+	// Create stores a new alert and returns its ID, or ErrAlertDuplicate
+	// when an identical alert exists and alert.AllowDuplicate is unset
+	Create(ctx context.Context, alert *Alert) (int64, error)
+	// SetPaused pauses or resumes an alert, clearing any system pause
+	// reason; reports false when no such alert exists at version, unless
+	// version is nil
+	SetPaused(ctx context.Context, alertID int64, paused bool, version *int) (bool, error)
+	// SetSchedule sets the schedule of an alert of userID; reports false
+	// when no such alert exists at version, unless version is nil
+	SetSchedule(ctx context.Context, userID, alertID int64, sched *schedule.Schedule, version *int) (bool, error)
+	// Update applies params to an alert of userID and re-arms it; reports
+	// false when no such alert exists at params.Version, or
+	// ErrAlertDuplicate when the change makes it identical to another alert
+	Update(ctx context.Context, userID, alertID int64, params UpdateAlertParams) (bool, error)
+	// Delete deletes an alert
+	Delete(ctx context.Context, alertID int64) error
+	// DeleteByUser deletes all alerts of userID and returns their number
+	DeleteByUser(ctx context.Context, userID int64) (int64, error)
+}
+
+// WatchlistRepository stores watchlists and looks up coins;
+// pgWatchlistRepository is the Postgres one
+type WatchlistRepository interface {
+	// CountByUser returns the number of coins watched by userID, of
+	// category only unless it is empty
+	CountByUser(ctx context.Context, userID int64, category string) (int64, error)
+	// ListByUser returns a page of the watchlist of userID, newest first
+	ListByUser(ctx context.Context, userID int64, category string, page pagination.Page) ([]WatchlistItem, error)
+	// Contains reports whether coinID is on the watchlist of userID
+	Contains(ctx context.Context, userID int64, coinID int) (bool, error)
+	// Add puts coinID on the watchlist of userID
+	Add(ctx context.Context, userID int64, coinID int) (*WatchlistItem, error)
+	// Remove takes coinID off the watchlist of userID along with its
+	// alerts; reports false when it was not watched
+	Remove(ctx context.Context, userID int64, coinID int) (deletedAlerts int64, removed bool, err error)
+	// DeleteByUser clears the watchlist and alerts of userID and returns
+	// the number of coins removed
+	DeleteByUser(ctx context.Context, userID int64) (int64, error)
+	// DeleteOrphaned removes entries of userID whose coin no longer exists
+	DeleteOrphaned(ctx context.Context, userID int64) error
+	// Export calls fn with each coin on the watchlist of userID and its
+	// active alerts, newest first, as the rows are read
+	Export(ctx context.Context, userID int64, fn func(WatchlistExportRow) error) error
+
+	// GetCoinID returns the ID of a coin, or ErrCoinNotFound
+	GetCoinID(ctx context.Context, symbol string) (int, error)
+	// GetWatchableCoin returns an active coin that is not excluded, or
+	// ErrCoinNotFound. Stablecoins only count when allowStablecoins is set
+	GetWatchableCoin(ctx context.Context, symbol string, allowStablecoins bool) (*Coin, error)
+	// SearchCoins returns watchable coins matching search (the top ranked
+	// ones when empty), of category only unless it is empty. Stablecoins
+	// are left out unless includeStablecoins is set
+	SearchCoins(ctx context.Context, search, category string, limit int, includeStablecoins bool) ([]Coin, error)
+	// GetCoinsBySymbols returns the non-stablecoins among symbols, largest
+	// market cap first
+	GetCoinsBySymbols(ctx context.Context, symbols []string, limit int) ([]Coin, error)
+}
+
+// PlanLimits provides the plan limits of users; implemented by UserService
+type PlanLimits interface {
+	CheckAndDowngradeExpiredPlan(ctx context.Context, userID int64) (bool, error)
+	GetWithLimits(ctx context.Context, userID int64) (*UserWithLimits, error)
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/status"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Incident severities
+const (
+	IncidentMinor    = "minor"
+	IncidentMajor    = "major"
+	IncidentCritical = "critical"
+)
+
+const (
+	// Windows of the signals shown on the status page
+	StatusUptimeWindow   = 24 * time.Hour
+	StatusDeliveryWindow = time.Hour
+
+	// StatusIncidentWindow is how far back resolved incidents are listed;
+	// unresolved ones are listed however old
+	StatusIncidentWindow = 14 * 24 * time.Hour
+
+	maxStatusIncidents = 20
+)
+
+// StatusService gathers what the public status page shows besides the
+// health checks: stream uptime, delivery rate and incidents
+type StatusService struct {
+	pool    *pgxpool.Pool
+	tracker *status.Tracker
+	logger  *slog.Logger
+}
+
+// NewStatusService creates a new StatusService
+func NewStatusService(pool *pgxpool.Pool, tracker *status.Tracker, logger *slog.Logger) *StatusService {
+	return &StatusService{
+		pool:    pool,
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// Incident is an outage or degradation posted by an operator
+type Incident struct {
+	ID          int64
+	Title       string
+	Description string
+	Severity    string
+	Operator    string
+	StartedAt   time.Time
+	ResolvedAt  *time.Time
+}
+
+// StatusSignals are the measured signals of the status page
+type StatusSignals struct {
+	BinanceUptime status.Uptime   // over StatusUptimeWindow
+	Delivery      status.Delivery // over StatusDeliveryWindow
+}
+
+// Signals reads the Binance stream uptime and the notification delivery
+// counts
+func (s *StatusService) Signals(ctx context.Context) (*StatusSignals, error) {
+	uptime, err := s.tracker.Uptime(ctx, status.ComponentBinanceStream, StatusUptimeWindow)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrRedis)
+	}
+	delivery, err := s.tracker.Delivery(ctx, StatusDeliveryWindow)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrRedis)
+	}
+
+	return &StatusSignals{BinanceUptime: uptime, Delivery: delivery}, nil
+}
+
+// RecentIncidents returns unresolved incidents and those resolved within
+// StatusIncidentWindow, newest first
+func (s *StatusService) RecentIncidents(ctx context.Context) ([]Incident, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, title, description, severity, operator, started_at, resolved_at
+		FROM status_incidents
+		WHERE resolved_at IS NULL OR resolved_at > $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`, time.Now().Add(-StatusIncidentWindow), maxStatusIncidents)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var incidents []Incident
+	for rows.Next() {
+		var i Incident
+		if err := rows.Scan(&i.ID, &i.Title, &i.Description, &i.Severity, &i.Operator, &i.StartedAt, &i.ResolvedAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		incidents = append(incidents, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return incidents, nil
+}
+
+// CreateIncident posts an incident, started now
+func (s *StatusService) CreateIncident(ctx context.Context, title, description, severity, operator string) (*Incident, error) {
+	i := Incident{
+		Title:       title,
+		Description: description,
+		Severity:    severity,
+		Operator:    operator,
+	}
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO status_incidents (title, description, severity, operator)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, started_at
+	`, title, description, severity, operator).Scan(&i.ID, &i.StartedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.logger.Warn("status incident posted",
+		slog.Int64("incident_id", i.ID),
+		slog.String("severity", severity),
+		slog.String("operator", operator),
+	)
+	return &i, nil
+}
+
+// ResolveIncident marks an open incident resolved
+func (s *StatusService) ResolveIncident(ctx context.Context, id int64) error {
+	result, err := s.pool.Exec(ctx, `
+		UPDATE status_incidents SET resolved_at = NOW()
+		WHERE id = $1 AND resolved_at IS NULL
+	`, id)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return errors.ErrNotFound.WithMessage("incident not found or already resolved")
+	}
+
+	s.logger.Info("status incident resolved", slog.Int64("incident_id", id))
+	return nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// Symbol mapping sources
+const (
+	MappingSourceAuto   = "auto"
+	MappingSourceManual = "manual"
+)
+
+// SymbolMappingService manages coin symbol to Binance pair mappings
+type SymbolMappingService struct {
+	pool         *pgxpool.Pool
+	exchangeInfo *binance.ExchangeInfo
+	logger       *slog.Logger
+}
+
+// NewSymbolMappingService creates a new SymbolMappingService
+func NewSymbolMappingService(pool *pgxpool.Pool, exchangeInfo *binance.ExchangeInfo, logger *slog.Logger) *SymbolMappingService {
+	return &SymbolMappingService{
+		pool:         pool,
+		exchangeInfo: exchangeInfo,
+		logger:       logger,
+	}
+}
+
+// SymbolMapping represents a coin symbol to Binance pair mapping
+type SymbolMapping struct {
+	Symbol        string
+	BinanceSymbol *string
+	Source        string
+	IsValid       *bool
+	CheckedAt     *time.Time
+	UpdatedAt     time.Time
+}
+
+// ReconcileResult summarizes a reconciliation run
+type ReconcileResult struct {
+	Checked int
+	Valid   int
+	Invalid int
+	Changed int
+}
+
+// GetAll returns all mappings
+func (s *SymbolMappingService) GetAll(ctx context.Context) ([]SymbolMapping, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT symbol, binance_symbol, source, is_valid, checked_at, updated_at
+		FROM symbol_mappings
+		ORDER BY source DESC, symbol ASC
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var mappings []SymbolMapping
+	for rows.Next() {
+		var m SymbolMapping
+		if err := rows.Scan(&m.Symbol, &m.BinanceSymbol, &m.Source, &m.IsValid, &m.CheckedAt, &m.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		mappings = append(mappings, m)
+	}
+
+	if mappings == nil {
+		mappings = []SymbolMapping{}
+	}
+
+	return mappings, nil
+}
+
+// SetManual stores an admin override for a coin symbol
+// A nil binanceSymbol marks the coin as not traded on Binance
+func (s *SymbolMappingService) SetManual(ctx context.Context, symbol string, binanceSymbol *string) (*SymbolMapping, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if binanceSymbol != nil {
+		normalized := strings.ToUpper(strings.TrimSpace(*binanceSymbol))
+		binanceSymbol = &normalized
+	}
+
+	var exists bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM coins WHERE symbol = $1)`, symbol).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if !exists {
+		return nil, errors.ErrCoinNotFound
+	}
+
+	// Validate right away so the admin gets feedback; the mapping is stored either way
+	var isValid *bool
+	if binanceSymbol != nil {
+		info, ok, err := s.exchangeInfo.Lookup(ctx, *binanceSymbol)
+		if err != nil {
+			s.logger.Warn("failed to validate symbol mapping", slog.String("error", err.Error()))
+		} else {
+			valid := ok && info.Status == binance.SymbolStatusTrading
+			isValid = &valid
+		}
+	}
+
+	var m SymbolMapping
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO symbol_mappings (symbol, binance_symbol, source, is_valid, checked_at)
+		VALUES ($1, $2, 'manual', $3, CASE WHEN $3::boolean IS NULL THEN NULL ELSE NOW() END)
+		ON CONFLICT (symbol) DO UPDATE SET
+			binance_symbol = EXCLUDED.binance_symbol,
+			source = 'manual',
+			is_valid = EXCLUDED.is_valid,
+			checked_at = EXCLUDED.checked_at
+		RETURNING symbol, binance_symbol, source, is_valid, checked_at, updated_at
+	`, symbol, binanceSymbol, isValid).Scan(&m.Symbol, &m.BinanceSymbol, &m.Source, &m.IsValid, &m.CheckedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := s.applyMapping(ctx, symbol, m.BinanceSymbol, m.IsValid); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// DeleteManual removes an admin override so the reconciler manages the symbol again
+func (s *SymbolMappingService) DeleteManual(ctx context.Context, symbol string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	result, err := s.pool.Exec(ctx, `
+		DELETE FROM symbol_mappings WHERE symbol = $1 AND source = 'manual'
+	`, symbol)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.ErrNotFound.WithMessage("Manual mapping not found")
+	}
+
+	return nil
+}
+
+// Reconcile checks every coin's Binance pair against exchangeInfo and updates
+// coins.binance_symbol. Coins without a valid pair fall back to CoinGecko pricing
+func (s *SymbolMappingService) Reconcile(ctx context.Context) (*ReconcileResult, error) {
+	symbols, err := s.exchangeInfo.Symbols(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrExternalService)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.symbol, c.binance_symbol, m.binance_symbol, m.source
+		FROM coins c
+		LEFT JOIN symbol_mappings m ON m.symbol = c.symbol
+		WHERE c.is_stablecoin = false
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	type coinMapping struct {
+		symbol  string
+		current *string
+		mapped  *string
+		source  *string
+	}
+
+	var coins []coinMapping
+	for rows.Next() {
+		var c coinMapping
+		if err := rows.Scan(&c.symbol, &c.current, &c.mapped, &c.source); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		coins = append(coins, c)
+	}
+	rows.Close()
+
+	result := &ReconcileResult{}
+	for _, c := range coins {
+		// Stop instead of logging a failure per remaining coin
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result.Checked++
+
+		// Manual overrides win; otherwise use the conventional USDT pair
+		candidate := binance.DefaultPairSymbol(c.symbol)
+		source := MappingSourceAuto
+		if c.source != nil && *c.source == MappingSourceManual {
+			source = MappingSourceManual
+			candidate = ""
+			if c.mapped != nil {
+				candidate = *c.mapped
+			}
+		}
+
+		valid := false
+		if candidate != "" {
+			info, ok := symbols[candidate]
+			valid = ok && info.Status == binance.SymbolStatusTrading
+		}
+
+		if valid {
+			result.Valid++
+		} else {
+			result.Invalid++
+		}
+
+		var resolved *string
+		if valid {
+			resolved = &candidate
+		}
+
+		if source == MappingSourceAuto {
+			_, err := s.pool.Exec(ctx, `
+				INSERT INTO symbol_mappings (symbol, binance_symbol, source, is_valid, checked_at)
+				VALUES ($1, $2, 'auto', $3, NOW())
+				ON CONFLICT (symbol) DO UPDATE SET
+					binance_symbol = EXCLUDED.binance_symbol,
+					is_valid = EXCLUDED.is_valid,
+					checked_at = NOW()
+				WHERE symbol_mappings.source = 'auto'
+			`, c.symbol, resolved, valid)
+			if err != nil {
+				s.logger.Error("failed to store symbol mapping",
+					slog.String("symbol", c.symbol),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+		} else {
+			_, err := s.pool.Exec(ctx, `
+				UPDATE symbol_mappings SET is_valid = $2, checked_at = NOW() WHERE symbol = $1
+			`, c.symbol, valid)
+			if err != nil {
+				s.logger.Error("failed to update symbol mapping",
+					slog.String("symbol", c.symbol),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+		}
+
+		if !equalStringPtr(c.current, resolved) {
+			if _, err := s.pool.Exec(ctx, `UPDATE coins SET binance_symbol = $2 WHERE symbol = $1`, c.symbol, resolved); err != nil {
+				s.logger.Error("failed to update coin binance symbol",
+					slog.String("symbol", c.symbol),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+			result.Changed++
+			s.logger.Info("binance symbol changed",
+				slog.String("symbol", c.symbol),
+				slog.Any("old", c.current),
+				slog.Any("new", resolved),
+			)
+		}
+	}
+
+	return result, nil
+}
+
+// RunReconcile runs a scheduled reconciliation and logs the outcome
+func (s *SymbolMappingService) RunReconcile(ctx context.Context) error {
+	result, err := s.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("symbol reconciliation completed",
+		slog.Int("checked", result.Checked),
+		slog.Int("valid", result.Valid),
+		slog.Int("invalid", result.Invalid),
+		slog.Int("changed", result.Changed),
+	)
+
+	return nil
+}
+
+// applyMapping updates coins.binance_symbol for a single coin
+func (s *SymbolMappingService) applyMapping(ctx context.Context, symbol string, binanceSymbol *string, isValid *bool) error {
+	// An override that is known to be invalid is treated as "not on Binance"
+	if isValid != nil && !*isValid {
+		binanceSymbol = nil
+	}
+
+	_, err := s.pool.Exec(ctx, `UPDATE coins SET binance_symbol = $2 WHERE symbol = $1`, symbol, binanceSymbol)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return nil
+}
+
+// equalStringPtr compares two optional strings
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+)
+
+const (
+	// Targets are personal notes, not plan-limited like alerts
+	maxTargetsPerUser = 50
+
+	// Recent price history used for the projected date
+	targetProjectionWindow = 6 * time.Hour
+	// Projections further out than this are reported as unknown
+	targetProjectionMaxAhead = 365 * 24 * time.Hour
+)
+
+// TargetService handles personal price targets. Unlike alerts they are
+// never evaluated by the alert engine; progress is computed on read
+type TargetService struct {
+	pool       *pgxpool.Pool
+	priceCache *cache.PriceCache
+	logger     *slog.Logger
+}
+
+// NewTargetService creates a new TargetService
+func NewTargetService(pool *pgxpool.Pool, priceCache *cache.PriceCache, logger *slog.Logger) *TargetService {
+	return &TargetService{
+		pool:       pool,
+		priceCache: priceCache,
+		logger:     logger,
+	}
+}
+
+// PriceTarget represents a user's price target
+type PriceTarget struct {
+	ID               int64
+	UserID           int64
+	Coin             Coin
+	TargetPrice      float64
+	PriceWhenCreated *float64
+	Note             string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Progress         TargetProgress
+}
+
+// TargetProgress describes how far the price has moved towards a target
+type TargetProgress struct {
+	CurrentPrice *float64
+	// Share of the way from the creation price to the target (can be
+	// negative when the price moved away, or above 100 once passed)
+	ProgressPct *float64
+	// Distance from the current price to the target
+	DistancePct *float64
+	Reached     bool
+	// Linear extrapolation of the recent trend; nil when the price moves
+	// away from the target or history is too short
+	ProjectedAt *time.Time
+}
+
+// UpdateTargetParams holds the fields of a target that can be changed
+type UpdateTargetParams struct {
+	TargetPrice *float64
+	Note        *string
+}
+
+// targetColumns selects a target with its coin, in scanTarget's order
+const targetColumns = `
+	t.id, t.user_id, t.target_price, t.price_when_created, t.note,
+	t.created_at, t.updated_at,
+	c.id, c.symbol, c.name, c.binance_symbol,
+	c.rank_by_market_cap, c.current_price, c.market_cap,
+	c.volume_24h, c.price_change_24h_pct`
+
+// scanTarget scans a row selected with targetColumns
+func scanTarget(row pgx.Row) (*PriceTarget, error) {
+	var t PriceTarget
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.TargetPrice, &t.PriceWhenCreated, &t.Note,
+		&t.CreatedAt, &t.UpdatedAt,
+		&t.Coin.ID, &t.Coin.Symbol, &t.Coin.Name, &t.Coin.BinanceSymbol,
+		&t.Coin.Rank, &t.Coin.CurrentPrice, &t.Coin.MarketCap,
+		&t.Coin.Volume24h, &t.Coin.PriceChange24hPct,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetByUserID retrieves a page of the user's targets with their progress,
+// newest first
+func (s *TargetService) GetByUserID(ctx context.Context, userID int64, page pagination.Page) (*pagination.Result[PriceTarget], error) {
+	var total int64
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM price_targets WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+targetColumns+`
+		FROM price_targets t
+		JOIN coins c ON c.id = t.coin_id
+		WHERE t.user_id = $1
+		  AND ($2::text IS NULL OR (t.created_at, t.id) < ($2::timestamptz, $3::bigint))
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $4
+	`, userID, page.AfterKey(), page.AfterID(), page.FetchLimit())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	targets := []PriceTarget{}
+	for rows.Next() {
+		t, err := scanTarget(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		targets = append(targets, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	result := pagination.NewResult(targets, total, page, func(t PriceTarget) pagination.Cursor {
+		return pagination.TimeCursor(t.CreatedAt, t.ID)
+	})
+	for i := range result.Items {
+		s.fillProgress(ctx, &result.Items[i])
+	}
+
+	return result, nil
+}
+
+// GetByID retrieves a target with its progress
+func (s *TargetService) GetByID(ctx context.Context, targetID int64) (*PriceTarget, error) {
+	t, err := scanTarget(s.pool.QueryRow(ctx, `
+		SELECT `+targetColumns+`
+		FROM price_targets t
+		JOIN coins c ON c.id = t.coin_id
+		WHERE t.id = $1
+	`, targetID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrTargetNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.fillProgress(ctx, t)
+	return t, nil
+}
+
+// Create records a new price target for a coin
+func (s *TargetService) Create(ctx context.Context, userID int64, coinSymbol string, targetPrice float64, note string) (*PriceTarget, error) {
+	coinSymbol = strings.ToUpper(strings.TrimSpace(coinSymbol))
+
+	var count int
+	err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM price_targets WHERE user_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if count >= maxTargetsPerUser {
+		return nil, errors.ErrLimitExceeded.WithMessage("Price target limit reached")
+	}
+
+	var t PriceTarget
+	err = s.pool.QueryRow(ctx, `
+		SELECT id, symbol, name, binance_symbol, rank_by_market_cap,
+		       current_price, market_cap, volume_24h, price_change_24h_pct
+		FROM coins WHERE symbol = $1 AND is_active = true
+	`, coinSymbol).Scan(
+		&t.Coin.ID, &t.Coin.Symbol, &t.Coin.Name, &t.Coin.BinanceSymbol, &t.Coin.Rank,
+		&t.Coin.CurrentPrice, &t.Coin.MarketCap, &t.Coin.Volume24h, &t.Coin.PriceChange24hPct,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrCoinNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Progress is measured from the price at creation
+	priceWhenCreated := s.currentPrice(ctx, &t.Coin)
+
+	err = s.pool.QueryRow(ctx, `
+		INSERT INTO price_targets (user_id, coin_id, target_price, price_when_created, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, target_price, price_when_created, note, created_at, updated_at
+	`, userID, t.Coin.ID, targetPrice, priceWhenCreated, note).Scan(
+		&t.ID, &t.UserID, &t.TargetPrice, &t.PriceWhenCreated, &t.Note, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.fillProgress(ctx, &t)
+	return &t, nil
+}
+
+// Update changes the target price and/or note of a target
+func (s *TargetService) Update(ctx context.Context, userID, targetID int64, params UpdateTargetParams) (*PriceTarget, error) {
+	if err := s.checkOwner(ctx, userID, targetID); err != nil {
+		return nil, err
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		UPDATE price_targets SET
+			target_price = COALESCE($2, target_price),
+			note = COALESCE($3, note)
+		WHERE id = $1
+	`, targetID, params.TargetPrice, params.Note)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return s.GetByID(ctx, targetID)
+}
+
+// Delete removes a target
+func (s *TargetService) Delete(ctx context.Context, userID, targetID int64) error {
+	if err := s.checkOwner(ctx, userID, targetID); err != nil {
+		return err
+	}
+
+	_, err := s.pool.Exec(ctx, `DELETE FROM price_targets WHERE id = $1`, targetID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return nil
+}
+
+// checkOwner verifies the target exists and belongs to the user
+func (s *TargetService) checkOwner(ctx context.Context, userID, targetID int64) error {
+	var ownerID int64
+	err := s.pool.QueryRow(ctx, `SELECT user_id FROM price_targets WHERE id = $1`, targetID).Scan(&ownerID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.ErrTargetNotFound
+		}
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if ownerID != userID {
+		return errors.ErrNotOwner
+	}
+
+	return nil
+}
+
+// currentPrice returns the live price for Binance coins, falling back to
+// the last synced price stored on the coin
+func (s *TargetService) currentPrice(ctx context.Context, coin *Coin) *float64 {
+	if coin.BinanceSymbol != nil {
+		data, err := s.priceCache.Get(ctx, *coin.BinanceSymbol)
+		if err != nil {
+			s.logger.Warn("failed to read cached price",
+				slog.String("symbol", *coin.BinanceSymbol),
+				slog.String("error", err.Error()),
+			)
+		} else if data != nil && data.Price > 0 {
+			price := data.Price
+			return &price
+		}
+	}
+	return coin.CurrentPrice
+}
+
+// fillProgress computes the target's progress from the current price and
+// the recent price history
+func (s *TargetService) fillProgress(ctx context.Context, t *PriceTarget) {
+	current := s.currentPrice(ctx, &t.Coin)
+	if current == nil || *current <= 0 {
+		return
+	}
+
+	var history []cache.PriceHistoryEntry
+	if t.Coin.BinanceSymbol != nil {
+		var err error
+		history, err = s.priceCache.GetHistory(ctx, *t.Coin.BinanceSymbol, int64(targetProjectionWindow/time.Minute))
+		if err != nil {
+			s.logger.Warn("failed to read price history",
+				slog.String("symbol", *t.Coin.BinanceSymbol),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	t.Progress = computeTargetProgress(*current, t.TargetPrice, t.PriceWhenCreated, history, time.Now())
+}
+
+// computeTargetProgress derives progress figures for a target. history is
+// newest first, as returned by PriceCache.GetHistory
+func computeTargetProgress(current, target float64, start *float64, history []cache.PriceHistoryEntry, now time.Time) TargetProgress {
+	p := TargetProgress{CurrentPrice: &current}
+
+	distance := (target - current) / current * 100
+	p.DistancePct = &distance
+
+	if start != nil && *start > 0 && *start != target {
+		progress := (current - *start) / (target - *start) * 100
+		p.ProgressPct = &progress
+		p.Reached = progress >= 100
+	} else {
+		p.Reached = current == target
+	}
+	if p.Reached {
+		return p
+	}
+
+	slope, ok := priceSlope(history, now.Add(-targetProjectionWindow))
+	if !ok || slope == 0 {
+		return p
+	}
+
+	// Only project when the trend points towards the target
+	seconds := (target - current) / slope
+	if seconds <= 0 {
+		return p
+	}
+	ahead := time.Duration(seconds * float64(time.Second))
+	if ahead > targetProjectionMaxAhead {
+		return p
+	}
+	projected := now.Add(ahead).Truncate(time.Minute)
+	p.ProjectedAt = &projected
+
+	return p
+}
+
+// priceSlope fits a least-squares line through history entries newer than
+// since and returns its slope in price per second
+func priceSlope(history []cache.PriceHistoryEntry, since time.Time) (float64, bool) {
+	var n, sumX, sumY, sumXY, sumXX float64
+	origin := since.Unix()
+	for _, entry := range history {
+		if entry.Timestamp < origin {
+			continue
+		}
+		x := float64(entry.Timestamp - origin)
+		n++
+		sumX += x
+		sumY += entry.Price
+		sumXY += x * entry.Price
+		sumXX += x * x
+	}
+
+	// Need a few points for the trend to mean anything
+	if n < 3 {
+		return 0, false
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, false
+	}
+
+	return (n*sumXY - sumX*sumY) / denom, true
+}
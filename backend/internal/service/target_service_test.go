@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/cache"
+)
+
+// risingHistory returns newest-first minute history rising by step per minute
+func risingHistory(now time.Time, last, step float64, points int) []cache.PriceHistoryEntry {
+	history := make([]cache.PriceHistoryEntry, points)
+	for i := range history {
+		history[i] = cache.PriceHistoryEntry{
+			Timestamp: now.Add(-time.Duration(i) * time.Minute).Unix(),
+			Price:     last - float64(i)*step,
+		}
+	}
+	return history
+}
+
+func TestComputeTargetProgress(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	start := 100.0
+
+	// Halfway from 100 to 120, rising 1 per minute: 10 minutes to go
+	p := computeTargetProgress(110, 120, &start, risingHistory(now, 110, 1, 60), now)
+	require.NotNil(t, p.ProgressPct)
+	assert.InDelta(t, 50, *p.ProgressPct, 0.001)
+	assert.InDelta(t, 9.0909, *p.DistancePct, 0.001)
+	assert.False(t, p.Reached)
+	require.NotNil(t, p.ProjectedAt)
+	assert.Equal(t, now.Add(10*time.Minute), *p.ProjectedAt)
+
+	// Price falling away from the target has no projection
+	p = computeTargetProgress(110, 120, &start, risingHistory(now, 110, -1, 60), now)
+	assert.Nil(t, p.ProjectedAt)
+
+	// Too little history has no projection
+	p = computeTargetProgress(110, 120, &start, risingHistory(now, 110, 1, 2), now)
+	assert.Nil(t, p.ProjectedAt)
+
+	// Downside targets work the same way
+	p = computeTargetProgress(90, 80, &start, risingHistory(now, 90, -1, 60), now)
+	assert.InDelta(t, 50, *p.ProgressPct, 0.001)
+	require.NotNil(t, p.ProjectedAt)
+	assert.Equal(t, now.Add(10*time.Minute), *p.ProjectedAt)
+
+	// Passed targets are reached
+	p = computeTargetProgress(125, 120, &start, nil, now)
+	assert.True(t, p.Reached)
+	assert.Nil(t, p.ProjectedAt)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+const (
+	// Watcher growth is measured against the snapshot of a day before
+	trendingGrowthWindow = 24 * time.Hour
+
+	// Snapshots are kept a week, enough to look back on missed runs
+	trendingRetention = 7 * 24 * time.Hour
+
+	// A user adding an alert on a coin counts twice as much as one adding
+	// it to their watchlist
+	trendingAlertWeight = 2
+
+	// Triggers count logarithmically, so a coin whose alerts fire all day
+	// does not outrank one users are flocking to
+	trendingTriggerWeight = 1.5
+)
+
+// TrendingService records how many users watch and alert each coin and
+// ranks coins by how fast that grows
+type TrendingService struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+// NewTrendingService creates a new TrendingService
+func NewTrendingService(pool *pgxpool.Pool, logger *slog.Logger) *TrendingService {
+	return &TrendingService{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// TrendingCoin is a coin of the trending list with its popularity
+type TrendingCoin struct {
+	Coin          Coin
+	Watchers      int
+	AlertUsers    int
+	WatcherGrowth int // watchers gained in the last 24 hours
+	Triggers24h   int
+	Score         float64
+	ComputedAt    time.Time
+}
+
+// coinPopularity counts the users of a coin at one point in time
+type coinPopularity struct {
+	watchers   int
+	alertUsers int
+	triggers   int
+}
+
+// trendingScore scores a coin by the users it gained since prev, relative
+// to its size so small coins catching on show up next to large ones, plus
+// its recent alert triggers
+func trendingScore(current, prev coinPopularity) float64 {
+	growth := float64(current.watchers-prev.watchers) +
+		trendingAlertWeight*float64(current.alertUsers-prev.alertUsers)
+	size := math.Sqrt(float64(prev.watchers + prev.alertUsers + 1))
+
+	return growth/size + trendingTriggerWeight*math.Log1p(float64(current.triggers))
+}
+
+// Compute snapshots the popularity of every coin that has watchers, alerts
+// or recent triggers and scores it. Snapshots are per hour, so running
+// twice in an hour replaces the first
+func (s *TrendingService) Compute(ctx context.Context) (int, error) {
+	now := time.Now().UTC().Truncate(time.Hour)
+
+	current, err := s.loadPopularity(ctx)
+	if err != nil {
+		return 0, err
+	}
+	prev, err := s.loadSnapshot(ctx, now.Add(-trendingGrowthWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	n := len(current)
+	coinIDs := make([]int32, 0, n)
+	watchers := make([]int32, 0, n)
+	alertUsers := make([]int32, 0, n)
+	growth := make([]int32, 0, n)
+	triggers := make([]int32, 0, n)
+	scores := make([]float64, 0, n)
+	for coinID, p := range current {
+		coinIDs = append(coinIDs, int32(coinID))
+		watchers = append(watchers, int32(p.watchers))
+		alertUsers = append(alertUsers, int32(p.alertUsers))
+		growth = append(growth, int32(p.watchers-prev[coinID].watchers))
+		triggers = append(triggers, int32(p.triggers))
+		scores = append(scores, trendingScore(p, prev[coinID]))
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO coin_popularity (coin_id, recorded_at, watchers, alert_users, watcher_growth, triggers_24h, score)
+		SELECT p.coin_id, $1, p.watchers, p.alert_users, p.growth, p.triggers, p.score
+		FROM unnest($2::int[], $3::int[], $4::int[], $5::int[], $6::int[], $7::float8[])
+			AS p(coin_id, watchers, alert_users, growth, triggers, score)
+		ON CONFLICT (coin_id, recorded_at) DO UPDATE SET
+			watchers = EXCLUDED.watchers,
+			alert_users = EXCLUDED.alert_users,
+			watcher_growth = EXCLUDED.watcher_growth,
+			triggers_24h = EXCLUDED.triggers_24h,
+			score = EXCLUDED.score
+	`, now, coinIDs, watchers, alertUsers, growth, triggers, scores)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Coins that lost all their users this hour keep no snapshot
+	_, err = tx.Exec(ctx, `
+		DELETE FROM coin_popularity
+		WHERE recorded_at < $1 OR (recorded_at = $2 AND NOT coin_id = ANY($3::int[]))
+	`, now.Add(-trendingRetention), now, coinIDs)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return n, nil
+}
+
+// RunCompute runs a scheduled computation and logs the outcome
+func (s *TrendingService) RunCompute(ctx context.Context) error {
+	coins, err := s.Compute(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("trending scores computed", slog.Int("coins", coins))
+	return nil
+}
+
+// loadPopularity counts the current watchers, users with active alerts and
+// triggers of the last 24 hours of every coin that has any
+func (s *TrendingService) loadPopularity(ctx context.Context) (map[int]coinPopularity, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH w AS (
+			SELECT coin_id, COUNT(*) AS n FROM watchlist GROUP BY coin_id
+		), a AS (
+			SELECT coin_id, COUNT(DISTINCT user_id) AS n FROM alerts
+			WHERE is_paused = false GROUP BY coin_id
+		), t AS (
+			SELECT coin_id, COUNT(*) AS n FROM alert_history
+			WHERE triggered_at > NOW() - INTERVAL '24 hours' GROUP BY coin_id
+		)
+		SELECT c.id, COALESCE(w.n, 0), COALESCE(a.n, 0), COALESCE(t.n, 0)
+		FROM coins c
+		LEFT JOIN w ON w.coin_id = c.id
+		LEFT JOIN a ON a.coin_id = c.id
+		LEFT JOIN t ON t.coin_id = c.id
+		WHERE w.n IS NOT NULL OR a.n IS NOT NULL OR t.n IS NOT NULL
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	popularity := make(map[int]coinPopularity)
+	for rows.Next() {
+		var coinID int
+		var p coinPopularity
+		if err := rows.Scan(&coinID, &p.watchers, &p.alertUsers, &p.triggers); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		popularity[coinID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return popularity, nil
+}
+
+// loadSnapshot returns the latest snapshot of each coin taken at or before
+// at but no more than a day before it. Coins missing from it had no users
+func (s *TrendingService) loadSnapshot(ctx context.Context, at time.Time) (map[int]coinPopularity, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT ON (coin_id) coin_id, watchers, alert_users
+		FROM coin_popularity
+		WHERE recorded_at <= $1 AND recorded_at > $1 - INTERVAL '24 hours'
+		ORDER BY coin_id, recorded_at DESC
+	`, at)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	snapshot := make(map[int]coinPopularity)
+	for rows.Next() {
+		var coinID int
+		var p coinPopularity
+		if err := rows.Scan(&coinID, &p.watchers, &p.alertUsers); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		snapshot[coinID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return snapshot, nil
+}
+
+// GetTrending returns the active coins with the highest score in the
+// latest snapshot, stablecoins left out
+func (s *TrendingService) GetTrending(ctx context.Context, limit int) ([]TrendingCoin, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.id, c.symbol, c.name, c.binance_symbol, c.rank_by_market_cap,
+		       c.current_price, c.market_cap, c.volume_24h, c.price_change_24h_pct,
+		       p.watchers, p.alert_users, p.watcher_growth, p.triggers_24h, p.score, p.recorded_at
+		FROM coin_popularity p
+		JOIN coins c ON c.id = p.coin_id
+		WHERE p.recorded_at = (SELECT MAX(recorded_at) FROM coin_popularity)
+		  AND p.score > 0
+		  AND c.is_active
+		  AND c.is_stablecoin = false AND c.is_excluded = false
+		ORDER BY p.score DESC, p.watchers DESC, c.id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	coins := []TrendingCoin{}
+	for rows.Next() {
+		var t TrendingCoin
+		err := rows.Scan(
+			&t.Coin.ID, &t.Coin.Symbol, &t.Coin.Name, &t.Coin.BinanceSymbol, &t.Coin.Rank,
+			&t.Coin.CurrentPrice, &t.Coin.MarketCap, &t.Coin.Volume24h, &t.Coin.PriceChange24hPct,
+			&t.Watchers, &t.AlertUsers, &t.WatcherGrowth, &t.Triggers24h, &t.Score, &t.ComputedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		coins = append(coins, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return coins, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrendingScore(t *testing.T) {
+	// A small coin doubling its watchers outranks a large one gaining the same
+	small := trendingScore(coinPopularity{watchers: 20}, coinPopularity{watchers: 10})
+	large := trendingScore(coinPopularity{watchers: 1010}, coinPopularity{watchers: 1000})
+	assert.Greater(t, small, large)
+
+	// Alerts count more than watchers
+	watched := trendingScore(coinPopularity{watchers: 5}, coinPopularity{})
+	alerted := trendingScore(coinPopularity{alertUsers: 5}, coinPopularity{})
+	assert.Greater(t, alerted, watched)
+
+	// Triggers add less and less
+	few := trendingScore(coinPopularity{triggers: 10}, coinPopularity{})
+	many := trendingScore(coinPopularity{triggers: 1000}, coinPopularity{})
+	assert.Less(t, many, 4*few)
+
+	// Losing users scores below zero
+	assert.Less(t, trendingScore(coinPopularity{watchers: 5}, coinPopularity{watchers: 10}), 0.0)
+	assert.Zero(t, trendingScore(coinPopularity{watchers: 10}, coinPopularity{watchers: 10}))
+}
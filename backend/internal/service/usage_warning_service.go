@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+// Plan resources whose usage is warned about
+const (
+	UsageResourceCoins  = "coins"
+	UsageResourceAlerts = "alerts"
+)
+
+// UsageThresholds are the percentages of a plan limit at which users are
+// warned, ascending
+var UsageThresholds = []int{80, 100}
+
+// upgradeStartParam is the Mini App start parameter of the upgrade deep
+// link, followed by the resource that ran out
+const upgradeStartParam = "upgrade_"
+
+// Bounds sending the nudge, which runs after the request is done
+const usageNudgeTimeout = 10 * time.Second
+
+// UsageWarningService raises an upsell event when a user reaches a usage
+// threshold of their plan and optionally nudges them on Telegram with a
+// link to upgrade
+type UsageWarningService struct {
+	pool       *pgxpool.Pool
+	telegram   *telegram.Client
+	miniAppURL string
+	nudges     bool
+	logger     *slog.Logger
+}
+
+// NewUsageWarningService creates a new UsageWarningService; nudges
+// enables the Telegram messages
+func NewUsageWarningService(pool *pgxpool.Pool, telegramClient *telegram.Client, miniAppURL string, nudges bool, logger *slog.Logger) *UsageWarningService {
+	return &UsageWarningService{
+		pool:       pool,
+		telegram:   telegramClient,
+		miniAppURL: miniAppURL,
+		nudges:     nudges,
+		logger:     logger,
+	}
+}
+
+// UsagePercent returns used as a percentage of limit, 100 for a limit of 0
+func UsagePercent(used int64, limit int) float64 {
+	if limit <= 0 {
+		return 100
+	}
+	return float64(used) * 100 / float64(limit)
+}
+
+// UsageThreshold returns the highest of UsageThresholds that used reaches,
+// 0 below all of them
+func UsageThreshold(used int64, limit int) int {
+	pct := UsagePercent(used, limit)
+	reached := 0
+	for _, threshold := range UsageThresholds {
+		if pct >= float64(threshold) {
+			reached = threshold
+		}
+	}
+	return reached
+}
+
+// Check raises the event of the threshold user reaches with used coins or
+// alerts of resource, unless it was raised for the same limit before.
+// Failures are logged, never returned
+func (s *UsageWarningService) Check(ctx context.Context, user *UserWithLimits, resource string, used int64) {
+	if s == nil {
+		return
+	}
+
+	limit := user.MaxCoins
+	if resource == UsageResourceAlerts {
+		limit = user.MaxAlerts
+	}
+	threshold := UsageThreshold(used, limit)
+	if threshold == 0 {
+		return
+	}
+
+	// Only the call that records the event nudges
+	var id int64
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO usage_warnings (user_id, resource, threshold, used, plan_limit, plan)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, resource, threshold, plan_limit) DO NOTHING
+		RETURNING id
+	`, user.ID, resource, threshold, used, limit, user.Plan).Scan(&id)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			s.logger.Error("failed to record usage warning",
+				slog.Int64("user_id", user.ID),
+				slog.String("resource", resource),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	s.logger.Info("usage threshold reached",
+		slog.Int64("user_id", user.ID),
+		slog.String("resource", resource),
+		slog.Int("threshold", threshold),
+		slog.Int("limit", limit),
+	)
+
+	if !s.nudges || s.telegram == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageNudgeTimeout)
+		defer cancel()
+
+		if err := s.nudge(ctx, user, resource, threshold, used, limit); err != nil {
+			s.logger.Error("failed to send usage nudge",
+				slog.Int64("user_id", user.ID),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+
+		if _, err := s.pool.Exec(ctx, `UPDATE usage_warnings SET nudged_at = NOW() WHERE id = $1`, id); err != nil {
+			s.logger.Error("failed to mark usage nudge sent", slog.Int64("id", id), slog.String("error", err.Error()))
+		}
+	}()
+}
+
+// nudge tells a user how much of their plan they use, with a button to
+// upgrade
+func (s *UsageWarningService) nudge(ctx context.Context, user *UserWithLimits, resource string, threshold int, used int64, limit int) error {
+	noun := "coins in your watchlist"
+	if resource == UsageResourceAlerts {
+		noun = "alerts"
+	}
+
+	var text string
+	if threshold >= 100 {
+		text = fmt.Sprintf(
+			"🚦 <b>You've reached your plan's limit</b>\n\nYou have %d of %d %s on the %s plan. Upgrade to add more.",
+			used, limit, noun, html.EscapeString(user.Plan),
+		)
+	} else {
+		text = fmt.Sprintf(
+			"📈 <b>Almost at your plan's limit</b>\n\nYou have %d of %d %s on the %s plan. Upgrade for more room.",
+			used, limit, noun, html.EscapeString(user.Plan),
+		)
+	}
+
+	var replyMarkup *telegram.InlineKeyboardMarkup
+	if link := upgradeURL(s.miniAppURL, resource); link != "" {
+		replyMarkup = &telegram.InlineKeyboardMarkup{
+			InlineKeyboard: [][]telegram.InlineKeyboardButton{
+				{
+					{Text: "⭐ Upgrade plan", URL: link},
+				},
+			},
+		}
+	}
+
+	_, err := s.telegram.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:                user.TelegramID,
+		Text:                  text,
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+		ReplyMarkup:           replyMarkup,
+	})
+	return err
+}
+
+// upgradeURL returns the Mini App deep link opening the upgrade screen,
+// e.g. https://t.me/bot/app?startapp=upgrade_coins. Empty without a Mini
+// App URL
+func upgradeURL(miniAppURL, resource string) string {
+	if miniAppURL == "" {
+		return ""
+	}
+	u, err := url.Parse(miniAppURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("startapp", upgradeStartParam+resource)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageThreshold(t *testing.T) {
+	tests := []struct {
+		used  int64
+		limit int
+		want  int
+	}{
+		{used: 0, limit: 10, want: 0},
+		{used: 7, limit: 10, want: 0},
+		{used: 8, limit: 10, want: 80},
+		{used: 9, limit: 10, want: 80},
+		{used: 10, limit: 10, want: 100},
+		{used: 12, limit: 10, want: 100}, // over the limit after a downgrade
+		{used: 0, limit: 0, want: 100},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, UsageThreshold(tt.used, tt.limit), "%d of %d", tt.used, tt.limit)
+	}
+}
+
+func TestUpgradeURL(t *testing.T) {
+	assert.Equal(t, "https://t.me/weqory_bot/app?startapp=upgrade_alerts", upgradeURL("https://t.me/weqory_bot/app", UsageResourceAlerts))
+	assert.Empty(t, upgradeURL("", UsageResourceCoins))
+}
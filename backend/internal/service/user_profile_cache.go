@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis key of a user's cached profile; + userID
+	userProfileCacheKey = "user:profile:"
+
+	// Bounds how stale a profile gets through changes that do not
+	// invalidate it, such as notifications counted by the notification
+	// service
+	userProfileCacheTTL = 30 * time.Second
+)
+
+// UserProfileCache caches the profiles served by GET /users/me: the user
+// with their plan limits and usage, which take joining the plan and
+// reading the usage counters. Services that change what a profile shows
+// invalidate it.
+// All methods are no-ops on a nil cache, and cache failures only cost a
+// database query
+type UserProfileCache struct {
+	redis  *redis.Client
+	logger *slog.Logger
+}
+
+// NewUserProfileCache creates a new UserProfileCache
+func NewUserProfileCache(client *redis.Client, logger *slog.Logger) *UserProfileCache {
+	return &UserProfileCache{
+		redis:  client,
+		logger: logger,
+	}
+}
+
+func userProfileKey(userID int64) string {
+	return userProfileCacheKey + strconv.FormatInt(userID, 10)
+}
+
+// Get returns the cached profile of a user, or nil when there is none
+func (c *UserProfileCache) Get(ctx context.Context, userID int64) *UserWithLimits {
+	if c == nil {
+		return nil
+	}
+
+	data, err := c.redis.Get(ctx, userProfileKey(userID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			c.logger.Warn("failed to read cached user profile",
+				slog.Int64("user_id", userID),
+				slog.String("error", err.Error()),
+			)
+		}
+		return nil
+	}
+
+	var user UserWithLimits
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil
+	}
+	return &user
+}
+
+// Set caches the profile of a user
+func (c *UserProfileCache) Set(ctx context.Context, user *UserWithLimits) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return
+	}
+	if err := c.redis.Set(ctx, userProfileKey(user.ID), data, userProfileCacheTTL).Err(); err != nil {
+		c.logger.Warn("failed to cache user profile",
+			slog.Int64("user_id", user.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// Invalidate drops the cached profile of a user, so the next request sees
+// a change made to it
+func (c *UserProfileCache) Invalidate(ctx context.Context, userID int64) {
+	if c == nil {
+		return
+	}
+
+	if err := c.redis.Del(ctx, userProfileKey(userID)).Err(); err != nil {
+		c.logger.Warn("failed to invalidate cached user profile",
+			slog.Int64("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
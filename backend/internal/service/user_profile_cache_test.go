@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProfileCache(t *testing.T) (*UserProfileCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewUserProfileCache(client, slog.New(slog.NewTextHandler(io.Discard, nil))), mr
+}
+
+func TestUserProfileCache(t *testing.T) {
+	ctx := context.Background()
+	cache, mr := newTestProfileCache(t)
+
+	assert.Nil(t, cache.Get(ctx, 1))
+
+	cache.Set(ctx, &UserWithLimits{User: User{ID: 1, Plan: "pro"}, MaxCoins: 50, CoinsUsed: 3})
+	user := cache.Get(ctx, 1)
+	require.NotNil(t, user)
+	assert.Equal(t, "pro", user.Plan)
+	assert.Equal(t, 50, user.MaxCoins)
+	assert.Equal(t, int64(3), user.CoinsUsed)
+	assert.Equal(t, userProfileCacheTTL, mr.TTL(userProfileKey(1)))
+
+	cache.Invalidate(ctx, 1)
+	assert.Nil(t, cache.Get(ctx, 1))
+
+	// A nil cache is a no-op
+	var none *UserProfileCache
+	none.Set(ctx, user)
+	none.Invalidate(ctx, 1)
+	assert.Nil(t, none.Get(ctx, 1))
+}
+
+func TestAlertService_DeleteInvalidatesProfile(t *testing.T) {
+	ctx := context.Background()
+	svc, alerts, _ := newTestAlertService(t)
+	cache, _ := newTestProfileCache(t)
+	svc.SetProfileCache(cache)
+
+	cache.Set(ctx, &UserWithLimits{User: User{ID: 1}, AlertsUsed: 2})
+	alerts.On("GetOwner", ctx, int64(5)).Return(int64(1), true, nil)
+	alerts.On("Delete", ctx, int64(5)).Return(nil)
+
+	require.NoError(t, svc.Delete(ctx, 1, 5))
+	assert.Nil(t, cache.Get(ctx, 1))
+}
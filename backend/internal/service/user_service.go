@@ -2,17 +2,21 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/rbac"
 	"github.com/weqory/backend/pkg/crypto"
 	"github.com/weqory/backend/pkg/errors"
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	pool *pgxpool.Pool
+	pool       *pgxpool.Pool
+	onboarding *OnboardingService
+	profiles   *UserProfileCache
 }
 
 // NewUserService creates a new UserService
@@ -20,6 +24,17 @@ func NewUserService(pool *pgxpool.Pool) *UserService {
 	return &UserService{pool: pool}
 }
 
+// SetOnboarding makes turning notifications on complete the onboarding step
+func (s *UserService) SetOnboarding(onboarding *OnboardingService) {
+	s.onboarding = onboarding
+}
+
+// SetProfileCache makes GetProfile serve profiles from cache and user
+// changes invalidate them
+func (s *UserService) SetProfileCache(profiles *UserProfileCache) {
+	s.profiles = profiles
+}
+
 // User represents a user from the database
 type User struct {
 	ID                   int64
@@ -31,13 +46,25 @@ type User struct {
 	Plan                 string
 	PlanExpiresAt        *time.Time
 	PlanPeriod           *string
+	ScheduledPlan        *string // plan taken at PlanExpiresAt instead of renewing
 	NotificationsUsed    int
 	NotificationsResetAt *time.Time
 	NotificationsEnabled bool
+	UnreachableReason    *string // why notifications were turned off when the bot cannot reach the user
 	VibrationEnabled     bool
+	Timezone             string // IANA name, e.g. Europe/Berlin
+	AlertsMutedUntil     *time.Time
+	Role                 rbac.Role
+	MessageFormat        string // detailed or compact alert messages
+	IncludeChart         bool   // attach a 24h price chart to alerts
+	SilentAtNight        bool   // no sound for alerts at night in Timezone
+	LanguageManual       bool   // LanguageCode was set in the app, not taken from Telegram
+	NumberFormat         string // number format of prices in messages, see telegram.NumberFormat*
+	AllowStablecoins     bool   // stablecoins can be added to the watchlist, e.g. to watch for depegs
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
 	LastActiveAt         time.Time
+	Version              int // counts changes to settings and locale through the API
 }
 
 // UserWithLimits includes user data with plan limits
@@ -47,6 +74,8 @@ type UserWithLimits struct {
 	MaxAlerts            int
 	MaxNotifications     *int
 	HistoryRetentionDays int
+	PriceHistoryDays     int
+	AlertCharts          bool // charts can be sent with alerts
 	CoinsUsed            int64
 	AlertsUsed           int64
 }
@@ -55,20 +84,22 @@ type UserWithLimits struct {
 func (s *UserService) GetByID(ctx context.Context, id int64) (*User, error) {
 	query := `
 		SELECT id, telegram_id, username, first_name, last_name, language_code,
-		       plan, plan_expires_at, plan_period,
+		       plan, plan_expires_at, plan_period, scheduled_plan,
 		       notifications_used, notifications_reset_at,
-		       notifications_enabled, vibration_enabled,
-		       created_at, updated_at, last_active_at
+		       notifications_enabled, notifications_disabled_reason, vibration_enabled, timezone, alerts_muted_until, role,
+		       message_format, include_chart, silent_at_night, language_manual, number_format, allow_stablecoins,
+		       created_at, updated_at, last_active_at, version
 		FROM users WHERE id = $1
 	`
 
 	var user User
 	err := s.pool.QueryRow(ctx, query, id).Scan(
 		&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName,
-		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod,
+		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod, &user.ScheduledPlan,
 		&user.NotificationsUsed, &user.NotificationsResetAt,
-		&user.NotificationsEnabled, &user.VibrationEnabled,
-		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt,
+		&user.NotificationsEnabled, &user.UnreachableReason, &user.VibrationEnabled, &user.Timezone, &user.AlertsMutedUntil, &user.Role,
+		&user.MessageFormat, &user.IncludeChart, &user.SilentAtNight, &user.LanguageManual, &user.NumberFormat, &user.AllowStablecoins,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt, &user.Version,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -84,20 +115,22 @@ func (s *UserService) GetByID(ctx context.Context, id int64) (*User, error) {
 func (s *UserService) GetByTelegramID(ctx context.Context, telegramID int64) (*User, error) {
 	query := `
 		SELECT id, telegram_id, username, first_name, last_name, language_code,
-		       plan, plan_expires_at, plan_period,
+		       plan, plan_expires_at, plan_period, scheduled_plan,
 		       notifications_used, notifications_reset_at,
-		       notifications_enabled, vibration_enabled,
-		       created_at, updated_at, last_active_at
+		       notifications_enabled, notifications_disabled_reason, vibration_enabled, timezone, alerts_muted_until, role,
+		       message_format, include_chart, silent_at_night, language_manual, number_format, allow_stablecoins,
+		       created_at, updated_at, last_active_at, version
 		FROM users WHERE telegram_id = $1
 	`
 
 	var user User
 	err := s.pool.QueryRow(ctx, query, telegramID).Scan(
 		&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName,
-		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod,
+		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod, &user.ScheduledPlan,
 		&user.NotificationsUsed, &user.NotificationsResetAt,
-		&user.NotificationsEnabled, &user.VibrationEnabled,
-		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt,
+		&user.NotificationsEnabled, &user.UnreachableReason, &user.VibrationEnabled, &user.Timezone, &user.AlertsMutedUntil, &user.Role,
+		&user.MessageFormat, &user.IncludeChart, &user.SilentAtNight, &user.LanguageManual, &user.NumberFormat, &user.AllowStablecoins,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt, &user.Version,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -120,7 +153,8 @@ func (s *UserService) GetOrCreateByTelegramID(ctx context.Context, tgUser *crypt
 			username = COALESCE($2, users.username),
 			first_name = COALESCE($3, users.first_name),
 			last_name = COALESCE($4, users.last_name),
-			language_code = COALESCE($5, users.language_code),
+			language_code = CASE WHEN users.language_manual THEN users.language_code
+			                     ELSE COALESCE($5, users.language_code) END,
 			last_active_at = NOW()
 		RETURNING id
 	`, tgUser.ID, nilIfEmpty(tgUser.Username), tgUser.FirstName, nilIfEmpty(tgUser.LastName), tgUser.LanguageCode).Scan(&userID)
@@ -128,21 +162,43 @@ func (s *UserService) GetOrCreateByTelegramID(ctx context.Context, tgUser *crypt
 		return nil, errors.Wrap(err, errors.ErrDatabase)
 	}
 
+	// Logging in may have changed the names and language
+	s.profiles.Invalidate(ctx, userID)
+
 	return s.GetWithLimits(ctx, userID)
 }
 
-// GetWithLimits retrieves user with plan limits and usage
+// GetProfile returns the user with plan limits and usage as shown on the
+// profile, from cache when possible. Limit checks must use GetWithLimits,
+// as the cached usage may be a few seconds old
+func (s *UserService) GetProfile(ctx context.Context, userID int64) (*UserWithLimits, error) {
+	if user := s.profiles.Get(ctx, userID); user != nil {
+		return user, nil
+	}
+
+	user, err := s.GetWithLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.profiles.Set(ctx, user)
+
+	return user, nil
+}
+
+// GetWithLimits retrieves user with plan limits and usage. Usage comes from
+// the counters kept by database triggers, see ReconcileUsageCounters
 func (s *UserService) GetWithLimits(ctx context.Context, userID int64) (*UserWithLimits, error) {
 	query := `
 		SELECT
 			u.id, u.telegram_id, u.username, u.first_name, u.last_name, u.language_code,
-			u.plan, u.plan_expires_at, u.plan_period,
+			u.plan, u.plan_expires_at, u.plan_period, u.scheduled_plan,
 			u.notifications_used, u.notifications_reset_at,
-			u.notifications_enabled, u.vibration_enabled,
-			u.created_at, u.updated_at, u.last_active_at,
+			u.notifications_enabled, u.notifications_disabled_reason, u.vibration_enabled, u.timezone, u.alerts_muted_until, u.role,
+			u.message_format, u.include_chart, u.silent_at_night, u.language_manual, u.number_format, u.allow_stablecoins,
+			u.created_at, u.updated_at, u.last_active_at, u.version,
 			sp.max_coins, sp.max_alerts, sp.max_notifications, sp.history_retention_days,
-			(SELECT COUNT(*) FROM watchlist w WHERE w.user_id = u.id AND EXISTS (SELECT 1 FROM coins c WHERE c.id = w.coin_id)) as coins_used,
-			(SELECT COUNT(*) FROM alerts a WHERE a.user_id = u.id AND EXISTS (SELECT 1 FROM coins c WHERE c.id = a.coin_id)) as alerts_used
+			sp.price_history_days, sp.alert_charts,
+			u.coins_used, u.alerts_used
 		FROM users u
 		JOIN subscription_plans sp ON sp.name = u.plan
 		WHERE u.id = $1
@@ -151,11 +207,13 @@ func (s *UserService) GetWithLimits(ctx context.Context, userID int64) (*UserWit
 	var user UserWithLimits
 	err := s.pool.QueryRow(ctx, query, userID).Scan(
 		&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName,
-		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod,
+		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod, &user.ScheduledPlan,
 		&user.NotificationsUsed, &user.NotificationsResetAt,
-		&user.NotificationsEnabled, &user.VibrationEnabled,
-		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt,
+		&user.NotificationsEnabled, &user.UnreachableReason, &user.VibrationEnabled, &user.Timezone, &user.AlertsMutedUntil, &user.Role,
+		&user.MessageFormat, &user.IncludeChart, &user.SilentAtNight, &user.LanguageManual, &user.NumberFormat, &user.AllowStablecoins,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt, &user.Version,
 		&user.MaxCoins, &user.MaxAlerts, &user.MaxNotifications, &user.HistoryRetentionDays,
+		&user.PriceHistoryDays, &user.AlertCharts,
 		&user.CoinsUsed, &user.AlertsUsed,
 	)
 	if err != nil {
@@ -168,36 +226,226 @@ func (s *UserService) GetWithLimits(ctx context.Context, userID int64) (*UserWit
 	return &user, nil
 }
 
-// UpdateSettings updates user settings
-func (s *UserService) UpdateSettings(ctx context.Context, userID int64, notificationsEnabled, vibrationEnabled *bool) (*User, error) {
+// Formats of alert messages
+const (
+	MessageFormatDetailed = "detailed"
+	MessageFormatCompact  = "compact"
+)
+
+// UpdateSettingsParams holds the settings to change; nil fields are kept
+type UpdateSettingsParams struct {
+	NotificationsEnabled *bool
+	VibrationEnabled     *bool
+	Timezone             *string
+	MessageFormat        *string
+	IncludeChart         *bool
+	SilentAtNight        *bool
+	AllowStablecoins     *bool
+	Version              *int // the change applies only at this version when set
+}
+
+// UpdateSettings updates user settings. Settings and locale changes bump
+// the user's version; with params.Version set, a user whose settings
+// changed since the client read them is left alone and ErrVersionConflict
+// is returned
+func (s *UserService) UpdateSettings(ctx context.Context, userID int64, params UpdateSettingsParams) (*User, error) {
 	query := `
 		UPDATE users SET
 			notifications_enabled = COALESCE($2, notifications_enabled),
+			notifications_disabled_reason = CASE WHEN $2::boolean IS NULL THEN notifications_disabled_reason END,
+			notifications_disabled_at = CASE WHEN $2::boolean IS NULL THEN notifications_disabled_at END,
 			vibration_enabled = COALESCE($3, vibration_enabled),
+			timezone = COALESCE($4, timezone),
+			timezone_manual = timezone_manual OR $4::varchar IS NOT NULL,
+			message_format = COALESCE($5, message_format),
+			include_chart = COALESCE($6, include_chart),
+			silent_at_night = COALESCE($7, silent_at_night),
+			allow_stablecoins = COALESCE($9, allow_stablecoins),
+			version = version + 1,
 			updated_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND ($8::int IS NULL OR version = $8)
 		RETURNING id, telegram_id, username, first_name, last_name, language_code,
-		          plan, plan_expires_at, plan_period,
+		          plan, plan_expires_at, plan_period, scheduled_plan,
 		          notifications_used, notifications_reset_at,
-		          notifications_enabled, vibration_enabled,
-		          created_at, updated_at, last_active_at
+		          notifications_enabled, notifications_disabled_reason, vibration_enabled, timezone, alerts_muted_until, role,
+		          message_format, include_chart, silent_at_night, language_manual, number_format, allow_stablecoins,
+		          created_at, updated_at, last_active_at, version
 	`
 
 	var user User
-	err := s.pool.QueryRow(ctx, query, userID, notificationsEnabled, vibrationEnabled).Scan(
+	err := s.pool.QueryRow(ctx, query, userID, params.NotificationsEnabled, params.VibrationEnabled, params.Timezone,
+		params.MessageFormat, params.IncludeChart, params.SilentAtNight, params.Version, params.AllowStablecoins,
+	).Scan(
 		&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName,
-		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod,
+		&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod, &user.ScheduledPlan,
 		&user.NotificationsUsed, &user.NotificationsResetAt,
-		&user.NotificationsEnabled, &user.VibrationEnabled,
-		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt,
+		&user.NotificationsEnabled, &user.UnreachableReason, &user.VibrationEnabled, &user.Timezone, &user.AlertsMutedUntil, &user.Role,
+		&user.MessageFormat, &user.IncludeChart, &user.SilentAtNight, &user.LanguageManual, &user.NumberFormat, &user.AllowStablecoins,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt, &user.Version,
 	)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, s.settingsUpdateMissed(ctx, userID)
+		}
 		return nil, errors.Wrap(err, errors.ErrDatabase)
 	}
+	s.profiles.Invalidate(ctx, userID)
+
+	if params.NotificationsEnabled != nil && *params.NotificationsEnabled {
+		s.onboarding.CompleteStep(ctx, userID, OnboardingStepEnabledNotifications)
+	}
 
 	return &user, nil
 }
 
+// settingsUpdateMissed tells why a settings or locale update changed no
+// row: the user is missing or no longer at the version the update expected
+func (s *UserService) settingsUpdateMissed(ctx context.Context, userID int64) error {
+	if _, err := s.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	return errors.ErrVersionConflict
+}
+
+// DetectTimezone stores the timezone reported by the Mini App unless the
+// user picked one in settings. Returns whether it was changed
+func (s *UserService) DetectTimezone(ctx context.Context, userID int64, timezone string) (bool, error) {
+	result, err := s.pool.Exec(ctx, `
+		UPDATE users SET timezone = $2, updated_at = NOW()
+		WHERE id = $1 AND NOT timezone_manual AND timezone <> $2
+	`, userID, timezone)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+	s.profiles.Invalidate(ctx, userID)
+	return true, nil
+}
+
+// UpdateLocaleParams holds the locale settings to change; nil fields are
+// kept. An empty Language goes back to following Telegram's language
+type UpdateLocaleParams struct {
+	Language     *string
+	NumberFormat *string
+	Version      *int // the change applies only at this version when set
+}
+
+// UpdateLocale sets the language and number format of a user's messages.
+// A language set here is no longer overwritten by Telegram's on login
+func (s *UserService) UpdateLocale(ctx context.Context, userID int64, params UpdateLocaleParams) (*User, error) {
+	var language *string
+	if params.Language != nil {
+		l := strings.ToLower(*params.Language)
+		language = &l
+	}
+
+	result, err := s.pool.Exec(ctx, `
+		UPDATE users SET
+			language_code = CASE WHEN COALESCE($2::varchar, '') = '' THEN language_code ELSE $2 END,
+			language_manual = CASE WHEN $2::varchar IS NULL THEN language_manual ELSE $2 <> '' END,
+			number_format = COALESCE($3, number_format),
+			version = version + 1,
+			updated_at = NOW()
+		WHERE id = $1 AND ($4::int IS NULL OR version = $4)
+	`, userID, language, params.NumberFormat, params.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, s.settingsUpdateMissed(ctx, userID)
+	}
+	s.profiles.Invalidate(ctx, userID)
+
+	return s.GetByID(ctx, userID)
+}
+
+// MaxAlertMute is the longest a user can mute all alerts for
+const MaxAlertMute = 7 * 24 * time.Hour
+
+// AlertsMuted reports whether the user muted all alerts until after now
+func (u *User) AlertsMuted(now time.Time) bool {
+	return u.AlertsMutedUntil != nil && u.AlertsMutedUntil.After(now)
+}
+
+// MuteAlerts stops all alert notifications of a user for the given
+// duration, capped at MaxAlertMute. Alerts keep triggering and are recorded
+// in history; only the Telegram messages are held back
+func (s *UserService) MuteAlerts(ctx context.Context, userID int64, duration time.Duration) (time.Time, error) {
+	until := time.Now().Add(min(duration, MaxAlertMute))
+
+	result, err := s.pool.Exec(ctx, `
+		UPDATE users SET alerts_muted_until = $2, updated_at = NOW() WHERE id = $1
+	`, userID, until)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errors.ErrDatabase)
+	}
+	if result.RowsAffected() == 0 {
+		return time.Time{}, errors.ErrUserNotFound
+	}
+	s.profiles.Invalidate(ctx, userID)
+
+	return until, nil
+}
+
+// MuteAlertsByTelegramID is MuteAlerts for a user known by Telegram ID, as
+// when muting from a button on an alert message
+func (s *UserService) MuteAlertsByTelegramID(ctx context.Context, telegramID int64, duration time.Duration) (*User, error) {
+	var userID int64
+	err := s.pool.QueryRow(ctx, `SELECT id FROM users WHERE telegram_id = $1`, telegramID).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if _, err := s.MuteAlerts(ctx, userID, duration); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, userID)
+}
+
+// UnmuteAlerts resumes alert notifications muted with MuteAlerts
+func (s *UserService) UnmuteAlerts(ctx context.Context, userID int64) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE users SET alerts_muted_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND alerts_muted_until IS NOT NULL
+	`, userID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+	return nil
+}
+
+// ReachableAgain turns notifications back on for the user with the given
+// Telegram ID if they were turned off because the bot could not reach
+// them, e.g. after the user unblocked the bot and sent /start. Returns
+// whether they were turned on
+func (s *UserService) ReachableAgain(ctx context.Context, telegramID int64) (bool, error) {
+	var userID int64
+	err := s.pool.QueryRow(ctx, `
+		UPDATE users SET
+			notifications_enabled = true,
+			notifications_disabled_reason = NULL,
+			notifications_disabled_at = NULL,
+			updated_at = NOW()
+		WHERE telegram_id = $1 AND notifications_disabled_reason IS NOT NULL
+		RETURNING id
+	`, telegramID).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+	return true, nil
+}
+
 // CheckAndDowngradeExpiredPlan checks if user's plan has expired and downgrades to standard
 // Returns true if plan was downgraded, false otherwise
 func (s *UserService) CheckAndDowngradeExpiredPlan(ctx context.Context, userID int64) (bool, error) {
@@ -259,6 +507,7 @@ func (s *UserService) DowngradePlan(ctx context.Context, userID int64) error {
 			plan = 'standard',
 			plan_expires_at = NULL,
 			plan_period = NULL,
+			scheduled_plan = NULL,
 			updated_at = NOW()
 		WHERE id = $1
 	`, userID)
@@ -307,17 +556,22 @@ func (s *UserService) DowngradePlan(ctx context.Context, userID int64) error {
 		return errors.Wrap(err, errors.ErrDatabase)
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+	return nil
 }
 
 // GetExpiredPlanUsers returns users whose plans have expired
 func (s *UserService) GetExpiredPlanUsers(ctx context.Context) ([]User, error) {
 	query := `
 		SELECT id, telegram_id, username, first_name, last_name, language_code,
-		       plan, plan_expires_at, plan_period,
+		       plan, plan_expires_at, plan_period, scheduled_plan,
 		       notifications_used, notifications_reset_at,
-		       notifications_enabled, vibration_enabled,
-		       created_at, updated_at, last_active_at
+		       notifications_enabled, notifications_disabled_reason, vibration_enabled, timezone, alerts_muted_until, role,
+		       message_format, include_chart, silent_at_night, language_manual, number_format, allow_stablecoins,
+		       created_at, updated_at, last_active_at, version
 		FROM users
 		WHERE plan != 'standard'
 		  AND plan_expires_at IS NOT NULL
@@ -335,10 +589,11 @@ func (s *UserService) GetExpiredPlanUsers(ctx context.Context) ([]User, error) {
 		var user User
 		err := rows.Scan(
 			&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName,
-			&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod,
+			&user.LanguageCode, &user.Plan, &user.PlanExpiresAt, &user.PlanPeriod, &user.ScheduledPlan,
 			&user.NotificationsUsed, &user.NotificationsResetAt,
-			&user.NotificationsEnabled, &user.VibrationEnabled,
-			&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt,
+			&user.NotificationsEnabled, &user.UnreachableReason, &user.VibrationEnabled, &user.Timezone, &user.AlertsMutedUntil, &user.Role,
+			&user.MessageFormat, &user.IncludeChart, &user.SilentAtNight, &user.LanguageManual, &user.NumberFormat, &user.AllowStablecoins,
+			&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt, &user.Version,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, errors.ErrDatabase)
@@ -364,6 +619,92 @@ func (s *UserService) ResetMonthlyNotifications(ctx context.Context) error {
 	return nil
 }
 
+// ReconcileUsageCounters corrects the coins_used and alerts_used counters
+// of users whose counters drifted from their watchlist and alerts, and
+// returns the IDs of the users it corrected
+func (s *UserService) ReconcileUsageCounters(ctx context.Context) ([]int64, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH actual AS (
+			SELECT u.id,
+				(SELECT COUNT(*) FROM watchlist w WHERE w.user_id = u.id) AS coins_used,
+				(SELECT COUNT(*) FROM alerts a WHERE a.user_id = u.id) AS alerts_used
+			FROM users u
+		)
+		UPDATE users u SET
+			coins_used = actual.coins_used,
+			alerts_used = actual.alerts_used
+		FROM actual
+		WHERE u.id = actual.id
+		  AND (u.coins_used <> actual.coins_used OR u.alerts_used <> actual.alerts_used)
+		RETURNING u.id
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	for _, id := range ids {
+		s.profiles.Invalidate(ctx, id)
+	}
+	return ids, nil
+}
+
+// SetRole changes the role of a user and returns the role they held before
+func (s *UserService) SetRole(ctx context.Context, userID int64, role rbac.Role) (rbac.Role, error) {
+	if !role.Valid() {
+		return "", errors.ErrValidationFailed.WithMessage("Unknown role")
+	}
+
+	var previous rbac.Role
+	err := s.pool.QueryRow(ctx, `
+		UPDATE users u SET role = $2, updated_at = NOW()
+		FROM users old
+		WHERE u.id = $1 AND old.id = u.id
+		RETURNING old.role
+	`, userID, role).Scan(&previous)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errors.ErrUserNotFound
+		}
+		return "", errors.Wrap(err, errors.ErrDatabase)
+	}
+	s.profiles.Invalidate(ctx, userID)
+
+	return previous, nil
+}
+
+// ListStaff returns the users holding a role above user
+func (s *UserService) ListStaff(ctx context.Context) ([]User, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, telegram_id, username, first_name, last_name, role
+		FROM users
+		WHERE role <> 'user'
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.TelegramID, &user.Username, &user.FirstName, &user.LastName, &user.Role); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return users, nil
+}
+
 func nilIfEmpty(s string) *string {
 	if s == "" {
 		return nil
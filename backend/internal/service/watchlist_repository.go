@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+)
+
+// coinColumns are scanned by scanCoin
+const coinColumns = `
+	id, symbol, name, binance_symbol, rank_by_market_cap,
+	current_price, market_cap, volume_24h, price_change_24h_pct`
+
+// pgWatchlistRepository is the WatchlistRepository on Postgres
+type pgWatchlistRepository struct {
+	pool *pgxpool.Pool
+}
+
+func (r *pgWatchlistRepository) CountByUser(ctx context.Context, userID int64, category string) (int64, error) {
+	var total int64
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM watchlist w
+		WHERE w.user_id = $1
+		  AND ($2 = '' OR EXISTS (
+			SELECT 1 FROM coin_categories cc WHERE cc.coin_id = w.coin_id AND cc.category_id = $2
+		  ))
+	`, userID, category).Scan(&total)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return total, nil
+}
+
+func (r *pgWatchlistRepository) ListByUser(ctx context.Context, userID int64, category string, page pagination.Page) ([]WatchlistItem, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			w.id, w.user_id, w.coin_id, `+textTime("w.created_at")+`,
+			c.id, c.symbol, c.name, c.binance_symbol,
+			c.rank_by_market_cap, c.current_price, c.market_cap,
+			c.volume_24h, c.price_change_24h_pct,
+			(SELECT COUNT(*) FROM alerts a WHERE a.user_id = w.user_id AND a.coin_id = w.coin_id) as alerts_count
+		FROM watchlist w
+		JOIN coins c ON c.id = w.coin_id
+		WHERE w.user_id = $1
+		  AND ($2 = '' OR EXISTS (
+			SELECT 1 FROM coin_categories cc WHERE cc.coin_id = c.id AND cc.category_id = $2
+		  ))
+		  AND ($3::text IS NULL OR (w.created_at, w.id) < ($3::timestamptz, $4::bigint))
+		ORDER BY w.created_at DESC, w.id DESC
+		LIMIT $5
+	`, userID, category, page.AfterKey(), page.AfterID(), page.FetchLimit())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var items []WatchlistItem
+	for rows.Next() {
+		var item WatchlistItem
+		err := rows.Scan(
+			&item.ID, &item.UserID, &item.CoinID, &item.CreatedAt,
+			&item.Coin.ID, &item.Coin.Symbol, &item.Coin.Name, &item.Coin.BinanceSymbol,
+			&item.Coin.Rank, &item.Coin.CurrentPrice, &item.Coin.MarketCap,
+			&item.Coin.Volume24h, &item.Coin.PriceChange24hPct,
+			&item.AlertsCount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return items, nil
+}
+
+func (r *pgWatchlistRepository) Contains(ctx context.Context, userID int64, coinID int) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM watchlist WHERE user_id = $1 AND coin_id = $2)
+	`, userID, coinID).Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return exists, nil
+}
+
+func (r *pgWatchlistRepository) Add(ctx context.Context, userID int64, coinID int) (*WatchlistItem, error) {
+	var item WatchlistItem
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO watchlist (user_id, coin_id)
+		VALUES ($1, $2)
+		RETURNING id, user_id, coin_id, `+textTime("created_at")+`
+	`, userID, coinID).Scan(&item.ID, &item.UserID, &item.CoinID, &item.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return &item, nil
+}
+
+func (r *pgWatchlistRepository) Remove(ctx context.Context, userID int64, coinID int) (int64, bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	// Delete alerts for this coin
+	var deletedAlerts int64
+	err = tx.QueryRow(ctx, `
+		WITH deleted AS (
+			DELETE FROM alerts WHERE user_id = $1 AND coin_id = $2
+			RETURNING id
+		)
+		SELECT COUNT(*) FROM deleted
+	`, userID, coinID).Scan(&deletedAlerts)
+	if err != nil {
+		return 0, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Delete from watchlist
+	result, err := tx.Exec(ctx, `
+		DELETE FROM watchlist WHERE user_id = $1 AND coin_id = $2
+	`, userID, coinID)
+	if err != nil {
+		return 0, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Nothing to commit; the rollback keeps the alerts
+	if result.RowsAffected() == 0 {
+		return 0, false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return deletedAlerts, true, nil
+}
+
+func (r *pgWatchlistRepository) DeleteByUser(ctx context.Context, userID int64) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer tx.Rollback(ctx)
+
+	// Delete all alerts first
+	_, err = tx.Exec(ctx, `DELETE FROM alerts WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Delete watchlist
+	result, err := tx.Exec(ctx, `DELETE FROM watchlist WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *pgWatchlistRepository) DeleteOrphaned(ctx context.Context, userID int64) error {
+	// Delete alerts referencing non-existent coins
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM alerts
+		WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM coins WHERE id = alerts.coin_id)
+	`, userID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	// Delete watchlist entries referencing non-existent coins
+	_, err = r.pool.Exec(ctx, `
+		DELETE FROM watchlist
+		WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM coins WHERE id = watchlist.coin_id)
+	`, userID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return nil
+}
+
+func (r *pgWatchlistRepository) GetCoinID(ctx context.Context, symbol string) (int, error) {
+	var coinID int
+	err := r.pool.QueryRow(ctx, `SELECT id FROM coins WHERE symbol = $1`, symbol).Scan(&coinID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.ErrCoinNotFound
+		}
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return coinID, nil
+}
+
+func (r *pgWatchlistRepository) GetWatchableCoin(ctx context.Context, symbol string, allowStablecoins bool) (*Coin, error) {
+	var coin Coin
+	err := scanCoin(r.pool.QueryRow(ctx, `
+		SELECT `+coinColumns+`
+		FROM coins
+		WHERE symbol = $1 AND is_active = true AND is_excluded = false
+		  AND ($2 OR is_stablecoin = false)
+	`, symbol, allowStablecoins), &coin)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.ErrCoinNotFound
+		}
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return &coin, nil
+}
+
+func (r *pgWatchlistRepository) SearchCoins(ctx context.Context, search, category string, limit int, includeStablecoins bool) ([]Coin, error) {
+	conditions := []string{"is_excluded = false", "is_active = true"}
+	if !includeStablecoins {
+		conditions = append(conditions, "is_stablecoin = false")
+	}
+	var args []interface{}
+
+	if search != "" {
+		args = append(args, "%"+strings.ToUpper(search)+"%")
+		n := strconv.Itoa(len(args))
+		conditions = append(conditions, "(UPPER(symbol) LIKE $"+n+" OR UPPER(name) LIKE $"+n+")")
+	} else {
+		conditions = append(conditions, "rank_by_market_cap IS NOT NULL")
+	}
+
+	if category != "" {
+		args = append(args, category)
+		conditions = append(conditions,
+			"EXISTS (SELECT 1 FROM coin_categories cc WHERE cc.coin_id = coins.id AND cc.category_id = $"+strconv.Itoa(len(args))+")")
+	}
+
+	args = append(args, limit)
+	query := `
+		SELECT ` + coinColumns + `
+		FROM coins
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY rank_by_market_cap ASC NULLS LAST
+		LIMIT $` + strconv.Itoa(len(args)) + `
+	`
+
+	return r.queryCoins(ctx, query, args...)
+}
+
+func (r *pgWatchlistRepository) GetCoinsBySymbols(ctx context.Context, symbols []string, limit int) ([]Coin, error) {
+	// Build placeholders for IN clause
+	placeholders := make([]string, len(symbols))
+	args := make([]interface{}, len(symbols)+1)
+	for i, sym := range symbols {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = strings.ToUpper(sym)
+	}
+	args[len(symbols)] = limit
+
+	query := `
+		SELECT ` + coinColumns + `
+		FROM coins
+		WHERE is_stablecoin = false AND is_excluded = false
+		  AND UPPER(symbol) IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY market_cap DESC NULLS LAST
+		LIMIT $` + strconv.Itoa(len(symbols)+1) + `
+	`
+
+	return r.queryCoins(ctx, query, args...)
+}
+
+func (r *pgWatchlistRepository) Export(ctx context.Context, userID int64, fn func(WatchlistExportRow) error) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT
+			c.id, c.symbol, c.name, c.binance_symbol,
+			c.rank_by_market_cap, c.current_price, c.market_cap,
+			c.volume_24h, c.price_change_24h_pct,
+			COALESCE(array_agg(a.alert_type ORDER BY a.id) FILTER (WHERE a.id IS NOT NULL), '{}'),
+			COALESCE(array_agg(a.condition_value ORDER BY a.id) FILTER (WHERE a.id IS NOT NULL), '{}'),
+			COALESCE(array_agg(COALESCE(a.quote_asset, '') ORDER BY a.id) FILTER (WHERE a.id IS NOT NULL), '{}')
+		FROM watchlist w
+		JOIN coins c ON c.id = w.coin_id
+		LEFT JOIN alerts a ON a.user_id = w.user_id AND a.coin_id = w.coin_id AND a.is_paused = false
+		WHERE w.user_id = $1
+		GROUP BY w.id, c.id
+		ORDER BY w.created_at DESC, w.id DESC
+	`, userID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row WatchlistExportRow
+		var types []string
+		var values []float64
+		var quotes []string
+		err := rows.Scan(
+			&row.Coin.ID, &row.Coin.Symbol, &row.Coin.Name, &row.Coin.BinanceSymbol,
+			&row.Coin.Rank, &row.Coin.CurrentPrice, &row.Coin.MarketCap,
+			&row.Coin.Volume24h, &row.Coin.PriceChange24hPct,
+			&types, &values, &quotes,
+		)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrDatabase)
+		}
+		for i := range types {
+			row.Alerts = append(row.Alerts, AlertCondition{Type: types[i], Value: values[i], Quote: quotes[i]})
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return nil
+}
+
+// queryCoins runs a query selecting coinColumns
+func (r *pgWatchlistRepository) queryCoins(ctx context.Context, query string, args ...interface{}) ([]Coin, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	coins := []Coin{}
+	for rows.Next() {
+		var coin Coin
+		if err := scanCoin(rows, &coin); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		coins = append(coins, coin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	return coins, nil
+}
+
+// scanCoin scans coinColumns into coin
+func scanCoin(row pgx.Row, coin *Coin) error {
+	return row.Scan(
+		&coin.ID, &coin.Symbol, &coin.Name, &coin.BinanceSymbol, &coin.Rank,
+		&coin.CurrentPrice, &coin.MarketCap, &coin.Volume24h, &coin.PriceChange24hPct,
+	)
+}
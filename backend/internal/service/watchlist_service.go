@@ -2,34 +2,55 @@ package service
 
 import (
 	"context"
-	"strconv"
+	"io"
 	"strings"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/pkg/alerttype"
 	"github.com/weqory/backend/pkg/errors"
+	"github.com/weqory/backend/pkg/pagination"
+	"github.com/weqory/backend/pkg/sheet"
 )
 
 // WatchlistService handles watchlist-related business logic
 type WatchlistService struct {
-	pool        *pgxpool.Pool
-	userService *UserService
+	watchlist   WatchlistRepository
+	userService PlanLimits
+	onboarding  *OnboardingService
+	usage       *UsageWarningService
+	profiles    *UserProfileCache
 }
 
 // NewWatchlistService creates a new WatchlistService
 func NewWatchlistService(pool *pgxpool.Pool, userService *UserService) *WatchlistService {
 	return &WatchlistService{
-		pool:        pool,
+		watchlist:   &pgWatchlistRepository{pool: pool},
 		userService: userService,
 	}
 }
 
+// SetOnboarding makes adding a coin complete the onboarding step
+func (s *WatchlistService) SetOnboarding(onboarding *OnboardingService) {
+	s.onboarding = onboarding
+}
+
+// SetUsageWarnings warns users nearing their plan's coin limit
+func (s *WatchlistService) SetUsageWarnings(usage *UsageWarningService) {
+	s.usage = usage
+}
+
+// SetProfileCache makes watchlist changes invalidate the cached profile,
+// which counts the coins used
+func (s *WatchlistService) SetProfileCache(profiles *UserProfileCache) {
+	s.profiles = profiles
+}
+
 // Coin represents a coin from the database
 type Coin struct {
 	ID               int
 	Symbol           string
 	Name             string
-	BinanceSymbol    string
+	BinanceSymbol    *string // nil for coins that are not traded on Binance
 	IsStablecoin     bool
 	Rank             *int
 	CurrentPrice     *float64
@@ -38,6 +59,20 @@ type Coin struct {
 	PriceChange24hPct *float64
 }
 
+// Price sources used to evaluate alerts
+const (
+	PriceSourceBinance   = "binance"
+	PriceSourceCoinGecko = "coingecko"
+)
+
+// PriceSource returns where live prices for the coin come from
+func (c *Coin) PriceSource() string {
+	if c.BinanceSymbol == nil {
+		return PriceSourceCoinGecko
+	}
+	return PriceSourceBinance
+}
+
 // WatchlistItem represents a watchlist item
 type WatchlistItem struct {
 	ID          int64
@@ -48,51 +83,25 @@ type WatchlistItem struct {
 	CreatedAt   string
 }
 
-// GetByUserID retrieves user's watchlist
-func (s *WatchlistService) GetByUserID(ctx context.Context, userID int64) ([]WatchlistItem, error) {
+// GetByUserID retrieves a page of the user's watchlist, newest first
+// category optionally restricts the result to coins in that category
+func (s *WatchlistService) GetByUserID(ctx context.Context, userID int64, category string, page pagination.Page) (*pagination.Result[WatchlistItem], error) {
 	// Cleanup orphaned entries first
 	_ = s.CleanupOrphanedEntries(ctx, userID)
 
-	query := `
-		SELECT
-			w.id, w.user_id, w.coin_id, w.created_at,
-			c.id, c.symbol, c.name, c.binance_symbol,
-			c.rank_by_market_cap, c.current_price, c.market_cap,
-			c.volume_24h, c.price_change_24h_pct,
-			(SELECT COUNT(*) FROM alerts a WHERE a.user_id = w.user_id AND a.coin_id = w.coin_id) as alerts_count
-		FROM watchlist w
-		JOIN coins c ON c.id = w.coin_id
-		WHERE w.user_id = $1
-		ORDER BY w.created_at DESC
-	`
-
-	rows, err := s.pool.Query(ctx, query, userID)
+	total, err := s.watchlist.CountByUser(ctx, userID, category)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
-	}
-	defer rows.Close()
-
-	var items []WatchlistItem
-	for rows.Next() {
-		var item WatchlistItem
-		err := rows.Scan(
-			&item.ID, &item.UserID, &item.CoinID, &item.CreatedAt,
-			&item.Coin.ID, &item.Coin.Symbol, &item.Coin.Name, &item.Coin.BinanceSymbol,
-			&item.Coin.Rank, &item.Coin.CurrentPrice, &item.Coin.MarketCap,
-			&item.Coin.Volume24h, &item.Coin.PriceChange24hPct,
-			&item.AlertsCount,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase)
-		}
-		items = append(items, item)
+		return nil, err
 	}
 
-	if items == nil {
-		items = []WatchlistItem{}
+	items, err := s.watchlist.ListByUser(ctx, userID, category, page)
+	if err != nil {
+		return nil, err
 	}
 
-	return items, nil
+	return pagination.NewResult(items, total, page, func(item WatchlistItem) pagination.Cursor {
+		return pagination.Cursor{Key: item.CreatedAt, ID: item.ID}
+	}), nil
 }
 
 // AddCoin adds a coin to user's watchlist
@@ -118,50 +127,35 @@ func (s *WatchlistService) AddCoin(ctx context.Context, userID int64, coinSymbol
 		)
 	}
 
-	// Get coin by symbol
-	var coin Coin
-	err = s.pool.QueryRow(ctx, `
-		SELECT id, symbol, name, binance_symbol, rank_by_market_cap,
-		       current_price, market_cap, volume_24h, price_change_24h_pct
-		FROM coins WHERE symbol = $1 AND is_stablecoin = false
-	`, coinSymbol).Scan(
-		&coin.ID, &coin.Symbol, &coin.Name, &coin.BinanceSymbol, &coin.Rank,
-		&coin.CurrentPrice, &coin.MarketCap, &coin.Volume24h, &coin.PriceChange24hPct,
-	)
+	// Get coin by symbol; stablecoins only for users who watch them for depegs
+	coin, err := s.watchlist.GetWatchableCoin(ctx, coinSymbol, user.AllowStablecoins)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, errors.ErrCoinNotFound
-		}
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		return nil, err
 	}
 
 	// Check if already in watchlist
-	var exists bool
-	err = s.pool.QueryRow(ctx, `
-		SELECT EXISTS(SELECT 1 FROM watchlist WHERE user_id = $1 AND coin_id = $2)
-	`, userID, coin.ID).Scan(&exists)
+	exists, err := s.watchlist.Contains(ctx, userID, coin.ID)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		return nil, err
 	}
 	if exists {
 		return nil, errors.ErrCoinInWatchlist
 	}
 
 	// Add to watchlist
-	var item WatchlistItem
-	err = s.pool.QueryRow(ctx, `
-		INSERT INTO watchlist (user_id, coin_id)
-		VALUES ($1, $2)
-		RETURNING id, user_id, coin_id, created_at
-	`, userID, coin.ID).Scan(&item.ID, &item.UserID, &item.CoinID, &item.CreatedAt)
+	item, err := s.watchlist.Add(ctx, userID, coin.ID)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
+		return nil, err
 	}
 
-	item.Coin = coin
+	item.Coin = *coin
 	item.AlertsCount = 0
 
-	return &item, nil
+	s.profiles.Invalidate(ctx, userID)
+	s.onboarding.CompleteStep(ctx, userID, OnboardingStepAddedFirstCoin)
+	s.usage.Check(ctx, user, UsageResourceCoins, user.CoinsUsed+1)
+
+	return item, nil
 }
 
 // RemoveCoin removes a coin from user's watchlist (and its alerts)
@@ -169,107 +163,30 @@ func (s *WatchlistService) RemoveCoin(ctx context.Context, userID int64, coinSym
 	coinSymbol = strings.ToUpper(strings.TrimSpace(coinSymbol))
 
 	// Get coin ID
-	var coinID int
-	err := s.pool.QueryRow(ctx, `SELECT id FROM coins WHERE symbol = $1`, coinSymbol).Scan(&coinID)
+	coinID, err := s.watchlist.GetCoinID(ctx, coinSymbol)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return 0, errors.ErrCoinNotFound
-		}
-		return 0, errors.Wrap(err, errors.ErrDatabase)
+		return 0, err
 	}
 
-	// Start transaction
-	tx, err := s.pool.Begin(ctx)
+	// Delete its alerts and the coin in one transaction
+	deletedAlerts, removed, err := s.watchlist.Remove(ctx, userID, coinID)
 	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
-	}
-	defer tx.Rollback(ctx)
-
-	// Delete alerts for this coin
-	var deletedAlerts int64
-	err = tx.QueryRow(ctx, `
-		WITH deleted AS (
-			DELETE FROM alerts WHERE user_id = $1 AND coin_id = $2
-			RETURNING id
-		)
-		SELECT COUNT(*) FROM deleted
-	`, userID, coinID).Scan(&deletedAlerts)
-	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
+		return 0, err
 	}
 
-	// Delete from watchlist
-	result, err := tx.Exec(ctx, `
-		DELETE FROM watchlist WHERE user_id = $1 AND coin_id = $2
-	`, userID, coinID)
-	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
-	}
-
-	if result.RowsAffected() == 0 {
+	if !removed {
 		return 0, errors.ErrNotFound.WithMessage("Coin not in watchlist")
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
-	}
+	s.profiles.Invalidate(ctx, userID)
 
 	return deletedAlerts, nil
 }
 
 // GetAvailableCoins returns coins that can be added to watchlist
-func (s *WatchlistService) GetAvailableCoins(ctx context.Context, search string, limit int) ([]Coin, error) {
-	var query string
-	var args []interface{}
-
-	if search != "" {
-		search = "%" + strings.ToUpper(search) + "%"
-		query = `
-			SELECT id, symbol, name, binance_symbol, rank_by_market_cap,
-			       current_price, market_cap, volume_24h, price_change_24h_pct
-			FROM coins
-			WHERE is_stablecoin = false
-			  AND (UPPER(symbol) LIKE $1 OR UPPER(name) LIKE $1)
-			ORDER BY rank_by_market_cap ASC NULLS LAST
-			LIMIT $2
-		`
-		args = []interface{}{search, limit}
-	} else {
-		query = `
-			SELECT id, symbol, name, binance_symbol, rank_by_market_cap,
-			       current_price, market_cap, volume_24h, price_change_24h_pct
-			FROM coins
-			WHERE is_stablecoin = false AND rank_by_market_cap IS NOT NULL
-			ORDER BY rank_by_market_cap ASC
-			LIMIT $1
-		`
-		args = []interface{}{limit}
-	}
-
-	rows, err := s.pool.Query(ctx, query, args...)
-	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
-	}
-	defer rows.Close()
-
-	var coins []Coin
-	for rows.Next() {
-		var coin Coin
-		err := rows.Scan(
-			&coin.ID, &coin.Symbol, &coin.Name, &coin.BinanceSymbol, &coin.Rank,
-			&coin.CurrentPrice, &coin.MarketCap, &coin.Volume24h, &coin.PriceChange24hPct,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase)
-		}
-		coins = append(coins, coin)
-	}
-
-	if coins == nil {
-		coins = []Coin{}
-	}
-
-	return coins, nil
+// category optionally restricts the result to coins in that category
+// includeStablecoins adds stablecoins, for users who allow them
+func (s *WatchlistService) GetAvailableCoins(ctx context.Context, search, category string, limit int, includeStablecoins bool) ([]Coin, error) {
+	return s.watchlist.SearchCoins(ctx, search, category, limit, includeStablecoins)
 }
 
 // GetCoinsBySymbols returns coins by their symbols with price data
@@ -278,97 +195,75 @@ func (s *WatchlistService) GetCoinsBySymbols(ctx context.Context, symbols []stri
 		return []Coin{}, nil
 	}
 
-	// Build placeholders for IN clause
-	placeholders := make([]string, len(symbols))
-	args := make([]interface{}, len(symbols)+1)
-	for i, sym := range symbols {
-		placeholders[i] = "$" + strconv.Itoa(i+1)
-		args[i] = strings.ToUpper(sym)
-	}
-	args[len(symbols)] = limit
-
-	query := `
-		SELECT id, symbol, name, binance_symbol, rank_by_market_cap,
-		       current_price, market_cap, volume_24h, price_change_24h_pct
-		FROM coins
-		WHERE is_stablecoin = false
-		  AND UPPER(symbol) IN (` + strings.Join(placeholders, ", ") + `)
-		ORDER BY market_cap DESC NULLS LAST
-		LIMIT $` + strconv.Itoa(len(symbols)+1) + `
-	`
-
-	rows, err := s.pool.Query(ctx, query, args...)
-	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrDatabase)
-	}
-	defer rows.Close()
-
-	var coins []Coin
-	for rows.Next() {
-		var coin Coin
-		err := rows.Scan(
-			&coin.ID, &coin.Symbol, &coin.Name, &coin.BinanceSymbol, &coin.Rank,
-			&coin.CurrentPrice, &coin.MarketCap, &coin.Volume24h, &coin.PriceChange24hPct,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, errors.ErrDatabase)
-		}
-		coins = append(coins, coin)
-	}
-
-	if coins == nil {
-		coins = []Coin{}
-	}
-
-	return coins, nil
+	return s.watchlist.GetCoinsBySymbols(ctx, symbols, limit)
 }
 
 // CleanupOrphanedEntries removes watchlist and alert entries referencing non-existent coins
 func (s *WatchlistService) CleanupOrphanedEntries(ctx context.Context, userID int64) error {
-	// Delete alerts referencing non-existent coins
-	_, err := s.pool.Exec(ctx, `
-		DELETE FROM alerts
-		WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM coins WHERE id = alerts.coin_id)
-	`, userID)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
-	}
+	return s.watchlist.DeleteOrphaned(ctx, userID)
+}
 
-	// Delete watchlist entries referencing non-existent coins
-	_, err = s.pool.Exec(ctx, `
-		DELETE FROM watchlist
-		WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM coins WHERE id = watchlist.coin_id)
-	`, userID)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrDatabase)
-	}
+// WatchlistExportRow is a watched coin with its active alerts
+type WatchlistExportRow struct {
+	Coin   Coin
+	Alerts []AlertCondition
+}
 
-	return nil
+// AlertCondition is the condition of an alert
+type AlertCondition struct {
+	Type  string
+	Value float64
+	Quote string // quote asset of Value, empty for USD
 }
 
-// DeleteAllByUser deletes all watchlist items for a user
-func (s *WatchlistService) DeleteAllByUser(ctx context.Context, userID int64) (int64, error) {
-	tx, err := s.pool.Begin(ctx)
+// watchlistExportHeader names the columns of a watchlist export
+var watchlistExportHeader = []any{
+	"Symbol", "Name", "Price (USD)", "24h Change (%)", "Market Cap (USD)", "Active Alerts", "Alert Conditions",
+}
+
+// Export writes the watchlist of userID with current prices and active
+// alerts to w as a sheet.FormatCSV or sheet.FormatXLSX file, row by row
+func (s *WatchlistService) Export(ctx context.Context, userID int64, format string, w io.Writer) error {
+	out, err := sheet.New(format, w)
 	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
+		return errors.ErrValidationFailed.WithMessage(err.Error())
 	}
-	defer tx.Rollback(ctx)
 
-	// Delete all alerts first
-	_, err = tx.Exec(ctx, `DELETE FROM alerts WHERE user_id = $1`, userID)
-	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
+	if err := out.WriteRow(watchlistExportHeader...); err != nil {
+		return err
 	}
 
-	// Delete watchlist
-	result, err := tx.Exec(ctx, `DELETE FROM watchlist WHERE user_id = $1`, userID)
+	err = s.watchlist.Export(ctx, userID, func(row WatchlistExportRow) error {
+		conditions := make([]string, 0, len(row.Alerts))
+		for _, a := range row.Alerts {
+			if def, ok := alerttype.Lookup(a.Type); ok {
+				conditions = append(conditions, def.DescribeIn(a.Value, a.Quote))
+			}
+		}
+
+		return out.WriteRow(
+			row.Coin.Symbol,
+			row.Coin.Name,
+			row.Coin.CurrentPrice,
+			row.Coin.PriceChange24hPct,
+			row.Coin.MarketCap,
+			len(row.Alerts),
+			strings.Join(conditions, "; "),
+		)
+	})
 	if err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
+		return err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return 0, errors.Wrap(err, errors.ErrDatabase)
-	}
+	return out.Close()
+}
 
-	return result.RowsAffected(), nil
+// DeleteAllByUser deletes all watchlist items for a user
+func (s *WatchlistService) DeleteAllByUser(ctx context.Context, userID int64) (int64, error) {
+	deleted, err := s.watchlist.DeleteByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.profiles.Invalidate(ctx, userID)
+	return deleted, nil
 }
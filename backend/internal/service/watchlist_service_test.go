@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+func newTestWatchlistService(t *testing.T) (*WatchlistService, *mockWatchlistRepository, *mockPlanLimits) {
+	watchlist := newMockWatchlistRepository(t)
+	limits := newMockPlanLimits(t)
+	return &WatchlistService{watchlist: watchlist, userService: limits}, watchlist, limits
+}
+
+// withCoinsUsed makes the user of limits have used of max coins
+func withCoinsUsed(limits *mockPlanLimits, userID int64, used int64, max int) {
+	limits.On("CheckAndDowngradeExpiredPlan", mock.Anything, userID).Return(false, nil)
+	limits.On("GetWithLimits", mock.Anything, userID).Return(&UserWithLimits{
+		User:      User{ID: userID},
+		MaxCoins:  max,
+		CoinsUsed: used,
+	}, nil)
+}
+
+func TestWatchlistService_AddCoin(t *testing.T) {
+	ctx := context.Background()
+	svc, watchlist, limits := newTestWatchlistService(t)
+	withCoinsUsed(limits, 1, 3, 10)
+
+	coin := &Coin{ID: 7, Symbol: "ETH", Name: "Ethereum"}
+	watchlist.On("GetWatchableCoin", ctx, "ETH", false).Return(coin, nil)
+	watchlist.On("Contains", ctx, int64(1), 7).Return(false, nil)
+	watchlist.On("Add", ctx, int64(1), 7).Return(&WatchlistItem{ID: 5, UserID: 1, CoinID: 7}, nil)
+
+	item, err := svc.AddCoin(ctx, 1, "eth")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), item.ID)
+	assert.Equal(t, "Ethereum", item.Coin.Name)
+}
+
+func TestWatchlistService_AddCoin_AllowStablecoins(t *testing.T) {
+	ctx := context.Background()
+	svc, watchlist, limits := newTestWatchlistService(t)
+	limits.On("CheckAndDowngradeExpiredPlan", mock.Anything, int64(1)).Return(false, nil)
+	limits.On("GetWithLimits", mock.Anything, int64(1)).Return(&UserWithLimits{
+		User:     User{ID: 1, AllowStablecoins: true},
+		MaxCoins: 10,
+	}, nil)
+
+	watchlist.On("GetWatchableCoin", ctx, "USDT", true).Return(&Coin{ID: 3, Symbol: "USDT"}, nil)
+	watchlist.On("Contains", ctx, int64(1), 3).Return(false, nil)
+	watchlist.On("Add", ctx, int64(1), 3).Return(&WatchlistItem{ID: 9, UserID: 1, CoinID: 3}, nil)
+
+	item, err := svc.AddCoin(ctx, 1, "usdt")
+	require.NoError(t, err)
+	assert.Equal(t, "USDT", item.Coin.Symbol)
+}
+
+func TestWatchlistService_AddCoin_Rejected(t *testing.T) {
+	ctx := context.Background()
+
+	// Limit reached: nothing is looked up
+	svc, _, limits := newTestWatchlistService(t)
+	withCoinsUsed(limits, 1, 10, 10)
+	_, err := svc.AddCoin(ctx, 1, "ETH")
+	assert.ErrorIs(t, err, errors.ErrWatchlistLimitExceeded)
+
+	// Already watched: nothing is added
+	svc, watchlist, limits := newTestWatchlistService(t)
+	withCoinsUsed(limits, 1, 3, 10)
+	watchlist.On("GetWatchableCoin", ctx, "ETH", false).Return(&Coin{ID: 7}, nil)
+	watchlist.On("Contains", ctx, int64(1), 7).Return(true, nil)
+	_, err = svc.AddCoin(ctx, 1, "ETH")
+	assert.ErrorIs(t, err, errors.ErrCoinInWatchlist)
+	watchlist.AssertNotCalled(t, "Add", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWatchlistService_RemoveCoin(t *testing.T) {
+	ctx := context.Background()
+	svc, watchlist, _ := newTestWatchlistService(t)
+
+	watchlist.On("GetCoinID", ctx, "ETH").Return(7, nil)
+	watchlist.On("Remove", ctx, int64(1), 7).Return(int64(3), true, nil).Once()
+	deleted, err := svc.RemoveCoin(ctx, 1, " eth")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	// Not on the watchlist
+	watchlist.On("Remove", ctx, int64(1), 7).Return(int64(0), false, nil).Once()
+	_, err = svc.RemoveCoin(ctx, 1, "ETH")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+}
+
+func TestWatchlistService_GetCoinsBySymbols_Empty(t *testing.T) {
+	svc, _, _ := newTestWatchlistService(t)
+
+	// No query is made for an empty list
+	coins, err := svc.GetCoinsBySymbols(context.Background(), nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, coins)
+}
+
+func TestWatchlistService_Export(t *testing.T) {
+	ctx := context.Background()
+	svc, watchlist, _ := newTestWatchlistService(t)
+
+	price, change := 70000.0, -1.25
+	watchlist.On("Export", ctx, int64(1), mock.Anything).Return(func(_ context.Context, _ int64, fn func(WatchlistExportRow) error) error {
+		if err := fn(WatchlistExportRow{
+			Coin: Coin{Symbol: "BTC", Name: "Bitcoin", CurrentPrice: &price, PriceChange24hPct: &change},
+			Alerts: []AlertCondition{
+				{Type: "PRICE_ABOVE", Value: 75000},
+				{Type: "PRICE_CHANGE_PCT", Value: 5},
+			},
+		}); err != nil {
+			return err
+		}
+		return fn(WatchlistExportRow{Coin: Coin{Symbol: "ETH", Name: "Ethereum"}})
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.Export(ctx, 1, "csv", &buf))
+	assert.Equal(t, "Symbol,Name,Price (USD),24h Change (%),Market Cap (USD),Active Alerts,Alert Conditions\n"+
+		"BTC,Bitcoin,70000,-1.25,,2,above $75000; price change of 5%\n"+
+		"ETH,Ethereum,,,,0,\n", buf.String())
+
+	err := svc.Export(ctx, 1, "pdf", &buf)
+	assert.ErrorIs(t, err, errors.ErrValidationFailed)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/weqory/backend/internal/cache"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+// WatchlistSummaryService computes the aggregate view of a watchlist shown
+// in the home screen header
+type WatchlistSummaryService struct {
+	pool       *pgxpool.Pool
+	priceCache *cache.PriceCache
+	logger     *slog.Logger
+}
+
+// NewWatchlistSummaryService creates a new WatchlistSummaryService
+func NewWatchlistSummaryService(pool *pgxpool.Pool, priceCache *cache.PriceCache, logger *slog.Logger) *WatchlistSummaryService {
+	return &WatchlistSummaryService{
+		pool:       pool,
+		priceCache: priceCache,
+		logger:     logger,
+	}
+}
+
+// WatchlistSummary is the aggregate 24h performance of a watchlist
+type WatchlistSummary struct {
+	Coins int
+	// Coins with a known 24h change, which the figures below are based on
+	Priced int
+	// Equal-weighted average 24h change of the priced coins
+	AvgChange24hPct float64
+	Gainers         int
+	Losers          int
+	// Coin with the largest absolute 24h change; nil when nothing is priced
+	TopMover *WatchlistMover
+}
+
+// WatchlistMover is a coin of a watchlist with its 24h change
+type WatchlistMover struct {
+	Symbol       string
+	Price        float64
+	Change24hPct float64
+}
+
+// watchlistCoinChange is a watchlisted coin with its latest price data
+type watchlistCoinChange struct {
+	symbol    string
+	price     float64
+	change    float64
+	hasChange bool
+}
+
+// Summary computes the summary of the watchlist of userID. Binance coins
+// use live prices, falling back to the last synced values stored on the coin
+func (s *WatchlistSummaryService) Summary(ctx context.Context, userID int64) (*WatchlistSummary, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.symbol, c.binance_symbol, c.current_price, c.price_change_24h_pct
+		FROM watchlist w
+		JOIN coins c ON c.id = w.coin_id
+		WHERE w.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var coins []watchlistCoinChange
+	var binanceSymbols []*string
+	for rows.Next() {
+		var coin watchlistCoinChange
+		var binanceSymbol *string
+		var price, change *float64
+		if err := rows.Scan(&coin.symbol, &binanceSymbol, &price, &change); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		if price != nil {
+			coin.price = *price
+		}
+		if change != nil {
+			coin.change, coin.hasChange = *change, true
+		}
+		coins = append(coins, coin)
+		binanceSymbols = append(binanceSymbols, binanceSymbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.applyLivePrices(ctx, coins, binanceSymbols)
+
+	return summarizeWatchlist(coins), nil
+}
+
+// applyLivePrices overwrites the stored prices of Binance coins with the
+// cached ticker data. A cache failure keeps the stored prices
+func (s *WatchlistSummaryService) applyLivePrices(ctx context.Context, coins []watchlistCoinChange, binanceSymbols []*string) {
+	var symbols []string
+	for _, symbol := range binanceSymbols {
+		if symbol != nil {
+			symbols = append(symbols, *symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return
+	}
+
+	prices, err := s.priceCache.GetMultiple(ctx, symbols)
+	if err != nil {
+		s.logger.Warn("failed to read cached prices for watchlist summary",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	for i, symbol := range binanceSymbols {
+		if symbol == nil {
+			continue
+		}
+		if data, ok := prices[*symbol]; ok && data.Price > 0 {
+			coins[i].price = data.Price
+			coins[i].change, coins[i].hasChange = data.ChangePercent, true
+		}
+	}
+}
+
+// summarizeWatchlist aggregates the 24h changes of coins
+func summarizeWatchlist(coins []watchlistCoinChange) *WatchlistSummary {
+	summary := &WatchlistSummary{Coins: len(coins)}
+
+	var total float64
+	for _, coin := range coins {
+		if !coin.hasChange {
+			continue
+		}
+
+		summary.Priced++
+		total += coin.change
+		switch {
+		case coin.change > 0:
+			summary.Gainers++
+		case coin.change < 0:
+			summary.Losers++
+		}
+
+		if summary.TopMover == nil || math.Abs(coin.change) > math.Abs(summary.TopMover.Change24hPct) {
+			summary.TopMover = &WatchlistMover{
+				Symbol:       coin.symbol,
+				Price:        coin.price,
+				Change24hPct: coin.change,
+			}
+		}
+	}
+
+	if summary.Priced > 0 {
+		summary.AvgChange24hPct = total / float64(summary.Priced)
+	}
+
+	return summary
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeWatchlist(t *testing.T) {
+	summary := summarizeWatchlist([]watchlistCoinChange{
+		{symbol: "BTC", price: 70000, change: 2, hasChange: true},
+		{symbol: "ETH", price: 3500, change: -6, hasChange: true},
+		{symbol: "SOL", price: 150, change: 1, hasChange: true},
+		{symbol: "NEW"},
+	})
+
+	assert.Equal(t, 4, summary.Coins)
+	assert.Equal(t, 3, summary.Priced)
+	assert.InDelta(t, -1.0, summary.AvgChange24hPct, 1e-9)
+	assert.Equal(t, 2, summary.Gainers)
+	assert.Equal(t, 1, summary.Losers)
+	require.NotNil(t, summary.TopMover)
+	assert.Equal(t, "ETH", summary.TopMover.Symbol)
+	assert.Equal(t, 3500.0, summary.TopMover.Price)
+
+	empty := summarizeWatchlist(nil)
+	assert.Zero(t, empty.Coins)
+	assert.Nil(t, empty.TopMover)
+}
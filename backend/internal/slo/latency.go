@@ -0,0 +1,166 @@
+// Package slo measures the latency of the alert pipeline, from a price tick
+// reaching the alert engine to its notification being sent, against its
+// service level objective
+package slo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/pkg/clock"
+)
+
+// Pipeline stages measured
+const (
+	// StageEngine is from tick receipt to the trigger being published
+	StageEngine = "engine"
+	// StageNotification is from publishing to the Telegram send, batching
+	// and retries included
+	StageNotification = "notification"
+	// StageEndToEnd is from tick receipt to the Telegram send
+	StageEndToEnd = "end_to_end"
+)
+
+var stages = []string{StageEngine, StageNotification, StageEndToEnd}
+
+const (
+	// DefaultWindow is how far back percentiles are computed
+	DefaultWindow = 5 * time.Minute
+
+	// maxSamples caps the samples kept per stage; the oldest are dropped
+	// first when alerts trigger faster than that per window
+	maxSamples = 10000
+)
+
+// Percentiles summarizes the latency samples of a window
+type Percentiles struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+}
+
+// P95 returns the 95th percentile as a duration
+func (p Percentiles) P95() time.Duration {
+	return time.Duration(p.P95Ms * float64(time.Millisecond))
+}
+
+type sample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// Recorder keeps the latency samples of the recent window per stage. Safe
+// for concurrent use
+type Recorder struct {
+	window time.Duration
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	samples map[string]*ring
+}
+
+// NewRecorder creates a Recorder computing percentiles over window
+func NewRecorder(window time.Duration) *Recorder {
+	r := &Recorder{
+		window:  window,
+		clock:   clock.Real{},
+		samples: make(map[string]*ring, len(stages)),
+	}
+	for _, stage := range stages {
+		r.samples[stage] = &ring{}
+	}
+	return r
+}
+
+// SetClock sets the clock samples are stamped with
+func (r *Recorder) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// Observe records a latency of stage. Negative latencies, from clock skew
+// between services, are recorded as zero
+func (r *Recorder) Observe(stage string, d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rg, ok := r.samples[stage]
+	if !ok {
+		return
+	}
+	rg.add(sample{at: r.clock.Now(), d: d})
+}
+
+// Percentiles returns the percentiles of stage over the window
+func (r *Recorder) Percentiles(stage string) Percentiles {
+	since := r.clock.Now().Add(-r.window)
+
+	r.mu.Lock()
+	var durations []time.Duration
+	if rg, ok := r.samples[stage]; ok {
+		durations = rg.since(since)
+	}
+	r.mu.Unlock()
+
+	return percentiles(durations)
+}
+
+// Snapshot returns the percentiles of every stage
+func (r *Recorder) Snapshot() map[string]Percentiles {
+	out := make(map[string]Percentiles, len(stages))
+	for _, stage := range stages {
+		out[stage] = r.Percentiles(stage)
+	}
+	return out
+}
+
+// percentiles computes nearest-rank percentiles
+func percentiles(durations []time.Duration) Percentiles {
+	p := Percentiles{Count: len(durations)}
+	if len(durations) == 0 {
+		return p
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	rank := func(q float64) float64 {
+		i := int(q*float64(len(durations))+0.5) - 1
+		i = max(0, min(i, len(durations)-1))
+		return float64(durations[i]) / float64(time.Millisecond)
+	}
+
+	p.P50Ms = rank(0.50)
+	p.P95Ms = rank(0.95)
+	p.P99Ms = rank(0.99)
+	return p
+}
+
+// ring is a fixed-size buffer of samples, overwriting the oldest
+type ring struct {
+	buf  []sample
+	next int
+}
+
+func (r *ring) add(s sample) {
+	if len(r.buf) < maxSamples {
+		r.buf = append(r.buf, s)
+		return
+	}
+	r.buf[r.next] = s
+	r.next = (r.next + 1) % maxSamples
+}
+
+// since returns the durations of the samples taken after t
+func (r *ring) since(t time.Time) []time.Duration {
+	out := make([]time.Duration, 0, len(r.buf))
+	for _, s := range r.buf {
+		if s.at.After(t) {
+			out = append(out, s.d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package slo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+func TestRecorder_Percentiles(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	r := NewRecorder(DefaultWindow)
+	r.SetClock(clk)
+
+	// An old slow sample falls out of the window
+	r.Observe(StageEndToEnd, time.Hour)
+	clk.Advance(DefaultWindow + time.Second)
+
+	for i := 1; i <= 100; i++ {
+		r.Observe(StageEndToEnd, time.Duration(i)*time.Millisecond)
+	}
+	r.Observe(StageEngine, -time.Second)
+	r.Observe("unknown", time.Second)
+
+	p := r.Percentiles(StageEndToEnd)
+	assert.Equal(t, 100, p.Count)
+	assert.Equal(t, 50.0, p.P50Ms)
+	assert.Equal(t, 95.0, p.P95Ms)
+	assert.Equal(t, 99.0, p.P99Ms)
+	assert.Equal(t, 95*time.Millisecond, p.P95())
+
+	snapshot := r.Snapshot()
+	assert.Len(t, snapshot, 3)
+	assert.Equal(t, Percentiles{Count: 1}, snapshot[StageEngine])
+	assert.Equal(t, Percentiles{}, snapshot[StageNotification])
+}
+
+func TestMonitor_Check(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecorder(DefaultWindow)
+
+	var alerts []string
+	m := NewMonitor(r, time.Second, func(_ context.Context, text string) error {
+		alerts = append(alerts, text)
+		return nil
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Too few samples to judge
+	for i := 0; i < minSamples-1; i++ {
+		r.Observe(StageEndToEnd, 5*time.Second)
+	}
+	m.Check(ctx)
+	assert.False(t, m.Breached())
+
+	r.Observe(StageEndToEnd, 5*time.Second)
+	m.Check(ctx)
+	assert.True(t, m.Breached())
+	m.Check(ctx)
+	assert.Len(t, alerts, 1, "alerts once per breach")
+	assert.Contains(t, alerts[0], "SLO breached")
+
+	m.SetObjective(10 * time.Second)
+	m.Check(ctx)
+	assert.False(t, m.Breached())
+	assert.Len(t, alerts, 2)
+	assert.Contains(t, alerts[1], "back within SLO")
+}
@@ -0,0 +1,128 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// CheckInterval is how often the monitor compares p95 to the objective
+	CheckInterval = time.Minute
+
+	// minSamples keeps a handful of slow sends in a quiet window from
+	// raising an alert
+	minSamples = 20
+)
+
+// Alerter delivers an internal alert to the team, e.g. to an ops chat
+type Alerter func(ctx context.Context, text string) error
+
+// Monitor raises an internal alert when the end-to-end p95 latency exceeds
+// the objective, and another one when it recovers
+type Monitor struct {
+	recorder *Recorder
+	alert    Alerter
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	objective time.Duration
+	breached  bool
+}
+
+// NewMonitor creates a Monitor for objective (0 disables it). alert is
+// optional; breaches are logged either way
+func NewMonitor(recorder *Recorder, objective time.Duration, alert Alerter, logger *slog.Logger) *Monitor {
+	return &Monitor{
+		recorder:  recorder,
+		alert:     alert,
+		logger:    logger,
+		objective: objective,
+	}
+}
+
+// SetObjective changes the p95 objective, e.g. on config reload
+func (m *Monitor) SetObjective(objective time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objective = objective
+}
+
+// Objective returns the p95 objective
+func (m *Monitor) Objective() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.objective
+}
+
+// Breached reports whether the objective was exceeded at the last check
+func (m *Monitor) Breached() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.breached
+}
+
+// Run checks the objective every CheckInterval until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check compares the end-to-end p95 to the objective and alerts when it
+// starts or stops being exceeded. Windows with too few samples keep the
+// previous state
+func (m *Monitor) Check(ctx context.Context) {
+	p := m.recorder.Percentiles(StageEndToEnd)
+
+	m.mu.Lock()
+	objective := m.objective
+	wasBreached := m.breached
+	switch {
+	case objective <= 0:
+		m.breached = false
+	case p.Count >= minSamples:
+		m.breached = p.P95() > objective
+	}
+	breached := m.breached
+	m.mu.Unlock()
+
+	if breached == wasBreached {
+		return
+	}
+
+	var text string
+	if breached {
+		text = fmt.Sprintf("Alert pipeline latency SLO breached: p95 %s over the last %s exceeds %s (%d alerts)",
+			p.P95().Round(time.Millisecond), m.recorder.window, objective, p.Count)
+		m.logger.Error("pipeline latency SLO breached",
+			slog.Duration("p95", p.P95()),
+			slog.Duration("objective", objective),
+			slog.Int("samples", p.Count),
+		)
+	} else {
+		text = fmt.Sprintf("Alert pipeline latency back within SLO: p95 %s, objective %s",
+			p.P95().Round(time.Millisecond), objective)
+		m.logger.Info("pipeline latency back within SLO",
+			slog.Duration("p95", p.P95()),
+			slog.Duration("objective", objective),
+		)
+	}
+
+	if m.alert == nil {
+		return
+	}
+	if err := m.alert(ctx, text); err != nil {
+		m.logger.Warn("failed to send SLO alert", slog.String("error", err.Error()))
+	}
+}
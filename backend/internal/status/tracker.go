@@ -0,0 +1,195 @@
+// Package status records the service signals shown on the public status
+// page, in per-minute Redis buckets shared by all replicas
+package status
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+// Components whose uptime is tracked
+const (
+	ComponentBinanceStream = "binance_stream"
+)
+
+const (
+	uptimeKeyPrefix   = "status:uptime:"
+	deliveryKeyPrefix = "status:delivery:"
+
+	// Buckets are kept a little longer than the longest window read
+	uptimeTTL   = 25 * time.Hour
+	deliveryTTL = 2 * time.Hour
+)
+
+// Uptime is the share of sampled minutes a component was up
+type Uptime struct {
+	Ratio   float64 // 0..1, 0 when nothing was sampled
+	Minutes int     // minutes with at least one sample
+}
+
+// Delivery counts notifications sent and finally failed
+type Delivery struct {
+	Sent   int64
+	Failed int64
+}
+
+// SuccessRate returns the share of notifications delivered, ok is false
+// when none were attempted
+func (d Delivery) SuccessRate() (rate float64, ok bool) {
+	total := d.Sent + d.Failed
+	if total == 0 {
+		return 0, false
+	}
+	return float64(d.Sent) / float64(total), true
+}
+
+// Tracker records and reads status signals
+type Tracker struct {
+	redis *redis.Client
+	clock clock.Clock
+}
+
+// NewTracker creates a new Tracker
+func NewTracker(redisClient *redis.Client) *Tracker {
+	return &Tracker{redis: redisClient, clock: clock.Real{}}
+}
+
+// SetClock sets the clock buckets are picked by
+func (t *Tracker) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// RecordUp samples whether component is up. A minute counts as down if
+// any replica sampled it down
+func (t *Tracker) RecordUp(ctx context.Context, component string, up bool) error {
+	field := "up"
+	if !up {
+		field = "down"
+	}
+	key := uptimeKey(component, minute(t.clock.Now()))
+
+	pipe := t.redis.TxPipeline()
+	pipe.HIncrBy(ctx, key, field, 1)
+	pipe.Expire(ctx, key, uptimeTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Uptime returns the uptime of component over the window ending now.
+// Minutes nobody sampled, such as while the reporting service was down,
+// are left out
+func (t *Tracker) Uptime(ctx context.Context, component string, window time.Duration) (Uptime, error) {
+	minutes := t.minutes(window)
+
+	pipe := t.redis.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(minutes))
+	for i, m := range minutes {
+		cmds[i] = pipe.HMGet(ctx, uptimeKey(component, m), "up", "down")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Uptime{}, err
+	}
+
+	var up Uptime
+	upMinutes := 0
+	for _, cmd := range cmds {
+		vals := cmd.Val()
+		ups, downs := count(vals, 0), count(vals, 1)
+		if ups+downs == 0 {
+			continue
+		}
+		up.Minutes++
+		if downs == 0 {
+			upMinutes++
+		}
+	}
+	if up.Minutes > 0 {
+		up.Ratio = float64(upMinutes) / float64(up.Minutes)
+	}
+	return up, nil
+}
+
+// RecordDelivery adds notifications sent and finally failed to the
+// current minute
+func (t *Tracker) RecordDelivery(ctx context.Context, sent, failed int) error {
+	key := deliveryKey(minute(t.clock.Now()))
+
+	pipe := t.redis.TxPipeline()
+	if sent > 0 {
+		pipe.HIncrBy(ctx, key, "sent", int64(sent))
+	}
+	if failed > 0 {
+		pipe.HIncrBy(ctx, key, "failed", int64(failed))
+	}
+	pipe.Expire(ctx, key, deliveryTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Delivery returns the notifications sent and failed over the window
+// ending now
+func (t *Tracker) Delivery(ctx context.Context, window time.Duration) (Delivery, error) {
+	minutes := t.minutes(window)
+
+	pipe := t.redis.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(minutes))
+	for i, m := range minutes {
+		cmds[i] = pipe.HMGet(ctx, deliveryKey(m), "sent", "failed")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Delivery{}, err
+	}
+
+	var d Delivery
+	for _, cmd := range cmds {
+		vals := cmd.Val()
+		d.Sent += count(vals, 0)
+		d.Failed += count(vals, 1)
+	}
+	return d, nil
+}
+
+// minutes returns the minute buckets of the window ending now, the current
+// minute included
+func (t *Tracker) minutes(window time.Duration) []int64 {
+	now := minute(t.clock.Now())
+	n := int64(window / time.Minute)
+	if n < 1 {
+		n = 1
+	}
+	out := make([]int64, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		out = append(out, now-i*60)
+	}
+	return out
+}
+
+// minute returns the Unix time of the start of t's minute
+func minute(t time.Time) int64 {
+	return t.Truncate(time.Minute).Unix()
+}
+
+func uptimeKey(component string, minute int64) string {
+	return uptimeKeyPrefix + component + ":" + strconv.FormatInt(minute, 10)
+}
+
+func deliveryKey(minute int64) string {
+	return deliveryKeyPrefix + strconv.FormatInt(minute, 10)
+}
+
+// count parses the HMGET value at i, 0 when missing
+func count(vals []any, i int) int64 {
+	if i >= len(vals) {
+		return 0
+	}
+	s, ok := vals[i].(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,80 @@
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+func newTestTracker(t *testing.T) (*Tracker, *clock.Fake) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+
+	clk := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	tracker := NewTracker(client)
+	tracker.SetClock(clk)
+	return tracker, clk
+}
+
+func TestTracker_Uptime(t *testing.T) {
+	ctx := context.Background()
+	tracker, clk := newTestTracker(t)
+
+	// Three minutes up, one with a down sample among ups, one not sampled
+	for i := 0; i < 3; i++ {
+		require.NoError(t, tracker.RecordUp(ctx, ComponentBinanceStream, true))
+		require.NoError(t, tracker.RecordUp(ctx, ComponentBinanceStream, true))
+		clk.Advance(time.Minute)
+	}
+	require.NoError(t, tracker.RecordUp(ctx, ComponentBinanceStream, true))
+	require.NoError(t, tracker.RecordUp(ctx, ComponentBinanceStream, false))
+	clk.Advance(2 * time.Minute)
+
+	up, err := tracker.Uptime(ctx, ComponentBinanceStream, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 4, up.Minutes)
+	assert.InDelta(t, 0.75, up.Ratio, 1e-9)
+
+	// Samples outside the window are ignored
+	up, err = tracker.Uptime(ctx, ComponentBinanceStream, 3*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, up.Minutes)
+	assert.Zero(t, up.Ratio)
+}
+
+func TestTracker_Delivery(t *testing.T) {
+	ctx := context.Background()
+	tracker, clk := newTestTracker(t)
+
+	d, err := tracker.Delivery(ctx, time.Hour)
+	require.NoError(t, err)
+	_, ok := d.SuccessRate()
+	assert.False(t, ok)
+
+	require.NoError(t, tracker.RecordDelivery(ctx, 5, 0))
+	clk.Advance(30 * time.Minute)
+	require.NoError(t, tracker.RecordDelivery(ctx, 4, 1))
+	clk.Advance(45 * time.Minute)
+	require.NoError(t, tracker.RecordDelivery(ctx, 10, 0))
+
+	// The first minute fell out of the last hour
+	d, err = tracker.Delivery(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, Delivery{Sent: 14, Failed: 1}, d)
+	rate, ok := d.SuccessRate()
+	assert.True(t, ok)
+	assert.InDelta(t, 14.0/15.0, rate, 1e-9)
+}
@@ -5,28 +5,60 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/weqory/backend/pkg/alerttype"
 )
 
 const (
-	telegramAPIURL = "https://api.telegram.org/bot"
+	telegramAPIURL = "https://api.telegram.org"
+
+	// Max alerts listed in a combined message (Telegram caps text at 4096 chars)
+	maxBatchLines = 20
+
+	// Telegram caps photo captions at 1024 chars
+	maxCaptionLength = 1024
 
 	// Timeouts
 	requestTimeout = 30 * time.Second
 
 	// Rate limiting
 	maxRequestsPerSecond = 30
+
+	// CopyVariantCompact is the single-line alert text of the
+	// notification_copy experiment
+	CopyVariantCompact = "compact"
+
+	// MuteAllCallbackPrefix starts the callback data of the mute-all buttons
+	// on alert messages, followed by the number of hours
+	MuteAllCallbackPrefix = "mute_all:"
+
+	// PauseAlertCallbackPrefix starts the callback data of the pause button
+	// on single alert messages, followed by the alert ID
+	PauseAlertCallbackPrefix = "pause_alert:"
 )
 
+// muteAllHours are the mute durations offered under alert messages
+var muteAllHours = []int{1, 8}
+
 // Client is a Telegram Bot API client
 type Client struct {
 	token      string
 	httpClient *http.Client
 	logger     *slog.Logger
+	apiURL     string
 	baseURL    string
+	templates  *Templates
+	mu         sync.RWMutex
 }
 
 // NewClient creates a new Telegram Bot API client
@@ -36,11 +68,37 @@ func NewClient(token string, logger *slog.Logger) *Client {
 		httpClient: &http.Client{
 			Timeout: requestTimeout,
 		},
-		logger:  logger,
-		baseURL: telegramAPIURL + token,
+		logger:    logger,
+		apiURL:    telegramAPIURL,
+		baseURL:   telegramAPIURL + "/bot" + token,
+		templates: defaultTemplates,
 	}
 }
 
+// SetTemplates sets the templates alert messages are rendered from
+func (c *Client) SetTemplates(templates *Templates) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.templates = templates
+}
+
+// SetAPIURL points the client at another Bot API server, such as a local
+// Bot API server or a fake in tests
+func (c *Client) SetAPIURL(apiURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiURL = strings.TrimSuffix(apiURL, "/")
+	c.baseURL = c.apiURL + "/bot" + c.token
+}
+
+// SetToken switches the client to a new bot token (e.g. after rotation)
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.baseURL = c.apiURL + "/bot" + token
+}
+
 // SendMessage sends a text message to a chat
 func (c *Client) SendMessage(ctx context.Context, req SendMessageRequest) (*NotificationResult, error) {
 	result := &NotificationResult{
@@ -64,6 +122,65 @@ func (c *Client) SendMessage(ctx context.Context, req SendMessageRequest) (*Noti
 		return result, err
 	}
 
+	return sentMessageResult(resp, result)
+}
+
+// SendPhoto uploads a photo to a chat, with an optional caption
+func (c *Client) SendPhoto(ctx context.Context, req SendPhotoRequest) (*NotificationResult, error) {
+	result := &NotificationResult{
+		SentAt: time.Now(),
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"chat_id":    strconv.FormatInt(req.ChatID, 10),
+		"caption":    req.Caption,
+		"parse_mode": req.ParseMode,
+	}
+	if fields["parse_mode"] == "" {
+		fields["parse_mode"] = "HTML"
+	}
+	if req.DisableNotification {
+		fields["disable_notification"] = "true"
+	}
+	if req.ReplyMarkup != nil {
+		markup, err := json.Marshal(req.ReplyMarkup)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to marshal reply markup: %w", err)
+			return result, result.Error
+		}
+		fields["reply_markup"] = string(markup)
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			result.Error = fmt.Errorf("failed to build request: %w", err)
+			return result, result.Error
+		}
+	}
+	photo, err := form.CreateFormFile("photo", "chart.png")
+	if err == nil {
+		_, err = photo.Write(req.Photo)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		result.Error = fmt.Errorf("failed to build request: %w", err)
+		return result, result.Error
+	}
+
+	resp, err := c.post(ctx, "sendPhoto", form.FormDataContentType(), body.Bytes())
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+
+	return sentMessageResult(resp, result)
+}
+
+// sentMessageResult fills result from the response to a send method
+func sentMessageResult(resp *APIResponse, result *NotificationResult) (*NotificationResult, error) {
 	if !resp.OK {
 		// Check for rate limiting
 		if resp.Parameters != nil && resp.Parameters.RetryAfter > 0 {
@@ -72,7 +189,7 @@ func (c *Client) SendMessage(ctx context.Context, req SendMessageRequest) (*Noti
 			return result, result.Error
 		}
 
-		result.Error = fmt.Errorf("telegram API error: %s (code: %d)", resp.Description, resp.ErrorCode)
+		result.Error = &APIError{Code: resp.ErrorCode, Description: resp.Description}
 		return result, result.Error
 	}
 
@@ -90,32 +207,19 @@ func (c *Client) SendMessage(ctx context.Context, req SendMessageRequest) (*Noti
 
 // SendAlertNotification sends an alert notification to a user
 func (c *Client) SendAlertNotification(ctx context.Context, notification AlertNotification, miniAppURL string) (*NotificationResult, error) {
-	text := formatAlertMessage(notification)
-
-	// Create inline keyboard with "Open App" button
-	var replyMarkup *InlineKeyboardMarkup
-	if miniAppURL != "" {
-		replyMarkup = &InlineKeyboardMarkup{
-			InlineKeyboard: [][]InlineKeyboardButton{
-				{
-					{
-						Text: "📱 Open Weqory",
-						WebApp: &WebAppInfo{URL: miniAppURL},
-					},
-				},
-			},
-		}
+	text := c.FormatAlertMessage(notification)
+
+	result, err := c.sendAlertChart(ctx, notification, text, miniAppURL)
+	if result == nil {
+		result, err = c.SendMessage(ctx, SendMessageRequest{
+			ChatID:                notification.TelegramID,
+			Text:                  text,
+			ParseMode:             "HTML",
+			DisableWebPagePreview: true,
+			DisableNotification:   notification.Silent,
+			ReplyMarkup:           alertKeyboard(miniAppURL, notification.AlertID),
+		})
 	}
-
-	req := SendMessageRequest{
-		ChatID:                notification.TelegramID,
-		Text:                  text,
-		ParseMode:             "HTML",
-		DisableWebPagePreview: true,
-		ReplyMarkup:           replyMarkup,
-	}
-
-	result, err := c.SendMessage(ctx, req)
 	if err != nil {
 		c.logger.Error("failed to send alert notification",
 			slog.Int64("telegram_id", notification.TelegramID),
@@ -133,16 +237,184 @@ func (c *Client) SendAlertNotification(ctx context.Context, notification AlertNo
 	return result, err
 }
 
+// sendAlertChart sends an alert as its chart captioned with text. A nil
+// result means the alert is to be sent as a text message: it has no chart,
+// the text is too long for a caption or the photo upload failed
+func (c *Client) sendAlertChart(ctx context.Context, n AlertNotification, text, miniAppURL string) (*NotificationResult, error) {
+	if len(n.Chart) == 0 || utf8.RuneCountInString(text) > maxCaptionLength {
+		return nil, nil
+	}
+
+	result, err := c.SendPhoto(ctx, SendPhotoRequest{
+		ChatID:              n.TelegramID,
+		Photo:               n.Chart,
+		Caption:             text,
+		ParseMode:           "HTML",
+		DisableNotification: n.Silent,
+		ReplyMarkup:         alertKeyboard(miniAppURL, n.AlertID),
+	})
+	if err != nil && result.RetryAfter == 0 {
+		c.logger.Warn("failed to send alert chart, sending text only",
+			slog.Int64("telegram_id", n.TelegramID),
+			slog.String("symbol", n.CoinSymbol),
+			slog.String("error", err.Error()),
+		)
+		return nil, nil
+	}
+	return result, err
+}
+
+// SendAlertBatch sends several alert notifications for one user as a single
+// combined message. A single notification is sent in the regular format
+func (c *Client) SendAlertBatch(ctx context.Context, notifications []AlertNotification, miniAppURL string) (*NotificationResult, error) {
+	if len(notifications) == 0 {
+		return nil, fmt.Errorf("no notifications to send")
+	}
+	if len(notifications) == 1 {
+		return c.SendAlertNotification(ctx, notifications[0], miniAppURL)
+	}
+
+	telegramID := notifications[0].TelegramID
+	result, err := c.SendMessage(ctx, SendMessageRequest{
+		ChatID:                telegramID,
+		Text:                  formatAlertBatchMessage(notifications),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+		DisableNotification:   notifications[0].Silent,
+		ReplyMarkup:           alertKeyboard(miniAppURL, 0),
+	})
+	if err != nil {
+		c.logger.Error("failed to send alert batch",
+			slog.Int64("telegram_id", telegramID),
+			slog.Int("alerts", len(notifications)),
+			slog.String("error", err.Error()),
+		)
+	} else {
+		c.logger.Info("sent alert batch",
+			slog.Int64("telegram_id", telegramID),
+			slog.Int("alerts", len(notifications)),
+			slog.Int64("message_id", result.MessageID),
+		)
+	}
+
+	return result, err
+}
+
+// alertKeyboard builds the buttons under an alert message: "Open App" when
+// the Mini App URL is known, a pause button when the message is about a
+// single alert (alertID is 0 otherwise), and the mute-all switch
+func alertKeyboard(miniAppURL string, alertID int64) *InlineKeyboardMarkup {
+	var rows [][]InlineKeyboardButton
+	if miniAppURL != "" {
+		rows = append(rows, []InlineKeyboardButton{
+			{
+				Text:   "📱 Open Weqory",
+				WebApp: &WebAppInfo{URL: miniAppURL},
+			},
+		})
+	}
+
+	if alertID > 0 {
+		rows = append(rows, []InlineKeyboardButton{
+			{
+				Text:         "⏸ Pause this alert",
+				CallbackData: fmt.Sprintf("%s%d", PauseAlertCallbackPrefix, alertID),
+			},
+		})
+	}
+
+	mute := make([]InlineKeyboardButton, len(muteAllHours))
+	for i, hours := range muteAllHours {
+		mute[i] = InlineKeyboardButton{
+			Text:         fmt.Sprintf("🔕 Mute all %dh", hours),
+			CallbackData: fmt.Sprintf("%s%d", MuteAllCallbackPrefix, hours),
+		}
+	}
+	rows = append(rows, mute)
+
+	return &InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// ParseMuteAllCallback returns how long a mute-all button asks to mute
+// alerts for. ok is false for callback data of other buttons
+func ParseMuteAllCallback(data string) (duration time.Duration, ok bool) {
+	value, found := strings.CutPrefix(data, MuteAllCallbackPrefix)
+	if !found {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours <= 0 {
+		return 0, false
+	}
+	return time.Duration(hours) * time.Hour, true
+}
+
+// ParsePauseAlertCallback returns the alert a pause button asks to pause.
+// ok is false for callback data of other buttons
+func ParsePauseAlertCallback(data string) (alertID int64, ok bool) {
+	value, found := strings.CutPrefix(data, PauseAlertCallbackPrefix)
+	if !found {
+		return 0, false
+	}
+	alertID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || alertID <= 0 {
+		return 0, false
+	}
+	return alertID, true
+}
+
+// ParseCommand splits a bot command such as "/mute@WeqoryBot 8" into its
+// name and arguments. ok is false for text that is not a command
+func ParseCommand(text string) (command, args string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+
+	command, args, _ = strings.Cut(text[1:], " ")
+	command, _, _ = strings.Cut(command, "@")
+	if command == "" {
+		return "", "", false
+	}
+	return strings.ToLower(command), strings.TrimSpace(args), true
+}
+
+// AnswerCallbackQuery acknowledges an inline button press, optionally with
+// a short notice shown to the user
+func (c *Client) AnswerCallbackQuery(ctx context.Context, req AnswerCallbackQueryRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "answerCallbackQuery", data)
+	if err != nil {
+		return err
+	}
+
+	if !resp.OK {
+		return fmt.Errorf("telegram API error: %s (code: %d)", resp.Description, resp.ErrorCode)
+	}
+
+	return nil
+}
+
 // doRequest performs an HTTP request to Telegram API
 func (c *Client) doRequest(ctx context.Context, method string, body []byte) (*APIResponse, error) {
+	return c.post(ctx, method, "application/json", body)
+}
+
+// post calls a Bot API method with a body of the given content type
+func (c *Client) post(ctx context.Context, method, contentType string, body []byte) (*APIResponse, error) {
+	c.mu.RLock()
 	url := fmt.Sprintf("%s/%s", c.baseURL, method)
+	c.mu.RUnlock()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -182,77 +454,207 @@ func (c *Client) GetMe(ctx context.Context) (*User, error) {
 	return &user, nil
 }
 
-// formatAlertMessage formats an alert notification message
+// FormatAlertMessage returns the text an alert notification is sent with,
+// rendered from the client's message templates unless the one-line text
+// is asked for
+func (c *Client) FormatAlertMessage(n AlertNotification) string {
+	if n.Compact || n.CopyVariant == CopyVariantCompact {
+		return formatCompactAlertMessage(n)
+	}
+
+	c.mu.RLock()
+	templates := c.templates
+	c.mu.RUnlock()
+
+	text, err := templates.Render(n)
+	if err != nil {
+		c.logger.Error("failed to render alert message, using built-in template",
+			slog.String("alert_type", n.AlertType),
+			slog.String("language", n.Language),
+			slog.String("error", err.Error()),
+		)
+		return formatAlertMessage(n)
+	}
+	return text
+}
+
+// formatAlertMessage formats an alert notification message with the
+// built-in templates
 func formatAlertMessage(n AlertNotification) string {
-	var icon string
-	var action string
-
-	switch n.AlertType {
-	case "PRICE_ABOVE":
-		icon = "🔺"
-		action = "rose above"
-	case "PRICE_BELOW":
-		icon = "🔻"
-		action = "fell below"
-	case "PERCENT_UP":
-		icon = "📈"
-		action = fmt.Sprintf("increased by %.2f%%", n.PriceChange)
-	case "PERCENT_DOWN":
-		icon = "📉"
-		action = fmt.Sprintf("decreased by %.2f%%", n.PriceChange)
-	case "PRICE_CHANGE":
-		if n.PriceChange >= 0 {
-			icon = "📈"
-			action = fmt.Sprintf("changed by +%.2f%%", n.PriceChange)
-		} else {
-			icon = "📉"
-			action = fmt.Sprintf("changed by %.2f%%", n.PriceChange)
-		}
-	case "PERIODIC":
-		icon = "🔔"
-		action = "periodic update"
-	default:
-		icon = "⚡"
-		action = "triggered"
+	if n.Compact || n.CopyVariant == CopyVariantCompact {
+		return formatCompactAlertMessage(n)
 	}
 
-	coinDisplay := n.CoinSymbol
-	if n.CoinName != "" {
-		coinDisplay = fmt.Sprintf("%s (%s)", n.CoinName, n.CoinSymbol)
+	text, err := defaultTemplates.Render(n)
+	if err != nil {
+		// Not expected from the built-in templates; the one-line format
+		// still gets the alert across
+		return formatCompactAlertMessage(n)
 	}
+	return text
+}
 
-	message := fmt.Sprintf(`%s <b>Alert Triggered!</b>
+// formatCompactAlertMessage formats an alert as a single line
+func formatCompactAlertMessage(n AlertNotification) string {
+	icon, action := alertIconAndAction(n)
 
-<b>%s</b> %s
+	_, current, _, target := alertValues(n)
+	if def, ok := alerttype.Lookup(n.AlertType); ok && def.ShowTarget {
+		action += " " + target
+	}
 
-💰 Current Price: <b>$%s</b>
-🎯 Target: $%s
-⏰ %s`,
+	message := fmt.Sprintf("%s <b>%s</b> %s · now <b>%s</b>",
 		icon,
-		coinDisplay,
+		n.CoinSymbol,
 		action,
-		formatPrice(n.TriggeredPrice),
-		formatPrice(n.ConditionValue),
-		n.TriggeredAt.Format("15:04:05 MST"),
+		current,
 	)
+	if n.Detail != "" {
+		message += " · " + html.EscapeString(n.Detail)
+	}
+	if n.AlertName != "" {
+		message += " · " + html.EscapeString(n.AlertName)
+	}
+	if n.HeldBack > 0 {
+		message += fmt.Sprintf(" · +%d more", n.HeldBack)
+	}
+	return message
+}
 
-	if n.IsRecurring {
-		message += "\n\n🔄 <i>This is a recurring alert</i>"
+// heldBackText describes the alerts the throttle folded into a message
+func heldBackText(n AlertNotification) string {
+	if n.HeldBack == 1 {
+		return fmt.Sprintf("1 more %s alert triggered since the last message", n.CoinSymbol)
 	}
+	return fmt.Sprintf("%d more %s alerts triggered since the last message", n.HeldBack, n.CoinSymbol)
+}
 
-	return message
+// formatAlertBatchMessage formats several alerts into one combined message
+func formatAlertBatchMessage(notifications []AlertNotification) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "⚡ <b>%d Alerts Triggered!</b>\n", len(notifications))
+
+	latest := notifications[0].TriggeredAt
+	for i, n := range notifications {
+		if n.TriggeredAt.After(latest) {
+			latest = n.TriggeredAt
+		}
+		if i >= maxBatchLines {
+			continue
+		}
+
+		icon, action := alertIconAndAction(n)
+		if n.AlertName != "" {
+			action += " · " + html.EscapeString(n.AlertName)
+		}
+		_, current, _, target := alertValues(n)
+		fmt.Fprintf(&b, "\n%s <b>%s</b> %s\n💰 %s · 🎯 %s\n",
+			icon,
+			n.CoinSymbol,
+			action,
+			current,
+			target,
+		)
+		if n.Detail != "" {
+			fmt.Fprintf(&b, "%s\n", html.EscapeString(n.Detail))
+		}
+		if n.HeldBack > 0 {
+			fmt.Fprintf(&b, "🔇 <i>%s</i>\n", heldBackText(n))
+		}
+	}
+
+	if len(notifications) > maxBatchLines {
+		fmt.Fprintf(&b, "\n<i>…and %d more</i>\n", len(notifications)-maxBatchLines)
+	}
+
+	fmt.Fprintf(&b, "\n⏰ %s", formatTriggeredAt(latest, notifications[0].Timezone))
+
+	return b.String()
 }
 
-// formatPrice formats a price for display
-func formatPrice(price float64) string {
+// formatTriggeredAt formats a trigger time in the user's timezone, falling
+// back to UTC rather than the server's zone
+func formatTriggeredAt(t time.Time, timezone string) string {
+	loc := time.UTC
+	if timezone != "" {
+		if l, err := time.LoadLocation(timezone); err == nil {
+			loc = l
+		}
+	}
+	return t.In(loc).Format("15:04:05 MST")
+}
+
+// alertIconAndAction returns the icon and action text of an alert type from
+// the alert type registry
+func alertIconAndAction(n AlertNotification) (icon, action string) {
+	if def, ok := alerttype.Lookup(n.AlertType); ok {
+		return def.Icon, def.Action
+	}
+	return "⚡", "triggered"
+}
+
+// alertValues returns the labels and formatted values of the current and
+// target lines of an alert, in the unit of its type
+func alertValues(n AlertNotification) (currentLabel, current, targetLabel, target string) {
+	format := resolveNumberFormat(n.NumberFormat, n.Language)
+
+	def, ok := alerttype.Lookup(n.AlertType)
+	if !ok {
+		return "Current Price", formatMoney(n.TriggeredPrice, n.QuoteAsset, format), "Target", formatMoney(n.ConditionValue, n.QuoteAsset, format)
+	}
+
+	current = formatMoney(n.TriggeredPrice, n.QuoteAsset, format)
+	if def.Unit == alerttype.UnitGwei {
+		// Triggered by the gas price, not the coin's
+		current = formatGwei(n.TriggeredPrice, format)
+	}
+	return def.CurrentLabel, current, def.TargetLabel, formatValue(def.Unit, n.ConditionValue, n.QuoteAsset, format)
+}
+
+// formatValue formats a condition value in its unit and number format, with
+// USD values in quote when it is set
+func formatValue(unit alerttype.Unit, value float64, quote, format string) string {
+	switch unit {
+	case alerttype.UnitPercent:
+		return localizeNumber(strconv.FormatFloat(value, 'f', -1, 64), format) + "%"
+	case alerttype.UnitGwei:
+		return formatGwei(value, format)
+	default:
+		return formatMoney(value, quote, format)
+	}
+}
+
+// formatMoney formats a price in USD, or in quote when it is set, e.g.
+// "$70000.00" or "0.051234 BTC"
+func formatMoney(price float64, quote, format string) string {
+	if quote == "" {
+		return "$" + formatPrice(price, format)
+	}
+	return formatPrice(price, format) + " " + quote
+}
+
+// formatPrice formats a price for display in a number format
+func formatPrice(price float64, format string) string {
+	var s string
 	if price >= 1000 {
-		return fmt.Sprintf("%.2f", price)
+		s = fmt.Sprintf("%.2f", price)
 	} else if price >= 1 {
-		return fmt.Sprintf("%.4f", price)
+		s = fmt.Sprintf("%.4f", price)
 	} else if price >= 0.0001 {
-		return fmt.Sprintf("%.6f", price)
+		s = fmt.Sprintf("%.6f", price)
+	} else {
+		s = fmt.Sprintf("%.8f", price)
 	}
-	return fmt.Sprintf("%.8f", price)
+	return localizeNumber(s, format)
+}
+
+// formatGwei formats a gas price for display in a number format
+func formatGwei(gwei float64, format string) string {
+	if gwei < 10 {
+		return localizeNumber(fmt.Sprintf("%.2f", gwei), format) + " gwei"
+	}
+	return localizeNumber(fmt.Sprintf("%.1f", gwei), format) + " gwei"
 }
 
 // ========== Telegram Stars Payment Methods ==========
@@ -323,7 +725,7 @@ func (c *Client) SendInvoice(ctx context.Context, req SendInvoiceRequest) (*Noti
 			return result, result.Error
 		}
 
-		result.Error = fmt.Errorf("telegram API error: %s (code: %d)", resp.Description, resp.ErrorCode)
+		result.Error = &APIError{Code: resp.ErrorCode, Description: resp.Description}
 		return result, result.Error
 	}
 
@@ -370,6 +772,57 @@ func (c *Client) AnswerPreCheckoutQuery(ctx context.Context, req AnswerPreChecko
 	return nil
 }
 
+// GetStarTransactions returns a page of the bot's Telegram Stars
+// transactions. Offset counts transactions to skip; Limit is capped at 100
+// by Telegram
+func (c *Client) GetStarTransactions(ctx context.Context, req GetStarTransactionsRequest) (*StarTransactions, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "getStarTransactions", data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.OK {
+		return nil, fmt.Errorf("telegram API error: %s (code: %d)", resp.Description, resp.ErrorCode)
+	}
+
+	var transactions StarTransactions
+	if err := json.Unmarshal(resp.Result, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &transactions, nil
+}
+
+// SetWebhook points the bot's updates at url. Telegram then sends
+// SecretToken in the WebhookSecretHeader of every update
+func (c *Client) SetWebhook(ctx context.Context, req SetWebhookRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "setWebhook", data)
+	if err != nil {
+		return err
+	}
+
+	if !resp.OK {
+		return fmt.Errorf("telegram API error: %s (code: %d)", resp.Description, resp.ErrorCode)
+	}
+
+	c.logger.Info("webhook set",
+		slog.String("url", req.URL),
+		slog.Any("allowed_updates", req.AllowedUpdates),
+	)
+
+	return nil
+}
+
 // CreateSubscriptionInvoiceLink is a helper to create invoice for subscription plans
 func (c *Client) CreateSubscriptionInvoiceLink(ctx context.Context, plan, period string, starsAmount int, payload string) (string, error) {
 	var title, description string
@@ -0,0 +1,196 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTriggeredAt(t *testing.T) {
+	at := time.Date(2026, 7, 1, 12, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "12:30:00 UTC", formatTriggeredAt(at, ""))
+	assert.Equal(t, "12:30:00 UTC", formatTriggeredAt(at, "Not/AZone"))
+
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skip("timezone data not available")
+	}
+	assert.Equal(t, "14:30:00 CEST", formatTriggeredAt(at, "Europe/Berlin"))
+}
+
+func TestFormatAlertMessage_Name(t *testing.T) {
+	n := AlertNotification{
+		CoinSymbol:     "BTC",
+		AlertType:      "PRICE_BELOW",
+		ConditionValue: 60000,
+		TriggeredPrice: 59900,
+		TriggeredAt:    time.Date(2026, 7, 1, 12, 30, 0, 0, time.UTC),
+	}
+	assert.Contains(t, formatAlertMessage(n), "<b>Alert Triggered!</b>")
+
+	n.AlertName = "Stop <loss>"
+	assert.Contains(t, formatAlertMessage(n), "<b>Stop &lt;loss&gt;</b>")
+	assert.NotContains(t, formatAlertMessage(n), "Alert Triggered!")
+
+	n.CopyVariant = CopyVariantCompact
+	assert.Contains(t, formatCompactAlertMessage(n), "Stop &lt;loss&gt;")
+	assert.Contains(t, formatAlertBatchMessage([]AlertNotification{n, n}), "fell below · Stop &lt;loss&gt;")
+}
+
+func TestFormatAlertMessage_WhaleTransfer(t *testing.T) {
+	n := AlertNotification{
+		CoinSymbol:     "BTC",
+		AlertType:      "WHALE_TRANSFER",
+		ConditionValue: 10000000,
+		TriggeredPrice: 70000,
+		TriggeredAt:    time.Date(2026, 7, 1, 12, 30, 0, 0, time.UTC),
+		Detail:         "200 BTC ($14.0M) from unknown wallet to binance",
+	}
+
+	message := formatAlertMessage(n)
+	assert.Contains(t, message, "🐋")
+	assert.Contains(t, message, "whale transfer\n200 BTC ($14.0M) from unknown wallet to binance")
+	assert.Contains(t, message, "Threshold: $10000000.00")
+
+	assert.Contains(t, formatCompactAlertMessage(n), "· 200 BTC ($14.0M)")
+}
+
+func TestFormatAlertMessage_GasBelow(t *testing.T) {
+	n := AlertNotification{
+		CoinSymbol:     "ETH",
+		AlertType:      "GAS_BELOW",
+		ConditionValue: 10,
+		TriggeredPrice: 8.25,
+		TriggeredAt:    time.Date(2026, 7, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	message := formatAlertMessage(n)
+	assert.Contains(t, message, "⛽")
+	assert.Contains(t, message, "Gas Price: <b>8.25 gwei</b>")
+	assert.Contains(t, message, "Target: 10.0 gwei")
+	assert.NotContains(t, message, "$")
+
+	assert.Contains(t, formatCompactAlertMessage(n), "gas fell below 10.0 gwei · now <b>8.25 gwei</b>")
+}
+
+func TestParseMuteAllCallback(t *testing.T) {
+	duration, ok := ParseMuteAllCallback("mute_all:8")
+	assert.True(t, ok)
+	assert.Equal(t, 8*time.Hour, duration)
+
+	for _, data := range []string{"", "mute_all:", "mute_all:0", "mute_all:x", "other:8"} {
+		_, ok := ParseMuteAllCallback(data)
+		assert.False(t, ok, data)
+	}
+}
+
+func TestParsePauseAlertCallback(t *testing.T) {
+	alertID, ok := ParsePauseAlertCallback("pause_alert:42")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), alertID)
+
+	for _, data := range []string{"", "pause_alert:", "pause_alert:0", "pause_alert:x", "mute_all:8"} {
+		_, ok := ParsePauseAlertCallback(data)
+		assert.False(t, ok, data)
+	}
+}
+
+func TestAlertKeyboard(t *testing.T) {
+	keyboard := alertKeyboard("", 0)
+	assert.Len(t, keyboard.InlineKeyboard, 1)
+
+	keyboard = alertKeyboard("https://t.me/weqory/app", 0)
+	assert.Len(t, keyboard.InlineKeyboard, 2)
+	for _, button := range keyboard.InlineKeyboard[1] {
+		_, ok := ParseMuteAllCallback(button.CallbackData)
+		assert.True(t, ok, button.Text)
+	}
+
+	keyboard = alertKeyboard("https://t.me/weqory/app", 42)
+	require.Len(t, keyboard.InlineKeyboard, 3)
+	alertID, ok := ParsePauseAlertCallback(keyboard.InlineKeyboard[1][0].CallbackData)
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), alertID)
+}
+
+func TestGetStarTransactions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/getStarTransactions", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"offset":100,"limit":100}`, string(body))
+
+		w.Write([]byte(`{"ok":true,"result":{"transactions":[
+			{"id":"ch_1","amount":250,"date":1700000000,"source":{"type":"user","user":{"id":7,"first_name":"A"},"invoice_payload":"{\"payment_id\":3}"}},
+			{"id":"ch_0","amount":100,"date":1690000000,"receiver":{"type":"user","user":{"id":8,"first_name":"B"}}}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("token", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.baseURL = srv.URL
+
+	resp, err := client.GetStarTransactions(context.Background(), GetStarTransactionsRequest{Offset: 100, Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, resp.Transactions, 2)
+
+	paid := resp.Transactions[0]
+	assert.Equal(t, "ch_1", paid.ID)
+	assert.Equal(t, 250, paid.Amount)
+	require.NotNil(t, paid.Source)
+	assert.Equal(t, `{"payment_id":3}`, paid.Source.InvoicePayload)
+	assert.Nil(t, paid.Receiver)
+
+	refund := resp.Transactions[1]
+	require.NotNil(t, refund.Receiver)
+	assert.Equal(t, int64(8), refund.Receiver.User.ID)
+}
+
+func TestSetWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/setWebhook", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"url":"https://api.example.com/hook","secret_token":"s3cret","allowed_updates":["message"]}`, string(body))
+
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("token", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.baseURL = srv.URL
+
+	err := client.SetWebhook(context.Background(), SetWebhookRequest{
+		URL:            "https://api.example.com/hook",
+		SecretToken:    "s3cret",
+		AllowedUpdates: []string{"message"},
+	})
+	require.NoError(t, err)
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text    string
+		command string
+		args    string
+		ok      bool
+	}{
+		{"/start", "start", "", true},
+		{"/mute 8", "mute", "8", true},
+		{"/Mute@WeqoryBot  24 ", "mute", "24", true},
+		{"hello", "", "", false},
+		{"/", "", "", false},
+	}
+
+	for _, tt := range tests {
+		command, args, ok := ParseCommand(tt.text)
+		assert.Equal(t, tt.ok, ok, tt.text)
+		assert.Equal(t, tt.command, command, tt.text)
+		assert.Equal(t, tt.args, args, tt.text)
+	}
+}
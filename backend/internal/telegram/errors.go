@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reasons a chat can no longer receive messages from the bot
+const (
+	UnreachableBlocked      = "blocked"
+	UnreachableChatNotFound = "chat_not_found"
+	UnreachableDeactivated  = "deactivated"
+)
+
+// APIError is an error response of the Bot API to a send method
+type APIError struct {
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram API error: %s (code: %d)", e.Description, e.Code)
+}
+
+// UnreachableReason returns why a send failed for good because the user
+// blocked the bot, deleted their account or the chat does not exist, and
+// an empty string for failures worth retrying
+func UnreachableReason(err error) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	description := strings.ToLower(apiErr.Description)
+	switch {
+	case strings.Contains(description, "bot was blocked by the user"):
+		return UnreachableBlocked
+	case strings.Contains(description, "user is deactivated"):
+		return UnreachableDeactivated
+	case strings.Contains(description, "chat not found"):
+		return UnreachableChatNotFound
+	default:
+		return ""
+	}
+}
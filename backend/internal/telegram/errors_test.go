@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnreachableReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"blocked", &APIError{Code: 403, Description: "Forbidden: bot was blocked by the user"}, UnreachableBlocked},
+		{"deactivated", &APIError{Code: 403, Description: "Forbidden: user is deactivated"}, UnreachableDeactivated},
+		{"chat not found", &APIError{Code: 400, Description: "Bad Request: chat not found"}, UnreachableChatNotFound},
+		{"wrapped", fmt.Errorf("send: %w", &APIError{Code: 403, Description: "Forbidden: bot was blocked by the user"}), UnreachableBlocked},
+		{"other API error", &APIError{Code: 400, Description: "Bad Request: message is too long"}, ""},
+		{"server error", &APIError{Code: 500, Description: "Internal Server Error"}, ""},
+		{"network error", errors.New("connection reset"), ""},
+		{"nil", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UnreachableReason(tt.err))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/weqory/backend/pkg/alerttype"
+)
+
+// Number formats of the values in messages, set per user
+const (
+	// NumberFormatAuto follows the user's language; languages without a
+	// known convention keep the plain 1234.56
+	NumberFormatAuto  = "auto"
+	NumberFormatPoint = "point" // 1,234.56
+	NumberFormatComma = "comma" // 1.234,56
+	NumberFormatSpace = "space" // 1 234,56
+)
+
+// languageNumberFormats maps base language codes to their usual number
+// format, for NumberFormatAuto
+var languageNumberFormats = map[string]string{
+	"de": NumberFormatComma,
+	"es": NumberFormatComma,
+	"id": NumberFormatComma,
+	"it": NumberFormatComma,
+	"nl": NumberFormatComma,
+	"pt": NumberFormatComma,
+	"tr": NumberFormatComma,
+	"vi": NumberFormatComma,
+	"cs": NumberFormatSpace,
+	"fr": NumberFormatSpace,
+	"pl": NumberFormatSpace,
+	"ru": NumberFormatSpace,
+	"sv": NumberFormatSpace,
+	"uk": NumberFormatSpace,
+}
+
+// resolveNumberFormat returns the number format to use for a user's format
+// setting and language
+func resolveNumberFormat(format, language string) string {
+	switch format {
+	case NumberFormatPoint, NumberFormatComma, NumberFormatSpace:
+		return format
+	}
+	base, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(language, "_", "-")), "-")
+	return languageNumberFormats[base]
+}
+
+// localizeNumber rewrites a number formatted with strconv or fmt, such as
+// "-1234.56", in a resolved number format. Unknown formats leave it as is
+func localizeNumber(s, format string) string {
+	var group, decimal string
+	switch format {
+	case NumberFormatPoint:
+		group, decimal = ",", "."
+	case NumberFormatComma:
+		group, decimal = ".", ","
+	case NumberFormatSpace:
+		// No-break space, so a price is never split across lines
+		group, decimal = "\u00a0", ","
+	default:
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(group)
+		}
+		b.WriteRune(c)
+	}
+	if hasFrac {
+		b.WriteString(decimal)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// DescribeCondition describes the condition of an alert, like
+// Definition.DescribeIn, with the value in the user's number format
+func DescribeCondition(def *alerttype.Definition, value float64, quote, numberFormat, language string) string {
+	format := resolveNumberFormat(numberFormat, language)
+	if format == "" || !strings.Contains(def.Condition, "%s") {
+		return def.DescribeIn(value, quote)
+	}
+	v := strconv.FormatFloat(value, 'f', -1, 64)
+	formatted := strings.Replace(def.Unit.FormatIn(value, quote), v, localizeNumber(v, format), 1)
+	return fmt.Sprintf(def.Condition, formatted)
+}
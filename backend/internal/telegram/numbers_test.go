@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/alerttype"
+)
+
+func TestLocalizeNumber(t *testing.T) {
+	assert.Equal(t, "1234567.89", localizeNumber("1234567.89", ""))
+	assert.Equal(t, "1,234,567.89", localizeNumber("1234567.89", NumberFormatPoint))
+	assert.Equal(t, "-1.234,5", localizeNumber("-1234.5", NumberFormatComma))
+	assert.Equal(t, "12 345", localizeNumber("12345", NumberFormatSpace))
+	assert.Equal(t, "999,00", localizeNumber("999.00", NumberFormatComma))
+}
+
+func TestAlertValues_NumberFormat(t *testing.T) {
+	n := AlertNotification{
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: 70000,
+		TriggeredPrice: 70123.5,
+		Language:       "de",
+	}
+
+	_, current, _, target := alertValues(n)
+	assert.Equal(t, "$70.123,50", current, "follows the language")
+	assert.Equal(t, "$70.000,00", target)
+
+	n.NumberFormat = NumberFormatPoint
+	_, current, _, _ = alertValues(n)
+	assert.Equal(t, "$70,123.50", current, "explicit format wins")
+
+	def, ok := alerttype.Lookup("PRICE_ABOVE")
+	require.True(t, ok)
+	assert.Equal(t, def.Describe(70000), DescribeCondition(def, 70000, "", NumberFormatAuto, "en"))
+	assert.Contains(t, DescribeCondition(def, 70000, "", NumberFormatSpace, ""), "$70 000")
+}
+
+func TestAlertValues_QuoteAsset(t *testing.T) {
+	n := AlertNotification{
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: 0.05,
+		TriggeredPrice: 0.0512,
+		QuoteAsset:     "BTC",
+		Language:       "de",
+	}
+
+	_, current, _, target := alertValues(n)
+	assert.Equal(t, "0,051200 BTC", current)
+	assert.Equal(t, "0,050000 BTC", target)
+
+	def, ok := alerttype.Lookup("PRICE_ABOVE")
+	require.True(t, ok)
+	assert.Equal(t, "above 0,05 BTC", DescribeCondition(def, 0.05, "BTC", NumberFormatAuto, "de"))
+}
@@ -0,0 +1,357 @@
+// Package telegramtest provides a fake Telegram Bot API server speaking the
+// methods used by the telegram package, for tests. Failures and rate limits
+// can be queued per method so retry logic can be exercised deterministically
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/internal/telegram"
+)
+
+// Request is a Bot API call received by the server
+type Request struct {
+	Token  string
+	Method string
+	Body   []byte
+	// Failed is set when the call was answered with an error
+	Failed bool
+}
+
+// Failure is an error response returned instead of handling a call
+type Failure struct {
+	// StatusCode is the HTTP status, ErrorCode when zero
+	StatusCode  int
+	ErrorCode   int
+	Description string
+	// RetryAfter is reported in the response parameters when positive
+	RetryAfter int
+}
+
+// RateLimited is the response Telegram sends when flood control kicks in
+func RateLimited(retryAfter int) Failure {
+	return Failure{
+		ErrorCode:   http.StatusTooManyRequests,
+		Description: fmt.Sprintf("Too Many Requests: retry after %d", retryAfter),
+		RetryAfter:  retryAfter,
+	}
+}
+
+// Common failures of sendMessage
+var (
+	BotBlocked = Failure{
+		ErrorCode:   http.StatusForbidden,
+		Description: "Forbidden: bot was blocked by the user",
+	}
+	ChatNotFound = Failure{
+		ErrorCode:   http.StatusBadRequest,
+		Description: "Bad Request: chat not found",
+	}
+	InternalError = Failure{
+		ErrorCode:   http.StatusInternalServerError,
+		Description: "Internal Server Error",
+	}
+
+	notFound = Failure{ErrorCode: http.StatusNotFound, Description: "Not Found"}
+)
+
+// badRequest is the failure of an invalid call
+func badRequest(description string) *Failure {
+	return &Failure{ErrorCode: http.StatusBadRequest, Description: "Bad Request: " + description}
+}
+
+// Photo is a photo uploaded with sendPhoto
+type Photo struct {
+	ChatID              int64  `json:"chat_id"`
+	Caption             string `json:"caption"`
+	DisableNotification bool   `json:"disable_notification"`
+	Photo               []byte `json:"photo"`
+}
+
+// Server is a fake Bot API answering sendMessage, sendPhoto, getMe,
+// createInvoiceLink, answerPreCheckoutQuery and getStarTransactions under
+// /bot<token>/. Mount it
+// with httptest.NewServer and point the client at it with
+// telegram.Client.SetAPIURL
+type Server struct {
+	mu       sync.Mutex
+	bot      telegram.User
+	requests []Request
+	failures map[string][]Failure
+	nextID   int64
+	stars    []telegram.StarTransaction
+}
+
+// NewServer creates a fake Bot API server
+func NewServer() *Server {
+	return &Server{
+		bot: telegram.User{
+			ID:        1000000001,
+			IsBot:     true,
+			FirstName: "Weqory",
+			Username:  "weqory_test_bot",
+		},
+		failures: make(map[string][]Failure),
+	}
+}
+
+// SetBot sets the user returned by getMe
+func (s *Server) SetBot(bot telegram.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bot = bot
+}
+
+// SetStarTransactions sets the transactions paged through by
+// getStarTransactions, in the order given
+func (s *Server) SetStarTransactions(transactions []telegram.StarTransaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stars = transactions
+}
+
+// FailNext makes the next calls of method return failures, one per call
+// in order, before it succeeds again. Calls add to the failures already
+// queued
+func (s *Server) FailNext(method string, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], failures...)
+}
+
+// Requests returns the calls of method received so far, or every call
+// when method is empty, failed ones included
+func (s *Server) Requests(method string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, r := range s.requests {
+		if method == "" || r.Method == method {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// Messages returns the messages sent successfully
+func (s *Server) Messages() []telegram.SendMessageRequest {
+	return decodeSucceeded[telegram.SendMessageRequest](s, "sendMessage")
+}
+
+// Photos returns the photos sent successfully
+func (s *Server) Photos() []Photo {
+	return decodeSucceeded[Photo](s, "sendPhoto")
+}
+
+// InvoiceLinks returns the invoice links created successfully
+func (s *Server) InvoiceLinks() []telegram.CreateInvoiceLinkRequest {
+	return decodeSucceeded[telegram.CreateInvoiceLinkRequest](s, "createInvoiceLink")
+}
+
+// PreCheckoutAnswers returns the pre-checkout queries answered successfully
+func (s *Server) PreCheckoutAnswers() []telegram.AnswerPreCheckoutQueryRequest {
+	return decodeSucceeded[telegram.AnswerPreCheckoutQueryRequest](s, "answerPreCheckoutQuery")
+}
+
+// Reset forgets the received calls and the queued failures
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.failures = make(map[string][]Failure)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, method, ok := parsePath(r.URL.Path)
+	if !ok {
+		writeError(w, notFound)
+		return
+	}
+
+	var body []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		// Uploads are recorded as the JSON of a Photo
+		body = decodePhoto(r)
+	} else if r.Body != nil {
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err == nil {
+			body = raw
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, failure := s.handle(method, body)
+	s.requests = append(s.requests, Request{
+		Token:  token,
+		Method: method,
+		Body:   body,
+		Failed: failure != nil,
+	})
+
+	if failure != nil {
+		writeError(w, *failure)
+		return
+	}
+	writeResult(w, result)
+}
+
+// handle answers a call, or returns the failure to respond with. Must be
+// called with s.mu held
+func (s *Server) handle(method string, body []byte) (any, *Failure) {
+	if queued := s.failures[method]; len(queued) > 0 {
+		s.failures[method] = queued[1:]
+		return nil, &queued[0]
+	}
+
+	switch method {
+	case "sendMessage":
+		var req telegram.SendMessageRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.ChatID == 0 {
+			return nil, &ChatNotFound
+		}
+		if strings.TrimSpace(req.Text) == "" {
+			return nil, badRequest("message text is empty")
+		}
+		s.nextID++
+		return telegram.SentMessage{
+			MessageID: s.nextID,
+			Chat:      &telegram.Chat{ID: req.ChatID, Type: "private"},
+			Date:      time.Now().Unix(),
+			Text:      req.Text,
+		}, nil
+
+	case "sendPhoto":
+		var req Photo
+		if err := json.Unmarshal(body, &req); err != nil || req.ChatID == 0 {
+			return nil, &ChatNotFound
+		}
+		if len(req.Photo) == 0 {
+			return nil, badRequest("there is no photo in the request")
+		}
+		s.nextID++
+		return telegram.SentMessage{
+			MessageID: s.nextID,
+			Chat:      &telegram.Chat{ID: req.ChatID, Type: "private"},
+			Date:      time.Now().Unix(),
+		}, nil
+
+	case "getMe":
+		return s.bot, nil
+
+	case "createInvoiceLink":
+		var req telegram.CreateInvoiceLinkRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.Payload == "" || len(req.Prices) == 0 {
+			return nil, badRequest("invalid invoice")
+		}
+		s.nextID++
+		return fmt.Sprintf("https://t.me/$invoice-%d", s.nextID), nil
+
+	case "answerPreCheckoutQuery":
+		var req telegram.AnswerPreCheckoutQueryRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.PreCheckoutQueryID == "" {
+			return nil, badRequest("QUERY_ID_INVALID")
+		}
+		return true, nil
+
+	case "getStarTransactions":
+		var req telegram.GetStarTransactionsRequest
+		_ = json.Unmarshal(body, &req)
+		if req.Limit <= 0 || req.Limit > 100 {
+			req.Limit = 100
+		}
+		start := min(max(req.Offset, 0), len(s.stars))
+		end := min(start+req.Limit, len(s.stars))
+		return telegram.StarTransactions{Transactions: s.stars[start:end]}, nil
+
+	default:
+		return nil, &notFound
+	}
+}
+
+// decodeSucceeded decodes the bodies of the successful calls of method
+func decodeSucceeded[T any](s *Server, method string) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []T
+	for _, r := range s.requests {
+		if r.Method != method || r.Failed {
+			continue
+		}
+		var item T
+		if err := json.Unmarshal(r.Body, &item); err == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// decodePhoto reads a sendPhoto upload as the JSON of a Photo, nil when
+// the form is invalid
+func decodePhoto(r *http.Request) []byte {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		return nil
+	}
+
+	var photo Photo
+	photo.ChatID, _ = strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	photo.Caption = r.FormValue("caption")
+	photo.DisableNotification = r.FormValue("disable_notification") == "true"
+	if file, _, err := r.FormFile("photo"); err == nil {
+		photo.Photo, _ = io.ReadAll(file)
+		file.Close()
+	}
+
+	body, _ := json.Marshal(photo)
+	return body
+}
+
+// parsePath splits /bot<token>/<method>
+func parsePath(path string) (token, method string, ok bool) {
+	rest, found := strings.CutPrefix(path, "/bot")
+	if !found {
+		return "", "", false
+	}
+	token, method, found = strings.Cut(rest, "/")
+	if !found || token == "" || method == "" {
+		return "", "", false
+	}
+	return token, method, true
+}
+
+// writeResult writes a successful Bot API response
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// writeError writes a failed Bot API response
+func writeError(w http.ResponseWriter, f Failure) {
+	resp := telegram.APIResponse{
+		OK:          false,
+		Description: f.Description,
+		ErrorCode:   f.ErrorCode,
+	}
+	if f.RetryAfter > 0 {
+		resp.Parameters = &telegram.ResponseParameters{RetryAfter: f.RetryAfter}
+	}
+
+	status := f.StatusCode
+	if status == 0 {
+		status = f.ErrorCode
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
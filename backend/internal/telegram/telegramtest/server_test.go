@@ -0,0 +1,130 @@
+package telegramtest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/telegram"
+)
+
+func newTestClient(t *testing.T) (*Server, *telegram.Client) {
+	srv := NewServer()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	client := telegram.NewClient("123:test", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client.SetAPIURL(ts.URL)
+	return srv, client
+}
+
+func TestServer_Methods(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	bot, err := client.GetMe(ctx)
+	require.NoError(t, err)
+	assert.True(t, bot.IsBot)
+
+	result, err := client.SendMessage(ctx, telegram.SendMessageRequest{ChatID: 42, Text: "hello"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, int64(1), result.MessageID)
+
+	link, err := client.CreateInvoiceLink(ctx, telegram.CreateInvoiceLinkRequest{
+		Title:   "Pro",
+		Payload: `{"payment_id":1}`,
+		Prices:  []telegram.LabeledPrice{{Label: "Pro", Amount: 250}},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, link)
+
+	err = client.AnswerPreCheckoutQuery(ctx, telegram.AnswerPreCheckoutQueryRequest{PreCheckoutQueryID: "q1", OK: true})
+	require.NoError(t, err)
+
+	require.Len(t, srv.Messages(), 1)
+	assert.Equal(t, "hello", srv.Messages()[0].Text)
+	require.Len(t, srv.InvoiceLinks(), 1)
+	assert.Equal(t, "XTR", srv.InvoiceLinks()[0].Currency)
+	require.Len(t, srv.PreCheckoutAnswers(), 1)
+	assert.Equal(t, "123:test", srv.Requests("getMe")[0].Token)
+	assert.Len(t, srv.Requests(""), 4)
+}
+
+func TestServer_FailNext(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+	srv.FailNext("sendMessage", RateLimited(7), BotBlocked)
+
+	result, err := client.SendMessage(ctx, telegram.SendMessageRequest{ChatID: 42, Text: "one"})
+	assert.Error(t, err)
+	assert.Equal(t, 7, result.RetryAfter)
+
+	_, err = client.SendMessage(ctx, telegram.SendMessageRequest{ChatID: 42, Text: "two"})
+	assert.ErrorContains(t, err, "blocked")
+
+	// Queued failures are used up; other methods are not affected
+	_, err = client.SendMessage(ctx, telegram.SendMessageRequest{ChatID: 42, Text: "three"})
+	require.NoError(t, err)
+
+	assert.Len(t, srv.Requests("sendMessage"), 3)
+	require.Len(t, srv.Messages(), 1)
+	assert.Equal(t, "three", srv.Messages()[0].Text)
+
+	srv.Reset()
+	assert.Empty(t, srv.Requests(""))
+}
+
+func TestServer_AlertDeliverySettings(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	n := telegram.AlertNotification{
+		TelegramID:     42,
+		CoinSymbol:     "BTC",
+		CoinName:       "Bitcoin",
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: 70000,
+		TriggeredPrice: 70100,
+		Silent:         true,
+		Chart:          []byte("png"),
+	}
+	_, err := client.SendAlertNotification(ctx, n, "")
+	require.NoError(t, err)
+
+	require.Len(t, srv.Photos(), 1)
+	photo := srv.Photos()[0]
+	assert.Equal(t, []byte("png"), photo.Photo)
+	assert.Contains(t, photo.Caption, "Bitcoin (BTC)")
+	assert.True(t, photo.DisableNotification)
+
+	// A failed upload still delivers the text
+	srv.FailNext("sendPhoto", InternalError)
+	n.Compact = true
+	_, err = client.SendAlertNotification(ctx, n, "")
+	require.NoError(t, err)
+
+	require.Len(t, srv.Messages(), 1)
+	assert.True(t, srv.Messages()[0].DisableNotification)
+	assert.NotContains(t, srv.Messages()[0].Text, "\n", "compact format")
+}
+
+func TestServer_StarTransactions(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	srv.SetStarTransactions([]telegram.StarTransaction{{ID: "a", Amount: 250}, {ID: "b", Amount: 500}, {ID: "c", Amount: 250}})
+
+	page, err := client.GetStarTransactions(ctx, telegram.GetStarTransactionsRequest{Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 1)
+	assert.Equal(t, "b", page.Transactions[0].ID)
+
+	page, err = client.GetStarTransactions(ctx, telegram.GetStarTransactionsRequest{Offset: 5, Limit: 100})
+	require.NoError(t, err)
+	assert.Empty(t, page.Transactions)
+}
@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Alert messages are rendered from templates named <language>/<name>.tmpl,
+// where name is an alert type (e.g. PERIODIC) or "alert" for every other
+// type. Lookup tries the user's language, its base language (pt-br -> pt)
+// and then English
+const (
+	defaultTemplateLanguage = "en"
+	defaultTemplateName     = "alert"
+	templateExt             = ".tmpl"
+)
+
+//go:embed templates/*/*.tmpl
+var builtinTemplateFS embed.FS
+
+// defaultTemplates are the built-in templates
+var defaultTemplates = func() *Templates {
+	t, err := NewTemplates("")
+	if err != nil {
+		panic(fmt.Sprintf("parse built-in message templates: %v", err))
+	}
+	return t
+}()
+
+// AlertMessageData is what alert message templates are executed with. The
+// notification's fields are available directly, e.g. {{.CoinSymbol}};
+// values are HTML-escaped by the template
+type AlertMessageData struct {
+	AlertNotification
+
+	// From the alert type registry
+	Icon         string
+	Action       string
+	CurrentLabel string
+	TargetLabel  string
+
+	// Formatted in the unit of the alert type, e.g. "$70000.00"
+	Current string
+	Target  string
+	// Trigger time in the user's timezone
+	Time string
+	// Describes HeldBack, e.g. "2 more BTC alerts triggered since the last
+	// message"
+	HeldBackText string
+}
+
+// Templates holds the alert message templates: the built-in ones, overridden
+// by those found in an optional directory. Safe for concurrent use
+type Templates struct {
+	dir string
+
+	mu  sync.RWMutex
+	set map[string]*template.Template // "<language>/<name>" -> template
+}
+
+// NewTemplates loads the built-in templates and the overrides in dir (empty
+// for none)
+func NewTemplates(dir string) (*Templates, error) {
+	t := &Templates{dir: dir}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload parses the templates again, picking up edits to the override
+// directory. On error the loaded templates stay in use
+func (t *Templates) Reload() error {
+	builtin, err := fs.Sub(builtinTemplateFS, "templates")
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]*template.Template)
+	if err := parseTemplates(builtin, set); err != nil {
+		return fmt.Errorf("built-in templates: %w", err)
+	}
+	if t.dir != "" {
+		if err := parseTemplates(os.DirFS(t.dir), set); err != nil {
+			return fmt.Errorf("templates in %s: %w", t.dir, err)
+		}
+	}
+
+	t.mu.Lock()
+	t.set = set
+	t.mu.Unlock()
+	return nil
+}
+
+// parseTemplates parses every <language>/<name>.tmpl of fsys into set
+func parseTemplates(fsys fs.FS, set map[string]*template.Template) error {
+	files, err := fs.Glob(fsys, "*/*"+templateExt)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		key := strings.TrimSuffix(file, templateExt)
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return err
+		}
+		set[key] = tmpl
+	}
+	return nil
+}
+
+// Render renders the alert message of n
+func (t *Templates) Render(n AlertNotification) (string, error) {
+	tmpl := t.lookup(n.Language, n.AlertType)
+	if tmpl == nil {
+		return "", fmt.Errorf("no message template for %s", n.AlertType)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newAlertMessageData(n)); err != nil {
+		return "", fmt.Errorf("render %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// lookup finds the template for an alert type in the closest language
+func (t *Templates) lookup(language, alertType string) *template.Template {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, lang := range templateLanguages(language) {
+		for _, name := range []string{alertType, defaultTemplateName} {
+			if tmpl, ok := t.set[path.Join(lang, name)]; ok {
+				return tmpl
+			}
+		}
+	}
+	return nil
+}
+
+// templateLanguages lists the languages to look templates up in, from the
+// most to the least specific
+func templateLanguages(language string) []string {
+	language = strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+
+	var langs []string
+	if language != "" {
+		langs = append(langs, language)
+		if base, _, ok := strings.Cut(language, "-"); ok {
+			langs = append(langs, base)
+		}
+	}
+	return append(langs, defaultTemplateLanguage)
+}
+
+// newAlertMessageData prepares the template data of n
+func newAlertMessageData(n AlertNotification) AlertMessageData {
+	icon, action := alertIconAndAction(n)
+	currentLabel, current, targetLabel, target := alertValues(n)
+
+	data := AlertMessageData{
+		AlertNotification: n,
+		Icon:              icon,
+		Action:            action,
+		CurrentLabel:      currentLabel,
+		TargetLabel:       targetLabel,
+		Current:           current,
+		Target:            target,
+		Time:              formatTriggeredAt(n.TriggeredAt, n.Timezone),
+	}
+	if n.HeldBack > 0 {
+		data.HeldBackText = heldBackText(n)
+	}
+	return data
+}
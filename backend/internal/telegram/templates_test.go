@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_RenderBuiltin(t *testing.T) {
+	n := AlertNotification{
+		CoinSymbol:     "BTC",
+		CoinName:       "Bitcoin",
+		AlertType:      "PRICE_ABOVE",
+		ConditionValue: 70000,
+		TriggeredPrice: 70123.5,
+		TriggeredAt:    time.Date(2026, 7, 1, 12, 30, 0, 0, time.UTC),
+		IsRecurring:    true,
+	}
+
+	text, err := defaultTemplates.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "🔺 <b>Alert Triggered!</b>\n\n"+
+		"<b>Bitcoin (BTC)</b> rose above\n\n"+
+		"💰 Current Price: <b>$70123.50</b>\n"+
+		"🎯 Target: $70000.00\n"+
+		"⏰ 12:30:00 UTC\n\n"+
+		"🔄 <i>This is a recurring alert</i>", text)
+
+	n.AlertType = "PERIODIC"
+	text, err = defaultTemplates.Render(n)
+	require.NoError(t, err)
+	assert.NotContains(t, text, "Target", "periodic updates have no target")
+}
+
+func TestTemplates_OverridesAndReload(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "de"), 0o755))
+	override := filepath.Join(dir, "de", "alert.tmpl")
+	require.NoError(t, os.WriteFile(override, []byte("{{.CoinSymbol}} {{.AlertName}} ausgelöst"), 0o644))
+
+	templates, err := NewTemplates(dir)
+	require.NoError(t, err)
+
+	n := AlertNotification{CoinSymbol: "ETH", AlertName: "<Ziel>", AlertType: "PRICE_BELOW", Language: "de-AT"}
+	text, err := templates.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "ETH &lt;Ziel&gt; ausgelöst", text, "base language, values escaped")
+
+	n.Language = "fr"
+	text, err = templates.Render(n)
+	require.NoError(t, err)
+	assert.Contains(t, text, "fell below", "falls back to English")
+
+	require.NoError(t, os.WriteFile(override, []byte("{{.CoinSymbol}} neu"), 0o644))
+	require.NoError(t, templates.Reload())
+	n.Language = "de"
+	text, err = templates.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "ETH neu", text)
+
+	require.NoError(t, os.WriteFile(override, []byte("{{.CoinSymbol"), 0o644))
+	assert.Error(t, templates.Reload())
+	text, err = templates.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "ETH neu", text, "a broken edit keeps the loaded templates")
+}
@@ -5,19 +5,23 @@ import (
 	"time"
 )
 
-// Update represents a Telegram update
+// Update represents a Telegram update delivered to the bot webhook. At
+// most one of its optional fields is set
 type Update struct {
-	UpdateID int64    `json:"update_id"`
-	Message  *Message `json:"message,omitempty"`
+	UpdateID         int64             `json:"update_id"`
+	Message          *Message          `json:"message,omitempty"`
+	CallbackQuery    *CallbackQuery    `json:"callback_query,omitempty"`
+	PreCheckoutQuery *PreCheckoutQuery `json:"pre_checkout_query,omitempty"`
 }
 
 // Message represents a Telegram message
 type Message struct {
-	MessageID int64  `json:"message_id"`
-	From      *User  `json:"from,omitempty"`
-	Chat      *Chat  `json:"chat"`
-	Date      int64  `json:"date"`
-	Text      string `json:"text,omitempty"`
+	MessageID         int64              `json:"message_id"`
+	From              *User              `json:"from,omitempty"`
+	Chat              *Chat              `json:"chat"`
+	Date              int64              `json:"date"`
+	Text              string             `json:"text,omitempty"`
+	SuccessfulPayment *SuccessfulPayment `json:"successful_payment,omitempty"`
 }
 
 // User represents a Telegram user
@@ -50,6 +54,31 @@ type SendMessageRequest struct {
 	ReplyMarkup           interface{} `json:"reply_markup,omitempty"`
 }
 
+// SendPhotoRequest represents a request to send an uploaded photo
+type SendPhotoRequest struct {
+	ChatID              int64
+	Photo               []byte // PNG
+	Caption             string
+	ParseMode           string
+	DisableNotification bool
+	ReplyMarkup         interface{}
+}
+
+// CallbackQuery represents a press of an inline keyboard button
+type CallbackQuery struct {
+	ID      string       `json:"id"`
+	From    *User        `json:"from"`
+	Message *SentMessage `json:"message,omitempty"`
+	Data    string       `json:"data,omitempty"`
+}
+
+// AnswerCallbackQueryRequest represents a request to answer a callback query
+type AnswerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+	ShowAlert       bool   `json:"show_alert,omitempty"`
+}
+
 // InlineKeyboardMarkup represents an inline keyboard
 type InlineKeyboardMarkup struct {
 	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
@@ -103,16 +132,42 @@ type NotificationResult struct {
 
 // AlertNotification represents an alert notification to send
 type AlertNotification struct {
+	AlertID        int64 // 0 for test notifications
 	UserID         int64
 	TelegramID     int64
 	CoinSymbol     string
 	CoinName       string
+	AlertName      string // user label shown in the message, empty when unnamed
+	Detail         string // what fired an event-driven alert, e.g. a whale transfer
 	AlertType      string
 	ConditionValue float64
 	TriggeredPrice float64
+	QuoteAsset     string // quote of ConditionValue and TriggeredPrice, e.g. BTC; empty is USD
 	TriggeredAt    time.Time
+	Timezone       string // user's IANA timezone for TriggeredAt; empty is UTC
+	Language       string // user's language code selecting the message template; empty is English
+	NumberFormat   string // user's number format of prices (NumberFormat*); empty follows Language
 	PriceChange    float64
 	IsRecurring    bool
+	// CopyVariant selects an experimental message text ("" or "control" for
+	// the regular one)
+	CopyVariant string
+	// IsTest marks a sample alert sent on request to verify delivery
+	IsTest bool
+	// HeldBack counts other alerts of the coin folded into this message by
+	// the per-coin throttle
+	HeldBack int
+	// Delivery settings of the user: Compact sends the one-line text,
+	// Silent sends without sound and Chart, a PNG, is sent as a photo with
+	// the text as caption
+	Compact bool
+	Silent  bool
+	Chart   []byte `json:"-"` // not kept with throttled alerts
+	// Pipeline stamps for latency tracking: when the price tick reached
+	// the alert engine and when the trigger was published. Not kept with
+	// throttled alerts, whose summary is late by design
+	TickReceivedAt time.Time `json:"-"`
+	PublishedAt    time.Time `json:"-"`
 }
 
 // ========== Telegram Stars Payment Types ==========
@@ -228,18 +283,44 @@ type AnswerPreCheckoutQueryRequest struct {
 	ErrorMessage       string `json:"error_message,omitempty"`
 }
 
-// PaymentUpdate represents a Telegram update with payment information
-type PaymentUpdate struct {
-	UpdateID         int64              `json:"update_id"`
-	Message          *PaymentMessage    `json:"message,omitempty"`
-	PreCheckoutQuery *PreCheckoutQuery  `json:"pre_checkout_query,omitempty"`
+// StarTransactions is a page of the bot's Telegram Stars transactions
+type StarTransactions struct {
+	Transactions []StarTransaction `json:"transactions"`
 }
 
-// PaymentMessage represents a message that may contain payment info
-type PaymentMessage struct {
-	MessageID         int64              `json:"message_id"`
-	From              *User              `json:"from,omitempty"`
-	Chat              *Chat              `json:"chat"`
-	Date              int64              `json:"date"`
-	SuccessfulPayment *SuccessfulPayment `json:"successful_payment,omitempty"`
+// StarTransaction is a Telegram Stars transaction of the bot. For incoming
+// payments ID equals SuccessfulPayment.TelegramPaymentChargeID; a refund
+// has the ID of the payment it refunds
+type StarTransaction struct {
+	ID       string              `json:"id"`
+	Amount   int                 `json:"amount"`
+	Date     int64               `json:"date"`
+	Source   *TransactionPartner `json:"source,omitempty"`
+	Receiver *TransactionPartner `json:"receiver,omitempty"`
+}
+
+// TransactionPartner is the other side of a Star transaction
+type TransactionPartner struct {
+	// "user" for payments and refunds of users
+	Type           string `json:"type"`
+	User           *User  `json:"user,omitempty"`
+	InvoicePayload string `json:"invoice_payload,omitempty"`
+}
+
+// GetStarTransactionsRequest represents a request for Star transactions
+type GetStarTransactionsRequest struct {
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+// WebhookSecretHeader carries the secret_token given to setWebhook on every
+// update Telegram delivers to the webhook
+const WebhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// SetWebhookRequest represents a request to set the bot's webhook
+type SetWebhookRequest struct {
+	URL                string   `json:"url"`
+	SecretToken        string   `json:"secret_token,omitempty"`
+	AllowedUpdates     []string `json:"allowed_updates,omitempty"`
+	DropPendingUpdates bool     `json:"drop_pending_updates,omitempty"`
 }
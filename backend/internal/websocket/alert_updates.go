@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+const (
+	// UserTopicPrefix prefixes the private per-user topics that
+	// authenticated clients are subscribed to on connect
+	UserTopicPrefix = "user:"
+
+	// Event bus topic for alert updates (must match alert package)
+	alertUpdatesChannel = "alert:updates"
+)
+
+// UserTopic returns the private topic of a user's sessions
+func UserTopic(userID int64) string {
+	return UserTopicPrefix + strconv.FormatInt(userID, 10)
+}
+
+// AlertUpdateRelay forwards alert updates from the event bus to the open
+// sessions of the alert's owner as alert_updated messages
+type AlertUpdateRelay struct {
+	bus    eventbus.Subscriber
+	hub    *Hub
+	logger *slog.Logger
+}
+
+// NewAlertUpdateRelay creates a new AlertUpdateRelay
+func NewAlertUpdateRelay(bus eventbus.Subscriber, hub *Hub, logger *slog.Logger) *AlertUpdateRelay {
+	return &AlertUpdateRelay{
+		bus:    bus,
+		hub:    hub,
+		logger: logger,
+	}
+}
+
+// Run relays alert updates until ctx is done, resubscribing after errors
+func (r *AlertUpdateRelay) Run(ctx context.Context) {
+	backoff := reconnectDelay
+
+	for {
+		err := r.bus.Subscribe(ctx, alertUpdatesChannel, func(_ context.Context, msg eventbus.Message) {
+			r.handle(msg.Data)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		r.logger.Error("alert update subscription error, reconnecting",
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", backoff),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxReconnectDelay)
+	}
+}
+
+// handle sends an alert update to its owner's sessions. The update is
+// passed on as is, so it carries whatever the publisher put in it
+func (r *AlertUpdateRelay) handle(data []byte) {
+	var update struct {
+		AlertID   int64  `json:"alertId"`
+		UserID    int64  `json:"userId"`
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal(data, &update); err != nil || update.UserID <= 0 {
+		r.logger.Error("dropping malformed alert update")
+		return
+	}
+
+	r.logger.Debug("relaying alert update",
+		slog.Int64("alert_id", update.AlertID),
+		slog.Int64("user_id", update.UserID),
+		slog.String("request_id", update.RequestID),
+	)
+
+	r.hub.Publish(UserTopic(update.UserID), MessageTypeAlertUpdated, json.RawMessage(data))
+}
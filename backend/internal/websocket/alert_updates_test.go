@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertUpdateRelay_SendsToOwner(t *testing.T) {
+	hub := newTestHub(t)
+	relay := NewAlertUpdateRelay(nil, hub, hub.logger)
+
+	owner := newTestClient(hub, "owner", 4)
+	other := newTestClient(hub, "other", 4)
+	hub.Register(owner)
+	hub.Register(other)
+	hub.Subscribe(owner, []string{UserTopic(7)})
+	hub.Subscribe(other, []string{UserTopic(8)})
+
+	relay.handle([]byte(`{"alertId":42,"userId":7,"reason":"paused","isPaused":true}`))
+	relay.handle([]byte(`{"alertId":43}`))
+
+	var msg Message
+	require.NoError(t, json.Unmarshal(<-owner.Send, &msg))
+	assert.Equal(t, MessageTypeAlertUpdated, msg.Type)
+	assert.JSONEq(t, `{"alertId":42,"userId":7,"reason":"paused","isPaused":true}`, string(msg.Payload))
+	assert.Empty(t, owner.Send)
+	assert.Empty(t, other.Send)
+}
+
+func TestAlertUpdateRelay_LogsRequestID(t *testing.T) {
+	hub := newTestHub(t)
+	var logs bytes.Buffer
+	relay := NewAlertUpdateRelay(nil, hub, slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	owner := newTestClient(hub, "owner", 4)
+	hub.Register(owner)
+	hub.Subscribe(owner, []string{UserTopic(7)})
+
+	relay.handle([]byte(`{"alertId":42,"userId":7,"reason":"paused","requestId":"req-1"}`))
+	<-owner.Send
+	assert.Contains(t, logs.String(), "request_id=req-1")
+}
+
+func TestHandler_UserTopicRequiresOwner(t *testing.T) {
+	hub := newTestHub(t)
+	handler := NewHandler(hub, hub.logger)
+
+	other := newTestClient(hub, "other", 4)
+	other.UserID = 8
+	hub.Register(other)
+
+	handler.handleMessage(other, []byte(`{"type":"subscribe","payload":{"symbols":["user:7"]}}`))
+	assert.False(t, other.Subscriptions[UserTopic(7)])
+	assert.Contains(t, string(<-other.Send), "not allowed")
+}
@@ -0,0 +1,172 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+const (
+	// DepthTopicPrefix prefixes the order book depth topics, e.g.
+	// depth:BTCUSDT. Subscribing to one starts streaming the symbol's depth
+	// from Binance
+	DepthTopicPrefix = "depth:"
+
+	// DefaultDepthIdleTimeout is how long a depth stream is kept without
+	// subscribers before it is stopped
+	DefaultDepthIdleTimeout = 2 * time.Minute
+
+	// How often streams without subscribers are checked
+	depthSweepInterval = 15 * time.Second
+
+	// maxDepthSymbols caps the depth streams open at once, each being a
+	// Binance stream and a message per second
+	maxDepthSymbols = 50
+)
+
+// ErrDepthLimit is returned when too many order books are already streamed
+var ErrDepthLimit = errors.New("too many order books streamed, try again later")
+
+// DepthTopic returns the order book depth topic of a Binance symbol
+func DepthTopic(symbol string) string {
+	return DepthTopicPrefix + symbol
+}
+
+// DepthUpdate is the payload of a depth_update message
+type DepthUpdate struct {
+	Symbol    string               `json:"symbol"`
+	Bids      []binance.DepthLevel `json:"bids"`
+	Asks      []binance.DepthLevel `json:"asks"`
+	UpdatedAt string               `json:"updatedAt"`
+}
+
+// DepthStreamer streams order book depth, e.g. the Binance client
+type DepthStreamer interface {
+	SubscribeDepth(symbols []string) error
+	UnsubscribeDepth(symbols []string) error
+}
+
+// DepthRelay streams the order book depth of the symbols clients are
+// viewing and relays it to the subscribers of their depth topic. Streams
+// are stopped once nobody subscribed for the idle timeout
+type DepthRelay struct {
+	hub         *Hub
+	streamer    DepthStreamer
+	idleTimeout time.Duration
+	logger      *slog.Logger
+	clock       clock.Clock
+
+	mu sync.Mutex
+	// Streamed symbols and when they last had subscribers
+	lastActive map[string]time.Time
+}
+
+// NewDepthRelay creates a DepthRelay; idleTimeout 0 uses
+// DefaultDepthIdleTimeout
+func NewDepthRelay(hub *Hub, streamer DepthStreamer, idleTimeout time.Duration, logger *slog.Logger) *DepthRelay {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultDepthIdleTimeout
+	}
+	return &DepthRelay{
+		hub:         hub,
+		streamer:    streamer,
+		idleTimeout: idleTimeout,
+		logger:      logger,
+		clock:       clock.Real{},
+		lastActive:  make(map[string]time.Time),
+	}
+}
+
+// SetClock replaces the clock, for tests
+func (r *DepthRelay) SetClock(clk clock.Clock) {
+	r.clock = clk
+}
+
+// Watch starts streaming the depth of symbol unless it already is
+func (r *DepthRelay) Watch(symbol string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lastActive[symbol]; ok {
+		r.lastActive[symbol] = r.clock.Now()
+		return nil
+	}
+	if len(r.lastActive) >= maxDepthSymbols {
+		return ErrDepthLimit
+	}
+
+	if err := r.streamer.SubscribeDepth([]string{symbol}); err != nil {
+		return err
+	}
+	r.lastActive[symbol] = r.clock.Now()
+	return nil
+}
+
+// Handle relays an order book snapshot to the subscribers of its topic
+func (r *DepthRelay) Handle(data binance.DepthData) {
+	r.hub.Publish(DepthTopic(data.Symbol), MessageTypeDepthUpdate, DepthUpdate{
+		Symbol:    data.Symbol,
+		Bids:      data.Bids,
+		Asks:      data.Asks,
+		UpdatedAt: data.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// Run stops idle streams until ctx is done
+func (r *DepthRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(depthSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Sweep()
+		}
+	}
+}
+
+// Sweep stops streaming the symbols nobody subscribed to for the idle
+// timeout
+func (r *DepthRelay) Sweep() {
+	now := r.clock.Now()
+
+	r.mu.Lock()
+	var idle []string
+	for symbol, last := range r.lastActive {
+		if r.hub.HasSubscribers(DepthTopic(symbol)) {
+			r.lastActive[symbol] = now
+			continue
+		}
+		if now.Sub(last) >= r.idleTimeout {
+			idle = append(idle, symbol)
+			delete(r.lastActive, symbol)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(idle) == 0 {
+		return
+	}
+	if err := r.streamer.UnsubscribeDepth(idle); err != nil {
+		r.logger.Error("failed to unsubscribe from order book depth",
+			slog.Any("symbols", idle),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	r.logger.Debug("stopped idle order book streams", slog.Any("symbols", idle))
+}
+
+// Symbols returns the number of symbols whose depth is streamed
+func (r *DepthRelay) Symbols() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.lastActive)
+}
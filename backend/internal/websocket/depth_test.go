@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/internal/binance"
+	"github.com/weqory/backend/pkg/clock"
+)
+
+type fakeDepthStreamer struct {
+	subscribed   []string
+	unsubscribed []string
+}
+
+func (f *fakeDepthStreamer) SubscribeDepth(symbols []string) error {
+	f.subscribed = append(f.subscribed, symbols...)
+	return nil
+}
+
+func (f *fakeDepthStreamer) UnsubscribeDepth(symbols []string) error {
+	f.unsubscribed = append(f.unsubscribed, symbols...)
+	return nil
+}
+
+func TestDepthRelay_RelaysAndStopsIdleStreams(t *testing.T) {
+	hub := newTestHub(t)
+	streamer := &fakeDepthStreamer{}
+	clk := clock.NewFake(time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC))
+	relay := NewDepthRelay(hub, streamer, time.Minute, hub.logger)
+	relay.SetClock(clk)
+
+	client := newTestClient(hub, "viewer", 4)
+	hub.Subscribe(client, []string{DepthTopic("BTCUSDT")})
+	require.NoError(t, relay.Watch("BTCUSDT"))
+	require.NoError(t, relay.Watch("BTCUSDT"))
+	assert.Equal(t, []string{"BTCUSDT"}, streamer.subscribed, "streamed once")
+
+	relay.Handle(binance.DepthData{
+		Symbol:    "BTCUSDT",
+		Bids:      []binance.DepthLevel{{Price: 70000, Quantity: 1.5}},
+		Asks:      []binance.DepthLevel{{Price: 70001, Quantity: 0.2}},
+		UpdatedAt: clk.Now(),
+	})
+	var msg Message
+	require.NoError(t, json.Unmarshal(<-client.Send, &msg))
+	assert.Equal(t, MessageTypeDepthUpdate, msg.Type)
+	var update DepthUpdate
+	require.NoError(t, json.Unmarshal(msg.Payload, &update))
+	assert.Equal(t, 70000.0, update.Bids[0].Price)
+
+	// Watched streams are kept however long ago they started
+	clk.Advance(5 * time.Minute)
+	relay.Sweep()
+	assert.Empty(t, streamer.unsubscribed)
+
+	hub.Unsubscribe(client, []string{DepthTopic("BTCUSDT")})
+	clk.Advance(30 * time.Second)
+	relay.Sweep()
+	assert.Empty(t, streamer.unsubscribed, "within the idle timeout")
+
+	clk.Advance(30 * time.Second)
+	relay.Sweep()
+	assert.Equal(t, []string{"BTCUSDT"}, streamer.unsubscribed)
+	assert.Equal(t, 0, relay.Symbols())
+}
+
+func TestDepthRelay_Limit(t *testing.T) {
+	hub := newTestHub(t)
+	relay := NewDepthRelay(hub, &fakeDepthStreamer{}, 0, hub.logger)
+
+	for i := 0; i < maxDepthSymbols; i++ {
+		require.NoError(t, relay.Watch(fmt.Sprintf("COIN%dUSDT", i)))
+	}
+	assert.ErrorIs(t, relay.Watch("BTCUSDT"), ErrDepthLimit)
+}
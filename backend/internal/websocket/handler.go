@@ -1,8 +1,11 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
@@ -24,10 +27,15 @@ const (
 	maxMessageSize = 512
 )
 
+// UserIDLocal is the connection local holding the authenticated user ID
+const UserIDLocal = "ws_user_id"
+
 // Handler handles WebSocket connections
 type Handler struct {
-	hub    *Hub
-	logger *slog.Logger
+	hub        *Hub
+	logger     *slog.Logger
+	watchlists *WatchlistSummaryPublisher
+	depth      *DepthRelay
 }
 
 // NewHandler creates a new WebSocket handler
@@ -38,6 +46,17 @@ func NewHandler(hub *Hub, logger *slog.Logger) *Handler {
 	}
 }
 
+// SetWatchlistSummaries makes subscribing to a watchlist topic publish the
+// current summary right away instead of at the next interval
+func (h *Handler) SetWatchlistSummaries(p *WatchlistSummaryPublisher) {
+	h.watchlists = p
+}
+
+// SetDepthRelay enables the order book depth topics
+func (h *Handler) SetDepthRelay(r *DepthRelay) {
+	h.depth = r
+}
+
 // Upgrade returns a middleware that upgrades HTTP to WebSocket
 func (h *Handler) Upgrade() fiber.Handler {
 	return websocket.New(h.HandleConnection, websocket.Config{
@@ -55,9 +74,17 @@ func (h *Handler) HandleConnection(conn *websocket.Conn) {
 		Subscriptions: make(map[string]bool),
 		Send:          make(chan []byte, 256),
 	}
+	if userID, ok := conn.Locals(UserIDLocal).(int64); ok {
+		client.UserID = userID
+	}
 
 	h.hub.Register(client)
 
+	// Authenticated sessions get the updates of their user's own data
+	if client.UserID != 0 {
+		h.hub.Subscribe(client, []string{UserTopic(client.UserID)})
+	}
+
 	// Start goroutines for reading and writing
 	go h.writePump(client)
 	h.readPump(client)
@@ -107,7 +134,11 @@ func (h *Handler) writePump(client *Client) {
 			client.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed the channel
-				client.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMsg := []byte{}
+				if client.Evicted() {
+					closeMsg = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "client too slow")
+				}
+				client.Conn.WriteMessage(websocket.CloseMessage, closeMsg)
 				return
 			}
 
@@ -143,7 +174,24 @@ func (h *Handler) handleMessage(client *Client, data []byte) {
 			h.sendError(client, "too many symbols (max 100)")
 			return
 		}
+		for _, topic := range payload.Symbols {
+			if strings.HasPrefix(topic, WatchlistTopicPrefix) && !canSubscribeWatchlist(client, topic) {
+				h.sendError(client, "not allowed to subscribe to "+topic)
+				return
+			}
+			if strings.HasPrefix(topic, UserTopicPrefix) && !canSubscribeUser(client, topic) {
+				h.sendError(client, "not allowed to subscribe to "+topic)
+				return
+			}
+			if symbol, ok := strings.CutPrefix(topic, DepthTopicPrefix); ok {
+				if err := h.watchDepth(symbol); err != nil {
+					h.sendError(client, "cannot subscribe to "+topic+": "+err.Error())
+					return
+				}
+			}
+		}
 		h.hub.Subscribe(client, payload.Symbols)
+		h.publishWatchlist(client, payload.Symbols)
 
 	case MessageTypeUnsubscribe:
 		var payload SubscribePayload
@@ -161,6 +209,57 @@ func (h *Handler) handleMessage(client *Client, data []byte) {
 	}
 }
 
+// watchDepth starts streaming the order book depth of a Binance symbol
+func (h *Handler) watchDepth(symbol string) error {
+	if h.depth == nil {
+		return errors.New("order book depth is not available")
+	}
+	if !isBinanceSymbol(symbol) {
+		return errors.New("invalid symbol")
+	}
+	return h.depth.Watch(symbol)
+}
+
+// isBinanceSymbol reports whether s looks like a Binance symbol, e.g. BTCUSDT
+func isBinanceSymbol(s string) bool {
+	if len(s) < 2 || len(s) > 20 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// canSubscribeWatchlist reports whether client may subscribe to a watchlist
+// topic: only the authenticated owner may
+func canSubscribeWatchlist(client *Client, topic string) bool {
+	return client.UserID != 0 && topic == WatchlistTopic(client.UserID)
+}
+
+// canSubscribeUser reports whether client may subscribe to a user topic:
+// only the authenticated user may, though they already are on connect
+func canSubscribeUser(client *Client, topic string) bool {
+	return client.UserID != 0 && topic == UserTopic(client.UserID)
+}
+
+// publishWatchlist sends the current summary when client just subscribed to
+// its watchlist topic
+func (h *Handler) publishWatchlist(client *Client, topics []string) {
+	if h.watchlists == nil || client.UserID == 0 {
+		return
+	}
+	own := WatchlistTopic(client.UserID)
+	for _, topic := range topics {
+		if topic == own {
+			go h.watchlists.PublishUser(context.Background(), client.UserID)
+			return
+		}
+	}
+}
+
 // sendError sends an error message to the client
 func (h *Handler) sendError(client *Client, errMsg string) {
 	payload, _ := json.Marshal(map[string]string{"message": errMsg})
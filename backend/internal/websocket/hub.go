@@ -3,8 +3,10 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"hash/fnv"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
@@ -18,8 +20,29 @@ const (
 	MessageTypePing        = "ping"
 	MessageTypePong        = "pong"
 	MessageTypeError       = "error"
+
+	MessageTypeWatchlistSummary = "watchlist_summary"
+	MessageTypeDepthUpdate      = "depth_update"
+	MessageTypeAlertUpdated     = "alert_updated"
 )
 
+// A client that misses this many messages in a row because its Send buffer
+// stayed full is disconnected; it can reconnect and resubscribe
+const slowClientDropLimit = 256
+
+// Subscriptions are split into this many independently locked shards so
+// price updates for different symbols fan out in parallel
+const symbolShardCount = 32
+
+// Upper bounds of the fanout latency histogram; the last bucket is open
+var fanoutBuckets = [...]time.Duration{
+	50 * time.Microsecond,
+	250 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	25 * time.Millisecond,
+}
+
 // Message represents a WebSocket message
 type Message struct {
 	Type    string          `json:"type"`
@@ -42,12 +65,33 @@ type PriceUpdate struct {
 
 // Client represents a WebSocket client
 type Client struct {
-	ID            string
+	ID string
+	// UserID is set when the connection was authenticated, which private
+	// topics require; 0 for anonymous clients
+	UserID        int64
 	Conn          *websocket.Conn
 	Hub           *Hub
 	Subscriptions map[string]bool
 	Send          chan []byte
 	mu            sync.RWMutex
+
+	// Messages dropped because Send was full; the consecutive count resets
+	// on every delivered message
+	dropped          atomic.Int64
+	consecutiveDrops atomic.Int64
+	evicted          atomic.Bool
+	// Set under mu once the hub has closed Send; later subscribes are ignored
+	closed bool
+}
+
+// Dropped returns how many messages the client missed
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Evicted reports whether the hub disconnected the client for being too slow
+func (c *Client) Evicted() bool {
+	return c.evicted.Load()
 }
 
 // Hub maintains active clients and broadcasts messages
@@ -56,21 +100,83 @@ type Hub struct {
 	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
-	symbols    map[string]map[*Client]bool // symbol -> clients subscribed
-	mu         sync.RWMutex
+	shards     [symbolShardCount]symbolShard
+	mu         sync.RWMutex // guards clients
 	logger     *slog.Logger
+
+	// Called when a topic gets its first subscriber or loses its last
+	onTopicsChanged func()
+
+	// Metrics
+	sent           atomic.Int64
+	dropped        atomic.Int64
+	evicted        atomic.Int64
+	fanouts        atomic.Int64
+	fanoutNanos    atomic.Int64
+	fanoutMaxNanos atomic.Int64
+	fanoutHist     [len(fanoutBuckets) + 1]atomic.Int64
+}
+
+// symbolShard holds the subscribers of a subset of symbols
+type symbolShard struct {
+	mu      sync.RWMutex
+	symbols map[string]map[*Client]bool // symbol -> clients subscribed
+}
+
+// HubStats is a snapshot of hub metrics
+type HubStats struct {
+	Clients   int
+	Symbols   int
+	Sent      int64
+	Dropped   int64
+	Evicted   int64
+	Fanouts   int64
+	FanoutAvg time.Duration
+	FanoutMax time.Duration
+	// Fanout counts per latency bucket (see FanoutBucketBounds)
+	FanoutHistogram []int64
+}
+
+// FanoutBucketBounds returns the upper bounds of the fanout histogram
+// buckets; the histogram has one more, open-ended bucket
+func FanoutBucketBounds() []time.Duration {
+	return append([]time.Duration(nil), fanoutBuckets[:]...)
 }
 
 // NewHub creates a new Hub
 func NewHub(logger *slog.Logger) *Hub {
-	return &Hub{
+	h := &Hub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		symbols:    make(map[string]map[*Client]bool),
 		logger:     logger,
 	}
+	for i := range h.shards {
+		h.shards[i].symbols = make(map[string]map[*Client]bool)
+	}
+	return h
+}
+
+// SetTopicsChangedHandler sets a function called whenever a topic gets its
+// first subscriber or loses its last. It is called with hub locks held and
+// must not block. Set it before clients connect
+func (h *Hub) SetTopicsChangedHandler(fn func()) {
+	h.onTopicsChanged = fn
+}
+
+// topicsChanged calls the topics changed handler, if any
+func (h *Hub) topicsChanged() {
+	if h.onTopicsChanged != nil {
+		h.onTopicsChanged()
+	}
+}
+
+// shard returns the shard holding a symbol's subscribers
+func (h *Hub) shard(symbol string) *symbolShard {
+	f := fnv.New32a()
+	f.Write([]byte(symbol))
+	return &h.shards[f.Sum32()%symbolShardCount]
 }
 
 // Run starts the hub
@@ -83,8 +189,7 @@ func (h *Hub) Run(ctx context.Context) {
 		case <-ctx.Done():
 			h.mu.Lock()
 			for client := range h.clients {
-				close(client.Send)
-				delete(h.clients, client)
+				h.removeClient(client)
 			}
 			h.mu.Unlock()
 			return
@@ -98,17 +203,7 @@ func (h *Hub) Run(ctx context.Context) {
 		case client := <-h.unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
-				// Remove from all symbol subscriptions
-				for symbol := range client.Subscriptions {
-					if clients, exists := h.symbols[symbol]; exists {
-						delete(clients, client)
-						if len(clients) == 0 {
-							delete(h.symbols, symbol)
-						}
-					}
-				}
-				delete(h.clients, client)
-				close(client.Send)
+				h.removeClient(client)
 			}
 			h.mu.Unlock()
 			h.logger.Debug("client unregistered", slog.String("client_id", client.ID))
@@ -116,11 +211,7 @@ func (h *Hub) Run(ctx context.Context) {
 		case message := <-h.broadcast:
 			h.mu.RLock()
 			for client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					// Client buffer full, skip
-				}
+				h.send(client, message)
 			}
 			h.mu.RUnlock()
 
@@ -131,6 +222,27 @@ func (h *Hub) Run(ctx context.Context) {
 	}
 }
 
+// removeClient drops a client from all symbol subscriptions and closes its
+// Send channel. Must be called with h.mu held. Removing it from the shards
+// first guarantees no fanout is still queueing to the channel
+func (h *Hub) removeClient(client *Client) {
+	client.mu.Lock()
+	client.closed = true
+	changed := false
+	for symbol := range client.Subscriptions {
+		if h.shard(symbol).remove(symbol, client) {
+			changed = true
+		}
+	}
+	client.mu.Unlock()
+	if changed {
+		h.topicsChanged()
+	}
+
+	delete(h.clients, client)
+	close(client.Send)
+}
+
 // pingClients sends ping to all connected clients
 func (h *Hub) pingClients() {
 	msg, _ := json.Marshal(Message{Type: MessageTypePing})
@@ -138,28 +250,59 @@ func (h *Hub) pingClients() {
 	defer h.mu.RUnlock()
 
 	for client := range h.clients {
-		select {
-		case client.Send <- msg:
-		default:
-		}
+		h.send(client, msg)
 	}
 }
 
+// send queues a message without blocking. Must be called with h.mu or the
+// client's shard lock held (read lock is enough) so Send can't be closed
+// concurrently. Clients that keep dropping messages are evicted
+func (h *Hub) send(client *Client, msg []byte) bool {
+	select {
+	case client.Send <- msg:
+		client.consecutiveDrops.Store(0)
+		h.sent.Add(1)
+		return true
+	default:
+	}
+
+	client.dropped.Add(1)
+	h.dropped.Add(1)
+
+	if client.consecutiveDrops.Add(1) >= slowClientDropLimit && client.evicted.CompareAndSwap(false, true) {
+		h.evicted.Add(1)
+		h.logger.Warn("evicting slow websocket client",
+			slog.String("client_id", client.ID),
+			slog.Int64("dropped", client.dropped.Load()),
+		)
+		// The caller holds a hub lock, so unregister asynchronously
+		go h.Unregister(client)
+	}
+
+	return false
+}
+
 // Subscribe adds a client to symbol subscriptions
 func (h *Hub) Subscribe(client *Client, symbols []string) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	client.mu.Lock()
 	defer client.mu.Unlock()
 
+	// The client was already unregistered; adding it back to a shard would
+	// queue messages to its closed Send channel
+	if client.closed {
+		return
+	}
+
+	changed := false
 	for _, symbol := range symbols {
-		if _, exists := h.symbols[symbol]; !exists {
-			h.symbols[symbol] = make(map[*Client]bool)
+		if h.shard(symbol).add(symbol, client) {
+			changed = true
 		}
-		h.symbols[symbol][client] = true
 		client.Subscriptions[symbol] = true
 	}
+	if changed {
+		h.topicsChanged()
+	}
 
 	h.logger.Debug("client subscribed",
 		slog.String("client_id", client.ID),
@@ -169,73 +312,180 @@ func (h *Hub) Subscribe(client *Client, symbols []string) {
 
 // Unsubscribe removes a client from symbol subscriptions
 func (h *Hub) Unsubscribe(client *Client, symbols []string) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	client.mu.Lock()
 	defer client.mu.Unlock()
 
+	changed := false
 	for _, symbol := range symbols {
-		if clients, exists := h.symbols[symbol]; exists {
-			delete(clients, client)
-			if len(clients) == 0 {
-				delete(h.symbols, symbol)
-			}
+		if h.shard(symbol).remove(symbol, client) {
+			changed = true
 		}
 		delete(client.Subscriptions, symbol)
 	}
+	if changed {
+		h.topicsChanged()
+	}
+}
+
+// add subscribes a client to a symbol. Reports whether the symbol had no
+// subscribers before
+func (sh *symbolShard) add(symbol string, client *Client) bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	_, exists := sh.symbols[symbol]
+	if !exists {
+		sh.symbols[symbol] = make(map[*Client]bool)
+	}
+	sh.symbols[symbol][client] = true
+	return !exists
+}
+
+// remove unsubscribes a client from a symbol. Reports whether that left the
+// symbol without subscribers
+func (sh *symbolShard) remove(symbol string, client *Client) bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if clients, exists := sh.symbols[symbol]; exists {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(sh.symbols, symbol)
+			return true
+		}
+	}
+	return false
 }
 
 // BroadcastPrice sends price update to subscribed clients
 func (h *Hub) BroadcastPrice(update PriceUpdate) {
-	payload, err := json.Marshal(update)
+	msg, err := encodeMessage(MessageTypePriceUpdate, update)
 	if err != nil {
 		return
 	}
 
-	msg, err := json.Marshal(Message{
-		Type:    MessageTypePriceUpdate,
-		Payload: payload,
-	})
+	start := time.Now()
+	if h.fanout(update.Symbol, msg) {
+		h.recordFanout(time.Since(start))
+	}
+}
+
+// Publish sends a message to the subscribers of a topic
+func (h *Hub) Publish(topic, messageType string, payload any) {
+	msg, err := encodeMessage(messageType, payload)
 	if err != nil {
 		return
 	}
+	h.fanout(topic, msg)
+}
 
-	h.mu.RLock()
-	clients, exists := h.symbols[update.Symbol]
+// fanout queues msg for the subscribers of a topic. Reports false when the
+// topic has none
+func (h *Hub) fanout(topic string, msg []byte) bool {
+	// Sends never block, so the shard's read lock is held throughout; this
+	// keeps unregister from closing Send while a message is being queued
+	sh := h.shard(topic)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	clients, exists := sh.symbols[topic]
 	if !exists {
-		h.mu.RUnlock()
-		return
+		return false
 	}
-
-	// Copy clients to avoid holding lock during send
-	clientList := make([]*Client, 0, len(clients))
 	for client := range clients {
-		clientList = append(clientList, client)
+		h.send(client, msg)
 	}
-	h.mu.RUnlock()
+	return true
+}
 
-	for _, client := range clientList {
-		select {
-		case client.Send <- msg:
-		default:
-			// Client buffer full, skip
+// encodeMessage builds a message of the given type
+func encodeMessage(messageType string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Message{
+		Type:    messageType,
+		Payload: data,
+	})
+}
+
+// recordFanout records how long one price update took to queue for all
+// subscribers
+func (h *Hub) recordFanout(d time.Duration) {
+	h.fanouts.Add(1)
+	h.fanoutNanos.Add(int64(d))
+
+	bucket := len(fanoutBuckets)
+	for i, bound := range fanoutBuckets {
+		if d <= bound {
+			bucket = i
+			break
+		}
+	}
+	h.fanoutHist[bucket].Add(1)
+
+	for {
+		current := h.fanoutMaxNanos.Load()
+		if int64(d) <= current || h.fanoutMaxNanos.CompareAndSwap(current, int64(d)) {
+			return
 		}
 	}
 }
 
-// GetSubscribedSymbols returns all currently subscribed symbols
-func (h *Hub) GetSubscribedSymbols() []string {
+// Stats returns a snapshot of hub metrics
+func (h *Hub) Stats() HubStats {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	stats := HubStats{
+		Clients: len(h.clients),
+	}
+	h.mu.RUnlock()
 
-	symbols := make([]string, 0, len(h.symbols))
-	for symbol := range h.symbols {
-		symbols = append(symbols, symbol)
+	for i := range h.shards {
+		sh := &h.shards[i]
+		sh.mu.RLock()
+		stats.Symbols += len(sh.symbols)
+		sh.mu.RUnlock()
+	}
+
+	stats.Sent = h.sent.Load()
+	stats.Dropped = h.dropped.Load()
+	stats.Evicted = h.evicted.Load()
+	stats.Fanouts = h.fanouts.Load()
+	stats.FanoutMax = time.Duration(h.fanoutMaxNanos.Load())
+	if stats.Fanouts > 0 {
+		stats.FanoutAvg = time.Duration(h.fanoutNanos.Load() / stats.Fanouts)
+	}
+	stats.FanoutHistogram = make([]int64, len(h.fanoutHist))
+	for i := range h.fanoutHist {
+		stats.FanoutHistogram[i] = h.fanoutHist[i].Load()
+	}
+
+	return stats
+}
+
+// GetSubscribedSymbols returns all currently subscribed symbols
+func (h *Hub) GetSubscribedSymbols() []string {
+	var symbols []string
+	for i := range h.shards {
+		sh := &h.shards[i]
+		sh.mu.RLock()
+		for symbol := range sh.symbols {
+			symbols = append(symbols, symbol)
+		}
+		sh.mu.RUnlock()
 	}
 	return symbols
 }
 
+// HasSubscribers reports whether any client is subscribed to a topic
+func (h *Hub) HasSubscribers(topic string) bool {
+	sh := h.shard(topic)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return len(sh.symbols[topic]) > 0
+}
+
 // ClientCount returns the number of connected clients
 func (h *Hub) ClientCount() int {
 	h.mu.RLock()
@@ -0,0 +1,190 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHub(t *testing.T) *Hub {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewHub(logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go hub.Run(ctx)
+
+	return hub
+}
+
+func newTestClient(hub *Hub, id string, buffer int) *Client {
+	return &Client{
+		ID:            id,
+		Hub:           hub,
+		Subscriptions: make(map[string]bool),
+		Send:          make(chan []byte, buffer),
+	}
+}
+
+func TestHub_EvictsPersistentlySlowClient(t *testing.T) {
+	hub := newTestHub(t)
+
+	fast := newTestClient(hub, "fast", 1)
+	slow := newTestClient(hub, "slow", 1)
+	hub.Register(fast)
+	hub.Register(slow)
+	hub.Subscribe(fast, []string{"BTCUSDT"})
+	hub.Subscribe(slow, []string{"BTCUSDT"})
+
+	for i := 0; i < slowClientDropLimit+1; i++ {
+		hub.BroadcastPrice(PriceUpdate{Symbol: "BTCUSDT", Price: float64(i)})
+		// The fast client keeps up
+		<-fast.Send
+	}
+
+	assert.Equal(t, int64(0), fast.Dropped())
+	assert.False(t, fast.Evicted())
+	assert.Equal(t, int64(slowClientDropLimit), slow.Dropped())
+	assert.True(t, slow.Evicted())
+
+	// Eviction unregisters the client, which closes its Send channel
+	require.Eventually(t, func() bool { return hub.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+	<-slow.Send
+	_, open := <-slow.Send
+	assert.False(t, open)
+
+	stats := hub.Stats()
+	assert.Equal(t, 1, stats.Clients)
+	assert.Equal(t, int64(slowClientDropLimit), stats.Dropped)
+	assert.Equal(t, int64(1), stats.Evicted)
+	assert.Equal(t, int64(slowClientDropLimit+1), stats.Fanouts)
+	assert.Equal(t, int64(slowClientDropLimit+2), stats.Sent)
+}
+
+func TestHub_DeliveryResetsConsecutiveDrops(t *testing.T) {
+	hub := newTestHub(t)
+
+	client := newTestClient(hub, "flaky", 1)
+	hub.Register(client)
+	hub.Subscribe(client, []string{"ETHUSDT"})
+
+	// Dropping just under the limit between reads never evicts
+	for round := 0; round < 3; round++ {
+		for i := 0; i < slowClientDropLimit; i++ {
+			hub.BroadcastPrice(PriceUpdate{Symbol: "ETHUSDT"})
+		}
+		<-client.Send
+	}
+
+	assert.False(t, client.Evicted())
+	assert.Equal(t, int64(3*(slowClientDropLimit-1)), client.Dropped())
+}
+
+func TestHub_ShardedSubscriptions(t *testing.T) {
+	hub := newTestHub(t)
+
+	client := newTestClient(hub, "multi", 64)
+	hub.Register(client)
+
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT", "XRPUSDT"}
+	hub.Subscribe(client, symbols)
+	assert.ElementsMatch(t, symbols, hub.GetSubscribedSymbols())
+
+	hub.Unsubscribe(client, []string{"ETHUSDT"})
+	hub.BroadcastPrice(PriceUpdate{Symbol: "ETHUSDT"})
+	hub.BroadcastPrice(PriceUpdate{Symbol: "SOLUSDT"})
+	assert.Len(t, client.Send, 1)
+	assert.Equal(t, 4, hub.Stats().Symbols)
+
+	// Unregistering clears the client from every shard
+	hub.Unregister(client)
+	require.Eventually(t, func() bool { return len(hub.GetSubscribedSymbols()) == 0 }, time.Second, 10*time.Millisecond)
+
+	// A late subscribe from the closed client is ignored
+	hub.Subscribe(client, []string{"BTCUSDT"})
+	assert.Empty(t, hub.GetSubscribedSymbols())
+	hub.BroadcastPrice(PriceUpdate{Symbol: "BTCUSDT"})
+}
+
+// BenchmarkHub_BroadcastPrice fans out updates for 100 symbols to 5000
+// clients subscribed to 20 symbols each, from parallel publishers
+func BenchmarkHub_BroadcastPrice(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(logger)
+
+	symbols := make([]string, 100)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("SYM%dUSDT", i)
+	}
+
+	for i := 0; i < 5000; i++ {
+		client := newTestClient(hub, strconv.Itoa(i), 256)
+		hub.clients[client] = true
+		subs := make([]string, 20)
+		for j := range subs {
+			subs[j] = symbols[(i+j*5)%len(symbols)]
+		}
+		hub.Subscribe(client, subs)
+
+		// Drain so the benchmark measures fanout, not drops
+		go func() {
+			for range client.Send {
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			hub.BroadcastPrice(PriceUpdate{Symbol: symbols[i%len(symbols)], Price: 1})
+			i++
+		}
+	})
+	b.StopTimer()
+
+	stats := hub.Stats()
+	b.ReportMetric(float64(stats.FanoutAvg.Microseconds()), "fanout-avg-us")
+	b.ReportMetric(float64(stats.Dropped)/float64(stats.Sent+stats.Dropped), "drop-ratio")
+
+	for client := range hub.clients {
+		close(client.Send)
+	}
+}
+
+func TestHandler_WatchlistTopicRequiresOwner(t *testing.T) {
+	hub := newTestHub(t)
+	handler := NewHandler(hub, hub.logger)
+
+	owner := newTestClient(hub, "owner", 4)
+	owner.UserID = 7
+	other := newTestClient(hub, "other", 4)
+	other.UserID = 8
+	anonymous := newTestClient(hub, "anonymous", 4)
+	for _, c := range []*Client{owner, other, anonymous} {
+		hub.Register(c)
+	}
+
+	subscribe := []byte(`{"type":"subscribe","payload":{"symbols":["watchlist:7"]}}`)
+	handler.handleMessage(owner, subscribe)
+	handler.handleMessage(other, subscribe)
+	handler.handleMessage(anonymous, subscribe)
+
+	assert.True(t, owner.Subscriptions[WatchlistTopic(7)])
+	assert.False(t, other.Subscriptions[WatchlistTopic(7)])
+	assert.False(t, anonymous.Subscriptions[WatchlistTopic(7)])
+	assert.Contains(t, string(<-other.Send), "not allowed")
+	assert.Contains(t, string(<-anonymous.Send), "not allowed")
+
+	hub.Publish(WatchlistTopic(7), MessageTypeWatchlistSummary, WatchlistSummaryUpdate{Coins: 3})
+	assert.Contains(t, string(<-owner.Send), `"type":"watchlist_summary"`)
+	assert.Empty(t, other.Send)
+}
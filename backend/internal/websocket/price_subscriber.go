@@ -6,11 +6,11 @@ import (
 	"log/slog"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/weqory/backend/pkg/eventbus"
 )
 
 const (
-	// Redis channel for price stream updates (must match alert package)
+	// Event bus topic for price stream updates (must match alert package)
 	priceStreamChannel = "prices:stream"
 
 	// Reconnect delay on subscription error
@@ -27,23 +27,24 @@ type PriceStreamPayload struct {
 	UpdatedAt    string  `json:"updatedAt"`
 }
 
-// PriceSubscriber subscribes to Redis pub/sub and forwards prices to WebSocket hub
+// PriceSubscriber subscribes to the price stream on the event bus and
+// forwards prices to WebSocket hub
 type PriceSubscriber struct {
-	client *redis.Client
+	bus    eventbus.Subscriber
 	hub    *Hub
 	logger *slog.Logger
 }
 
 // NewPriceSubscriber creates a new price subscriber
-func NewPriceSubscriber(client *redis.Client, hub *Hub, logger *slog.Logger) *PriceSubscriber {
+func NewPriceSubscriber(bus eventbus.Subscriber, hub *Hub, logger *slog.Logger) *PriceSubscriber {
 	return &PriceSubscriber{
-		client: client,
+		bus:    bus,
 		hub:    hub,
 		logger: logger,
 	}
 }
 
-// Subscribe starts listening to price updates from Redis and broadcasts to WebSocket clients
+// Subscribe starts listening to price updates from the event bus and broadcasts to WebSocket clients
 func (s *PriceSubscriber) Subscribe(ctx context.Context) error {
 	backoff := reconnectDelay
 
@@ -83,37 +84,17 @@ func (s *PriceSubscriber) Subscribe(ctx context.Context) error {
 
 // subscribeLoop handles the actual subscription and message processing
 func (s *PriceSubscriber) subscribeLoop(ctx context.Context) error {
-	pubsub := s.client.Subscribe(ctx, priceStreamChannel)
-	defer pubsub.Close()
+	s.logger.Info("subscribing to price stream", slog.String("channel", priceStreamChannel))
 
-	// Wait for subscription confirmation
-	if _, err := pubsub.Receive(ctx); err != nil {
-		return err
-	}
-
-	s.logger.Info("subscribed to price stream", slog.String("channel", priceStreamChannel))
-
-	ch := pubsub.Channel()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-
-		case msg, ok := <-ch:
-			if !ok {
-				return nil // Channel closed
-			}
-
-			s.handleMessage(msg)
-		}
-	}
+	return s.bus.Subscribe(ctx, priceStreamChannel, func(_ context.Context, msg eventbus.Message) {
+		s.handleMessage(msg.Data)
+	})
 }
 
 // handleMessage processes a single price update message
-func (s *PriceSubscriber) handleMessage(msg *redis.Message) {
+func (s *PriceSubscriber) handleMessage(data []byte) {
 	var payload PriceStreamPayload
-	if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+	if err := json.Unmarshal(data, &payload); err != nil {
 		s.logger.Error("failed to unmarshal price update",
 			slog.String("error", err.Error()),
 		)
@@ -12,6 +12,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/eventbus"
 )
 
 // mockHub tracks BroadcastPrice calls for testing
@@ -55,7 +56,7 @@ func newTestPriceSubscriber(t *testing.T) (*PriceSubscriber, *redis.Client, *moc
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	hub := newMockHub()
-	subscriber := NewPriceSubscriber(redisClient, hub.Hub, logger)
+	subscriber := NewPriceSubscriber(eventbus.NewRedisPubSub(redisClient), hub.Hub, logger)
 
 	return subscriber, redisClient, hub
 }
@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/weqory/backend/pkg/eventbus"
+)
+
+const (
+	// Event bus topic the symbols clients view are announced on, for the
+	// alert engine to stream them (must match alert package)
+	symbolDemandChannel = "symbols:demand"
+
+	// DemandInterval is how often the symbols are announced even when they
+	// did not change. The engine stops streaming the symbols of a gateway
+	// that missed three announcements
+	DemandInterval = 30 * time.Second
+
+	// demandDebounce batches the subscription changes of a burst of
+	// clients into one announcement
+	demandDebounce = time.Second
+)
+
+// SymbolDemand is the payload of a symbols:demand message: every price
+// symbol the clients of a gateway instance are subscribed to. Each message
+// replaces the instance's previous one
+type SymbolDemand struct {
+	Instance string   `json:"instance"`
+	Symbols  []string `json:"symbols"`
+}
+
+// DemandAnnouncer announces the price symbols the clients of a hub are
+// subscribed to, so the alert engine streams the symbols nobody else needs
+// from Binance while clients view them
+type DemandAnnouncer struct {
+	hub      *Hub
+	bus      eventbus.Publisher
+	instance string
+	logger   *slog.Logger
+	changed  chan struct{}
+}
+
+// NewDemandAnnouncer creates a DemandAnnouncer and hooks it to the hub's
+// subscription changes
+func NewDemandAnnouncer(hub *Hub, bus eventbus.Publisher, logger *slog.Logger) *DemandAnnouncer {
+	a := &DemandAnnouncer{
+		hub:      hub,
+		bus:      bus,
+		instance: uuid.NewString(),
+		logger:   logger,
+		changed:  make(chan struct{}, 1),
+	}
+	hub.SetTopicsChangedHandler(a.notify)
+	return a
+}
+
+// notify schedules an announcement without blocking
+func (a *DemandAnnouncer) notify() {
+	select {
+	case a.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Run announces the symbols on every change and each DemandInterval until
+// ctx is done, then withdraws them
+func (a *DemandAnnouncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(DemandInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Release the symbols now instead of when the lease runs out
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			a.announce(releaseCtx, nil)
+			cancel()
+			return
+		case <-a.changed:
+			select {
+			case <-ctx.Done():
+				continue
+			case <-time.After(demandDebounce):
+			}
+		case <-ticker.C:
+		}
+
+		a.announce(ctx, a.Symbols())
+	}
+}
+
+// Symbols returns the price symbols clients are subscribed to, sorted
+func (a *DemandAnnouncer) Symbols() []string {
+	var symbols []string
+	for _, topic := range a.hub.GetSubscribedSymbols() {
+		// Other topics carry a prefix, such as depth:BTCUSDT
+		if isBinanceSymbol(topic) {
+			symbols = append(symbols, topic)
+		}
+	}
+	slices.Sort(symbols)
+	return symbols
+}
+
+func (a *DemandAnnouncer) announce(ctx context.Context, symbols []string) {
+	data, err := json.Marshal(SymbolDemand{Instance: a.instance, Symbols: symbols})
+	if err != nil {
+		return
+	}
+	if err := a.bus.Publish(ctx, symbolDemandChannel, data); err != nil {
+		a.logger.Error("failed to announce symbol demand", slog.String("error", err.Error()))
+	}
+}
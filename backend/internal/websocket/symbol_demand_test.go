@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	published chan []byte
+}
+
+func (p *fakePublisher) Publish(_ context.Context, _ string, data []byte) error {
+	p.published <- data
+	return nil
+}
+
+func TestDemandAnnouncer_AnnouncesPriceSymbols(t *testing.T) {
+	hub := newTestHub(t)
+	bus := &fakePublisher{published: make(chan []byte, 4)}
+	announcer := NewDemandAnnouncer(hub, bus, hub.logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go announcer.Run(ctx)
+
+	client := newTestClient(hub, "viewer", 4)
+	hub.Subscribe(client, []string{"ETHUSDT", "BTCUSDT", DepthTopic("SOLUSDT"), WatchlistTopic(7)})
+
+	var demand SymbolDemand
+	select {
+	case data := <-bus.published:
+		require.NoError(t, json.Unmarshal(data, &demand))
+	case <-time.After(3 * time.Second):
+		t.Fatal("symbols not announced")
+	}
+	assert.NotEmpty(t, demand.Instance)
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, demand.Symbols, "price topics only")
+
+	// Stopping withdraws the symbols
+	cancel()
+	select {
+	case data := <-bus.published:
+		require.NoError(t, json.Unmarshal(data, &demand))
+		assert.Empty(t, demand.Symbols)
+	case <-time.After(3 * time.Second):
+		t.Fatal("symbols not withdrawn")
+	}
+}
@@ -0,0 +1,147 @@
+package websocket
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weqory/backend/internal/service"
+)
+
+const (
+	// WatchlistTopicPrefix prefixes the private per-user watchlist summary
+	// topics
+	WatchlistTopicPrefix = "watchlist:"
+
+	// How often watchlist summaries are recomputed
+	watchlistSummaryInterval = time.Minute
+
+	// Time allowed to compute and publish a single summary
+	watchlistSummaryTimeout = 10 * time.Second
+)
+
+// WatchlistTopic returns the summary topic of a user's watchlist
+func WatchlistTopic(userID int64) string {
+	return WatchlistTopicPrefix + strconv.FormatInt(userID, 10)
+}
+
+// WatchlistSummaryUpdate is the payload of a watchlist_summary message
+type WatchlistSummaryUpdate struct {
+	Coins           int                  `json:"coins"`
+	Priced          int                  `json:"priced"`
+	AvgChange24hPct float64              `json:"avgChange24hPct"`
+	Gainers         int                  `json:"gainers"`
+	Losers          int                  `json:"losers"`
+	TopMover        *WatchlistMoverEntry `json:"topMover"`
+	UpdatedAt       string               `json:"updatedAt"`
+}
+
+// WatchlistMoverEntry is the biggest 24h mover of a watchlist
+type WatchlistMoverEntry struct {
+	Symbol       string  `json:"symbol"`
+	Price        float64 `json:"price"`
+	Change24hPct float64 `json:"change24hPct"`
+}
+
+// WatchlistSummarizer computes watchlist summaries
+type WatchlistSummarizer interface {
+	Summary(ctx context.Context, userID int64) (*service.WatchlistSummary, error)
+}
+
+// WatchlistSummaryPublisher periodically publishes the watchlist summary of
+// every user subscribed to their watchlist topic
+type WatchlistSummaryPublisher struct {
+	hub       *Hub
+	summaries WatchlistSummarizer
+	logger    *slog.Logger
+}
+
+// NewWatchlistSummaryPublisher creates a new WatchlistSummaryPublisher
+func NewWatchlistSummaryPublisher(hub *Hub, summaries WatchlistSummarizer, logger *slog.Logger) *WatchlistSummaryPublisher {
+	return &WatchlistSummaryPublisher{
+		hub:       hub,
+		summaries: summaries,
+		logger:    logger,
+	}
+}
+
+// Run publishes summaries every minute until ctx is cancelled
+func (p *WatchlistSummaryPublisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(watchlistSummaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.publishAll(ctx)
+		}
+	}
+}
+
+// publishAll publishes the summary of every subscribed watchlist
+func (p *WatchlistSummaryPublisher) publishAll(ctx context.Context) {
+	for _, topic := range p.hub.GetSubscribedSymbols() {
+		userID, ok := parseWatchlistTopic(topic)
+		if !ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		p.PublishUser(ctx, userID)
+	}
+}
+
+// PublishUser computes and publishes the watchlist summary of userID
+func (p *WatchlistSummaryPublisher) PublishUser(ctx context.Context, userID int64) {
+	ctx, cancel := context.WithTimeout(ctx, watchlistSummaryTimeout)
+	defer cancel()
+
+	summary, err := p.summaries.Summary(ctx, userID)
+	if err != nil {
+		p.logger.Warn("failed to compute watchlist summary",
+			slog.Int64("user_id", userID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	p.hub.Publish(WatchlistTopic(userID), MessageTypeWatchlistSummary, newWatchlistSummaryUpdate(summary, time.Now()))
+}
+
+// newWatchlistSummaryUpdate converts a summary to its message payload
+func newWatchlistSummaryUpdate(summary *service.WatchlistSummary, now time.Time) WatchlistSummaryUpdate {
+	update := WatchlistSummaryUpdate{
+		Coins:           summary.Coins,
+		Priced:          summary.Priced,
+		AvgChange24hPct: summary.AvgChange24hPct,
+		Gainers:         summary.Gainers,
+		Losers:          summary.Losers,
+		UpdatedAt:       now.UTC().Format(time.RFC3339),
+	}
+	if summary.TopMover != nil {
+		update.TopMover = &WatchlistMoverEntry{
+			Symbol:       summary.TopMover.Symbol,
+			Price:        summary.TopMover.Price,
+			Change24hPct: summary.TopMover.Change24hPct,
+		}
+	}
+	return update
+}
+
+// parseWatchlistTopic extracts the user ID of a watchlist topic
+func parseWatchlistTopic(topic string) (int64, bool) {
+	rest, ok := strings.CutPrefix(topic, WatchlistTopicPrefix)
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,191 @@
+// Package whale reads large on-chain transfers from the Whale Alert API
+package whale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBaseURL = "https://api.whale-alert.io/v1"
+	defaultTimeout = 30 * time.Second
+
+	// MinValueUSD is the smallest transfer the API reports on its standard
+	// plan; whale alerts cannot use a lower threshold
+	MinValueUSD = 500000
+
+	// MaxLookback is how far back the API serves transfers
+	MaxLookback = time.Hour
+)
+
+// Client is a Whale Alert API client
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	logger     *slog.Logger
+}
+
+// NewClient creates a new Whale Alert client
+func NewClient(apiKey string, logger *slog.Logger) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		baseURL: defaultBaseURL,
+		apiKey:  apiKey,
+		logger:  logger,
+	}
+}
+
+// SetBaseURL points the client at another API endpoint, e.g. a test server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// Owner is one side of a transfer
+type Owner struct {
+	Address string `json:"address"`
+	// Known owner such as an exchange name; empty for unknown wallets
+	Owner     string `json:"owner"`
+	OwnerType string `json:"owner_type"`
+}
+
+// Name returns the owner's name, or "unknown wallet"
+func (o Owner) Name() string {
+	if o.Owner == "" || o.Owner == "unknown" {
+		return "unknown wallet"
+	}
+	return o.Owner
+}
+
+// Transfer is a large on-chain transaction
+type Transfer struct {
+	ID         string  `json:"id"`
+	Blockchain string  `json:"blockchain"`
+	Symbol     string  `json:"symbol"`
+	Type       string  `json:"transaction_type"`
+	Hash       string  `json:"hash"`
+	From       Owner   `json:"from"`
+	To         Owner   `json:"to"`
+	Timestamp  int64   `json:"timestamp"`
+	Amount     float64 `json:"amount"`
+	AmountUSD  float64 `json:"amount_usd"`
+}
+
+// CoinSymbol returns the transferred asset's ticker in upper case
+func (t Transfer) CoinSymbol() string {
+	return strings.ToUpper(t.Symbol)
+}
+
+// Price returns the asset price implied by the transfer's USD value
+func (t Transfer) Price() float64 {
+	if t.Amount <= 0 {
+		return 0
+	}
+	return t.AmountUSD / t.Amount
+}
+
+// Summary describes the transfer in one line, e.g.
+// "1,200 BTC ($84.2M) from unknown wallet to binance"
+func (t Transfer) Summary() string {
+	return fmt.Sprintf("%s %s (%s) from %s to %s",
+		formatAmount(t.Amount), t.CoinSymbol(), formatUSD(t.AmountUSD), t.From.Name(), t.To.Name())
+}
+
+// TransactionsPage is a page of transfers with the cursor of the next one
+type TransactionsPage struct {
+	Cursor       string     `json:"cursor"`
+	Count        int        `json:"count"`
+	Transactions []Transfer `json:"transactions"`
+}
+
+// apiResponse is the envelope of every API response
+type apiResponse struct {
+	TransactionsPage
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+// Transactions returns transfers worth at least minValueUSD since start,
+// continuing from cursor when it is not empty
+func (c *Client) Transactions(ctx context.Context, start time.Time, cursor string, minValueUSD float64) (*TransactionsPage, error) {
+	params := url.Values{}
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("min_value", strconv.FormatFloat(math.Max(minValueUSD, MinValueUSD), 'f', 0, 64))
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/transactions?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("X-WA-API-KEY", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("rate limit exceeded")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var out apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if out.Result != "success" {
+		return nil, fmt.Errorf("whale alert error: %s", out.Message)
+	}
+
+	return &out.TransactionsPage, nil
+}
+
+// formatAmount formats a token amount with thousands separators
+func formatAmount(amount float64) string {
+	if amount < 1000 {
+		return strconv.FormatFloat(amount, 'f', -1, 64)
+	}
+
+	digits := strconv.FormatFloat(math.Round(amount), 'f', 0, 64)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// formatUSD formats a dollar value compactly, e.g. $84.2M
+func formatUSD(value float64) string {
+	switch {
+	case value >= 1e9:
+		return fmt.Sprintf("$%.1fB", value/1e9)
+	case value >= 1e6:
+		return fmt.Sprintf("$%.1fM", value/1e6)
+	case value >= 1e3:
+		return fmt.Sprintf("$%.0fK", value/1e3)
+	default:
+		return fmt.Sprintf("$%.0f", value)
+	}
+}
@@ -0,0 +1,69 @@
+package whale
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/alerttype"
+)
+
+func TestClient_Transactions(t *testing.T) {
+	var query, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		apiKey = r.Header.Get("X-WA-API-KEY")
+		w.Write([]byte(`{
+			"result": "success",
+			"cursor": "abc-def",
+			"count": 1,
+			"transactions": [{
+				"blockchain": "bitcoin", "symbol": "btc", "id": "1", "transaction_type": "transfer",
+				"hash": "h1", "timestamp": 1780000000, "amount": 1200, "amount_usd": 84200000,
+				"from": {"address": "a1", "owner_type": "unknown"},
+				"to": {"address": "a2", "owner": "binance", "owner_type": "exchange"}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("key", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.SetBaseURL(server.URL)
+
+	page, err := c.Transactions(context.Background(), time.Unix(1780000000, 0), "prev", 1000)
+	require.NoError(t, err)
+	assert.Equal(t, "key", apiKey)
+	assert.Equal(t, "cursor=prev&min_value=500000&start=1780000000", query, "threshold is raised to the API minimum")
+
+	assert.Equal(t, "abc-def", page.Cursor)
+	require.Len(t, page.Transactions, 1)
+	transfer := page.Transactions[0]
+	assert.Equal(t, "BTC", transfer.CoinSymbol())
+	assert.InDelta(t, 70166.67, transfer.Price(), 0.01)
+	assert.Equal(t, "1,200 BTC ($84.2M) from unknown wallet to binance", transfer.Summary())
+}
+
+func TestClient_TransactionsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result": "error", "message": "invalid api key"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient("bad", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.SetBaseURL(server.URL)
+
+	_, err := c.Transactions(context.Background(), time.Now(), "", MinValueUSD)
+	assert.ErrorContains(t, err, "invalid api key")
+}
+
+func TestMinValueMatchesAlertType(t *testing.T) {
+	def, ok := alerttype.Lookup(alerttype.WhaleTransfer)
+	require.True(t, ok)
+	assert.Equal(t, float64(MinValueUSD), def.MinValue)
+}
@@ -0,0 +1,261 @@
+// Package alerttype is the registry of alert types. Each type is defined
+// once here and the validator, the service, the engine and the notification
+// formatter read its behavior from the definition, so adding a type means
+// adding an entry to the table below
+package alerttype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Alert types
+const (
+	PriceAbove      = "PRICE_ABOVE"
+	PriceBelow      = "PRICE_BELOW"
+	PriceChangePct  = "PRICE_CHANGE_PCT"
+	Periodic        = "PERIODIC"
+	VolumeSpike     = "VOLUME_SPIKE"
+	VolumeChangePct = "VOLUME_CHANGE_PCT"
+	MarketCapAbove  = "MARKET_CAP_ABOVE"
+	MarketCapBelow  = "MARKET_CAP_BELOW"
+	WhaleTransfer   = "WHALE_TRANSFER"
+	GasBelow        = "GAS_BELOW"
+)
+
+// Condition operators stored with an alert
+const (
+	OperatorAbove  = "above"
+	OperatorBelow  = "below"
+	OperatorChange = "change"
+)
+
+// Source is the data an alert is evaluated against
+type Source int
+
+const (
+	// SourcePrice alerts are evaluated on every price tick of their coin
+	SourcePrice Source = iota
+	// SourceTransfer alerts are evaluated on large on-chain transfers
+	SourceTransfer
+	// SourceGas alerts are evaluated on the Ethereum gas price
+	SourceGas
+)
+
+// Unit is the unit of an alert's condition value
+type Unit int
+
+const (
+	UnitUSD Unit = iota
+	UnitPercent
+	UnitGwei
+)
+
+// Format formats a condition value in the unit, e.g. "$70000" or "5%"
+func (u Unit) Format(value float64) string {
+	return u.FormatIn(value, "")
+}
+
+// FormatIn formats a condition value like Format, with USD values in
+// quote instead when it is set, e.g. "0.05 BTC"
+func (u Unit) FormatIn(value float64, quote string) string {
+	v := strconv.FormatFloat(value, 'f', -1, 64)
+	if u == UnitUSD && quote != "" {
+		return v + " " + quote
+	}
+	switch u {
+	case UnitPercent:
+		return v + "%"
+	case UnitGwei:
+		return v + " gwei"
+	default:
+		return "$" + v
+	}
+}
+
+// Field says whether an alert type takes an optional setting
+type Field int
+
+const (
+	FieldOptional Field = iota
+	FieldRequired
+	FieldForbidden
+)
+
+// check validates the presence of a setting named name
+func (f Field) check(name string, set bool) error {
+	switch {
+	case f == FieldRequired && !set:
+		return fmt.Errorf("%s is required", name)
+	case f == FieldForbidden && set:
+		return fmt.Errorf("%s is not supported", name)
+	}
+	return nil
+}
+
+// Definition describes an alert type
+type Definition struct {
+	Type     string
+	Operator string
+	Source   Source
+	Unit     Unit
+
+	// Users can create it through the API; the rest are evaluated but only
+	// created internally
+	Creatable bool
+
+	// Validation of the condition: the value must be at least MinValue,
+	// and the timeframe and periodic interval present as required
+	MinValue  float64
+	Timeframe Field
+	Interval  Field
+	// Coin the type is limited to; empty for any coin
+	Coin string
+	// Quotable types can be set in another quote asset than USD, e.g. ETH
+	// priced in BTC; the condition is evaluated on that pair
+	Quotable bool
+
+	// StaysArmed types fire once per event instead of once per crossing
+	StaysArmed bool
+	// Delivery priority of alerts created without one
+	Priority string
+
+	// Notification text. Condition describes the condition with %s in
+	// place of the value, e.g. "above %s"; ShowTarget repeats the value in
+	// one-line messages
+	Icon         string
+	Action       string
+	CurrentLabel string
+	TargetLabel  string
+	Condition    string
+	ShowTarget   bool
+}
+
+// definitions is the registry
+var definitions = []Definition{
+	{
+		Type: PriceAbove, Operator: OperatorAbove, Source: SourcePrice, Unit: UnitUSD,
+		Creatable: true, Quotable: true, Priority: "normal",
+		Icon: "🔺", Action: "rose above", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "above %s", ShowTarget: true,
+	},
+	{
+		Type: PriceBelow, Operator: OperatorBelow, Source: SourcePrice, Unit: UnitUSD,
+		Creatable: true, Quotable: true, Priority: "normal",
+		Icon: "🔻", Action: "fell below", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "below %s", ShowTarget: true,
+	},
+	{
+		Type: PriceChangePct, Operator: OperatorChange, Source: SourcePrice, Unit: UnitPercent,
+		Creatable: true, Quotable: true, Priority: "normal",
+		Icon: "📈", Action: "moved by", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "price change of %s", ShowTarget: true,
+	},
+	{
+		Type: Periodic, Operator: OperatorChange, Source: SourcePrice, Unit: UnitUSD,
+		Creatable: true, Quotable: true, Interval: FieldRequired, Priority: "low",
+		Icon: "🔔", Action: "periodic update", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "periodic update",
+	},
+	{
+		Type: VolumeSpike, Operator: OperatorChange, Source: SourcePrice, Unit: UnitPercent,
+		Icon: "📊", Action: "volume spike", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "volume spike of %s of average", Priority: "normal",
+	},
+	{
+		Type: VolumeChangePct, Operator: OperatorChange, Source: SourcePrice, Unit: UnitPercent,
+		Icon: "📊", Action: "volume moved by", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "volume change of %s", ShowTarget: true, Priority: "normal",
+	},
+	{
+		Type: MarketCapAbove, Operator: OperatorAbove, Source: SourcePrice, Unit: UnitUSD,
+		Icon: "🔺", Action: "market cap rose above", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "market cap above %s", ShowTarget: true, Priority: "normal",
+	},
+	{
+		Type: MarketCapBelow, Operator: OperatorBelow, Source: SourcePrice, Unit: UnitUSD,
+		Icon: "🔻", Action: "market cap fell below", CurrentLabel: "Current Price", TargetLabel: "Target",
+		Condition: "market cap below %s", ShowTarget: true, Priority: "normal",
+	},
+	{
+		// The Whale Alert API reports transfers from $500k on its standard plan
+		Type: WhaleTransfer, Operator: OperatorAbove, Source: SourceTransfer, Unit: UnitUSD,
+		Creatable: true, MinValue: 500000, Timeframe: FieldForbidden, Interval: FieldForbidden,
+		StaysArmed: true, Priority: "normal",
+		Icon: "🐋", Action: "whale transfer", CurrentLabel: "Current Price", TargetLabel: "Threshold",
+		Condition: "whale transfers over %s",
+	},
+	{
+		Type: GasBelow, Operator: OperatorBelow, Source: SourceGas, Unit: UnitGwei,
+		Creatable: true, Timeframe: FieldForbidden, Interval: FieldForbidden, Coin: "ETH",
+		Icon: "⛽", Action: "gas fell below", CurrentLabel: "Gas Price", TargetLabel: "Target",
+		Condition: "gas below %s", ShowTarget: true, Priority: "normal",
+	},
+}
+
+// registry indexes definitions by type
+var registry = func() map[string]*Definition {
+	m := make(map[string]*Definition, len(definitions))
+	for i := range definitions {
+		m[definitions[i].Type] = &definitions[i]
+	}
+	return m
+}()
+
+// Lookup returns the definition of alertType
+func Lookup(alertType string) (*Definition, bool) {
+	def, ok := registry[alertType]
+	return def, ok
+}
+
+// Creatable reports whether users can create alerts of alertType
+func Creatable(alertType string) bool {
+	def, ok := registry[alertType]
+	return ok && def.Creatable
+}
+
+// All returns every definition in registry order
+func All() []Definition {
+	out := make([]Definition, len(definitions))
+	copy(out, definitions)
+	return out
+}
+
+// Validate checks an alert's condition against the type's rules. Errors
+// are user-facing
+func (d *Definition) Validate(coin string, value float64, hasTimeframe, hasInterval bool) error {
+	if d.Coin != "" && !strings.EqualFold(coin, d.Coin) {
+		return fmt.Errorf("%s alerts can only be set on %s", d.Name(), d.Coin)
+	}
+	if value < d.MinValue {
+		return fmt.Errorf("%s alerts need a threshold of at least %s", d.Name(), d.Unit.Format(d.MinValue))
+	}
+	if err := d.Timeframe.check("condition_timeframe", hasTimeframe); err != nil {
+		return fmt.Errorf("%s alerts: %w", d.Name(), err)
+	}
+	if err := d.Interval.check("periodic_interval", hasInterval); err != nil {
+		return fmt.Errorf("%s alerts: %w", d.Name(), err)
+	}
+	return nil
+}
+
+// Name returns the type in words, e.g. "Whale transfer" for WHALE_TRANSFER
+func (d *Definition) Name() string {
+	name := strings.ToLower(strings.ReplaceAll(d.Type, "_", " "))
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Describe describes the condition with value, e.g. "above $70000"
+func (d *Definition) Describe(value float64) string {
+	return d.DescribeIn(value, "")
+}
+
+// DescribeIn describes the condition like Describe, with the value in
+// quote when it is set, e.g. "above 0.05 BTC"
+func (d *Definition) DescribeIn(value float64, quote string) string {
+	if !strings.Contains(d.Condition, "%s") {
+		return d.Condition
+	}
+	return fmt.Sprintf(d.Condition, d.Unit.FormatIn(value, quote))
+}
@@ -0,0 +1,71 @@
+package alerttype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, def := range All() {
+		assert.False(t, seen[def.Type], "duplicate type %s", def.Type)
+		seen[def.Type] = true
+
+		assert.Contains(t, []string{OperatorAbove, OperatorBelow, OperatorChange}, def.Operator, def.Type)
+		assert.NotEmpty(t, def.Icon, def.Type)
+		assert.NotEmpty(t, def.Action, def.Type)
+		assert.NotEmpty(t, def.Condition, def.Type)
+	}
+
+	assert.True(t, Creatable(PriceAbove))
+	assert.False(t, Creatable(VolumeSpike), "evaluated but not offered to users")
+	assert.False(t, Creatable("UNKNOWN"))
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	whale, ok := Lookup(WhaleTransfer)
+	require.True(t, ok)
+	assert.NoError(t, whale.Validate("BTC", 1_000_000, false, false))
+	assert.EqualError(t, whale.Validate("BTC", 1000, false, false),
+		"Whale transfer alerts need a threshold of at least $500000")
+	assert.EqualError(t, whale.Validate("BTC", 1_000_000, true, false),
+		"Whale transfer alerts: condition_timeframe is not supported")
+
+	gas, _ := Lookup(GasBelow)
+	assert.NoError(t, gas.Validate("eth", 10, false, false))
+	assert.EqualError(t, gas.Validate("BTC", 10, false, false), "Gas below alerts can only be set on ETH")
+
+	periodic, _ := Lookup(Periodic)
+	assert.EqualError(t, periodic.Validate("BTC", 1, false, false), "Periodic alerts: periodic_interval is required")
+	assert.NoError(t, periodic.Validate("BTC", 1, false, true))
+}
+
+func TestDefinition_Describe(t *testing.T) {
+	tests := map[string]string{
+		PriceAbove:     "above $70000",
+		PriceChangePct: "price change of 5%",
+		GasBelow:       "gas below 5 gwei",
+		Periodic:       "periodic update",
+	}
+	for alertType, want := range tests {
+		def, ok := Lookup(alertType)
+		require.True(t, ok, alertType)
+		value := 5.0
+		if alertType == PriceAbove {
+			value = 70000
+		}
+		assert.Equal(t, want, def.Describe(value), alertType)
+	}
+}
+
+func TestDefinition_DescribeIn(t *testing.T) {
+	above, _ := Lookup(PriceAbove)
+	assert.Equal(t, "above 0.05 BTC", above.DescribeIn(0.05, "BTC"))
+	assert.Equal(t, "above $70000", above.DescribeIn(70000, ""))
+
+	// Percentages do not depend on the quote
+	change, _ := Lookup(PriceChangePct)
+	assert.Equal(t, "price change of 5%", change.DescribeIn(5, "BTC"))
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "weqory.env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_FileWithEnvOverrides(t *testing.T) {
+	path := writeConfigFile(t, `
+# comment
+PORT=9000
+RATE_LIMIT_MAX_REQUESTS=50
+export RATE_LIMIT_WINDOW="30s"
+SCHEDULE_COINGECKO_SYNC='*/30 * * * *'
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("RATE_LIMIT_MAX_REQUESTS", "75")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "9000", cfg.Server.Port, "value from file")
+	assert.Equal(t, 75, cfg.RateLimit.MaxRequests, "environment wins over file")
+	assert.Equal(t, 30*time.Second, cfg.RateLimit.Window)
+	assert.Equal(t, "*/30 * * * *", cfg.Scheduler.Overrides["coingecko-sync"])
+}
+
+func TestLoad_ReportsAllProblems(t *testing.T) {
+	t.Setenv("PORT", "http")
+	t.Setenv("DB_MAX_CONNS", "ten")
+	t.Setenv("RETRY_BASE_DELAY", "5")
+	t.Setenv("REDIS_URL", "localhost:6379")
+	t.Setenv("ANOMALY_MAX_JUMP_PCT", "-1")
+
+	_, err := Load()
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Problems, 5)
+	for _, key := range []string{"PORT", "DB_MAX_CONNS", "RETRY_BASE_DELAY", "REDIS_URL", "ANOMALY_MAX_JUMP_PCT"} {
+		assert.Contains(t, err.Error(), key+":")
+	}
+}
+
+func TestLoad_ProductionRequiresSecrets(t *testing.T) {
+	t.Setenv("ENV", "production")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TELEGRAM_BOT_TOKEN: is required in production")
+	assert.Contains(t, err.Error(), "JWT_SECRET: is required in production")
+	assert.Contains(t, err.Error(), "TELEGRAM_WEBHOOK_SECRET: is required in production")
+}
+
+func TestLoad_GRPCRequiresToken(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Server.GRPCPort, "internal API disabled by default")
+
+	t.Setenv("GRPC_PORT", "9090")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "INTERNAL_GRPC_TOKEN: is required when GRPC_PORT is set")
+
+	t.Setenv("INTERNAL_GRPC_TOKEN", "secret")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Server.GRPCPort)
+}
+
+func TestLoad_WebhookSettings(t *testing.T) {
+	t.Setenv("TELEGRAM_WEBHOOK_URL", "http://api.example.com/hook")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "not allowed!")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TELEGRAM_WEBHOOK_URL: must use scheme https")
+	assert.Contains(t, err.Error(), "TELEGRAM_WEBHOOK_SECRET: must be 1-256 characters")
+
+	t.Setenv("TELEGRAM_WEBHOOK_URL", "https://api.example.com/hook")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "s3cret_token-1")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret_token-1", cfg.Telegram.WebhookSecret)
+}
+
+func TestReloader_AppliesOnlyTunables(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	r := NewReloader(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var applied *Config
+	r.OnReload(func(c *Config) { applied = c })
+
+	t.Setenv("RATE_LIMIT_MAX_REQUESTS", "5")
+	t.Setenv("PORT", "9999")
+	require.NoError(t, r.Reload())
+
+	require.NotNil(t, applied)
+	assert.Equal(t, 5, r.Current().RateLimit.MaxRequests)
+	assert.Equal(t, cfg.Server.Port, r.Current().Server.Port, "port needs a restart")
+
+	// Invalid config keeps the current one
+	t.Setenv("RATE_LIMIT_MAX_REQUESTS", "0")
+	assert.Error(t, r.Reload())
+	assert.Equal(t, 5, r.Current().RateLimit.MaxRequests)
+}
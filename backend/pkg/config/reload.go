@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Reloader holds the live configuration and reloads it on SIGHUP
+// Only tunables (RateLimit, AlertEngine, Scheduler) change on reload;
+// connection settings, secrets and ports keep their startup values and a
+// warning is logged when they differ, since applying them needs a restart
+type Reloader struct {
+	current  *Config
+	handlers []func(cfg *Config)
+	logger   *slog.Logger
+	mu       sync.RWMutex
+}
+
+// NewReloader creates a Reloader starting from cfg
+func NewReloader(cfg *Config, logger *slog.Logger) *Reloader {
+	return &Reloader{
+		current: cfg,
+		logger:  logger,
+	}
+}
+
+// Current returns the live configuration. The returned value must not be
+// modified
+func (r *Reloader) Current() *Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// OnReload registers a handler called with the new configuration after
+// every successful reload
+func (r *Reloader) OnReload(fn func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// Reload loads the configuration again. On error the current configuration
+// stays in effect
+func (r *Reloader) Reload() error {
+	next, err := Load()
+	if err != nil {
+		r.logger.Error("config reload failed, keeping current configuration",
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	r.mu.Lock()
+	prev := r.current
+	for _, key := range restartRequired(prev, next) {
+		r.logger.Warn("config change requires a restart and was not applied",
+			slog.String("key", key),
+		)
+	}
+
+	// Keep startup values for everything that is not reloadable
+	reloaded := *prev
+	reloaded.RateLimit = next.RateLimit
+	reloaded.AlertEngine = next.AlertEngine
+	reloaded.Scheduler = next.Scheduler
+	r.current = &reloaded
+
+	handlers := append([]func(cfg *Config){}, r.handlers...)
+	r.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(&reloaded)
+	}
+
+	r.logger.Info("configuration reloaded")
+	return nil
+}
+
+// Watch reloads the configuration on every SIGHUP until ctx is done
+func (r *Reloader) Watch(ctx context.Context) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigChan)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigChan:
+				r.logger.Info("SIGHUP received, reloading configuration")
+				_ = r.Reload()
+			}
+		}
+	}()
+}
+
+// restartRequired lists the non-reloadable settings that differ
+func restartRequired(prev, next *Config) []string {
+	var keys []string
+	check := func(key string, changed bool) {
+		if changed {
+			keys = append(keys, key)
+		}
+	}
+
+	check("ENV", prev.Server.Env != next.Server.Env)
+	check("PORT", prev.Server.Port != next.Server.Port)
+	check("GRPC_PORT", prev.Server.GRPCPort != next.Server.GRPCPort)
+	check("ALERT_ENGINE_GRPC_ADDR", prev.Services.AlertEngineGRPCAddr != next.Services.AlertEngineGRPCAddr)
+	check("NOTIFICATION_GRPC_ADDR", prev.Services.NotificationGRPCAddr != next.Services.NotificationGRPCAddr)
+	check("INTERNAL_GRPC_TOKEN", prev.Services.GRPCToken != next.Services.GRPCToken)
+	check("DATABASE_URL", prev.Database.URL != next.Database.URL)
+	check("DB_MAX_CONNS", prev.Database.MaxConns != next.Database.MaxConns)
+	check("DB_MIN_CONNS", prev.Database.MinConns != next.Database.MinConns)
+	check("REDIS_URL", prev.Redis.URL != next.Redis.URL)
+	check("REDIS_DB", prev.Redis.DB != next.Redis.DB)
+	check("TELEGRAM_BOT_TOKEN", prev.Telegram.BotToken != next.Telegram.BotToken)
+	check("TELEGRAM_MINI_APP_URL", prev.Telegram.MiniAppURL != next.Telegram.MiniAppURL)
+	check("TELEGRAM_WEBHOOK_URL", prev.Telegram.WebhookURL != next.Telegram.WebhookURL)
+	check("TELEGRAM_WEBHOOK_SECRET", prev.Telegram.WebhookSecret != next.Telegram.WebhookSecret)
+	check("JWT_SECRET", prev.JWT.Secret != next.JWT.Secret)
+	check("ADMIN_API_KEY", prev.Admin.APIKey != next.Admin.APIKey)
+	check("BINANCE_WS_URL", prev.Binance.WSURL != next.Binance.WSURL)
+	check("BINANCE_REST_URL", prev.Binance.RESTURL != next.Binance.RESTURL)
+	check("BINANCE_DEPTH_ENABLED", prev.Binance.DepthEnabled != next.Binance.DepthEnabled)
+	check("BINANCE_DEPTH_IDLE_TIMEOUT", prev.Binance.DepthIdleTimeout != next.Binance.DepthIdleTimeout)
+	check("ADMIN_IMPERSONATION_ENABLED", prev.Admin.ImpersonationEnabled != next.Admin.ImpersonationEnabled)
+	check("EXCHANGE_KEY_ENCRYPTION_KEY", prev.Exchange.KeyEncryptionKey != next.Exchange.KeyEncryptionKey)
+	check("LOG_REQUEST_BODIES", prev.Logging.RequestBodies != next.Logging.RequestBodies)
+	check("LOG_BODY_MAX_SIZE", prev.Logging.BodyMaxSize != next.Logging.BodyMaxSize)
+	check("NOTIFICATION_BATCH_WINDOW", prev.Notification.BatchWindow != next.Notification.BatchWindow)
+	check("NOTIFICATION_BATCH_MAX_SIZE", prev.Notification.BatchMaxSize != next.Notification.BatchMaxSize)
+	check("NOTIFICATION_COIN_THROTTLE", prev.Notification.CoinThrottle != next.Notification.CoinThrottle)
+	check("NOTIFICATION_SLO_ALERT_CHAT_ID", prev.Notification.SLOAlertChatID != next.Notification.SLOAlertChatID)
+	check("ABUSE_CHURN_LIMIT", prev.Abuse.ChurnLimit != next.Abuse.ChurnLimit)
+	check("ABUSE_CHURN_WINDOW", prev.Abuse.ChurnWindow != next.Abuse.ChurnWindow)
+	check("ABUSE_THROTTLE_DURATION", prev.Abuse.ThrottleDuration != next.Abuse.ThrottleDuration)
+
+	return keys
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Supported secret providers (SECRETS_PROVIDER)
+const (
+	SecretsProviderVault = "vault"
+	SecretsProviderAWS   = "aws"
+)
+
+// secretsFetchTimeout bounds a single fetch from the secret provider
+const secretsFetchTimeout = 10 * time.Second
+
+// SecretProvider fetches secrets from an external store. Keys use the same
+// names as the environment (TELEGRAM_BOT_TOKEN, JWT_SECRET, DATABASE_URL, ...)
+type SecretProvider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// SecretsConfig selects and configures the secret provider
+type SecretsConfig struct {
+	// Provider is "vault", "aws" or empty to read secrets from env only
+	Provider string
+	// How often secrets are re-fetched to pick up rotations
+	RefreshInterval time.Duration
+
+	// Vault KV v2: secrets are read from <VaultAddr>/v1/<VaultMount>/data/<VaultPath>
+	VaultAddr  string
+	VaultToken string
+	VaultMount string
+	VaultPath  string
+
+	// AWS Secrets Manager: SecretString of AWSSecretID must be a JSON object
+	AWSRegion          string
+	AWSSecretID        string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	// AWSEndpoint overrides the regional endpoint (optional)
+	AWSEndpoint string
+}
+
+// problems checks the provider settings; required fields depend on Provider
+func (c SecretsConfig) problems() []string {
+	var p []string
+	require := func(key, value string) {
+		if value == "" {
+			p = append(p, key+": is required when SECRETS_PROVIDER="+c.Provider)
+		}
+	}
+
+	switch c.Provider {
+	case "":
+		return nil
+	case SecretsProviderVault:
+		require("VAULT_ADDR", c.VaultAddr)
+		require("VAULT_TOKEN", c.VaultToken)
+		require("VAULT_SECRET_PATH", c.VaultPath)
+		if c.VaultAddr != "" {
+			if err := checkURL(c.VaultAddr, "http", "https"); err != nil {
+				p = append(p, "VAULT_ADDR: "+err.Error())
+			}
+		}
+	case SecretsProviderAWS:
+		require("AWS_REGION", c.AWSRegion)
+		require("AWS_SECRET_ID", c.AWSSecretID)
+		require("AWS_ACCESS_KEY_ID", c.AWSAccessKeyID)
+		require("AWS_SECRET_ACCESS_KEY", c.AWSSecretAccessKey)
+	default:
+		p = append(p, fmt.Sprintf("SECRETS_PROVIDER: must be one of vault, aws or empty, got %q", c.Provider))
+	}
+
+	if c.RefreshInterval < 0 {
+		p = append(p, fmt.Sprintf("SECRETS_REFRESH_INTERVAL: must not be negative (0 disables refresh), got %s", c.RefreshInterval))
+	}
+
+	return p
+}
+
+// NewSecretProvider builds the configured provider, or nil if none is set
+func NewSecretProvider(cfg SecretsConfig) (SecretProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case SecretsProviderVault:
+		return newVaultProvider(cfg)
+	case SecretsProviderAWS:
+		return newAWSProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", cfg.Provider)
+	}
+}
+
+// loadSecrets fetches the secrets used while loading the configuration
+func loadSecrets(cfg SecretsConfig) (map[string]string, error) {
+	provider, err := NewSecretProvider(cfg)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsFetchTimeout)
+	defer cancel()
+
+	values, err := provider.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secrets from %s: %w", provider.Name(), err)
+	}
+	return values, nil
+}
+
+// SecretStore caches secrets from a provider and notifies rotation handlers
+// when a periodic refresh returns a changed value
+type SecretStore struct {
+	provider SecretProvider
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	values    map[string]string
+	fetchedAt time.Time
+	handlers  map[string][]func(value string)
+}
+
+// NewSecretStore creates a store for the provider configured in cfg, seeded
+// with the secrets fetched by Load. Returns nil when no provider is set; a
+// nil store is safe to use and does nothing
+func NewSecretStore(cfg *Config, logger *slog.Logger) (*SecretStore, error) {
+	provider, err := NewSecretProvider(cfg.Secrets)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	store := newSecretStore(provider, cfg.Secrets.RefreshInterval, logger)
+	store.values = copySecrets(cfg.secrets)
+	store.fetchedAt = time.Now()
+	return store, nil
+}
+
+func newSecretStore(provider SecretProvider, interval time.Duration, logger *slog.Logger) *SecretStore {
+	return &SecretStore{
+		provider: provider,
+		interval: interval,
+		logger:   logger,
+		values:   map[string]string{},
+		handlers: map[string][]func(value string){},
+	}
+}
+
+// Get returns a cached secret, refreshing the cache first if it is older than
+// the refresh interval. A stale value is returned if the refresh fails
+func (s *SecretStore) Get(ctx context.Context, key string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	s.mu.RLock()
+	stale := s.interval > 0 && time.Since(s.fetchedAt) >= s.interval
+	s.mu.RUnlock()
+
+	if stale {
+		if err := s.Refresh(ctx); err != nil {
+			s.logger.Warn("secret refresh failed, using cached value",
+				slog.String("provider", s.provider.Name()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// OnRotate registers a handler called with the new value whenever key changes
+func (s *SecretStore) OnRotate(key string, fn func(value string)) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[key] = append(s.handlers[key], fn)
+}
+
+// Refresh fetches all secrets and replaces the cached set with them. The
+// rotation handlers of keys added or changed since the last fetch run with
+// the new value; a removed key is only logged, as its value stays in use
+// until restart
+func (s *SecretStore) Refresh(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretsFetchTimeout)
+	defer cancel()
+
+	values, err := s.provider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	var changed, removed []string
+	for key, value := range values {
+		if prev, ok := s.values[key]; !ok || prev != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range s.values {
+		if _, ok := values[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	s.values = values
+	s.fetchedAt = time.Now()
+
+	sort.Strings(changed)
+	sort.Strings(removed)
+	type rotation struct {
+		key      string
+		value    string
+		handlers []func(value string)
+	}
+	rotations := make([]rotation, 0, len(changed))
+	for _, key := range changed {
+		rotations = append(rotations, rotation{
+			key:      key,
+			value:    values[key],
+			handlers: append([]func(value string){}, s.handlers[key]...),
+		})
+	}
+	s.mu.Unlock()
+
+	for _, key := range removed {
+		s.logger.Warn("secret removed from provider, value in use kept until restart",
+			slog.String("key", key),
+		)
+	}
+	for _, r := range rotations {
+		if len(r.handlers) == 0 {
+			s.logger.Warn("secret rotated but is only applied on restart",
+				slog.String("key", r.key),
+			)
+			continue
+		}
+		for _, fn := range r.handlers {
+			fn(r.value)
+		}
+		s.logger.Info("secret rotated", slog.String("key", r.key))
+	}
+
+	return nil
+}
+
+// Watch refreshes the secrets every refresh interval until ctx is done
+func (s *SecretStore) Watch(ctx context.Context) {
+	if s == nil || s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+					s.logger.Error("failed to refresh secrets",
+						slog.String("provider", s.provider.Name()),
+						slog.String("error", err.Error()),
+					)
+				}
+			}
+		}
+	}()
+}
+
+func copySecrets(values map[string]string) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVaultServer serves a KV v2 secret whose bot token is read from token
+func newVaultServer(t *testing.T, token *atomic.Value) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/weqory" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data": map[string]any{
+					"TELEGRAM_BOT_TOKEN": token.Load().(string),
+					"JWT_SECRET":         "vault-jwt",
+				},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLoad_VaultSecretsOverrideEnv(t *testing.T) {
+	var token atomic.Value
+	token.Store("vault-token")
+	srv := newVaultServer(t, &token)
+
+	t.Setenv("SECRETS_PROVIDER", "vault")
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("VAULT_SECRET_PATH", "weqory")
+	t.Setenv("TELEGRAM_BOT_TOKEN", "stale-env-token")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "vault-token", cfg.Telegram.BotToken)
+	assert.Equal(t, "vault-jwt", cfg.JWT.Secret)
+}
+
+func TestLoad_SecretsProviderMisconfigured(t *testing.T) {
+	t.Setenv("SECRETS_PROVIDER", "aws")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	_, err := Load()
+	require.Error(t, err)
+
+	for _, key := range []string{"AWS_SECRET_ID", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+		assert.Contains(t, err.Error(), key+":")
+	}
+}
+
+func TestSecretStore_RotationCallbacks(t *testing.T) {
+	var token atomic.Value
+	token.Store("v1")
+	srv := newVaultServer(t, &token)
+
+	t.Setenv("SECRETS_PROVIDER", "vault")
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("VAULT_SECRET_PATH", "weqory")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	store, err := NewSecretStore(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	var rotated []string
+	store.OnRotate("TELEGRAM_BOT_TOKEN", func(value string) {
+		rotated = append(rotated, value)
+	})
+
+	// Unchanged secrets do not fire callbacks
+	require.NoError(t, store.Refresh(context.Background()))
+	assert.Empty(t, rotated)
+
+	token.Store("v2")
+	require.NoError(t, store.Refresh(context.Background()))
+	assert.Equal(t, []string{"v2"}, rotated)
+
+	value, ok := store.Get(context.Background(), "TELEGRAM_BOT_TOKEN")
+	assert.True(t, ok)
+	assert.Equal(t, "v2", value)
+}
+
+func TestSecretStore_RefreshAddsAndRemovesKeys(t *testing.T) {
+	var secrets atomic.Value
+	secrets.Store(map[string]string{"JWT_SECRET": "jwt", "DATABASE_URL": "postgres://db"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"data": secrets.Load()},
+		})
+	}))
+	defer srv.Close()
+
+	provider, err := newVaultProvider(SecretsConfig{VaultAddr: srv.URL, VaultToken: "root", VaultPath: "weqory"})
+	require.NoError(t, err)
+	store := newSecretStore(provider, 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, store.Refresh(context.Background()))
+
+	var rotated []string
+	store.OnRotate("TELEGRAM_BOT_TOKEN", func(value string) {
+		rotated = append(rotated, value)
+	})
+
+	// A key added to the provider after startup
+	secrets.Store(map[string]string{"JWT_SECRET": "jwt", "TELEGRAM_BOT_TOKEN": "bot"})
+	require.NoError(t, store.Refresh(context.Background()))
+	assert.Equal(t, []string{"bot"}, rotated)
+
+	value, ok := store.Get(context.Background(), "TELEGRAM_BOT_TOKEN")
+	assert.True(t, ok)
+	assert.Equal(t, "bot", value)
+
+	// A key removed from the provider
+	_, ok = store.Get(context.Background(), "DATABASE_URL")
+	assert.False(t, ok)
+}
+
+func TestSecretStore_NilIsNoop(t *testing.T) {
+	store, err := NewSecretStore(&Config{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	store.OnRotate("JWT_SECRET", func(string) {})
+	store.Watch(context.Background())
+	assert.NoError(t, store.Refresh(context.Background()))
+	_, ok := store.Get(context.Background(), "JWT_SECRET")
+	assert.False(t, ok)
+}
+
+func TestAWSProvider_Fetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "20240115T120000Z", r.Header.Get("X-Amz-Date"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240115/eu-west-1/secretsmanager/aws4_request, "))
+		assert.Contains(t, auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-target, ")
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "weqory/prod", body["SecretId"])
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"SecretString": `{"DATABASE_URL":"postgres://db/weqory","DB_MAX_CONNS":50}`,
+		})
+	}))
+	defer srv.Close()
+
+	provider, err := newAWSProvider(SecretsConfig{
+		AWSRegion:          "eu-west-1",
+		AWSSecretID:        "weqory/prod",
+		AWSAccessKeyID:     "AKID",
+		AWSSecretAccessKey: "secret",
+		AWSEndpoint:        srv.URL,
+	})
+	require.NoError(t, err)
+	provider.now = func() time.Time { return time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC) }
+
+	values, err := provider.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://db/weqory", values["DATABASE_URL"])
+	assert.Equal(t, "50", values["DB_MAX_CONNS"])
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// vaultProvider reads a secret from HashiCorp Vault's KV v2 engine
+type vaultProvider struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func newVaultProvider(cfg SecretsConfig) (*vaultProvider, error) {
+	if cfg.VaultAddr == "" || cfg.VaultToken == "" || cfg.VaultPath == "" {
+		return nil, fmt.Errorf("vault provider requires VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+	}
+
+	mount := strings.Trim(cfg.VaultMount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+
+	return &vaultProvider{
+		url:        fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimRight(cfg.VaultAddr, "/"), mount, strings.Trim(cfg.VaultPath, "/")),
+		token:      cfg.VaultToken,
+		httpClient: &http.Client{Timeout: secretsFetchTimeout},
+	}, nil
+}
+
+func (p *vaultProvider) Name() string {
+	return SecretsProviderVault
+}
+
+// Fetch returns the latest version of the secret's key/value pairs
+func (p *vaultProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return stringValues(payload.Data.Data), nil
+}
+
+// stringValues converts a JSON object to strings; non-string values keep
+// their JSON encoding
+func stringValues(data map[string]any) map[string]string {
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case nil:
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			values[key] = string(encoded)
+		}
+	}
+	return values
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleOverridePrefix marks variables that override job schedules
+const scheduleOverridePrefix = "SCHEDULE_"
+
+// source resolves configuration keys from the secret provider, the
+// environment and the config file (in that order) and records values that
+// fail to parse
+type source struct {
+	secrets  map[string]string
+	file     map[string]string
+	problems []string
+}
+
+// newSource creates a source, reading the config file if a path is given
+func newSource(path string) (*source, error) {
+	src := &source{file: map[string]string{}}
+	if path == "" {
+		return src, nil
+	}
+
+	file, err := parseConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src.file = file
+
+	return src, nil
+}
+
+// parseConfigFile reads KEY=VALUE lines. Blank lines and lines starting with
+// # are ignored; values may be wrapped in single or double quotes
+func parseConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("config file %s:%d: expected KEY=VALUE", path, lineNo)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return values, nil
+}
+
+// lookup returns the value for key. Secrets win over the environment so a
+// stale variable cannot shadow a rotated secret
+func (s *source) lookup(key string) (string, bool) {
+	if value, ok := s.secrets[key]; ok && value != "" {
+		return value, true
+	}
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	if value, ok := s.file[key]; ok && value != "" {
+		return value, true
+	}
+	return "", false
+}
+
+// String returns a string value
+func (s *source) String(key, defaultValue string) string {
+	if value, ok := s.lookup(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Int returns an integer value
+func (s *source) Int(key string, defaultValue int) int {
+	value, ok := s.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	intVal, err := strconv.Atoi(value)
+	if err != nil {
+		s.addProblem(key, "must be an integer, got %q", value)
+		return defaultValue
+	}
+	return intVal
+}
+
+// Float returns a floating point value
+func (s *source) Float(key string, defaultValue float64) float64 {
+	value, ok := s.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	floatVal, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.addProblem(key, "must be a number, got %q", value)
+		return defaultValue
+	}
+	return floatVal
+}
+
+// Bool returns a boolean value such as "true", "1" or "false"
+func (s *source) Bool(key string, defaultValue bool) bool {
+	value, ok := s.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	boolVal, err := strconv.ParseBool(value)
+	if err != nil {
+		s.addProblem(key, "must be true or false, got %q", value)
+		return defaultValue
+	}
+	return boolVal
+}
+
+// Duration returns a duration value such as "30s" or "1h"
+func (s *source) Duration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := s.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		s.addProblem(key, "must be a duration like 30s or 5m, got %q", value)
+		return defaultValue
+	}
+	return duration
+}
+
+// ScheduleOverrides collects SCHEDULE_* keys keyed by job name
+// (SCHEDULE_COINGECKO_SYNC -> coingecko-sync)
+func (s *source) ScheduleOverrides() map[string]string {
+	overrides := make(map[string]string)
+
+	add := func(key, value string) {
+		if !strings.HasPrefix(key, scheduleOverridePrefix) || value == "" {
+			return
+		}
+		name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, scheduleOverridePrefix), "_", "-"))
+		overrides[name] = value
+	}
+
+	for key, value := range s.file {
+		add(key, value)
+	}
+	// Environment wins over the file
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			add(key, value)
+		}
+	}
+
+	return overrides
+}
+
+// Problems returns the recorded parse errors in key order
+func (s *source) Problems() []string {
+	problems := append([]string(nil), s.problems...)
+	sort.Strings(problems)
+	return problems
+}
+
+func (s *source) addProblem(key, format string, args ...any) {
+	s.problems = append(s.problems, key+": "+fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every invalid or missing configuration value
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validEnvs are the supported values of ENV
+var validEnvs = map[string]bool{
+	"development": true,
+	"staging":     true,
+	"production":  true,
+	"test":        true,
+}
+
+// Validate validates the configuration
+func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// problems checks every rule and returns one message per violation
+func (c *Config) problems() []string {
+	var p []string
+	add := func(key, format string, args ...any) {
+		p = append(p, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	// Server
+	if !validEnvs[c.Server.Env] {
+		add("ENV", "must be one of development, staging, production, test, got %q", c.Server.Env)
+	}
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("PORT", "must be a port number between 1 and 65535, got %q", c.Server.Port)
+	}
+	if c.Server.GRPCPort != "" {
+		if port, err := strconv.Atoi(c.Server.GRPCPort); err != nil || port < 1 || port > 65535 {
+			add("GRPC_PORT", "must be a port number between 1 and 65535, got %q", c.Server.GRPCPort)
+		}
+		// The internal API sends test notifications and reloads alerts for
+		// any user, so it never listens unauthenticated
+		if c.Services.GRPCToken == "" {
+			add("INTERNAL_GRPC_TOKEN", "is required when GRPC_PORT is set")
+		}
+	}
+	checkPositive(add, "REQUEST_TIMEOUT", c.Server.RequestTimeout)
+
+	// Secrets required outside development
+	if c.IsProduction() {
+		if c.Telegram.BotToken == "" {
+			add("TELEGRAM_BOT_TOKEN", "is required in production")
+		}
+		if c.JWT.Secret == "" {
+			add("JWT_SECRET", "is required in production")
+		}
+		if c.Telegram.WebhookSecret == "" {
+			add("TELEGRAM_WEBHOOK_SECRET", "is required in production")
+		}
+	}
+
+	// Telegram webhook
+	if c.Telegram.WebhookSecret != "" && !validWebhookSecret(c.Telegram.WebhookSecret) {
+		add("TELEGRAM_WEBHOOK_SECRET", "must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+	if c.Telegram.WebhookURL != "" {
+		if err := checkURL(c.Telegram.WebhookURL, "https"); err != nil {
+			add("TELEGRAM_WEBHOOK_URL", "%s", err)
+		}
+		if c.Telegram.WebhookSecret == "" {
+			add("TELEGRAM_WEBHOOK_SECRET", "is required when TELEGRAM_WEBHOOK_URL is set")
+		}
+	}
+
+	// Binance endpoints
+	if c.Binance.WSURL != "" {
+		if err := checkURL(c.Binance.WSURL, "ws", "wss"); err != nil {
+			add("BINANCE_WS_URL", "%s", err)
+		}
+	}
+	if c.Binance.RESTURL != "" {
+		if err := checkURL(c.Binance.RESTURL, "http", "https"); err != nil {
+			add("BINANCE_REST_URL", "%s", err)
+		}
+	}
+	checkPositive(add, "BINANCE_DEPTH_IDLE_TIMEOUT", c.Binance.DepthIdleTimeout)
+
+	// Database
+	if err := checkURL(c.Database.URL, "postgres", "postgresql"); err != nil {
+		add("DATABASE_URL", "%s", err)
+	}
+	if c.Database.MaxConns < 1 {
+		add("DB_MAX_CONNS", "must be at least 1, got %d", c.Database.MaxConns)
+	}
+	if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+		add("DB_MIN_CONNS", "must be between 0 and DB_MAX_CONNS (%d), got %d", c.Database.MaxConns, c.Database.MinConns)
+	}
+	checkPositive(add, "DB_MAX_CONN_LIFETIME", c.Database.MaxConnLifetime)
+	checkPositive(add, "DB_MAX_CONN_IDLE_TIME", c.Database.MaxConnIdleTime)
+
+	// Redis
+	if err := checkURL(c.Redis.URL, "redis", "rediss"); err != nil {
+		add("REDIS_URL", "%s", err)
+	}
+	if c.Redis.DB < 0 {
+		add("REDIS_DB", "must not be negative, got %d", c.Redis.DB)
+	}
+
+	// Event bus
+	switch c.EventBus.Transport {
+	case "redis", "redis-streams":
+	case "nats", "nats-jetstream":
+		if err := checkURL(c.EventBus.NATSURL, "nats", "tls"); err != nil {
+			add("NATS_URL", "%s", err)
+		}
+		if c.EventBus.Transport == "nats-jetstream" && c.EventBus.Consumer == "" {
+			add("EVENT_BUS_CONSUMER", "is required with nats-jetstream")
+		}
+	default:
+		add("EVENT_BUS_TRANSPORT", "must be one of redis, redis-streams, nats, nats-jetstream, got %q", c.EventBus.Transport)
+	}
+	if c.EventBus.StreamMaxLen < 1 {
+		add("EVENT_BUS_STREAM_MAX_LEN", "must be at least 1, got %d", c.EventBus.StreamMaxLen)
+	}
+
+	// Optional URLs
+	optionalURLs := []struct {
+		key   string
+		value string
+	}{
+		{"TELEGRAM_MINI_APP_URL", c.Telegram.MiniAppURL},
+		{"ALERT_ENGINE_URL", c.Services.AlertEngineURL},
+		{"NOTIFICATION_URL", c.Services.NotificationURL},
+	}
+	for _, u := range optionalURLs {
+		if u.value == "" {
+			continue
+		}
+		if err := checkURL(u.value, "http", "https"); err != nil {
+			add(u.key, "%s", err)
+		}
+	}
+
+	// Internal gRPC addresses
+	grpcAddrs := []struct {
+		key   string
+		value string
+	}{
+		{"ALERT_ENGINE_GRPC_ADDR", c.Services.AlertEngineGRPCAddr},
+		{"NOTIFICATION_GRPC_ADDR", c.Services.NotificationGRPCAddr},
+	}
+	for _, a := range grpcAddrs {
+		if a.value == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(a.value); err != nil {
+			add(a.key, "must be host:port, got %q", a.value)
+		}
+	}
+
+	checkPositive(add, "JWT_EXPIRY", c.JWT.Expiry)
+
+	// Exchange API key encryption
+	if c.Exchange.KeyEncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Exchange.KeyEncryptionKey)
+		if err != nil || len(key) != 32 {
+			add("EXCHANGE_KEY_ENCRYPTION_KEY", "must be a base64-encoded 32-byte key")
+		}
+	}
+
+	// Logging
+	if c.Logging.BodyMaxSize < 1 {
+		add("LOG_BODY_MAX_SIZE", "must be at least 1, got %d", c.Logging.BodyMaxSize)
+	}
+
+	// Rate limits
+	if c.RateLimit.MaxRequests < 1 {
+		add("RATE_LIMIT_MAX_REQUESTS", "must be at least 1, got %d", c.RateLimit.MaxRequests)
+	}
+	if c.RateLimit.Window < time.Second {
+		add("RATE_LIMIT_WINDOW", "must be at least 1s, got %s", c.RateLimit.Window)
+	}
+
+	// Alert engine
+	if c.AlertEngine.AnomalyMaxJumpPct < 0 {
+		add("ANOMALY_MAX_JUMP_PCT", "must not be negative (0 disables the filter), got %g", c.AlertEngine.AnomalyMaxJumpPct)
+	}
+	checkPositive(add, "ANOMALY_CONFIRM_WINDOW", c.AlertEngine.AnomalyConfirmWindow)
+	if c.AlertEngine.TradeStreamNearPct < 0 {
+		add("TRADE_STREAM_NEAR_PCT", "must not be negative (0 disables trade streams), got %g", c.AlertEngine.TradeStreamNearPct)
+	}
+	if c.AlertEngine.BookTickerNearPct < 0 {
+		add("BOOK_TICKER_NEAR_PCT", "must not be negative (0 disables book ticker streams), got %g", c.AlertEngine.BookTickerNearPct)
+	}
+	if c.AlertEngine.WatchlistSymbolLimit < 0 || c.AlertEngine.WatchlistSymbolLimit > 1000 {
+		add("WATCHLIST_SYMBOL_LIMIT", "must be between 0 and 1000 (Binance allows 1024 streams per connection), got %d", c.AlertEngine.WatchlistSymbolLimit)
+	}
+	if c.AlertEngine.RetryMaxAttempts < 1 {
+		add("RETRY_MAX_ATTEMPTS", "must be at least 1, got %d", c.AlertEngine.RetryMaxAttempts)
+	}
+	checkPositive(add, "RETRY_BASE_DELAY", c.AlertEngine.RetryBaseDelay)
+	if c.AlertEngine.RetryMaxDelay < c.AlertEngine.RetryBaseDelay {
+		add("RETRY_MAX_DELAY", "must not be less than RETRY_BASE_DELAY (%s), got %s", c.AlertEngine.RetryBaseDelay, c.AlertEngine.RetryMaxDelay)
+	}
+	if c.AlertEngine.BurstChunkSize < 1 {
+		add("BURST_CHUNK_SIZE", "must be at least 1, got %d", c.AlertEngine.BurstChunkSize)
+	}
+	checkPositive(add, "BURST_INTERVAL", c.AlertEngine.BurstInterval)
+
+	// Kafka sink
+	if len(c.Kafka.Brokers) > 0 {
+		for _, broker := range c.Kafka.Brokers {
+			if _, _, err := net.SplitHostPort(broker); err != nil {
+				add("KAFKA_BROKERS", "must be a comma-separated list of host:port, got %q", broker)
+			}
+		}
+		if c.Kafka.TicksTopic == "" {
+			add("KAFKA_TICKS_TOPIC", "is required with KAFKA_BROKERS")
+		}
+		if c.Kafka.TriggersTopic == "" {
+			add("KAFKA_TRIGGERS_TOPIC", "is required with KAFKA_BROKERS")
+		}
+		if c.Kafka.BatchSize < 1 {
+			add("KAFKA_BATCH_SIZE", "must be at least 1, got %d", c.Kafka.BatchSize)
+		}
+		checkPositive(add, "KAFKA_BATCH_TIMEOUT", c.Kafka.BatchTimeout)
+		switch c.Kafka.Compression {
+		case "none", "gzip", "snappy", "lz4", "zstd":
+		default:
+			add("KAFKA_COMPRESSION", "must be one of none, gzip, snappy, lz4, zstd, got %q", c.Kafka.Compression)
+		}
+		if c.Kafka.BufferSize < c.Kafka.BatchSize {
+			add("KAFKA_BUFFER_SIZE", "must not be less than KAFKA_BATCH_SIZE (%d), got %d", c.Kafka.BatchSize, c.Kafka.BufferSize)
+		}
+	}
+
+	// Notifications
+	if c.Notification.BatchWindow < 0 {
+		add("NOTIFICATION_BATCH_WINDOW", "must not be negative (0 disables batching), got %s", c.Notification.BatchWindow)
+	}
+	if c.Notification.BatchMaxSize < 1 {
+		add("NOTIFICATION_BATCH_MAX_SIZE", "must be at least 1, got %d", c.Notification.BatchMaxSize)
+	}
+	if c.Notification.CoinThrottle < 0 {
+		add("NOTIFICATION_COIN_THROTTLE", "must not be negative (0 disables the throttle), got %s", c.Notification.CoinThrottle)
+	}
+	if c.Notification.LatencySLO < 0 {
+		add("NOTIFICATION_LATENCY_SLO", "must not be negative (0 disables the check), got %s", c.Notification.LatencySLO)
+	}
+	if c.Notification.MinWorkers < 1 {
+		add("NOTIFICATION_MIN_WORKERS", "must be at least 1, got %d", c.Notification.MinWorkers)
+	}
+	if c.Notification.MaxWorkers < c.Notification.MinWorkers {
+		add("NOTIFICATION_MAX_WORKERS", "must not be less than NOTIFICATION_MIN_WORKERS (%d), got %d", c.Notification.MinWorkers, c.Notification.MaxWorkers)
+	}
+	if c.Notification.QueueSize < 1 {
+		add("NOTIFICATION_QUEUE_SIZE", "must be at least 1, got %d", c.Notification.QueueSize)
+	}
+
+	// Abuse detection
+	if c.Abuse.ChurnLimit < 0 {
+		add("ABUSE_CHURN_LIMIT", "must not be negative (0 disables detection), got %d", c.Abuse.ChurnLimit)
+	}
+	checkPositive(add, "ABUSE_CHURN_WINDOW", c.Abuse.ChurnWindow)
+	checkPositive(add, "ABUSE_THROTTLE_DURATION", c.Abuse.ThrottleDuration)
+
+	// Secret provider
+	p = append(p, c.Secrets.problems()...)
+
+	return p
+}
+
+// checkURL verifies that value is an absolute URL with one of the schemes
+func checkURL(value string, schemes ...string) error {
+	if value == "" {
+		return fmt.Errorf("is required")
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL, got %q", redactURL(value))
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("must use scheme %s, got %q", strings.Join(schemes, " or "), u.Scheme)
+}
+
+// validWebhookSecret reports whether s is accepted by Telegram as a
+// webhook secret_token
+func validWebhookSecret(s string) bool {
+	if len(s) > 256 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPositive records a problem when a duration is not positive
+func checkPositive(add func(key, format string, args ...any), key string, d time.Duration) {
+	if d <= 0 {
+		add(key, "must be a positive duration, got %s", d)
+	}
+}
+
+// redactURL hides credentials so they never end up in logs
+func redactURL(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		u.User = url.User("***")
+		return u.String()
+	}
+	if strings.Contains(value, "@") {
+		return "***"
+	}
+	return value
+}
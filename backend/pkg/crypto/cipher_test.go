@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+
+	a, err := c.Encrypt("api-secret")
+	require.NoError(t, err)
+	b, err := c.Encrypt("api-secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b, "nonce must be random")
+
+	plaintext, err := c.Decrypt(a)
+	require.NoError(t, err)
+	assert.Equal(t, "api-secret", plaintext)
+}
+
+func TestCipher_RejectsTamperedOrForeignValues(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+	other, err := NewCipherFromBase64(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, KeySize)))
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt("api-secret")
+	require.NoError(t, err)
+
+	_, err = other.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	raw, _ := base64.StdEncoding.DecodeString(encrypted)
+	raw[len(raw)-1] ^= 0xff
+	_, err = c.Decrypt(base64.StdEncoding.EncodeToString(raw))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = c.Decrypt("not base64!")
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNewCipher_KeySize(t *testing.T) {
+	_, err := NewCipher([]byte("short"))
+	assert.Error(t, err)
+}
@@ -1,8 +1,10 @@
 package crypto
 
 import (
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -12,12 +14,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/weqory/backend/pkg/clock"
 	"github.com/weqory/backend/pkg/errors"
 )
 
 const (
 	// InitDataExpiry is the maximum age of init data (24 hours)
 	InitDataExpiry = 24 * time.Hour
+
+	// InitDataClockSkew is how far in the future auth_date may be, to allow
+	// for clock drift between Telegram and us
+	InitDataClockSkew = time.Minute
+)
+
+// Public keys Telegram signs init data with for third-party validation
+var (
+	TelegramPublicKey     = mustDecodeKey("e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d")
+	TelegramTestPublicKey = mustDecodeKey("40055058a4ee38156a06562e52eece92a771bcd8346a8c4615cb7376eddf72ec")
 )
 
 // TelegramUser represents a Telegram user from InitData
@@ -37,12 +50,36 @@ type InitData struct {
 	User         *TelegramUser `json:"user,omitempty"`
 	AuthDate     int64         `json:"auth_date"`
 	Hash         string        `json:"hash"`
+	Signature    string        `json:"signature,omitempty"`
 	StartParam   string        `json:"start_param,omitempty"`
 }
 
+// InitDataValidator validates init data either with the bot token (HMAC) or,
+// for a bot whose token we do not hold, with the Ed25519 signature Telegram
+// adds for third-party validation
+type InitDataValidator struct {
+	// BotToken verifies the HMAC hash; ignored when BotID is set
+	BotToken string
+	// BotID switches to third-party validation of the signature issued for
+	// this bot
+	BotID int64
+	// PublicKey verifies third-party signatures; TelegramPublicKey when nil
+	PublicKey ed25519.PublicKey
+	// MaxAge is how old auth_date may be; InitDataExpiry when zero
+	MaxAge time.Duration
+	// Clock tells the time auth_date is checked against; the system clock
+	// when nil
+	Clock clock.Clock
+}
+
 // ValidateInitData validates Telegram Mini App InitData
 // See: https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
 func ValidateInitData(initData string, botToken string) (*InitData, error) {
+	return InitDataValidator{BotToken: botToken}.Validate(initData)
+}
+
+// Validate validates and parses init data
+func (v InitDataValidator) Validate(initData string) (*InitData, error) {
 	if initData == "" {
 		return nil, errors.ErrInvalidInitData
 	}
@@ -60,9 +97,9 @@ func ValidateInitData(initData string, botToken string) (*InitData, error) {
 		return nil, errors.ErrInvalidInitData.WithMessage("invalid auth_date")
 	}
 
-	// Check if auth_date is not too old
-	if time.Now().Unix()-authDate > int64(InitDataExpiry.Seconds()) {
-		return nil, errors.ErrExpiredInitData
+	// Check if auth_date is neither too old nor in the future
+	if err := v.checkAuthDate(authDate); err != nil {
+		return nil, err
 	}
 
 	// Extract hash
@@ -70,25 +107,33 @@ func ValidateInitData(initData string, botToken string) (*InitData, error) {
 	if hash == "" {
 		return nil, errors.ErrInvalidInitData.WithMessage("missing hash")
 	}
+	signature := values.Get("signature")
 
 	// Remove hash from values for verification
 	values.Del("hash")
 
-	// Build data-check-string
-	dataCheckString := buildDataCheckString(values)
+	if v.BotID != 0 {
+		if err := v.verifySignature(values); err != nil {
+			return nil, err
+		}
+	} else {
+		// Build data-check-string
+		dataCheckString := buildDataCheckString(values)
 
-	// Calculate expected hash
-	expectedHash := calculateHash(dataCheckString, botToken)
+		// Calculate expected hash
+		expectedHash := calculateHash(dataCheckString, v.BotToken)
 
-	// Compare hashes
-	if !hmac.Equal([]byte(hash), []byte(expectedHash)) {
-		return nil, errors.ErrInvalidInitData.WithMessage("invalid hash")
+		// Compare hashes
+		if !hmac.Equal([]byte(hash), []byte(expectedHash)) {
+			return nil, errors.ErrInvalidInitData.WithMessage("invalid hash")
+		}
 	}
 
 	// Parse user data
 	result := &InitData{
 		AuthDate:   authDate,
 		Hash:       hash,
+		Signature:  signature,
 		QueryID:    values.Get("query_id"),
 		StartParam: values.Get("start_param"),
 	}
@@ -106,6 +151,71 @@ func ValidateInitData(initData string, botToken string) (*InitData, error) {
 	return result, nil
 }
 
+// checkAuthDate rejects init data issued too long ago or in the future
+func (v InitDataValidator) checkAuthDate(authDate int64) error {
+	var now time.Time
+	if v.Clock != nil {
+		now = v.Clock.Now()
+	} else {
+		now = time.Now()
+	}
+
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = InitDataExpiry
+	}
+
+	age := now.Sub(time.Unix(authDate, 0))
+	if age > maxAge {
+		return errors.ErrExpiredInitData
+	}
+	if age < -InitDataClockSkew {
+		return errors.ErrInvalidInitData.WithMessage("auth_date is in the future")
+	}
+	return nil
+}
+
+// verifySignature checks the third-party Ed25519 signature of values, which
+// must no longer contain the hash
+func (v InitDataValidator) verifySignature(values url.Values) error {
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(values.Get("signature"), "="))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return errors.ErrInvalidInitData.WithMessage("missing or malformed signature")
+	}
+
+	publicKey := v.PublicKey
+	if publicKey == nil {
+		publicKey = TelegramPublicKey
+	}
+
+	if !ed25519.Verify(publicKey, []byte(thirdPartyDataCheckString(v.BotID, values)), signature) {
+		return errors.ErrInvalidInitData.WithMessage("invalid signature")
+	}
+	return nil
+}
+
+// thirdPartyDataCheckString builds the string Telegram signs for third-party
+// validation: the bot ID header followed by the fields other than hash and
+// signature
+func thirdPartyDataCheckString(botID int64, values url.Values) string {
+	fields := url.Values{}
+	for k, v := range values {
+		if k != "signature" {
+			fields[k] = v
+		}
+	}
+	return strconv.FormatInt(botID, 10) + ":WebAppData\n" + buildDataCheckString(fields)
+}
+
+// mustDecodeKey decodes a hex encoded Ed25519 public key
+func mustDecodeKey(s string) ed25519.PublicKey {
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		panic("crypto: invalid public key " + s)
+	}
+	return key
+}
+
 // buildDataCheckString builds the data-check-string for hash verification
 func buildDataCheckString(values url.Values) string {
 	// Get sorted keys
@@ -159,3 +269,22 @@ func GenerateTestInitData(user *TelegramUser, botToken string) string {
 
 	return values.Encode()
 }
+
+// GenerateTestSignedInitData generates test InitData signed for third-party
+// validation of botID with privateKey
+// WARNING: Only use in development/testing environments
+func GenerateTestSignedInitData(user *TelegramUser, botID int64, privateKey ed25519.PrivateKey, authDate time.Time) string {
+	userJSON, _ := json.Marshal(user)
+
+	values := url.Values{}
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	values.Set("user", string(userJSON))
+	values.Set("query_id", "test_query_id")
+
+	signature := ed25519.Sign(privateKey, []byte(thirdPartyDataCheckString(botID, values)))
+	values.Set("signature", base64.RawURLEncoding.EncodeToString(signature))
+	// Third-party validation ignores the hash, but Telegram always sends one
+	values.Set("hash", "0")
+
+	return values.Encode()
+}
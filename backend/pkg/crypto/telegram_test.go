@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weqory/backend/pkg/clock"
+	"github.com/weqory/backend/pkg/errors"
+)
+
+func TestInitDataValidator_AuthDate(t *testing.T) {
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	v := InitDataValidator{BotToken: "bot-token", MaxAge: time.Hour, Clock: clock.NewFake(now)}
+
+	initData := func(authDate time.Time) string {
+		values := url.Values{}
+		values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+		values.Set("user", `{"id":42,"first_name":"Test"}`)
+		values.Set("hash", calculateHash(buildDataCheckString(values), "bot-token"))
+		return values.Encode()
+	}
+
+	data, err := v.Validate(initData(now.Add(-59 * time.Minute)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), data.User.ID)
+
+	_, err = v.Validate(initData(now.Add(-61 * time.Minute)))
+	assert.ErrorIs(t, err, errors.ErrExpiredInitData)
+
+	_, err = v.Validate(initData(now.Add(10 * time.Minute)))
+	assert.ErrorIs(t, err, errors.ErrInvalidInitData)
+
+	_, err = InitDataValidator{BotToken: "other-token", Clock: clock.NewFake(now)}.Validate(initData(now))
+	assert.ErrorIs(t, err, errors.ErrInvalidInitData)
+}
+
+func TestInitDataValidator_ThirdPartySignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	user := &TelegramUser{ID: 42, FirstName: "Test"}
+	signed := GenerateTestSignedInitData(user, 7001, privateKey, now)
+
+	data, err := InitDataValidator{BotID: 7001, PublicKey: publicKey}.Validate(signed)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), data.User.ID)
+	assert.NotEmpty(t, data.Signature)
+
+	// Signed for another bot
+	_, err = InitDataValidator{BotID: 7002, PublicKey: publicKey}.Validate(signed)
+	assert.ErrorIs(t, err, errors.ErrInvalidInitData)
+
+	// Tampered user
+	values, err := url.ParseQuery(signed)
+	require.NoError(t, err)
+	values.Set("user", `{"id":43,"first_name":"Test"}`)
+	_, err = InitDataValidator{BotID: 7001, PublicKey: publicKey}.Validate(values.Encode())
+	assert.ErrorIs(t, err, errors.ErrInvalidInitData)
+
+	// Telegram's production key did not sign it
+	_, err = InitDataValidator{BotID: 7001}.Validate(signed)
+	assert.ErrorIs(t, err, errors.ErrInvalidInitData)
+}
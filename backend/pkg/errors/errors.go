@@ -26,17 +26,27 @@ var (
 	ErrAlertNotFound    = New("alert not found", http.StatusNotFound)
 	ErrPlanNotFound     = New("plan not found", http.StatusNotFound)
 	ErrCoinNotInWatchlist = New("coin not in watchlist", http.StatusNotFound)
+	ErrCategoryNotFound = New("category not found", http.StatusNotFound)
+	ErrExperimentNotFound = New("experiment not found", http.StatusNotFound)
+	ErrTargetNotFound   = New("price target not found", http.StatusNotFound)
+	ErrExchangeNotConnected = New("exchange not connected", http.StatusNotFound)
 
 	// Validation errors
 	ErrBadRequest       = New("bad request", http.StatusBadRequest)
 	ErrInvalidInput     = New("invalid input", http.StatusBadRequest)
 	ErrValidationFailed = New("validation failed", http.StatusBadRequest)
+	ErrTradingPairUnavailable = New("trading pair unavailable", http.StatusUnprocessableEntity)
+	ErrCoinDelisted     = New("coin has been delisted", http.StatusUnprocessableEntity)
 
 	// Conflict errors
 	ErrConflict         = New("resource already exists", http.StatusConflict)
 	ErrAlreadyExists    = New("already exists", http.StatusConflict)
 	ErrCoinInWatchlist  = New("coin already in watchlist", http.StatusConflict)
 	ErrCoinAlreadyInWatchlist = New("coin already in watchlist", http.StatusConflict)
+	ErrTrialUnavailable = New("trial not available", http.StatusConflict)
+	ErrNoSubscription   = New("no active subscription", http.StatusConflict)
+	ErrAlertDuplicate   = New("identical alert already exists", http.StatusConflict)
+	ErrVersionConflict  = New("changed since it was read", http.StatusConflict)
 
 	// Limit errors
 	ErrLimitExceeded        = New("limit exceeded", http.StatusForbidden)
@@ -52,6 +62,8 @@ var (
 	ErrDatabase        = New("database error", http.StatusInternalServerError)
 	ErrRedis           = New("redis error", http.StatusInternalServerError)
 	ErrExternalService = New("external service error", http.StatusBadGateway)
+	ErrServiceUnavailable = New("service unavailable", http.StatusServiceUnavailable)
+	ErrTimeout         = New("request timed out", http.StatusGatewayTimeout)
 )
 
 // AppError represents an application error with HTTP status code
@@ -60,6 +72,7 @@ type AppError struct {
 	StatusCode int    `json:"-"`
 	Details    any    `json:"details,omitempty"`
 	cause      error
+	base       *AppError // sentinel the error was derived from
 }
 
 // New creates a new AppError
@@ -90,6 +103,7 @@ func (e *AppError) WithCause(cause error) *AppError {
 		StatusCode: e.StatusCode,
 		Details:    e.Details,
 		cause:      cause,
+		base:       e.root(),
 	}
 }
 
@@ -100,6 +114,7 @@ func (e *AppError) WithDetails(details any) *AppError {
 		StatusCode: e.StatusCode,
 		Details:    details,
 		cause:      e.cause,
+		base:       e.root(),
 	}
 }
 
@@ -110,16 +125,31 @@ func (e *AppError) WithMessage(message string) *AppError {
 		StatusCode: e.StatusCode,
 		Details:    e.Details,
 		cause:      e.cause,
+		base:       e.root(),
 	}
 }
 
-// Is checks if the target error is the same as this error
+// Is checks if the target error is the same as this error: an AppError
+// with the same message and status code, or the sentinel this error was
+// derived from with WithMessage, WithCause or WithDetails. Other error
+// types never match, though a wrapped cause is still found through Unwrap
 func (e *AppError) Is(target error) bool {
 	t, ok := target.(*AppError)
 	if !ok {
 		return false
 	}
-	return e.Message == t.Message && e.StatusCode == t.StatusCode
+	if e.Message == t.Message && e.StatusCode == t.StatusCode {
+		return true
+	}
+	return e.root() == t.root()
+}
+
+// root returns the sentinel the error was derived from
+func (e *AppError) root() *AppError {
+	if e.base != nil {
+		return e.base
+	}
+	return e
 }
 
 // IsAppError checks if an error is an AppError
@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppError_Is(t *testing.T) {
+	cause := io.ErrUnexpectedEOF
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "sentinel", err: ErrAlertNotFound, target: ErrAlertNotFound, want: true},
+		{name: "other sentinel", err: ErrAlertNotFound, target: ErrCoinNotFound, want: false},
+		{name: "same message and status", err: ErrCoinAlreadyInWatchlist, target: ErrCoinInWatchlist, want: true},
+		{name: "same message, other status", err: New("alert not found", 400), target: ErrAlertNotFound, want: false},
+
+		{name: "wrapped", err: Wrap(cause, ErrDatabase), target: ErrDatabase, want: true},
+		{name: "wrapped, other sentinel", err: Wrap(cause, ErrDatabase), target: ErrRedis, want: false},
+		{name: "wrapped cause", err: Wrap(cause, ErrDatabase), target: cause, want: true},
+		{name: "wrapped twice", err: Wrap(Wrap(cause, ErrRedis), ErrDatabase), target: ErrRedis, want: true},
+
+		{name: "with message", err: ErrAlertLimitExceeded.WithMessage("Upgrade for more alerts."), target: ErrAlertLimitExceeded, want: true},
+		{name: "with message, other sentinel", err: ErrAlertLimitExceeded.WithMessage("Upgrade for more alerts."), target: ErrWatchlistLimitExceeded, want: false},
+		{name: "with message, then details", err: ErrAlertDuplicate.WithMessage("Exists.").WithDetails(1), target: ErrAlertDuplicate, want: true},
+		{name: "with message and cause", err: Wrap(cause, ErrServiceUnavailable).WithMessage("Try again."), target: ErrServiceUnavailable, want: true},
+		{name: "with message and cause, cause", err: Wrap(cause, ErrServiceUnavailable).WithMessage("Try again."), target: cause, want: true},
+
+		{name: "fmt wrapped", err: fmt.Errorf("create alert: %w", ErrAlertDuplicate.WithMessage("Exists.")), target: ErrAlertDuplicate, want: true},
+		{name: "foreign error", err: errors.New("alert not found"), target: ErrAlertNotFound, want: false},
+		{name: "foreign target", err: ErrAlertNotFound, target: errors.New("alert not found"), want: false},
+		{name: "nil", err: nil, target: ErrAlertNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Is(tt.err, tt.target))
+		})
+	}
+}
+
+func TestAppError_DerivedKeepsSentinel(t *testing.T) {
+	err := Wrap(io.EOF, ErrAlertDuplicate).WithMessage("Exists.").WithDetails(map[string]int64{"existing_alert_id": 7})
+
+	assert.Equal(t, "Exists.: EOF", err.Error())
+	assert.Equal(t, ErrAlertDuplicate.StatusCode, err.StatusCode)
+	assert.Same(t, ErrAlertDuplicate, err.root())
+
+	// The sentinel itself is never changed
+	assert.Equal(t, "identical alert already exists", ErrAlertDuplicate.Error())
+	assert.Nil(t, ErrAlertDuplicate.Details)
+}
@@ -0,0 +1,81 @@
+// Package eventbus decouples services from the transport that carries
+// events between them. Publishers and subscribers only know topic names;
+// the transport (Redis pub/sub, Redis Streams, NATS or NATS JetStream) is
+// chosen by config
+package eventbus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Supported transports
+const (
+	TransportRedis        = "redis"
+	TransportRedisStreams = "redis-streams"
+	TransportNATS         = "nats"
+	TransportJetStream    = "nats-jetstream"
+)
+
+// Message is an event received from a topic
+type Message struct {
+	Topic string
+	Data  []byte
+}
+
+// Handler processes a received message. Handlers of one subscription are
+// called sequentially
+type Handler func(ctx context.Context, msg Message)
+
+// Publisher publishes events to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+}
+
+// Subscriber delivers the events of a topic to a handler. Every
+// subscription receives every event published after it started (fan-out).
+// Subscribe blocks until ctx is done, returning ctx.Err(), or until the
+// subscription fails
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, handler Handler) error
+}
+
+// Bus is a transport that can both publish and subscribe
+type Bus interface {
+	Publisher
+	Subscriber
+	// Close releases connections the bus owns
+	Close() error
+}
+
+// Config selects and configures the transport
+type Config struct {
+	Transport string
+	// NATS server URL, used by the nats and nats-jetstream transports
+	NATSURL string
+	// Approximate number of events kept per stream by the redis-streams
+	// and nats-jetstream transports
+	StreamMaxLen int64
+	// Name of this service instance, used by nats-jetstream to name its
+	// durable consumers
+	Consumer string
+}
+
+// New creates the bus selected by cfg. The Redis transports use the given
+// client, which stays owned by the caller
+func New(cfg Config, redisClient *redis.Client) (Bus, error) {
+	switch cfg.Transport {
+	case "", TransportRedis:
+		return NewRedisPubSub(redisClient), nil
+	case TransportRedisStreams:
+		return NewRedisStreams(redisClient, cfg.StreamMaxLen), nil
+	case TransportNATS:
+		return NewNATS(cfg.NATSURL)
+	case TransportJetStream:
+		return NewJetStream(cfg.NATSURL, cfg.Consumer, cfg.StreamMaxLen)
+	default:
+		return nil, fmt.Errorf("unknown event bus transport %q", cfg.Transport)
+	}
+}
@@ -0,0 +1,177 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// Default cap on messages kept per stream
+	defaultJetStreamMaxMsgs = 100000
+
+	// Events older than this are dropped from streams
+	jetStreamMaxAge = 24 * time.Hour
+
+	// Durable consumers of instances that went away are removed after
+	// this long
+	jetStreamInactiveThreshold = 24 * time.Hour
+
+	// Unacknowledged messages in flight per consumer
+	jetStreamMaxAckPending = 1000
+
+	// Timeout of stream and consumer management requests
+	jetStreamRequestTimeout = 10 * time.Second
+)
+
+// JetStream carries events over NATS JetStream, one stream per topic.
+// Each subscription is a durable consumer named after the consumer name
+// and the topic, so an instance that restarts under the same name resumes
+// after the last event it acknowledged. Instances with different names
+// each receive every event
+type JetStream struct {
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	closed   <-chan struct{}
+	consumer string
+	maxMsgs  int64
+
+	mu      sync.Mutex
+	streams map[string]bool // topics whose stream is known to exist
+}
+
+// NewJetStream connects to a NATS server with JetStream enabled. consumer
+// identifies this service instance; maxMsgs caps the events kept per topic
+// (0 uses the default)
+func NewJetStream(url, consumer string, maxMsgs int64) (*JetStream, error) {
+	if consumer == "" {
+		return nil, fmt.Errorf("jetstream consumer name is required")
+	}
+	if maxMsgs <= 0 {
+		maxMsgs = defaultJetStreamMaxMsgs
+	}
+
+	conn, closed, err := connectNATS(url)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jetstream: %w", err)
+	}
+
+	return &JetStream{
+		conn:     conn,
+		js:       js,
+		closed:   closed,
+		consumer: consumer,
+		maxMsgs:  maxMsgs,
+		streams:  make(map[string]bool),
+	}, nil
+}
+
+// Publish implements Publisher, waiting for the server to store the event
+func (b *JetStream) Publish(ctx context.Context, topic string, data []byte) error {
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+	if _, err := b.js.Publish(ctx, topic, data); err != nil {
+		return fmt.Errorf("jetstream publish %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe implements Subscriber. Events are acknowledged once the handler
+// returns; a durable created by the first subscription starts with events
+// published after it
+func (b *JetStream) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	if err := b.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, jetStreamRequestTimeout)
+	defer cancel()
+
+	consumer, err := b.js.CreateOrUpdateConsumer(reqCtx, jetStreamName(topic), jetstream.ConsumerConfig{
+		Durable:           jetStreamName(b.consumer + "_" + topic),
+		FilterSubject:     topic,
+		DeliverPolicy:     jetstream.DeliverNewPolicy,
+		AckPolicy:         jetstream.AckExplicitPolicy,
+		MaxAckPending:     jetStreamMaxAckPending,
+		InactiveThreshold: jetStreamInactiveThreshold,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("jetstream consumer for %s: %w", topic, err)
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		handler(ctx, Message{Topic: msg.Subject(), Data: msg.Data()})
+		_ = msg.Ack()
+	})
+	if err != nil {
+		return fmt.Errorf("jetstream consume %s: %w", topic, err)
+	}
+	defer consumeCtx.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closed:
+		return errSubscriptionClosed
+	}
+}
+
+// Close implements Bus, flushing pending publishes first
+func (b *JetStream) Close() error {
+	return b.conn.Drain()
+}
+
+// ensureStream creates the stream of a topic, or updates its limits, the
+// first time the topic is used
+func (b *JetStream) ensureStream(ctx context.Context, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.streams[topic] {
+		return nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, jetStreamRequestTimeout)
+	defer cancel()
+
+	_, err := b.js.CreateOrUpdateStream(reqCtx, jetstream.StreamConfig{
+		Name:     jetStreamName(topic),
+		Subjects: []string{topic},
+		Storage:  jetstream.FileStorage,
+		Discard:  jetstream.DiscardOld,
+		MaxMsgs:  b.maxMsgs,
+		MaxAge:   jetStreamMaxAge,
+	})
+	if err != nil {
+		return fmt.Errorf("jetstream stream for %s: %w", topic, err)
+	}
+
+	b.streams[topic] = true
+	return nil
+}
+
+// jetStreamName turns a topic into a valid stream or consumer name
+func jetStreamName(topic string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ':', '/', '\\', ' ', '\t':
+			return '_'
+		}
+		return r
+	}, topic)
+}
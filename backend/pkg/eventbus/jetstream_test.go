@@ -0,0 +1,138 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestJetStream connects to the NATS server at NATS_URL (default
+// localhost) and skips when none with JetStream is running
+func newTestJetStream(t *testing.T, consumer string) *JetStream {
+	t.Helper()
+
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		url = "nats://localhost:4222"
+	}
+
+	bus, err := NewJetStream(url, consumer, 1000)
+	if err != nil {
+		t.Skip("NATS not available, skipping integration test")
+	}
+	t.Cleanup(func() { bus.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := bus.js.AccountInfo(ctx); err != nil {
+		t.Skip("JetStream not enabled, skipping integration test")
+	}
+
+	return bus
+}
+
+// uniqueName keeps runs from sharing durable consumers
+func uniqueName(t *testing.T) string {
+	return fmt.Sprintf("%s_%d", t.Name(), time.Now().UnixNano())
+}
+
+// receive waits for the next message of a subscription
+func receive(t *testing.T, ch <-chan Message) Message {
+	t.Helper()
+
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(3 * time.Second):
+		t.Fatal("message was not delivered")
+		return Message{}
+	}
+}
+
+// subscribe runs Subscribe in the background until the returned cancel is
+// called and waits for the durable consumer to exist
+func subscribe(t *testing.T, bus *JetStream, topic string) (<-chan Message, context.CancelFunc) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan Message, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = bus.Subscribe(ctx, topic, func(_ context.Context, msg Message) {
+			received <- msg
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := bus.js.Consumer(context.Background(), jetStreamName(topic), jetStreamName(bus.consumer+"_"+topic))
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+
+	return received, func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestJetStream_Delivers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	testDelivery(t, newTestJetStream(t, uniqueName(t)))
+}
+
+func TestJetStream_DurableResumesAfterRestart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	consumer := uniqueName(t)
+	bus := newTestJetStream(t, consumer)
+	topic := "test:durable"
+	ctx := context.Background()
+
+	received, stop := subscribe(t, bus, topic)
+	require.NoError(t, bus.Publish(ctx, topic, []byte("1")))
+	assert.Equal(t, "1", string(receive(t, received).Data))
+	stop()
+
+	// Published while the instance is down
+	require.NoError(t, bus.Publish(ctx, topic, []byte("2")))
+
+	restarted := newTestJetStream(t, consumer)
+	received, stop = subscribe(t, restarted, topic)
+	defer stop()
+	assert.Equal(t, "2", string(receive(t, received).Data))
+}
+
+func TestJetStream_FansOutToEveryInstance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	first := newTestJetStream(t, uniqueName(t)+"_a")
+	second := newTestJetStream(t, uniqueName(t)+"_b")
+	topic := "test:fanout"
+
+	a, stopA := subscribe(t, first, topic)
+	defer stopA()
+	b, stopB := subscribe(t, second, topic)
+	defer stopB()
+
+	require.NoError(t, first.Publish(context.Background(), topic, []byte("tick")))
+	assert.Equal(t, "tick", string(receive(t, a).Data))
+	assert.Equal(t, "tick", string(receive(t, b).Data))
+}
+
+func TestJetStreamName(t *testing.T) {
+	assert.Equal(t, "alert_notifications", jetStreamName("alert:notifications"))
+	assert.Equal(t, "gw-1_prices_stream", jetStreamName("gw-1_prices:stream"))
+	assert.Equal(t, "a_b_c_d", jetStreamName("a.b*c>d"))
+}
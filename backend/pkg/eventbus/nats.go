@@ -0,0 +1,73 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Time allowed for pending publishes to flush on Close
+const natsDrainTimeout = 5 * time.Second
+
+// NATS carries events over core NATS subjects named after the topics.
+// The connection reconnects on its own; events published while a
+// subscriber is disconnected are lost to it
+type NATS struct {
+	conn   *nats.Conn
+	closed <-chan struct{}
+}
+
+// NewNATS connects to a NATS server
+func NewNATS(url string) (*NATS, error) {
+	conn, closed, err := connectNATS(url)
+	if err != nil {
+		return nil, err
+	}
+	return &NATS{conn: conn, closed: closed}, nil
+}
+
+// connectNATS opens a connection that reconnects forever; the returned
+// channel is closed once the connection is closed for good
+func connectNATS(url string) (*nats.Conn, <-chan struct{}, error) {
+	closed := make(chan struct{})
+	conn, err := nats.Connect(url,
+		nats.Name("weqory"),
+		nats.MaxReconnects(-1),
+		nats.DrainTimeout(natsDrainTimeout),
+		nats.ClosedHandler(func(*nats.Conn) { close(closed) }),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect nats: %w", err)
+	}
+	return conn, closed, nil
+}
+
+// Publish implements Publisher
+func (b *NATS) Publish(_ context.Context, topic string, data []byte) error {
+	return b.conn.Publish(topic, data)
+}
+
+// Subscribe implements Subscriber
+func (b *NATS) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	sub, err := b.conn.Subscribe(topic, func(msg *nats.Msg) {
+		handler(ctx, Message{Topic: msg.Subject, Data: msg.Data})
+	})
+	if err != nil {
+		return fmt.Errorf("subscribe %s: %w", topic, err)
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closed:
+		return errSubscriptionClosed
+	}
+}
+
+// Close implements Bus, flushing pending publishes first
+func (b *NATS) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,145 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// errSubscriptionClosed is returned when the transport ends a subscription
+var errSubscriptionClosed = errors.New("subscription closed")
+
+// RedisPubSub carries events over Redis pub/sub. Events published while a
+// subscriber is disconnected are lost to it
+type RedisPubSub struct {
+	client *redis.Client
+}
+
+// NewRedisPubSub creates a Redis pub/sub bus
+func NewRedisPubSub(client *redis.Client) *RedisPubSub {
+	return &RedisPubSub{client: client}
+}
+
+// Publish implements Publisher
+func (b *RedisPubSub) Publish(ctx context.Context, topic string, data []byte) error {
+	return b.client.Publish(ctx, topic, data).Err()
+}
+
+// Subscribe implements Subscriber
+func (b *RedisPubSub) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	pubsub := b.client.Subscribe(ctx, topic)
+	defer pubsub.Close()
+
+	// Wait for subscription confirmation
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return errSubscriptionClosed
+			}
+			handler(ctx, Message{Topic: msg.Channel, Data: []byte(msg.Payload)})
+		}
+	}
+}
+
+// Close implements Bus; the client is owned by the caller
+func (b *RedisPubSub) Close() error {
+	return nil
+}
+
+const (
+	// Field of a stream entry holding the event
+	streamDataField = "data"
+
+	// Default cap on stream length
+	defaultStreamMaxLen = 100000
+
+	// How long one XREAD waits for new entries; a blocked read does not
+	// notice a cancelled context, so this bounds how long Subscribe takes
+	// to return
+	streamReadBlock = time.Second
+
+	// Entries read per XREAD
+	streamReadCount = 100
+)
+
+// RedisStreams carries events over Redis Streams, one stream per topic,
+// each capped at roughly maxLen entries. Unlike pub/sub, a subscriber
+// that falls behind reads the backlog instead of losing events
+type RedisStreams struct {
+	client *redis.Client
+	maxLen int64
+}
+
+// NewRedisStreams creates a Redis Streams bus keeping about maxLen events
+// per topic (0 uses the default)
+func NewRedisStreams(client *redis.Client, maxLen int64) *RedisStreams {
+	if maxLen <= 0 {
+		maxLen = defaultStreamMaxLen
+	}
+	return &RedisStreams{client: client, maxLen: maxLen}
+}
+
+// Publish implements Publisher
+func (b *RedisStreams) Publish(ctx context.Context, topic string, data []byte) error {
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]any{streamDataField: data},
+	}).Err()
+}
+
+// Subscribe implements Subscriber. It reads entries added after the call
+// without a consumer group, so every subscriber receives every event
+func (b *RedisStreams) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	lastID := "$"
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{topic, lastID},
+			Count:   streamReadCount,
+			Block:   streamReadBlock,
+		}).Result()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				lastID = entry.ID
+				data, ok := entry.Values[streamDataField].(string)
+				if !ok {
+					continue
+				}
+				handler(ctx, Message{Topic: stream.Stream, Data: []byte(data)})
+			}
+		}
+	}
+}
+
+// Close implements Bus; the client is owned by the caller
+func (b *RedisStreams) Close() error {
+	return nil
+}
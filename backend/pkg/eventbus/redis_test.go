@@ -0,0 +1,95 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err, "failed to start miniredis")
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+	return client
+}
+
+// testDelivery subscribes to a topic, publishes until the subscription
+// receives, and checks the message and that cancelling ends Subscribe
+func testDelivery(t *testing.T, bus Bus) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan Message, 10)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- bus.Subscribe(ctx, "test:topic", func(_ context.Context, msg Message) {
+			received <- msg
+		})
+	}()
+
+	// Events published before the subscription starts are not delivered,
+	// so publish until one arrives
+	deadline := time.After(3 * time.Second)
+	for {
+		require.NoError(t, bus.Publish(ctx, "test:topic", []byte(`{"n":1}`)))
+		select {
+		case msg := <-received:
+			assert.Equal(t, "test:topic", msg.Topic)
+			assert.JSONEq(t, `{"n":1}`, string(msg.Data))
+
+			cancel()
+			select {
+			case err := <-errCh:
+				assert.ErrorIs(t, err, context.Canceled)
+			case <-time.After(streamReadBlock + time.Second):
+				t.Fatal("Subscribe did not return after cancel")
+			}
+			return
+		case <-deadline:
+			t.Fatal("message was not delivered")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestRedisPubSub_Delivers(t *testing.T) {
+	testDelivery(t, NewRedisPubSub(newTestRedis(t)))
+}
+
+func TestRedisStreams_Delivers(t *testing.T) {
+	testDelivery(t, NewRedisStreams(newTestRedis(t), 0))
+}
+
+func TestRedisStreams_CapsStreamLength(t *testing.T) {
+	client := newTestRedis(t)
+	bus := NewRedisStreams(client, 5)
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, bus.Publish(ctx, "test:capped", []byte("x")))
+	}
+
+	// Trimming is approximate on Redis, exact on miniredis
+	n, err := client.XLen(ctx, "test:capped").Result()
+	require.NoError(t, err)
+	assert.Less(t, n, int64(20))
+}
+
+func TestNew_UnknownTransport(t *testing.T) {
+	_, err := New(Config{Transport: "carrier-pigeon"}, nil)
+	assert.Error(t, err)
+}
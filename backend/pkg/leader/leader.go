@@ -0,0 +1,172 @@
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// keyPrefix namespaces leader election keys in Redis
+	keyPrefix = "leader:"
+
+	// DefaultTTL is how long leadership is held without renewal
+	DefaultTTL = 15 * time.Second
+)
+
+// renewScript extends the lease only if this instance still holds it
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if this instance still holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Elector holds a Redis lease so that only one replica runs singleton work
+// A nil *Elector always reports leadership, which keeps single-instance
+// setups and tests working without Redis
+type Elector struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+	logger *slog.Logger
+
+	isLeader atomic.Bool
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a new Elector for the named role
+func New(client *redis.Client, name string, ttl time.Duration, logger *slog.Logger) *Elector {
+	return &Elector{
+		client: client,
+		key:    keyPrefix + name,
+		id:     instanceID(),
+		ttl:    ttl,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start tries to acquire leadership right away, then keeps campaigning and
+// renewing the lease in the background
+func (e *Elector) Start(ctx context.Context) {
+	e.tick(ctx)
+
+	go func() {
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.done:
+				return
+			case <-ticker.C:
+				e.tick(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops campaigning and releases the lease so another replica can take over
+func (e *Elector) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.done)
+
+		if !e.isLeader.Swap(false) {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		if err := releaseScript.Run(ctx, e.client, []string{e.key}, e.id).Err(); err != nil {
+			e.logger.Warn("failed to release leadership",
+				slog.String("key", e.key),
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		e.logger.Info("released leadership", slog.String("key", e.key))
+	})
+}
+
+// IsLeader reports whether this instance currently holds the lease
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	return e.isLeader.Load()
+}
+
+// ID returns this instance's identifier
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// tick renews the lease when leading, otherwise tries to acquire it
+func (e *Elector) tick(ctx context.Context) {
+	if e.isLeader.Load() {
+		renewed, err := renewScript.Run(ctx, e.client, []string{e.key}, e.id, e.ttl.Milliseconds()).Int()
+		if err != nil || renewed == 0 {
+			e.isLeader.Store(false)
+			attrs := []any{slog.String("key", e.key)}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			e.logger.Warn("lost leadership", attrs...)
+		}
+		return
+	}
+
+	acquired, err := e.client.SetNX(ctx, e.key, e.id, e.ttl).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			e.logger.Error("leader election failed",
+				slog.String("key", e.key),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+
+	if acquired {
+		e.isLeader.Store(true)
+		e.logger.Info("acquired leadership",
+			slog.String("key", e.key),
+			slog.String("id", e.id),
+		)
+	}
+}
+
+// instanceID returns a unique identifier for this process
+func instanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
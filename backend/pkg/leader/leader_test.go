@@ -0,0 +1,111 @@
+package leader
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err, "failed to start miniredis")
+
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+
+	return mr, client
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestElector_OnlyOneLeader(t *testing.T) {
+	_, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	a := New(client, "jobs", DefaultTTL, testLogger())
+	b := New(client, "jobs", DefaultTTL, testLogger())
+
+	a.tick(ctx)
+	b.tick(ctx)
+
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+}
+
+func TestElector_FailoverAfterRelease(t *testing.T) {
+	_, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	a := New(client, "jobs", DefaultTTL, testLogger())
+	b := New(client, "jobs", DefaultTTL, testLogger())
+
+	a.tick(ctx)
+	require.True(t, a.IsLeader())
+
+	a.Stop()
+	assert.False(t, a.IsLeader())
+
+	b.tick(ctx)
+	assert.True(t, b.IsLeader())
+}
+
+func TestElector_FailoverAfterExpiry(t *testing.T) {
+	mr, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	a := New(client, "jobs", DefaultTTL, testLogger())
+	b := New(client, "jobs", DefaultTTL, testLogger())
+
+	a.tick(ctx)
+	require.True(t, a.IsLeader())
+
+	// Leader stops renewing (crash, network partition)
+	mr.FastForward(DefaultTTL + time.Second)
+
+	b.tick(ctx)
+	assert.True(t, b.IsLeader())
+
+	// The old leader notices on its next renewal
+	a.tick(ctx)
+	assert.False(t, a.IsLeader())
+}
+
+func TestElector_RenewKeepsLease(t *testing.T) {
+	mr, client := setupTestRedis(t)
+	ctx := context.Background()
+
+	a := New(client, "jobs", DefaultTTL, testLogger())
+	b := New(client, "jobs", DefaultTTL, testLogger())
+
+	a.tick(ctx)
+	for i := 0; i < 5; i++ {
+		mr.FastForward(DefaultTTL / 2)
+		a.tick(ctx)
+		b.tick(ctx)
+	}
+
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+}
+
+func TestElector_NilIsLeader(t *testing.T) {
+	var e *Elector
+	assert.True(t, e.IsLeader())
+}
@@ -0,0 +1,117 @@
+// Package pagination implements keyset pagination for lists ordered newest
+// first. A cursor names the sort key and ID of the last item of a page; the
+// next page continues strictly after it, so items added or removed between
+// requests never shift pages the way offsets do
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Page size limits
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by Encode
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item of a page. Key is the item's sort
+// key as text (a timestamp for lists ordered by time) and ID breaks ties
+type Cursor struct {
+	Key string `json:"k"`
+	ID  int64  `json:"id"`
+}
+
+// TimeCursor returns the cursor of an item sorted by a time
+func TimeCursor(t time.Time, id int64) Cursor {
+	return Cursor{Key: t.UTC().Format(time.RFC3339Nano), ID: id}
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses an encoded cursor; an empty string is the first page
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Key == "" || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Page requests up to Limit items after a cursor. The zero Page is the
+// whole list
+type Page struct {
+	Limit int
+	After *Cursor
+}
+
+// FetchLimit is the LIMIT to query with: one more than the page so the
+// result shows whether another page follows, or nil (no limit) for the
+// whole list
+func (p Page) FetchLimit() any {
+	if p.Limit <= 0 {
+		return nil
+	}
+	return p.Limit + 1
+}
+
+// AfterKey and AfterID are the keyset query arguments, nil on the first
+// page. Queries filter with
+//
+//	($n::text IS NULL OR (sort_col, id) < ($n::timestamptz, $m))
+func (p Page) AfterKey() any {
+	if p.After == nil {
+		return nil
+	}
+	return p.After.Key
+}
+
+// AfterID is the ID half of the keyset arguments, see AfterKey
+func (p Page) AfterID() any {
+	if p.After == nil {
+		return nil
+	}
+	return p.After.ID
+}
+
+// Result is one page of a list
+type Result[T any] struct {
+	Items []T
+	// Items in the whole list
+	Total int64
+	// Cursor of the next page, empty on the last one
+	NextCursor string
+}
+
+// NewResult builds the result of items fetched with p.FetchLimit, dropping
+// the extra item and deriving the next cursor from the last one kept
+func NewResult[T any](items []T, total int64, p Page, cursor func(T) Cursor) *Result[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	result := &Result[T]{Items: items, Total: total}
+	if p.Limit > 0 && len(items) > p.Limit {
+		result.Items = items[:p.Limit]
+		result.NextCursor = cursor(result.Items[p.Limit-1]).Encode()
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.FixedZone("X", 3600))
+	c := TimeCursor(at, 42)
+
+	decoded, err := Decode(c.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, c, *decoded)
+	assert.Equal(t, "2024-05-01T11:30:00.123456Z", decoded.Key)
+}
+
+func TestDecode(t *testing.T) {
+	c, err := Decode("")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	for _, s := range []string{"not base64!", "bm90IGpzb24", Cursor{Key: "", ID: 1}.Encode(), Cursor{Key: "x", ID: 0}.Encode()} {
+		_, err := Decode(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
+
+func TestPage_Args(t *testing.T) {
+	var whole Page
+	assert.Nil(t, whole.FetchLimit())
+	assert.Nil(t, whole.AfterKey())
+	assert.Nil(t, whole.AfterID())
+
+	page := Page{Limit: 10, After: &Cursor{Key: "k", ID: 7}}
+	assert.Equal(t, 11, page.FetchLimit())
+	assert.Equal(t, "k", page.AfterKey())
+	assert.Equal(t, int64(7), page.AfterID())
+}
+
+func TestNewResult(t *testing.T) {
+	cursor := func(id int64) Cursor { return Cursor{Key: "k", ID: id} }
+	page := Page{Limit: 2}
+
+	// Fetched one more than the page: another page follows
+	result := NewResult([]int64{5, 4, 3}, 10, page, cursor)
+	assert.Equal(t, []int64{5, 4}, result.Items)
+	assert.Equal(t, int64(10), result.Total)
+	assert.Equal(t, cursor(4).Encode(), result.NextCursor)
+
+	result = NewResult([]int64{2, 1}, 10, page, cursor)
+	assert.Equal(t, []int64{2, 1}, result.Items)
+	assert.Empty(t, result.NextCursor)
+
+	result = NewResult[int64](nil, 0, Page{}, cursor)
+	assert.NotNil(t, result.Items)
+	assert.Empty(t, result.NextCursor)
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -91,51 +92,51 @@ type RateLimiter struct {
 	client *redis.Client
 }
 
+// allowScript counts and records a request in a sliding window in one step,
+// so concurrent requests cannot all see room for one more. It returns
+// whether the request is allowed, the requests remaining and when the
+// oldest request in the window expires
+var allowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], 0, now - window)
+
+local count = redis.call("ZCARD", KEYS[1])
+local resetAt = now + window
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+if #oldest > 0 then
+	resetAt = tonumber(oldest[2]) + window
+end
+
+if count >= limit then
+	return {0, 0, resetAt}
+end
+
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], window)
+return {1, limit - count - 1, resetAt}
+`)
+
 // NewRateLimiter creates a new RateLimiter instance
 func NewRateLimiter(client *redis.Client) *RateLimiter {
 	return &RateLimiter{client: client}
 }
 
-// Allow checks if a request is allowed under the rate limit
+// Allow checks if a request is allowed under the rate limit. It returns
+// the requests remaining in the window and, in Unix milliseconds, when the
+// oldest request in the window expires and frees a slot
 func (r *RateLimiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, int64, int64, error) {
 	now := time.Now().UnixMilli()
-	windowStart := now - window.Milliseconds()
-
-	pipe := r.client.Pipeline()
-
-	// Remove old entries
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart))
-
-	// Count current requests in window
-	countCmd := pipe.ZCard(ctx, key)
 
-	// Set expiry on the key
-	pipe.Expire(ctx, key, window)
+	// Unique per request, as several can arrive in the same millisecond
+	member := fmt.Sprintf("%d-%d", now, rand.Uint64())
 
-	_, err := pipe.Exec(ctx)
+	res, err := allowScript.Run(ctx, r.client, []string{key}, now, window.Milliseconds(), limit, member).Int64Slice()
 	if err != nil {
 		return false, 0, 0, err
 	}
 
-	count := countCmd.Val()
-	resetAt := now + window.Milliseconds()
-
-	// Check limit BEFORE adding request
-	if count >= limit {
-		return false, 0, resetAt, nil
-	}
-
-	// Only add if under limit
-	// Use unique member by combining timestamp with random component to handle concurrent requests
-	member := fmt.Sprintf("%d", now)
-	if err := r.client.ZAdd(ctx, key, redis.Z{Score: float64(now), Member: member}).Err(); err != nil {
-		return false, 0, 0, err
-	}
-
-	remaining := limit - count - 1
-	if remaining < 0 {
-		remaining = 0
-	}
-
-	return true, remaining, resetAt, nil
+	return res[0] == 1, res[1], res[2], nil
 }
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRateLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), PoolSize: 50})
+	t.Cleanup(func() { client.Close() })
+	return NewRateLimiter(client), mr
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter, mr := newTestRateLimiter(t)
+	ctx := context.Background()
+
+	start := time.Now().UnixMilli()
+	for i, want := range []int64{2, 1, 0} {
+		allowed, remaining, resetAt, err := limiter.Allow(ctx, "rl:test", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d", i)
+		assert.Equal(t, want, remaining)
+		assert.InDelta(t, start+time.Minute.Milliseconds(), resetAt, 1000)
+	}
+
+	allowed, remaining, _, err := limiter.Allow(ctx, "rl:test", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Zero(t, remaining)
+
+	// The key expires with the window
+	assert.Equal(t, time.Minute, mr.TTL("rl:test"))
+}
+
+func TestRateLimiter_AllowConcurrent(t *testing.T) {
+	limiter, _ := newTestRateLimiter(t)
+	ctx := context.Background()
+
+	const limit, requests = 10, 50
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, _, err := limiter.Allow(ctx, "rl:concurrent", limit, time.Minute)
+			assert.NoError(t, err)
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(limit), allowed.Load())
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayGuard detects init data replayed by another client. The first client
+// to present a hash claims it for the TTL; presenting the same hash with a
+// different fingerprint is a replay
+type ReplayGuard struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewReplayGuard creates a new ReplayGuard remembering hashes for ttl, which
+// should cover the lifetime of init data
+func NewReplayGuard(client *redis.Client, ttl time.Duration) *ReplayGuard {
+	return &ReplayGuard{client: client, ttl: ttl}
+}
+
+// Replayed records fingerprint as the owner of hash when it is new and
+// reports whether hash was already claimed by a different fingerprint
+func (g *ReplayGuard) Replayed(ctx context.Context, hash, fingerprint string) (bool, error) {
+	key := "initdata:" + hash
+
+	claimed, err := g.client.SetNX(ctx, key, fingerprint, g.ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	if claimed {
+		return false, nil
+	}
+
+	owner, err := g.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// Expired in between; the next request claims it again
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner != fingerprint, nil
+}
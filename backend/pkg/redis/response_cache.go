@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CachedResponse is an HTTP response stored by ResponseCache
+type CachedResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+	StoredAt    time.Time
+}
+
+// ResponseCache stores rendered HTTP responses
+type ResponseCache struct {
+	client *redis.Client
+}
+
+// NewResponseCache creates a new ResponseCache instance
+func NewResponseCache(client *redis.Client) *ResponseCache {
+	return &ResponseCache{client: client}
+}
+
+// Get returns the response stored under key, or nil when there is none
+func (c *ResponseCache) Get(ctx context.Context, key string) (*CachedResponse, error) {
+	fields, err := c.client.HGetAll(ctx, "http_cache:"+key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	status, _ := strconv.Atoi(fields["status"])
+	storedAt, _ := strconv.ParseInt(fields["stored_at"], 10, 64)
+	return &CachedResponse{
+		Status:      status,
+		ContentType: fields["content_type"],
+		Body:        []byte(fields["body"]),
+		StoredAt:    time.UnixMilli(storedAt),
+	}, nil
+}
+
+// Set stores a response under key for ttl
+func (c *ResponseCache) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	key = "http_cache:" + key
+	pipe := c.client.TxPipeline()
+	pipe.HSet(ctx, key,
+		"status", resp.Status,
+		"content_type", resp.ContentType,
+		"body", resp.Body,
+		"stored_at", resp.StoredAt.UnixMilli(),
+	)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,159 @@
+// Package schedule describes the weekly windows during which an alert is
+// active, evaluated in the owner's timezone
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxWindows bounds the number of windows in a schedule
+const MaxWindows = 14
+
+// Day names accepted in a window, indexed by time.Weekday
+var dayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule is a set of weekly windows. An alert with a schedule is only
+// evaluated while at least one window is open
+type Schedule struct {
+	Windows []Window `json:"windows"`
+}
+
+// Window is open on the listed days from Start to End ("HH:MM", 24h clock).
+// A window whose End is not after Start runs past midnight into the next
+// day, e.g. 22:00-06:00
+type Window struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Validate checks that every window is well formed
+func (s *Schedule) Validate() error {
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("schedule needs at least one window")
+	}
+	if len(s.Windows) > MaxWindows {
+		return fmt.Errorf("schedule can have at most %d windows", MaxWindows)
+	}
+
+	for i, w := range s.Windows {
+		if len(w.Days) == 0 {
+			return fmt.Errorf("window %d: days are required", i+1)
+		}
+		for _, day := range w.Days {
+			if parseDay(day) < 0 {
+				return fmt.Errorf("window %d: invalid day %q", i+1, day)
+			}
+		}
+		start, err := parseClock(w.Start)
+		if err != nil || start == 24*60 {
+			return fmt.Errorf("window %d: invalid start %q", i+1, w.Start)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return fmt.Errorf("window %d: invalid end: %w", i+1, err)
+		}
+		if start == end {
+			return fmt.Errorf("window %d: start and end must differ", i+1)
+		}
+	}
+
+	return nil
+}
+
+// Active reports whether t falls in any window, in the given location.
+// A nil schedule is always active; malformed windows never match
+func (s *Schedule) Active(t time.Time, loc *time.Location) bool {
+	if s == nil {
+		return true
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+
+	for _, w := range s.Windows {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			continue
+		}
+
+		if start < end {
+			if w.hasDay(today) && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+
+		// Overnight: the evening part belongs to the listed day, the
+		// morning part to the day after it
+		if w.hasDay(today) && minute >= start {
+			return true
+		}
+		if w.hasDay(yesterday) && minute < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasDay reports whether the window is open on a weekday
+func (w Window) hasDay(day time.Weekday) bool {
+	for _, d := range w.Days {
+		if parseDay(d) == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDay returns the weekday index of a day name, or -1
+func parseDay(day string) int {
+	day = strings.ToLower(strings.TrimSpace(day))
+	for i, name := range dayNames {
+		if day == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseClock parses "HH:MM" into minutes since midnight. "24:00" is
+// accepted as the end of the day
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	if h == 24 && m == 0 {
+		return 24 * 60, nil
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("time out of range: %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// LoadLocation returns the location for an IANA timezone name, falling
+// back to UTC for empty or unknown names
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
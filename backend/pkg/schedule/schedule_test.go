@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{
+			name:     "weekdays office hours",
+			schedule: Schedule{Windows: []Window{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00"}}},
+		},
+		{
+			name:     "overnight until end of day",
+			schedule: Schedule{Windows: []Window{{Days: []string{"sat"}, Start: "22:00", End: "24:00"}}},
+		},
+		{name: "no windows", schedule: Schedule{}, wantErr: true},
+		{
+			name:     "unknown day",
+			schedule: Schedule{Windows: []Window{{Days: []string{"funday"}, Start: "09:00", End: "18:00"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "bad clock",
+			schedule: Schedule{Windows: []Window{{Days: []string{"mon"}, Start: "9:00", End: "18:00"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "empty window",
+			schedule: Schedule{Windows: []Window{{Days: []string{"mon"}, Start: "09:00", End: "09:00"}}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSchedule_Active(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	weekdays := &Schedule{Windows: []Window{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00"}}}
+
+	// Monday 2026-03-02 08:30 UTC is 09:30 in Berlin
+	assert.True(t, weekdays.Active(time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC), berlin))
+	// Monday 07:30 UTC is 08:30 in Berlin
+	assert.False(t, weekdays.Active(time.Date(2026, 3, 2, 7, 30, 0, 0, time.UTC), berlin))
+	// Saturday midday
+	assert.False(t, weekdays.Active(time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), berlin))
+
+	overnight := &Schedule{Windows: []Window{{Days: []string{"fri"}, Start: "22:00", End: "06:00"}}}
+
+	// Friday 23:00 and Saturday 05:00 are inside, Saturday 23:00 is not
+	assert.True(t, overnight.Active(time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC), time.UTC))
+	assert.True(t, overnight.Active(time.Date(2026, 3, 7, 5, 0, 0, 0, time.UTC), time.UTC))
+	assert.False(t, overnight.Active(time.Date(2026, 3, 7, 23, 0, 0, 0, time.UTC), time.UTC))
+
+	var none *Schedule
+	assert.True(t, none.Active(time.Now(), time.UTC))
+}
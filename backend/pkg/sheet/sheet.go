@@ -0,0 +1,117 @@
+// Package sheet writes tables as CSV or XLSX one row at a time, so exports
+// can be streamed to the client instead of being built in memory
+package sheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Supported formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Writer writes the rows of a table. Cells are strings, numbers (int,
+// int64, float64), *float64 or nil for an empty cell. Close must be called
+// to complete the file
+type Writer interface {
+	WriteRow(cells ...any) error
+	Close() error
+}
+
+// New returns a Writer for format
+func New(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSV(w), nil
+	case FormatXLSX:
+		return NewXLSX(w)
+	default:
+		return nil, fmt.Errorf("unsupported sheet format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// csvWriter writes CSV
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSV returns a Writer producing CSV
+func NewCSV(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(cells ...any) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		v, ok := number(cell)
+		switch {
+		case ok:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case isEmpty(cell):
+		default:
+			record[i] = escapeFormula(fmt.Sprint(cell))
+		}
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// Flush every row so the output reaches the client as it is written
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula keeps spreadsheet apps from evaluating text cells that
+// look like formulas
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// number returns the value of a numeric cell; ok is false for other cells
+// and nil pointers
+func number(cell any) (v float64, ok bool) {
+	switch n := cell.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case *float64:
+		if n == nil {
+			return 0, false
+		}
+		return *n, true
+	}
+	return 0, false
+}
+
+// isEmpty reports whether a cell is left empty
+func isEmpty(cell any) bool {
+	if cell == nil {
+		return true
+	}
+	p, ok := cell.(*float64)
+	return ok && p == nil
+}
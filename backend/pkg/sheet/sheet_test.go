@@ -0,0 +1,62 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(FormatCSV, &buf)
+	require.NoError(t, err)
+
+	price := 70123.5
+	var missing *float64
+	require.NoError(t, w.WriteRow("Symbol", "Price", "Change", "Alerts"))
+	require.NoError(t, w.WriteRow("BTC", &price, missing, 2))
+	require.NoError(t, w.WriteRow("=HYPERLINK(1)", -1.5, nil, "a, b"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "Symbol,Price,Change,Alerts\n"+
+		"BTC,70123.5,,2\n"+
+		"'=HYPERLINK(1),-1.5,,\"a, b\"\n", buf.String())
+}
+
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(FormatXLSX, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow("Symbol", "Price"))
+	require.NoError(t, w.WriteRow("<BTC> & co", 70123.5))
+	require.NoError(t, w.Close())
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;BTC&gt; &amp; co</t></is></c><c r="B2"><v>70123.5</v></c></row>`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, columnName(i), i)
+	}
+}
@@ -3,8 +3,10 @@ package validator
 import (
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/weqory/backend/pkg/alerttype"
 )
 
 // Validator wraps the go-playground validator
@@ -12,25 +14,54 @@ type Validator struct {
 	validate *validator.Validate
 }
 
+// Parts of a request a validation error can refer to
+const (
+	InBody  = "body"
+	InQuery = "query"
+	InPath  = "path"
+)
+
+// RuleType is the rule of values that could not be decoded into the
+// field's type
+const RuleType = "type"
+
 // ValidationError represents a validation error for a single field
 type ValidationError struct {
 	Field   string `json:"field"`
-	Tag     string `json:"tag"`
+	In      string `json:"in"`
+	Rule    string `json:"rule"`
 	Value   string `json:"value,omitempty"`
 	Message string `json:"message"`
 }
 
+// Errors is a list of validation errors that can be returned as an error
+type Errors []ValidationError
+
+// Error implements error
+func (e Errors) Error() string {
+	if len(e) == 0 {
+		return "validation failed"
+	}
+	return "validation failed: " + e[0].Field + ": " + e[0].Message
+}
+
 // New creates a new Validator instance
 func New() *Validator {
 	v := validator.New()
 
-	// Use JSON tag names in error messages
+	// Use the names clients send in error messages: JSON keys, query
+	// parameters or path parameters
 	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-		if name == "-" {
-			return ""
+		for _, key := range []string{"json", "query", "params"} {
+			name := strings.SplitN(fld.Tag.Get(key), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
 		}
-		return name
+		return ""
 	})
 
 	// Register custom validations
@@ -38,12 +69,19 @@ func New() *Validator {
 	_ = v.RegisterValidation("alert_type", validateAlertType)
 	_ = v.RegisterValidation("plan", validatePlan)
 	_ = v.RegisterValidation("timeframe", validateTimeframe)
+	_ = v.RegisterValidation("timezone", validateTimezone)
 
 	return &Validator{validate: v}
 }
 
-// Validate validates a struct and returns validation errors
+// Validate validates a request body struct and returns validation errors
 func (v *Validator) Validate(i interface{}) []ValidationError {
+	return v.ValidateIn(i, InBody)
+}
+
+// ValidateIn validates a struct decoded from the given part of a request
+// (InBody, InQuery or InPath) and returns validation errors
+func (v *Validator) ValidateIn(i interface{}, in string) []ValidationError {
 	err := v.validate.Struct(i)
 	if err == nil {
 		return nil
@@ -53,7 +91,8 @@ func (v *Validator) Validate(i interface{}) []ValidationError {
 	for _, err := range err.(validator.ValidationErrors) {
 		errors = append(errors, ValidationError{
 			Field:   err.Field(),
-			Tag:     err.Tag(),
+			In:      in,
+			Rule:    err.Tag(),
 			Value:   err.Param(),
 			Message: getErrorMessage(err),
 		})
@@ -95,6 +134,12 @@ func getErrorMessage(err validator.FieldError) string {
 		return "Invalid plan"
 	case "timeframe":
 		return "Invalid timeframe"
+	case "timezone":
+		return "Invalid timezone"
+	case "bcp47_language_tag":
+		return "Invalid language code"
+	case "datetime":
+		return "Invalid time, expected RFC 3339"
 	default:
 		return "Invalid value"
 	}
@@ -117,14 +162,7 @@ func validateCoinSymbol(fl validator.FieldLevel) bool {
 }
 
 func validateAlertType(fl validator.FieldLevel) bool {
-	alertType := fl.Field().String()
-	validTypes := map[string]bool{
-		"PRICE_ABOVE":      true,
-		"PRICE_BELOW":      true,
-		"PRICE_CHANGE_PCT": true,
-		"PERIODIC":         true,
-	}
-	return validTypes[alertType]
+	return alerttype.Creatable(fl.Field().String())
 }
 
 func validatePlan(fl validator.FieldLevel) bool {
@@ -152,3 +190,13 @@ func validateTimeframe(fl validator.FieldLevel) bool {
 	}
 	return validTimeframes[timeframe]
 }
+
+func validateTimezone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	// time.LoadLocation treats "" as UTC and "Local" as the server zone
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}